@@ -1,10 +1,12 @@
 package main
 
 import (
+	"context"
 	"log"
 	"os"
 	"os/signal"
 	"strconv"
+	"strings"
 	"syscall"
 	"time"
 
@@ -14,7 +16,9 @@ import (
 	"github.com/nimbus/backend/internal/config"
 	"github.com/nimbus/backend/internal/db"
 	"github.com/nimbus/backend/internal/handlers"
+	"github.com/nimbus/backend/internal/mailer"
 	"github.com/nimbus/backend/internal/middleware"
+	"github.com/nimbus/backend/internal/models"
 	"github.com/nimbus/backend/internal/repository"
 	"github.com/nimbus/backend/internal/services"
 	"github.com/nimbus/backend/internal/workers"
@@ -44,23 +48,238 @@ func main() {
 	serviceRepo := repository.NewServiceRepository(database)
 	preferencesRepo := repository.NewPreferencesRepository(database)
 	statusLogRepo := repository.NewStatusLogRepository(database)
+	fingerprintRepo := repository.NewFingerprintRepository(database)
+	domainRepo := repository.NewDomainRepository(database)
+	settingsRepo := repository.NewSettingsRepository(database)
+	activityRepo := repository.NewActivityLogRepository(database)
+	statusEventRepo := repository.NewStatusEventRepository(database)
 
 	// Initialize services
 	authService := services.NewAuthService()
 
+	// Initialize egress policy (enforced for health checks and icon URLs)
+	egressService := services.NewEgressPolicyService(settingsRepo, activityRepo)
+	if err := egressService.Load(context.Background()); err != nil {
+		log.Fatalf("Failed to load egress policy: %v", err)
+	}
+
 	// Initialize health check service
 	healthCheckTimeout := getEnvDuration("HEALTH_CHECK_TIMEOUT", 10*time.Second)
-	healthCheckService := services.NewHealthCheckService(serviceRepo, statusLogRepo, healthCheckTimeout)
+	services.SetDNSCacheTTL(
+		getEnvDuration("DNS_CACHE_TTL", 5*time.Minute),
+		getEnvDuration("DNS_NEGATIVE_CACHE_TTL", 30*time.Second),
+	)
+	var healthCheckDNS *models.DNSConfig
+	if server := os.Getenv("HEALTH_CHECK_DNS_SERVER"); server != "" {
+		healthCheckDNS = &models.DNSConfig{
+			Server:        server,
+			Protocol:      os.Getenv("HEALTH_CHECK_DNS_PROTOCOL"),
+			TLSServerName: os.Getenv("HEALTH_CHECK_DNS_TLS_SERVER_NAME"),
+		}
+		if err := services.ValidateDNSConfig(healthCheckDNS); err != nil {
+			log.Fatalf("Invalid health check DNS configuration: %v", err)
+		}
+		log.Printf("✓ Health checks resolve hostnames via %s", server)
+	}
+	healthCheckService := services.NewHealthCheckService(serviceRepo, statusLogRepo, fingerprintRepo, healthCheckTimeout, healthCheckDNS, egressService)
+
+	// Batch check results from the health monitor into few large writes
+	statusWriter := services.NewStatusWriter(
+		serviceRepo,
+		statusLogRepo,
+		getEnvInt("STATUS_WRITER_QUEUE_SIZE", services.DefaultStatusWriterQueueSize),
+		getEnvInt("STATUS_WRITER_BATCH_SIZE", services.DefaultStatusWriterBatchSize),
+		getEnvDuration("STATUS_WRITER_FLUSH_INTERVAL", services.DefaultStatusWriterFlushInterval),
+	)
+	healthCheckService.SetStatusWriter(statusWriter)
+
+	// Record status transitions for outage history, MTTR and MTBF
+	healthCheckService.SetStatusEventRepository(statusEventRepo)
+	statusEventService := services.NewStatusEventService(statusEventRepo)
 
 	// Initialize metrics service
 	metricsService := services.NewMetricsService(statusLogRepo, serviceRepo)
+	metricsService.SetStatusWriter(statusWriter)
+	metricsService.SetCheckMetrics(healthCheckService.CheckMetrics())
+
+	// Status logs are partitioned by day (or week); retention drops whole partitions
+	partitionService, err := services.NewStatusLogPartitionService(
+		repository.NewStatusLogPartitionRepository(database),
+		os.Getenv("STATUS_LOG_PARTITION_INTERVAL"),
+		getEnvInt("STATUS_LOG_PARTITIONS_AHEAD", services.DefaultPartitionsAhead),
+	)
+	if err != nil {
+		log.Fatalf("Invalid STATUS_LOG_PARTITION_INTERVAL: %v", err)
+	}
+	metricsService.SetPartitionService(partitionService)
+
+	// Time-weighted uptime: a result counts until the next one, for at most UPTIME_MAX_STALENESS
+	// (default: three check intervals, so a single missed check doesn't open a gap)
+	healthCheckInterval := getEnvDuration("HEALTH_CHECK_INTERVAL", 60*time.Second)
+	uptimeMaxStaleness := getEnvDuration("UPTIME_MAX_STALENESS", 3*healthCheckInterval)
+	metricsService.SetUptimeMaxStaleness(uptimeMaxStaleness)
+
+	// Tiered history: raw logs for METRICS_RETENTION_DAYS, hourly rollups for months, daily rollups forever
+	rollupRepo := repository.NewStatusRollupRepository(database)
+	rollupService := services.NewRollupService(rollupRepo, getEnvInt("ROLLUP_HOURLY_RETENTION_MONTHS", services.DefaultHourlyRetentionMonths), uptimeMaxStaleness)
+	metricsService.SetRollups(rollupRepo, getEnvInt("METRICS_RETENTION_DAYS", 30), rollupService.HourlyRetentionMonths())
+
+	// SLO error budgets, computed from status logs and exported to Prometheus
+	sloService := services.NewSLOService(repository.NewSLORepository(database), serviceRepo, statusLogRepo, uptimeMaxStaleness)
+	metricsService.SetSLOService(sloService)
+
+	// Optional OpenTelemetry export of check metrics and traces (enabled by OTEL_EXPORTER_OTLP_ENDPOINT)
+	var otlpExporter *services.OTLPExporter
+	if endpoint := os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT"); endpoint != "" {
+		headers, err := services.ParseOTLPHeaders(os.Getenv("OTEL_EXPORTER_OTLP_HEADERS"))
+		if err != nil {
+			log.Fatalf("Invalid OTEL_EXPORTER_OTLP_HEADERS: %v", err)
+		}
+		otlpExporter, err = services.NewOTLPExporter(services.OTLPConfig{
+			Endpoint:    endpoint,
+			Protocol:    os.Getenv("OTEL_EXPORTER_OTLP_PROTOCOL"),
+			Headers:     headers,
+			ServiceName: os.Getenv("OTEL_SERVICE_NAME"),
+			Traces:      os.Getenv("OTEL_TRACES_EXPORTER") != "none",
+			Metrics:     os.Getenv("OTEL_METRICS_EXPORTER") != "none",
+			// Milliseconds, as in the OpenTelemetry SDKs
+			MetricsInterval: time.Duration(getEnvInt("OTEL_METRIC_EXPORT_INTERVAL", int(services.DefaultOTLPMetricsInterval/time.Millisecond))) * time.Millisecond,
+		})
+		if err != nil {
+			log.Fatalf("Invalid OpenTelemetry configuration: %v", err)
+		}
+		otlpExporter.SetMetricsService(metricsService)
+		healthCheckService.SetTracer(otlpExporter.Tracer())
+	}
+
+	// Optional export of every check result to InfluxDB and/or Graphite
+	var resultExporters []*services.ResultExporter
+	newResultExporter := func(sink services.ResultSink) {
+		exporter := services.NewResultExporter(
+			sink,
+			getEnvInt("RESULT_EXPORT_QUEUE_SIZE", services.DefaultResultExportQueueSize),
+			getEnvInt("RESULT_EXPORT_BATCH_SIZE", services.DefaultResultExportBatchSize),
+			getEnvDuration("RESULT_EXPORT_FLUSH_INTERVAL", services.DefaultResultExportFlushInterval),
+		)
+		healthCheckService.AddResultExporter(exporter)
+		resultExporters = append(resultExporters, exporter)
+	}
+	if influxURL := os.Getenv("INFLUXDB_URL"); influxURL != "" {
+		influxSink, err := services.NewInfluxDBSink(services.InfluxDBConfig{
+			URL:             influxURL,
+			Measurement:     os.Getenv("INFLUXDB_MEASUREMENT"),
+			Token:           os.Getenv("INFLUXDB_TOKEN"),
+			Org:             os.Getenv("INFLUXDB_ORG"),
+			Bucket:          os.Getenv("INFLUXDB_BUCKET"),
+			Database:        os.Getenv("INFLUXDB_DATABASE"),
+			RetentionPolicy: os.Getenv("INFLUXDB_RETENTION_POLICY"),
+			Username:        os.Getenv("INFLUXDB_USERNAME"),
+			Password:        os.Getenv("INFLUXDB_PASSWORD"),
+		})
+		if err != nil {
+			log.Fatalf("Invalid InfluxDB configuration: %v", err)
+		}
+		newResultExporter(influxSink)
+	}
+	if graphiteAddress := os.Getenv("GRAPHITE_ADDRESS"); graphiteAddress != "" {
+		graphiteSink, err := services.NewGraphiteSink(graphiteAddress, os.Getenv("GRAPHITE_PREFIX"))
+		if err != nil {
+			log.Fatalf("Invalid Graphite configuration: %v", err)
+		}
+		newResultExporter(graphiteSink)
+	}
+
+	// Live status updates for the dashboard, streamed as Server-Sent Events
+	eventBroker := services.NewEventBroker(getEnvInt("EVENT_STREAM_HISTORY_SIZE", services.DefaultEventHistorySize))
+	healthCheckService.SetEventBroker(eventBroker)
+
+	// Email (alerts, invitations) with the SMTP settings admins manage in system settings
+	mailService := services.NewMailService(settingsRepo, activityRepo, getEnvInt("MAIL_QUEUE_SIZE", mailer.DefaultQueueSize))
+	if err := mailService.Load(context.Background()); err != nil {
+		log.Fatalf("Failed to load SMTP settings: %v", err)
+	}
+
+	// Invitation links point at the web app (APP_URL, or the first CORS origin)
+	appURL := os.Getenv("APP_URL")
+	if appURL == "" {
+		appURL = strings.TrimSpace(strings.Split(os.Getenv("CORS_ORIGINS"), ",")[0])
+	}
+	invitationService := services.NewInvitationService(
+		repository.NewInvitationRepository(database),
+		userRepo,
+		activityRepo,
+		mailService,
+		appURL,
+		time.Duration(getEnvInt("INVITATION_EXPIRY_HOURS", int(services.DefaultInvitationExpiry/time.Hour)))*time.Hour,
+	)
+
+	// Notifications for status transitions (webhook, ntfy, Gotify, Discord, Slack, email), sent asynchronously
+	notificationChannelRepo := repository.NewNotificationChannelRepository(database)
+	notificationService := services.NewNotificationService(
+		notificationChannelRepo,
+		serviceRepo,
+		egressService,
+		getEnvInt("NOTIFICATION_QUEUE_SIZE", services.DefaultNotificationQueueSize),
+	)
+	notificationService.SetMailService(mailService)
+	healthCheckService.SetNotificationService(notificationService)
+
+	// Alert rules: delays, reminders, escalation, recovery notices and quiet hours on top of notifications
+	alertService := services.NewAlertService(
+		repository.NewAlertRuleRepository(database),
+		serviceRepo,
+		notificationChannelRepo,
+		notificationService,
+		getEnvDuration("ALERT_EVALUATION_INTERVAL", services.DefaultAlertEvaluationInterval),
+	)
+	healthCheckService.SetAlertService(alertService)
+
+	// Incidents open and resolve with status transitions; acknowledging one silences alert escalation
+	incidentRepo := repository.NewIncidentRepository(database)
+	incidentService := services.NewIncidentService(incidentRepo, serviceRepo, statusEventRepo, activityRepo)
+	healthCheckService.SetIncidentService(incidentService)
+	alertService.SetIncidentService(incidentService)
+
+	// Digest reports: scheduled uptime summaries sent to the users' channels or email
+	certificateRepo := repository.NewCertificateRepository(database)
+	healthCheckService.SetCertificateRepository(certificateRepo)
+	digestService := services.NewDigestService(preferencesRepo, serviceRepo, userRepo, incidentRepo, certificateRepo, notificationChannelRepo, metricsService)
+	digestService.SetNotificationService(notificationService)
+	digestService.SetMailService(mailService)
+
+	// Public status pages: selected services with uptime history, readable without an account
+	statusPageService := services.NewStatusPageService(repository.NewStatusPageRepository(database), serviceRepo, incidentRepo, metricsService)
+
+	// Initialize domain expiry monitor
+	domainService := services.NewDomainMonitorService(
+		domainRepo,
+		serviceRepo,
+		os.Getenv("RDAP_BASE_URL"),
+		getEnvInt("DOMAIN_EXPIRY_WARNING_DAYS", 30),
+		getEnvInt("DOMAIN_EXPIRY_CRITICAL_DAYS", 7),
+	)
 
 	// Initialize handlers
 	authHandler := handlers.NewAuthHandler(userRepo, authService)
-	serviceHandler := handlers.NewServiceHandler(serviceRepo, healthCheckService)
-	preferencesHandler := handlers.NewPreferencesHandler(preferencesRepo)
+	authHandler.SetInvitationService(invitationService)
+	serviceHandler := handlers.NewServiceHandler(serviceRepo, healthCheckService, egressService)
+	serviceHandler.SetEventBroker(eventBroker)
+	preferencesHandler := handlers.NewPreferencesHandler(preferencesRepo, digestService)
 	adminHandler := handlers.NewAdminHandler(userRepo)
+	egressPolicyHandler := handlers.NewEgressPolicyHandler(egressService)
+	mailHandler := handlers.NewMailHandler(mailService)
+	invitationHandler := handlers.NewInvitationHandler(invitationService)
 	metricsHandler := handlers.NewMetricsHandler(metricsService, serviceRepo)
+	fingerprintHandler := handlers.NewFingerprintHandler(fingerprintRepo, serviceRepo)
+	statusEventHandler := handlers.NewStatusEventHandler(statusEventService, serviceRepo)
+	sloHandler := handlers.NewSLOHandler(sloService)
+	notificationHandler := handlers.NewNotificationHandler(notificationService)
+	alertHandler := handlers.NewAlertHandler(alertService)
+	incidentHandler := handlers.NewIncidentHandler(incidentService)
+	digestHandler := handlers.NewDigestHandler(digestService)
+	statusPageHandler := handlers.NewStatusPageHandler(statusPageService)
+	domainHandler := handlers.NewDomainHandler(domainService)
+	eventStreamHandler := handlers.NewEventStreamHandler(eventBroker, getEnvDuration("EVENT_STREAM_HEARTBEAT", handlers.DefaultEventStreamHeartbeat))
 	uploadHandler := handlers.NewUploadHandler()
 	staticHandler := handlers.NewStaticHandler()
 
@@ -71,10 +290,13 @@ func main() {
 
 	// Middleware
 	app.Use(logger.New())
+	if otlpExporter != nil {
+		app.Use(middleware.Tracing(otlpExporter.Tracer()))
+	}
 	app.Use(cors.New(cors.Config{
 		AllowOrigins:     os.Getenv("CORS_ORIGINS"),
 		AllowCredentials: true,
-		AllowHeaders:     "Origin, Content-Type, Accept, Authorization",
+		AllowHeaders:     "Origin, Content-Type, Accept, Authorization, " + handlers.StatusPagePasswordHeader,
 	}))
 
 	// Routes
@@ -109,6 +331,50 @@ func main() {
 	services.Delete("/:id", serviceHandler.DeleteService)
 	services.Post("/:id/check", serviceHandler.CheckService)
 	services.Get("/:id/status-logs", metricsHandler.GetRecentStatusLogs)
+	services.Get("/:id/fingerprints", fingerprintHandler.GetFingerprints)
+	services.Get("/:id/events", statusEventHandler.GetEvents)
+	services.Get("/:id/notification-channels", notificationHandler.GetServiceChannels)
+	services.Put("/:id/notification-channels", notificationHandler.UpdateServiceChannels)
+
+	// Notification channel routes (protected, global channels are admin only)
+	notificationChannels := v1.Group("/notification-channels", middleware.AuthMiddleware(authService, userRepo))
+	notificationChannels.Get("/", notificationHandler.GetChannels)
+	notificationChannels.Post("/", notificationHandler.CreateChannel)
+	notificationChannels.Post("/test", notificationHandler.TestChannelConfig) // Must be before /:id routes
+	notificationChannels.Put("/:id", notificationHandler.UpdateChannel)
+	notificationChannels.Delete("/:id", notificationHandler.DeleteChannel)
+	notificationChannels.Post("/:id/test", notificationHandler.TestChannel)
+
+	// Alert rule routes (protected)
+	alerts := v1.Group("/alerts", middleware.AuthMiddleware(authService, userRepo))
+	alerts.Get("/", alertHandler.GetRules)
+	alerts.Post("/", alertHandler.CreateRule)
+	alerts.Get("/active", alertHandler.GetActiveAlerts) // Must be before /:id routes
+	alerts.Get("/:id", alertHandler.GetRule)
+	alerts.Put("/:id", alertHandler.UpdateRule)
+	alerts.Delete("/:id", alertHandler.DeleteRule)
+
+	// Incident routes (protected)
+	incidents := v1.Group("/incidents", middleware.AuthMiddleware(authService, userRepo))
+	incidents.Get("/", incidentHandler.GetIncidents)
+	incidents.Post("/", incidentHandler.CreateIncident)
+	incidents.Get("/:id", incidentHandler.GetIncident)
+	incidents.Get("/:id/timeline", incidentHandler.GetTimeline)
+	incidents.Post("/:id/acknowledge", incidentHandler.AcknowledgeIncident)
+	incidents.Post("/:id/resolve", incidentHandler.ResolveIncident)
+	incidents.Post("/:id/notes", incidentHandler.AddNote)
+	incidents.Post("/:id/services", incidentHandler.LinkServices)
+	incidents.Delete("/:id/services/:serviceId", incidentHandler.UnlinkService)
+
+	// Live event stream (protected)
+	events := v1.Group("/events", middleware.AuthMiddleware(authService, userRepo))
+	events.Get("/stream", eventStreamHandler.Stream)
+
+	// Domain expiry routes (protected)
+	domains := v1.Group("/domains", middleware.AuthMiddleware(authService, userRepo))
+	domains.Get("/", domainHandler.GetDomains)
+	domains.Post("/", domainHandler.AddDomain)
+	domains.Delete("/:id", domainHandler.DeleteDomain)
 
 	// Static file serving (public, but files are only accessible if you know the filename)
 	// IMPORTANT: This must be registered BEFORE the uploads group to avoid auth middleware
@@ -121,10 +387,20 @@ func main() {
 	// Metrics routes (protected)
 	metrics := v1.Group("/metrics", middleware.AuthMiddleware(authService, userRepo))
 	metrics.Get("/:id", metricsHandler.GetServiceMetrics)
+	metrics.Get("/:id/histogram", metricsHandler.GetLatencyHistogram)
+
+	// SLO routes (protected)
+	slos := v1.Group("/slos", middleware.AuthMiddleware(authService, userRepo))
+	slos.Get("/", sloHandler.GetSLOs)
+	slos.Post("/", sloHandler.CreateSLO)
+	slos.Get("/:id", sloHandler.GetSLO)
+	slos.Put("/:id", sloHandler.UpdateSLO)
+	slos.Delete("/:id", sloHandler.DeleteSLO)
 
 	// Prometheus metrics endpoint (supports both JWT and API key authentication)
 	// Middleware is optional - handler checks for both JWT (from middleware) and API key
 	prometheus := v1.Group("/prometheus")
+	prometheus.Get("/metrics", middleware.OptionalAuthMiddleware(authService, userRepo), metricsHandler.GetPrometheusMetrics)
 	prometheus.Get("/metrics/user/:userID", middleware.OptionalAuthMiddleware(authService, userRepo), metricsHandler.GetUserPrometheusMetrics)
 
 	// User preferences routes (protected)
@@ -132,22 +408,77 @@ func main() {
 	preferences.Get("/", preferencesHandler.GetPreferences)
 	preferences.Put("/", preferencesHandler.UpdatePreferences)
 
+	// Digest report routes (protected)
+	digest := v1.Group("/digest", middleware.AuthMiddleware(authService, userRepo))
+	digest.Get("/preview", digestHandler.GetPreview)
+
+	// Public status page (no account needed, optional password)
+	// IMPORTANT: This must be registered BEFORE the status pages group to avoid auth middleware
+	v1.Get("/status-pages/:slug", statusPageHandler.GetPublicStatusPage)
+
+	// Status page management routes (protected)
+	statusPages := v1.Group("/status-pages", middleware.AuthMiddleware(authService, userRepo))
+	statusPages.Get("/", statusPageHandler.GetStatusPages)
+	statusPages.Post("/", statusPageHandler.CreateStatusPage)
+	statusPages.Put("/:id", statusPageHandler.UpdateStatusPage)
+	statusPages.Delete("/:id", statusPageHandler.DeleteStatusPage)
+	statusPages.Get("/:id/announcements", statusPageHandler.GetAnnouncements)
+	statusPages.Post("/:id/announcements", statusPageHandler.CreateAnnouncement)
+	statusPages.Put("/:id/announcements/:announcementId", statusPageHandler.UpdateAnnouncement)
+	statusPages.Delete("/:id/announcements/:announcementId", statusPageHandler.DeleteAnnouncement)
+
 	// Admin routes (protected, admin only)
 	admin := v1.Group("/admin", middleware.AuthMiddleware(authService, userRepo), middleware.AdminOnly())
 	admin.Get("/users", adminHandler.GetAllUsers)
 	admin.Get("/users/stats", adminHandler.GetUserStats)
 	admin.Put("/users/:id/role", adminHandler.UpdateUserRole)
 	admin.Delete("/users/:id", adminHandler.DeleteUser)
+	admin.Get("/egress-policy", egressPolicyHandler.GetPolicy)
+	admin.Put("/egress-policy", egressPolicyHandler.UpdatePolicy)
+	admin.Get("/smtp", mailHandler.GetSMTPSettings)
+	admin.Put("/smtp", mailHandler.UpdateSMTPSettings)
+	admin.Post("/smtp/test", mailHandler.SendTestEmail)
+	admin.Get("/invitations", invitationHandler.GetInvitations)
+	admin.Post("/invitations", invitationHandler.CreateInvitation)
+	admin.Delete("/invitations/:id", invitationHandler.DeleteInvitation)
+
+	// Start status writer before the monitor that feeds it
+	statusWriter.Start()
+
+	if otlpExporter != nil {
+		otlpExporter.Start()
+	}
+	for _, exporter := range resultExporters {
+		exporter.Start()
+	}
+	mailService.Start()
+	notificationService.Start()
+	alertService.Start()
 
 	// Start health check monitor
-	healthCheckInterval := getEnvDuration("HEALTH_CHECK_INTERVAL", 60*time.Second)
 	healthMonitor := workers.NewHealthMonitor(healthCheckService, serviceRepo, healthCheckInterval)
 	healthMonitor.Start()
 
+	// Start status log partition worker
+	partitionWorker := workers.NewStatusLogPartitionWorker(partitionService)
+	partitionWorker.Start()
+
+	// Start rollup worker
+	rollupWorker := workers.NewRollupWorker(rollupService)
+	rollupWorker.Start()
+
 	// Start metrics cleanup worker
 	metricsCleanup := workers.NewMetricsCleanupWorker(metricsService)
 	metricsCleanup.Start()
 
+	// Start digest report worker
+	digestReports := workers.NewDigestReportWorker(digestService)
+	digestReports.Start()
+
+	// Start domain expiry worker
+	domainExpiry := workers.NewDomainExpiryWorker(domainService)
+	domainExpiry.Start()
+
 	// Setup graceful shutdown
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
@@ -173,6 +504,7 @@ func main() {
 		log.Printf("  DELETE /api/v1/services/:id (protected)")
 		log.Printf("  POST   /api/v1/services/:id/check (protected) - Manual health check")
 		log.Printf("  PUT    /api/v1/services/reorder (protected) - Reorder services")
+		log.Printf("  GET    /api/v1/events/stream (protected) - Live updates (Server-Sent Events)")
 		if err := app.Listen(":" + port); err != nil {
 			log.Fatalf("Server failed to start: %v", err)
 		}
@@ -184,13 +516,32 @@ func main() {
 
 	// Stop workers
 	healthMonitor.Stop()
+	statusWriter.Stop()  // Drains results queued by the last check cycle
+	digestReports.Stop() // Before notifications and mail, which send its reports
+	alertService.Stop()  // Before notifications, which send its queued alerts
+	notificationService.Stop()
+	mailService.Stop() // After notifications, which may still send email
 	metricsCleanup.Stop()
+	partitionWorker.Stop()
+	rollupWorker.Stop()
+	domainExpiry.Stop()
+
+	// End open event streams, otherwise the server waits for clients to disconnect
+	eventBroker.Close()
 
 	// Shutdown Fiber app
 	if err := app.Shutdown(); err != nil {
 		log.Printf("Error during shutdown: %v", err)
 	}
 
+	// Export the last spans and metrics once nothing produces them anymore
+	if otlpExporter != nil {
+		otlpExporter.Stop()
+	}
+	for _, exporter := range resultExporters {
+		exporter.Stop()
+	}
+
 	log.Println("Server stopped")
 }
 
@@ -209,3 +560,19 @@ func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
 
 	return time.Duration(seconds) * time.Second
 }
+
+// getEnvInt reads a positive integer from environment variable
+func getEnvInt(key string, defaultValue int) int {
+	valStr := os.Getenv(key)
+	if valStr == "" {
+		return defaultValue
+	}
+
+	val, err := strconv.Atoi(valStr)
+	if err != nil || val < 0 {
+		log.Printf("Invalid value for %s: %s, using default %d", key, valStr, defaultValue)
+		return defaultValue
+	}
+
+	return val
+}
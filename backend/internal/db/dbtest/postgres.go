@@ -0,0 +1,108 @@
+// Package dbtest provides PostgreSQL databases for tests that SQLite can't stand in for
+package dbtest
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"testing"
+
+	_ "github.com/lib/pq"
+)
+
+// URLEnv names the environment variable holding the connection URL of the test server
+// Tests using Postgres are skipped when it is unset
+const URLEnv = "TEST_DB_URL"
+
+// Postgres opens a connection to an empty schema of its own on the test server
+// The schema is dropped when the test ends
+func Postgres(t *testing.T) *sql.DB {
+	t.Helper()
+
+	rawURL := os.Getenv(URLEnv)
+	if rawURL == "" {
+		t.Skipf("%s not set", URLEnv)
+	}
+
+	suffix := make([]byte, 6)
+	if _, err := rand.Read(suffix); err != nil {
+		t.Fatalf("failed to generate schema name: %v", err)
+	}
+	schema := "test_" + hex.EncodeToString(suffix)
+
+	admin, err := sql.Open("postgres", rawURL)
+	if err != nil {
+		t.Fatalf("failed to open test database: %v", err)
+	}
+	t.Cleanup(func() { admin.Close() })
+
+	if _, err := admin.Exec(`CREATE SCHEMA ` + schema); err != nil {
+		t.Fatalf("failed to create schema: %v", err)
+	}
+	t.Cleanup(func() {
+		if _, err := admin.Exec(`DROP SCHEMA ` + schema + ` CASCADE`); err != nil {
+			t.Errorf("failed to drop schema %s: %v", schema, err)
+		}
+	})
+
+	// lib/pq passes unknown URL parameters on as run-time settings
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		t.Fatalf("invalid %s: %v", URLEnv, err)
+	}
+	query := u.Query()
+	query.Set("search_path", schema)
+	u.RawQuery = query.Encode()
+
+	db, err := sql.Open("postgres", u.String())
+	if err != nil {
+		t.Fatalf("failed to open test schema: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	return db
+}
+
+// MigrationsDir is the directory holding the SQL migrations
+func MigrationsDir() string {
+	_, file, _, _ := runtime.Caller(0)
+	return filepath.Join(filepath.Dir(file), "..", "migrations")
+}
+
+// Migrate runs the up migrations in name order, up to and including the one starting with last
+// An empty last runs them all
+func Migrate(t *testing.T, db *sql.DB, last string) {
+	t.Helper()
+
+	files, err := filepath.Glob(filepath.Join(MigrationsDir(), "*.up.sql"))
+	if err != nil {
+		t.Fatalf("failed to list migrations: %v", err)
+	}
+	sort.Strings(files)
+
+	for _, file := range files {
+		Exec(t, db, file)
+		if last != "" && strings.HasPrefix(filepath.Base(file), last) {
+			return
+		}
+	}
+}
+
+// Exec runs a migration file
+func Exec(t *testing.T, db *sql.DB, file string) {
+	t.Helper()
+
+	sqlBytes, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatalf("failed to read %s: %v", file, err)
+	}
+	if _, err := db.Exec(string(sqlBytes)); err != nil {
+		t.Fatalf("failed to run %s: %v", filepath.Base(file), err)
+	}
+}
@@ -0,0 +1,61 @@
+package db
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	"github.com/nimbus/backend/internal/db/dbtest"
+)
+
+func TestPartitionStatusLogsMigration_UpDownUp(t *testing.T) {
+	conn := dbtest.Postgres(t)
+	dbtest.Migrate(t, conn, "000015_")
+
+	var serviceID string
+	err := conn.QueryRow(`
+		WITH u AS (
+			INSERT INTO users (email, name, password) VALUES ('migrate@example.com', 'Migrate', 'x') RETURNING id
+		)
+		INSERT INTO services (user_id, name, url) SELECT id, 'Service', 'http://example.com' FROM u RETURNING id
+	`).Scan(&serviceID)
+	if err != nil {
+		t.Fatalf("failed to create service: %v", err)
+	}
+	if _, err := conn.Exec(`INSERT INTO service_status_logs (service_id, status, checked_at) VALUES ($1, 'online', now())`, serviceID); err != nil {
+		t.Fatalf("failed to create status log: %v", err)
+	}
+
+	up := filepath.Join(dbtest.MigrationsDir(), "000015_partition_service_status_logs.up.sql")
+	down := filepath.Join(dbtest.MigrationsDir(), "000015_partition_service_status_logs.down.sql")
+
+	dbtest.Exec(t, conn, down)
+	assertStatusLogsTable(t, conn, "r")
+
+	dbtest.Exec(t, conn, up)
+	assertStatusLogsTable(t, conn, "p")
+
+	dbtest.Exec(t, conn, down)
+	assertStatusLogsTable(t, conn, "r")
+}
+
+// assertStatusLogsTable checks the kind of service_status_logs ("r" plain, "p" partitioned) and that its row survived
+func assertStatusLogsTable(t *testing.T, conn *sql.DB, kind string) {
+	t.Helper()
+
+	var relkind string
+	if err := conn.QueryRow(`SELECT relkind::text FROM pg_class WHERE oid = 'service_status_logs'::regclass`).Scan(&relkind); err != nil {
+		t.Fatalf("failed to inspect service_status_logs: %v", err)
+	}
+	if relkind != kind {
+		t.Errorf("expected relkind %q, got %q", kind, relkind)
+	}
+
+	var count int
+	if err := conn.QueryRow(`SELECT COUNT(*) FROM service_status_logs`).Scan(&count); err != nil {
+		t.Fatalf("failed to count status logs: %v", err)
+	}
+	if count != 1 {
+		t.Errorf("expected 1 status log, got %d", count)
+	}
+}
@@ -0,0 +1,9 @@
+-- Rollback: Remove fingerprint history, status log events and check_config
+
+ALTER TABLE service_status_logs
+DROP COLUMN IF EXISTS event_detail,
+DROP COLUMN IF EXISTS event;
+
+DROP TABLE IF EXISTS service_fingerprints CASCADE;
+
+ALTER TABLE services DROP COLUMN IF EXISTS check_config;
@@ -0,0 +1,33 @@
+-- Add per-service check configuration (fingerprinting and future check options)
+-- Stored as JSONB so new check options don't require a schema change
+ALTER TABLE services ADD COLUMN IF NOT EXISTS check_config JSONB NOT NULL DEFAULT '{}';
+
+COMMENT ON COLUMN services.check_config IS 'Per-service health check options (see models.CheckConfig)';
+
+-- Create service_fingerprints table for response change detection
+-- A new row is only written when the fingerprint differs from the previous one
+CREATE TABLE IF NOT EXISTS service_fingerprints (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    service_id UUID NOT NULL REFERENCES services(id) ON DELETE CASCADE,
+    title TEXT,
+    body_hash VARCHAR(64) NOT NULL,
+    headers JSONB NOT NULL DEFAULT '{}',
+    cert_fingerprint VARCHAR(64),
+    changed_fields JSONB NOT NULL DEFAULT '[]',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Composite index for fetching the latest fingerprint per service
+CREATE INDEX IF NOT EXISTS idx_fingerprints_service_time ON service_fingerprints(service_id, created_at DESC);
+
+COMMENT ON TABLE service_fingerprints IS 'History of HTTP response fingerprints used to detect unexpected changes';
+COMMENT ON COLUMN service_fingerprints.body_hash IS 'SHA-256 of the whitespace-normalized response body';
+COMMENT ON COLUMN service_fingerprints.cert_fingerprint IS 'SHA-256 of the leaf TLS certificate (NULL for plain HTTP)';
+COMMENT ON COLUMN service_fingerprints.changed_fields IS 'Fields that differ from the previous fingerprint (empty for the baseline)';
+
+-- Add event columns to status logs so checks can record notable events (e.g. fingerprint changes)
+ALTER TABLE service_status_logs ADD COLUMN IF NOT EXISTS event VARCHAR(50);
+ALTER TABLE service_status_logs ADD COLUMN IF NOT EXISTS event_detail TEXT;
+
+COMMENT ON COLUMN service_status_logs.event IS 'Notable event detected during the check (NULL if none)';
+COMMENT ON COLUMN service_status_logs.event_detail IS 'Human-readable details for the event';
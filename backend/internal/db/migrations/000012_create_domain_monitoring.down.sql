@@ -0,0 +1,4 @@
+-- Rollback: Remove domain monitoring tables
+
+DROP TABLE IF EXISTS domain_registrations;
+DROP TABLE IF EXISTS monitored_domains;
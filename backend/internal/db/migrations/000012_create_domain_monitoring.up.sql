@@ -0,0 +1,25 @@
+-- Domains explicitly listed by users for registration expiry monitoring
+CREATE TABLE IF NOT EXISTS monitored_domains (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    domain VARCHAR(253) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT uq_monitored_domains_user_domain UNIQUE (user_id, domain)
+);
+
+CREATE INDEX IF NOT EXISTS idx_monitored_domains_user_id ON monitored_domains(user_id);
+
+-- Cached RDAP lookups, shared between users (registration data is public)
+CREATE TABLE IF NOT EXISTS domain_registrations (
+    domain VARCHAR(253) PRIMARY KEY,
+    expires_at TIMESTAMP WITH TIME ZONE,
+    registrar VARCHAR(255),
+    last_checked_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    last_error TEXT
+);
+
+COMMENT ON TABLE monitored_domains IS 'Domains added manually for expiry monitoring (service domains are derived from URLs)';
+COMMENT ON TABLE domain_registrations IS 'Cached RDAP registration data per registrable domain';
+COMMENT ON COLUMN domain_registrations.expires_at IS 'Registration expiration date (NULL if the registry does not publish one)';
+COMMENT ON COLUMN domain_registrations.last_error IS 'Error from the most recent RDAP lookup (NULL if successful)';
@@ -0,0 +1,5 @@
+-- Rollback: Remove transaction step results
+
+ALTER TABLE service_status_logs
+DROP COLUMN IF EXISTS step_timings,
+DROP COLUMN IF EXISTS failed_step;
@@ -0,0 +1,7 @@
+-- Record per-step results of multi-step transaction checks
+ALTER TABLE service_status_logs
+ADD COLUMN IF NOT EXISTS failed_step INTEGER,
+ADD COLUMN IF NOT EXISTS step_timings JSONB;
+
+COMMENT ON COLUMN service_status_logs.failed_step IS '1-based transaction step that failed (NULL for single-request checks or successful transactions)';
+COMMENT ON COLUMN service_status_logs.step_timings IS 'Per-step status code, duration and error of a transaction check';
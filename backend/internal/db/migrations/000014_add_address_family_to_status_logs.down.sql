@@ -0,0 +1,9 @@
+-- Rollback: Remove address family from status logs
+
+DROP INDEX IF EXISTS idx_status_logs_service_family_time;
+
+ALTER TABLE service_status_logs
+DROP CONSTRAINT IF EXISTS chk_address_family;
+
+ALTER TABLE service_status_logs
+DROP COLUMN IF EXISTS address_family;
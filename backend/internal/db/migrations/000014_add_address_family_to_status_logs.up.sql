@@ -0,0 +1,11 @@
+-- Record which IP address family each health check used so metrics can be split by family
+ALTER TABLE service_status_logs
+ADD COLUMN IF NOT EXISTS address_family VARCHAR(4);
+
+ALTER TABLE service_status_logs
+ADD CONSTRAINT chk_address_family CHECK (address_family IS NULL OR address_family IN ('ipv4', 'ipv6'));
+
+-- Composite index for per-family metrics queries
+CREATE INDEX IF NOT EXISTS idx_status_logs_service_family_time ON service_status_logs(service_id, address_family, checked_at DESC);
+
+COMMENT ON COLUMN service_status_logs.address_family IS 'IP address family used for the check (ipv4/ipv6, NULL if no connection was made)';
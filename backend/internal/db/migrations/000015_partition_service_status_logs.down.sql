@@ -0,0 +1,37 @@
+-- Rollback: Convert service_status_logs back to a single table
+
+ALTER TABLE service_status_logs RENAME TO service_status_logs_partitioned;
+ALTER TABLE service_status_logs_partitioned RENAME CONSTRAINT service_status_logs_pkey TO service_status_logs_partitioned_pkey;
+DROP INDEX IF EXISTS idx_status_logs_service_id;
+DROP INDEX IF EXISTS idx_status_logs_service_time;
+DROP INDEX IF EXISTS idx_status_logs_checked_at;
+DROP INDEX IF EXISTS idx_status_logs_service_family_time;
+
+CREATE TABLE service_status_logs (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    service_id UUID NOT NULL REFERENCES services(id) ON DELETE CASCADE,
+    status VARCHAR(20) NOT NULL,
+    response_time INTEGER,
+    error_message TEXT,
+    checked_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    event VARCHAR(50),
+    event_detail TEXT,
+    failed_step INTEGER,
+    step_timings JSONB,
+    address_family VARCHAR(4),
+
+    CONSTRAINT chk_status CHECK (status IN ('online', 'offline', 'unknown')),
+    CONSTRAINT chk_address_family CHECK (address_family IS NULL OR address_family IN ('ipv4', 'ipv6'))
+);
+
+INSERT INTO service_status_logs (id, service_id, status, response_time, error_message, checked_at, event, event_detail, failed_step, step_timings, address_family)
+SELECT id, service_id, status, response_time, error_message, checked_at, event, event_detail, failed_step, step_timings, address_family
+FROM service_status_logs_partitioned;
+
+-- Drops every partition with it
+DROP TABLE service_status_logs_partitioned;
+
+CREATE INDEX IF NOT EXISTS idx_status_logs_service_id ON service_status_logs(service_id);
+CREATE INDEX IF NOT EXISTS idx_status_logs_service_time ON service_status_logs(service_id, checked_at DESC);
+CREATE INDEX IF NOT EXISTS idx_status_logs_checked_at ON service_status_logs(checked_at);
+CREATE INDEX IF NOT EXISTS idx_status_logs_service_family_time ON service_status_logs(service_id, address_family, checked_at DESC);
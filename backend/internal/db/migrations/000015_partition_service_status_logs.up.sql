@@ -0,0 +1,80 @@
+-- Convert service_status_logs to a table range-partitioned by checked_at
+-- Retention then drops whole partitions instead of deleting rows (see services.StatusLogPartitionService)
+-- Partitions are bounded at UTC midnight and named service_status_logs_pYYYYMMDD after their first day
+
+-- Keep the old table aside until its rows are copied
+ALTER TABLE service_status_logs RENAME TO service_status_logs_unpartitioned;
+ALTER TABLE service_status_logs_unpartitioned RENAME CONSTRAINT service_status_logs_pkey TO service_status_logs_unpartitioned_pkey;
+DROP INDEX IF EXISTS idx_status_logs_service_id;
+DROP INDEX IF EXISTS idx_status_logs_service_time;
+DROP INDEX IF EXISTS idx_status_logs_checked_at;
+DROP INDEX IF EXISTS idx_status_logs_service_family_time;
+
+-- The partition key must be part of the primary key
+CREATE TABLE service_status_logs (
+    id UUID NOT NULL DEFAULT gen_random_uuid(),
+    service_id UUID NOT NULL REFERENCES services(id) ON DELETE CASCADE,
+    status VARCHAR(20) NOT NULL,
+    response_time INTEGER,
+    error_message TEXT,
+    checked_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    event VARCHAR(50),
+    event_detail TEXT,
+    failed_step INTEGER,
+    step_timings JSONB,
+    address_family VARCHAR(4),
+
+    PRIMARY KEY (id, checked_at),
+    CONSTRAINT chk_status CHECK (status IN ('online', 'offline', 'unknown')),
+    CONSTRAINT chk_address_family CHECK (address_family IS NULL OR address_family IN ('ipv4', 'ipv6'))
+) PARTITION BY RANGE (checked_at);
+
+-- Indexes are created on every partition automatically
+CREATE INDEX IF NOT EXISTS idx_status_logs_service_id ON service_status_logs(service_id);
+CREATE INDEX IF NOT EXISTS idx_status_logs_service_time ON service_status_logs(service_id, checked_at DESC);
+CREATE INDEX IF NOT EXISTS idx_status_logs_checked_at ON service_status_logs(checked_at);
+CREATE INDEX IF NOT EXISTS idx_status_logs_service_family_time ON service_status_logs(service_id, address_family, checked_at DESC);
+
+-- Catches rows outside every partition so an insert never fails; should stay (almost) empty
+CREATE TABLE IF NOT EXISTS service_status_logs_default PARTITION OF service_status_logs DEFAULT;
+
+-- One daily partition for every day that has data, plus today and the next week
+DO $$
+DECLARE
+    partition_day TIMESTAMP WITH TIME ZONE;
+BEGIN
+    FOR partition_day IN
+        SELECT DISTINCT date_trunc('day', checked_at AT TIME ZONE 'UTC') AT TIME ZONE 'UTC'
+        FROM service_status_logs_unpartitioned
+        UNION
+        SELECT generate_series(
+            date_trunc('day', now() AT TIME ZONE 'UTC'),
+            date_trunc('day', now() AT TIME ZONE 'UTC') + INTERVAL '7 days',
+            INTERVAL '1 day'
+        ) AT TIME ZONE 'UTC'
+    LOOP
+        EXECUTE format(
+            'CREATE TABLE IF NOT EXISTS %I PARTITION OF service_status_logs FOR VALUES FROM (%L) TO (%L)',
+            'service_status_logs_p' || to_char(partition_day AT TIME ZONE 'UTC', 'YYYYMMDD'),
+            partition_day,
+            partition_day + INTERVAL '24 hours' -- Absolute, unaffected by the session time zone's DST
+        );
+    END LOOP;
+END $$;
+
+-- Move existing data into the partitions
+INSERT INTO service_status_logs (id, service_id, status, response_time, error_message, checked_at, event, event_detail, failed_step, step_timings, address_family)
+SELECT id, service_id, status, response_time, error_message, checked_at, event, event_detail, failed_step, step_timings, address_family
+FROM service_status_logs_unpartitioned;
+
+DROP TABLE service_status_logs_unpartitioned;
+
+COMMENT ON TABLE service_status_logs IS 'Historical log of service health check results (partitioned by checked_at)';
+COMMENT ON COLUMN service_status_logs.response_time IS 'Response time in milliseconds (NULL if check failed)';
+COMMENT ON COLUMN service_status_logs.error_message IS 'Error details if check failed (NULL if successful)';
+COMMENT ON COLUMN service_status_logs.checked_at IS 'Timestamp when the health check was performed (partition key)';
+COMMENT ON COLUMN service_status_logs.event IS 'Notable event detected during the check (NULL if none)';
+COMMENT ON COLUMN service_status_logs.event_detail IS 'Human-readable details for the event';
+COMMENT ON COLUMN service_status_logs.failed_step IS '1-based transaction step that failed (NULL for single-request checks or successful transactions)';
+COMMENT ON COLUMN service_status_logs.step_timings IS 'Per-step status code, duration and error of a transaction check';
+COMMENT ON COLUMN service_status_logs.address_family IS 'IP address family used for the check (ipv4/ipv6, NULL if no connection was made)';
@@ -0,0 +1,3 @@
+-- Drop status rollup tables and their indexes
+DROP TABLE IF EXISTS service_status_rollups_daily CASCADE;
+DROP TABLE IF EXISTS service_status_rollups_hourly CASCADE;
@@ -0,0 +1,44 @@
+-- Hourly and daily rollups of service_status_logs for long-term uptime history
+-- Raw logs are kept for METRICS_RETENTION_DAYS, hourly rollups for ROLLUP_HOURLY_RETENTION_MONTHS
+-- and daily rollups forever. Both tiers are computed from raw logs (see services.RollupService)
+
+CREATE TABLE IF NOT EXISTS service_status_rollups_hourly (
+    service_id UUID NOT NULL REFERENCES services(id) ON DELETE CASCADE,
+    bucket TIMESTAMP WITH TIME ZONE NOT NULL,
+    check_count INTEGER NOT NULL,
+    online_count INTEGER NOT NULL,
+    offline_count INTEGER NOT NULL,
+    response_count INTEGER NOT NULL,
+    response_time_sum BIGINT NOT NULL,
+    min_response_time INTEGER,
+    max_response_time INTEGER,
+    p95_response_time DOUBLE PRECISION,
+
+    PRIMARY KEY (service_id, bucket)
+);
+
+CREATE TABLE IF NOT EXISTS service_status_rollups_daily (
+    service_id UUID NOT NULL REFERENCES services(id) ON DELETE CASCADE,
+    bucket TIMESTAMP WITH TIME ZONE NOT NULL,
+    check_count INTEGER NOT NULL,
+    online_count INTEGER NOT NULL,
+    offline_count INTEGER NOT NULL,
+    response_count INTEGER NOT NULL,
+    response_time_sum BIGINT NOT NULL,
+    min_response_time INTEGER,
+    max_response_time INTEGER,
+    p95_response_time DOUBLE PRECISION,
+
+    PRIMARY KEY (service_id, bucket)
+);
+
+-- Index on bucket for retention cleanup and finding the latest rolled-up bucket
+CREATE INDEX IF NOT EXISTS idx_rollups_hourly_bucket ON service_status_rollups_hourly(bucket);
+CREATE INDEX IF NOT EXISTS idx_rollups_daily_bucket ON service_status_rollups_daily(bucket);
+
+COMMENT ON TABLE service_status_rollups_hourly IS 'Per-service health check aggregates per UTC hour';
+COMMENT ON TABLE service_status_rollups_daily IS 'Per-service health check aggregates per UTC day (kept forever)';
+COMMENT ON COLUMN service_status_rollups_hourly.response_count IS 'Checks with a recorded response time (denominator for the average)';
+COMMENT ON COLUMN service_status_rollups_daily.response_count IS 'Checks with a recorded response time (denominator for the average)';
+COMMENT ON COLUMN service_status_rollups_hourly.response_time_sum IS 'Sum of response times in milliseconds, so averages can be merged across buckets';
+COMMENT ON COLUMN service_status_rollups_daily.response_time_sum IS 'Sum of response times in milliseconds, so averages can be merged across buckets';
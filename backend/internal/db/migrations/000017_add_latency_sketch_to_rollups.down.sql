@@ -0,0 +1,3 @@
+-- Rollback: Remove latency sketches from rollups
+ALTER TABLE service_status_rollups_daily DROP COLUMN IF EXISTS latency_sketch;
+ALTER TABLE service_status_rollups_hourly DROP COLUMN IF EXISTS latency_sketch;
@@ -0,0 +1,20 @@
+-- Mergeable response time sketches on rollups so percentiles can be computed over any range of buckets
+ALTER TABLE service_status_rollups_hourly ADD COLUMN IF NOT EXISTS latency_sketch JSONB;
+ALTER TABLE service_status_rollups_daily ADD COLUMN IF NOT EXISTS latency_sketch JSONB;
+
+COMMENT ON COLUMN service_status_rollups_hourly.latency_sketch IS 'Response time counts per log bucket index (see models.LatencySketch)';
+COMMENT ON COLUMN service_status_rollups_daily.latency_sketch IS 'Response time counts per log bucket index (see models.LatencySketch)';
+
+-- Drop buckets that can be rebuilt from retained raw logs; the rollup worker recomputes them with sketches
+-- (the first, partially retained bucket and everything older keep their values without a sketch)
+DELETE FROM service_status_rollups_hourly
+WHERE bucket >= (
+    SELECT date_trunc('hour', MIN(checked_at) AT TIME ZONE 'UTC') AT TIME ZONE 'UTC' + INTERVAL '1 hour'
+    FROM service_status_logs
+);
+
+DELETE FROM service_status_rollups_daily
+WHERE bucket >= (
+    SELECT date_trunc('day', MIN(checked_at) AT TIME ZONE 'UTC') AT TIME ZONE 'UTC' + INTERVAL '24 hours'
+    FROM service_status_logs
+);
@@ -0,0 +1,5 @@
+-- Rollback: Remove state durations from rollups
+ALTER TABLE service_status_rollups_daily DROP COLUMN IF EXISTS offline_seconds;
+ALTER TABLE service_status_rollups_daily DROP COLUMN IF EXISTS online_seconds;
+ALTER TABLE service_status_rollups_hourly DROP COLUMN IF EXISTS offline_seconds;
+ALTER TABLE service_status_rollups_hourly DROP COLUMN IF EXISTS online_seconds;
@@ -0,0 +1,20 @@
+-- Time spent online/offline per bucket, for time-weighted uptime over rollups
+-- (each result holds until the next one, capped at the max staleness; the rest of a bucket has no data)
+ALTER TABLE service_status_rollups_hourly ADD COLUMN IF NOT EXISTS online_seconds DOUBLE PRECISION;
+ALTER TABLE service_status_rollups_hourly ADD COLUMN IF NOT EXISTS offline_seconds DOUBLE PRECISION;
+ALTER TABLE service_status_rollups_daily ADD COLUMN IF NOT EXISTS online_seconds DOUBLE PRECISION;
+ALTER TABLE service_status_rollups_daily ADD COLUMN IF NOT EXISTS offline_seconds DOUBLE PRECISION;
+
+-- Drop buckets that can be rebuilt from retained raw logs; the rollup worker recomputes them with durations
+-- (older buckets keep NULL durations, which are estimated from their check counts)
+DELETE FROM service_status_rollups_hourly
+WHERE bucket >= (
+    SELECT date_trunc('hour', MIN(checked_at) AT TIME ZONE 'UTC') AT TIME ZONE 'UTC' + INTERVAL '1 hour'
+    FROM service_status_logs
+);
+
+DELETE FROM service_status_rollups_daily
+WHERE bucket >= (
+    SELECT date_trunc('day', MIN(checked_at) AT TIME ZONE 'UTC') AT TIME ZONE 'UTC' + INTERVAL '24 hours'
+    FROM service_status_logs
+);
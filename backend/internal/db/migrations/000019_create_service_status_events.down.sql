@@ -0,0 +1,2 @@
+-- Drop status event table and its indexes
+DROP TABLE IF EXISTS service_status_events CASCADE;
@@ -0,0 +1,18 @@
+-- Effective status transitions of services (online -> offline etc.), written by the health checker
+-- when a service's status changes. Events are small and kept forever, unlike raw status logs
+
+CREATE TABLE IF NOT EXISTS service_status_events (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    service_id UUID NOT NULL REFERENCES services(id) ON DELETE CASCADE,
+    from_status VARCHAR(20) NOT NULL CHECK (from_status IN ('online', 'offline', 'unknown')),
+    to_status VARCHAR(20) NOT NULL CHECK (to_status IN ('online', 'offline', 'unknown')),
+    occurred_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    previous_duration_ms BIGINT,
+    error_message TEXT
+);
+
+CREATE INDEX IF NOT EXISTS idx_service_status_events_service_occurred ON service_status_events(service_id, occurred_at DESC);
+
+COMMENT ON TABLE service_status_events IS 'Status transitions per service, used for outage history and MTTR/MTBF';
+COMMENT ON COLUMN service_status_events.previous_duration_ms IS 'How long the service was in from_status (NULL if its start is unknown)';
+COMMENT ON COLUMN service_status_events.error_message IS 'Error of the check that triggered the transition (NULL if it succeeded)';
@@ -0,0 +1,2 @@
+-- Drop SLO table and its indexes
+DROP TABLE IF EXISTS slos CASCADE;
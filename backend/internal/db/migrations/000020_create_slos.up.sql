@@ -0,0 +1,27 @@
+-- Service level objectives: an availability target and an optional latency target over a window
+-- for one service or a group of services. Error budgets are computed from status logs
+
+CREATE TABLE IF NOT EXISTS slos (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    service_ids JSONB NOT NULL DEFAULT '[]',
+    availability_target DOUBLE PRECISION NOT NULL CHECK (availability_target > 0 AND availability_target < 100),
+    latency_threshold_ms INTEGER CHECK (latency_threshold_ms > 0),
+    latency_target DOUBLE PRECISION CHECK (latency_target > 0 AND latency_target < 100),
+    window_type VARCHAR(20) NOT NULL CHECK (window_type IN ('rolling', 'calendar')),
+    window_days INTEGER,
+    calendar_period VARCHAR(20) CHECK (calendar_period IN ('week', 'month', 'quarter')),
+    timezone VARCHAR(64) NOT NULL DEFAULT 'UTC',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_slos_user_id ON slos(user_id);
+
+COMMENT ON TABLE slos IS 'Service level objectives with error budget tracking';
+COMMENT ON COLUMN slos.service_ids IS 'Services the objective covers (one service or a group)';
+COMMENT ON COLUMN slos.availability_target IS 'Percentage of time the services must be online, e.g. 99.5';
+COMMENT ON COLUMN slos.latency_target IS 'Percentage of checks that must respond within latency_threshold_ms (NULL without a latency objective)';
+COMMENT ON COLUMN slos.window_days IS 'Length of a rolling window (NULL for calendar windows)';
+COMMENT ON COLUMN slos.calendar_period IS 'Calendar period of a calendar window, aligned to timezone (NULL for rolling windows)';
@@ -0,0 +1,3 @@
+-- Drop notification channel tables and their indexes
+DROP TABLE IF EXISTS service_notification_channels CASCADE;
+DROP TABLE IF EXISTS notification_channels CASCADE;
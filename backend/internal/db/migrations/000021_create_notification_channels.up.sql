@@ -0,0 +1,29 @@
+-- Notification channels (webhook, ntfy, Gotify, Discord, Slack) that status transitions are sent to.
+-- Channels belong to a user, or are global (user_id NULL) when added by an admin.
+-- Each service selects the channels it notifies through service_notification_channels
+
+CREATE TABLE IF NOT EXISTS notification_channels (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID REFERENCES users(id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    type VARCHAR(20) NOT NULL CHECK (type IN ('webhook', 'ntfy', 'gotify', 'discord', 'slack')),
+    config JSONB NOT NULL DEFAULT '{}',
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_notification_channels_user_id ON notification_channels(user_id);
+
+CREATE TABLE IF NOT EXISTS service_notification_channels (
+    service_id UUID NOT NULL REFERENCES services(id) ON DELETE CASCADE,
+    channel_id UUID NOT NULL REFERENCES notification_channels(id) ON DELETE CASCADE,
+    PRIMARY KEY (service_id, channel_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_service_notification_channels_channel_id ON service_notification_channels(channel_id);
+
+COMMENT ON TABLE notification_channels IS 'Destinations for service status notifications';
+COMMENT ON COLUMN notification_channels.user_id IS 'Owner of the channel (NULL for global channels managed by admins)';
+COMMENT ON COLUMN notification_channels.config IS 'Provider settings (URL, topic, token, body template)';
+COMMENT ON TABLE service_notification_channels IS 'Channels each service notifies when its status changes';
@@ -0,0 +1,8 @@
+-- Remove email notification channels and restore the previous type constraint
+DELETE FROM notification_channels WHERE type = 'email';
+
+ALTER TABLE notification_channels DROP CONSTRAINT IF EXISTS notification_channels_type_check;
+ALTER TABLE notification_channels ADD CONSTRAINT notification_channels_type_check
+    CHECK (type IN ('webhook', 'ntfy', 'gotify', 'discord', 'slack'));
+
+COMMENT ON COLUMN notification_channels.config IS 'Provider settings (URL, topic, token, body template)';
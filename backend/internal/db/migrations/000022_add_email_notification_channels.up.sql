@@ -0,0 +1,7 @@
+-- Email notification channels, sent with the SMTP settings admins store in system_settings
+
+ALTER TABLE notification_channels DROP CONSTRAINT IF EXISTS notification_channels_type_check;
+ALTER TABLE notification_channels ADD CONSTRAINT notification_channels_type_check
+    CHECK (type IN ('webhook', 'ntfy', 'gotify', 'discord', 'slack', 'email'));
+
+COMMENT ON COLUMN notification_channels.config IS 'Provider settings (URL, topic, token, body template, email recipients)';
@@ -0,0 +1,4 @@
+-- Drop alert rule tables and their indexes
+DROP TABLE IF EXISTS alert_states CASCADE;
+DROP TABLE IF EXISTS alert_rule_channels CASCADE;
+DROP TABLE IF EXISTS alert_rules CASCADE;
@@ -0,0 +1,54 @@
+-- Alert rules: notify after a service has been down for a while, remind while it stays down,
+-- escalate to more channels, announce recovery and hold messages during quiet hours.
+-- alert_states tracks each rule's progress per service so alerts survive restarts
+
+CREATE TABLE IF NOT EXISTS alert_rules (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    service_id UUID REFERENCES services(id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    delay_minutes INTEGER NOT NULL DEFAULT 0 CHECK (delay_minutes >= 0),
+    repeat_minutes INTEGER NOT NULL DEFAULT 0 CHECK (repeat_minutes >= 0),
+    escalation_minutes INTEGER NOT NULL DEFAULT 0 CHECK (escalation_minutes >= 0),
+    notify_recovery BOOLEAN NOT NULL DEFAULT TRUE,
+    quiet_hours_start VARCHAR(5),
+    quiet_hours_end VARCHAR(5),
+    timezone VARCHAR(64) NOT NULL DEFAULT 'UTC',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_alert_rules_user_id ON alert_rules(user_id);
+CREATE INDEX IF NOT EXISTS idx_alert_rules_service_id ON alert_rules(service_id);
+
+CREATE TABLE IF NOT EXISTS alert_rule_channels (
+    rule_id UUID NOT NULL REFERENCES alert_rules(id) ON DELETE CASCADE,
+    channel_id UUID NOT NULL REFERENCES notification_channels(id) ON DELETE CASCADE,
+    escalation BOOLEAN NOT NULL DEFAULT FALSE,
+    PRIMARY KEY (rule_id, channel_id, escalation)
+);
+
+CREATE INDEX IF NOT EXISTS idx_alert_rule_channels_channel_id ON alert_rule_channels(channel_id);
+
+CREATE TABLE IF NOT EXISTS alert_states (
+    rule_id UUID NOT NULL REFERENCES alert_rules(id) ON DELETE CASCADE,
+    service_id UUID NOT NULL REFERENCES services(id) ON DELETE CASCADE,
+    down_since TIMESTAMP WITH TIME ZONE NOT NULL,
+    error_message TEXT,
+    alerted_at TIMESTAMP WITH TIME ZONE,
+    last_notified_at TIMESTAMP WITH TIME ZONE,
+    notification_count INTEGER NOT NULL DEFAULT 0,
+    escalated_at TIMESTAMP WITH TIME ZONE,
+    recovered_at TIMESTAMP WITH TIME ZONE,
+    PRIMARY KEY (rule_id, service_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_alert_states_service_id ON alert_states(service_id);
+
+COMMENT ON TABLE alert_rules IS 'Rules deciding when and where service outages are alerted';
+COMMENT ON COLUMN alert_rules.service_id IS 'Service the rule applies to (NULL for all of the user''s services)';
+COMMENT ON COLUMN alert_rules.quiet_hours_start IS 'Start of the daily quiet period (HH:MM in timezone), messages are held until it ends';
+COMMENT ON TABLE alert_rule_channels IS 'Channels notified by a rule; escalation channels are added after escalation_minutes';
+COMMENT ON TABLE alert_states IS 'Progress of each rule for each service that is down (or whose recovery notice is held)';
+COMMENT ON COLUMN alert_states.recovered_at IS 'Set when the service recovered during quiet hours; the recovery notice is sent when they end';
@@ -0,0 +1,4 @@
+-- Drop incident tables and their indexes
+DROP TABLE IF EXISTS incident_notes CASCADE;
+DROP TABLE IF EXISTS incident_services CASCADE;
+DROP TABLE IF EXISTS incidents CASCADE;
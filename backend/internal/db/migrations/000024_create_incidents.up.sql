@@ -0,0 +1,45 @@
+-- Incidents: an outage record opened when a service goes offline and resolved when it recovers.
+-- Users acknowledge incidents (silencing alert escalation), add notes and link more affected services
+
+CREATE TABLE IF NOT EXISTS incidents (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    title VARCHAR(255) NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'open',
+    started_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    acknowledged_at TIMESTAMP WITH TIME ZONE,
+    acknowledged_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    resolved_at TIMESTAMP WITH TIME ZONE,
+    resolved_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT incidents_status_check CHECK (status IN ('open', 'acknowledged', 'resolved'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_incidents_user_id_started_at ON incidents(user_id, started_at DESC);
+CREATE INDEX IF NOT EXISTS idx_incidents_unresolved ON incidents(user_id) WHERE status <> 'resolved';
+
+CREATE TABLE IF NOT EXISTS incident_services (
+    incident_id UUID NOT NULL REFERENCES incidents(id) ON DELETE CASCADE,
+    service_id UUID NOT NULL REFERENCES services(id) ON DELETE CASCADE,
+    added_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (incident_id, service_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_incident_services_service_id ON incident_services(service_id);
+
+CREATE TABLE IF NOT EXISTS incident_notes (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    incident_id UUID NOT NULL REFERENCES incidents(id) ON DELETE CASCADE,
+    user_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    body TEXT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_incident_notes_incident_id ON incident_notes(incident_id, created_at);
+
+COMMENT ON TABLE incidents IS 'Outage records, opened automatically when a service goes offline';
+COMMENT ON COLUMN incidents.status IS 'open, acknowledged (escalation silenced) or resolved';
+COMMENT ON COLUMN incidents.resolved_by IS 'User who resolved the incident (NULL when it resolved on recovery)';
+COMMENT ON TABLE incident_services IS 'Services affected by an incident; it resolves once all of them are back online';
+COMMENT ON TABLE incident_notes IS 'Timestamped notes added to an incident';
@@ -0,0 +1,10 @@
+-- Drop digest report columns, the certificate table and their indexes
+DROP TABLE IF EXISTS service_certificates CASCADE;
+
+DROP INDEX IF EXISTS idx_user_preferences_digest_enabled;
+ALTER TABLE user_preferences DROP COLUMN IF EXISTS digest_last_sent_at;
+ALTER TABLE user_preferences DROP COLUMN IF EXISTS digest_channel_ids;
+ALTER TABLE user_preferences DROP COLUMN IF EXISTS digest_email;
+ALTER TABLE user_preferences DROP COLUMN IF EXISTS digest_timezone;
+ALTER TABLE user_preferences DROP COLUMN IF EXISTS digest_schedule;
+ALTER TABLE user_preferences DROP COLUMN IF EXISTS digest_enabled;
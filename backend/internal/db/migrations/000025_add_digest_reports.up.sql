@@ -0,0 +1,24 @@
+-- Digest reports: an opt-in periodic summary (uptime, incidents, slowest services, expiring certificates)
+-- The schedule is a cron expression evaluated in the user's timezone
+
+ALTER TABLE user_preferences ADD COLUMN IF NOT EXISTS digest_enabled BOOLEAN NOT NULL DEFAULT false;
+ALTER TABLE user_preferences ADD COLUMN IF NOT EXISTS digest_schedule VARCHAR(100) NOT NULL DEFAULT '0 8 * * 1';
+ALTER TABLE user_preferences ADD COLUMN IF NOT EXISTS digest_timezone VARCHAR(64) NOT NULL DEFAULT 'UTC';
+ALTER TABLE user_preferences ADD COLUMN IF NOT EXISTS digest_email BOOLEAN NOT NULL DEFAULT true;
+ALTER TABLE user_preferences ADD COLUMN IF NOT EXISTS digest_channel_ids JSONB NOT NULL DEFAULT '[]';
+ALTER TABLE user_preferences ADD COLUMN IF NOT EXISTS digest_last_sent_at TIMESTAMP WITH TIME ZONE;
+
+CREATE INDEX IF NOT EXISTS idx_user_preferences_digest_enabled ON user_preferences(user_id) WHERE digest_enabled;
+
+-- Leaf TLS certificate last seen by each service's health check
+CREATE TABLE IF NOT EXISTS service_certificates (
+    service_id UUID PRIMARY KEY REFERENCES services(id) ON DELETE CASCADE,
+    subject VARCHAR(255) NOT NULL,
+    issuer VARCHAR(255) NOT NULL,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    checked_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_service_certificates_expires_at ON service_certificates(expires_at);
+
+COMMENT ON TABLE service_certificates IS 'Leaf TLS certificate of each HTTPS service, updated when it changes';
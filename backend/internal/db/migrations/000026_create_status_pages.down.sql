@@ -0,0 +1,4 @@
+-- Drop status page tables and their indexes
+DROP TABLE IF EXISTS status_page_announcements CASCADE;
+DROP TABLE IF EXISTS status_page_services CASCADE;
+DROP TABLE IF EXISTS status_pages CASCADE;
@@ -0,0 +1,52 @@
+-- Public status pages: a slug-addressed page showing selected services with 90-day uptime bars,
+-- current incidents and announcements, readable without an account (optionally password protected)
+
+CREATE TABLE IF NOT EXISTS status_pages (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    slug VARCHAR(64) NOT NULL,
+    title VARCHAR(255) NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    timezone VARCHAR(64) NOT NULL DEFAULT 'UTC',
+    password_hash VARCHAR(255),
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT status_pages_slug_key UNIQUE (slug)
+);
+
+CREATE INDEX IF NOT EXISTS idx_status_pages_user_id ON status_pages(user_id);
+
+CREATE TABLE IF NOT EXISTS status_page_services (
+    status_page_id UUID NOT NULL REFERENCES status_pages(id) ON DELETE CASCADE,
+    service_id UUID NOT NULL REFERENCES services(id) ON DELETE CASCADE,
+    position INTEGER NOT NULL DEFAULT 0,
+    display_name VARCHAR(255),
+    hide_url BOOLEAN NOT NULL DEFAULT false,
+    PRIMARY KEY (status_page_id, service_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_status_page_services_service_id ON status_page_services(service_id);
+
+CREATE TABLE IF NOT EXISTS status_page_announcements (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    status_page_id UUID NOT NULL REFERENCES status_pages(id) ON DELETE CASCADE,
+    title VARCHAR(255) NOT NULL,
+    body TEXT NOT NULL DEFAULT '',
+    severity VARCHAR(20) NOT NULL DEFAULT 'info',
+    starts_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    ends_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT status_page_announcements_severity_check CHECK (severity IN ('info', 'maintenance', 'warning')),
+    CONSTRAINT status_page_announcements_period_check CHECK (ends_at IS NULL OR ends_at > starts_at)
+);
+
+CREATE INDEX IF NOT EXISTS idx_status_page_announcements_page_id ON status_page_announcements(status_page_id, starts_at DESC);
+
+COMMENT ON TABLE status_pages IS 'Public status pages, served unauthenticated by slug';
+COMMENT ON COLUMN status_pages.timezone IS 'IANA zone the daily uptime bars are aligned to';
+COMMENT ON COLUMN status_pages.password_hash IS 'bcrypt hash of the password visitors must send (NULL for a public page)';
+COMMENT ON TABLE status_page_services IS 'Services shown on a status page, in position order';
+COMMENT ON COLUMN status_page_services.display_name IS 'Name shown instead of the service name (NULL to use the service name)';
+COMMENT ON COLUMN status_page_services.hide_url IS 'Leave the service URL off the public page';
+COMMENT ON TABLE status_page_announcements IS 'Notices shown on a status page between starts_at and ends_at';
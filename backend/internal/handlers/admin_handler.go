@@ -12,10 +12,6 @@ import (
 
 type AdminHandler struct {
 	userRepo *repository.UserRepository
-	// TODO: Add these repositories when implementing invitation system
-	// activityRepo   *repository.ActivityLogRepository
-	// invitationRepo *repository.InvitationRepository
-	// settingsRepo   *repository.SettingsRepository
 }
 
 func NewAdminHandler(userRepo *repository.UserRepository) *AdminHandler {
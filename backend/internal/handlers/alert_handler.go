@@ -0,0 +1,146 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/nimbus/backend/internal/models"
+	"github.com/nimbus/backend/internal/services"
+)
+
+type AlertHandler struct {
+	alertService *services.AlertService
+}
+
+func NewAlertHandler(alertService *services.AlertService) *AlertHandler {
+	return &AlertHandler{
+		alertService: alertService,
+	}
+}
+
+// alertError maps alert service errors to responses
+func alertError(c *fiber.Ctx, err error, action string) error {
+	switch {
+	case errors.Is(err, services.ErrInvalidAlertRule):
+		return BadRequest(c, strings.TrimPrefix(err.Error(), services.ErrInvalidAlertRule.Error()+": "))
+	case errors.Is(err, sql.ErrNoRows):
+		return NotFound(c, "Alert rule not found")
+	default:
+		return InternalError(c, "Failed to "+action+" alert rule")
+	}
+}
+
+// GetRules returns the user's alert rules
+// GET /api/v1/alerts
+func (h *AlertHandler) GetRules(c *fiber.Ctx) error {
+	userID, err := getUserID(c)
+	if err != nil {
+		return Unauthorized(c, "Unauthorized")
+	}
+
+	rules, err := h.alertService.List(c.Context(), userID)
+	if err != nil {
+		return InternalError(c, "Failed to retrieve alert rules")
+	}
+
+	return Success(c, fiber.Map{
+		"rules": rules,
+		"count": len(rules),
+	})
+}
+
+// GetActiveAlerts returns the outages the user's rules are tracking
+// GET /api/v1/alerts/active
+func (h *AlertHandler) GetActiveAlerts(c *fiber.Ctx) error {
+	userID, err := getUserID(c)
+	if err != nil {
+		return Unauthorized(c, "Unauthorized")
+	}
+
+	alerts, err := h.alertService.Active(c.Context(), userID)
+	if err != nil {
+		return InternalError(c, "Failed to retrieve active alerts")
+	}
+
+	return Success(c, fiber.Map{
+		"alerts": alerts,
+		"count":  len(alerts),
+	})
+}
+
+// GetRule returns one alert rule
+// GET /api/v1/alerts/:id
+func (h *AlertHandler) GetRule(c *fiber.Ctx) error {
+	userID, err := getUserID(c)
+	if err != nil {
+		return Unauthorized(c, "Unauthorized")
+	}
+
+	rule, err := h.alertService.Get(c.Context(), userID, c.Params("id"))
+	if err != nil {
+		return alertError(c, err, "retrieve")
+	}
+
+	return Success(c, rule)
+}
+
+// CreateRule adds an alert rule
+// POST /api/v1/alerts
+func (h *AlertHandler) CreateRule(c *fiber.Ctx) error {
+	userID, err := getUserID(c)
+	if err != nil {
+		return Unauthorized(c, "Unauthorized")
+	}
+
+	var req models.AlertRuleRequest
+	if err := c.BodyParser(&req); err != nil {
+		return BadRequest(c, "Invalid request body")
+	}
+
+	rule, err := h.alertService.Create(c.Context(), userID, &req)
+	if err != nil {
+		return alertError(c, err, "create")
+	}
+
+	return Created(c, rule)
+}
+
+// UpdateRule replaces an alert rule's settings
+// PUT /api/v1/alerts/:id
+func (h *AlertHandler) UpdateRule(c *fiber.Ctx) error {
+	userID, err := getUserID(c)
+	if err != nil {
+		return Unauthorized(c, "Unauthorized")
+	}
+
+	var req models.AlertRuleRequest
+	if err := c.BodyParser(&req); err != nil {
+		return BadRequest(c, "Invalid request body")
+	}
+
+	rule, err := h.alertService.Update(c.Context(), userID, c.Params("id"), &req)
+	if err != nil {
+		return alertError(c, err, "update")
+	}
+
+	return Success(c, rule)
+}
+
+// DeleteRule removes an alert rule and its pending alerts
+// DELETE /api/v1/alerts/:id
+func (h *AlertHandler) DeleteRule(c *fiber.Ctx) error {
+	userID, err := getUserID(c)
+	if err != nil {
+		return Unauthorized(c, "Unauthorized")
+	}
+
+	if err := h.alertService.Delete(c.Context(), userID, c.Params("id")); err != nil {
+		return alertError(c, err, "delete")
+	}
+
+	return Success(c, fiber.Map{
+		"message": "Alert rule deleted successfully",
+	})
+}
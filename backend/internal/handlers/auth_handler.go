@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"errors"
 	"log"
 	"os"
 	"time"
@@ -12,8 +13,9 @@ import (
 )
 
 type AuthHandler struct {
-	userRepo    *repository.UserRepository
-	authService *services.AuthService
+	userRepo          *repository.UserRepository
+	authService       *services.AuthService
+	invitationService *services.InvitationService // Checks invite tokens on registration (nil rejects them)
 }
 
 func NewAuthHandler(userRepo *repository.UserRepository, authService *services.AuthService) *AuthHandler {
@@ -23,6 +25,11 @@ func NewAuthHandler(userRepo *repository.UserRepository, authService *services.A
 	}
 }
 
+// SetInvitationService sets the service checking and accepting invitations on registration
+func (h *AuthHandler) SetInvitationService(invitationService *services.InvitationService) {
+	h.invitationService = invitationService
+}
+
 // getCookieSecure returns whether cookies should be secure based on environment
 // Returns true for production (HTTPS), false for local development (HTTP)
 func (h *AuthHandler) getCookieSecure() bool {
@@ -67,6 +74,28 @@ func (h *AuthHandler) Register(c *fiber.Ctx) error {
 		})
 	}
 
+	// An invitation link must be pending and sent to this address
+	var invitation *models.UserInvitation
+	if req.InviteToken != "" {
+		if h.invitationService == nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": services.ErrInvitationUnusable.Error(),
+			})
+		}
+		invitation, err = h.invitationService.Validate(c.Context(), req.InviteToken, req.Email)
+		if errors.Is(err, services.ErrInvitationUnusable) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to check invitation",
+			})
+		}
+		req.Email = invitation.Email
+	}
+
 	// Hash password
 	hashedPassword, err := h.authService.HashPassword(req.Password)
 	if err != nil {
@@ -91,6 +120,13 @@ func (h *AuthHandler) Register(c *fiber.Ctx) error {
 		})
 	}
 
+	// The account exists either way; a failure only leaves the link usable until it expires
+	if invitation != nil {
+		if err := h.invitationService.Accept(c.Context(), invitation, user.ID, c.IP()); err != nil {
+			log.Printf("Failed to accept invitation %s: %v", invitation.ID, err)
+		}
+	}
+
 	// Generate token
 	token, err := h.authService.GenerateToken(user.ID, user.Email, user.Role)
 	if err != nil {
@@ -2,8 +2,10 @@ package handlers
 
 import (
 	"bytes"
+	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"os"
@@ -419,6 +421,87 @@ func TestAuthHandler_Register(t *testing.T) {
 	})
 }
 
+func TestAuthHandler_RegisterWithInvitation(t *testing.T) {
+	os.Setenv("JWT_SECRET", "test-secret-for-jwt-token-generation-minimum-32-chars")
+	defer os.Unsetenv("JWT_SECRET")
+
+	os.Setenv("COOKIE_SECURE", "false")
+	defer os.Unsetenv("COOKIE_SECURE")
+
+	db := setupAuthTestDB(t)
+	defer db.Close()
+
+	_, err := db.Exec(`
+		CREATE TABLE user_invitations (
+			id TEXT PRIMARY KEY DEFAULT (lower(hex(randomblob(16)))),
+			email TEXT NOT NULL,
+			token TEXT NOT NULL UNIQUE,
+			invited_by TEXT NOT NULL,
+			expires_at TIMESTAMP NOT NULL,
+			accepted_at TIMESTAMP,
+			created_at TIMESTAMP NOT NULL
+		)
+	`)
+	if err != nil {
+		t.Fatalf("Failed to create invitations table: %v", err)
+	}
+
+	userRepo := repository.NewUserRepository(db)
+	invitationRepo := repository.NewInvitationRepository(db)
+	handler := NewAuthHandler(userRepo, services.NewAuthService())
+	handler.SetInvitationService(services.NewInvitationService(invitationRepo, userRepo, nil, nil, "http://nimbus.local", 0))
+
+	app := fiber.New()
+	app.Post("/register", handler.Register)
+
+	ctx := context.Background()
+	invitation, err := invitationRepo.Create(ctx, "invited@example.com", "admin-id", 72)
+	if err != nil {
+		t.Fatalf("Failed to create invitation: %v", err)
+	}
+	expired, err := invitationRepo.Create(ctx, "late@example.com", "admin-id", -1)
+	if err != nil {
+		t.Fatalf("Failed to create invitation: %v", err)
+	}
+
+	register := func(email, token string) int {
+		bodyJSON, _ := json.Marshal(models.RegisterRequest{Email: email, Name: "Invited", Password: "SecurePassword123!", InviteToken: token})
+		req := httptest.NewRequest(http.MethodPost, "/register", bytes.NewReader(bodyJSON))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := app.Test(req, -1)
+		if err != nil {
+			t.Fatalf("Failed to execute request: %v", err)
+		}
+		return resp.StatusCode
+	}
+
+	if status := register("invited@example.com", "unknown-token"); status != http.StatusBadRequest {
+		t.Errorf("Expected %d for an unknown token, got %d", http.StatusBadRequest, status)
+	}
+	if status := register("someone-else@example.com", invitation.Token); status != http.StatusBadRequest {
+		t.Errorf("Expected %d for a token sent to another address, got %d", http.StatusBadRequest, status)
+	}
+	if status := register("late@example.com", expired.Token); status != http.StatusBadRequest {
+		t.Errorf("Expected %d for an expired token, got %d", http.StatusBadRequest, status)
+	}
+
+	if status := register("Invited@example.com", invitation.Token); status != http.StatusCreated {
+		t.Fatalf("Expected %d for a valid invitation, got %d", http.StatusCreated, status)
+	}
+	accepted, err := invitationRepo.GetByToken(ctx, invitation.Token)
+	if err != nil {
+		t.Fatalf("Failed to get invitation: %v", err)
+	}
+	if accepted.AcceptedAt == nil {
+		t.Error("Expected the invitation to be marked as accepted")
+	}
+
+	// A used link stops working
+	if _, err := handler.invitationService.Validate(ctx, invitation.Token, "invited@example.com"); !errors.Is(err, services.ErrInvitationUnusable) {
+		t.Errorf("Validate() on a used invitation error = %v, want ErrInvitationUnusable", err)
+	}
+}
+
 func TestAuthHandler_Logout(t *testing.T) {
 	os.Setenv("JWT_SECRET", "test-secret-for-jwt-token-generation-minimum-32-chars")
 	defer os.Unsetenv("JWT_SECRET")
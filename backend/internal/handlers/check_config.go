@@ -0,0 +1,162 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/nimbus/backend/internal/models"
+	"github.com/nimbus/backend/internal/services"
+)
+
+// maxFingerprintHeaders limits how many response headers can be fingerprinted per service
+const maxFingerprintHeaders = 20
+
+// maxTransactionSteps limits how many HTTP requests a transaction check can make
+const maxTransactionSteps = 20
+
+// variableNameRegex matches valid transaction variable names (referenced as {{name}})
+var variableNameRegex = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// transactionMethods are the HTTP methods allowed in transaction steps
+var transactionMethods = map[string]bool{
+	http.MethodGet:     true,
+	http.MethodHead:    true,
+	http.MethodPost:    true,
+	http.MethodPut:     true,
+	http.MethodPatch:   true,
+	http.MethodDelete:  true,
+	http.MethodOptions: true,
+}
+
+// headerNameRegex matches valid HTTP header field names (RFC 7230 token)
+var headerNameRegex = regexp.MustCompile("^[A-Za-z0-9!#$%&'*+.^_`|~-]+$")
+
+// templateVarPattern matches {{name}} variable references in transaction steps
+var templateVarPattern = regexp.MustCompile(`\{\{\s*[A-Za-z_][A-Za-z0-9_]*\s*\}\}`)
+
+// validateCheckConfig validates per-service health check settings
+func validateCheckConfig(cfg *models.CheckConfig) error {
+	if cfg.Fingerprint != nil {
+		if len(cfg.Fingerprint.Headers) > maxFingerprintHeaders {
+			return fmt.Errorf("fingerprint.headers supports at most %d headers", maxFingerprintHeaders)
+		}
+		for _, header := range cfg.Fingerprint.Headers {
+			if !headerNameRegex.MatchString(header) {
+				return fmt.Errorf("fingerprint.headers contains an invalid header name: %q", header)
+			}
+		}
+	}
+
+	switch cfg.AddressFamily {
+	case "", models.AddressFamilyAuto, models.AddressFamilyIPv4, models.AddressFamilyIPv6, models.AddressFamilyBoth:
+	default:
+		return fmt.Errorf("address_family must be one of auto, ipv4, ipv6, both")
+	}
+
+	if err := services.ValidateDNSConfig(cfg.DNS); err != nil {
+		return err
+	}
+
+	if cfg.TLS != nil {
+		switch cfg.TLS.MinVersion {
+		case "", models.TLSVersion12, models.TLSVersion13:
+		default:
+			return fmt.Errorf("tls.min_version must be '%s' or '%s'", models.TLSVersion12, models.TLSVersion13)
+		}
+		if strings.ContainsAny(cfg.TLS.ServerName, " /:*") {
+			return fmt.Errorf("tls.server_name must be a hostname")
+		}
+	}
+
+	switch cfg.Type {
+	case "", models.CheckTypeHTTP:
+	case models.CheckTypeTransaction:
+		if err := validateTransaction(cfg.Transaction); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("type must be '%s' or '%s'", models.CheckTypeHTTP, models.CheckTypeTransaction)
+	}
+
+	return nil
+}
+
+// validateTransaction validates the steps of a transaction check
+func validateTransaction(tx *models.TransactionConfig) error {
+	if tx == nil || len(tx.Steps) == 0 {
+		return fmt.Errorf("transaction requires at least one step")
+	}
+	if len(tx.Steps) > maxTransactionSteps {
+		return fmt.Errorf("transaction supports at most %d steps", maxTransactionSteps)
+	}
+
+	for i, step := range tx.Steps {
+		prefix := fmt.Sprintf("transaction.steps[%d]", i)
+
+		if step.Method != "" && !transactionMethods[strings.ToUpper(step.Method)] {
+			return fmt.Errorf("%s.method is not supported: %q", prefix, step.Method)
+		}
+
+		// Variables may appear anywhere in the URL, so validate it with placeholders filled in
+		placeholderURL := templateVarPattern.ReplaceAllString(step.URL, "x")
+		parsed, err := url.Parse(placeholderURL)
+		if err != nil {
+			return fmt.Errorf("%s.url is invalid", prefix)
+		}
+		if parsed.IsAbs() && parsed.Scheme != "http" && parsed.Scheme != "https" {
+			return fmt.Errorf("%s.url must use http or https", prefix)
+		}
+
+		for name := range step.Headers {
+			if !headerNameRegex.MatchString(name) {
+				return fmt.Errorf("%s.headers contains an invalid header name: %q", prefix, name)
+			}
+		}
+
+		for j, extract := range step.Extract {
+			extractPrefix := fmt.Sprintf("%s.extract[%d]", prefix, j)
+			if !variableNameRegex.MatchString(extract.Name) {
+				return fmt.Errorf("%s.name must be a valid identifier", extractPrefix)
+			}
+			if extract.Key == "" {
+				return fmt.Errorf("%s.key is required", extractPrefix)
+			}
+			switch extract.Source {
+			case models.ExtractSourceCookie, models.ExtractSourceHeader, models.ExtractSourceJSON:
+			case models.ExtractSourceRegex:
+				re, err := regexp.Compile(extract.Key)
+				if err != nil {
+					return fmt.Errorf("%s.key is not a valid regex: %v", extractPrefix, err)
+				}
+				// Omitted (0) means group 1, as when the step runs
+				group := extract.Group
+				if group == 0 {
+					group = 1
+				}
+				if group < 0 || group > re.NumSubexp() {
+					return fmt.Errorf("%s.group is out of range (the regex needs a capture group)", extractPrefix)
+				}
+			default:
+				return fmt.Errorf("%s.source must be one of cookie, header, json, regex", extractPrefix)
+			}
+		}
+
+		if step.Assert != nil {
+			for _, code := range step.Assert.Status {
+				if code < 100 || code > 599 {
+					return fmt.Errorf("%s.assert.status contains an invalid status code: %d", prefix, code)
+				}
+			}
+			if step.Assert.BodyRegex != "" {
+				if _, err := regexp.Compile(step.Assert.BodyRegex); err != nil {
+					return fmt.Errorf("%s.assert.body_regex is not a valid regex: %v", prefix, err)
+				}
+			}
+		}
+	}
+
+	return nil
+}
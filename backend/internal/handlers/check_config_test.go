@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"testing"
+
+	"github.com/nimbus/backend/internal/models"
+)
+
+func TestValidateCheckConfig(t *testing.T) {
+	validStep := models.TransactionStep{Name: "home", URL: "/"}
+
+	tests := []struct {
+		name    string
+		config  models.CheckConfig
+		wantErr bool
+	}{
+		{"Empty config", models.CheckConfig{}, false},
+		{"Fingerprint headers", models.CheckConfig{
+			Fingerprint: &models.FingerprintConfig{Enabled: true, Headers: []string{"Server", "X-Powered-By"}},
+		}, false},
+		{"Invalid fingerprint header", models.CheckConfig{
+			Fingerprint: &models.FingerprintConfig{Enabled: true, Headers: []string{"Bad Header"}},
+		}, true},
+		{"Unknown check type", models.CheckConfig{Type: "tcp"}, true},
+		{"Dual-stack address family", models.CheckConfig{AddressFamily: models.AddressFamilyBoth}, false},
+		{"Unknown address family", models.CheckConfig{AddressFamily: "ipv5"}, true},
+		{"Custom DNS server", models.CheckConfig{DNS: &models.DNSConfig{Server: "192.168.1.1"}}, false},
+		{"DNS over TLS", models.CheckConfig{
+			DNS: &models.DNSConfig{Server: "dns.lan:853", Protocol: models.DNSProtocolTLS, TLSServerName: "dns.lan"},
+		}, false},
+		{"Unknown DNS protocol", models.CheckConfig{DNS: &models.DNSConfig{Server: "192.168.1.1", Protocol: "doh"}}, true},
+		{"TLS options with fresh connections", models.CheckConfig{
+			TLS: &models.TLSConfig{ServerName: "nas.internal", MinVersion: models.TLSVersion13}, FreshConnection: true,
+		}, false},
+		{"Unknown TLS version", models.CheckConfig{TLS: &models.TLSConfig{MinVersion: "1.1"}}, true},
+		{"Transaction without steps", models.CheckConfig{
+			Type: models.CheckTypeTransaction, Transaction: &models.TransactionConfig{},
+		}, true},
+		{"Valid transaction", models.CheckConfig{
+			Type: models.CheckTypeTransaction,
+			Transaction: &models.TransactionConfig{Steps: []models.TransactionStep{
+				validStep,
+				{
+					Method:  "POST",
+					URL:     "https://auth.example.com/login?next={{next}}",
+					Headers: map[string]string{"Authorization": "Bearer {{token}}"},
+					Extract: []models.TransactionExtract{
+						{Name: "token", Source: models.ExtractSourceRegex, Key: `token=(\w+)`, Group: 1},
+					},
+					Assert: &models.TransactionAssert{Status: []int{200, 302}, BodyRegex: `ok|done`},
+				},
+			}},
+		}, false},
+		{"Unsupported method", models.CheckConfig{
+			Type:        models.CheckTypeTransaction,
+			Transaction: &models.TransactionConfig{Steps: []models.TransactionStep{{Method: "CONNECT", URL: "/"}}},
+		}, true},
+		{"Non-HTTP URL", models.CheckConfig{
+			Type:        models.CheckTypeTransaction,
+			Transaction: &models.TransactionConfig{Steps: []models.TransactionStep{{URL: "ftp://example.com/"}}},
+		}, true},
+		{"Invalid variable name", models.CheckConfig{
+			Type: models.CheckTypeTransaction,
+			Transaction: &models.TransactionConfig{Steps: []models.TransactionStep{{
+				URL:     "/",
+				Extract: []models.TransactionExtract{{Name: "1abc", Source: models.ExtractSourceCookie, Key: "sid"}},
+			}}},
+		}, true},
+		{"Unknown extract source", models.CheckConfig{
+			Type: models.CheckTypeTransaction,
+			Transaction: &models.TransactionConfig{Steps: []models.TransactionStep{{
+				URL:     "/",
+				Extract: []models.TransactionExtract{{Name: "x", Source: "xpath", Key: "//a"}},
+			}}},
+		}, true},
+		{"Regex group out of range", models.CheckConfig{
+			Type: models.CheckTypeTransaction,
+			Transaction: &models.TransactionConfig{Steps: []models.TransactionStep{{
+				URL:     "/",
+				Extract: []models.TransactionExtract{{Name: "x", Source: models.ExtractSourceRegex, Key: `(a)`, Group: 2}},
+			}}},
+		}, true},
+		{"Regex without a group for the default group", models.CheckConfig{
+			Type: models.CheckTypeTransaction,
+			Transaction: &models.TransactionConfig{Steps: []models.TransactionStep{{
+				URL:     "/",
+				Extract: []models.TransactionExtract{{Name: "x", Source: models.ExtractSourceRegex, Key: `token=\w+`}},
+			}}},
+		}, true},
+		{"Invalid assert status", models.CheckConfig{
+			Type: models.CheckTypeTransaction,
+			Transaction: &models.TransactionConfig{Steps: []models.TransactionStep{{
+				URL:    "/",
+				Assert: &models.TransactionAssert{Status: []int{999}},
+			}}},
+		}, true},
+		{"Invalid body regex", models.CheckConfig{
+			Type: models.CheckTypeTransaction,
+			Transaction: &models.TransactionConfig{Steps: []models.TransactionStep{{
+				URL:    "/",
+				Assert: &models.TransactionAssert{BodyRegex: `(unclosed`},
+			}}},
+		}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateCheckConfig(&tt.config)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateCheckConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
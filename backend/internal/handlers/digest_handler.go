@@ -0,0 +1,46 @@
+package handlers
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/nimbus/backend/internal/services"
+)
+
+type DigestHandler struct {
+	digestService *services.DigestService
+}
+
+func NewDigestHandler(digestService *services.DigestService) *DigestHandler {
+	return &DigestHandler{
+		digestService: digestService,
+	}
+}
+
+// GetPreview returns the digest report the user would receive now
+// GET /api/v1/digest/preview?format=json|html|markdown
+func (h *DigestHandler) GetPreview(c *fiber.Ctx) error {
+	userID, err := getUserID(c)
+	if err != nil {
+		return Unauthorized(c, "Unauthorized")
+	}
+
+	format := c.Query("format", "json")
+	if format != "json" && format != "html" && format != "markdown" {
+		return BadRequest(c, "format must be json, html or markdown")
+	}
+
+	preview, err := h.digestService.Preview(c.Context(), userID)
+	if err != nil {
+		return InternalError(c, "Failed to build digest report")
+	}
+
+	switch format {
+	case "html":
+		c.Set(fiber.HeaderContentType, fiber.MIMETextHTMLCharsetUTF8)
+		return c.SendString(preview.HTML)
+	case "markdown":
+		c.Set(fiber.HeaderContentType, "text/markdown; charset=utf-8")
+		return c.SendString(preview.Markdown)
+	default:
+		return Success(c, preview.Report)
+	}
+}
@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/nimbus/backend/internal/models"
+	"github.com/nimbus/backend/internal/services"
+)
+
+type DomainHandler struct {
+	domainService *services.DomainMonitorService
+}
+
+func NewDomainHandler(domainService *services.DomainMonitorService) *DomainHandler {
+	return &DomainHandler{
+		domainService: domainService,
+	}
+}
+
+// GetDomains returns registration expiry status for the user's service and manually listed domains
+// GET /api/v1/domains
+func (h *DomainHandler) GetDomains(c *fiber.Ctx) error {
+	userID, err := getUserID(c)
+	if err != nil {
+		return Unauthorized(c, "Unauthorized")
+	}
+
+	domains, err := h.domainService.GetDomainStatuses(c.Context(), userID)
+	if err != nil {
+		return InternalError(c, "Failed to retrieve domains")
+	}
+
+	return Success(c, fiber.Map{
+		"domains":       domains,
+		"count":         len(domains),
+		"warning_days":  h.domainService.WarningDays(),
+		"critical_days": h.domainService.CriticalDays(),
+	})
+}
+
+// AddDomain adds a domain to the user's monitored list
+// POST /api/v1/domains
+func (h *DomainHandler) AddDomain(c *fiber.Ctx) error {
+	userID, err := getUserID(c)
+	if err != nil {
+		return Unauthorized(c, "Unauthorized")
+	}
+
+	var req models.MonitoredDomainCreateRequest
+	if err := c.BodyParser(&req); err != nil {
+		return BadRequest(c, "Invalid request body")
+	}
+
+	status, err := h.domainService.AddMonitoredDomain(c.Context(), userID, req.Domain)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidDomain):
+			return BadRequest(c, "Invalid domain: must be a publicly registrable domain name")
+		case errors.Is(err, services.ErrDomainAlreadyMonitored):
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"error": "Domain is already monitored",
+			})
+		default:
+			return InternalError(c, "Failed to add domain")
+		}
+	}
+
+	return Created(c, status)
+}
+
+// DeleteDomain removes a manually added domain
+// DELETE /api/v1/domains/:id
+func (h *DomainHandler) DeleteDomain(c *fiber.Ctx) error {
+	userID, err := getUserID(c)
+	if err != nil {
+		return Unauthorized(c, "Unauthorized")
+	}
+
+	id := c.Params("id")
+	if id == "" {
+		return BadRequest(c, "Domain ID is required")
+	}
+
+	if err := h.domainService.DeleteMonitoredDomain(c.Context(), id, userID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return NotFound(c, "Domain not found")
+		}
+		return InternalError(c, "Failed to delete domain")
+	}
+
+	return Success(c, fiber.Map{
+		"message": "Domain removed successfully",
+	})
+}
@@ -0,0 +1,49 @@
+package handlers
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/nimbus/backend/internal/models"
+	"github.com/nimbus/backend/internal/services"
+)
+
+type EgressPolicyHandler struct {
+	egressService *services.EgressPolicyService
+}
+
+func NewEgressPolicyHandler(egressService *services.EgressPolicyService) *EgressPolicyHandler {
+	return &EgressPolicyHandler{
+		egressService: egressService,
+	}
+}
+
+// GetPolicy returns the egress policy currently being enforced (admin only)
+// GET /api/v1/admin/egress-policy
+func (h *EgressPolicyHandler) GetPolicy(c *fiber.Ctx) error {
+	return Success(c, h.egressService.Policy())
+}
+
+// UpdatePolicy replaces the egress policy (admin only)
+// PUT /api/v1/admin/egress-policy
+func (h *EgressPolicyHandler) UpdatePolicy(c *fiber.Ctx) error {
+	userID, err := getUserID(c)
+	if err != nil {
+		return Unauthorized(c, "Unauthorized")
+	}
+
+	var policy models.EgressPolicy
+	if err := c.BodyParser(&policy); err != nil {
+		return BadRequest(c, "Invalid request body")
+	}
+
+	if err := h.egressService.Update(c.Context(), policy, &userID); err != nil {
+		if errors.Is(err, services.ErrInvalidEgressPolicy) {
+			return BadRequest(c, strings.TrimPrefix(err.Error(), services.ErrInvalidEgressPolicy.Error()+": "))
+		}
+		return InternalError(c, "Failed to update egress policy")
+	}
+
+	return Success(c, h.egressService.Policy())
+}
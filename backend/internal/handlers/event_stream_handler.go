@@ -0,0 +1,123 @@
+package handlers
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/nimbus/backend/internal/services"
+)
+
+// Default interval between keep-alive comments on an idle stream
+const DefaultEventStreamHeartbeat = 15 * time.Second
+
+// eventStreamRetry is the reconnect delay suggested to EventSource clients, in milliseconds
+const eventStreamRetry = 3000
+
+// EventStreamHandler streams live service events to the browser as Server-Sent Events
+type EventStreamHandler struct {
+	broker    *services.EventBroker
+	heartbeat time.Duration
+}
+
+func NewEventStreamHandler(broker *services.EventBroker, heartbeat time.Duration) *EventStreamHandler {
+	if heartbeat <= 0 {
+		heartbeat = DefaultEventStreamHeartbeat
+	}
+	return &EventStreamHandler{
+		broker:    broker,
+		heartbeat: heartbeat,
+	}
+}
+
+// Stream pushes status changes and service create/update/delete events for the user's services
+// GET /api/v1/events/stream
+// A reconnecting client sends Last-Event-ID (or ?last_event_id=) and gets the events it missed;
+// if they are no longer available a "resync" event tells it to reload its services instead
+func (h *EventStreamHandler) Stream(c *fiber.Ctx) error {
+	userID, err := getUserID(c)
+	if err != nil {
+		return Unauthorized(c, "Unauthorized")
+	}
+
+	lastEventID, err := parseLastEventID(c)
+	if err != nil {
+		return BadRequest(c, "Invalid Last-Event-ID")
+	}
+
+	sub, replay, complete := h.broker.Subscribe(userID, lastEventID)
+
+	c.Set(fiber.HeaderContentType, "text/event-stream")
+	c.Set(fiber.HeaderCacheControl, "no-cache")
+	c.Set(fiber.HeaderConnection, "keep-alive")
+	c.Set("X-Accel-Buffering", "no") // Keep nginx from buffering the stream
+
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		defer sub.Unsubscribe()
+
+		fmt.Fprintf(w, "retry: %d\n\n", eventStreamRetry)
+		if !complete {
+			fmt.Fprint(w, "event: resync\ndata: {}\n\n")
+		}
+		for _, event := range replay {
+			if err := writeEvent(w, event); err != nil {
+				return
+			}
+		}
+		if err := w.Flush(); err != nil {
+			return
+		}
+
+		ticker := time.NewTicker(h.heartbeat)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case event, ok := <-sub.Events:
+				if !ok {
+					// Dropped for falling behind, or the server is shutting down
+					return
+				}
+				if err := writeEvent(w, event); err != nil {
+					return
+				}
+			case <-ticker.C:
+				fmt.Fprint(w, ": ping\n\n")
+			}
+			// A failed flush means the client went away
+			if err := w.Flush(); err != nil {
+				return
+			}
+		}
+	})
+
+	return nil
+}
+
+// parseLastEventID reads the resume point from the Last-Event-ID header or query (0 if absent)
+func parseLastEventID(c *fiber.Ctx) (uint64, error) {
+	value := c.Get("Last-Event-ID")
+	if value == "" {
+		value = c.Query("last_event_id")
+	}
+	if value == "" {
+		return 0, nil
+	}
+	return strconv.ParseUint(value, 10, 64)
+}
+
+// writeEvent writes one event in the text/event-stream format
+func writeEvent(w *bufio.Writer, event services.Event) error {
+	data, err := json.Marshal(event.Data)
+	if err != nil {
+		// Skip the event rather than end the stream
+		log.Printf("Failed to marshal %s event: %v", event.Type, err)
+		return nil
+	}
+	_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Type, data)
+	return err
+}
@@ -0,0 +1,145 @@
+package handlers
+
+import (
+	"fmt"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/nimbus/backend/internal/services"
+)
+
+// setupEventStreamApp serves the event stream for a fixed user
+func setupEventStreamApp(broker *services.EventBroker, userID string) *fiber.App {
+	app := fiber.New()
+	handler := NewEventStreamHandler(broker, time.Hour)
+	app.Get("/events/stream", func(c *fiber.Ctx) error {
+		if userID != "" {
+			c.Locals("user_id", userID)
+		}
+		return c.Next()
+	}, handler.Stream)
+	return app
+}
+
+// closeWhenSubscribed closes the broker once a client is connected, ending its stream
+func closeWhenSubscribed(broker *services.EventBroker, publish func()) {
+	go func() {
+		for broker.SubscriberCount() == 0 {
+			time.Sleep(5 * time.Millisecond)
+		}
+		if publish != nil {
+			publish()
+		}
+		broker.Close()
+	}()
+}
+
+func TestEventStreamHandler_StreamsLiveEvents(t *testing.T) {
+	broker := services.NewEventBroker(10)
+	app := setupEventStreamApp(broker, "user-1")
+
+	closeWhenSubscribed(broker, func() {
+		broker.Publish("user-2", services.EventServiceCreated, "svc-2", services.ServiceDeletedEventData{ID: "svc-2"})
+		broker.Publish("user-1", services.EventServiceStatus, "svc-1", services.ServiceStatusEventData{ID: "svc-1", Status: "offline"})
+	})
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/events/stream", nil), 2000)
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Errorf("Expected text/event-stream, got %q", ct)
+	}
+
+	body, _ := io.ReadAll(resp.Body)
+	stream := string(body)
+	if !strings.HasPrefix(stream, "retry: ") {
+		t.Errorf("Expected stream to start with a retry hint, got %q", stream)
+	}
+	if !strings.Contains(stream, "event: service.status\ndata: {\"id\":\"svc-1\",\"status\":\"offline\"") {
+		t.Errorf("Missing status event in %q", stream)
+	}
+	if strings.Contains(stream, "svc-2") {
+		t.Errorf("Stream leaked another user's event: %q", stream)
+	}
+	if strings.Contains(stream, "event: resync") {
+		t.Errorf("Unexpected resync on a fresh connection: %q", stream)
+	}
+}
+
+func TestEventStreamHandler_ResumesFromLastEventID(t *testing.T) {
+	broker := services.NewEventBroker(10)
+	broker.Publish("user-1", services.EventServiceCreated, "svc-1", services.ServiceDeletedEventData{ID: "svc-1"})
+	sub, replay, _ := broker.Subscribe("user-1", 1)
+	sub.Unsubscribe()
+	if len(replay) != 1 {
+		t.Fatalf("Expected the published event in history, got %d", len(replay))
+	}
+	lastID := replay[0].ID
+	broker.Publish("user-1", services.EventServiceDeleted, "svc-1", services.ServiceDeletedEventData{ID: "svc-1"})
+
+	app := setupEventStreamApp(broker, "user-1")
+	closeWhenSubscribed(broker, nil)
+
+	req := httptest.NewRequest("GET", "/events/stream", nil)
+	req.Header.Set("Last-Event-ID", fmt.Sprint(lastID))
+	resp, err := app.Test(req, 2000)
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+
+	body, _ := io.ReadAll(resp.Body)
+	stream := string(body)
+	if strings.Contains(stream, "event: service.created") {
+		t.Errorf("Replayed an event the client already saw: %q", stream)
+	}
+	if !strings.Contains(stream, fmt.Sprintf("id: %d\nevent: service.deleted\n", lastID+1)) {
+		t.Errorf("Missing replayed delete event in %q", stream)
+	}
+	if strings.Contains(stream, "event: resync") {
+		t.Errorf("Unexpected resync for a complete replay: %q", stream)
+	}
+}
+
+func TestEventStreamHandler_ResyncForUnknownEventID(t *testing.T) {
+	broker := services.NewEventBroker(10)
+	app := setupEventStreamApp(broker, "user-1")
+	closeWhenSubscribed(broker, nil)
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/events/stream?last_event_id=1", nil), 2000)
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+
+	body, _ := io.ReadAll(resp.Body)
+	if !strings.Contains(string(body), "event: resync\n") {
+		t.Errorf("Expected resync event, got %q", body)
+	}
+}
+
+func TestEventStreamHandler_Errors(t *testing.T) {
+	broker := services.NewEventBroker(10)
+	defer broker.Close()
+
+	resp, err := setupEventStreamApp(broker, "").Test(httptest.NewRequest("GET", "/events/stream", nil))
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	if resp.StatusCode != fiber.StatusUnauthorized {
+		t.Errorf("Expected 401 without a user, got %d", resp.StatusCode)
+	}
+
+	req := httptest.NewRequest("GET", "/events/stream", nil)
+	req.Header.Set("Last-Event-ID", "not-a-number")
+	resp, err = setupEventStreamApp(broker, "user-1").Test(req)
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	if resp.StatusCode != fiber.StatusBadRequest {
+		t.Errorf("Expected 400 for an invalid Last-Event-ID, got %d", resp.StatusCode)
+	}
+}
@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/nimbus/backend/internal/models"
+	"github.com/nimbus/backend/internal/repository"
+)
+
+type FingerprintHandler struct {
+	fingerprintRepo *repository.FingerprintRepository
+	serviceRepo     repository.ServiceRepositoryInterface
+}
+
+func NewFingerprintHandler(fingerprintRepo *repository.FingerprintRepository, serviceRepo repository.ServiceRepositoryInterface) *FingerprintHandler {
+	return &FingerprintHandler{
+		fingerprintRepo: fingerprintRepo,
+		serviceRepo:     serviceRepo,
+	}
+}
+
+// GetFingerprints retrieves the response fingerprint history for a service
+// GET /api/v1/services/:id/fingerprints
+func (h *FingerprintHandler) GetFingerprints(c *fiber.Ctx) error {
+	serviceID := c.Params("id")
+	if serviceID == "" {
+		return BadRequest(c, "Service ID is required")
+	}
+
+	// Get authenticated user
+	userID, err := getUserID(c)
+	if err != nil {
+		return Unauthorized(c, "Unauthorized")
+	}
+
+	// Verify service belongs to user
+	service, err := h.serviceRepo.GetByID(c.Context(), serviceID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return NotFound(c, "Service not found")
+		}
+		return InternalError(c, "Failed to retrieve service")
+	}
+
+	if service.UserID != userID {
+		return Forbidden(c, "Access denied")
+	}
+
+	// Parse limit from query
+	limit, err := strconv.Atoi(c.Query("limit", "50"))
+	if err != nil || limit < 1 || limit > 500 {
+		limit = 50
+	}
+
+	fingerprints, err := h.fingerprintRepo.GetByServiceID(c.Context(), serviceID, limit)
+	if err != nil {
+		return InternalError(c, "Failed to retrieve fingerprints")
+	}
+
+	responses := make([]models.ServiceFingerprintResponse, len(fingerprints))
+	for i, fp := range fingerprints {
+		responses[i] = fp.ToResponse()
+	}
+
+	return Success(c, fiber.Map{
+		"fingerprints": responses,
+		"count":        len(responses),
+	})
+}
@@ -15,3 +15,9 @@ func getUserID(c *fiber.Ctx) (string, error) {
 	}
 	return userID, nil
 }
+
+// isAdmin reports whether the authenticated user has the admin role
+func isAdmin(c *fiber.Ctx) bool {
+	role, ok := c.Locals("role").(string)
+	return ok && role == "admin"
+}
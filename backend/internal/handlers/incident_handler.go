@@ -0,0 +1,208 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/nimbus/backend/internal/models"
+	"github.com/nimbus/backend/internal/services"
+)
+
+type IncidentHandler struct {
+	incidentService *services.IncidentService
+}
+
+func NewIncidentHandler(incidentService *services.IncidentService) *IncidentHandler {
+	return &IncidentHandler{
+		incidentService: incidentService,
+	}
+}
+
+// incidentError maps incident service errors to responses
+func incidentError(c *fiber.Ctx, err error, action string) error {
+	switch {
+	case errors.Is(err, services.ErrInvalidIncident):
+		return BadRequest(c, strings.TrimPrefix(err.Error(), services.ErrInvalidIncident.Error()+": "))
+	case errors.Is(err, services.ErrIncidentResolved):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": "Incident is already resolved",
+		})
+	case errors.Is(err, sql.ErrNoRows):
+		return NotFound(c, "Incident not found")
+	default:
+		return InternalError(c, "Failed to "+action)
+	}
+}
+
+// GetIncidents returns the user's incidents, newest first
+// GET /api/v1/incidents?status=active&limit=50
+func (h *IncidentHandler) GetIncidents(c *fiber.Ctx) error {
+	userID, err := getUserID(c)
+	if err != nil {
+		return Unauthorized(c, "Unauthorized")
+	}
+
+	limit, err := strconv.Atoi(c.Query("limit", strconv.Itoa(services.DefaultIncidentListLimit)))
+	if err != nil || limit < 1 || limit > services.MaxIncidentListLimit {
+		limit = services.DefaultIncidentListLimit
+	}
+
+	incidents, err := h.incidentService.List(c.Context(), userID, c.Query("status"), limit)
+	if err != nil {
+		return incidentError(c, err, "retrieve incidents")
+	}
+
+	return Success(c, fiber.Map{
+		"incidents": incidents,
+		"count":     len(incidents),
+	})
+}
+
+// CreateIncident opens an incident manually
+// POST /api/v1/incidents
+func (h *IncidentHandler) CreateIncident(c *fiber.Ctx) error {
+	userID, err := getUserID(c)
+	if err != nil {
+		return Unauthorized(c, "Unauthorized")
+	}
+
+	var req models.CreateIncidentRequest
+	if err := c.BodyParser(&req); err != nil {
+		return BadRequest(c, "Invalid request body")
+	}
+
+	incident, err := h.incidentService.Create(c.Context(), userID, &req)
+	if err != nil {
+		return incidentError(c, err, "create incident")
+	}
+
+	return Created(c, incident)
+}
+
+// GetIncident returns an incident with its notes
+// GET /api/v1/incidents/:id
+func (h *IncidentHandler) GetIncident(c *fiber.Ctx) error {
+	userID, err := getUserID(c)
+	if err != nil {
+		return Unauthorized(c, "Unauthorized")
+	}
+
+	incident, err := h.incidentService.Get(c.Context(), userID, c.Params("id"))
+	if err != nil {
+		return incidentError(c, err, "retrieve incident")
+	}
+
+	return Success(c, incident)
+}
+
+// GetTimeline returns an incident's timeline, merged from status events, notes and acknowledgements
+// GET /api/v1/incidents/:id/timeline
+func (h *IncidentHandler) GetTimeline(c *fiber.Ctx) error {
+	userID, err := getUserID(c)
+	if err != nil {
+		return Unauthorized(c, "Unauthorized")
+	}
+
+	timeline, err := h.incidentService.Timeline(c.Context(), userID, c.Params("id"))
+	if err != nil {
+		return incidentError(c, err, "retrieve incident timeline")
+	}
+
+	return Success(c, fiber.Map{
+		"timeline": timeline,
+		"count":    len(timeline),
+	})
+}
+
+// AcknowledgeIncident marks an incident as acknowledged, silencing alert escalation
+// POST /api/v1/incidents/:id/acknowledge
+func (h *IncidentHandler) AcknowledgeIncident(c *fiber.Ctx) error {
+	userID, err := getUserID(c)
+	if err != nil {
+		return Unauthorized(c, "Unauthorized")
+	}
+
+	incident, err := h.incidentService.Acknowledge(c.Context(), userID, c.Params("id"), c.IP())
+	if err != nil {
+		return incidentError(c, err, "acknowledge incident")
+	}
+
+	return Success(c, incident)
+}
+
+// ResolveIncident marks an incident as resolved
+// POST /api/v1/incidents/:id/resolve
+func (h *IncidentHandler) ResolveIncident(c *fiber.Ctx) error {
+	userID, err := getUserID(c)
+	if err != nil {
+		return Unauthorized(c, "Unauthorized")
+	}
+
+	incident, err := h.incidentService.Resolve(c.Context(), userID, c.Params("id"))
+	if err != nil {
+		return incidentError(c, err, "resolve incident")
+	}
+
+	return Success(c, incident)
+}
+
+// AddNote adds a timestamped note to an incident
+// POST /api/v1/incidents/:id/notes
+func (h *IncidentHandler) AddNote(c *fiber.Ctx) error {
+	userID, err := getUserID(c)
+	if err != nil {
+		return Unauthorized(c, "Unauthorized")
+	}
+
+	var req models.IncidentNoteRequest
+	if err := c.BodyParser(&req); err != nil {
+		return BadRequest(c, "Invalid request body")
+	}
+
+	note, err := h.incidentService.AddNote(c.Context(), userID, c.Params("id"), req.Body, c.IP())
+	if err != nil {
+		return incidentError(c, err, "add incident note")
+	}
+
+	return Created(c, note)
+}
+
+// LinkServices adds affected services to an incident
+// POST /api/v1/incidents/:id/services
+func (h *IncidentHandler) LinkServices(c *fiber.Ctx) error {
+	userID, err := getUserID(c)
+	if err != nil {
+		return Unauthorized(c, "Unauthorized")
+	}
+
+	var req models.IncidentServicesRequest
+	if err := c.BodyParser(&req); err != nil {
+		return BadRequest(c, "Invalid request body")
+	}
+
+	incident, err := h.incidentService.LinkServices(c.Context(), userID, c.Params("id"), req.ServiceIDs)
+	if err != nil {
+		return incidentError(c, err, "link incident services")
+	}
+
+	return Success(c, incident)
+}
+
+// UnlinkService removes a service from an incident
+// DELETE /api/v1/incidents/:id/services/:serviceId
+func (h *IncidentHandler) UnlinkService(c *fiber.Ctx) error {
+	userID, err := getUserID(c)
+	if err != nil {
+		return Unauthorized(c, "Unauthorized")
+	}
+
+	incident, err := h.incidentService.UnlinkService(c.Context(), userID, c.Params("id"), c.Params("serviceId"))
+	if err != nil {
+		return incidentError(c, err, "unlink incident service")
+	}
+
+	return Success(c, incident)
+}
@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/nimbus/backend/internal/models"
+	"github.com/nimbus/backend/internal/repository"
+	"github.com/nimbus/backend/internal/services"
+)
+
+type InvitationHandler struct {
+	invitationService *services.InvitationService
+}
+
+func NewInvitationHandler(invitationService *services.InvitationService) *InvitationHandler {
+	return &InvitationHandler{
+		invitationService: invitationService,
+	}
+}
+
+// GetInvitations returns all invitations (admin only)
+// GET /api/v1/admin/invitations
+func (h *InvitationHandler) GetInvitations(c *fiber.Ctx) error {
+	invitations, err := h.invitationService.List(c.Context())
+	if err != nil {
+		return InternalError(c, "Failed to retrieve invitations")
+	}
+
+	return Success(c, fiber.Map{
+		"invitations": invitations,
+		"count":       len(invitations),
+	})
+}
+
+// CreateInvitation invites an address and emails the registration link (admin only)
+// POST /api/v1/admin/invitations
+func (h *InvitationHandler) CreateInvitation(c *fiber.Ctx) error {
+	userID, err := getUserID(c)
+	if err != nil {
+		return Unauthorized(c, "Unauthorized")
+	}
+
+	var req models.InviteUserRequest
+	if err := c.BodyParser(&req); err != nil {
+		return BadRequest(c, "Invalid request body")
+	}
+
+	response, err := h.invitationService.Invite(c.Context(), req.Email, userID, c.IP())
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidInvitation):
+			return BadRequest(c, strings.TrimPrefix(err.Error(), services.ErrInvalidInvitation.Error()+": "))
+		case errors.Is(err, services.ErrInviteeExists):
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"error": "A user with this email already exists",
+			})
+		default:
+			return InternalError(c, "Failed to create invitation")
+		}
+	}
+
+	return Created(c, response)
+}
+
+// DeleteInvitation revokes an invitation (admin only)
+// DELETE /api/v1/admin/invitations/:id
+func (h *InvitationHandler) DeleteInvitation(c *fiber.Ctx) error {
+	if err := h.invitationService.Revoke(c.Context(), c.Params("id")); err != nil {
+		if errors.Is(err, repository.ErrInvitationNotFound) {
+			return NotFound(c, "Invitation not found")
+		}
+		return InternalError(c, "Failed to delete invitation")
+	}
+
+	return Success(c, fiber.Map{
+		"message": "Invitation deleted successfully",
+	})
+}
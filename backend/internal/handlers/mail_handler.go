@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/nimbus/backend/internal/models"
+	"github.com/nimbus/backend/internal/services"
+)
+
+type MailHandler struct {
+	mailService *services.MailService
+}
+
+func NewMailHandler(mailService *services.MailService) *MailHandler {
+	return &MailHandler{
+		mailService: mailService,
+	}
+}
+
+// GetSMTPSettings returns the SMTP settings without the password (admin only)
+// GET /api/v1/admin/smtp
+func (h *MailHandler) GetSMTPSettings(c *fiber.Ctx) error {
+	return Success(c, h.mailService.Settings())
+}
+
+// UpdateSMTPSettings replaces the SMTP settings (admin only)
+// PUT /api/v1/admin/smtp
+func (h *MailHandler) UpdateSMTPSettings(c *fiber.Ctx) error {
+	userID, err := getUserID(c)
+	if err != nil {
+		return Unauthorized(c, "Unauthorized")
+	}
+
+	var settings models.SMTPSettings
+	if err := c.BodyParser(&settings); err != nil {
+		return BadRequest(c, "Invalid request body")
+	}
+
+	if err := h.mailService.Update(c.Context(), settings, &userID); err != nil {
+		if errors.Is(err, services.ErrInvalidSMTPSettings) {
+			return BadRequest(c, strings.TrimPrefix(err.Error(), services.ErrInvalidSMTPSettings.Error()+": "))
+		}
+		return InternalError(c, "Failed to update SMTP settings")
+	}
+
+	return Success(c, h.mailService.Settings())
+}
+
+// SendTestEmail sends a test email with the saved settings, to the admin unless another address is given
+// POST /api/v1/admin/smtp/test
+func (h *MailHandler) SendTestEmail(c *fiber.Ctx) error {
+	var req models.SendTestEmailRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return BadRequest(c, "Invalid request body")
+		}
+	}
+
+	to := strings.TrimSpace(req.To)
+	if to == "" {
+		to, _ = c.Locals("email").(string)
+	}
+	if to == "" {
+		return BadRequest(c, "Recipient address is required")
+	}
+
+	if err := h.mailService.SendTest(c.Context(), to); err != nil {
+		if errors.Is(err, services.ErrMailNotConfigured) {
+			return BadRequest(c, "Email is not enabled")
+		}
+		// Report the server's reply so admins can fix their settings
+		return c.Status(fiber.StatusBadGateway).JSON(fiber.Map{
+			"error": "Failed to send test email: " + err.Error(),
+		})
+	}
+
+	return Success(c, fiber.Map{
+		"message": "Test email sent to " + to,
+	})
+}
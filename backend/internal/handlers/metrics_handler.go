@@ -1,8 +1,10 @@
 package handlers
 
 import (
+	"fmt"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
@@ -23,7 +25,7 @@ func NewMetricsHandler(metricsService *services.MetricsService, serviceRepo repo
 }
 
 // GetServiceMetrics retrieves metrics for a specific service
-// GET /api/v1/metrics/:serviceID
+// GET /api/v1/metrics/:serviceID?range=24h&interval=5 or ?start=...&end=...&interval=1h&tz=Europe/Amsterdam
 func (h *MetricsHandler) GetServiceMetrics(c *fiber.Ctx) error {
 	serviceID := c.Params("id")
 	if serviceID == "" {
@@ -47,40 +49,167 @@ func (h *MetricsHandler) GetServiceMetrics(c *fiber.Ctx) error {
 	}
 
 	// Parse query parameters
-	timeRange := c.Query("range", "24h")    // 1h, 6h, 24h, 7d, 30d
-	intervalStr := c.Query("interval", "5") // interval in minutes
+	startTime, endTime, err := parseMetricsTimeRange(c, time.Now())
+	if err != nil {
+		return BadRequest(c, err.Error())
+	}
+
+	interval, err := parseMetricsInterval(c.Query("interval", "5"))
+	if err != nil {
+		return BadRequest(c, err.Error())
+	}
+
+	loc, err := time.LoadLocation(c.Query("tz", "UTC"))
+	if err != nil {
+		return BadRequest(c, "Invalid timezone")
+	}
 
-	interval, err := strconv.Atoi(intervalStr)
-	if err != nil || interval < 1 {
-		interval = 5
+	// Get metrics
+	metrics, err := h.metricsService.GetServiceMetrics(c.Context(), serviceID, startTime, endTime, interval, loc)
+	if err != nil {
+		return InternalError(c, "Failed to retrieve metrics")
 	}
 
-	// Calculate start and end times based on range
-	endTime := time.Now()
-	var startTime time.Time
+	return Success(c, metrics)
+}
+
+// maxMetricsRange limits custom start/end ranges
+const maxMetricsRange = 5 * 366 * 24 * time.Hour
+
+// parseMetricsTimeRange reads start/end (RFC 3339) or a relative range (1h, 6h, 24h, 7d, 30d) from the query
+// end defaults to now; without start, the range (default 24h) is counted back from end
+func parseMetricsTimeRange(c *fiber.Ctx, now time.Time) (time.Time, time.Time, error) {
+	endTime := now
+	if raw := c.Query("end"); raw != "" {
+		parsed, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid end time, expected RFC 3339 (e.g. 2026-10-18T00:00:00Z)")
+		}
+		endTime = parsed
+	}
+
+	raw := c.Query("start")
+	if raw == "" {
+		return parseMetricsRange(c.Query("range", "24h"), endTime), endTime, nil
+	}
+
+	startTime, err := time.Parse(time.RFC3339, raw)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid start time, expected RFC 3339 (e.g. 2026-10-17T00:00:00Z)")
+	}
+	if !startTime.Before(endTime) {
+		return time.Time{}, time.Time{}, fmt.Errorf("start time must be before end time")
+	}
+	if endTime.Sub(startTime) > maxMetricsRange {
+		return time.Time{}, time.Time{}, fmt.Errorf("time range must not exceed 5 years")
+	}
 
+	return startTime, endTime, nil
+}
+
+// parseMetricsRange calculates the start of a relative range (1h, 6h, 24h, 7d, 30d) ending at end
+// Unknown ranges default to 24h
+func parseMetricsRange(timeRange string, end time.Time) time.Time {
 	switch timeRange {
 	case "1h":
-		startTime = endTime.Add(-1 * time.Hour)
+		return end.Add(-1 * time.Hour)
 	case "6h":
-		startTime = endTime.Add(-6 * time.Hour)
-	case "24h":
-		startTime = endTime.Add(-24 * time.Hour)
+		return end.Add(-6 * time.Hour)
 	case "7d":
-		startTime = endTime.AddDate(0, 0, -7)
+		return end.AddDate(0, 0, -7)
 	case "30d":
-		startTime = endTime.AddDate(0, 0, -30)
+		return end.AddDate(0, 0, -30)
 	default:
-		startTime = endTime.Add(-24 * time.Hour)
+		return end.Add(-24 * time.Hour)
 	}
+}
 
-	// Get metrics
-	metrics, err := h.metricsService.GetServiceMetrics(c.Context(), serviceID, startTime, endTime, interval)
+// parseMetricsInterval parses a bucket width as minutes ("5") or a duration ("90m", "6h", "1d")
+func parseMetricsInterval(raw string) (int, error) {
+	if minutes, err := strconv.Atoi(raw); err == nil {
+		if minutes < 1 {
+			return 0, fmt.Errorf("interval must be at least 1 minute")
+		}
+		return minutes, nil
+	}
+
+	if days, found := strings.CutSuffix(raw, "d"); found {
+		if n, err := strconv.Atoi(days); err == nil && n >= 1 {
+			return n * 24 * 60, nil
+		}
+		return 0, fmt.Errorf("invalid interval: %q", raw)
+	}
+
+	duration, err := time.ParseDuration(raw)
+	if err != nil || duration < time.Minute || duration%time.Minute != 0 {
+		return 0, fmt.Errorf("invalid interval: %q (use whole minutes, e.g. 5, 90m, 6h or 1d)", raw)
+	}
+	return int(duration / time.Minute), nil
+}
+
+// GetLatencyHistogram retrieves the response time distribution of a service
+// GET /api/v1/metrics/:id/histogram?range=24h&buckets=100,250,500 (or start/end like GetServiceMetrics)
+func (h *MetricsHandler) GetLatencyHistogram(c *fiber.Ctx) error {
+	serviceID := c.Params("id")
+	if serviceID == "" {
+		return BadRequest(c, "Service ID is required")
+	}
+
+	// Get authenticated user
+	userID, err := getUserID(c)
 	if err != nil {
-		return InternalError(c, "Failed to retrieve metrics")
+		return Unauthorized(c, "Unauthorized")
 	}
 
-	return Success(c, metrics)
+	// Verify service belongs to user
+	service, err := h.serviceRepo.GetByID(c.Context(), serviceID)
+	if err != nil {
+		return NotFound(c, "Service not found")
+	}
+
+	if service.UserID != userID {
+		return Forbidden(c, "Access denied")
+	}
+
+	bounds, err := parseLatencyBuckets(c.Query("buckets"))
+	if err != nil {
+		return BadRequest(c, err.Error())
+	}
+
+	startTime, endTime, err := parseMetricsTimeRange(c, time.Now())
+	if err != nil {
+		return BadRequest(c, err.Error())
+	}
+
+	histogram, err := h.metricsService.GetLatencyHistogram(c.Context(), serviceID, startTime, endTime, bounds)
+	if err != nil {
+		return InternalError(c, "Failed to retrieve latency histogram")
+	}
+
+	return Success(c, histogram)
+}
+
+// parseLatencyBuckets parses comma-separated bucket upper bounds in milliseconds
+// An empty value selects services.DefaultLatencyBuckets
+func parseLatencyBuckets(raw string) ([]int, error) {
+	if strings.TrimSpace(raw) == "" {
+		return services.DefaultLatencyBuckets, nil
+	}
+
+	parts := strings.Split(raw, ",")
+	bounds := make([]int, 0, len(parts))
+	for _, part := range parts {
+		bound, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil {
+			return nil, fmt.Errorf("invalid latency bucket: %q", strings.TrimSpace(part))
+		}
+		bounds = append(bounds, bound)
+	}
+
+	if err := services.ValidateLatencyBuckets(bounds); err != nil {
+		return nil, err
+	}
+	return bounds, nil
 }
 
 // GetRecentStatusLogs retrieves recent status logs for a service
@@ -132,20 +261,53 @@ func (h *MetricsHandler) GetRecentStatusLogs(c *fiber.Ctx) error {
 	})
 }
 
-// GetPrometheusMetrics exports ALL metrics in Prometheus format (admin only, authenticated)
-// GET /api/v1/prometheus/metrics (protected, admin only)
+// validPrometheusAPIKey reports whether the request carries the PROMETHEUS_API_KEY
+// (X-API-Key header or "Authorization: Bearer <key>"), which has admin-level access
+func validPrometheusAPIKey(c *fiber.Ctx) bool {
+	apiKey := c.Get("X-API-Key")
+	if apiKey == "" {
+		// Also try Authorization header with "Bearer" format
+		authHeader := c.Get("Authorization")
+		if len(authHeader) > 7 && authHeader[:7] == "Bearer " {
+			apiKey = authHeader[7:]
+		}
+	}
+
+	// Validate API key (stored in environment variable)
+	expectedKey := os.Getenv("PROMETHEUS_API_KEY")
+	return expectedKey != "" && apiKey == expectedKey
+}
+
+// sendPrometheusMetrics writes the metrics in OpenMetrics when the scraper asks for it,
+// otherwise in the Prometheus text format
+func sendPrometheusMetrics(c *fiber.Ctx, metrics *services.PrometheusMetrics) error {
+	if strings.Contains(c.Get("Accept"), "application/openmetrics-text") {
+		c.Set("Content-Type", services.OpenMetricsTextContentType)
+		return c.SendString(services.FormatOpenMetrics(metrics))
+	}
+
+	c.Set("Content-Type", services.PrometheusTextContentType)
+	return c.SendString(services.FormatPrometheusMetrics(metrics))
+}
+
+// GetPrometheusMetrics exports ALL metrics in Prometheus format, including runtime metrics
+// (authenticated via an admin JWT or the API key)
+// GET /api/v1/prometheus/metrics
 func (h *MetricsHandler) GetPrometheusMetrics(c *fiber.Ctx) error {
+	if _, jwtAuth := c.Locals("user_id").(string); jwtAuth {
+		if role, _ := c.Locals("role").(string); role != "admin" {
+			return c.Status(fiber.StatusForbidden).SendString("# Forbidden: Admin access required\n")
+		}
+	} else if !validPrometheusAPIKey(c) {
+		return c.Status(fiber.StatusUnauthorized).SendString("# Unauthorized: Invalid or missing API key\n")
+	}
+
 	metrics, err := h.metricsService.GetPrometheusMetrics(c.Context())
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).SendString("# Error retrieving metrics\n")
 	}
 
-	// Format as Prometheus text
-	output := services.FormatPrometheusMetrics(metrics)
-
-	// Set content type for Prometheus
-	c.Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
-	return c.SendString(output)
+	return sendPrometheusMetrics(c, metrics)
 }
 
 // GetUserPrometheusMetrics exports metrics for a specific user (authenticated via JWT or API key)
@@ -163,18 +325,7 @@ func (h *MetricsHandler) GetUserPrometheusMetrics(c *fiber.Ctx) error {
 
 	// If no JWT, try API key authentication
 	if !jwtAuth {
-		apiKey := c.Get("X-API-Key")
-		if apiKey == "" {
-			// Also try Authorization header with "Bearer" format
-			authHeader := c.Get("Authorization")
-			if len(authHeader) > 7 && authHeader[:7] == "Bearer " {
-				apiKey = authHeader[7:]
-			}
-		}
-
-		// Validate API key (stored in environment variable)
-		expectedKey := os.Getenv("PROMETHEUS_API_KEY")
-		if expectedKey == "" || apiKey != expectedKey {
+		if !validPrometheusAPIKey(c) {
 			return c.Status(fiber.StatusUnauthorized).SendString("# Unauthorized: Invalid or missing API key\n")
 		}
 
@@ -194,10 +345,5 @@ func (h *MetricsHandler) GetUserPrometheusMetrics(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusInternalServerError).SendString("# Error retrieving metrics\n")
 	}
 
-	// Format as Prometheus text
-	output := services.FormatPrometheusMetrics(metrics)
-
-	// Set content type for Prometheus
-	c.Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
-	return c.SendString(output)
+	return sendPrometheusMetrics(c, metrics)
 }
@@ -11,11 +11,16 @@ package handlers
 // - Invalid/missing API keys
 // - Empty service lists
 // - Prometheus output format validation
+// - Admin endpoint authentication and OpenMetrics negotiation
+// - Latency histogram bucket parsing and metrics interval parsing
 
 import (
 	"database/sql"
+	"io"
 	"net/http/httptest"
 	"os"
+	"reflect"
+	"strings"
 	"testing"
 	"time"
 
@@ -47,6 +52,7 @@ func setupMetricsTestDB(t *testing.T) *sql.DB {
 			status TEXT NOT NULL,
 			response_time INTEGER,
 			position INTEGER DEFAULT 0,
+			check_config TEXT NOT NULL DEFAULT '{}',
 			created_at TIMESTAMP NOT NULL,
 			updated_at TIMESTAMP NOT NULL
 		);
@@ -548,3 +554,161 @@ func indexOf(s, substr string) int {
 	}
 	return -1
 }
+
+func TestGetPrometheusMetrics_AdminEndpoint(t *testing.T) {
+	db := setupMetricsTestDB(t)
+	defer db.Close()
+
+	serviceRepo := repository.NewServiceRepository(db)
+	statusLogRepo := repository.NewStatusLogRepository(db)
+	metricsService := services.NewMetricsService(statusLogRepo, serviceRepo)
+	handler := NewMetricsHandler(metricsService, serviceRepo)
+
+	testAPIKey := "test-api-key-12345"
+	os.Setenv("PROMETHEUS_API_KEY", testAPIKey)
+	defer os.Unsetenv("PROMETHEUS_API_KEY")
+
+	createTestService(t, db, &models.Service{
+		ID:        "service-1",
+		UserID:    "user-2",
+		Name:      "User 2 Service",
+		URL:       "https://example.com",
+		Icon:      "🔗",
+		Status:    models.StatusOnline,
+		Position:  0,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	})
+
+	app := fiber.New()
+
+	// Simulate JWT authentication from the X-Test-Role header
+	app.Use(func(c *fiber.Ctx) error {
+		if role := c.Get("X-Test-Role"); role != "" {
+			c.Locals("user_id", "user-1")
+			c.Locals("role", role)
+		}
+		return c.Next()
+	})
+
+	app.Get("/api/v1/prometheus/metrics", handler.GetPrometheusMetrics)
+
+	tests := []struct {
+		name                string
+		role                string
+		apiKey              string
+		accept              string
+		expectedStatus      int
+		expectedContentType string
+	}{
+		{"Valid API key", "", testAPIKey, "", 200, services.PrometheusTextContentType},
+		{"Admin JWT", "admin", "", "", 200, services.PrometheusTextContentType},
+		{"OpenMetrics negotiated", "", testAPIKey, "application/openmetrics-text; version=1.0.0,text/plain;q=0.5", 200, services.OpenMetricsTextContentType},
+		{"Non-admin JWT", "user", "", "", 403, ""},
+		{"Invalid API key", "", "wrong-key", "", 401, ""},
+		{"No credentials", "", "", "", 401, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/api/v1/prometheus/metrics", nil)
+			if tt.role != "" {
+				req.Header.Set("X-Test-Role", tt.role)
+			}
+			if tt.apiKey != "" {
+				req.Header.Set("X-API-Key", tt.apiKey)
+			}
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatalf("Failed to perform request: %v", err)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != tt.expectedStatus {
+				t.Fatalf("Expected status %d, got %d", tt.expectedStatus, resp.StatusCode)
+			}
+			if tt.expectedStatus != 200 {
+				return
+			}
+
+			if contentType := resp.Header.Get("Content-Type"); contentType != tt.expectedContentType {
+				t.Errorf("Expected content type %q, got %q", tt.expectedContentType, contentType)
+			}
+
+			body, _ := io.ReadAll(resp.Body)
+			bodyStr := string(body)
+			for _, expected := range []string{"User 2 Service", "nimbus_build_info{", "go_goroutines "} {
+				if !strings.Contains(bodyStr, expected) {
+					t.Errorf("Expected admin metrics to contain %q", expected)
+				}
+			}
+			if openMetrics := strings.HasSuffix(bodyStr, "# EOF\n"); openMetrics != (tt.accept != "") {
+				t.Errorf("Expected OpenMetrics output %v, got %v", tt.accept != "", openMetrics)
+			}
+		})
+	}
+}
+
+func TestParseLatencyBuckets(t *testing.T) {
+	tests := []struct {
+		name     string
+		raw      string
+		expected []int
+		wantErr  bool
+	}{
+		{"Default buckets", "", services.DefaultLatencyBuckets, false},
+		{"Custom buckets", "100, 250,1000", []int{100, 250, 1000}, false},
+		{"Not a number", "100,fast", nil, true},
+		{"Not ascending", "250,100", nil, true},
+		{"Duplicate bound", "100,100", nil, true},
+		{"Zero bound", "0,100", nil, true},
+		{"Too many buckets", strings.Repeat("1,", services.MaxLatencyBuckets) + "1", nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseLatencyBuckets(tt.raw)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseLatencyBuckets() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("parseLatencyBuckets() = %v, expected %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestParseMetricsInterval(t *testing.T) {
+	tests := []struct {
+		raw      string
+		expected int
+		wantErr  bool
+	}{
+		{"5", 5, false},
+		{"90m", 90, false},
+		{"6h", 360, false},
+		{"1d", 1440, false},
+		{"7d", 7 * 1440, false},
+		{"0", 0, true},
+		{"30s", 0, true},
+		{"1h30s", 0, true},
+		{"0d", 0, true},
+		{"weekly", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.raw, func(t *testing.T) {
+			got, err := parseMetricsInterval(tt.raw)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseMetricsInterval(%q) error = %v, wantErr %v", tt.raw, err, tt.wantErr)
+			}
+			if got != tt.expected {
+				t.Errorf("parseMetricsInterval(%q) = %d, expected %d", tt.raw, got, tt.expected)
+			}
+		})
+	}
+}
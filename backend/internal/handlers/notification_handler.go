@@ -0,0 +1,213 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/nimbus/backend/internal/models"
+	"github.com/nimbus/backend/internal/services"
+)
+
+type NotificationHandler struct {
+	notificationService *services.NotificationService
+}
+
+func NewNotificationHandler(notificationService *services.NotificationService) *NotificationHandler {
+	return &NotificationHandler{
+		notificationService: notificationService,
+	}
+}
+
+// notificationError maps notification service errors to responses
+func notificationError(c *fiber.Ctx, err error, action string) error {
+	switch {
+	case errors.Is(err, services.ErrInvalidNotificationChannel):
+		return BadRequest(c, strings.TrimPrefix(err.Error(), services.ErrInvalidNotificationChannel.Error()+": "))
+	case errors.Is(err, services.ErrGlobalNotificationChannel):
+		return Forbidden(c, "Only admins can manage global notification channels")
+	case errors.Is(err, sql.ErrNoRows):
+		return NotFound(c, "Notification channel not found")
+	default:
+		return InternalError(c, "Failed to "+action+" notification channel")
+	}
+}
+
+// GetChannels returns the user's notification channels and the global channels
+// GET /api/v1/notification-channels
+func (h *NotificationHandler) GetChannels(c *fiber.Ctx) error {
+	userID, err := getUserID(c)
+	if err != nil {
+		return Unauthorized(c, "Unauthorized")
+	}
+
+	channels, err := h.notificationService.List(c.Context(), userID, isAdmin(c))
+	if err != nil {
+		return InternalError(c, "Failed to retrieve notification channels")
+	}
+
+	return Success(c, fiber.Map{
+		"channels": channels,
+		"count":    len(channels),
+	})
+}
+
+// CreateChannel adds a notification channel (global channels are admin only)
+// POST /api/v1/notification-channels
+func (h *NotificationHandler) CreateChannel(c *fiber.Ctx) error {
+	userID, err := getUserID(c)
+	if err != nil {
+		return Unauthorized(c, "Unauthorized")
+	}
+
+	var req models.NotificationChannelRequest
+	if err := c.BodyParser(&req); err != nil {
+		return BadRequest(c, "Invalid request body")
+	}
+
+	channel, err := h.notificationService.Create(c.Context(), userID, isAdmin(c), &req)
+	if err != nil {
+		return notificationError(c, err, "create")
+	}
+
+	return Created(c, channel.ToResponse(true))
+}
+
+// UpdateChannel replaces a notification channel's settings
+// PUT /api/v1/notification-channels/:id
+func (h *NotificationHandler) UpdateChannel(c *fiber.Ctx) error {
+	userID, err := getUserID(c)
+	if err != nil {
+		return Unauthorized(c, "Unauthorized")
+	}
+
+	var req models.NotificationChannelRequest
+	if err := c.BodyParser(&req); err != nil {
+		return BadRequest(c, "Invalid request body")
+	}
+
+	channel, err := h.notificationService.Update(c.Context(), userID, isAdmin(c), c.Params("id"), &req)
+	if err != nil {
+		return notificationError(c, err, "update")
+	}
+
+	return Success(c, channel.ToResponse(true))
+}
+
+// DeleteChannel removes a notification channel
+// DELETE /api/v1/notification-channels/:id
+func (h *NotificationHandler) DeleteChannel(c *fiber.Ctx) error {
+	userID, err := getUserID(c)
+	if err != nil {
+		return Unauthorized(c, "Unauthorized")
+	}
+
+	if err := h.notificationService.Delete(c.Context(), userID, isAdmin(c), c.Params("id")); err != nil {
+		return notificationError(c, err, "delete")
+	}
+
+	return Success(c, fiber.Map{
+		"message": "Notification channel deleted successfully",
+	})
+}
+
+// TestChannel sends a test notification to a stored channel
+// POST /api/v1/notification-channels/:id/test
+func (h *NotificationHandler) TestChannel(c *fiber.Ctx) error {
+	userID, err := getUserID(c)
+	if err != nil {
+		return Unauthorized(c, "Unauthorized")
+	}
+
+	if err := h.notificationService.SendTest(c.Context(), userID, isAdmin(c), c.Params("id")); err != nil {
+		return testSendError(c, err)
+	}
+
+	return Success(c, fiber.Map{
+		"message": "Test notification sent",
+	})
+}
+
+// TestChannelConfig sends a test notification to a channel definition before it is saved
+// POST /api/v1/notification-channels/test
+func (h *NotificationHandler) TestChannelConfig(c *fiber.Ctx) error {
+	userID, err := getUserID(c)
+	if err != nil {
+		return Unauthorized(c, "Unauthorized")
+	}
+
+	var req models.NotificationChannelRequest
+	if err := c.BodyParser(&req); err != nil {
+		return BadRequest(c, "Invalid request body")
+	}
+
+	if err := h.notificationService.SendTestConfig(c.Context(), userID, &req); err != nil {
+		return testSendError(c, err)
+	}
+
+	return Success(c, fiber.Map{
+		"message": "Test notification sent",
+	})
+}
+
+// testSendError reports delivery failures as 502 with the provider's error, so users can fix their settings
+func testSendError(c *fiber.Ctx, err error) error {
+	if errors.Is(err, services.ErrInvalidNotificationChannel) || errors.Is(err, services.ErrGlobalNotificationChannel) || errors.Is(err, sql.ErrNoRows) {
+		return notificationError(c, err, "test")
+	}
+	return c.Status(fiber.StatusBadGateway).JSON(fiber.Map{
+		"error": "Failed to send test notification: " + err.Error(),
+	})
+}
+
+// GetServiceChannels returns the channels a service notifies
+// GET /api/v1/services/:id/notification-channels
+func (h *NotificationHandler) GetServiceChannels(c *fiber.Ctx) error {
+	userID, err := getUserID(c)
+	if err != nil {
+		return Unauthorized(c, "Unauthorized")
+	}
+
+	channelIDs, err := h.notificationService.GetServiceChannels(c.Context(), userID, c.Params("id"))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return NotFound(c, "Service not found")
+		}
+		return InternalError(c, "Failed to retrieve service notification channels")
+	}
+
+	return Success(c, fiber.Map{
+		"channel_ids": channelIDs,
+	})
+}
+
+// UpdateServiceChannels selects the channels a service notifies
+// PUT /api/v1/services/:id/notification-channels
+func (h *NotificationHandler) UpdateServiceChannels(c *fiber.Ctx) error {
+	userID, err := getUserID(c)
+	if err != nil {
+		return Unauthorized(c, "Unauthorized")
+	}
+
+	var req models.ServiceNotificationChannelsRequest
+	if err := c.BodyParser(&req); err != nil {
+		return BadRequest(c, "Invalid request body")
+	}
+
+	channelIDs, err := h.notificationService.SetServiceChannels(c.Context(), userID, c.Params("id"), req.ChannelIDs)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidNotificationChannel):
+			return BadRequest(c, strings.TrimPrefix(err.Error(), services.ErrInvalidNotificationChannel.Error()+": "))
+		case errors.Is(err, sql.ErrNoRows):
+			return NotFound(c, "Service not found")
+		default:
+			return InternalError(c, "Failed to update service notification channels")
+		}
+	}
+
+	return Success(c, fiber.Map{
+		"channel_ids": channelIDs,
+	})
+}
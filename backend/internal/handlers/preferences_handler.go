@@ -2,23 +2,27 @@ package handlers
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
 	"net/url"
+	"strings"
 	"time"
 
 	"github.com/go-playground/validator/v10"
 	"github.com/gofiber/fiber/v2"
 	"github.com/nimbus/backend/internal/models"
 	"github.com/nimbus/backend/internal/repository"
+	"github.com/nimbus/backend/internal/services"
 	"github.com/nimbus/backend/internal/utils"
 )
 
 type PreferencesHandler struct {
 	preferencesRepo *repository.PreferencesRepository
+	digestService   *services.DigestService
 	validator       *validator.Validate
 }
 
-func NewPreferencesHandler(preferencesRepo *repository.PreferencesRepository) *PreferencesHandler {
+func NewPreferencesHandler(preferencesRepo *repository.PreferencesRepository, digestService *services.DigestService) *PreferencesHandler {
 	v := validator.New()
 
 	// Register custom validator for HTTP(S) URLs only
@@ -39,6 +43,7 @@ func NewPreferencesHandler(preferencesRepo *repository.PreferencesRepository) *P
 
 	return &PreferencesHandler{
 		preferencesRepo: preferencesRepo,
+		digestService:   digestService,
 		validator:       v,
 	}
 }
@@ -60,6 +65,7 @@ func (h *PreferencesHandler) GetPreferences(c *fiber.Ctx) error {
 			ThemeMode:        "light",
 			ThemeBackground:  nil,
 			ThemeAccentColor: nil,
+			Digest:           models.DefaultDigestPreferences(),
 			UpdatedAt:        time.Time{}, // Zero value for time
 		})
 	}
@@ -158,6 +164,21 @@ func (h *PreferencesHandler) UpdatePreferences(c *fiber.Ctx) error {
 		})
 	}
 
+	// Validate the digest settings before anything is saved
+	if req.Digest != nil {
+		if err := h.digestService.Validate(c.Context(), userID, req.Digest); err != nil {
+			if errors.Is(err, services.ErrInvalidDigest) {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"error":  "Validation failed",
+					"fields": map[string]string{"digest": strings.TrimPrefix(err.Error(), services.ErrInvalidDigest.Error()+": ")},
+				})
+			}
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to update preferences",
+			})
+		}
+	}
+
 	// Upsert preferences (create if doesn't exist, update if exists)
 	if err := h.preferencesRepo.Upsert(c.Context(), userID, &req); err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
@@ -165,6 +186,14 @@ func (h *PreferencesHandler) UpdatePreferences(c *fiber.Ctx) error {
 		})
 	}
 
+	if req.Digest != nil {
+		if err := h.preferencesRepo.UpsertDigest(c.Context(), userID, req.Digest); err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to update digest preferences",
+			})
+		}
+	}
+
 	// Retrieve and return updated preferences
 	preferences, err := h.preferencesRepo.GetByUserID(c.Context(), userID)
 	if err != nil {
@@ -20,15 +20,38 @@ import (
 type ServiceHandler struct {
 	serviceRepo        *repository.ServiceRepository
 	healthCheckService *services.HealthCheckService
+	egressService      *services.EgressPolicyService
+	eventBroker        *services.EventBroker // nil unless live updates are streamed
 }
 
-func NewServiceHandler(serviceRepo *repository.ServiceRepository, healthCheckService *services.HealthCheckService) *ServiceHandler {
+func NewServiceHandler(serviceRepo *repository.ServiceRepository, healthCheckService *services.HealthCheckService, egressService *services.EgressPolicyService) *ServiceHandler {
 	return &ServiceHandler{
 		serviceRepo:        serviceRepo,
 		healthCheckService: healthCheckService,
+		egressService:      egressService,
 	}
 }
 
+// SetEventBroker pushes service creates, updates and deletes to the owner's live clients
+func (h *ServiceHandler) SetEventBroker(b *services.EventBroker) {
+	h.eventBroker = b
+}
+
+// checkIconURLEgress applies the admin egress policy to an icon URL
+// Returns a user-facing error message, or "" if the URL is allowed
+func (h *ServiceHandler) checkIconURLEgress(c *fiber.Ctx, iconURL, userID string) string {
+	if h.egressService == nil {
+		return ""
+	}
+	if err := h.egressService.CheckURL(c.Context(), iconURL, models.EgressPurposeIcon, userID); err != nil {
+		if errors.Is(err, services.ErrEgressBlocked) {
+			return "Image URL is blocked by the egress policy"
+		}
+		return fmt.Sprintf("Invalid or unsafe image URL: %s", err.Error())
+	}
+	return ""
+}
+
 // CreateService handles service creation
 func (h *ServiceHandler) CreateService(c *fiber.Ctx) error {
 	// Get user ID from context (set by auth middleware)
@@ -109,6 +132,20 @@ func (h *ServiceHandler) CreateService(c *fiber.Ctx) error {
 				"error": fmt.Sprintf("Invalid or unsafe image URL: %s", err.Error()),
 			})
 		}
+		if msg := h.checkIconURLEgress(c, iconImagePath, userID); msg != "" {
+			return BadRequest(c, msg)
+		}
+	}
+
+	// Validate optional check configuration
+	var checkConfig models.CheckConfig
+	if req.CheckConfig != nil {
+		if err := validateCheckConfig(req.CheckConfig); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": fmt.Sprintf("Invalid check_config: %s", err.Error()),
+			})
+		}
+		checkConfig = *req.CheckConfig
 	}
 
 	// Create service
@@ -121,6 +158,7 @@ func (h *ServiceHandler) CreateService(c *fiber.Ctx) error {
 		IconImagePath: iconImagePath,
 		Description:   req.Description,
 		Status:        models.StatusUnknown, // Initial status
+		CheckConfig:   checkConfig,
 		CreatedAt:     time.Now(),
 		UpdatedAt:     time.Now(),
 	}
@@ -131,8 +169,11 @@ func (h *ServiceHandler) CreateService(c *fiber.Ctx) error {
 		})
 	}
 
+	response := service.ToResponse()
+	h.eventBroker.Publish(userID, services.EventServiceCreated, service.ID, response)
+
 	// Return created service
-	return c.Status(fiber.StatusCreated).JSON(service.ToResponse())
+	return c.Status(fiber.StatusCreated).JSON(response)
 }
 
 // GetServices retrieves all services for the authenticated user
@@ -333,6 +374,18 @@ func (h *ServiceHandler) UpdateService(c *fiber.Ctx) error {
 					"error": fmt.Sprintf("Invalid or unsafe image URL: %s", err.Error()),
 				})
 			}
+			if msg := h.checkIconURLEgress(c, iconImagePath, userID); msg != "" {
+				return BadRequest(c, msg)
+			}
+		}
+	}
+
+	// Validate check configuration (preserve existing config if not provided)
+	if req.CheckConfig != nil {
+		if err := validateCheckConfig(req.CheckConfig); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": fmt.Sprintf("Invalid check_config: %s", err.Error()),
+			})
 		}
 	}
 
@@ -355,6 +408,9 @@ func (h *ServiceHandler) UpdateService(c *fiber.Ctx) error {
 	existingService.IconType = iconType
 	existingService.IconImagePath = iconImagePath
 	existingService.Description = req.Description
+	if req.CheckConfig != nil {
+		existingService.CheckConfig = *req.CheckConfig
+	}
 	existingService.UpdatedAt = time.Now()
 
 	if err := h.serviceRepo.Update(c.Context(), existingService); err != nil {
@@ -363,7 +419,10 @@ func (h *ServiceHandler) UpdateService(c *fiber.Ctx) error {
 		})
 	}
 
-	return c.JSON(existingService.ToResponse())
+	response := existingService.ToResponse()
+	h.eventBroker.Publish(userID, services.EventServiceUpdated, existingService.ID, response)
+
+	return c.JSON(response)
 }
 
 // DeleteService handles service deletion
@@ -428,6 +487,13 @@ func (h *ServiceHandler) DeleteService(c *fiber.Ctx) error {
 		}
 	}
 
+	// Stop exporting the deleted service's check counters and histograms
+	if h.healthCheckService != nil {
+		h.healthCheckService.CheckMetrics().Forget(serviceID)
+	}
+
+	h.eventBroker.Publish(userID, services.EventServiceDeleted, serviceID, services.ServiceDeletedEventData{ID: serviceID})
+
 	return c.JSON(fiber.Map{
 		"message": "Service deleted successfully",
 	})
@@ -477,8 +543,8 @@ func (h *ServiceHandler) CheckService(c *fiber.Ctx) error {
 		})
 	}
 
-	// Perform health check
-	if err := h.healthCheckService.CheckService(c.Context(), service); err != nil {
+	// Perform health check (the user context carries the request's trace span)
+	if err := h.healthCheckService.CheckService(c.UserContext(), service); err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Failed to perform health check",
 		})
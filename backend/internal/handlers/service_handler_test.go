@@ -37,6 +37,7 @@ func setupTestDB(t *testing.T) *sql.DB {
 			status TEXT NOT NULL,
 			response_time INTEGER,
 			position INTEGER DEFAULT 0,
+			check_config TEXT NOT NULL DEFAULT '{}',
 			created_at TIMESTAMP NOT NULL,
 			updated_at TIMESTAMP NOT NULL
 		);
@@ -79,7 +80,7 @@ func TestServiceHandler_ReorderServices(t *testing.T) {
 	defer db.Close()
 
 	serviceRepo := repository.NewServiceRepository(db)
-	handler := NewServiceHandler(serviceRepo, nil)
+	handler := NewServiceHandler(serviceRepo, nil, nil)
 
 	// Create test services
 	services := []*models.Service{
@@ -256,7 +257,7 @@ func TestServiceHandler_ReorderServices_NoAuth(t *testing.T) {
 	defer db.Close()
 
 	serviceRepo := repository.NewServiceRepository(db)
-	handler := NewServiceHandler(serviceRepo, nil)
+	handler := NewServiceHandler(serviceRepo, nil, nil)
 
 	app := fiber.New()
 
@@ -288,7 +289,7 @@ func TestServiceHandler_ReorderServices_InvalidJSON(t *testing.T) {
 	defer db.Close()
 
 	serviceRepo := repository.NewServiceRepository(db)
-	handler := NewServiceHandler(serviceRepo, nil)
+	handler := NewServiceHandler(serviceRepo, nil, nil)
 
 	app := fiber.New()
 
@@ -0,0 +1,127 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/nimbus/backend/internal/models"
+	"github.com/nimbus/backend/internal/services"
+)
+
+type SLOHandler struct {
+	sloService *services.SLOService
+}
+
+func NewSLOHandler(sloService *services.SLOService) *SLOHandler {
+	return &SLOHandler{
+		sloService: sloService,
+	}
+}
+
+// sloError maps SLO service errors to responses
+func sloError(c *fiber.Ctx, err error, action string) error {
+	switch {
+	case errors.Is(err, services.ErrInvalidSLO):
+		return BadRequest(c, strings.TrimPrefix(err.Error(), services.ErrInvalidSLO.Error()+": "))
+	case errors.Is(err, sql.ErrNoRows):
+		return NotFound(c, "SLO not found")
+	default:
+		return InternalError(c, "Failed to "+action+" SLO")
+	}
+}
+
+// GetSLOs returns the user's SLOs with their error budgets and burn rates
+// GET /api/v1/slos
+func (h *SLOHandler) GetSLOs(c *fiber.Ctx) error {
+	userID, err := getUserID(c)
+	if err != nil {
+		return Unauthorized(c, "Unauthorized")
+	}
+
+	slos, err := h.sloService.List(c.Context(), userID)
+	if err != nil {
+		return InternalError(c, "Failed to retrieve SLOs")
+	}
+
+	return Success(c, fiber.Map{
+		"slos":  slos,
+		"count": len(slos),
+	})
+}
+
+// GetSLO returns one SLO with its error budgets and burn rates
+// GET /api/v1/slos/:id
+func (h *SLOHandler) GetSLO(c *fiber.Ctx) error {
+	userID, err := getUserID(c)
+	if err != nil {
+		return Unauthorized(c, "Unauthorized")
+	}
+
+	slo, err := h.sloService.Get(c.Context(), userID, c.Params("id"))
+	if err != nil {
+		return sloError(c, err, "retrieve")
+	}
+
+	return Success(c, slo)
+}
+
+// CreateSLO defines a new SLO for one service or a group of services
+// POST /api/v1/slos
+func (h *SLOHandler) CreateSLO(c *fiber.Ctx) error {
+	userID, err := getUserID(c)
+	if err != nil {
+		return Unauthorized(c, "Unauthorized")
+	}
+
+	var req models.SLORequest
+	if err := c.BodyParser(&req); err != nil {
+		return BadRequest(c, "Invalid request body")
+	}
+
+	slo, err := h.sloService.Create(c.Context(), userID, &req)
+	if err != nil {
+		return sloError(c, err, "create")
+	}
+
+	return Created(c, slo)
+}
+
+// UpdateSLO replaces an SLO's definition
+// PUT /api/v1/slos/:id
+func (h *SLOHandler) UpdateSLO(c *fiber.Ctx) error {
+	userID, err := getUserID(c)
+	if err != nil {
+		return Unauthorized(c, "Unauthorized")
+	}
+
+	var req models.SLORequest
+	if err := c.BodyParser(&req); err != nil {
+		return BadRequest(c, "Invalid request body")
+	}
+
+	slo, err := h.sloService.Update(c.Context(), userID, c.Params("id"), &req)
+	if err != nil {
+		return sloError(c, err, "update")
+	}
+
+	return Success(c, slo)
+}
+
+// DeleteSLO removes an SLO
+// DELETE /api/v1/slos/:id
+func (h *SLOHandler) DeleteSLO(c *fiber.Ctx) error {
+	userID, err := getUserID(c)
+	if err != nil {
+		return Unauthorized(c, "Unauthorized")
+	}
+
+	if err := h.sloService.Delete(c.Context(), userID, c.Params("id")); err != nil {
+		return sloError(c, err, "delete")
+	}
+
+	return Success(c, fiber.Map{
+		"message": "SLO deleted successfully",
+	})
+}
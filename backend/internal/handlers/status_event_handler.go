@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/nimbus/backend/internal/repository"
+	"github.com/nimbus/backend/internal/services"
+)
+
+type StatusEventHandler struct {
+	statusEventService *services.StatusEventService
+	serviceRepo        repository.ServiceRepositoryInterface
+}
+
+func NewStatusEventHandler(statusEventService *services.StatusEventService, serviceRepo repository.ServiceRepositoryInterface) *StatusEventHandler {
+	return &StatusEventHandler{
+		statusEventService: statusEventService,
+		serviceRepo:        serviceRepo,
+	}
+}
+
+// GetEvents retrieves the status transitions of a service with MTTR, MTBF and outage statistics
+// GET /api/v1/services/:id/events?range=30d&limit=100 or ?start=...&end=...
+func (h *StatusEventHandler) GetEvents(c *fiber.Ctx) error {
+	serviceID := c.Params("id")
+	if serviceID == "" {
+		return BadRequest(c, "Service ID is required")
+	}
+
+	// Get authenticated user
+	userID, err := getUserID(c)
+	if err != nil {
+		return Unauthorized(c, "Unauthorized")
+	}
+
+	// Verify service belongs to user
+	service, err := h.serviceRepo.GetByID(c.Context(), serviceID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return NotFound(c, "Service not found")
+		}
+		return InternalError(c, "Failed to retrieve service")
+	}
+
+	if service.UserID != userID {
+		return Forbidden(c, "Access denied")
+	}
+
+	// Parse query parameters
+	startTime, endTime, err := parseMetricsTimeRange(c, time.Now())
+	if err != nil {
+		return BadRequest(c, err.Error())
+	}
+
+	limit, err := strconv.Atoi(c.Query("limit", "100"))
+	if err != nil || limit < 1 || limit > 1000 {
+		limit = 100
+	}
+
+	events, err := h.statusEventService.GetServiceEvents(c.Context(), serviceID, startTime, endTime, limit)
+	if errors.Is(err, services.ErrInvalidTimeRange) {
+		return BadRequest(c, strings.TrimPrefix(err.Error(), services.ErrInvalidTimeRange.Error()+": "))
+	}
+	if err != nil {
+		return InternalError(c, "Failed to retrieve status events")
+	}
+
+	return Success(c, events)
+}
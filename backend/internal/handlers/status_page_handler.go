@@ -0,0 +1,227 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/nimbus/backend/internal/models"
+	"github.com/nimbus/backend/internal/services"
+)
+
+// StatusPagePasswordHeader carries the password of a protected status page
+const StatusPagePasswordHeader = "X-Status-Page-Password"
+
+// statusPageCacheControl is how long browsers and proxies may reuse a public status page
+// (the page itself is rebuilt at most once a minute)
+const statusPageCacheControl = "max-age=60"
+
+type StatusPageHandler struct {
+	statusPageService *services.StatusPageService
+}
+
+func NewStatusPageHandler(statusPageService *services.StatusPageService) *StatusPageHandler {
+	return &StatusPageHandler{
+		statusPageService: statusPageService,
+	}
+}
+
+// statusPageError maps status page service errors to responses
+func statusPageError(c *fiber.Ctx, err error, action, notFound string) error {
+	switch {
+	case errors.Is(err, services.ErrInvalidStatusPage):
+		return BadRequest(c, strings.TrimPrefix(err.Error(), services.ErrInvalidStatusPage.Error()+": "))
+	case errors.Is(err, services.ErrInvalidAnnouncement):
+		return BadRequest(c, strings.TrimPrefix(err.Error(), services.ErrInvalidAnnouncement.Error()+": "))
+	case errors.Is(err, sql.ErrNoRows):
+		return NotFound(c, notFound+" not found")
+	default:
+		return InternalError(c, "Failed to "+action)
+	}
+}
+
+// GetPublicStatusPage returns a status page for visitors, without authentication
+// Protected pages need the password in the X-Status-Page-Password header
+// GET /api/v1/status-pages/:slug
+func (h *StatusPageHandler) GetPublicStatusPage(c *fiber.Ctx) error {
+	page, err := h.statusPageService.Public(c.Context(), c.Params("slug"), c.Get(StatusPagePasswordHeader))
+	switch {
+	case errors.Is(err, services.ErrStatusPagePassword):
+		c.Set(fiber.HeaderCacheControl, "no-store")
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error":             "This status page is password protected",
+			"password_required": true,
+		})
+	case errors.Is(err, sql.ErrNoRows):
+		return NotFound(c, "Status page not found")
+	case err != nil:
+		return InternalError(c, "Failed to retrieve status page")
+	}
+
+	// Shared caches may only keep public pages; protected ones depend on the password header
+	if page.PasswordProtected {
+		c.Set(fiber.HeaderCacheControl, "private, "+statusPageCacheControl)
+		c.Vary(StatusPagePasswordHeader)
+	} else {
+		c.Set(fiber.HeaderCacheControl, "public, "+statusPageCacheControl)
+	}
+
+	return Success(c, page)
+}
+
+// GetStatusPages returns the user's status pages
+// GET /api/v1/status-pages
+func (h *StatusPageHandler) GetStatusPages(c *fiber.Ctx) error {
+	userID, err := getUserID(c)
+	if err != nil {
+		return Unauthorized(c, "Unauthorized")
+	}
+
+	pages, err := h.statusPageService.List(c.Context(), userID)
+	if err != nil {
+		return InternalError(c, "Failed to retrieve status pages")
+	}
+
+	return Success(c, fiber.Map{
+		"status_pages": pages,
+		"count":        len(pages),
+	})
+}
+
+// CreateStatusPage creates a status page
+// POST /api/v1/status-pages
+func (h *StatusPageHandler) CreateStatusPage(c *fiber.Ctx) error {
+	userID, err := getUserID(c)
+	if err != nil {
+		return Unauthorized(c, "Unauthorized")
+	}
+
+	var req models.StatusPageRequest
+	if err := c.BodyParser(&req); err != nil {
+		return BadRequest(c, "Invalid request body")
+	}
+
+	page, err := h.statusPageService.Create(c.Context(), userID, &req)
+	if err != nil {
+		return statusPageError(c, err, "create status page", "Status page")
+	}
+
+	return Created(c, page)
+}
+
+// UpdateStatusPage replaces a status page's definition
+// PUT /api/v1/status-pages/:id
+func (h *StatusPageHandler) UpdateStatusPage(c *fiber.Ctx) error {
+	userID, err := getUserID(c)
+	if err != nil {
+		return Unauthorized(c, "Unauthorized")
+	}
+
+	var req models.StatusPageRequest
+	if err := c.BodyParser(&req); err != nil {
+		return BadRequest(c, "Invalid request body")
+	}
+
+	page, err := h.statusPageService.Update(c.Context(), userID, c.Params("id"), &req)
+	if err != nil {
+		return statusPageError(c, err, "update status page", "Status page")
+	}
+
+	return Success(c, page)
+}
+
+// DeleteStatusPage removes a status page
+// DELETE /api/v1/status-pages/:id
+func (h *StatusPageHandler) DeleteStatusPage(c *fiber.Ctx) error {
+	userID, err := getUserID(c)
+	if err != nil {
+		return Unauthorized(c, "Unauthorized")
+	}
+
+	if err := h.statusPageService.Delete(c.Context(), userID, c.Params("id")); err != nil {
+		return statusPageError(c, err, "delete status page", "Status page")
+	}
+
+	return Success(c, fiber.Map{
+		"message": "Status page deleted successfully",
+	})
+}
+
+// GetAnnouncements returns all announcements of a status page, including past and scheduled ones
+// GET /api/v1/status-pages/:id/announcements
+func (h *StatusPageHandler) GetAnnouncements(c *fiber.Ctx) error {
+	userID, err := getUserID(c)
+	if err != nil {
+		return Unauthorized(c, "Unauthorized")
+	}
+
+	announcements, err := h.statusPageService.ListAnnouncements(c.Context(), userID, c.Params("id"))
+	if err != nil {
+		return statusPageError(c, err, "retrieve announcements", "Status page")
+	}
+
+	return Success(c, fiber.Map{
+		"announcements": announcements,
+		"count":         len(announcements),
+	})
+}
+
+// CreateAnnouncement adds an announcement to a status page
+// POST /api/v1/status-pages/:id/announcements
+func (h *StatusPageHandler) CreateAnnouncement(c *fiber.Ctx) error {
+	userID, err := getUserID(c)
+	if err != nil {
+		return Unauthorized(c, "Unauthorized")
+	}
+
+	var req models.AnnouncementRequest
+	if err := c.BodyParser(&req); err != nil {
+		return BadRequest(c, "Invalid request body")
+	}
+
+	announcement, err := h.statusPageService.CreateAnnouncement(c.Context(), userID, c.Params("id"), &req)
+	if err != nil {
+		return statusPageError(c, err, "create announcement", "Status page")
+	}
+
+	return Created(c, announcement)
+}
+
+// UpdateAnnouncement replaces an announcement
+// PUT /api/v1/status-pages/:id/announcements/:announcementId
+func (h *StatusPageHandler) UpdateAnnouncement(c *fiber.Ctx) error {
+	userID, err := getUserID(c)
+	if err != nil {
+		return Unauthorized(c, "Unauthorized")
+	}
+
+	var req models.AnnouncementRequest
+	if err := c.BodyParser(&req); err != nil {
+		return BadRequest(c, "Invalid request body")
+	}
+
+	announcement, err := h.statusPageService.UpdateAnnouncement(c.Context(), userID, c.Params("id"), c.Params("announcementId"), &req)
+	if err != nil {
+		return statusPageError(c, err, "update announcement", "Announcement")
+	}
+
+	return Success(c, announcement)
+}
+
+// DeleteAnnouncement removes an announcement
+// DELETE /api/v1/status-pages/:id/announcements/:announcementId
+func (h *StatusPageHandler) DeleteAnnouncement(c *fiber.Ctx) error {
+	userID, err := getUserID(c)
+	if err != nil {
+		return Unauthorized(c, "Unauthorized")
+	}
+
+	if err := h.statusPageService.DeleteAnnouncement(c.Context(), userID, c.Params("id"), c.Params("announcementId")); err != nil {
+		return statusPageError(c, err, "delete announcement", "Announcement")
+	}
+
+	return Success(c, fiber.Map{
+		"message": "Announcement deleted successfully",
+	})
+}
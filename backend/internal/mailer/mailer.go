@@ -0,0 +1,336 @@
+// Package mailer sends email over SMTP
+// Messages are multipart text/HTML rendered from templates; Queue delivers them in the background
+// and retries temporary failures
+package mailer
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Connection security modes
+const (
+	SecurityStartTLS = "starttls" // Plain connection upgraded with STARTTLS (usually port 587)
+	SecurityTLS      = "tls"      // Implicit TLS (usually port 465)
+	SecurityNone     = "none"     // Unencrypted, for relays on a trusted network
+)
+
+// DefaultTimeout bounds one SMTP conversation when the context has no deadline
+const DefaultTimeout = 30 * time.Second
+
+// maxRecipients limits the recipients of one message
+const maxRecipients = 50
+
+var (
+	// ErrNotConfigured is returned when no SMTP server is set up
+	ErrNotConfigured = errors.New("email is not configured")
+
+	// ErrInvalidConfig is returned for incomplete or invalid SMTP settings
+	ErrInvalidConfig = errors.New("invalid SMTP settings")
+
+	// ErrInvalidMessage is returned for messages that can't be sent (no or invalid recipients)
+	ErrInvalidMessage = errors.New("invalid email message")
+)
+
+// Config holds the SMTP server settings
+type Config struct {
+	Host          string
+	Port          int    // Defaults to 587 for STARTTLS, 465 for TLS and 25 without encryption
+	Security      string // SecurityStartTLS (default), SecurityTLS or SecurityNone
+	Username      string // Authenticates with AUTH PLAIN when set
+	Password      string
+	From          string // Sender address
+	FromName      string // Optional display name for the sender
+	SkipTLSVerify bool   // Accept self-signed certificates
+}
+
+// Validate checks the config and fills in defaults
+func (c *Config) Validate() error {
+	c.Host = strings.TrimSpace(c.Host)
+	if c.Host == "" {
+		return fmt.Errorf("%w: host is required", ErrInvalidConfig)
+	}
+
+	switch c.Security {
+	case "":
+		c.Security = SecurityStartTLS
+	case SecurityStartTLS, SecurityTLS, SecurityNone:
+	default:
+		return fmt.Errorf("%w: security must be starttls, tls or none", ErrInvalidConfig)
+	}
+
+	if c.Port == 0 {
+		switch c.Security {
+		case SecurityTLS:
+			c.Port = 465
+		case SecurityNone:
+			c.Port = 25
+		default:
+			c.Port = 587
+		}
+	}
+	if c.Port < 1 || c.Port > 65535 {
+		return fmt.Errorf("%w: port must be between 1 and 65535", ErrInvalidConfig)
+	}
+
+	if c.Username == "" && c.Password != "" {
+		return fmt.Errorf("%w: a password requires a username", ErrInvalidConfig)
+	}
+
+	from, err := mail.ParseAddress(c.From)
+	if err != nil || from.Name != "" {
+		return fmt.Errorf("%w: from must be an email address", ErrInvalidConfig)
+	}
+	c.From = from.Address
+	if strings.ContainsAny(c.FromName, "\r\n") {
+		return fmt.Errorf("%w: from name must be a single line", ErrInvalidConfig)
+	}
+
+	return nil
+}
+
+// Message is one email
+// Text is required; HTML is optional and sent as the preferred alternative
+type Message struct {
+	To      []string
+	Subject string
+	Text    string
+	HTML    string
+}
+
+// IsPermanent reports whether a send error won't succeed on retry:
+// invalid settings or messages, SMTP 5xx replies (rejected login, sender or recipient) and
+// certificate verification failures. Network errors and 4xx replies are temporary
+func IsPermanent(err error) bool {
+	if errors.Is(err, ErrNotConfigured) || errors.Is(err, ErrInvalidConfig) || errors.Is(err, ErrInvalidMessage) {
+		return true
+	}
+
+	var protoErr *textproto.Error
+	if errors.As(err, &protoErr) {
+		return protoErr.Code >= 500
+	}
+
+	var certErr *tls.CertificateVerificationError
+	return errors.As(err, &certErr)
+}
+
+// Send delivers a message in a single SMTP conversation
+func Send(ctx context.Context, cfg Config, msg *Message) error {
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+	recipients, err := parseRecipients(msg.To)
+	if err != nil {
+		return err
+	}
+	data, err := msg.build(&cfg, recipients, time.Now())
+	if err != nil {
+		return err
+	}
+
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, DefaultTimeout)
+		defer cancel()
+	}
+
+	tlsConfig := &tls.Config{
+		ServerName:         cfg.Host,
+		InsecureSkipVerify: cfg.SkipTLSVerify,
+		MinVersion:         tls.VersionTLS12,
+	}
+
+	addr := net.JoinHostPort(cfg.Host, strconv.Itoa(cfg.Port))
+	dialer := &net.Dialer{}
+	var conn net.Conn
+	if cfg.Security == SecurityTLS {
+		conn, err = (&tls.Dialer{NetDialer: dialer, Config: tlsConfig}).DialContext(ctx, "tcp", addr)
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", addr)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to connect to %s: %w", addr, err)
+	}
+	defer conn.Close()
+
+	// The SMTP client has no context support: bound the conversation with the connection deadline
+	deadline, _ := ctx.Deadline()
+	conn.SetDeadline(deadline)
+	stop := context.AfterFunc(ctx, func() { conn.SetDeadline(time.Now()) })
+	defer stop()
+
+	client, err := smtp.NewClient(conn, cfg.Host)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	if cfg.Security == SecurityStartTLS {
+		if ok, _ := client.Extension("STARTTLS"); !ok {
+			return fmt.Errorf("%w: server does not support STARTTLS", ErrInvalidConfig)
+		}
+		if err := client.StartTLS(tlsConfig); err != nil {
+			return fmt.Errorf("STARTTLS failed: %w", err)
+		}
+	}
+
+	if cfg.Username != "" {
+		if ok, _ := client.Extension("AUTH"); !ok {
+			return fmt.Errorf("%w: server does not support authentication", ErrInvalidConfig)
+		}
+		// PlainAuth refuses to send credentials over an unencrypted connection, except to localhost
+		if err := client.Auth(smtp.PlainAuth("", cfg.Username, cfg.Password, cfg.Host)); err != nil {
+			if _, ok := err.(*textproto.Error); !ok {
+				err = fmt.Errorf("%w: %v", ErrInvalidConfig, err)
+			}
+			return fmt.Errorf("authentication failed: %w", err)
+		}
+	}
+
+	if err := client.Mail(cfg.From); err != nil {
+		return fmt.Errorf("sender rejected: %w", err)
+	}
+	for _, rcpt := range recipients {
+		if err := client.Rcpt(rcpt.Address); err != nil {
+			return fmt.Errorf("recipient %s rejected: %w", rcpt.Address, err)
+		}
+	}
+
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(data); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("message rejected: %w", err)
+	}
+
+	return client.Quit()
+}
+
+// parseRecipients validates recipient addresses
+func parseRecipients(to []string) ([]*mail.Address, error) {
+	if len(to) == 0 {
+		return nil, fmt.Errorf("%w: no recipients", ErrInvalidMessage)
+	}
+	if len(to) > maxRecipients {
+		return nil, fmt.Errorf("%w: at most %d recipients", ErrInvalidMessage, maxRecipients)
+	}
+
+	addresses := make([]*mail.Address, 0, len(to))
+	for _, rcpt := range to {
+		addr, err := mail.ParseAddress(rcpt)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid recipient %q", ErrInvalidMessage, rcpt)
+		}
+		addresses = append(addresses, addr)
+	}
+	return addresses, nil
+}
+
+// build encodes the message with its headers, as sent after DATA
+// With an HTML body the message is multipart/alternative, otherwise plain text
+func (m *Message) build(cfg *Config, recipients []*mail.Address, now time.Time) ([]byte, error) {
+	to := make([]string, 0, len(recipients))
+	for _, rcpt := range recipients {
+		to = append(to, rcpt.String())
+	}
+
+	// Header values must stay on one line, otherwise they could inject headers
+	subject := strings.Join(strings.Fields(m.Subject), " ")
+
+	headers := [][2]string{
+		{"From", (&mail.Address{Name: cfg.FromName, Address: cfg.From}).String()},
+		{"To", strings.Join(to, ", ")},
+		{"Subject", mime.QEncoding.Encode("utf-8", subject)},
+		{"Date", now.Format(time.RFC1123Z)},
+		{"Message-ID", messageID(cfg.From)},
+		{"MIME-Version", "1.0"},
+	}
+
+	var buf bytes.Buffer
+	if m.HTML == "" {
+		headers = append(headers,
+			[2]string{"Content-Type", "text/plain; charset=utf-8"},
+			[2]string{"Content-Transfer-Encoding", "quoted-printable"},
+		)
+		writeHeaders(&buf, headers)
+		if err := writeQuotedPrintable(&buf, m.Text); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	}
+
+	var body bytes.Buffer
+	parts := multipart.NewWriter(&body)
+	for _, part := range []struct{ contentType, content string }{
+		{"text/plain; charset=utf-8", m.Text},
+		{"text/html; charset=utf-8", m.HTML},
+	} {
+		w, err := parts.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		if err := writeQuotedPrintable(w, part.content); err != nil {
+			return nil, err
+		}
+	}
+	if err := parts.Close(); err != nil {
+		return nil, err
+	}
+
+	headers = append(headers, [2]string{"Content-Type", mime.FormatMediaType("multipart/alternative", map[string]string{"boundary": parts.Boundary()})})
+	writeHeaders(&buf, headers)
+	buf.Write(body.Bytes())
+	return buf.Bytes(), nil
+}
+
+func writeHeaders(buf *bytes.Buffer, headers [][2]string) {
+	for _, header := range headers {
+		fmt.Fprintf(buf, "%s: %s\r\n", header[0], header[1])
+	}
+	buf.WriteString("\r\n")
+}
+
+func writeQuotedPrintable(w io.Writer, content string) error {
+	qp := quotedprintable.NewWriter(w)
+	// SMTP requires CRLF line endings
+	content = strings.ReplaceAll(strings.ReplaceAll(content, "\r\n", "\n"), "\n", "\r\n")
+	if _, err := qp.Write([]byte(content)); err != nil {
+		return err
+	}
+	return qp.Close()
+}
+
+// messageID returns a unique Message-ID in the sender's domain
+func messageID(from string) string {
+	domain := "nimbus.local"
+	if at := strings.LastIndex(from, "@"); at >= 0 && at < len(from)-1 {
+		domain = from[at+1:]
+	}
+	random := make([]byte, 16)
+	rand.Read(random)
+	return fmt.Sprintf("<%s@%s>", hex.EncodeToString(random), domain)
+}
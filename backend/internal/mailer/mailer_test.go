@@ -0,0 +1,256 @@
+package mailer
+
+import (
+	"context"
+	"errors"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nimbus/backend/internal/mailer/mailertest"
+)
+
+func testConfig(server *mailertest.Server, security string) Config {
+	return Config{
+		Host:          server.Host,
+		Port:          server.Port,
+		Security:      security,
+		From:          "nimbus@example.com",
+		FromName:      "Nimbus",
+		SkipTLSVerify: true,
+	}
+}
+
+func testMessage() *Message {
+	return &Message{
+		To:      []string{"Alice <alice@example.com>", "bob@example.com"},
+		Subject: "Plex is down",
+		Text:    "Plex went offline.",
+		HTML:    "<p>Plex went offline.</p>",
+	}
+}
+
+// parseParts reads a received multipart/alternative message
+func parseParts(t *testing.T, data string) (*mail.Message, map[string]string) {
+	t.Helper()
+	msg, err := mail.ReadMessage(strings.NewReader(data))
+	if err != nil {
+		t.Fatalf("Invalid message: %v", err)
+	}
+
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/alternative" {
+		t.Fatalf("Expected multipart/alternative, got %q", msg.Header.Get("Content-Type"))
+	}
+
+	parts := make(map[string]string)
+	reader := multipart.NewReader(msg.Body, params["boundary"])
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Invalid part: %v", err)
+		}
+		body, _ := io.ReadAll(part) // Decodes quoted-printable
+		contentType, _, _ := mime.ParseMediaType(part.Header.Get("Content-Type"))
+		parts[contentType] = string(body)
+	}
+	return msg, parts
+}
+
+func TestSend_Plain(t *testing.T) {
+	server := mailertest.NewServer(t, mailertest.Options{})
+
+	if err := Send(context.Background(), testConfig(server, SecurityNone), testMessage()); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+
+	messages := server.Messages()
+	if len(messages) != 1 {
+		t.Fatalf("Expected 1 message, got %d", len(messages))
+	}
+	received := messages[0]
+	if received.From != "nimbus@example.com" || strings.Join(received.To, ",") != "alice@example.com,bob@example.com" {
+		t.Errorf("Unexpected envelope %+v", received)
+	}
+
+	msg, parts := parseParts(t, received.Data)
+	if msg.Header.Get("From") != `"Nimbus" <nimbus@example.com>` || msg.Header.Get("Subject") != "Plex is down" {
+		t.Errorf("Unexpected headers %v", msg.Header)
+	}
+	if !strings.Contains(msg.Header.Get("Message-ID"), "@example.com>") {
+		t.Errorf("Unexpected Message-ID %q", msg.Header.Get("Message-ID"))
+	}
+	if parts["text/plain"] != "Plex went offline." || parts["text/html"] != "<p>Plex went offline.</p>" {
+		t.Errorf("Unexpected parts %v", parts)
+	}
+}
+
+func TestSend_StartTLSWithAuth(t *testing.T) {
+	server := mailertest.NewServer(t, mailertest.Options{StartTLS: true, Username: "nimbus", Password: "s3cret"})
+
+	cfg := testConfig(server, SecurityStartTLS)
+	cfg.Username = "nimbus"
+	cfg.Password = "s3cret"
+	if err := Send(context.Background(), cfg, testMessage()); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	if messages := server.Messages(); len(messages) != 1 || !messages[0].TLS {
+		t.Fatalf("Expected 1 message over TLS, got %+v", messages)
+	}
+
+	// A wrong password is rejected with 535, which won't succeed on retry
+	cfg.Password = "wrong"
+	err := Send(context.Background(), cfg, testMessage())
+	if err == nil || !IsPermanent(err) {
+		t.Errorf("Expected permanent authentication error, got %v", err)
+	}
+}
+
+func TestSend_ImplicitTLS(t *testing.T) {
+	server := mailertest.NewServer(t, mailertest.Options{ImplicitTLS: true})
+
+	if err := Send(context.Background(), testConfig(server, SecurityTLS), testMessage()); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	if messages := server.Messages(); len(messages) != 1 || !messages[0].TLS {
+		t.Fatalf("Expected 1 message over TLS, got %+v", messages)
+	}
+
+	// The self-signed certificate fails verification
+	cfg := testConfig(server, SecurityTLS)
+	cfg.SkipTLSVerify = false
+	if err := Send(context.Background(), cfg, testMessage()); err == nil || !IsPermanent(err) {
+		t.Errorf("Expected permanent certificate error, got %v", err)
+	}
+}
+
+func TestSend_StartTLSRequired(t *testing.T) {
+	server := mailertest.NewServer(t, mailertest.Options{})
+
+	err := Send(context.Background(), testConfig(server, SecurityStartTLS), testMessage())
+	if !errors.Is(err, ErrInvalidConfig) {
+		t.Errorf("Expected ErrInvalidConfig without STARTTLS support, got %v", err)
+	}
+	if len(server.Messages()) != 0 {
+		t.Error("Expected no message to be sent in the clear")
+	}
+}
+
+func TestSend_RecipientErrors(t *testing.T) {
+	server := mailertest.NewServer(t, mailertest.Options{TempFailures: 1, RejectRecipient: "bob@example.com"})
+	cfg := testConfig(server, SecurityNone)
+
+	err := Send(context.Background(), cfg, testMessage())
+	if err == nil || IsPermanent(err) {
+		t.Errorf("Expected temporary error for 451, got %v", err)
+	}
+
+	err = Send(context.Background(), cfg, testMessage())
+	if err == nil || !IsPermanent(err) {
+		t.Errorf("Expected permanent error for 550, got %v", err)
+	}
+
+	if err := Send(context.Background(), cfg, &Message{Subject: "x", Text: "x"}); !errors.Is(err, ErrInvalidMessage) {
+		t.Errorf("Expected ErrInvalidMessage without recipients, got %v", err)
+	}
+}
+
+func TestConfig_Validate(t *testing.T) {
+	cfg := Config{Host: " smtp.example.com ", From: "nimbus@example.com", Security: SecurityTLS}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+	if cfg.Host != "smtp.example.com" || cfg.Port != 465 {
+		t.Errorf("Expected defaults to be filled in, got %+v", cfg)
+	}
+
+	tests := []struct {
+		name string
+		cfg  Config
+	}{
+		{"Missing host", Config{From: "nimbus@example.com"}},
+		{"Unknown security", Config{Host: "smtp.example.com", From: "nimbus@example.com", Security: "ssl"}},
+		{"Invalid port", Config{Host: "smtp.example.com", From: "nimbus@example.com", Port: 70000}},
+		{"Missing from", Config{Host: "smtp.example.com"}},
+		{"From with name", Config{Host: "smtp.example.com", From: "Nimbus <nimbus@example.com>"}},
+		{"Password without username", Config{Host: "smtp.example.com", From: "nimbus@example.com", Password: "x"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.cfg.Validate(); !errors.Is(err, ErrInvalidConfig) {
+				t.Errorf("Expected ErrInvalidConfig, got %v", err)
+			}
+		})
+	}
+}
+
+func TestMessage_Build(t *testing.T) {
+	cfg := Config{From: "nimbus@example.com", FromName: "Nimbüs"}
+	recipients, _ := parseRecipients([]string{"alice@example.com"})
+
+	// Line breaks in the subject can't inject headers; non-ASCII is encoded
+	msg := &Message{To: []string{"alice@example.com"}, Subject: "🔴 Plex\r\nBcc: eve@example.com", Text: "line 1\nline 2"}
+	data, err := msg.build(&cfg, recipients, time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("build() error = %v", err)
+	}
+
+	parsed, err := mail.ReadMessage(strings.NewReader(string(data)))
+	if err != nil {
+		t.Fatalf("Invalid message: %v", err)
+	}
+	if parsed.Header.Get("Bcc") != "" {
+		t.Error("Subject injected a header")
+	}
+	subject, _ := new(mime.WordDecoder).DecodeHeader(parsed.Header.Get("Subject"))
+	if subject != "🔴 Plex Bcc: eve@example.com" {
+		t.Errorf("Unexpected subject %q", subject)
+	}
+	if !strings.HasPrefix(parsed.Header.Get("Content-Type"), "text/plain") || parsed.Header.Get("Date") != "Sun, 18 Oct 2026 12:00:00 +0000" {
+		t.Errorf("Unexpected headers %v", parsed.Header)
+	}
+	if !strings.Contains(string(data), "line 1\r\nline 2") {
+		t.Errorf("Expected CRLF line endings, got %q", data)
+	}
+}
+
+func TestTemplates(t *testing.T) {
+	msg, err := Alert.Render([]string{"alice@example.com"}, AlertData{
+		Title:      "🔴 Plex is down",
+		Lines:      []string{"Plex went offline.", "Error: <script>"},
+		ServiceURL: "http://plex.lan:32400",
+		Down:       true,
+	})
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	if msg.Subject != "🔴 Plex is down" || !strings.Contains(msg.Text, "Error: <script>") {
+		t.Errorf("Unexpected text message %q / %q", msg.Subject, msg.Text)
+	}
+	if strings.Contains(msg.HTML, "<script>") || !strings.Contains(msg.HTML, "&lt;script&gt;") || !strings.Contains(msg.HTML, accentDown) {
+		t.Errorf("Expected escaped HTML with the down accent, got %s", msg.HTML)
+	}
+
+	msg, err = Invitation.Render([]string{"bob@example.com"}, InvitationData{
+		InvitedBy: "Alice",
+		Email:     "bob@example.com",
+		URL:       "https://nimbus.example.com/register?invite=abc&email=bob%40example.com",
+		ExpiresAt: time.Date(2026, 10, 21, 12, 0, 0, 0, time.UTC),
+	})
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	if msg.Subject != "Alice invited you to Nimbus" || !strings.Contains(msg.Text, "invite=abc&email=bob%40example.com") {
+		t.Errorf("Unexpected invitation %q / %q", msg.Subject, msg.Text)
+	}
+	if !strings.Contains(msg.HTML, `href="https://nimbus.example.com/register?invite=abc&amp;email=bob%40example.com"`) {
+		t.Errorf("Expected the link in the HTML body, got %s", msg.HTML)
+	}
+}
@@ -0,0 +1,288 @@
+// Package mailertest provides a local SMTP server for tests
+package mailertest
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"fmt"
+	"math/big"
+	"net"
+	"net/textproto"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Options configure the server's behaviour
+type Options struct {
+	StartTLS        bool   // Advertise STARTTLS
+	ImplicitTLS     bool   // Speak TLS from the start (SMTPS)
+	Username        string // Require AUTH PLAIN with these credentials
+	Password        string
+	TempFailures    int    // Reply 451 to the first TempFailures RCPT commands
+	RejectRecipient string // Reply 550 to RCPT for this address
+}
+
+// Message is an email received by the server
+type Message struct {
+	From string
+	To   []string
+	Data string // Headers and body as sent after DATA
+	TLS  bool   // Received over an encrypted connection
+}
+
+// Server is a minimal SMTP server listening on 127.0.0.1
+// Its certificate is self-signed, so clients must skip verification
+type Server struct {
+	Host string
+	Port int
+
+	opts      Options
+	listener  net.Listener
+	tlsConfig *tls.Config
+	wg        sync.WaitGroup
+
+	mu           sync.Mutex
+	messages     []Message
+	tempFailures int
+}
+
+// NewServer starts a server that is closed when the test ends
+func NewServer(t testing.TB, opts Options) *Server {
+	t.Helper()
+
+	cert, err := selfSignedCertificate()
+	if err != nil {
+		t.Fatalf("Failed to create certificate: %v", err)
+	}
+	tlsConfig := &tls.Config{Certificates: []tls.Certificate{cert}}
+
+	var listener net.Listener
+	if opts.ImplicitTLS {
+		listener, err = tls.Listen("tcp", "127.0.0.1:0", tlsConfig)
+	} else {
+		listener, err = net.Listen("tcp", "127.0.0.1:0")
+	}
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+
+	s := &Server{
+		Host:         "127.0.0.1",
+		Port:         listener.Addr().(*net.TCPAddr).Port,
+		opts:         opts,
+		listener:     listener,
+		tlsConfig:    tlsConfig,
+		tempFailures: opts.TempFailures,
+	}
+
+	s.wg.Add(1)
+	go s.serve()
+	t.Cleanup(s.Close)
+	return s
+}
+
+// Close stops the server
+func (s *Server) Close() {
+	s.listener.Close()
+	s.wg.Wait()
+}
+
+// Messages returns the messages received so far
+func (s *Server) Messages() []Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Message(nil), s.messages...)
+}
+
+// WaitForMessages waits until n messages were received, returning false on timeout
+func (s *Server) WaitForMessages(n int, timeout time.Duration) bool {
+	deadline := time.Now().Add(timeout)
+	for len(s.Messages()) < n {
+		if time.Now().After(deadline) {
+			return false
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	return true
+}
+
+func (s *Server) serve() {
+	defer s.wg.Done()
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			defer conn.Close()
+			conn.SetDeadline(time.Now().Add(10 * time.Second))
+			s.handle(conn)
+		}()
+	}
+}
+
+// handle runs one SMTP session
+func (s *Server) handle(conn net.Conn) {
+	text := textproto.NewConn(conn)
+	encrypted := s.opts.ImplicitTLS
+	authenticated := false
+	var msg *Message
+
+	reply := func(format string, args ...any) bool {
+		return text.PrintfLine(format, args...) == nil
+	}
+
+	reply("220 mailertest ESMTP")
+	for {
+		line, err := text.ReadLine()
+		if err != nil {
+			return
+		}
+		verb, arg, _ := strings.Cut(line, " ")
+
+		switch strings.ToUpper(verb) {
+		case "EHLO", "HELO":
+			lines := []string{"mailertest"}
+			if s.opts.StartTLS && !encrypted {
+				lines = append(lines, "STARTTLS")
+			}
+			if s.opts.Username != "" {
+				lines = append(lines, "AUTH PLAIN")
+			}
+			lines = append(lines, "8BITMIME")
+			for i, l := range lines {
+				sep := "-"
+				if i == len(lines)-1 {
+					sep = " "
+				}
+				reply("250%s%s", sep, l)
+			}
+		case "STARTTLS":
+			if !s.opts.StartTLS || encrypted {
+				reply("502 5.5.1 STARTTLS not available")
+				continue
+			}
+			reply("220 2.0.0 Ready to start TLS")
+			tlsConn := tls.Server(conn, s.tlsConfig)
+			if err := tlsConn.Handshake(); err != nil {
+				return
+			}
+			conn = tlsConn
+			text = textproto.NewConn(conn)
+			encrypted = true
+		case "AUTH":
+			mechanism, initial, _ := strings.Cut(arg, " ")
+			if !strings.EqualFold(mechanism, "PLAIN") || s.opts.Username == "" {
+				reply("504 5.5.4 Unrecognized authentication type")
+				continue
+			}
+			decoded, err := base64.StdEncoding.DecodeString(initial)
+			parts := strings.Split(string(decoded), "\x00")
+			if err != nil || len(parts) != 3 || parts[1] != s.opts.Username || parts[2] != s.opts.Password {
+				reply("535 5.7.8 Authentication credentials invalid")
+				continue
+			}
+			authenticated = true
+			reply("235 2.7.0 Authentication successful")
+		case "MAIL":
+			if s.opts.Username != "" && !authenticated {
+				reply("530 5.7.0 Authentication required")
+				continue
+			}
+			msg = &Message{From: addressArg(arg), TLS: encrypted}
+			reply("250 2.1.0 OK")
+		case "RCPT":
+			if msg == nil {
+				reply("503 5.5.1 MAIL first")
+				continue
+			}
+			rcpt := addressArg(arg)
+			if s.takeTempFailure() {
+				reply("451 4.3.0 Try again later")
+				continue
+			}
+			if strings.EqualFold(rcpt, s.opts.RejectRecipient) {
+				reply("550 5.1.1 No such user")
+				continue
+			}
+			msg.To = append(msg.To, rcpt)
+			reply("250 2.1.5 OK")
+		case "DATA":
+			if msg == nil || len(msg.To) == 0 {
+				reply("503 5.5.1 RCPT first")
+				continue
+			}
+			reply("354 End data with <CR><LF>.<CR><LF>")
+			data, err := text.ReadDotBytes()
+			if err != nil {
+				return
+			}
+			msg.Data = string(data)
+			s.mu.Lock()
+			s.messages = append(s.messages, *msg)
+			s.mu.Unlock()
+			msg = nil
+			reply("250 2.0.0 Queued")
+		case "RSET":
+			msg = nil
+			reply("250 2.0.0 OK")
+		case "NOOP":
+			reply("250 2.0.0 OK")
+		case "QUIT":
+			reply("221 2.0.0 Bye")
+			return
+		default:
+			reply("502 5.5.2 Command not recognized")
+		}
+	}
+}
+
+func (s *Server) takeTempFailure() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.tempFailures > 0 {
+		s.tempFailures--
+		return true
+	}
+	return false
+}
+
+// addressArg extracts the address from "FROM:<a@b>" or "TO:<a@b>"
+func addressArg(arg string) string {
+	_, addr, _ := strings.Cut(arg, ":")
+	addr, _, _ = strings.Cut(strings.TrimSpace(addr), " ")
+	return strings.Trim(addr, "<>")
+}
+
+// selfSignedCertificate creates a certificate for 127.0.0.1
+func selfSignedCertificate() (tls.Certificate, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "mailertest"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return tls.Certificate{}, fmt.Errorf("failed to create certificate: %w", err)
+	}
+
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}, nil
+}
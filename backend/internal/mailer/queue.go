@@ -0,0 +1,172 @@
+package mailer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Default queue settings
+const (
+	DefaultQueueSize  = 500
+	DefaultMaxRetries = 5
+
+	queueWorkers    = 2
+	queueMaxBackoff = 10 * time.Minute
+)
+
+// ErrQueueFull is returned by Enqueue when the queue has no room
+var ErrQueueFull = errors.New("email queue is full")
+
+// ConfigFunc returns the current SMTP settings, or ErrNotConfigured
+// It is called for every attempt, so settings changed by an admin apply to queued messages
+type ConfigFunc func() (Config, error)
+
+// QueueStats is a snapshot of the queue's counters
+type QueueStats struct {
+	QueueLength int
+	Dropped     int64 // Messages discarded because the queue was full
+	Sent        int64
+	Failed      int64 // Messages given up after a permanent error or the last retry
+}
+
+// Queue sends messages in the background
+// Temporary failures (network errors, 4xx replies) are retried with exponential backoff
+type Queue struct {
+	config     ConfigFunc
+	maxRetries int
+	backoff    time.Duration // Delay before the first retry, doubled for each further retry
+
+	queue    chan *Message
+	stopChan chan struct{}
+	wg       sync.WaitGroup
+	stopOnce sync.Once
+
+	dropped atomic.Int64
+	sent    atomic.Int64
+	failed  atomic.Int64
+}
+
+// NewQueue creates a queue holding up to size messages
+func NewQueue(config ConfigFunc, size int) *Queue {
+	if size <= 0 {
+		size = DefaultQueueSize
+	}
+
+	return &Queue{
+		config:     config,
+		maxRetries: DefaultMaxRetries,
+		backoff:    30 * time.Second,
+		queue:      make(chan *Message, size),
+		stopChan:   make(chan struct{}),
+	}
+}
+
+// Start begins sending queued messages
+func (q *Queue) Start() {
+	for i := 0; i < queueWorkers; i++ {
+		q.wg.Add(1)
+		go q.run()
+	}
+	fmt.Printf("Email queue started (size: %d, workers: %d)\n", cap(q.queue), queueWorkers)
+}
+
+// Stop sends the queued messages (without further retries) and stops
+func (q *Queue) Stop() {
+	q.stopOnce.Do(func() {
+		fmt.Println("Stopping email queue...")
+		close(q.stopChan)
+		q.wg.Wait()
+		fmt.Println("Email queue stopped")
+	})
+}
+
+// Stats returns the queue's counters
+func (q *Queue) Stats() QueueStats {
+	return QueueStats{
+		QueueLength: len(q.queue),
+		Dropped:     q.dropped.Load(),
+		Sent:        q.sent.Load(),
+		Failed:      q.failed.Load(),
+	}
+}
+
+// Enqueue adds a message to the queue without blocking
+// Recipients are checked up front, so invalid messages are reported to the caller
+func (q *Queue) Enqueue(msg *Message) error {
+	if _, err := parseRecipients(msg.To); err != nil {
+		return err
+	}
+
+	select {
+	case q.queue <- msg:
+		return nil
+	default:
+		q.dropped.Add(1)
+		return ErrQueueFull
+	}
+}
+
+func (q *Queue) run() {
+	defer q.wg.Done()
+
+	for {
+		select {
+		case msg := <-q.queue:
+			q.sendWithRetry(msg)
+		case <-q.stopChan:
+			for {
+				select {
+				case msg := <-q.queue:
+					q.sendWithRetry(msg)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+// sendWithRetry sends one message, retrying temporary failures with exponential backoff
+func (q *Queue) sendWithRetry(msg *Message) {
+	backoff := q.backoff
+	for attempt := 0; ; attempt++ {
+		err := q.send(msg)
+		if err == nil {
+			q.sent.Add(1)
+			return
+		}
+
+		stopping := false
+		select {
+		case <-q.stopChan:
+			stopping = true
+		default:
+		}
+
+		if IsPermanent(err) || attempt >= q.maxRetries || stopping {
+			q.failed.Add(1)
+			fmt.Printf("Failed to send email %q: %v\n", msg.Subject, err)
+			return
+		}
+
+		fmt.Printf("Failed to send email %q (retrying in %v): %v\n", msg.Subject, backoff, err)
+		select {
+		case <-time.After(backoff):
+		case <-q.stopChan:
+			// Shutting down: one last attempt without waiting
+		}
+		backoff = min(2*backoff, queueMaxBackoff)
+	}
+}
+
+func (q *Queue) send(msg *Message) error {
+	cfg, err := q.config()
+	if err != nil {
+		return err
+	}
+	return Send(context.Background(), cfg, msg)
+}
@@ -0,0 +1,96 @@
+package mailer
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/nimbus/backend/internal/mailer/mailertest"
+)
+
+// waitForQueue polls until n messages were sent or given up
+func waitForQueue(t *testing.T, q *Queue, n int64) QueueStats {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		stats := q.Stats()
+		if stats.Sent+stats.Failed >= n {
+			return stats
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Timed out waiting for %d messages, got %+v", n, stats)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestQueue_RetriesTemporaryFailures(t *testing.T) {
+	server := mailertest.NewServer(t, mailertest.Options{TempFailures: 2})
+	q := NewQueue(func() (Config, error) { return testConfig(server, SecurityNone), nil }, 10)
+	q.backoff = 10 * time.Millisecond
+	q.Start()
+	defer q.Stop()
+
+	msg := testMessage()
+	msg.To = msg.To[:1]
+	if err := q.Enqueue(msg); err != nil {
+		t.Fatalf("Enqueue() error = %v", err)
+	}
+
+	stats := waitForQueue(t, q, 1)
+	if stats.Sent != 1 || stats.Failed != 0 {
+		t.Errorf("Expected the message to be sent after retries, got %+v", stats)
+	}
+	if len(server.Messages()) != 1 {
+		t.Errorf("Expected 1 message, got %d", len(server.Messages()))
+	}
+}
+
+func TestQueue_PermanentFailures(t *testing.T) {
+	server := mailertest.NewServer(t, mailertest.Options{RejectRecipient: "alice@example.com"})
+	var disabled atomic.Bool
+	q := NewQueue(func() (Config, error) {
+		if disabled.Load() {
+			return Config{}, ErrNotConfigured
+		}
+		return testConfig(server, SecurityNone), nil
+	}, 10)
+	q.backoff = time.Hour // A retry would time out the test
+	q.Start()
+	defer q.Stop()
+
+	if err := q.Enqueue(testMessage()); err != nil {
+		t.Fatalf("Enqueue() error = %v", err)
+	}
+	stats := waitForQueue(t, q, 1)
+	if stats.Failed != 1 {
+		t.Errorf("Expected the rejected message to fail without retries, got %+v", stats)
+	}
+
+	// Messages queued before email was disabled are given up
+	disabled.Store(true)
+	if err := q.Enqueue(&Message{To: []string{"bob@example.com"}, Subject: "x", Text: "x"}); err != nil {
+		t.Fatalf("Enqueue() error = %v", err)
+	}
+	if stats := waitForQueue(t, q, 2); stats.Failed != 2 {
+		t.Errorf("Expected 2 failures, got %+v", stats)
+	}
+}
+
+func TestQueue_Enqueue(t *testing.T) {
+	q := NewQueue(func() (Config, error) { return Config{}, ErrNotConfigured }, 1)
+
+	if err := q.Enqueue(&Message{To: []string{"not an address"}}); !errors.Is(err, ErrInvalidMessage) {
+		t.Errorf("Expected ErrInvalidMessage, got %v", err)
+	}
+	if err := q.Enqueue(testMessage()); err != nil {
+		t.Fatalf("Enqueue() error = %v", err)
+	}
+	if err := q.Enqueue(testMessage()); !errors.Is(err, ErrQueueFull) {
+		t.Errorf("Expected ErrQueueFull, got %v", err)
+	}
+	if stats := q.Stats(); stats.QueueLength != 1 || stats.Dropped != 1 {
+		t.Errorf("Unexpected stats %+v", stats)
+	}
+}
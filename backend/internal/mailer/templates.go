@@ -0,0 +1,195 @@
+package mailer
+
+import (
+	"bytes"
+	"fmt"
+	htmltemplate "html/template"
+	"strings"
+	texttemplate "text/template"
+	"time"
+)
+
+// Template renders one kind of email: a subject line plus text and HTML bodies
+// HTML bodies are wrapped in a shared layout and escaped by html/template
+type Template struct {
+	subject *texttemplate.Template
+	text    *texttemplate.Template
+	html    *htmltemplate.Template
+}
+
+// layoutHTML is the frame around every HTML body; the body is the "content" template
+const layoutHTML = `<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><meta name="viewport" content="width=device-width, initial-scale=1"></head>
+<body style="margin:0;padding:24px;background:#f4f5f7;font-family:-apple-system,BlinkMacSystemFont,'Segoe UI',Roboto,Helvetica,Arial,sans-serif;color:#1f2933;">
+<table role="presentation" width="100%" cellpadding="0" cellspacing="0"><tr><td align="center">
+<table role="presentation" width="560" cellpadding="0" cellspacing="0" style="max-width:560px;background:#ffffff;border-radius:8px;overflow:hidden;">
+<tr><td style="height:6px;background:{{.Accent}};"></td></tr>
+<tr><td style="padding:28px 32px;font-size:15px;line-height:1.5;">{{template "content" .Data}}</td></tr>
+<tr><td style="padding:16px 32px;border-top:1px solid #e4e7eb;font-size:12px;color:#7b8794;">Sent by Nimbus</td></tr>
+</table>
+</td></tr></table>
+</body>
+</html>
+`
+
+// Accent colours for the bar at the top of HTML emails
+const (
+	accentDefault  = "#3949AB"
+	accentDown     = "#E53935"
+	accentRecovery = "#43A047"
+)
+
+var templateFuncs = map[string]any{
+	"datetime": func(t time.Time) string { return t.UTC().Format("Mon, 02 Jan 2006 15:04 MST") },
+}
+
+// NewTemplate parses a template; html is the body inside the shared layout
+func NewTemplate(name, subject, text, html string) (*Template, error) {
+	subjectTmpl, err := texttemplate.New(name + ".subject").Funcs(templateFuncs).Parse(subject)
+	if err != nil {
+		return nil, err
+	}
+	textTmpl, err := texttemplate.New(name + ".txt").Funcs(templateFuncs).Parse(text)
+	if err != nil {
+		return nil, err
+	}
+	htmlTmpl, err := htmltemplate.New("layout").Funcs(templateFuncs).Parse(layoutHTML)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := htmlTmpl.New("content").Parse(html); err != nil {
+		return nil, err
+	}
+
+	return &Template{subject: subjectTmpl, text: textTmpl, html: htmlTmpl}, nil
+}
+
+// MustTemplate is NewTemplate for the built-in templates, panicking on parse errors
+func MustTemplate(name, subject, text, html string) *Template {
+	t, err := NewTemplate(name, subject, text, html)
+	if err != nil {
+		panic(fmt.Sprintf("mailer: template %s: %v", name, err))
+	}
+	return t
+}
+
+// accented is implemented by template data that picks the layout's accent colour
+type accented interface {
+	accent() string
+}
+
+// Render builds a message for the recipients from the template and data
+func (t *Template) Render(to []string, data any) (*Message, error) {
+	var subject, text, html bytes.Buffer
+	if err := t.subject.Execute(&subject, data); err != nil {
+		return nil, err
+	}
+	if err := t.text.Execute(&text, data); err != nil {
+		return nil, err
+	}
+
+	accent := accentDefault
+	if a, ok := data.(accented); ok {
+		accent = a.accent()
+	}
+	if err := t.html.ExecuteTemplate(&html, "layout", struct {
+		Accent htmltemplate.CSS
+		Data   any
+	}{htmltemplate.CSS(accent), data}); err != nil {
+		return nil, err
+	}
+
+	return &Message{
+		To:      to,
+		Subject: strings.TrimSpace(subject.String()),
+		Text:    text.String(),
+		HTML:    html.String(),
+	}, nil
+}
+
+// AlertData is the data for the Alert template
+type AlertData struct {
+	Title      string   // e.g. "🔴 Plex is down"
+	Lines      []string // Message body, one paragraph per line
+	ServiceURL string   // Linked below the message (empty for none)
+	Down       bool     // Rendered red; otherwise green
+}
+
+func (d AlertData) accent() string {
+	if d.Down {
+		return accentDown
+	}
+	return accentRecovery
+}
+
+// Alert announces a service status change
+var Alert = MustTemplate("alert",
+	`{{.Title}}`,
+	`{{.Title}}
+
+{{range .Lines}}{{.}}
+{{end}}{{if .ServiceURL}}
+Open the service: {{.ServiceURL}}
+{{end}}`,
+	`<h2 style="margin:0 0 16px;font-size:20px;">{{.Title}}</h2>
+{{range .Lines}}<p style="margin:0 0 8px;">{{.}}</p>
+{{end}}{{if .ServiceURL}}<p style="margin:16px 0 0;"><a href="{{.ServiceURL}}" style="color:#3949AB;">Open the service</a></p>{{end}}`,
+)
+
+// InvitationData is the data for the Invitation template
+type InvitationData struct {
+	InvitedBy string // Name of the admin who sent the invitation
+	Email     string
+	URL       string // Registration link
+	ExpiresAt time.Time
+}
+
+// Invitation invites someone to create a Nimbus account
+var Invitation = MustTemplate("invitation",
+	`{{.InvitedBy}} invited you to Nimbus`,
+	`Hi,
+
+{{.InvitedBy}} invited you to join their Nimbus dashboard.
+
+Create your account with this link:
+{{.URL}}
+
+The invitation for {{.Email}} expires on {{datetime .ExpiresAt}}.
+If you weren't expecting it, you can ignore this email.
+`,
+	`<h2 style="margin:0 0 16px;font-size:20px;">You're invited to Nimbus</h2>
+<p style="margin:0 0 16px;">{{.InvitedBy}} invited you to join their Nimbus dashboard.</p>
+<p style="margin:0 0 24px;"><a href="{{.URL}}" style="display:inline-block;padding:10px 20px;background:#3949AB;color:#ffffff;text-decoration:none;border-radius:6px;">Create your account</a></p>
+<p style="margin:0;font-size:13px;color:#7b8794;">The invitation for {{.Email}} expires on {{datetime .ExpiresAt}}. If you weren't expecting it, you can ignore this email.</p>`,
+)
+
+// DigestData is the data for the Digest template
+type DigestData struct {
+	Title string
+	Text  string            // Plain text (Markdown) report
+	HTML  htmltemplate.HTML // Pre-rendered HTML report, placed inside the layout
+}
+
+// Digest is a user's periodic report; the report bodies are rendered by the caller
+var Digest = MustTemplate("digest",
+	`{{.Title}}`,
+	`{{.Text}}`,
+	`{{.HTML}}`,
+)
+
+// TestData is the data for the Test template
+type TestData struct {
+	SentAt time.Time
+}
+
+// Test checks the SMTP settings from the admin settings page
+var Test = MustTemplate("test",
+	`Nimbus test email`,
+	`Your Nimbus email settings work.
+
+Sent on {{datetime .SentAt}}.
+`,
+	`<h2 style="margin:0 0 16px;font-size:20px;">Email is set up</h2>
+<p style="margin:0;">Your Nimbus email settings work. Sent on {{datetime .SentAt}}.</p>`,
+)
@@ -0,0 +1,56 @@
+package middleware
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/nimbus/backend/internal/services"
+)
+
+// Tracing starts a server span for every request, continuing the caller's trace from its
+// W3C traceparent header
+// The span is available to handlers through c.UserContext(); a nil tracer disables tracing
+func Tracing(tracer *services.Tracer) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if tracer == nil {
+			return c.Next()
+		}
+
+		ctx := c.UserContext()
+		if parent, ok := services.ParseTraceparent(c.Get("traceparent")); ok {
+			ctx = services.ContextWithRemoteSpanContext(ctx, parent)
+		}
+
+		ctx, span := tracer.StartSpan(ctx, c.Method(), services.SpanKindServer,
+			services.Attribute{Key: "http.request.method", Value: c.Method()},
+			services.Attribute{Key: "url.path", Value: c.Path()},
+			services.Attribute{Key: "user_agent.original", Value: c.Get(fiber.HeaderUserAgent)},
+		)
+		defer span.Finish()
+		c.SetUserContext(ctx)
+
+		err := c.Next()
+
+		// The route is only known once the router has matched it
+		route := c.Route().Path
+		span.SetName(c.Method() + " " + route)
+		span.SetAttributes(services.Attribute{Key: "http.route", Value: route})
+
+		status := c.Response().StatusCode()
+		if err != nil {
+			// The error handler hasn't written the response yet
+			status = fiber.StatusInternalServerError
+			var fiberErr *fiber.Error
+			if errors.As(err, &fiberErr) {
+				status = fiberErr.Code
+			}
+		}
+		span.SetAttributes(services.Attribute{Key: "http.response.status_code", Value: status})
+		if status >= fiber.StatusInternalServerError {
+			span.SetStatus(services.SpanStatusError, "HTTP "+strconv.Itoa(status))
+		}
+
+		return err
+	}
+}
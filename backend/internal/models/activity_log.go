@@ -24,4 +24,8 @@ const (
 	ActionInvitationSent  = "invitation_sent"
 	ActionInvitationUsed  = "invitation_used"
 	ActionSettingChanged  = "setting_changed"
+	ActionEgressBlocked   = "egress_blocked"
+
+	ActionIncidentAcknowledged = "incident_acknowledged"
+	ActionIncidentNoteAdded    = "incident_note_added"
 )
@@ -0,0 +1,70 @@
+package models
+
+import "time"
+
+// AlertRule decides when and where a user's service outages are alerted
+// A service down for DelayMinutes is alerted on ChannelIDs, reminded every RepeatMinutes while it
+// stays down and escalated to EscalationChannelIDs after EscalationMinutes. Messages due during
+// quiet hours are held until they end
+type AlertRule struct {
+	ID                   string    `json:"id" db:"id"`
+	UserID               string    `json:"user_id" db:"user_id"`
+	ServiceID            *string   `json:"service_id" db:"service_id"` // nil applies the rule to all of the user's services
+	Name                 string    `json:"name" db:"name"`
+	Enabled              bool      `json:"enabled" db:"enabled"`
+	DelayMinutes         int       `json:"delay_minutes" db:"delay_minutes"`           // 0 alerts on the first failed check
+	RepeatMinutes        int       `json:"repeat_minutes" db:"repeat_minutes"`         // 0 disables reminders
+	EscalationMinutes    int       `json:"escalation_minutes" db:"escalation_minutes"` // 0 disables escalation
+	NotifyRecovery       bool      `json:"notify_recovery" db:"notify_recovery"`
+	QuietHoursStart      string    `json:"quiet_hours_start" db:"quiet_hours_start"` // "HH:MM", empty for none
+	QuietHoursEnd        string    `json:"quiet_hours_end" db:"quiet_hours_end"`
+	Timezone             string    `json:"timezone" db:"timezone"` // IANA name used for quiet hours
+	ChannelIDs           []string  `json:"channel_ids"`
+	EscalationChannelIDs []string  `json:"escalation_channel_ids"`
+	CreatedAt            time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt            time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// AppliesTo reports whether the rule covers a service
+func (r *AlertRule) AppliesTo(service *Service) bool {
+	if service.UserID != r.UserID {
+		return false
+	}
+	return r.ServiceID == nil || *r.ServiceID == service.ID
+}
+
+// AlertRuleRequest is the payload for creating or updating an alert rule
+type AlertRuleRequest struct {
+	Name                 string   `json:"name"`
+	ServiceID            *string  `json:"service_id"` // Omit or null for all services
+	Enabled              *bool    `json:"enabled"`    // Defaults to true
+	DelayMinutes         int      `json:"delay_minutes"`
+	RepeatMinutes        int      `json:"repeat_minutes"`
+	EscalationMinutes    int      `json:"escalation_minutes"`
+	NotifyRecovery       *bool    `json:"notify_recovery"` // Defaults to true
+	QuietHoursStart      string   `json:"quiet_hours_start"`
+	QuietHoursEnd        string   `json:"quiet_hours_end"`
+	Timezone             string   `json:"timezone"` // Defaults to UTC
+	ChannelIDs           []string `json:"channel_ids"`
+	EscalationChannelIDs []string `json:"escalation_channel_ids"`
+}
+
+// AlertState is a rule's progress for a service that is down
+type AlertState struct {
+	RuleID            string     `json:"rule_id" db:"rule_id"`
+	ServiceID         string     `json:"service_id" db:"service_id"`
+	DownSince         time.Time  `json:"down_since" db:"down_since"`
+	ErrorMessage      *string    `json:"error_message" db:"error_message"`       // Error of the check that took the service down
+	AlertedAt         *time.Time `json:"alerted_at" db:"alerted_at"`             // First alert (nil while delayed or held)
+	LastNotifiedAt    *time.Time `json:"last_notified_at" db:"last_notified_at"` // Last alert or reminder
+	NotificationCount int        `json:"notification_count" db:"notification_count"`
+	EscalatedAt       *time.Time `json:"escalated_at" db:"escalated_at"`
+	RecoveredAt       *time.Time `json:"recovered_at" db:"recovered_at"` // Recovery held by quiet hours
+}
+
+// ActiveAlert is a pending alert state with the names of its rule and service
+type ActiveAlert struct {
+	AlertState
+	RuleName    string `json:"rule_name"`
+	ServiceName string `json:"service_name"`
+}
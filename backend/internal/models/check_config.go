@@ -0,0 +1,119 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+)
+
+// Check type constants
+const (
+	CheckTypeHTTP        = "http"        // Single GET request to the service URL (default)
+	CheckTypeTransaction = "transaction" // Ordered list of HTTP steps (see TransactionConfig)
+)
+
+// Address family constants
+const (
+	AddressFamilyAuto = "auto" // Let the dialer pick (happy eyeballs, default)
+	AddressFamilyIPv4 = "ipv4"
+	AddressFamilyIPv6 = "ipv6"
+	AddressFamilyBoth = "both" // Check each family separately and record both results
+)
+
+// DNS protocol constants
+const (
+	DNSProtocolUDP = "udp" // Plain DNS over UDP, retried over TCP when truncated (default)
+	DNSProtocolTCP = "tcp"
+	DNSProtocolTLS = "tls" // DNS over TLS (RFC 7858)
+)
+
+// TLS version constants for TLSConfig.MinVersion
+const (
+	TLSVersion12 = "1.2" // Default
+	TLSVersion13 = "1.3"
+)
+
+// CheckConfig holds optional per-service health check settings
+// Stored as JSON in services.check_config so new options don't require a migration
+type CheckConfig struct {
+	Type            string             `json:"type,omitempty"`           // CheckTypeHTTP or CheckTypeTransaction (empty means CheckTypeHTTP)
+	AddressFamily   string             `json:"address_family,omitempty"` // AddressFamilyAuto, IPv4, IPv6, or Both (empty means auto)
+	Fingerprint     *FingerprintConfig `json:"fingerprint,omitempty"`
+	Transaction     *TransactionConfig `json:"transaction,omitempty"`
+	DNS             *DNSConfig         `json:"dns,omitempty"` // Overrides the global health check resolver
+	TLS             *TLSConfig         `json:"tls,omitempty"`
+	FreshConnection bool               `json:"fresh_connection,omitempty"` // New connection per check, so response times include DNS/TCP/TLS setup
+}
+
+// TLSConfig holds per-service TLS client options
+type TLSConfig struct {
+	ServerName string `json:"server_name,omitempty"` // SNI and certificate name override
+	MinVersion string `json:"min_version,omitempty"` // TLSVersion12 (default) or TLSVersion13
+}
+
+// DNSConfig selects the DNS server used to resolve a service's hostname
+// Useful for split-horizon setups where internal names only resolve on the LAN resolver
+type DNSConfig struct {
+	Server        string `json:"server"`                    // host or host:port (port defaults to 53, or 853 for tls)
+	Protocol      string `json:"protocol,omitempty"`        // DNSProtocolUDP, TCP, or TLS (empty means udp)
+	TLSServerName string `json:"tls_server_name,omitempty"` // Certificate name for tls (defaults to the server host)
+}
+
+// FingerprintConfig controls HTTP response fingerprinting for change detection
+type FingerprintConfig struct {
+	Enabled bool     `json:"enabled"`
+	Headers []string `json:"headers,omitempty"` // Response headers to include (defaults to DefaultFingerprintHeaders)
+}
+
+// DefaultFingerprintHeaders are used when fingerprinting is enabled without an explicit header list
+var DefaultFingerprintHeaders = []string{"Server"}
+
+// IsTransaction reports whether the service uses a multi-step transaction check
+func (c CheckConfig) IsTransaction() bool {
+	return c.Type == CheckTypeTransaction && c.Transaction != nil && len(c.Transaction.Steps) > 0
+}
+
+// FingerprintEnabled reports whether response fingerprinting is turned on
+func (c CheckConfig) FingerprintEnabled() bool {
+	return c.Fingerprint != nil && c.Fingerprint.Enabled
+}
+
+// FingerprintHeaders returns the headers to fingerprint, falling back to the defaults
+func (c CheckConfig) FingerprintHeaders() []string {
+	if c.Fingerprint == nil || len(c.Fingerprint.Headers) == 0 {
+		return DefaultFingerprintHeaders
+	}
+	return c.Fingerprint.Headers
+}
+
+// Value implements driver.Valuer so CheckConfig can be written as JSON
+func (c CheckConfig) Value() (driver.Value, error) {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+// Scan implements sql.Scanner so CheckConfig can be read from JSON/JSONB columns
+func (c *CheckConfig) Scan(src interface{}) error {
+	var data []byte
+	switch v := src.(type) {
+	case nil:
+		*c = CheckConfig{}
+		return nil
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return fmt.Errorf("unsupported type for CheckConfig: %T", src)
+	}
+
+	if len(data) == 0 {
+		*c = CheckConfig{}
+		return nil
+	}
+
+	return json.Unmarshal(data, c)
+}
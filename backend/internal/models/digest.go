@@ -0,0 +1,50 @@
+package models
+
+import "time"
+
+// ServiceCertificate is the leaf TLS certificate last seen by a service's health check
+type ServiceCertificate struct {
+	ServiceID   string    `json:"service_id" db:"service_id"`
+	ServiceName string    `json:"service_name" db:"-"`
+	Subject     string    `json:"subject" db:"subject"` // Common name (or first DNS name)
+	Issuer      string    `json:"issuer" db:"issuer"`
+	ExpiresAt   time.Time `json:"expires_at" db:"expires_at"`
+	CheckedAt   time.Time `json:"checked_at" db:"checked_at"` // When this certificate was first seen
+}
+
+// DigestReport summarizes a user's services over a period
+type DigestReport struct {
+	Title                string                 `json:"title"`
+	PeriodStart          time.Time              `json:"period_start"`
+	PeriodEnd            time.Time              `json:"period_end"`
+	Timezone             string                 `json:"timezone"`
+	UptimePercentage     float64                `json:"uptime_percentage"` // Average of the services' time-weighted uptime
+	Services             []DigestServiceSummary `json:"services"`          // Lowest uptime first
+	SlowestServices      []DigestServiceSummary `json:"slowest_services"`  // Highest average response time first
+	Incidents            []DigestIncident       `json:"incidents"`         // Incidents open at any time during the period, newest first
+	ExpiringCertificates []ServiceCertificate   `json:"expiring_certificates"`
+	GeneratedAt          time.Time              `json:"generated_at"`
+}
+
+// DigestServiceSummary is one service's line in a digest report
+type DigestServiceSummary struct {
+	ServiceID          string  `json:"service_id"`
+	Name               string  `json:"name"`
+	URL                string  `json:"url"`
+	Status             string  `json:"status"` // Current status
+	UptimePercentage   float64 `json:"uptime_percentage"`
+	CoveragePercentage float64 `json:"coverage_percentage"` // Share of the period with check results
+	AvgResponseTime    float64 `json:"avg_response_time"`
+	P95ResponseTime    float64 `json:"p95_response_time"`
+	TotalChecks        int     `json:"total_checks"`
+}
+
+// DigestIncident is one incident in a digest report
+type DigestIncident struct {
+	ID              string     `json:"id"`
+	Title           string     `json:"title"`
+	Status          string     `json:"status"`
+	StartedAt       time.Time  `json:"started_at"`
+	ResolvedAt      *time.Time `json:"resolved_at,omitempty"`
+	DurationSeconds float64    `json:"duration_seconds"` // Until resolution, or until the end of the period
+}
@@ -0,0 +1,56 @@
+package models
+
+import "time"
+
+// Domain expiry states
+const (
+	DomainStateOK       = "ok"
+	DomainStateWarning  = "warning"
+	DomainStateCritical = "critical"
+	DomainStateExpired  = "expired"
+	DomainStateUnknown  = "unknown" // Looked up, but the registry has no expiry date
+	DomainStatePending  = "pending" // Not looked up yet
+)
+
+// Domain sources
+const (
+	DomainSourceService = "service"
+	DomainSourceManual  = "manual"
+)
+
+// MonitoredDomain is a domain explicitly added by a user for expiry monitoring
+type MonitoredDomain struct {
+	ID        string    `json:"id" db:"id"`
+	UserID    string    `json:"user_id" db:"user_id"`
+	Domain    string    `json:"domain" db:"domain"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+// MonitoredDomainCreateRequest is the payload for adding a domain to monitor
+type MonitoredDomainCreateRequest struct {
+	Domain string `json:"domain"`
+}
+
+// DomainRegistration is cached RDAP registration data for a registrable domain
+type DomainRegistration struct {
+	Domain        string     `json:"domain" db:"domain"`
+	ExpiresAt     *time.Time `json:"expires_at" db:"expires_at"`
+	Registrar     *string    `json:"registrar" db:"registrar"`
+	LastCheckedAt time.Time  `json:"last_checked_at" db:"last_checked_at"`
+	LastError     *string    `json:"last_error" db:"last_error"`
+}
+
+// DomainStatus is the expiry status of a domain returned to clients
+type DomainStatus struct {
+	Domain        string     `json:"domain"`
+	Sources       []string   `json:"sources"`                // DomainSourceService and/or DomainSourceManual
+	ServiceIDs    []string   `json:"service_ids,omitempty"`  // Services whose URL uses this domain
+	MonitoredID   *string    `json:"monitored_id,omitempty"` // ID of the manual entry (for deletion)
+	ExpiresAt     *time.Time `json:"expires_at,omitempty"`
+	DaysRemaining *int       `json:"days_remaining,omitempty"`
+	Registrar     *string    `json:"registrar,omitempty"`
+	State         string     `json:"state"`           // DomainStateOK, DomainStateWarning, DomainStateCritical, DomainStateExpired, DomainStateUnknown or DomainStatePending
+	Stale         bool       `json:"stale,omitempty"` // The cached lookup is due for a refresh
+	LastCheckedAt *time.Time `json:"last_checked_at,omitempty"`
+	Error         *string    `json:"error,omitempty"`
+}
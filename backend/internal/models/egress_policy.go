@@ -0,0 +1,46 @@
+package models
+
+// SettingEgressPolicy is the system_settings key holding the JSON-encoded EgressPolicy
+const SettingEgressPolicy = "egress_policy"
+
+// Egress policy actions
+const (
+	EgressActionAllow = "allow"
+	EgressActionDeny  = "deny"
+)
+
+// Egress purposes identify which feature made an outbound connection
+const (
+	EgressPurposeHealthCheck = "health_check"
+	EgressPurposeIcon        = "icon"
+	EgressPurposeWebhook     = "webhook"
+)
+
+// EgressPolicy controls which destinations the server may connect to on behalf of users
+// Rules are checked after DNS resolution against every address that would be dialed:
+//  1. A destination matching any deny rule (host, CIDR or port) is blocked
+//  2. If allow_ports is set, other ports are blocked
+//  3. A destination matching allow_hosts or allow_cidrs is allowed
+//  4. Anything else gets default_action
+type EgressPolicy struct {
+	DefaultAction string   `json:"default_action"`        // EgressActionAllow or EgressActionDeny
+	AllowCIDRs    []string `json:"allow_cidrs,omitempty"` // e.g. "192.168.1.0/24" or a single IP
+	DenyCIDRs     []string `json:"deny_cidrs,omitempty"`
+	AllowPorts    []string `json:"allow_ports,omitempty"` // e.g. "443" or "8000-8999"
+	DenyPorts     []string `json:"deny_ports,omitempty"`
+	AllowHosts    []string `json:"allow_hosts,omitempty"` // e.g. "example.com" or "*.example.com"
+	DenyHosts     []string `json:"deny_hosts,omitempty"`
+}
+
+// DefaultEgressPolicy allows everything except cloud metadata endpoints
+// Homelab health checks need private ranges, so those are left to the admin to restrict
+func DefaultEgressPolicy() EgressPolicy {
+	return EgressPolicy{
+		DefaultAction: EgressActionAllow,
+		DenyCIDRs: []string{
+			"169.254.169.254/32", // AWS/GCP/Azure instance metadata
+			"168.63.129.16/32",   // Azure wire server
+			"fd00:ec2::254/128",  // AWS IPv6 instance metadata
+		},
+	}
+}
@@ -0,0 +1,53 @@
+package models
+
+import "time"
+
+// Fingerprint field names used in ChangedFields and status log event details
+const (
+	FingerprintFieldTitle       = "title"
+	FingerprintFieldBody        = "body"
+	FingerprintFieldCertificate = "certificate"
+	FingerprintFieldHeaderPfx   = "header:" // Prefixed with the lower-cased header name, e.g. "header:server"
+)
+
+// ServiceFingerprint represents a snapshot of the identifying parts of an HTTP response
+type ServiceFingerprint struct {
+	ID              string            `json:"id" db:"id"`
+	ServiceID       string            `json:"service_id" db:"service_id"`
+	Title           *string           `json:"title" db:"title"`                       // HTML <title> (nil for non-HTML responses)
+	BodyHash        string            `json:"body_hash" db:"body_hash"`               // SHA-256 of the normalized body
+	Headers         map[string]string `json:"headers" db:"headers"`                   // Selected response headers (lower-cased names)
+	CertFingerprint *string           `json:"cert_fingerprint" db:"cert_fingerprint"` // SHA-256 of the leaf certificate (nil for plain HTTP)
+	ChangedFields   []string          `json:"changed_fields" db:"changed_fields"`     // Fields that differ from the previous fingerprint
+	CreatedAt       time.Time         `json:"created_at" db:"created_at"`
+}
+
+// ServiceFingerprintResponse is the safe fingerprint data to return to clients
+type ServiceFingerprintResponse struct {
+	ID              string            `json:"id"`
+	ServiceID       string            `json:"service_id"`
+	Title           *string           `json:"title,omitempty"`
+	BodyHash        string            `json:"body_hash"`
+	Headers         map[string]string `json:"headers"`
+	CertFingerprint *string           `json:"cert_fingerprint,omitempty"`
+	ChangedFields   []string          `json:"changed_fields"`
+	CreatedAt       time.Time         `json:"created_at"`
+}
+
+// ToResponse converts ServiceFingerprint to ServiceFingerprintResponse
+func (f *ServiceFingerprint) ToResponse() ServiceFingerprintResponse {
+	changed := f.ChangedFields
+	if changed == nil {
+		changed = []string{}
+	}
+	return ServiceFingerprintResponse{
+		ID:              f.ID,
+		ServiceID:       f.ServiceID,
+		Title:           f.Title,
+		BodyHash:        f.BodyHash,
+		Headers:         f.Headers,
+		CertFingerprint: f.CertFingerprint,
+		ChangedFields:   changed,
+		CreatedAt:       f.CreatedAt,
+	}
+}
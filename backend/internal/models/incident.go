@@ -0,0 +1,93 @@
+package models
+
+import "time"
+
+// Incident statuses
+const (
+	IncidentStatusOpen         = "open"
+	IncidentStatusAcknowledged = "acknowledged" // Someone is on it: alert escalation is silenced
+	IncidentStatusResolved     = "resolved"
+)
+
+// Incident is the record of an outage affecting one or more of a user's services
+// Incidents open when a service goes offline and resolve once all their services are back online
+type Incident struct {
+	ID             string            `json:"id" db:"id"`
+	UserID         string            `json:"user_id" db:"user_id"`
+	Title          string            `json:"title" db:"title"`
+	Status         string            `json:"status" db:"status"`
+	StartedAt      time.Time         `json:"started_at" db:"started_at"`
+	AcknowledgedAt *time.Time        `json:"acknowledged_at" db:"acknowledged_at"`
+	AcknowledgedBy *string           `json:"acknowledged_by" db:"acknowledged_by"`
+	ResolvedAt     *time.Time        `json:"resolved_at" db:"resolved_at"`
+	ResolvedBy     *string           `json:"resolved_by" db:"resolved_by"` // nil when resolved on recovery
+	Services       []IncidentService `json:"services"`
+	CreatedAt      time.Time         `json:"created_at" db:"created_at"`
+	UpdatedAt      time.Time         `json:"updated_at" db:"updated_at"`
+}
+
+// IsResolved reports whether the incident is over
+func (i *Incident) IsResolved() bool {
+	return i.Status == IncidentStatusResolved
+}
+
+// IncidentService is a service affected by an incident
+type IncidentService struct {
+	ServiceID   string    `json:"service_id" db:"service_id"`
+	ServiceName string    `json:"service_name" db:"service_name"`
+	AddedAt     time.Time `json:"added_at" db:"added_at"`
+}
+
+// IncidentNote is a timestamped note on an incident
+type IncidentNote struct {
+	ID         string    `json:"id" db:"id"`
+	IncidentID string    `json:"incident_id" db:"incident_id"`
+	UserID     *string   `json:"user_id" db:"user_id"` // nil once the author is deleted
+	Body       string    `json:"body" db:"body"`
+	CreatedAt  time.Time `json:"created_at" db:"created_at"`
+}
+
+// IncidentDetail is an incident with its notes
+type IncidentDetail struct {
+	*Incident
+	Notes []*IncidentNote `json:"notes"`
+}
+
+// Incident timeline entry types
+const (
+	IncidentTimelineOpened        = "opened"
+	IncidentTimelineStatusChange  = "status_change" // A status event of an affected service
+	IncidentTimelineServiceLinked = "service_linked"
+	IncidentTimelineAcknowledged  = "acknowledged"
+	IncidentTimelineNote          = "note"
+	IncidentTimelineResolved      = "resolved"
+)
+
+// IncidentTimelineEntry is one thing that happened during an incident
+type IncidentTimelineEntry struct {
+	Type         string    `json:"type"`
+	OccurredAt   time.Time `json:"occurred_at"`
+	ServiceID    string    `json:"service_id,omitempty"`
+	ServiceName  string    `json:"service_name,omitempty"`
+	FromStatus   string    `json:"from_status,omitempty"`
+	ToStatus     string    `json:"to_status,omitempty"`
+	ErrorMessage *string   `json:"error_message,omitempty"`
+	UserID       *string   `json:"user_id,omitempty"` // Who acknowledged, resolved or wrote the note
+	Body         string    `json:"body,omitempty"`    // Note text
+}
+
+// CreateIncidentRequest is the payload for opening an incident manually
+type CreateIncidentRequest struct {
+	Title      string   `json:"title"`
+	ServiceIDs []string `json:"service_ids"`
+}
+
+// IncidentNoteRequest is the payload for adding a note
+type IncidentNoteRequest struct {
+	Body string `json:"body"`
+}
+
+// IncidentServicesRequest is the payload for linking services to an incident
+type IncidentServicesRequest struct {
+	ServiceIDs []string `json:"service_ids"`
+}
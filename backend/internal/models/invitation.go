@@ -43,3 +43,12 @@ func (inv *UserInvitation) ToResponse() InvitationResponse {
 type InviteUserRequest struct {
 	Email string `json:"email" validate:"required,email"`
 }
+
+// InvitationCreatedResponse is returned when an invitation is created
+// URL lets admins share the link themselves when email is not set up
+type InvitationCreatedResponse struct {
+	Invitation  InvitationResponse `json:"invitation"`
+	URL         string             `json:"url"`
+	EmailQueued bool               `json:"email_queued"`
+	EmailError  string             `json:"email_error,omitempty"` // Why the email was not queued
+}
@@ -0,0 +1,134 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// LatencySketchRelativeAccuracy is the maximum relative error of quantiles read from a LatencySketch
+const LatencySketchRelativeAccuracy = 0.02
+
+var (
+	latencySketchGamma    = (1 + LatencySketchRelativeAccuracy) / (1 - LatencySketchRelativeAccuracy)
+	latencySketchLogGamma = math.Log(latencySketchGamma)
+)
+
+// LatencySketch is a mergeable response time distribution (DDSketch-style log buckets)
+// Keys are bucket indexes and values are counts. Bucket i holds response times in
+// (gamma^(i-1), gamma^i] milliseconds; bucket 0 holds everything up to 1ms.
+// Sketches of adjacent periods merge exactly by adding counts, so rollups can report
+// percentiles over any range of buckets
+type LatencySketch map[int]int64
+
+// LatencySketchLogGamma returns ln(gamma), used to compute bucket indexes in SQL (see LatencySketchIndex)
+func LatencySketchLogGamma() float64 {
+	return latencySketchLogGamma
+}
+
+// LatencySketchIndex returns the bucket index of a response time in milliseconds
+func LatencySketchIndex(ms int) int {
+	if ms <= 1 {
+		return 0
+	}
+	return int(math.Ceil(math.Log(float64(ms)) / latencySketchLogGamma))
+}
+
+// latencySketchValue returns the representative response time of a bucket
+func latencySketchValue(index int) float64 {
+	if index <= 0 {
+		return 1
+	}
+	// Midpoint (in relative terms) of (gamma^(i-1), gamma^i]
+	return 2 * math.Pow(latencySketchGamma, float64(index)) / (latencySketchGamma + 1)
+}
+
+// Add records a response time in milliseconds
+func (s LatencySketch) Add(ms int) {
+	s[LatencySketchIndex(ms)]++
+}
+
+// Merge adds all counts of other into s
+func (s LatencySketch) Merge(other LatencySketch) {
+	for index, count := range other {
+		s[index] += count
+	}
+}
+
+// Count returns the number of recorded response times
+func (s LatencySketch) Count() int64 {
+	var total int64
+	for _, count := range s {
+		total += count
+	}
+	return total
+}
+
+// Quantile returns the estimated response time at quantile q (0-1), or 0 if the sketch is empty
+func (s LatencySketch) Quantile(q float64) float64 {
+	total := s.Count()
+	if total == 0 {
+		return 0
+	}
+
+	indexes := make([]int, 0, len(s))
+	for index := range s {
+		indexes = append(indexes, index)
+	}
+	sort.Ints(indexes)
+
+	rank := int64(q * float64(total-1))
+	var seen int64
+	for _, index := range indexes {
+		seen += s[index]
+		if seen > rank {
+			return latencySketchValue(index)
+		}
+	}
+
+	return latencySketchValue(indexes[len(indexes)-1])
+}
+
+// Buckets calls fn with the representative response time and count of every non-empty bucket
+func (s LatencySketch) Buckets(fn func(ms float64, count int64)) {
+	for index, count := range s {
+		fn(latencySketchValue(index), count)
+	}
+}
+
+// Value implements driver.Valuer so LatencySketch can be stored as JSON/JSONB
+func (s LatencySketch) Value() (driver.Value, error) {
+	if len(s) == 0 {
+		return nil, nil
+	}
+	data, err := json.Marshal(s)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+// Scan implements sql.Scanner for JSON/JSONB columns
+func (s *LatencySketch) Scan(src interface{}) error {
+	var data []byte
+	switch v := src.(type) {
+	case nil:
+		*s = LatencySketch{}
+		return nil
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return fmt.Errorf("unsupported type for LatencySketch: %T", src)
+	}
+
+	sketch := LatencySketch{}
+	if err := json.Unmarshal(data, &sketch); err != nil {
+		return err
+	}
+	*s = sketch
+	return nil
+}
@@ -0,0 +1,63 @@
+package models
+
+import (
+	"math"
+	"testing"
+)
+
+func TestLatencySketch_Quantile(t *testing.T) {
+	sketch := LatencySketch{}
+	for ms := 1; ms <= 1000; ms++ {
+		sketch.Add(ms)
+	}
+
+	if sketch.Count() != 1000 {
+		t.Fatalf("Expected 1000 values, got %d", sketch.Count())
+	}
+
+	for _, q := range []float64{0.5, 0.9, 0.95, 0.99} {
+		exact := q * 1000
+		got := sketch.Quantile(q)
+		if math.Abs(got-exact) > exact*LatencySketchRelativeAccuracy+1 {
+			t.Errorf("Quantile(%v) = %v, expected %v within %v%%", q, got, exact, LatencySketchRelativeAccuracy*100)
+		}
+	}
+
+	if empty := (LatencySketch{}).Quantile(0.5); empty != 0 {
+		t.Errorf("Expected 0 for an empty sketch, got %v", empty)
+	}
+}
+
+func TestLatencySketch_MergeAndScan(t *testing.T) {
+	first, second, combined := LatencySketch{}, LatencySketch{}, LatencySketch{}
+	for ms := 1; ms <= 500; ms++ {
+		first.Add(ms)
+		combined.Add(ms)
+	}
+	for ms := 501; ms <= 1000; ms++ {
+		second.Add(ms)
+		combined.Add(ms)
+	}
+
+	// Merging sketches of adjacent periods is exact
+	first.Merge(second)
+	if first.Quantile(0.9) != combined.Quantile(0.9) || first.Count() != combined.Count() {
+		t.Errorf("Expected merged sketch to equal the combined sketch")
+	}
+
+	value, err := first.Value()
+	if err != nil {
+		t.Fatalf("Value() error = %v", err)
+	}
+	var scanned LatencySketch
+	if err := scanned.Scan(value); err != nil {
+		t.Fatalf("Scan() error = %v", err)
+	}
+	if scanned.Count() != 1000 || scanned.Quantile(0.5) != first.Quantile(0.5) {
+		t.Errorf("Expected sketch to survive a JSON round trip")
+	}
+
+	if err := scanned.Scan(nil); err != nil || scanned.Count() != 0 {
+		t.Errorf("Expected NULL to scan as an empty sketch, got %v (%v)", scanned, err)
+	}
+}
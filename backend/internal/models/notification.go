@@ -0,0 +1,138 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Notification channel types
+const (
+	NotificationTypeWebhook = "webhook" // Generic HTTP request with a templated JSON body
+	NotificationTypeNtfy    = "ntfy"
+	NotificationTypeGotify  = "gotify"
+	NotificationTypeDiscord = "discord" // Discord incoming webhook
+	NotificationTypeSlack   = "slack"   // Slack incoming webhook
+	NotificationTypeEmail   = "email"   // Sent with the admin's SMTP settings
+)
+
+// Notification events
+const (
+	NotificationEventDown       = "service.down"       // A service went offline
+	NotificationEventUp         = "service.up"         // An offline service recovered
+	NotificationEventReminder   = "service.reminder"   // An alert rule's reminder that a service is still down
+	NotificationEventEscalation = "service.escalation" // An alert rule escalated an outage to more channels
+	NotificationEventDigest     = "report.digest"      // A user's scheduled digest report
+	NotificationEventTest       = "test"               // Sent from the test endpoint
+)
+
+// NotificationChannel is a destination for service status notifications
+// Channels without a user are global: added by an admin and selectable by every user
+type NotificationChannel struct {
+	ID        string                    `json:"id" db:"id"`
+	UserID    *string                   `json:"user_id" db:"user_id"` // nil for global channels
+	Name      string                    `json:"name" db:"name"`
+	Type      string                    `json:"type" db:"type"`
+	Config    NotificationChannelConfig `json:"config" db:"config"`
+	Enabled   bool                      `json:"enabled" db:"enabled"`
+	CreatedAt time.Time                 `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time                 `json:"updated_at" db:"updated_at"`
+}
+
+// IsGlobal reports whether the channel is an admin-managed global channel
+func (c *NotificationChannel) IsGlobal() bool {
+	return c.UserID == nil
+}
+
+// NotificationChannelConfig holds provider settings
+// Stored as JSON in notification_channels.config; each type uses a subset of the fields
+type NotificationChannelConfig struct {
+	URL          string            `json:"url,omitempty"`           // Webhook URL, or the ntfy/Gotify server
+	Method       string            `json:"method,omitempty"`        // webhook: POST (default) or PUT
+	Headers      map[string]string `json:"headers,omitempty"`       // webhook: extra request headers
+	BodyTemplate string            `json:"body_template,omitempty"` // webhook: Go template rendering the JSON body (default: the notification as JSON)
+	Topic        string            `json:"topic,omitempty"`         // ntfy topic
+	Token        string            `json:"token,omitempty"`         // ntfy access token or Gotify application token
+	Priority     *int              `json:"priority,omitempty"`      // ntfy (1-5) or Gotify (0-10) message priority
+	To           []string          `json:"to,omitempty"`            // email: recipient addresses
+}
+
+// Value implements driver.Valuer so NotificationChannelConfig can be written as JSON
+func (c NotificationChannelConfig) Value() (driver.Value, error) {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+// Scan implements sql.Scanner so NotificationChannelConfig can be read from JSON/JSONB columns
+func (c *NotificationChannelConfig) Scan(src interface{}) error {
+	var data []byte
+	switch v := src.(type) {
+	case nil:
+		*c = NotificationChannelConfig{}
+		return nil
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return fmt.Errorf("unsupported type for NotificationChannelConfig: %T", src)
+	}
+
+	if len(data) == 0 {
+		*c = NotificationChannelConfig{}
+		return nil
+	}
+	return json.Unmarshal(data, c)
+}
+
+// NotificationChannelRequest is the payload for creating or updating a notification channel
+type NotificationChannelRequest struct {
+	Name    string                    `json:"name"`
+	Type    string                    `json:"type"`
+	Config  NotificationChannelConfig `json:"config"`  // An empty token keeps the stored one on update
+	Enabled *bool                     `json:"enabled"` // Defaults to true
+	Global  bool                      `json:"global"`  // Admin only, ignored on update
+}
+
+// NotificationChannelResponse is the channel data returned to clients
+// Tokens are never returned; Config is omitted for global channels unless the caller is an admin
+type NotificationChannelResponse struct {
+	ID        string                     `json:"id"`
+	Name      string                     `json:"name"`
+	Type      string                     `json:"type"`
+	Global    bool                       `json:"global"`
+	Enabled   bool                       `json:"enabled"`
+	Config    *NotificationChannelConfig `json:"config,omitempty"`
+	HasToken  bool                       `json:"has_token"`
+	CreatedAt time.Time                  `json:"created_at"`
+	UpdatedAt time.Time                  `json:"updated_at"`
+}
+
+// ToResponse converts a channel to its client representation
+func (c *NotificationChannel) ToResponse(includeConfig bool) NotificationChannelResponse {
+	response := NotificationChannelResponse{
+		ID:        c.ID,
+		Name:      c.Name,
+		Type:      c.Type,
+		Global:    c.IsGlobal(),
+		Enabled:   c.Enabled,
+		HasToken:  c.Config.Token != "",
+		CreatedAt: c.CreatedAt,
+		UpdatedAt: c.UpdatedAt,
+	}
+	if includeConfig {
+		config := c.Config
+		config.Token = ""
+		response.Config = &config
+	}
+	return response
+}
+
+// ServiceNotificationChannelsRequest selects the channels a service notifies
+type ServiceNotificationChannelsRequest struct {
+	ChannelIDs []string `json:"channel_ids"`
+}
@@ -46,31 +46,60 @@ func (ns NullableString) GetValue() *string {
 
 // UserPreferences represents a user's theme and UI preferences
 type UserPreferences struct {
-	ID               string    `json:"id" db:"id"`
-	UserID           string    `json:"user_id" db:"user_id"`
-	ThemeMode        string    `json:"theme_mode" db:"theme_mode"`                 // "light" or "dark"
-	ThemeBackground  *string   `json:"theme_background" db:"theme_background"`     // Background image URL or color
-	ThemeAccentColor *string   `json:"theme_accent_color" db:"theme_accent_color"` // Hex color like #3B82F6
-	OpenInNewTab     bool      `json:"open_in_new_tab" db:"open_in_new_tab"`       // Whether to open services in new tab
-	CreatedAt        time.Time `json:"created_at" db:"created_at"`
-	UpdatedAt        time.Time `json:"updated_at" db:"updated_at"`
+	ID               string            `json:"id" db:"id"`
+	UserID           string            `json:"user_id" db:"user_id"`
+	ThemeMode        string            `json:"theme_mode" db:"theme_mode"`                 // "light" or "dark"
+	ThemeBackground  *string           `json:"theme_background" db:"theme_background"`     // Background image URL or color
+	ThemeAccentColor *string           `json:"theme_accent_color" db:"theme_accent_color"` // Hex color like #3B82F6
+	OpenInNewTab     bool              `json:"open_in_new_tab" db:"open_in_new_tab"`       // Whether to open services in new tab
+	Digest           DigestPreferences `json:"digest"`
+	CreatedAt        time.Time         `json:"created_at" db:"created_at"`
+	UpdatedAt        time.Time         `json:"updated_at" db:"updated_at"`
+}
+
+// Digest report defaults
+const (
+	DefaultDigestSchedule = "0 8 * * 1" // Mondays at 08:00
+	DefaultDigestTimezone = "UTC"
+)
+
+// DigestPreferences configures the periodic digest report
+type DigestPreferences struct {
+	Enabled    bool       `json:"enabled" db:"digest_enabled"`
+	Schedule   string     `json:"schedule" db:"digest_schedule"`       // Cron expression: minute hour day-of-month month day-of-week
+	Timezone   string     `json:"timezone" db:"digest_timezone"`       // IANA zone the schedule is evaluated in
+	Email      bool       `json:"email" db:"digest_email"`             // Send to the account's email address
+	ChannelIDs []string   `json:"channel_ids" db:"digest_channel_ids"` // Notification channels that receive the report
+	LastSentAt *time.Time `json:"last_sent_at" db:"digest_last_sent_at"`
+}
+
+// DefaultDigestPreferences returns the digest settings of a user who hasn't changed them
+func DefaultDigestPreferences() DigestPreferences {
+	return DigestPreferences{
+		Schedule:   DefaultDigestSchedule,
+		Timezone:   DefaultDigestTimezone,
+		Email:      true,
+		ChannelIDs: []string{},
+	}
 }
 
 // PreferencesUpdateRequest represents the data needed to update preferences
 type PreferencesUpdateRequest struct {
-	ThemeMode        *string        `json:"theme_mode" validate:"omitempty,oneof=light dark"`
-	ThemeBackground  NullableString `json:"theme_background"`   // Tracks presence separately from value
-	ThemeAccentColor NullableString `json:"theme_accent_color"` // Tracks presence separately from value
-	OpenInNewTab     *bool          `json:"open_in_new_tab"`    // Optional, defaults to true if not provided
+	ThemeMode        *string            `json:"theme_mode" validate:"omitempty,oneof=light dark"`
+	ThemeBackground  NullableString     `json:"theme_background"`   // Tracks presence separately from value
+	ThemeAccentColor NullableString     `json:"theme_accent_color"` // Tracks presence separately from value
+	OpenInNewTab     *bool              `json:"open_in_new_tab"`    // Optional, defaults to true if not provided
+	Digest           *DigestPreferences `json:"digest"`             // Optional, replaces the digest settings (last_sent_at is ignored)
 }
 
 // PreferencesResponse is the safe preferences data to return to clients
 type PreferencesResponse struct {
-	ThemeMode        string    `json:"theme_mode"`
-	ThemeBackground  *string   `json:"theme_background,omitempty"`
-	ThemeAccentColor *string   `json:"theme_accent_color,omitempty"`
-	OpenInNewTab     bool      `json:"open_in_new_tab"`
-	UpdatedAt        time.Time `json:"updated_at"`
+	ThemeMode        string            `json:"theme_mode"`
+	ThemeBackground  *string           `json:"theme_background,omitempty"`
+	ThemeAccentColor *string           `json:"theme_accent_color,omitempty"`
+	OpenInNewTab     bool              `json:"open_in_new_tab"`
+	Digest           DigestPreferences `json:"digest"`
+	UpdatedAt        time.Time         `json:"updated_at"`
 }
 
 // ToResponse converts UserPreferences to PreferencesResponse
@@ -80,6 +109,7 @@ func (p *UserPreferences) ToResponse() PreferencesResponse {
 		ThemeBackground:  p.ThemeBackground,
 		ThemeAccentColor: p.ThemeAccentColor,
 		OpenInNewTab:     p.OpenInNewTab,
+		Digest:           p.Digest,
 		UpdatedAt:        p.UpdatedAt,
 	}
 }
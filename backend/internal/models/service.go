@@ -22,55 +22,59 @@ const (
 
 // Service represents a service/link in the homelab dashboard
 type Service struct {
-	ID            string    `json:"id" db:"id"`
-	UserID        string    `json:"user_id" db:"user_id"`
-	Name          string    `json:"name" db:"name"`
-	URL           string    `json:"url" db:"url"`
-	Icon          string    `json:"icon" db:"icon"`                       // Emoji text (used when IconType is 'emoji')
-	IconType      string    `json:"icon_type" db:"icon_type"`             // 'emoji', 'image_upload', or 'image_url'
-	IconImagePath string    `json:"icon_image_path" db:"icon_image_path"` // File path or URL for image icons
-	Description   string    `json:"description" db:"description"`
-	Status        string    `json:"status" db:"status"`               // StatusOnline, StatusOffline, or StatusUnknown
-	ResponseTime  *int      `json:"response_time" db:"response_time"` // Response time in milliseconds (nil if never checked)
-	Position      int       `json:"position" db:"position"`           // User-defined position for dashboard ordering
-	CreatedAt     time.Time `json:"created_at" db:"created_at"`
-	UpdatedAt     time.Time `json:"updated_at" db:"updated_at"`
+	ID            string      `json:"id" db:"id"`
+	UserID        string      `json:"user_id" db:"user_id"`
+	Name          string      `json:"name" db:"name"`
+	URL           string      `json:"url" db:"url"`
+	Icon          string      `json:"icon" db:"icon"`                       // Emoji text (used when IconType is 'emoji')
+	IconType      string      `json:"icon_type" db:"icon_type"`             // 'emoji', 'image_upload', or 'image_url'
+	IconImagePath string      `json:"icon_image_path" db:"icon_image_path"` // File path or URL for image icons
+	Description   string      `json:"description" db:"description"`
+	Status        string      `json:"status" db:"status"`               // StatusOnline, StatusOffline, or StatusUnknown
+	ResponseTime  *int        `json:"response_time" db:"response_time"` // Response time in milliseconds (nil if never checked)
+	Position      int         `json:"position" db:"position"`           // User-defined position for dashboard ordering
+	CheckConfig   CheckConfig `json:"check_config" db:"check_config"`   // Optional per-service health check settings
+	CreatedAt     time.Time   `json:"created_at" db:"created_at"`
+	UpdatedAt     time.Time   `json:"updated_at" db:"updated_at"`
 }
 
 // ServiceCreateRequest represents the data needed to create a new service
 type ServiceCreateRequest struct {
-	Name          string `json:"name" validate:"required"`
-	URL           string `json:"url" validate:"required,url"`
-	Icon          string `json:"icon"`
-	IconType      string `json:"icon_type"`       // 'emoji', 'image_upload', or 'image_url'
-	IconImagePath string `json:"icon_image_path"` // File path or URL for image icons
-	Description   string `json:"description"`
+	Name          string       `json:"name" validate:"required"`
+	URL           string       `json:"url" validate:"required,url"`
+	Icon          string       `json:"icon"`
+	IconType      string       `json:"icon_type"`       // 'emoji', 'image_upload', or 'image_url'
+	IconImagePath string       `json:"icon_image_path"` // File path or URL for image icons
+	Description   string       `json:"description"`
+	CheckConfig   *CheckConfig `json:"check_config"` // Optional health check settings
 }
 
 // ServiceUpdateRequest represents the data needed to update a service
 type ServiceUpdateRequest struct {
-	Name          string `json:"name" validate:"required"`
-	URL           string `json:"url" validate:"required,url"`
-	Icon          string `json:"icon"`
-	IconType      string `json:"icon_type"`       // 'emoji', 'image_upload', or 'image_url'
-	IconImagePath string `json:"icon_image_path"` // File path or URL for image icons
-	Description   string `json:"description"`
+	Name          string       `json:"name" validate:"required"`
+	URL           string       `json:"url" validate:"required,url"`
+	Icon          string       `json:"icon"`
+	IconType      string       `json:"icon_type"`       // 'emoji', 'image_upload', or 'image_url'
+	IconImagePath string       `json:"icon_image_path"` // File path or URL for image icons
+	Description   string       `json:"description"`
+	CheckConfig   *CheckConfig `json:"check_config"` // Optional, preserved on update if omitted
 }
 
 // ServiceResponse is the safe service data to return to clients
 type ServiceResponse struct {
-	ID            string    `json:"id"`
-	Name          string    `json:"name"`
-	URL           string    `json:"url"`
-	Icon          string    `json:"icon"`
-	IconType      string    `json:"icon_type"`
-	IconImagePath string    `json:"icon_image_path,omitempty"` // Omitted if empty
-	Description   string    `json:"description"`
-	Status        string    `json:"status"`
-	ResponseTime  *int      `json:"response_time,omitempty"` // Response time in milliseconds (omitted if nil)
-	Position      int       `json:"position"`
-	CreatedAt     time.Time `json:"created_at"`
-	UpdatedAt     time.Time `json:"updated_at"`
+	ID            string      `json:"id"`
+	Name          string      `json:"name"`
+	URL           string      `json:"url"`
+	Icon          string      `json:"icon"`
+	IconType      string      `json:"icon_type"`
+	IconImagePath string      `json:"icon_image_path,omitempty"` // Omitted if empty
+	Description   string      `json:"description"`
+	Status        string      `json:"status"`
+	ResponseTime  *int        `json:"response_time,omitempty"` // Response time in milliseconds (omitted if nil)
+	Position      int         `json:"position"`
+	CheckConfig   CheckConfig `json:"check_config"`
+	CreatedAt     time.Time   `json:"created_at"`
+	UpdatedAt     time.Time   `json:"updated_at"`
 }
 
 // ToResponse converts Service to ServiceResponse
@@ -86,6 +90,7 @@ func (s *Service) ToResponse() ServiceResponse {
 		Status:        s.Status,
 		ResponseTime:  s.ResponseTime,
 		Position:      s.Position,
+		CheckConfig:   s.CheckConfig,
 		CreatedAt:     s.CreatedAt,
 		UpdatedAt:     s.UpdatedAt,
 	}
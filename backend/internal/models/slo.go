@@ -0,0 +1,51 @@
+package models
+
+import "time"
+
+// SLO window types
+const (
+	SLOWindowRolling  = "rolling"
+	SLOWindowCalendar = "calendar"
+)
+
+// SLO calendar periods
+const (
+	SLOPeriodWeek    = "week"
+	SLOPeriodMonth   = "month"
+	SLOPeriodQuarter = "quarter"
+)
+
+// SLO is a service level objective for one service or a group of services
+type SLO struct {
+	ID                 string    `json:"id" db:"id"`
+	UserID             string    `json:"user_id" db:"user_id"`
+	Name               string    `json:"name" db:"name"`
+	ServiceIDs         []string  `json:"service_ids" db:"service_ids"`                   // Services the objective covers
+	AvailabilityTarget float64   `json:"availability_target" db:"availability_target"`   // Percentage of time online, e.g. 99.5
+	LatencyThresholdMs *int      `json:"latency_threshold_ms" db:"latency_threshold_ms"` // Response time checks must stay within (nil without a latency objective)
+	LatencyTarget      *float64  `json:"latency_target" db:"latency_target"`             // Percentage of checks within the threshold, e.g. 95
+	WindowType         string    `json:"window_type" db:"window_type"`                   // SLOWindowRolling or SLOWindowCalendar
+	WindowDays         *int      `json:"window_days" db:"window_days"`                   // Length of a rolling window
+	CalendarPeriod     *string   `json:"calendar_period" db:"calendar_period"`           // SLOPeriodWeek, SLOPeriodMonth or SLOPeriodQuarter for calendar windows
+	Timezone           string    `json:"timezone" db:"timezone"`                         // IANA zone calendar windows are aligned to
+	CreatedAt          time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt          time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// HasLatencyObjective reports whether the SLO includes a latency target
+func (s *SLO) HasLatencyObjective() bool {
+	return s.LatencyThresholdMs != nil && s.LatencyTarget != nil
+}
+
+// SLORequest is the payload for creating or updating an SLO
+type SLORequest struct {
+	Name               string   `json:"name"`
+	ServiceIDs         []string `json:"service_ids"`
+	AvailabilityTarget float64  `json:"availability_target"`
+	LatencyThresholdMs *int     `json:"latency_threshold_ms"`
+	LatencyTarget      *float64 `json:"latency_target"`
+	WindowType         string   `json:"window_type"`
+	WindowDays         *int     `json:"window_days"`
+	CalendarPeriod     *string  `json:"calendar_period"`
+	Timezone           string   `json:"timezone"`
+}
@@ -0,0 +1,50 @@
+package models
+
+// SettingSMTP is the system_settings key holding the JSON-encoded SMTPSettings
+const SettingSMTP = "smtp"
+
+// SMTPSettings configure the server used to send email (alerts, invitations)
+type SMTPSettings struct {
+	Enabled       bool   `json:"enabled"`
+	Host          string `json:"host"`
+	Port          int    `json:"port"`     // Defaults to 587, 465 or 25 depending on security
+	Security      string `json:"security"` // "starttls" (default), "tls" (implicit TLS) or "none"
+	Username      string `json:"username"`
+	Password      string `json:"password,omitempty"` // Write-only; an empty password keeps the stored one
+	FromAddress   string `json:"from_address"`
+	FromName      string `json:"from_name"`
+	SkipTLSVerify bool   `json:"skip_tls_verify"` // Accept self-signed certificates
+}
+
+// SMTPSettingsResponse is the settings data returned to admins (without the password)
+type SMTPSettingsResponse struct {
+	Enabled       bool   `json:"enabled"`
+	Host          string `json:"host"`
+	Port          int    `json:"port"`
+	Security      string `json:"security"`
+	Username      string `json:"username"`
+	HasPassword   bool   `json:"has_password"`
+	FromAddress   string `json:"from_address"`
+	FromName      string `json:"from_name"`
+	SkipTLSVerify bool   `json:"skip_tls_verify"`
+}
+
+// ToResponse converts SMTPSettings to SMTPSettingsResponse
+func (s SMTPSettings) ToResponse() SMTPSettingsResponse {
+	return SMTPSettingsResponse{
+		Enabled:       s.Enabled,
+		Host:          s.Host,
+		Port:          s.Port,
+		Security:      s.Security,
+		Username:      s.Username,
+		HasPassword:   s.Password != "",
+		FromAddress:   s.FromAddress,
+		FromName:      s.FromName,
+		SkipTLSVerify: s.SkipTLSVerify,
+	}
+}
+
+// SendTestEmailRequest is the payload for sending a test email
+type SendTestEmailRequest struct {
+	To string `json:"to"` // Defaults to the admin's own address
+}
@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// StatusEvent is an effective status transition of a service
+type StatusEvent struct {
+	ID                 string    `json:"id" db:"id"`
+	ServiceID          string    `json:"service_id" db:"service_id"`
+	FromStatus         string    `json:"from_status" db:"from_status"`                   // Status before the transition
+	ToStatus           string    `json:"to_status" db:"to_status"`                       // Status after the transition
+	OccurredAt         time.Time `json:"occurred_at" db:"occurred_at"`                   // Time of the check that changed the status
+	PreviousDurationMs *int64    `json:"previous_duration_ms" db:"previous_duration_ms"` // Time spent in FromStatus (nil if unknown)
+	ErrorMessage       *string   `json:"error_message" db:"error_message"`               // Error of the triggering check (nil if it succeeded)
+}
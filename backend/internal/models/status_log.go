@@ -2,34 +2,54 @@ package models
 
 import "time"
 
+// Status log event constants
+const (
+	EventFingerprintChanged = "fingerprint_changed"
+)
+
 // StatusLog represents a historical health check result
 type StatusLog struct {
-	ID           string    `json:"id" db:"id"`
-	ServiceID    string    `json:"service_id" db:"service_id"`
-	Status       string    `json:"status" db:"status"`               // StatusOnline, StatusOffline, or StatusUnknown
-	ResponseTime *int      `json:"response_time" db:"response_time"` // Response time in milliseconds (nil if check failed)
-	ErrorMessage *string   `json:"error_message" db:"error_message"` // Error details if check failed (nil if successful)
-	CheckedAt    time.Time `json:"checked_at" db:"checked_at"`
+	ID            string      `json:"id" db:"id"`
+	ServiceID     string      `json:"service_id" db:"service_id"`
+	Status        string      `json:"status" db:"status"`                 // StatusOnline, StatusOffline, or StatusUnknown
+	ResponseTime  *int        `json:"response_time" db:"response_time"`   // Response time in milliseconds (nil if check failed)
+	ErrorMessage  *string     `json:"error_message" db:"error_message"`   // Error details if check failed (nil if successful)
+	Event         *string     `json:"event" db:"event"`                   // Notable event detected during the check (nil if none)
+	EventDetail   *string     `json:"event_detail" db:"event_detail"`     // Human-readable event details
+	FailedStep    *int        `json:"failed_step" db:"failed_step"`       // 1-based transaction step that failed (nil if none)
+	StepTimings   StepTimings `json:"step_timings" db:"step_timings"`     // Per-step results of a transaction check
+	AddressFamily *string     `json:"address_family" db:"address_family"` // AddressFamilyIPv4 or AddressFamilyIPv6 used for the check (nil if unknown)
+	CheckedAt     time.Time   `json:"checked_at" db:"checked_at"`
 }
 
 // StatusLogResponse is the safe status log data to return to clients
 type StatusLogResponse struct {
-	ID           string    `json:"id"`
-	ServiceID    string    `json:"service_id"`
-	Status       string    `json:"status"`
-	ResponseTime *int      `json:"response_time,omitempty"`
-	ErrorMessage *string   `json:"error_message,omitempty"`
-	CheckedAt    time.Time `json:"checked_at"`
+	ID            string      `json:"id"`
+	ServiceID     string      `json:"service_id"`
+	Status        string      `json:"status"`
+	ResponseTime  *int        `json:"response_time,omitempty"`
+	ErrorMessage  *string     `json:"error_message,omitempty"`
+	Event         *string     `json:"event,omitempty"`
+	EventDetail   *string     `json:"event_detail,omitempty"`
+	FailedStep    *int        `json:"failed_step,omitempty"`
+	StepTimings   StepTimings `json:"step_timings,omitempty"`
+	AddressFamily *string     `json:"address_family,omitempty"`
+	CheckedAt     time.Time   `json:"checked_at"`
 }
 
 // ToResponse converts StatusLog to StatusLogResponse
 func (sl *StatusLog) ToResponse() StatusLogResponse {
 	return StatusLogResponse{
-		ID:           sl.ID,
-		ServiceID:    sl.ServiceID,
-		Status:       sl.Status,
-		ResponseTime: sl.ResponseTime,
-		ErrorMessage: sl.ErrorMessage,
-		CheckedAt:    sl.CheckedAt,
+		ID:            sl.ID,
+		ServiceID:     sl.ServiceID,
+		Status:        sl.Status,
+		ResponseTime:  sl.ResponseTime,
+		ErrorMessage:  sl.ErrorMessage,
+		Event:         sl.Event,
+		EventDetail:   sl.EventDetail,
+		FailedStep:    sl.FailedStep,
+		StepTimings:   sl.StepTimings,
+		AddressFamily: sl.AddressFamily,
+		CheckedAt:     sl.CheckedAt,
 	}
 }
@@ -0,0 +1,118 @@
+package models
+
+import "time"
+
+// Announcement severities
+const (
+	AnnouncementSeverityInfo        = "info"
+	AnnouncementSeverityMaintenance = "maintenance"
+	AnnouncementSeverityWarning     = "warning"
+)
+
+// Overall status of a public status page
+const (
+	StatusPageOperational   = "operational"    // No service on the page is offline
+	StatusPagePartialOutage = "partial_outage" // Some services are offline
+	StatusPageMajorOutage   = "major_outage"   // Every checked service is offline
+)
+
+// StatusPage is a public page showing some of a user's services, served by slug without an account
+type StatusPage struct {
+	ID           string            `json:"id" db:"id"`
+	UserID       string            `json:"user_id" db:"user_id"`
+	Slug         string            `json:"slug" db:"slug"`
+	Title        string            `json:"title" db:"title"`
+	Description  string            `json:"description" db:"description"`
+	Timezone     string            `json:"timezone" db:"timezone"` // IANA zone the daily uptime bars are aligned to
+	PasswordHash *string           `json:"-" db:"password_hash"`   // bcrypt hash (nil for a public page)
+	HasPassword  bool              `json:"has_password" db:"-"`    // Whether visitors need the password
+	Services     []StatusPageEntry `json:"services" db:"-"`        // In display order
+	CreatedAt    time.Time         `json:"created_at" db:"created_at"`
+	UpdatedAt    time.Time         `json:"updated_at" db:"updated_at"`
+}
+
+// StatusPageEntry is a service shown on a status page
+type StatusPageEntry struct {
+	ServiceID   string  `json:"service_id" db:"service_id"`
+	DisplayName *string `json:"display_name" db:"display_name"` // Shown instead of the service name (nil for the service name)
+	HideURL     bool    `json:"hide_url" db:"hide_url"`         // Leave the service URL off the public page
+}
+
+// StatusPageRequest is the payload for creating or updating a status page
+type StatusPageRequest struct {
+	Slug        string            `json:"slug"`
+	Title       string            `json:"title"`
+	Description string            `json:"description"`
+	Timezone    string            `json:"timezone"`
+	Password    *string           `json:"password"` // nil keeps the current password, "" removes it
+	Services    []StatusPageEntry `json:"services"`
+}
+
+// StatusPageAnnouncement is a notice shown on a status page while it is active
+type StatusPageAnnouncement struct {
+	ID           string     `json:"id" db:"id"`
+	StatusPageID string     `json:"status_page_id" db:"status_page_id"`
+	Title        string     `json:"title" db:"title"`
+	Body         string     `json:"body" db:"body"`
+	Severity     string     `json:"severity" db:"severity"` // AnnouncementSeverityInfo, AnnouncementSeverityMaintenance or AnnouncementSeverityWarning
+	StartsAt     time.Time  `json:"starts_at" db:"starts_at"`
+	EndsAt       *time.Time `json:"ends_at" db:"ends_at"` // nil to show it until it is deleted
+	CreatedAt    time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// AnnouncementRequest is the payload for creating or updating an announcement
+type AnnouncementRequest struct {
+	Title    string     `json:"title"`
+	Body     string     `json:"body"`
+	Severity string     `json:"severity"`  // Defaults to AnnouncementSeverityInfo
+	StartsAt *time.Time `json:"starts_at"` // Defaults to now
+	EndsAt   *time.Time `json:"ends_at"`
+}
+
+// PublicStatusPage is what visitors of a status page see
+type PublicStatusPage struct {
+	Slug              string                     `json:"slug"`
+	Title             string                     `json:"title"`
+	Description       string                     `json:"description"`
+	Timezone          string                     `json:"timezone"`
+	PasswordProtected bool                       `json:"password_protected"`
+	Status            string                     `json:"status"` // StatusPageOperational, StatusPagePartialOutage or StatusPageMajorOutage
+	Services          []PublicStatusService      `json:"services"`
+	Incidents         []PublicStatusIncident     `json:"incidents"`     // Unresolved incidents affecting the page's services
+	Announcements     []PublicStatusAnnouncement `json:"announcements"` // Active announcements, newest first
+	GeneratedAt       time.Time                  `json:"generated_at"`
+}
+
+// PublicStatusService is a service on a public status page
+type PublicStatusService struct {
+	Name             string          `json:"name"`
+	URL              string          `json:"url,omitempty"` // Empty when hidden
+	Status           string          `json:"status"`
+	UptimePercentage *float64        `json:"uptime_percentage"` // Over the days shown (nil without data)
+	Days             []StatusPageDay `json:"days"`              // Oldest first, ending today
+}
+
+// StatusPageDay is one bar of a service's uptime history
+type StatusPageDay struct {
+	Date             string   `json:"date"`              // YYYY-MM-DD in the page's timezone
+	UptimePercentage *float64 `json:"uptime_percentage"` // nil without check results that day
+}
+
+// PublicStatusIncident is an unresolved incident on a public status page
+type PublicStatusIncident struct {
+	Title          string     `json:"title"` // Built from the display names, e.g. "Plex is down" (the stored title may name the real service)
+	Status         string     `json:"status"`
+	StartedAt      time.Time  `json:"started_at"`
+	AcknowledgedAt *time.Time `json:"acknowledged_at"`
+	Services       []string   `json:"services"` // Display names of the affected services on the page
+}
+
+// PublicStatusAnnouncement is an announcement on a public status page
+type PublicStatusAnnouncement struct {
+	Title    string     `json:"title"`
+	Body     string     `json:"body"`
+	Severity string     `json:"severity"`
+	StartsAt time.Time  `json:"starts_at"`
+	EndsAt   *time.Time `json:"ends_at"`
+}
@@ -0,0 +1,95 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+)
+
+// Extraction sources for transaction step variables
+const (
+	ExtractSourceCookie = "cookie" // Value of a cookie in the shared jar
+	ExtractSourceHeader = "header" // Value of a response header
+	ExtractSourceJSON   = "json"   // Field of a JSON response body (dot path, e.g. "data.items.0.id")
+	ExtractSourceRegex  = "regex"  // Capture group of a regex applied to the response body
+)
+
+// TransactionConfig is an ordered list of HTTP steps run as a single check
+// Variables extracted by a step can be referenced in later steps as {{name}}
+type TransactionConfig struct {
+	Steps []TransactionStep `json:"steps"`
+}
+
+// TransactionStep is a single HTTP request within a transaction check
+type TransactionStep struct {
+	Name    string            `json:"name,omitempty"`
+	Method  string            `json:"method,omitempty"` // Defaults to GET
+	URL     string            `json:"url"`              // Absolute, or relative to the service URL
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    string            `json:"body,omitempty"`
+
+	Extract []TransactionExtract `json:"extract,omitempty"`
+	Assert  *TransactionAssert   `json:"assert,omitempty"`
+}
+
+// TransactionExtract captures a value from a step's response into a variable
+type TransactionExtract struct {
+	Name   string `json:"name"`
+	Source string `json:"source"`          // ExtractSourceCookie, ExtractSourceHeader, ExtractSourceJSON, or ExtractSourceRegex
+	Key    string `json:"key"`             // Cookie/header name, JSON path, or regex pattern
+	Group  int    `json:"group,omitempty"` // Regex capture group (defaults to 1; the regex must have one)
+}
+
+// TransactionAssert defines the conditions a step's response must meet
+type TransactionAssert struct {
+	Status       []int  `json:"status,omitempty"` // Accepted status codes (defaults to any 2xx/3xx)
+	BodyContains string `json:"body_contains,omitempty"`
+	BodyRegex    string `json:"body_regex,omitempty"`
+}
+
+// StepTiming records the outcome of a single transaction step
+type StepTiming struct {
+	Step       int    `json:"step"` // 1-based step number
+	Name       string `json:"name,omitempty"`
+	StatusCode int    `json:"status_code,omitempty"`
+	DurationMs int    `json:"duration_ms"`
+	Error      string `json:"error,omitempty"`
+}
+
+// StepTimings is the per-step timing breakdown of a transaction check, stored as JSON
+type StepTimings []StepTiming
+
+// Value implements driver.Valuer; empty timings are stored as NULL
+func (s StepTimings) Value() (driver.Value, error) {
+	if len(s) == 0 {
+		return nil, nil
+	}
+	data, err := json.Marshal(s)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+// Scan implements sql.Scanner for JSON/JSONB columns
+func (s *StepTimings) Scan(src interface{}) error {
+	var data []byte
+	switch v := src.(type) {
+	case nil:
+		*s = nil
+		return nil
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return fmt.Errorf("unsupported type for StepTimings: %T", src)
+	}
+
+	if len(data) == 0 {
+		*s = nil
+		return nil
+	}
+
+	return json.Unmarshal(data, s)
+}
@@ -47,9 +47,10 @@ type LoginRequest struct {
 
 // RegisterRequest represents registration data
 type RegisterRequest struct {
-	Name     string `json:"name" validate:"required"`
-	Email    string `json:"email" validate:"required,email"`
-	Password string `json:"password" validate:"required,min=8"`
+	Name        string `json:"name" validate:"required"`
+	Email       string `json:"email" validate:"required,email"`
+	Password    string `json:"password" validate:"required,min=8"`
+	InviteToken string `json:"invite_token,omitempty"` // From an invitation link (optional); must match Email
 }
 
 // AuthResponse represents authentication response with token
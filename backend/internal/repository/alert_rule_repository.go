@@ -0,0 +1,400 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/nimbus/backend/internal/models"
+)
+
+type AlertRuleRepository struct {
+	db *sql.DB
+}
+
+func NewAlertRuleRepository(db *sql.DB) *AlertRuleRepository {
+	return &AlertRuleRepository{db: db}
+}
+
+const alertRuleColumns = `id, user_id, service_id, name, enabled, delay_minutes, repeat_minutes, escalation_minutes,
+	notify_recovery, quiet_hours_start, quiet_hours_end, timezone, created_at, updated_at`
+
+// Create stores a new alert rule and its channels
+func (r *AlertRuleRepository) Create(ctx context.Context, rule *models.AlertRule) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `
+		INSERT INTO alert_rules (user_id, service_id, name, enabled, delay_minutes, repeat_minutes, escalation_minutes,
+			notify_recovery, quiet_hours_start, quiet_hours_end, timezone, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+		RETURNING id
+	`
+
+	err = tx.QueryRowContext(
+		ctx,
+		query,
+		rule.UserID,
+		rule.ServiceID,
+		rule.Name,
+		rule.Enabled,
+		rule.DelayMinutes,
+		rule.RepeatMinutes,
+		rule.EscalationMinutes,
+		rule.NotifyRecovery,
+		nullString(rule.QuietHoursStart),
+		nullString(rule.QuietHoursEnd),
+		rule.Timezone,
+		rule.CreatedAt,
+		rule.UpdatedAt,
+	).Scan(&rule.ID)
+	if err != nil {
+		return fmt.Errorf("failed to create alert rule: %w", err)
+	}
+
+	if err := setAlertRuleChannels(ctx, tx, rule); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// GetByID retrieves an alert rule
+// Returns sql.ErrNoRows if it doesn't exist
+func (r *AlertRuleRepository) GetByID(ctx context.Context, id string) (*models.AlertRule, error) {
+	rules, err := r.query(ctx, `SELECT `+alertRuleColumns+` FROM alert_rules WHERE id = $1`, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(rules) == 0 {
+		return nil, sql.ErrNoRows
+	}
+	return rules[0], nil
+}
+
+// GetByUserID retrieves a user's alert rules
+func (r *AlertRuleRepository) GetByUserID(ctx context.Context, userID string) ([]*models.AlertRule, error) {
+	return r.query(ctx, `SELECT `+alertRuleColumns+` FROM alert_rules WHERE user_id = $1 ORDER BY name ASC`, userID)
+}
+
+// GetEnabledForService retrieves the enabled rules covering a service: its own and the owner's catch-all rules
+func (r *AlertRuleRepository) GetEnabledForService(ctx context.Context, userID, serviceID string) ([]*models.AlertRule, error) {
+	query := `
+		SELECT ` + alertRuleColumns + `
+		FROM alert_rules
+		WHERE user_id = $1 AND (service_id = $2 OR service_id IS NULL) AND enabled = TRUE
+		ORDER BY name ASC
+	`
+	return r.query(ctx, query, userID, serviceID)
+}
+
+// Update saves an alert rule and replaces its channels
+// Returns sql.ErrNoRows if it doesn't exist
+func (r *AlertRuleRepository) Update(ctx context.Context, rule *models.AlertRule) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `
+		UPDATE alert_rules
+		SET service_id = $1, name = $2, enabled = $3, delay_minutes = $4, repeat_minutes = $5, escalation_minutes = $6,
+			notify_recovery = $7, quiet_hours_start = $8, quiet_hours_end = $9, timezone = $10, updated_at = $11
+		WHERE id = $12
+	`
+
+	result, err := tx.ExecContext(
+		ctx,
+		query,
+		rule.ServiceID,
+		rule.Name,
+		rule.Enabled,
+		rule.DelayMinutes,
+		rule.RepeatMinutes,
+		rule.EscalationMinutes,
+		rule.NotifyRecovery,
+		nullString(rule.QuietHoursStart),
+		nullString(rule.QuietHoursEnd),
+		rule.Timezone,
+		rule.UpdatedAt,
+		rule.ID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update alert rule: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM alert_rule_channels WHERE rule_id = $1`, rule.ID); err != nil {
+		return fmt.Errorf("failed to clear alert rule channels: %w", err)
+	}
+	if err := setAlertRuleChannels(ctx, tx, rule); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Delete removes an alert rule with its channels and states
+// Returns sql.ErrNoRows if it doesn't exist
+func (r *AlertRuleRepository) Delete(ctx context.Context, id string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM alert_states WHERE rule_id = $1`, id); err != nil {
+		return fmt.Errorf("failed to delete alert states: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM alert_rule_channels WHERE rule_id = $1`, id); err != nil {
+		return fmt.Errorf("failed to delete alert rule channels: %w", err)
+	}
+
+	result, err := tx.ExecContext(ctx, `DELETE FROM alert_rules WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete alert rule: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return tx.Commit()
+}
+
+const alertStateColumns = `rule_id, service_id, down_since, error_message, alerted_at, last_notified_at,
+	notification_count, escalated_at, recovered_at`
+
+// GetState retrieves a rule's state for a service
+// Returns sql.ErrNoRows if the rule has nothing pending for the service
+func (r *AlertRuleRepository) GetState(ctx context.Context, ruleID, serviceID string) (*models.AlertState, error) {
+	states, err := r.queryStates(ctx, `SELECT `+alertStateColumns+` FROM alert_states WHERE rule_id = $1 AND service_id = $2`, ruleID, serviceID)
+	if err != nil {
+		return nil, err
+	}
+	if len(states) == 0 {
+		return nil, sql.ErrNoRows
+	}
+	return states[0], nil
+}
+
+// GetStates retrieves all pending states
+func (r *AlertRuleRepository) GetStates(ctx context.Context) ([]*models.AlertState, error) {
+	return r.queryStates(ctx, `SELECT `+alertStateColumns+` FROM alert_states ORDER BY down_since ASC`)
+}
+
+// GetStatesByServiceID retrieves the pending states of a service
+func (r *AlertRuleRepository) GetStatesByServiceID(ctx context.Context, serviceID string) ([]*models.AlertState, error) {
+	return r.queryStates(ctx, `SELECT `+alertStateColumns+` FROM alert_states WHERE service_id = $1`, serviceID)
+}
+
+// GetStatesByUserID retrieves the pending states of a user's rules
+func (r *AlertRuleRepository) GetStatesByUserID(ctx context.Context, userID string) ([]*models.AlertState, error) {
+	query := `
+		SELECT s.rule_id, s.service_id, s.down_since, s.error_message, s.alerted_at, s.last_notified_at,
+			s.notification_count, s.escalated_at, s.recovered_at
+		FROM alert_states s
+		JOIN alert_rules r ON r.id = s.rule_id
+		WHERE r.user_id = $1
+		ORDER BY s.down_since ASC
+	`
+	return r.queryStates(ctx, query, userID)
+}
+
+// SaveState creates or replaces a rule's state for a service
+func (r *AlertRuleRepository) SaveState(ctx context.Context, state *models.AlertState) error {
+	query := `
+		INSERT INTO alert_states (rule_id, service_id, down_since, error_message, alerted_at, last_notified_at,
+			notification_count, escalated_at, recovered_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		ON CONFLICT (rule_id, service_id)
+		DO UPDATE SET
+			down_since = EXCLUDED.down_since,
+			error_message = EXCLUDED.error_message,
+			alerted_at = EXCLUDED.alerted_at,
+			last_notified_at = EXCLUDED.last_notified_at,
+			notification_count = EXCLUDED.notification_count,
+			escalated_at = EXCLUDED.escalated_at,
+			recovered_at = EXCLUDED.recovered_at
+	`
+
+	_, err := r.db.ExecContext(
+		ctx,
+		query,
+		state.RuleID,
+		state.ServiceID,
+		state.DownSince,
+		state.ErrorMessage,
+		state.AlertedAt,
+		state.LastNotifiedAt,
+		state.NotificationCount,
+		state.EscalatedAt,
+		state.RecoveredAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save alert state: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteState removes a rule's state for a service
+func (r *AlertRuleRepository) DeleteState(ctx context.Context, ruleID, serviceID string) error {
+	if _, err := r.db.ExecContext(ctx, `DELETE FROM alert_states WHERE rule_id = $1 AND service_id = $2`, ruleID, serviceID); err != nil {
+		return fmt.Errorf("failed to delete alert state: %w", err)
+	}
+	return nil
+}
+
+// DeleteStatesByRuleID removes all of a rule's states
+func (r *AlertRuleRepository) DeleteStatesByRuleID(ctx context.Context, ruleID string) error {
+	if _, err := r.db.ExecContext(ctx, `DELETE FROM alert_states WHERE rule_id = $1`, ruleID); err != nil {
+		return fmt.Errorf("failed to delete alert states: %w", err)
+	}
+	return nil
+}
+
+func setAlertRuleChannels(ctx context.Context, tx *sql.Tx, rule *models.AlertRule) error {
+	for _, group := range []struct {
+		ids        []string
+		escalation bool
+	}{
+		{rule.ChannelIDs, false},
+		{rule.EscalationChannelIDs, true},
+	} {
+		for _, channelID := range group.ids {
+			_, err := tx.ExecContext(ctx, `INSERT INTO alert_rule_channels (rule_id, channel_id, escalation) VALUES ($1, $2, $3)`, rule.ID, channelID, group.escalation)
+			if err != nil {
+				return fmt.Errorf("failed to add alert rule channel: %w", err)
+			}
+		}
+	}
+	return nil
+}
+
+func (r *AlertRuleRepository) query(ctx context.Context, query string, args ...interface{}) ([]*models.AlertRule, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get alert rules: %w", err)
+	}
+	defer rows.Close()
+
+	var rules []*models.AlertRule
+	for rows.Next() {
+		rule := &models.AlertRule{ChannelIDs: []string{}, EscalationChannelIDs: []string{}}
+		var serviceID, quietStart, quietEnd sql.NullString
+
+		err := rows.Scan(
+			&rule.ID,
+			&rule.UserID,
+			&serviceID,
+			&rule.Name,
+			&rule.Enabled,
+			&rule.DelayMinutes,
+			&rule.RepeatMinutes,
+			&rule.EscalationMinutes,
+			&rule.NotifyRecovery,
+			&quietStart,
+			&quietEnd,
+			&rule.Timezone,
+			&rule.CreatedAt,
+			&rule.UpdatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan alert rule: %w", err)
+		}
+
+		if serviceID.Valid {
+			rule.ServiceID = &serviceID.String
+		}
+		rule.QuietHoursStart = quietStart.String
+		rule.QuietHoursEnd = quietEnd.String
+
+		rules = append(rules, rule)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	for _, rule := range rules {
+		if err := r.loadChannels(ctx, rule); err != nil {
+			return nil, err
+		}
+	}
+
+	return rules, nil
+}
+
+func (r *AlertRuleRepository) loadChannels(ctx context.Context, rule *models.AlertRule) error {
+	rows, err := r.db.QueryContext(ctx, `SELECT channel_id, escalation FROM alert_rule_channels WHERE rule_id = $1 ORDER BY channel_id`, rule.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get alert rule channels: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var channelID string
+		var escalation bool
+		if err := rows.Scan(&channelID, &escalation); err != nil {
+			return fmt.Errorf("failed to scan alert rule channel: %w", err)
+		}
+		if escalation {
+			rule.EscalationChannelIDs = append(rule.EscalationChannelIDs, channelID)
+		} else {
+			rule.ChannelIDs = append(rule.ChannelIDs, channelID)
+		}
+	}
+
+	return rows.Err()
+}
+
+func (r *AlertRuleRepository) queryStates(ctx context.Context, query string, args ...interface{}) ([]*models.AlertState, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get alert states: %w", err)
+	}
+	defer rows.Close()
+
+	var states []*models.AlertState
+	for rows.Next() {
+		state := &models.AlertState{}
+		err := rows.Scan(
+			&state.RuleID,
+			&state.ServiceID,
+			&state.DownSince,
+			&state.ErrorMessage,
+			&state.AlertedAt,
+			&state.LastNotifiedAt,
+			&state.NotificationCount,
+			&state.EscalatedAt,
+			&state.RecoveredAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan alert state: %w", err)
+		}
+		states = append(states, state)
+	}
+
+	return states, rows.Err()
+}
+
+// nullString stores empty strings as NULL
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
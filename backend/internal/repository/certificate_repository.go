@@ -0,0 +1,65 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/nimbus/backend/internal/models"
+)
+
+type CertificateRepository struct {
+	db *sql.DB
+}
+
+func NewCertificateRepository(db *sql.DB) *CertificateRepository {
+	return &CertificateRepository{db: db}
+}
+
+// Save stores the certificate last seen for a service, replacing the previous one
+func (r *CertificateRepository) Save(ctx context.Context, cert *models.ServiceCertificate) error {
+	query := `
+		INSERT INTO service_certificates (service_id, subject, issuer, expires_at, checked_at)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (service_id) DO UPDATE SET
+			subject = $2,
+			issuer = $3,
+			expires_at = $4,
+			checked_at = $5
+	`
+
+	_, err := r.db.ExecContext(ctx, query, cert.ServiceID, cert.Subject, cert.Issuer, cert.ExpiresAt, cert.CheckedAt)
+	if err != nil {
+		return fmt.Errorf("failed to save certificate: %w", err)
+	}
+	return nil
+}
+
+// GetExpiringByUserID retrieves the certificates of a user's services that expire before a time, soonest first
+func (r *CertificateRepository) GetExpiringByUserID(ctx context.Context, userID string, before time.Time) ([]models.ServiceCertificate, error) {
+	query := `
+		SELECT c.service_id, s.name, c.subject, c.issuer, c.expires_at, c.checked_at
+		FROM service_certificates c
+		JOIN services s ON s.id = c.service_id
+		WHERE s.user_id = $1 AND c.expires_at < $2
+		ORDER BY c.expires_at ASC, s.name ASC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, userID, before)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get expiring certificates: %w", err)
+	}
+	defer rows.Close()
+
+	certs := []models.ServiceCertificate{}
+	for rows.Next() {
+		var cert models.ServiceCertificate
+		if err := rows.Scan(&cert.ServiceID, &cert.ServiceName, &cert.Subject, &cert.Issuer, &cert.ExpiresAt, &cert.CheckedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan certificate: %w", err)
+		}
+		certs = append(certs, cert)
+	}
+
+	return certs, rows.Err()
+}
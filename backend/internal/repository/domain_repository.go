@@ -0,0 +1,138 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/nimbus/backend/internal/models"
+)
+
+type DomainRepository struct {
+	db *sql.DB
+}
+
+func NewDomainRepository(db *sql.DB) *DomainRepository {
+	return &DomainRepository{db: db}
+}
+
+// CreateMonitored adds a domain to a user's monitored list
+func (r *DomainRepository) CreateMonitored(ctx context.Context, domain *models.MonitoredDomain) error {
+	query := `
+		INSERT INTO monitored_domains (user_id, domain, created_at)
+		VALUES ($1, $2, $3)
+		RETURNING id
+	`
+
+	err := r.db.QueryRowContext(ctx, query, domain.UserID, domain.Domain, domain.CreatedAt).Scan(&domain.ID)
+	if err != nil {
+		return fmt.Errorf("failed to create monitored domain: %w", err)
+	}
+
+	return nil
+}
+
+// GetMonitoredByUserID retrieves the domains a user added manually
+func (r *DomainRepository) GetMonitoredByUserID(ctx context.Context, userID string) ([]*models.MonitoredDomain, error) {
+	query := `
+		SELECT id, user_id, domain, created_at
+		FROM monitored_domains
+		WHERE user_id = $1
+		ORDER BY domain ASC
+	`
+
+	return r.queryMonitored(ctx, query, userID)
+}
+
+// GetAllMonitored retrieves manually added domains for all users
+func (r *DomainRepository) GetAllMonitored(ctx context.Context) ([]*models.MonitoredDomain, error) {
+	query := `
+		SELECT id, user_id, domain, created_at
+		FROM monitored_domains
+		ORDER BY domain ASC
+	`
+
+	return r.queryMonitored(ctx, query)
+}
+
+func (r *DomainRepository) queryMonitored(ctx context.Context, query string, args ...interface{}) ([]*models.MonitoredDomain, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get monitored domains: %w", err)
+	}
+	defer rows.Close()
+
+	var domains []*models.MonitoredDomain
+	for rows.Next() {
+		domain := &models.MonitoredDomain{}
+		if err := rows.Scan(&domain.ID, &domain.UserID, &domain.Domain, &domain.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan monitored domain: %w", err)
+		}
+		domains = append(domains, domain)
+	}
+
+	return domains, rows.Err()
+}
+
+// DeleteMonitored removes a manually added domain (only if owned by the user)
+func (r *DomainRepository) DeleteMonitored(ctx context.Context, id, userID string) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM monitored_domains WHERE id = $1 AND user_id = $2`, id, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete monitored domain: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// GetRegistration retrieves cached RDAP data for a domain
+// Returns sql.ErrNoRows if the domain has never been looked up
+func (r *DomainRepository) GetRegistration(ctx context.Context, domain string) (*models.DomainRegistration, error) {
+	query := `
+		SELECT domain, expires_at, registrar, last_checked_at, last_error
+		FROM domain_registrations
+		WHERE domain = $1
+	`
+
+	reg := &models.DomainRegistration{}
+	err := r.db.QueryRowContext(ctx, query, domain).Scan(
+		&reg.Domain,
+		&reg.ExpiresAt,
+		&reg.Registrar,
+		&reg.LastCheckedAt,
+		&reg.LastError,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return reg, nil
+}
+
+// UpsertRegistration stores the result of an RDAP lookup
+func (r *DomainRepository) UpsertRegistration(ctx context.Context, reg *models.DomainRegistration) error {
+	query := `
+		INSERT INTO domain_registrations (domain, expires_at, registrar, last_checked_at, last_error)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (domain) DO UPDATE SET
+			expires_at = EXCLUDED.expires_at,
+			registrar = EXCLUDED.registrar,
+			last_checked_at = EXCLUDED.last_checked_at,
+			last_error = EXCLUDED.last_error
+	`
+
+	_, err := r.db.ExecContext(ctx, query, reg.Domain, reg.ExpiresAt, reg.Registrar, reg.LastCheckedAt, reg.LastError)
+	if err != nil {
+		return fmt.Errorf("failed to upsert domain registration: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,120 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/nimbus/backend/internal/models"
+)
+
+type FingerprintRepository struct {
+	db *sql.DB
+}
+
+func NewFingerprintRepository(db *sql.DB) *FingerprintRepository {
+	return &FingerprintRepository{db: db}
+}
+
+// Create stores a new fingerprint snapshot for a service
+func (r *FingerprintRepository) Create(ctx context.Context, fp *models.ServiceFingerprint) error {
+	headersJSON, err := json.Marshal(fp.Headers)
+	if err != nil {
+		return fmt.Errorf("failed to marshal headers: %w", err)
+	}
+
+	changed := fp.ChangedFields
+	if changed == nil {
+		changed = []string{}
+	}
+	changedJSON, err := json.Marshal(changed)
+	if err != nil {
+		return fmt.Errorf("failed to marshal changed fields: %w", err)
+	}
+
+	query := `
+		INSERT INTO service_fingerprints (service_id, title, body_hash, headers, cert_fingerprint, changed_fields, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id
+	`
+
+	err = r.db.QueryRowContext(
+		ctx,
+		query,
+		fp.ServiceID,
+		fp.Title,
+		fp.BodyHash,
+		string(headersJSON),
+		fp.CertFingerprint,
+		string(changedJSON),
+		fp.CreatedAt,
+	).Scan(&fp.ID)
+	if err != nil {
+		return fmt.Errorf("failed to create fingerprint: %w", err)
+	}
+
+	return nil
+}
+
+// GetLatestByServiceID retrieves the most recent fingerprint for a service
+// Returns sql.ErrNoRows if the service has never been fingerprinted
+func (r *FingerprintRepository) GetLatestByServiceID(ctx context.Context, serviceID string) (*models.ServiceFingerprint, error) {
+	fingerprints, err := r.GetByServiceID(ctx, serviceID, 1)
+	if err != nil {
+		return nil, err
+	}
+	if len(fingerprints) == 0 {
+		return nil, sql.ErrNoRows
+	}
+	return fingerprints[0], nil
+}
+
+// GetByServiceID retrieves the fingerprint history for a service, newest first
+func (r *FingerprintRepository) GetByServiceID(ctx context.Context, serviceID string, limit int) ([]*models.ServiceFingerprint, error) {
+	query := `
+		SELECT id, service_id, title, body_hash, headers, cert_fingerprint, changed_fields, created_at
+		FROM service_fingerprints
+		WHERE service_id = $1
+		ORDER BY created_at DESC
+		LIMIT $2
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, serviceID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get fingerprints: %w", err)
+	}
+	defer rows.Close()
+
+	var fingerprints []*models.ServiceFingerprint
+	for rows.Next() {
+		fp := &models.ServiceFingerprint{}
+		var headersJSON, changedJSON []byte
+
+		err := rows.Scan(
+			&fp.ID,
+			&fp.ServiceID,
+			&fp.Title,
+			&fp.BodyHash,
+			&headersJSON,
+			&fp.CertFingerprint,
+			&changedJSON,
+			&fp.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan fingerprint: %w", err)
+		}
+
+		// Unmarshal JSON columns
+		if err := json.Unmarshal(headersJSON, &fp.Headers); err != nil {
+			fp.Headers = make(map[string]string)
+		}
+		if err := json.Unmarshal(changedJSON, &fp.ChangedFields); err != nil {
+			fp.ChangedFields = []string{}
+		}
+
+		fingerprints = append(fingerprints, fp)
+	}
+
+	return fingerprints, rows.Err()
+}
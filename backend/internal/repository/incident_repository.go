@@ -0,0 +1,325 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/nimbus/backend/internal/models"
+)
+
+type IncidentRepository struct {
+	db *sql.DB
+}
+
+func NewIncidentRepository(db *sql.DB) *IncidentRepository {
+	return &IncidentRepository{db: db}
+}
+
+const incidentColumns = `id, user_id, title, status, started_at, acknowledged_at, acknowledged_by, resolved_at, resolved_by,
+	created_at, updated_at`
+
+// Create stores a new incident and links its services
+func (r *IncidentRepository) Create(ctx context.Context, incident *models.Incident) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `
+		INSERT INTO incidents (user_id, title, status, started_at, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id
+	`
+
+	err = tx.QueryRowContext(
+		ctx,
+		query,
+		incident.UserID,
+		incident.Title,
+		incident.Status,
+		incident.StartedAt,
+		incident.CreatedAt,
+		incident.UpdatedAt,
+	).Scan(&incident.ID)
+	if err != nil {
+		return fmt.Errorf("failed to create incident: %w", err)
+	}
+
+	for _, service := range incident.Services {
+		if err := linkIncidentService(ctx, tx, incident.ID, service.ServiceID, service.AddedAt); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// GetByID retrieves an incident with its services
+// Returns sql.ErrNoRows if it doesn't exist
+func (r *IncidentRepository) GetByID(ctx context.Context, id string) (*models.Incident, error) {
+	incidents, err := r.query(ctx, `SELECT `+incidentColumns+` FROM incidents WHERE id = $1`, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(incidents) == 0 {
+		return nil, sql.ErrNoRows
+	}
+	return incidents[0], nil
+}
+
+// GetByUserID retrieves a user's incidents, newest first
+// status filters by status; "active" selects the unresolved ones and "" all of them
+func (r *IncidentRepository) GetByUserID(ctx context.Context, userID, status string, limit int) ([]*models.Incident, error) {
+	query := `SELECT ` + incidentColumns + ` FROM incidents WHERE user_id = $1`
+	args := []interface{}{userID}
+
+	switch status {
+	case "":
+	case "active":
+		query += ` AND status <> 'resolved'`
+	default:
+		query += ` AND status = $2`
+		args = append(args, status)
+	}
+
+	query += fmt.Sprintf(` ORDER BY started_at DESC LIMIT $%d`, len(args)+1)
+	args = append(args, limit)
+
+	return r.query(ctx, query, args...)
+}
+
+// GetByUserIDBetween retrieves a user's incidents that were unresolved at any time in [start, end), newest first
+func (r *IncidentRepository) GetByUserIDBetween(ctx context.Context, userID string, start, end time.Time) ([]*models.Incident, error) {
+	query := `
+		SELECT ` + incidentColumns + `
+		FROM incidents
+		WHERE user_id = $1 AND (resolved_at IS NULL OR resolved_at >= $2) AND started_at < $3
+		ORDER BY started_at DESC
+	`
+	return r.query(ctx, query, userID, start, end)
+}
+
+// GetUnresolvedByServiceID retrieves the open and acknowledged incidents affecting a service
+func (r *IncidentRepository) GetUnresolvedByServiceID(ctx context.Context, serviceID string) ([]*models.Incident, error) {
+	query := `
+		SELECT ` + incidentColumns + `
+		FROM incidents
+		WHERE status <> 'resolved' AND id IN (SELECT incident_id FROM incident_services WHERE service_id = $1)
+		ORDER BY started_at ASC
+	`
+	return r.query(ctx, query, serviceID)
+}
+
+// IsAcknowledged reports whether a service is affected by an acknowledged (and unresolved) incident
+func (r *IncidentRepository) IsAcknowledged(ctx context.Context, serviceID string) (bool, error) {
+	query := `
+		SELECT EXISTS (
+			SELECT 1
+			FROM incidents i
+			JOIN incident_services s ON s.incident_id = i.id
+			WHERE s.service_id = $1 AND i.status = 'acknowledged'
+		)
+	`
+
+	var acknowledged bool
+	if err := r.db.QueryRowContext(ctx, query, serviceID).Scan(&acknowledged); err != nil {
+		return false, fmt.Errorf("failed to check incident acknowledgement: %w", err)
+	}
+	return acknowledged, nil
+}
+
+// Acknowledge marks an open incident as acknowledged
+// Returns false if the incident isn't open
+func (r *IncidentRepository) Acknowledge(ctx context.Context, id, userID string, at time.Time) (bool, error) {
+	query := `
+		UPDATE incidents
+		SET status = 'acknowledged', acknowledged_at = $1, acknowledged_by = $2, updated_at = $1
+		WHERE id = $3 AND status = 'open'
+	`
+	return r.exec(ctx, "acknowledge", query, at, userID, id)
+}
+
+// Resolve marks an unresolved incident as resolved; resolvedBy is nil when it resolved on recovery
+// Returns false if the incident was already resolved
+func (r *IncidentRepository) Resolve(ctx context.Context, id string, resolvedBy *string, at time.Time) (bool, error) {
+	query := `
+		UPDATE incidents
+		SET status = 'resolved', resolved_at = $1, resolved_by = $2, updated_at = $1
+		WHERE id = $3 AND status <> 'resolved'
+	`
+	return r.exec(ctx, "resolve", query, at, resolvedBy, id)
+}
+
+// LinkService adds an affected service to an incident (linking it twice does nothing)
+func (r *IncidentRepository) LinkService(ctx context.Context, id, serviceID string, at time.Time) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := linkIncidentService(ctx, tx, id, serviceID, at); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `UPDATE incidents SET updated_at = $1 WHERE id = $2`, at, id); err != nil {
+		return fmt.Errorf("failed to update incident: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+// UnlinkService removes a service from an incident
+// Returns sql.ErrNoRows if the service wasn't linked
+func (r *IncidentRepository) UnlinkService(ctx context.Context, id, serviceID string) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM incident_services WHERE incident_id = $1 AND service_id = $2`, id, serviceID)
+	if err != nil {
+		return fmt.Errorf("failed to unlink incident service: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// AddNote stores a note on an incident
+func (r *IncidentRepository) AddNote(ctx context.Context, note *models.IncidentNote) error {
+	query := `
+		INSERT INTO incident_notes (incident_id, user_id, body, created_at)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id
+	`
+
+	if err := r.db.QueryRowContext(ctx, query, note.IncidentID, note.UserID, note.Body, note.CreatedAt).Scan(&note.ID); err != nil {
+		return fmt.Errorf("failed to add incident note: %w", err)
+	}
+	return nil
+}
+
+// GetNotes retrieves an incident's notes, oldest first
+func (r *IncidentRepository) GetNotes(ctx context.Context, incidentID string) ([]*models.IncidentNote, error) {
+	query := `
+		SELECT id, incident_id, user_id, body, created_at
+		FROM incident_notes
+		WHERE incident_id = $1
+		ORDER BY created_at ASC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, incidentID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get incident notes: %w", err)
+	}
+	defer rows.Close()
+
+	notes := []*models.IncidentNote{}
+	for rows.Next() {
+		note := &models.IncidentNote{}
+		if err := rows.Scan(&note.ID, &note.IncidentID, &note.UserID, &note.Body, &note.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan incident note: %w", err)
+		}
+		notes = append(notes, note)
+	}
+
+	return notes, rows.Err()
+}
+
+func linkIncidentService(ctx context.Context, tx *sql.Tx, incidentID, serviceID string, at time.Time) error {
+	query := `
+		INSERT INTO incident_services (incident_id, service_id, added_at)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (incident_id, service_id) DO NOTHING
+	`
+	if _, err := tx.ExecContext(ctx, query, incidentID, serviceID, at); err != nil {
+		return fmt.Errorf("failed to link incident service: %w", err)
+	}
+	return nil
+}
+
+func (r *IncidentRepository) exec(ctx context.Context, action, query string, args ...interface{}) (bool, error) {
+	result, err := r.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return false, fmt.Errorf("failed to %s incident: %w", action, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rowsAffected > 0, nil
+}
+
+func (r *IncidentRepository) query(ctx context.Context, query string, args ...interface{}) ([]*models.Incident, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get incidents: %w", err)
+	}
+	defer rows.Close()
+
+	var incidents []*models.Incident
+	for rows.Next() {
+		incident := &models.Incident{Services: []models.IncidentService{}}
+		err := rows.Scan(
+			&incident.ID,
+			&incident.UserID,
+			&incident.Title,
+			&incident.Status,
+			&incident.StartedAt,
+			&incident.AcknowledgedAt,
+			&incident.AcknowledgedBy,
+			&incident.ResolvedAt,
+			&incident.ResolvedBy,
+			&incident.CreatedAt,
+			&incident.UpdatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan incident: %w", err)
+		}
+		incidents = append(incidents, incident)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	for _, incident := range incidents {
+		if err := r.loadServices(ctx, incident); err != nil {
+			return nil, err
+		}
+	}
+
+	return incidents, nil
+}
+
+func (r *IncidentRepository) loadServices(ctx context.Context, incident *models.Incident) error {
+	query := `
+		SELECT s.service_id, sv.name, s.added_at
+		FROM incident_services s
+		JOIN services sv ON sv.id = s.service_id
+		WHERE s.incident_id = $1
+		ORDER BY s.added_at ASC, sv.name ASC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, incident.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get incident services: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var service models.IncidentService
+		if err := rows.Scan(&service.ServiceID, &service.ServiceName, &service.AddedAt); err != nil {
+			return fmt.Errorf("failed to scan incident service: %w", err)
+		}
+		incident.Services = append(incident.Services, service)
+	}
+
+	return rows.Err()
+}
@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	"github.com/nimbus/backend/internal/models"
 )
@@ -18,5 +19,23 @@ type ServiceRepositoryInterface interface {
 	UpdateStatusWithResponseTime(ctx context.Context, id, status string, responseTime *int) error
 }
 
-// Ensure ServiceRepository implements the interface
-var _ ServiceRepositoryInterface = (*ServiceRepository)(nil)
+// StatusBatchUpdater applies many service status updates at once (used by the status writer)
+type StatusBatchUpdater interface {
+	UpdateStatusesBatch(ctx context.Context, updates []ServiceStatusUpdate) error
+}
+
+// StatusLogPartitioner manages the partitions of the status log table
+type StatusLogPartitioner interface {
+	IsPartitioned(ctx context.Context) (bool, error)
+	List(ctx context.Context) ([]StatusLogPartition, error)
+	Create(ctx context.Context, name string, from, to time.Time) error
+	Drop(ctx context.Context, name string) error
+	DeleteFromDefaultOlderThan(ctx context.Context, cutoffTime time.Time) (int64, error)
+}
+
+// Ensure the repositories implement the interfaces
+var (
+	_ ServiceRepositoryInterface = (*ServiceRepository)(nil)
+	_ StatusBatchUpdater         = (*ServiceRepository)(nil)
+	_ StatusLogPartitioner       = (*StatusLogPartitionRepository)(nil)
+)
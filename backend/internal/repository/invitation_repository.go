@@ -5,12 +5,18 @@ import (
 	"crypto/rand"
 	"database/sql"
 	"encoding/hex"
+	"errors"
 	"fmt"
 	"time"
 
 	"github.com/nimbus/backend/internal/models"
 )
 
+// Sentinel errors for invitation repository
+var (
+	ErrInvitationNotFound = errors.New("invitation not found")
+)
+
 type InvitationRepository struct {
 	db *sql.DB
 }
@@ -71,7 +77,7 @@ func (r *InvitationRepository) GetByToken(ctx context.Context, token string) (*m
 	)
 
 	if err == sql.ErrNoRows {
-		return nil, fmt.Errorf("invitation not found")
+		return nil, ErrInvitationNotFound
 	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to get invitation: %w", err)
@@ -116,11 +122,12 @@ func (r *InvitationRepository) GetAll(ctx context.Context) ([]*models.UserInvita
 }
 
 // MarkAsAccepted marks an invitation as accepted
+// Returns ErrInvitationNotFound if it doesn't exist or was already accepted
 func (r *InvitationRepository) MarkAsAccepted(ctx context.Context, token string) error {
 	query := `
 		UPDATE user_invitations
 		SET accepted_at = CURRENT_TIMESTAMP
-		WHERE token = $1
+		WHERE token = $1 AND accepted_at IS NULL
 	`
 
 	result, err := r.db.ExecContext(ctx, query, token)
@@ -134,7 +141,7 @@ func (r *InvitationRepository) MarkAsAccepted(ctx context.Context, token string)
 	}
 
 	if rowsAffected == 0 {
-		return fmt.Errorf("invitation not found")
+		return ErrInvitationNotFound
 	}
 
 	return nil
@@ -155,7 +162,7 @@ func (r *InvitationRepository) Delete(ctx context.Context, id string) error {
 	}
 
 	if rowsAffected == 0 {
-		return fmt.Errorf("invitation not found")
+		return ErrInvitationNotFound
 	}
 
 	return nil
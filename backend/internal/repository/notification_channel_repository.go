@@ -0,0 +1,244 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/nimbus/backend/internal/models"
+)
+
+type NotificationChannelRepository struct {
+	db *sql.DB
+}
+
+func NewNotificationChannelRepository(db *sql.DB) *NotificationChannelRepository {
+	return &NotificationChannelRepository{db: db}
+}
+
+const notificationChannelColumns = `id, user_id, name, type, config, enabled, created_at, updated_at`
+
+// Create stores a new notification channel
+func (r *NotificationChannelRepository) Create(ctx context.Context, channel *models.NotificationChannel) error {
+	query := `
+		INSERT INTO notification_channels (user_id, name, type, config, enabled, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id
+	`
+
+	err := r.db.QueryRowContext(
+		ctx,
+		query,
+		channel.UserID,
+		channel.Name,
+		channel.Type,
+		channel.Config,
+		channel.Enabled,
+		channel.CreatedAt,
+		channel.UpdatedAt,
+	).Scan(&channel.ID)
+	if err != nil {
+		return fmt.Errorf("failed to create notification channel: %w", err)
+	}
+
+	return nil
+}
+
+// GetByID retrieves a notification channel
+// Returns sql.ErrNoRows if it doesn't exist
+func (r *NotificationChannelRepository) GetByID(ctx context.Context, id string) (*models.NotificationChannel, error) {
+	query := `SELECT ` + notificationChannelColumns + ` FROM notification_channels WHERE id = $1`
+
+	channels, err := r.query(ctx, query, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(channels) == 0 {
+		return nil, sql.ErrNoRows
+	}
+	return channels[0], nil
+}
+
+// GetAvailableToUser retrieves the user's own channels and all global channels
+func (r *NotificationChannelRepository) GetAvailableToUser(ctx context.Context, userID string) ([]*models.NotificationChannel, error) {
+	query := `
+		SELECT ` + notificationChannelColumns + `
+		FROM notification_channels
+		WHERE user_id = $1 OR user_id IS NULL
+		ORDER BY name ASC
+	`
+	return r.query(ctx, query, userID)
+}
+
+// GetEnabledByServiceID retrieves the enabled channels a service notifies
+func (r *NotificationChannelRepository) GetEnabledByServiceID(ctx context.Context, serviceID string) ([]*models.NotificationChannel, error) {
+	query := `
+		SELECT c.id, c.user_id, c.name, c.type, c.config, c.enabled, c.created_at, c.updated_at
+		FROM notification_channels c
+		JOIN service_notification_channels s ON s.channel_id = c.id
+		WHERE s.service_id = $1 AND c.enabled = TRUE
+		ORDER BY c.name ASC
+	`
+	return r.query(ctx, query, serviceID)
+}
+
+// GetEnabledByIDs retrieves the enabled channels among the given IDs
+func (r *NotificationChannelRepository) GetEnabledByIDs(ctx context.Context, ids []string) ([]*models.NotificationChannel, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	placeholders := ""
+	args := make([]interface{}, 0, len(ids))
+	for i, id := range ids {
+		if i > 0 {
+			placeholders += ", "
+		}
+		placeholders += fmt.Sprintf("$%d", i+1)
+		args = append(args, id)
+	}
+
+	query := fmt.Sprintf(`
+		SELECT `+notificationChannelColumns+`
+		FROM notification_channels
+		WHERE id IN (%s) AND enabled = TRUE
+		ORDER BY name ASC
+	`, placeholders)
+	return r.query(ctx, query, args...)
+}
+
+// Update saves a channel's settings
+// Returns sql.ErrNoRows if it doesn't exist
+func (r *NotificationChannelRepository) Update(ctx context.Context, channel *models.NotificationChannel) error {
+	query := `
+		UPDATE notification_channels
+		SET name = $1, type = $2, config = $3, enabled = $4, updated_at = $5
+		WHERE id = $6
+	`
+
+	result, err := r.db.ExecContext(
+		ctx,
+		query,
+		channel.Name,
+		channel.Type,
+		channel.Config,
+		channel.Enabled,
+		channel.UpdatedAt,
+		channel.ID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update notification channel: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// Delete removes a channel and its service selections
+func (r *NotificationChannelRepository) Delete(ctx context.Context, id string) error {
+	if _, err := r.db.ExecContext(ctx, `DELETE FROM service_notification_channels WHERE channel_id = $1`, id); err != nil {
+		return fmt.Errorf("failed to delete notification channel selections: %w", err)
+	}
+
+	result, err := r.db.ExecContext(ctx, `DELETE FROM notification_channels WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete notification channel: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// GetChannelIDsByServiceID retrieves the IDs of the channels a service notifies
+func (r *NotificationChannelRepository) GetChannelIDsByServiceID(ctx context.Context, serviceID string) ([]string, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT channel_id FROM service_notification_channels WHERE service_id = $1 ORDER BY channel_id`, serviceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get service notification channels: %w", err)
+	}
+	defer rows.Close()
+
+	channelIDs := []string{}
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan service notification channel: %w", err)
+		}
+		channelIDs = append(channelIDs, id)
+	}
+
+	return channelIDs, rows.Err()
+}
+
+// SetServiceChannels replaces the channels a service notifies
+func (r *NotificationChannelRepository) SetServiceChannels(ctx context.Context, serviceID string, channelIDs []string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM service_notification_channels WHERE service_id = $1`, serviceID); err != nil {
+		return fmt.Errorf("failed to clear service notification channels: %w", err)
+	}
+
+	for _, channelID := range channelIDs {
+		_, err := tx.ExecContext(ctx, `INSERT INTO service_notification_channels (service_id, channel_id) VALUES ($1, $2)`, serviceID, channelID)
+		if err != nil {
+			return fmt.Errorf("failed to add service notification channel: %w", err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (r *NotificationChannelRepository) query(ctx context.Context, query string, args ...interface{}) ([]*models.NotificationChannel, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get notification channels: %w", err)
+	}
+	defer rows.Close()
+
+	var channels []*models.NotificationChannel
+	for rows.Next() {
+		channel := &models.NotificationChannel{}
+		var userID sql.NullString
+
+		err := rows.Scan(
+			&channel.ID,
+			&userID,
+			&channel.Name,
+			&channel.Type,
+			&channel.Config,
+			&channel.Enabled,
+			&channel.CreatedAt,
+			&channel.UpdatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan notification channel: %w", err)
+		}
+
+		if userID.Valid {
+			channel.UserID = &userID.String
+		}
+
+		channels = append(channels, channel)
+	}
+
+	return channels, rows.Err()
+}
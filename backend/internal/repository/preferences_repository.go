@@ -3,6 +3,9 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
 
 	"github.com/nimbus/backend/internal/models"
 )
@@ -26,8 +29,11 @@ func getOpenInNewTabValue(value *bool) bool {
 // GetByUserID retrieves preferences for a specific user
 func (r *PreferencesRepository) GetByUserID(ctx context.Context, userID string) (*models.UserPreferences, error) {
 	preferences := &models.UserPreferences{}
+	var channelsJSON []byte
 	query := `
-		SELECT id, user_id, theme_mode, theme_background, theme_accent_color, open_in_new_tab, created_at, updated_at
+		SELECT id, user_id, theme_mode, theme_background, theme_accent_color, open_in_new_tab,
+			digest_enabled, digest_schedule, digest_timezone, digest_email, digest_channel_ids, digest_last_sent_at,
+			created_at, updated_at
 		FROM user_preferences
 		WHERE user_id = $1
 	`
@@ -39,6 +45,12 @@ func (r *PreferencesRepository) GetByUserID(ctx context.Context, userID string)
 		&preferences.ThemeBackground,
 		&preferences.ThemeAccentColor,
 		&preferences.OpenInNewTab,
+		&preferences.Digest.Enabled,
+		&preferences.Digest.Schedule,
+		&preferences.Digest.Timezone,
+		&preferences.Digest.Email,
+		&channelsJSON,
+		&preferences.Digest.LastSentAt,
 		&preferences.CreatedAt,
 		&preferences.UpdatedAt,
 	)
@@ -46,8 +58,15 @@ func (r *PreferencesRepository) GetByUserID(ctx context.Context, userID string)
 	if err == sql.ErrNoRows {
 		return nil, sql.ErrNoRows
 	}
+	if err != nil {
+		return nil, err
+	}
 
-	return preferences, err
+	if preferences.Digest.ChannelIDs, err = unmarshalChannelIDs(channelsJSON); err != nil {
+		return nil, err
+	}
+
+	return preferences, nil
 }
 
 // Create creates default preferences for a new user
@@ -156,3 +175,83 @@ func (r *PreferencesRepository) Upsert(ctx context.Context, userID string, prefe
 
 	return err
 }
+
+// UpsertDigest replaces a user's digest settings, creating default preferences if needed
+// Re-enabling a disabled digest forgets when it was last sent, so the next report starts fresh
+func (r *PreferencesRepository) UpsertDigest(ctx context.Context, userID string, digest *models.DigestPreferences) error {
+	channelsJSON, err := json.Marshal(digest.ChannelIDs)
+	if err != nil {
+		return fmt.Errorf("failed to marshal digest channels: %w", err)
+	}
+
+	query := `
+		INSERT INTO user_preferences (user_id, digest_enabled, digest_schedule, digest_timezone, digest_email, digest_channel_ids, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
+		ON CONFLICT (user_id) DO UPDATE SET
+			digest_enabled = $2,
+			digest_schedule = $3,
+			digest_timezone = $4,
+			digest_email = $5,
+			digest_channel_ids = $6,
+			digest_last_sent_at = CASE WHEN user_preferences.digest_enabled THEN user_preferences.digest_last_sent_at END,
+			updated_at = CURRENT_TIMESTAMP
+	`
+
+	_, err = r.db.ExecContext(ctx, query, userID, digest.Enabled, digest.Schedule, digest.Timezone, digest.Email, string(channelsJSON))
+	if err != nil {
+		return fmt.Errorf("failed to update digest preferences: %w", err)
+	}
+	return nil
+}
+
+// GetDigestSubscribers retrieves the digest settings of all users with digests enabled, keyed by user ID
+func (r *PreferencesRepository) GetDigestSubscribers(ctx context.Context) (map[string]*models.DigestPreferences, error) {
+	query := `
+		SELECT user_id, digest_enabled, digest_schedule, digest_timezone, digest_email, digest_channel_ids, digest_last_sent_at
+		FROM user_preferences
+		WHERE digest_enabled
+	`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get digest subscribers: %w", err)
+	}
+	defer rows.Close()
+
+	subscribers := make(map[string]*models.DigestPreferences)
+	for rows.Next() {
+		var userID string
+		var channelsJSON []byte
+		digest := &models.DigestPreferences{}
+		err := rows.Scan(&userID, &digest.Enabled, &digest.Schedule, &digest.Timezone, &digest.Email, &channelsJSON, &digest.LastSentAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan digest subscriber: %w", err)
+		}
+		if digest.ChannelIDs, err = unmarshalChannelIDs(channelsJSON); err != nil {
+			return nil, err
+		}
+		subscribers[userID] = digest
+	}
+
+	return subscribers, rows.Err()
+}
+
+// SetDigestSentAt records when a user's digest was last sent
+func (r *PreferencesRepository) SetDigestSentAt(ctx context.Context, userID string, at time.Time) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE user_preferences SET digest_last_sent_at = $1 WHERE user_id = $2`, at, userID)
+	if err != nil {
+		return fmt.Errorf("failed to record digest delivery: %w", err)
+	}
+	return nil
+}
+
+func unmarshalChannelIDs(data []byte) ([]string, error) {
+	channelIDs := []string{}
+	if len(data) == 0 {
+		return channelIDs, nil
+	}
+	if err := json.Unmarshal(data, &channelIDs); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal digest channels: %w", err)
+	}
+	return channelIDs, nil
+}
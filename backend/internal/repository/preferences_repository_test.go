@@ -77,6 +77,12 @@ func setupPreferencesTestDB(t *testing.T) *sql.DB {
 			theme_background TEXT,
 			theme_accent_color TEXT,
 			open_in_new_tab BOOLEAN NOT NULL DEFAULT 1,
+			digest_enabled BOOLEAN NOT NULL DEFAULT 0,
+			digest_schedule TEXT NOT NULL DEFAULT '0 8 * * 1',
+			digest_timezone TEXT NOT NULL DEFAULT 'UTC',
+			digest_email BOOLEAN NOT NULL DEFAULT 1,
+			digest_channel_ids TEXT NOT NULL DEFAULT '[]',
+			digest_last_sent_at TIMESTAMP,
 			created_at TIMESTAMP NOT NULL,
 			updated_at TIMESTAMP NOT NULL,
 			FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
@@ -672,3 +678,76 @@ func TestPreferencesRepository_OpenInNewTab(t *testing.T) {
 		}
 	})
 }
+
+func TestPreferencesRepository_Digest(t *testing.T) {
+	db := setupPreferencesTestDB(t)
+	defer db.Close()
+
+	repo := NewPreferencesRepository(db)
+	ctx := context.Background()
+
+	// Theme preferences keep the default digest settings
+	if err := repo.Upsert(ctx, "user-1", &models.PreferencesUpdateRequest{ThemeMode: stringPtr("dark")}); err != nil {
+		t.Fatalf("Upsert() failed: %v", err)
+	}
+	preferences, err := repo.GetByUserID(ctx, "user-1")
+	if err != nil {
+		t.Fatalf("GetByUserID() failed: %v", err)
+	}
+	if preferences.Digest.Enabled || preferences.Digest.Schedule != models.DefaultDigestSchedule || !preferences.Digest.Email {
+		t.Errorf("Digest = %+v, want the defaults", preferences.Digest)
+	}
+	if preferences.Digest.ChannelIDs == nil || len(preferences.Digest.ChannelIDs) != 0 {
+		t.Errorf("Digest.ChannelIDs = %v, want empty", preferences.Digest.ChannelIDs)
+	}
+
+	subscribers, err := repo.GetDigestSubscribers(ctx)
+	if err != nil {
+		t.Fatalf("GetDigestSubscribers() failed: %v", err)
+	}
+	if len(subscribers) != 0 {
+		t.Errorf("GetDigestSubscribers() = %d users, want 0", len(subscribers))
+	}
+
+	digest := &models.DigestPreferences{
+		Enabled:    true,
+		Schedule:   "0 9 * * *",
+		Timezone:   "Europe/Berlin",
+		ChannelIDs: []string{"channel-1"},
+	}
+	if err := repo.UpsertDigest(ctx, "user-1", digest); err != nil {
+		t.Fatalf("UpsertDigest() failed: %v", err)
+	}
+
+	sentAt := time.Date(2025, 6, 2, 9, 0, 0, 0, time.UTC)
+	if err := repo.SetDigestSentAt(ctx, "user-1", sentAt); err != nil {
+		t.Fatalf("SetDigestSentAt() failed: %v", err)
+	}
+
+	subscribers, err = repo.GetDigestSubscribers(ctx)
+	if err != nil {
+		t.Fatalf("GetDigestSubscribers() failed: %v", err)
+	}
+	got, ok := subscribers["user-1"]
+	if !ok {
+		t.Fatalf("GetDigestSubscribers() = %v, want user-1", subscribers)
+	}
+	if got.Schedule != "0 9 * * *" || got.Timezone != "Europe/Berlin" || got.Email {
+		t.Errorf("Digest = %+v, want the updated settings", got)
+	}
+	if len(got.ChannelIDs) != 1 || got.ChannelIDs[0] != "channel-1" {
+		t.Errorf("Digest.ChannelIDs = %v, want [channel-1]", got.ChannelIDs)
+	}
+	if got.LastSentAt == nil || !got.LastSentAt.Equal(sentAt) {
+		t.Errorf("Digest.LastSentAt = %v, want %v", got.LastSentAt, sentAt)
+	}
+
+	// The theme is untouched by digest updates
+	preferences, err = repo.GetByUserID(ctx, "user-1")
+	if err != nil {
+		t.Fatalf("GetByUserID() failed: %v", err)
+	}
+	if preferences.ThemeMode != "dark" {
+		t.Errorf("ThemeMode = %q, want dark", preferences.ThemeMode)
+	}
+}
@@ -9,6 +9,39 @@ import (
 	"github.com/nimbus/backend/internal/models"
 )
 
+// serviceColumns is the column list shared by all service SELECT queries (must match scanService)
+const serviceColumns = `id, user_id, name, url, icon, icon_type, icon_image_path, description, status, response_time, position, check_config, created_at, updated_at`
+
+// rowScanner is satisfied by both *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanService scans a row selected with serviceColumns into a Service
+func scanService(row rowScanner) (*models.Service, error) {
+	service := &models.Service{}
+	err := row.Scan(
+		&service.ID,
+		&service.UserID,
+		&service.Name,
+		&service.URL,
+		&service.Icon,
+		&service.IconType,
+		&service.IconImagePath,
+		&service.Description,
+		&service.Status,
+		&service.ResponseTime,
+		&service.Position,
+		&service.CheckConfig,
+		&service.CreatedAt,
+		&service.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return service, nil
+}
+
 type ServiceRepository struct {
 	db           *sql.DB
 	isPostgreSQL bool
@@ -65,8 +98,8 @@ func (r *ServiceRepository) Create(ctx context.Context, service *models.Service)
 	}
 
 	query := `
-		INSERT INTO services (user_id, name, url, icon, icon_type, icon_image_path, description, status, position, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		INSERT INTO services (user_id, name, url, icon, icon_type, icon_image_path, description, status, position, check_config, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
 		RETURNING id
 	`
 
@@ -82,6 +115,7 @@ func (r *ServiceRepository) Create(ctx context.Context, service *models.Service)
 		service.Description,
 		service.Status,
 		service.Position,
+		service.CheckConfig,
 		service.CreatedAt,
 		service.UpdatedAt,
 	).Scan(&service.ID)
@@ -94,29 +128,13 @@ func (r *ServiceRepository) Create(ctx context.Context, service *models.Service)
 
 // GetByID retrieves a service by ID
 func (r *ServiceRepository) GetByID(ctx context.Context, id string) (*models.Service, error) {
-	service := &models.Service{}
 	query := `
-		SELECT id, user_id, name, url, icon, icon_type, icon_image_path, description, status, response_time, position, created_at, updated_at
+		SELECT ` + serviceColumns + `
 		FROM services
 		WHERE id = $1
 	`
 
-	err := r.db.QueryRowContext(ctx, query, id).Scan(
-		&service.ID,
-		&service.UserID,
-		&service.Name,
-		&service.URL,
-		&service.Icon,
-		&service.IconType,
-		&service.IconImagePath,
-		&service.Description,
-		&service.Status,
-		&service.ResponseTime,
-		&service.Position,
-		&service.CreatedAt,
-		&service.UpdatedAt,
-	)
-
+	service, err := scanService(r.db.QueryRowContext(ctx, query, id))
 	if err == sql.ErrNoRows {
 		return nil, sql.ErrNoRows
 	}
@@ -127,7 +145,7 @@ func (r *ServiceRepository) GetByID(ctx context.Context, id string) (*models.Ser
 // GetAllByUserID retrieves all services for a specific user
 func (r *ServiceRepository) GetAllByUserID(ctx context.Context, userID string) ([]*models.Service, error) {
 	query := `
-		SELECT id, user_id, name, url, icon, icon_type, icon_image_path, description, status, response_time, position, created_at, updated_at
+		SELECT ` + serviceColumns + `
 		FROM services
 		WHERE user_id = $1
 		ORDER BY position ASC, created_at DESC
@@ -141,22 +159,7 @@ func (r *ServiceRepository) GetAllByUserID(ctx context.Context, userID string) (
 
 	var services []*models.Service
 	for rows.Next() {
-		service := &models.Service{}
-		err := rows.Scan(
-			&service.ID,
-			&service.UserID,
-			&service.Name,
-			&service.URL,
-			&service.Icon,
-			&service.IconType,
-			&service.IconImagePath,
-			&service.Description,
-			&service.Status,
-			&service.ResponseTime,
-			&service.Position,
-			&service.CreatedAt,
-			&service.UpdatedAt,
-		)
+		service, err := scanService(rows)
 		if err != nil {
 			return nil, err
 		}
@@ -169,7 +172,7 @@ func (r *ServiceRepository) GetAllByUserID(ctx context.Context, userID string) (
 // GetAll retrieves all services across all users (used by health check monitor)
 func (r *ServiceRepository) GetAll(ctx context.Context) ([]*models.Service, error) {
 	query := `
-		SELECT id, user_id, name, url, icon, icon_type, icon_image_path, description, status, response_time, position, created_at, updated_at
+		SELECT ` + serviceColumns + `
 		FROM services
 		ORDER BY created_at DESC
 	`
@@ -182,22 +185,7 @@ func (r *ServiceRepository) GetAll(ctx context.Context) ([]*models.Service, erro
 
 	var services []*models.Service
 	for rows.Next() {
-		service := &models.Service{}
-		err := rows.Scan(
-			&service.ID,
-			&service.UserID,
-			&service.Name,
-			&service.URL,
-			&service.Icon,
-			&service.IconType,
-			&service.IconImagePath,
-			&service.Description,
-			&service.Status,
-			&service.ResponseTime,
-			&service.Position,
-			&service.CreatedAt,
-			&service.UpdatedAt,
-		)
+		service, err := scanService(rows)
 		if err != nil {
 			return nil, err
 		}
@@ -211,8 +199,8 @@ func (r *ServiceRepository) GetAll(ctx context.Context) ([]*models.Service, erro
 func (r *ServiceRepository) Update(ctx context.Context, service *models.Service) error {
 	query := `
 		UPDATE services
-		SET name = $1, url = $2, icon = $3, icon_type = $4, icon_image_path = $5, description = $6, updated_at = $7
-		WHERE id = $8 AND user_id = $9
+		SET name = $1, url = $2, icon = $3, icon_type = $4, icon_image_path = $5, description = $6, check_config = $7, updated_at = $8
+		WHERE id = $9 AND user_id = $10
 	`
 
 	result, err := r.db.ExecContext(
@@ -224,6 +212,7 @@ func (r *ServiceRepository) Update(ctx context.Context, service *models.Service)
 		service.IconType,
 		service.IconImagePath,
 		service.Description,
+		service.CheckConfig,
 		service.UpdatedAt,
 		service.ID,
 		service.UserID,
@@ -308,6 +297,75 @@ func (r *ServiceRepository) UpdateStatusWithResponseTime(ctx context.Context, id
 	return nil
 }
 
+// ServiceStatusUpdate is one service's new status for UpdateStatusesBatch
+type ServiceStatusUpdate struct {
+	ID           string
+	Status       string
+	ResponseTime *int
+}
+
+// UpdateStatusesBatch updates status and response time for many services at once (used by the status writer)
+// Services that no longer exist are skipped
+func (r *ServiceRepository) UpdateStatusesBatch(ctx context.Context, updates []ServiceStatusUpdate) error {
+	if len(updates) == 0 {
+		return nil
+	}
+	if r.isPostgreSQL {
+		return r.bulkUpdateStatusesPostgreSQL(ctx, updates)
+	}
+	return r.loopUpdateStatuses(ctx, updates)
+}
+
+// bulkUpdateStatusesPostgreSQL updates all statuses in one statement using PostgreSQL arrays
+func (r *ServiceRepository) bulkUpdateStatusesPostgreSQL(ctx context.Context, updates []ServiceStatusUpdate) error {
+	ids := make([]string, len(updates))
+	statuses := make([]string, len(updates))
+	responseTimes := make([]sql.NullInt64, len(updates))
+
+	for i, update := range updates {
+		ids[i] = update.ID
+		statuses[i] = update.Status
+		if update.ResponseTime != nil {
+			responseTimes[i] = sql.NullInt64{Int64: int64(*update.ResponseTime), Valid: true}
+		}
+	}
+
+	query := `
+		UPDATE services
+		SET status = data.status,
+		    response_time = data.response_time,
+		    updated_at = CURRENT_TIMESTAMP
+		FROM (
+			SELECT unnest($1::uuid[]) AS id,
+			       unnest($2::text[]) AS status,
+			       unnest($3::int[]) AS response_time
+		) AS data
+		WHERE services.id = data.id
+	`
+
+	_, err := r.db.ExecContext(ctx, query, pq.Array(ids), pq.Array(statuses), pq.Array(responseTimes))
+	return err
+}
+
+// loopUpdateStatuses uses individual UPDATE statements in one transaction (SQLite compatible)
+func (r *ServiceRepository) loopUpdateStatuses(ctx context.Context, updates []ServiceStatusUpdate) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `UPDATE services SET status = $1, response_time = $2, updated_at = CURRENT_TIMESTAMP WHERE id = $3`
+
+	for _, update := range updates {
+		if _, err := tx.ExecContext(ctx, query, update.Status, update.ResponseTime, update.ID); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
 // UpdatePositions updates positions for multiple services in a transaction
 func (r *ServiceRepository) UpdatePositions(ctx context.Context, userID string, positions map[string]int) error {
 	if r.isPostgreSQL {
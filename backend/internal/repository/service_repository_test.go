@@ -32,6 +32,7 @@ func setupTestDB(t *testing.T) *sql.DB {
 			status TEXT NOT NULL,
 			response_time INTEGER,
 			position INTEGER DEFAULT 0,
+			check_config TEXT NOT NULL DEFAULT '{}',
 			created_at TIMESTAMP NOT NULL,
 			updated_at TIMESTAMP NOT NULL
 		);
@@ -698,6 +699,54 @@ func TestServiceRepository_UpdateStatusWithResponseTime(t *testing.T) {
 	}
 }
 
+func TestServiceRepository_UpdateStatusesBatch(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	repo := NewServiceRepository(db)
+	ctx := context.Background()
+
+	for _, id := range []string{"service-1", "service-2"} {
+		createServiceDirectly(t, db, &models.Service{
+			ID:        id,
+			UserID:    "user-1",
+			Name:      "Test Service " + id,
+			URL:       "https://example.com",
+			Icon:      "🔗",
+			Status:    models.StatusUnknown,
+			CreatedAt: time.Now(),
+			UpdatedAt: time.Now(),
+		})
+	}
+
+	responseTime := 120
+	updates := []ServiceStatusUpdate{
+		{ID: "service-1", Status: models.StatusOnline, ResponseTime: &responseTime},
+		{ID: "service-2", Status: models.StatusOffline},
+		{ID: "deleted-service", Status: models.StatusOnline}, // Deleted since the check - skipped
+	}
+
+	if err := repo.UpdateStatusesBatch(ctx, updates); err != nil {
+		t.Fatalf("UpdateStatusesBatch() error = %v", err)
+	}
+
+	service1, err := repo.GetByID(ctx, "service-1")
+	if err != nil {
+		t.Fatalf("Failed to retrieve service: %v", err)
+	}
+	if service1.Status != models.StatusOnline || service1.ResponseTime == nil || *service1.ResponseTime != responseTime {
+		t.Errorf("Expected service-1 online with 120ms, got %s %v", service1.Status, service1.ResponseTime)
+	}
+
+	service2, err := repo.GetByID(ctx, "service-2")
+	if err != nil {
+		t.Fatalf("Failed to retrieve service: %v", err)
+	}
+	if service2.Status != models.StatusOffline || service2.ResponseTime != nil {
+		t.Errorf("Expected service-2 offline without response time, got %s %v", service2.Status, service2.ResponseTime)
+	}
+}
+
 func TestServiceRepository_UpdatePositions(t *testing.T) {
 	db := setupTestDB(t)
 	defer db.Close()
@@ -0,0 +1,201 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/nimbus/backend/internal/models"
+)
+
+type SLORepository struct {
+	db *sql.DB
+}
+
+func NewSLORepository(db *sql.DB) *SLORepository {
+	return &SLORepository{db: db}
+}
+
+const sloColumns = `id, user_id, name, service_ids, availability_target, latency_threshold_ms, latency_target, window_type, window_days, calendar_period, timezone, created_at, updated_at`
+
+// Create stores a new SLO
+func (r *SLORepository) Create(ctx context.Context, slo *models.SLO) error {
+	serviceIDs, err := marshalServiceIDs(slo.ServiceIDs)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO slos (user_id, name, service_ids, availability_target, latency_threshold_ms, latency_target, window_type, window_days, calendar_period, timezone, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+		RETURNING id
+	`
+
+	err = r.db.QueryRowContext(
+		ctx,
+		query,
+		slo.UserID,
+		slo.Name,
+		serviceIDs,
+		slo.AvailabilityTarget,
+		slo.LatencyThresholdMs,
+		slo.LatencyTarget,
+		slo.WindowType,
+		slo.WindowDays,
+		slo.CalendarPeriod,
+		slo.Timezone,
+		slo.CreatedAt,
+		slo.UpdatedAt,
+	).Scan(&slo.ID)
+	if err != nil {
+		return fmt.Errorf("failed to create SLO: %w", err)
+	}
+
+	return nil
+}
+
+// GetByID retrieves an SLO
+// Returns sql.ErrNoRows if it doesn't exist
+func (r *SLORepository) GetByID(ctx context.Context, id string) (*models.SLO, error) {
+	query := `SELECT ` + sloColumns + ` FROM slos WHERE id = $1`
+
+	slos, err := r.query(ctx, query, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(slos) == 0 {
+		return nil, sql.ErrNoRows
+	}
+	return slos[0], nil
+}
+
+// GetAllByUserID retrieves a user's SLOs
+func (r *SLORepository) GetAllByUserID(ctx context.Context, userID string) ([]*models.SLO, error) {
+	query := `SELECT ` + sloColumns + ` FROM slos WHERE user_id = $1 ORDER BY name ASC`
+	return r.query(ctx, query, userID)
+}
+
+// GetAll retrieves the SLOs of all users
+func (r *SLORepository) GetAll(ctx context.Context) ([]*models.SLO, error) {
+	query := `SELECT ` + sloColumns + ` FROM slos ORDER BY name ASC`
+	return r.query(ctx, query)
+}
+
+// Update saves an SLO's settings (only if owned by its user)
+// Returns sql.ErrNoRows if it doesn't exist or belongs to another user
+func (r *SLORepository) Update(ctx context.Context, slo *models.SLO) error {
+	serviceIDs, err := marshalServiceIDs(slo.ServiceIDs)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		UPDATE slos
+		SET name = $1, service_ids = $2, availability_target = $3, latency_threshold_ms = $4, latency_target = $5,
+			window_type = $6, window_days = $7, calendar_period = $8, timezone = $9, updated_at = $10
+		WHERE id = $11 AND user_id = $12
+	`
+
+	result, err := r.db.ExecContext(
+		ctx,
+		query,
+		slo.Name,
+		serviceIDs,
+		slo.AvailabilityTarget,
+		slo.LatencyThresholdMs,
+		slo.LatencyTarget,
+		slo.WindowType,
+		slo.WindowDays,
+		slo.CalendarPeriod,
+		slo.Timezone,
+		slo.UpdatedAt,
+		slo.ID,
+		slo.UserID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update SLO: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// Delete removes an SLO (only if owned by the user)
+func (r *SLORepository) Delete(ctx context.Context, id, userID string) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM slos WHERE id = $1 AND user_id = $2`, id, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete SLO: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+func (r *SLORepository) query(ctx context.Context, query string, args ...interface{}) ([]*models.SLO, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get SLOs: %w", err)
+	}
+	defer rows.Close()
+
+	var slos []*models.SLO
+	for rows.Next() {
+		slo := &models.SLO{}
+		var serviceIDsJSON []byte
+
+		err := rows.Scan(
+			&slo.ID,
+			&slo.UserID,
+			&slo.Name,
+			&serviceIDsJSON,
+			&slo.AvailabilityTarget,
+			&slo.LatencyThresholdMs,
+			&slo.LatencyTarget,
+			&slo.WindowType,
+			&slo.WindowDays,
+			&slo.CalendarPeriod,
+			&slo.Timezone,
+			&slo.CreatedAt,
+			&slo.UpdatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan SLO: %w", err)
+		}
+
+		if err := json.Unmarshal(serviceIDsJSON, &slo.ServiceIDs); err != nil {
+			slo.ServiceIDs = []string{}
+		}
+
+		slos = append(slos, slo)
+	}
+
+	return slos, rows.Err()
+}
+
+func marshalServiceIDs(serviceIDs []string) (string, error) {
+	if serviceIDs == nil {
+		serviceIDs = []string{}
+	}
+	data, err := json.Marshal(serviceIDs)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal service IDs: %w", err)
+	}
+	return string(data), nil
+}
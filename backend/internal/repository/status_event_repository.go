@@ -0,0 +1,127 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/nimbus/backend/internal/models"
+)
+
+type StatusEventRepository struct {
+	db *sql.DB
+}
+
+func NewStatusEventRepository(db *sql.DB) *StatusEventRepository {
+	return &StatusEventRepository{db: db}
+}
+
+const statusEventColumns = `id, service_id, from_status, to_status, occurred_at, previous_duration_ms, error_message`
+
+// Create stores a status transition
+func (r *StatusEventRepository) Create(ctx context.Context, event *models.StatusEvent) error {
+	query := `
+		INSERT INTO service_status_events (service_id, from_status, to_status, occurred_at, previous_duration_ms, error_message)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id
+	`
+
+	err := r.db.QueryRowContext(
+		ctx,
+		query,
+		event.ServiceID,
+		event.FromStatus,
+		event.ToStatus,
+		event.OccurredAt.UTC(),
+		event.PreviousDurationMs,
+		event.ErrorMessage,
+	).Scan(&event.ID)
+	if err != nil {
+		return fmt.Errorf("failed to create status event: %w", err)
+	}
+
+	return nil
+}
+
+// GetLatestByServiceID retrieves the most recent transition of a service
+// Returns sql.ErrNoRows if the service never changed status
+func (r *StatusEventRepository) GetLatestByServiceID(ctx context.Context, serviceID string) (*models.StatusEvent, error) {
+	query := `
+		SELECT ` + statusEventColumns + `
+		FROM service_status_events
+		WHERE service_id = $1
+		ORDER BY occurred_at DESC
+		LIMIT 1
+	`
+
+	events, err := r.query(ctx, query, serviceID)
+	if err != nil {
+		return nil, err
+	}
+	if len(events) == 0 {
+		return nil, sql.ErrNoRows
+	}
+	return events[0], nil
+}
+
+// GetLatestBefore retrieves the last transition of a service before a time
+// Returns sql.ErrNoRows if the service had no transitions by then
+func (r *StatusEventRepository) GetLatestBefore(ctx context.Context, serviceID string, before time.Time) (*models.StatusEvent, error) {
+	query := `
+		SELECT ` + statusEventColumns + `
+		FROM service_status_events
+		WHERE service_id = $1 AND occurred_at < $2
+		ORDER BY occurred_at DESC
+		LIMIT 1
+	`
+
+	events, err := r.query(ctx, query, serviceID, before.UTC())
+	if err != nil {
+		return nil, err
+	}
+	if len(events) == 0 {
+		return nil, sql.ErrNoRows
+	}
+	return events[0], nil
+}
+
+// GetByServiceID retrieves the transitions of a service within [startTime, endTime], oldest first
+func (r *StatusEventRepository) GetByServiceID(ctx context.Context, serviceID string, startTime, endTime time.Time) ([]*models.StatusEvent, error) {
+	query := `
+		SELECT ` + statusEventColumns + `
+		FROM service_status_events
+		WHERE service_id = $1 AND occurred_at >= $2 AND occurred_at <= $3
+		ORDER BY occurred_at ASC
+	`
+
+	return r.query(ctx, query, serviceID, startTime.UTC(), endTime.UTC())
+}
+
+func (r *StatusEventRepository) query(ctx context.Context, query string, args ...interface{}) ([]*models.StatusEvent, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get status events: %w", err)
+	}
+	defer rows.Close()
+
+	var events []*models.StatusEvent
+	for rows.Next() {
+		event := &models.StatusEvent{}
+		err := rows.Scan(
+			&event.ID,
+			&event.ServiceID,
+			&event.FromStatus,
+			&event.ToStatus,
+			&event.OccurredAt,
+			&event.PreviousDurationMs,
+			&event.ErrorMessage,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan status event: %w", err)
+		}
+		events = append(events, event)
+	}
+
+	return events, rows.Err()
+}
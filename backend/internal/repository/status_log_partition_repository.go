@@ -0,0 +1,214 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// StatusLogPartition is one partition of the service_status_logs table (PostgreSQL only)
+type StatusLogPartition struct {
+	Name    string
+	From    time.Time // Inclusive lower bound of checked_at
+	To      time.Time // Exclusive upper bound of checked_at
+	Default bool      // Catch-all partition for rows outside every range
+}
+
+// StatusLogDefaultPartition is the name of the catch-all partition created by the partitioning migration
+const StatusLogDefaultPartition = "service_status_logs_default"
+
+// StatusLogPartitionRepository manages the partitions of service_status_logs
+type StatusLogPartitionRepository struct {
+	db *sql.DB
+}
+
+func NewStatusLogPartitionRepository(db *sql.DB) *StatusLogPartitionRepository {
+	return &StatusLogPartitionRepository{db: db}
+}
+
+// IsPartitioned reports whether service_status_logs is a partitioned table
+func (r *StatusLogPartitionRepository) IsPartitioned(ctx context.Context) (bool, error) {
+	query := `SELECT EXISTS (SELECT 1 FROM pg_partitioned_table WHERE partrelid = to_regclass('service_status_logs'))`
+
+	var partitioned bool
+	if err := r.db.QueryRowContext(ctx, query).Scan(&partitioned); err != nil {
+		return false, err
+	}
+	return partitioned, nil
+}
+
+// List returns all partitions of service_status_logs with their bounds
+func (r *StatusLogPartitionRepository) List(ctx context.Context) ([]StatusLogPartition, error) {
+	// Print bounds in UTC so they parse the same regardless of the server's TimeZone
+	tx, err := r.db.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `SET LOCAL TimeZone = 'UTC'`); err != nil {
+		return nil, err
+	}
+
+	rows, err := tx.QueryContext(ctx, `
+		SELECT c.relname, pg_get_expr(c.relpartbound, c.oid)
+		FROM pg_inherits i
+		JOIN pg_class c ON c.oid = i.inhrelid
+		WHERE i.inhparent = 'service_status_logs'::regclass
+		ORDER BY c.relname
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var partitions []StatusLogPartition
+	for rows.Next() {
+		var name, bound string
+		if err := rows.Scan(&name, &bound); err != nil {
+			return nil, err
+		}
+
+		partition, err := parsePartitionBound(name, bound)
+		if err != nil {
+			return nil, err
+		}
+		partitions = append(partitions, partition)
+	}
+
+	return partitions, rows.Err()
+}
+
+// Create creates a partition for checked_at in [from, to)
+// Rows already caught by the default partition in that range are moved into the new partition:
+// PostgreSQL refuses to create a partition overlapping rows of the default one, so the default
+// is detached while the partition is created and attached again once the rows have moved
+func (r *StatusLogPartitionRepository) Create(ctx context.Context, name string, from, to time.Time) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var exists, hasDefault bool
+	err = tx.QueryRowContext(ctx, `SELECT to_regclass($1) IS NOT NULL, to_regclass($2) IS NOT NULL`,
+		pq.QuoteIdentifier(name), pq.QuoteIdentifier(StatusLogDefaultPartition)).Scan(&exists, &hasDefault)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return nil
+	}
+
+	// Block inserts so no row can reach the default partition between the check and the move
+	if _, err := tx.ExecContext(ctx, `LOCK TABLE service_status_logs IN SHARE ROW EXCLUSIVE MODE`); err != nil {
+		return err
+	}
+
+	stray := false
+	if hasDefault {
+		query := `SELECT EXISTS (SELECT 1 FROM ` + pq.QuoteIdentifier(StatusLogDefaultPartition) + ` WHERE checked_at >= $1 AND checked_at < $2)`
+		if err := tx.QueryRowContext(ctx, query, from, to).Scan(&stray); err != nil {
+			return fmt.Errorf("failed to check default partition: %w", err)
+		}
+	}
+
+	if stray {
+		detach := `ALTER TABLE service_status_logs DETACH PARTITION ` + pq.QuoteIdentifier(StatusLogDefaultPartition)
+		if _, err := tx.ExecContext(ctx, detach); err != nil {
+			return fmt.Errorf("failed to detach default partition: %w", err)
+		}
+	}
+
+	// DDL doesn't accept bind parameters - quote everything explicitly
+	query := fmt.Sprintf(
+		`CREATE TABLE %s PARTITION OF service_status_logs FOR VALUES FROM (%s) TO (%s)`,
+		pq.QuoteIdentifier(name),
+		pq.QuoteLiteral(from.UTC().Format(time.RFC3339)),
+		pq.QuoteLiteral(to.UTC().Format(time.RFC3339)),
+	)
+	if _, err := tx.ExecContext(ctx, query); err != nil {
+		return err
+	}
+
+	if stray {
+		move := fmt.Sprintf(
+			`WITH moved AS (DELETE FROM %s WHERE checked_at >= $1 AND checked_at < $2 RETURNING *) INSERT INTO %s SELECT * FROM moved`,
+			pq.QuoteIdentifier(StatusLogDefaultPartition),
+			pq.QuoteIdentifier(name),
+		)
+		if _, err := tx.ExecContext(ctx, move, from, to); err != nil {
+			return fmt.Errorf("failed to move rows from default partition: %w", err)
+		}
+
+		attach := `ALTER TABLE service_status_logs ATTACH PARTITION ` + pq.QuoteIdentifier(StatusLogDefaultPartition) + ` DEFAULT`
+		if _, err := tx.ExecContext(ctx, attach); err != nil {
+			return fmt.Errorf("failed to attach default partition: %w", err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// Drop drops a partition and all of its rows
+func (r *StatusLogPartitionRepository) Drop(ctx context.Context, name string) error {
+	_, err := r.db.ExecContext(ctx, `DROP TABLE IF EXISTS `+pq.QuoteIdentifier(name))
+	return err
+}
+
+// DeleteFromDefaultOlderThan deletes rows older than cutoffTime from the catch-all partition
+func (r *StatusLogPartitionRepository) DeleteFromDefaultOlderThan(ctx context.Context, cutoffTime time.Time) (int64, error) {
+	query := `DELETE FROM ` + pq.QuoteIdentifier(StatusLogDefaultPartition) + ` WHERE checked_at < $1`
+
+	result, err := r.db.ExecContext(ctx, query, cutoffTime)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+// partitionBoundPattern matches pg_get_expr output for a range partition, e.g.
+// FOR VALUES FROM ('2026-10-18 00:00:00+00') TO ('2026-10-19 00:00:00+00')
+var partitionBoundPattern = regexp.MustCompile(`^FOR VALUES FROM \('([^']+)'\) TO \('([^']+)'\)$`)
+
+// partitionBoundLayouts are the timestamptz formats PostgreSQL prints (whole-hour or hh:mm offsets)
+var partitionBoundLayouts = []string{"2006-01-02 15:04:05-07", "2006-01-02 15:04:05-07:00"}
+
+// parsePartitionBound parses the bound expression of a partition
+func parsePartitionBound(name, bound string) (StatusLogPartition, error) {
+	if bound == "DEFAULT" {
+		return StatusLogPartition{Name: name, Default: true}, nil
+	}
+
+	match := partitionBoundPattern.FindStringSubmatch(bound)
+	if match == nil {
+		return StatusLogPartition{}, fmt.Errorf("unexpected bound for partition %s: %s", name, bound)
+	}
+
+	from, err := parsePartitionTime(match[1])
+	if err != nil {
+		return StatusLogPartition{}, fmt.Errorf("invalid lower bound for partition %s: %w", name, err)
+	}
+	to, err := parsePartitionTime(match[2])
+	if err != nil {
+		return StatusLogPartition{}, fmt.Errorf("invalid upper bound for partition %s: %w", name, err)
+	}
+
+	return StatusLogPartition{Name: name, From: from, To: to}, nil
+}
+
+func parsePartitionTime(value string) (time.Time, error) {
+	var err error
+	for _, layout := range partitionBoundLayouts {
+		var t time.Time
+		if t, err = time.Parse(layout, value); err == nil {
+			return t.UTC(), nil
+		}
+	}
+	return time.Time{}, err
+}
@@ -0,0 +1,113 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/nimbus/backend/internal/db/dbtest"
+)
+
+func TestParsePartitionBound(t *testing.T) {
+	tests := []struct {
+		name     string
+		bound    string
+		expected StatusLogPartition
+		wantErr  bool
+	}{
+		{
+			"UTC range",
+			"FOR VALUES FROM ('2026-10-18 00:00:00+00') TO ('2026-10-19 00:00:00+00')",
+			StatusLogPartition{From: time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC), To: time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC)},
+			false,
+		},
+		{
+			"Half-hour offset",
+			"FOR VALUES FROM ('2026-10-18 05:30:00+05:30') TO ('2026-10-19 05:30:00+05:30')",
+			StatusLogPartition{From: time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC), To: time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC)},
+			false,
+		},
+		{"Default partition", "DEFAULT", StatusLogPartition{Default: true}, false},
+		{"List partition", "FOR VALUES IN ('online')", StatusLogPartition{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parsePartitionBound("p", tt.bound)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parsePartitionBound() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if got.Default != tt.expected.Default || !got.From.Equal(tt.expected.From) || !got.To.Equal(tt.expected.To) {
+				t.Errorf("parsePartitionBound() = %+v, expected %+v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestStatusLogPartitionRepository_CreateMovesDefaultRows(t *testing.T) {
+	db := dbtest.Postgres(t)
+	dbtest.Migrate(t, db, "")
+	ctx := context.Background()
+
+	var serviceID string
+	err := db.QueryRow(`
+		WITH u AS (
+			INSERT INTO users (email, name, password) VALUES ('partitions@example.com', 'Partitions', 'x') RETURNING id
+		)
+		INSERT INTO services (user_id, name, url) SELECT id, 'Service', 'http://example.com' FROM u RETURNING id
+	`).Scan(&serviceID)
+	if err != nil {
+		t.Fatalf("Failed to create service: %v", err)
+	}
+
+	// Far beyond the partitions created by the migration, so the rows land in the default partition
+	from := time.Date(2040, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := from.Add(24 * time.Hour)
+	for _, checkedAt := range []time.Time{from.Add(time.Hour), to.Add(time.Hour)} {
+		if _, err := db.Exec(`INSERT INTO service_status_logs (service_id, status, checked_at) VALUES ($1, 'online', $2)`, serviceID, checkedAt); err != nil {
+			t.Fatalf("Failed to insert status log: %v", err)
+		}
+	}
+
+	repo := NewStatusLogPartitionRepository(db)
+	if err := repo.Create(ctx, "service_status_logs_p20400101", from, to); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	// Idempotent
+	if err := repo.Create(ctx, "service_status_logs_p20400101", from, to); err != nil {
+		t.Fatalf("Create() again error = %v", err)
+	}
+
+	counts := map[string]int{}
+	rows, err := db.Query(`SELECT tableoid::regclass::text, COUNT(*) FROM service_status_logs GROUP BY 1`)
+	if err != nil {
+		t.Fatalf("Failed to count rows: %v", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var partition string
+		var count int
+		if err := rows.Scan(&partition, &count); err != nil {
+			t.Fatalf("Failed to scan count: %v", err)
+		}
+		counts[partition] = count
+	}
+	if counts["service_status_logs_p20400101"] != 1 || counts[StatusLogDefaultPartition] != 1 {
+		t.Errorf("Expected one row in the new partition and one left in the default, got %v", counts)
+	}
+
+	partitions, err := repo.List(ctx)
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	hasDefault := false
+	for _, p := range partitions {
+		hasDefault = hasDefault || (p.Default && p.Name == StatusLogDefaultPartition)
+	}
+	if !hasDefault {
+		t.Error("Expected the default partition to be attached again")
+	}
+}
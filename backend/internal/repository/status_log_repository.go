@@ -3,19 +3,37 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
 	"time"
 
+	"github.com/lib/pq"
 	"github.com/nimbus/backend/internal/models"
 )
 
 type StatusLogRepository struct {
-	db *sql.DB
+	db           *sql.DB
+	isPostgreSQL bool
 }
 
 func NewStatusLogRepository(db *sql.DB) *StatusLogRepository {
-	return &StatusLogRepository{db: db}
+	// Detect PostgreSQL the same way as ServiceRepository (percentiles use percentile_cont there)
+	isPostgreSQL := false
+	if db != nil {
+		var version string
+		if err := db.QueryRow("SELECT version()").Scan(&version); err == nil {
+			isPostgreSQL = strings.HasPrefix(version, "PostgreSQL")
+		}
+	}
+
+	return &StatusLogRepository{db: db, isPostgreSQL: isPostgreSQL}
 }
 
+// statusLogColumns is the column list shared by all status log SELECTs (see scanStatusLog)
+const statusLogColumns = `id, service_id, status, response_time, error_message, event, event_detail, failed_step, step_timings, address_family, checked_at`
+
 // Create creates a new status log entry
 func (r *StatusLogRepository) Create(ctx context.Context, log *models.StatusLog) error {
 	// If ID is provided, use it; otherwise let the database generate it
@@ -25,8 +43,8 @@ func (r *StatusLogRepository) Create(ctx context.Context, log *models.StatusLog)
 	if log.ID != "" {
 		// ID provided (e.g., in tests) - insert it directly
 		query = `
-			INSERT INTO service_status_logs (id, service_id, status, response_time, error_message, checked_at)
-			VALUES ($1, $2, $3, $4, $5, $6)
+			INSERT INTO service_status_logs (id, service_id, status, response_time, error_message, event, event_detail, failed_step, step_timings, address_family, checked_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
 		`
 		_, err = r.db.ExecContext(
 			ctx,
@@ -36,13 +54,18 @@ func (r *StatusLogRepository) Create(ctx context.Context, log *models.StatusLog)
 			log.Status,
 			log.ResponseTime,
 			log.ErrorMessage,
+			log.Event,
+			log.EventDetail,
+			log.FailedStep,
+			log.StepTimings,
+			log.AddressFamily,
 			log.CheckedAt,
 		)
 	} else {
 		// No ID provided - let database generate it
 		query = `
-			INSERT INTO service_status_logs (service_id, status, response_time, error_message, checked_at)
-			VALUES ($1, $2, $3, $4, $5)
+			INSERT INTO service_status_logs (service_id, status, response_time, error_message, event, event_detail, failed_step, step_timings, address_family, checked_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
 			RETURNING id
 		`
 		err = r.db.QueryRowContext(
@@ -52,6 +75,11 @@ func (r *StatusLogRepository) Create(ctx context.Context, log *models.StatusLog)
 			log.Status,
 			log.ResponseTime,
 			log.ErrorMessage,
+			log.Event,
+			log.EventDetail,
+			log.FailedStep,
+			log.StepTimings,
+			log.AddressFamily,
 			log.CheckedAt,
 		).Scan(&log.ID)
 	}
@@ -59,17 +87,90 @@ func (r *StatusLogRepository) Create(ctx context.Context, log *models.StatusLog)
 	return err
 }
 
-// GetByServiceID retrieves status logs for a specific service within a time range
-func (r *StatusLogRepository) GetByServiceID(ctx context.Context, serviceID string, startTime, endTime time.Time, limit int) ([]*models.StatusLog, error) {
-	query := `
-		SELECT id, service_id, status, response_time, error_message, checked_at
-		FROM service_status_logs
-		WHERE service_id = $1 AND checked_at >= $2 AND checked_at <= $3
-		ORDER BY checked_at DESC
-		LIMIT $4
-	`
+// statusLogBatchChunk is the number of rows per multi-row INSERT in CreateBatch
+// 50 rows x 10 columns stays well below the bind parameter limits of PostgreSQL and SQLite
+const statusLogBatchChunk = 50
+
+// CreateBatch inserts many status logs using multi-row INSERTs in a single transaction
+// IDs are generated by the database and not read back
+func (r *StatusLogRepository) CreateBatch(ctx context.Context, logs []*models.StatusLog) error {
+	if len(logs) == 0 {
+		return nil
+	}
 
-	rows, err := r.db.QueryContext(ctx, query, serviceID, startTime, endTime, limit)
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for start := 0; start < len(logs); start += statusLogBatchChunk {
+		end := start + statusLogBatchChunk
+		if end > len(logs) {
+			end = len(logs)
+		}
+		chunk := logs[start:end]
+
+		const columns = 10
+		placeholders := make([]string, 0, len(chunk))
+		args := make([]interface{}, 0, len(chunk)*columns)
+		for i, log := range chunk {
+			params := make([]string, columns)
+			for j := range params {
+				params[j] = fmt.Sprintf("$%d", i*columns+j+1)
+			}
+			placeholders = append(placeholders, "("+strings.Join(params, ", ")+")")
+			args = append(args,
+				log.ServiceID,
+				log.Status,
+				log.ResponseTime,
+				log.ErrorMessage,
+				log.Event,
+				log.EventDetail,
+				log.FailedStep,
+				log.StepTimings,
+				log.AddressFamily,
+				log.CheckedAt,
+			)
+		}
+
+		query := `
+			INSERT INTO service_status_logs (service_id, status, response_time, error_message, event, event_detail, failed_step, step_timings, address_family, checked_at)
+			VALUES ` + strings.Join(placeholders, ", ")
+
+		if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+			return fmt.Errorf("failed to insert status logs: %w", err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// scanStatusLog scans a row selected with statusLogColumns
+func scanStatusLog(row rowScanner) (*models.StatusLog, error) {
+	log := &models.StatusLog{}
+	err := row.Scan(
+		&log.ID,
+		&log.ServiceID,
+		&log.Status,
+		&log.ResponseTime,
+		&log.ErrorMessage,
+		&log.Event,
+		&log.EventDetail,
+		&log.FailedStep,
+		&log.StepTimings,
+		&log.AddressFamily,
+		&log.CheckedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return log, nil
+}
+
+// queryStatusLogs runs a query selecting statusLogColumns and scans all rows
+func (r *StatusLogRepository) queryStatusLogs(ctx context.Context, query string, args ...interface{}) ([]*models.StatusLog, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -77,15 +178,7 @@ func (r *StatusLogRepository) GetByServiceID(ctx context.Context, serviceID stri
 
 	var logs []*models.StatusLog
 	for rows.Next() {
-		log := &models.StatusLog{}
-		err := rows.Scan(
-			&log.ID,
-			&log.ServiceID,
-			&log.Status,
-			&log.ResponseTime,
-			&log.ErrorMessage,
-			&log.CheckedAt,
-		)
+		log, err := scanStatusLog(rows)
 		if err != nil {
 			return nil, err
 		}
@@ -95,49 +188,41 @@ func (r *StatusLogRepository) GetByServiceID(ctx context.Context, serviceID stri
 	return logs, rows.Err()
 }
 
+// GetByServiceID retrieves status logs for a specific service within a time range
+func (r *StatusLogRepository) GetByServiceID(ctx context.Context, serviceID string, startTime, endTime time.Time, limit int) ([]*models.StatusLog, error) {
+	query := `
+		SELECT ` + statusLogColumns + `
+		FROM service_status_logs
+		WHERE service_id = $1 AND checked_at >= $2 AND checked_at <= $3
+		ORDER BY checked_at DESC
+		LIMIT $4
+	`
+
+	return r.queryStatusLogs(ctx, query, serviceID, startTime, endTime, limit)
+}
+
 // GetLatestByServiceID retrieves the most recent N status logs for a service
 func (r *StatusLogRepository) GetLatestByServiceID(ctx context.Context, serviceID string, limit int) ([]*models.StatusLog, error) {
 	query := `
-		SELECT id, service_id, status, response_time, error_message, checked_at
+		SELECT ` + statusLogColumns + `
 		FROM service_status_logs
 		WHERE service_id = $1
 		ORDER BY checked_at DESC
 		LIMIT $2
 	`
 
-	rows, err := r.db.QueryContext(ctx, query, serviceID, limit)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-
-	var logs []*models.StatusLog
-	for rows.Next() {
-		log := &models.StatusLog{}
-		err := rows.Scan(
-			&log.ID,
-			&log.ServiceID,
-			&log.Status,
-			&log.ResponseTime,
-			&log.ErrorMessage,
-			&log.CheckedAt,
-		)
-		if err != nil {
-			return nil, err
-		}
-		logs = append(logs, log)
-	}
-
-	return logs, rows.Err()
+	return r.queryStatusLogs(ctx, query, serviceID, limit)
 }
 
 // GetUptimeStats calculates uptime statistics for a service within a time range
+// Dual-stack checks log a row per family at the same checked_at; they count as one check,
+// online only if every family was. Response times cover every row
 func (r *StatusLogRepository) GetUptimeStats(ctx context.Context, serviceID string, startTime, endTime time.Time) (map[string]interface{}, error) {
 	query := `
 		SELECT
-			COUNT(*) as total_checks,
-			COUNT(CASE WHEN status = 'online' THEN 1 END) as online_count,
-			COUNT(CASE WHEN status = 'offline' THEN 1 END) as offline_count,
+			COUNT(DISTINCT checked_at) as total_checks,
+			COUNT(DISTINCT checked_at) - COUNT(DISTINCT CASE WHEN status <> 'online' THEN checked_at END) as online_count,
+			COUNT(DISTINCT CASE WHEN status = 'offline' THEN checked_at END) as offline_count,
 			COALESCE(AVG(CASE WHEN response_time IS NOT NULL THEN response_time END), 0) as avg_response_time,
 			COALESCE(MIN(CASE WHEN response_time IS NOT NULL THEN response_time END), 0) as min_response_time,
 			COALESCE(MAX(CASE WHEN response_time IS NOT NULL THEN response_time END), 0) as max_response_time
@@ -177,55 +262,379 @@ func (r *StatusLogRepository) GetUptimeStats(ctx context.Context, serviceID stri
 	}, nil
 }
 
-// GetAggregatedByInterval returns status logs aggregated by time intervals (for graphing)
-func (r *StatusLogRepository) GetAggregatedByInterval(ctx context.Context, serviceID string, startTime, endTime time.Time, intervalMinutes int) ([]map[string]interface{}, error) {
+// GetUptimeStatsByFamily calculates uptime statistics per IP address family within a time range
+// Checks without a recorded family are excluded
+func (r *StatusLogRepository) GetUptimeStatsByFamily(ctx context.Context, serviceID string, startTime, endTime time.Time) (map[string]map[string]interface{}, error) {
 	query := `
 		SELECT
-			date_trunc('minute', checked_at) -
-			(EXTRACT(minute FROM checked_at)::int % $4) * interval '1 minute' as time_bucket,
-			COUNT(*) as check_count,
+			address_family,
+			COUNT(*) as total_checks,
 			COUNT(CASE WHEN status = 'online' THEN 1 END) as online_count,
+			COUNT(CASE WHEN status = 'offline' THEN 1 END) as offline_count,
 			COALESCE(AVG(CASE WHEN response_time IS NOT NULL THEN response_time END), 0) as avg_response_time
 		FROM service_status_logs
-		WHERE service_id = $1 AND checked_at >= $2 AND checked_at <= $3
-		GROUP BY time_bucket
-		ORDER BY time_bucket ASC
+		WHERE service_id = $1 AND checked_at >= $2 AND checked_at <= $3 AND address_family IS NOT NULL
+		GROUP BY address_family
 	`
 
-	rows, err := r.db.QueryContext(ctx, query, serviceID, startTime, endTime, intervalMinutes)
+	rows, err := r.db.QueryContext(ctx, query, serviceID, startTime, endTime)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var results []map[string]interface{}
+	results := make(map[string]map[string]interface{})
 	for rows.Next() {
-		var timeBucket time.Time
-		var checkCount, onlineCount int
+		var family string
+		var totalChecks, onlineCount, offlineCount int
 		var avgResponseTime float64
 
-		err := rows.Scan(&timeBucket, &checkCount, &onlineCount, &avgResponseTime)
-		if err != nil {
+		if err := rows.Scan(&family, &totalChecks, &onlineCount, &offlineCount, &avgResponseTime); err != nil {
 			return nil, err
 		}
 
 		uptimePercentage := 0.0
-		if checkCount > 0 {
-			uptimePercentage = (float64(onlineCount) / float64(checkCount)) * 100
+		if totalChecks > 0 {
+			uptimePercentage = (float64(onlineCount) / float64(totalChecks)) * 100
 		}
 
-		results = append(results, map[string]interface{}{
-			"timestamp":         timeBucket,
-			"check_count":       checkCount,
+		results[family] = map[string]interface{}{
+			"total_checks":      totalChecks,
 			"online_count":      onlineCount,
+			"offline_count":     offlineCount,
 			"uptime_percentage": uptimePercentage,
 			"avg_response_time": avgResponseTime,
-		})
+		}
+	}
+
+	return results, rows.Err()
+}
+
+// StatusSample is the outcome of one check result, used for time-weighted uptime
+type StatusSample struct {
+	CheckedAt     time.Time
+	Status        string
+	AddressFamily *string
+}
+
+// GetStatusSamples returns a service's check results within a time range, oldest first
+func (r *StatusLogRepository) GetStatusSamples(ctx context.Context, serviceID string, startTime, endTime time.Time) ([]StatusSample, error) {
+	query := `
+		SELECT checked_at, status, address_family
+		FROM service_status_logs
+		WHERE service_id = $1 AND checked_at >= $2 AND checked_at <= $3
+		ORDER BY checked_at ASC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, serviceID, startTime, endTime)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var samples []StatusSample
+	for rows.Next() {
+		var sample StatusSample
+		var addressFamily sql.NullString
+		if err := rows.Scan(&sample.CheckedAt, &sample.Status, &addressFamily); err != nil {
+			return nil, err
+		}
+		if addressFamily.Valid {
+			sample.AddressFamily = &addressFamily.String
+		}
+		samples = append(samples, sample)
+	}
+
+	return samples, rows.Err()
+}
+
+// CountResponseTimesWithin counts a service's checks with a response time within a time range,
+// and how many of them responded within thresholdMs (for latency objectives)
+func (r *StatusLogRepository) CountResponseTimesWithin(ctx context.Context, serviceID string, startTime, endTime time.Time, thresholdMs int) (within, total int, err error) {
+	// The threshold is an int - inlined because SQLite numbers parameters by their first appearance
+	query := fmt.Sprintf(`
+		SELECT
+			COALESCE(SUM(CASE WHEN response_time <= %d THEN 1 ELSE 0 END), 0) as within_threshold,
+			COUNT(*) as total
+		FROM service_status_logs
+		WHERE service_id = $1 AND checked_at >= $2 AND checked_at <= $3 AND response_time IS NOT NULL
+	`, thresholdMs)
+
+	err = r.db.QueryRowContext(ctx, query, serviceID, startTime, endTime).Scan(&within, &total)
+	return within, total, err
+}
+
+// GetAggregatedByBuckets returns status logs aggregated into buckets (for graphing)
+// boundaries are the ascending bucket starts; a log belongs to the last bucket starting at or before it.
+// Buckets are computed by the caller so they can follow a time zone's wall clock. Empty buckets are omitted
+func (r *StatusLogRepository) GetAggregatedByBuckets(ctx context.Context, serviceID string, startTime, endTime time.Time, boundaries []time.Time) ([]map[string]interface{}, error) {
+	if len(boundaries) == 0 {
+		return nil, nil
+	}
+
+	if !r.isPostgreSQL {
+		return r.aggregateByBucketsInMemory(ctx, serviceID, startTime, endTime, boundaries)
+	}
+
+	bounds := make([]string, len(boundaries))
+	for i, boundary := range boundaries {
+		bounds[i] = boundary.UTC().Format(time.RFC3339Nano)
+	}
+
+	query := `
+		SELECT
+			width_bucket(checked_at, $4::timestamptz[]) as bucket,
+			COUNT(DISTINCT checked_at) as check_count,
+			COUNT(DISTINCT checked_at) - COUNT(DISTINCT CASE WHEN status <> 'online' THEN checked_at END) as online_count,
+			COALESCE(AVG(CASE WHEN response_time IS NOT NULL THEN response_time END), 0) as avg_response_time,
+			percentile_cont(ARRAY[0.5, 0.9, 0.95, 0.99]) WITHIN GROUP (ORDER BY response_time) as percentiles
+		FROM service_status_logs
+		WHERE service_id = $1 AND checked_at >= $2 AND checked_at <= $3
+		GROUP BY bucket
+		ORDER BY bucket ASC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, serviceID, startTime, endTime, pq.Array(bounds))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var results []map[string]interface{}
+	for rows.Next() {
+		var bucket int
+		var checkCount, onlineCount int
+		var avgResponseTime float64
+		var percentiles pq.Float64Array
+
+		err := rows.Scan(&bucket, &checkCount, &onlineCount, &avgResponseTime, &percentiles)
+		if err != nil {
+			return nil, err
+		}
+
+		// width_bucket numbers buckets from 1 (0 is before the first boundary)
+		if bucket < 1 {
+			continue
+		}
+		results = append(results, aggregatedBucket(boundaries[bucket-1], checkCount, onlineCount, avgResponseTime, percentilesFromArray(percentiles)))
 	}
 
 	return results, rows.Err()
 }
 
+// aggregateByBucketsInMemory is GetAggregatedByBuckets for databases without width_bucket/percentile_cont
+func (r *StatusLogRepository) aggregateByBucketsInMemory(ctx context.Context, serviceID string, startTime, endTime time.Time, boundaries []time.Time) ([]map[string]interface{}, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT checked_at, status, response_time FROM service_status_logs
+		WHERE service_id = $1 AND checked_at >= $2 AND checked_at <= $3
+	`, serviceID, startTime, endTime)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	type bucketStats struct {
+		checks        map[int64]bool // checked_at -> every result online (one per dual-stack check)
+		responseTimes []float64
+	}
+	buckets := make(map[int]*bucketStats)
+
+	for rows.Next() {
+		var checkedAt time.Time
+		var status string
+		var responseTime sql.NullInt64
+		if err := rows.Scan(&checkedAt, &status, &responseTime); err != nil {
+			return nil, err
+		}
+
+		index := sort.Search(len(boundaries), func(i int) bool { return boundaries[i].After(checkedAt) }) - 1
+		if index < 0 {
+			continue
+		}
+
+		stats, ok := buckets[index]
+		if !ok {
+			stats = &bucketStats{checks: make(map[int64]bool)}
+			buckets[index] = stats
+		}
+		online, seen := stats.checks[checkedAt.UnixNano()]
+		stats.checks[checkedAt.UnixNano()] = (online || !seen) && status == string(models.StatusOnline)
+		if responseTime.Valid {
+			stats.responseTimes = append(stats.responseTimes, float64(responseTime.Int64))
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	indexes := make([]int, 0, len(buckets))
+	for index := range buckets {
+		indexes = append(indexes, index)
+	}
+	sort.Ints(indexes)
+
+	results := make([]map[string]interface{}, 0, len(indexes))
+	for _, index := range indexes {
+		stats := buckets[index]
+		avgResponseTime := 0.0
+		for _, responseTime := range stats.responseTimes {
+			avgResponseTime += responseTime
+		}
+		if len(stats.responseTimes) > 0 {
+			avgResponseTime /= float64(len(stats.responseTimes))
+		}
+
+		onlineCount := 0
+		for _, online := range stats.checks {
+			if online {
+				onlineCount++
+			}
+		}
+		results = append(results, aggregatedBucket(boundaries[index], len(stats.checks), onlineCount, avgResponseTime, percentilesOf(stats.responseTimes)))
+	}
+
+	return results, nil
+}
+
+func aggregatedBucket(timestamp time.Time, checkCount, onlineCount int, avgResponseTime float64, percentiles ResponseTimePercentiles) map[string]interface{} {
+	uptimePercentage := 0.0
+	if checkCount > 0 {
+		uptimePercentage = (float64(onlineCount) / float64(checkCount)) * 100
+	}
+
+	return map[string]interface{}{
+		"timestamp":         timestamp,
+		"check_count":       checkCount,
+		"online_count":      onlineCount,
+		"uptime_percentage": uptimePercentage,
+		"avg_response_time": avgResponseTime,
+		"percentiles":       percentiles,
+	}
+}
+
+// ResponseTimePercentiles are response time percentiles in milliseconds (0 without responses)
+type ResponseTimePercentiles struct {
+	P50 float64
+	P90 float64
+	P95 float64
+	P99 float64
+}
+
+// percentileQuantiles are the quantiles of ResponseTimePercentiles, in field order
+var percentileQuantiles = []float64{0.5, 0.9, 0.95, 0.99}
+
+func percentilesFromArray(values []float64) ResponseTimePercentiles {
+	if len(values) != len(percentileQuantiles) {
+		return ResponseTimePercentiles{}
+	}
+	return ResponseTimePercentiles{P50: values[0], P90: values[1], P95: values[2], P99: values[3]}
+}
+
+// GetResponseTimePercentiles calculates response time percentiles for a service within a time range
+// Uses percentile_cont on PostgreSQL; other databases interpolate the same way in Go
+func (r *StatusLogRepository) GetResponseTimePercentiles(ctx context.Context, serviceID string, startTime, endTime time.Time) (ResponseTimePercentiles, error) {
+	if r.isPostgreSQL {
+		query := `
+			SELECT percentile_cont(ARRAY[0.5, 0.9, 0.95, 0.99]) WITHIN GROUP (ORDER BY response_time)
+			FROM service_status_logs
+			WHERE service_id = $1 AND checked_at >= $2 AND checked_at <= $3
+		`
+
+		var values pq.Float64Array
+		if err := r.db.QueryRowContext(ctx, query, serviceID, startTime, endTime).Scan(&values); err != nil {
+			return ResponseTimePercentiles{}, err
+		}
+		return percentilesFromArray(values), nil
+	}
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT response_time FROM service_status_logs
+		WHERE service_id = $1 AND checked_at >= $2 AND checked_at <= $3 AND response_time IS NOT NULL
+	`, serviceID, startTime, endTime)
+	if err != nil {
+		return ResponseTimePercentiles{}, err
+	}
+	defer rows.Close()
+
+	var responseTimes []float64
+	for rows.Next() {
+		var responseTime float64
+		if err := rows.Scan(&responseTime); err != nil {
+			return ResponseTimePercentiles{}, err
+		}
+		responseTimes = append(responseTimes, responseTime)
+	}
+	if err := rows.Err(); err != nil {
+		return ResponseTimePercentiles{}, err
+	}
+	return percentilesOf(responseTimes), nil
+}
+
+// percentilesOf calculates percentiles of response times in Go (sorts values in place)
+func percentilesOf(values []float64) ResponseTimePercentiles {
+	if len(values) == 0 {
+		return ResponseTimePercentiles{}
+	}
+	sort.Float64s(values)
+
+	result := make([]float64, len(percentileQuantiles))
+	for i, q := range percentileQuantiles {
+		result[i] = interpolatePercentile(values, q)
+	}
+	return percentilesFromArray(result)
+}
+
+// interpolatePercentile matches PostgreSQL's percentile_cont on sorted values
+func interpolatePercentile(sorted []float64, q float64) float64 {
+	position := q * float64(len(sorted)-1)
+	lower := int(math.Floor(position))
+	upper := int(math.Ceil(position))
+	return sorted[lower] + (sorted[upper]-sorted[lower])*(position-float64(lower))
+}
+
+// GetResponseTimeHistogram counts a service's response times per bucket within a time range
+// bounds are ascending upper bounds in milliseconds; bucket i holds (bounds[i-1], bounds[i]]
+// and the last of the len(bounds)+1 counts holds everything above the last bound
+func (r *StatusLogRepository) GetResponseTimeHistogram(ctx context.Context, serviceID string, startTime, endTime time.Time, bounds []int) ([]int64, error) {
+	// Bounds are integers, so they are inlined (SQLite numbers $N placeholders by first appearance)
+	var bucketExpr strings.Builder
+	bucketExpr.WriteString("CASE")
+	for i, bound := range bounds {
+		fmt.Fprintf(&bucketExpr, " WHEN response_time <= %d THEN %d", bound, i)
+	}
+	fmt.Fprintf(&bucketExpr, " ELSE %d END", len(bounds))
+
+	query := `
+		SELECT bucket, COUNT(*)
+		FROM (
+			SELECT ` + bucketExpr.String() + ` as bucket
+			FROM service_status_logs
+			WHERE service_id = $1 AND checked_at >= $2 AND checked_at <= $3 AND response_time IS NOT NULL
+		) binned
+		GROUP BY bucket
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, serviceID, startTime, endTime)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := make([]int64, len(bounds)+1)
+	for rows.Next() {
+		var bucket int
+		var count int64
+		if err := rows.Scan(&bucket, &count); err != nil {
+			return nil, err
+		}
+		if bucket >= 0 && bucket < len(counts) {
+			counts[bucket] = count
+		}
+	}
+
+	return counts, rows.Err()
+}
+
 // DeleteOlderThan deletes status logs older than the specified time
 func (r *StatusLogRepository) DeleteOlderThan(ctx context.Context, cutoffTime time.Time) (int64, error) {
 	query := `DELETE FROM service_status_logs WHERE checked_at < $1`
@@ -3,6 +3,7 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"math"
 	"testing"
 	"time"
 
@@ -29,6 +30,7 @@ func setupStatusLogTestDB(t *testing.T) *sql.DB {
 			status TEXT DEFAULT 'unknown',
 			response_time INTEGER,
 			position INTEGER,
+			check_config TEXT NOT NULL DEFAULT '{}',
 			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
 			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
 		)
@@ -45,6 +47,11 @@ func setupStatusLogTestDB(t *testing.T) *sql.DB {
 			status TEXT NOT NULL CHECK(status IN ('online', 'offline', 'unknown')),
 			response_time INTEGER,
 			error_message TEXT,
+			event TEXT,
+			event_detail TEXT,
+			failed_step INTEGER,
+			step_timings TEXT,
+			address_family TEXT,
 			checked_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
 			FOREIGN KEY(service_id) REFERENCES services(id) ON DELETE CASCADE
 		)
@@ -90,6 +97,50 @@ func TestStatusLogRepository_Create(t *testing.T) {
 	}
 }
 
+func TestStatusLogRepository_CreateBatch(t *testing.T) {
+	db := setupStatusLogTestDB(t)
+	defer db.Close()
+
+	repo := NewStatusLogRepository(db)
+	ctx := context.Background()
+
+	// More than one chunk, so several multi-row INSERTs run in the transaction
+	total := statusLogBatchChunk + 7
+	now := time.Now()
+	logs := make([]*models.StatusLog, total)
+	for i := range logs {
+		responseTime := i
+		logs[i] = &models.StatusLog{
+			ServiceID:    "test-service-1",
+			Status:       models.StatusOnline,
+			ResponseTime: &responseTime,
+			CheckedAt:    now.Add(time.Duration(i) * time.Second),
+		}
+	}
+	errorMsg := "HTTP 503"
+	logs[total-1].Status = models.StatusOffline
+	logs[total-1].ErrorMessage = &errorMsg
+
+	if err := repo.CreateBatch(ctx, logs); err != nil {
+		t.Fatalf("Failed to create status logs: %v", err)
+	}
+
+	stored, err := repo.GetLatestByServiceID(ctx, "test-service-1", total+10)
+	if err != nil {
+		t.Fatalf("Failed to get status logs: %v", err)
+	}
+	if len(stored) != total {
+		t.Fatalf("Expected %d status logs, got %d", total, len(stored))
+	}
+	if stored[0].Status != models.StatusOffline || stored[0].ErrorMessage == nil || *stored[0].ErrorMessage != errorMsg {
+		t.Errorf("Expected newest log to keep its status and error, got %+v", stored[0])
+	}
+
+	if err := repo.CreateBatch(ctx, nil); err != nil {
+		t.Errorf("Expected empty batch to be a no-op, got %v", err)
+	}
+}
+
 func TestStatusLogRepository_GetLatestByServiceID(t *testing.T) {
 	db := setupStatusLogTestDB(t)
 	defer db.Close()
@@ -189,6 +240,175 @@ func TestStatusLogRepository_GetUptimeStats(t *testing.T) {
 	}
 }
 
+func TestStatusLogRepository_GetUptimeStatsByFamily(t *testing.T) {
+	db := setupStatusLogTestDB(t)
+	defer db.Close()
+
+	repo := NewStatusLogRepository(db)
+	ctx := context.Background()
+
+	ipv4 := models.AddressFamilyIPv4
+	ipv6 := models.AddressFamilyIPv6
+	now := time.Now()
+
+	// 4 IPv4 checks (all online), 4 IPv6 checks (half offline), 1 check without a family
+	for i := 0; i < 4; i++ {
+		responseTime := 100
+		logs := []*models.StatusLog{
+			{ServiceID: "test-service-1", Status: models.StatusOnline, ResponseTime: &responseTime, AddressFamily: &ipv4},
+			{ServiceID: "test-service-1", Status: models.StatusOnline, ResponseTime: &responseTime, AddressFamily: &ipv6},
+		}
+		if i%2 == 0 {
+			logs[1].Status = models.StatusOffline
+		}
+		for _, log := range logs {
+			log.CheckedAt = now.Add(-time.Duration(i) * time.Minute)
+			if err := repo.Create(ctx, log); err != nil {
+				t.Fatalf("Failed to create test log: %v", err)
+			}
+		}
+	}
+	if err := repo.Create(ctx, &models.StatusLog{ServiceID: "test-service-1", Status: models.StatusOffline, CheckedAt: now}); err != nil {
+		t.Fatalf("Failed to create test log: %v", err)
+	}
+
+	stats, err := repo.GetUptimeStatsByFamily(ctx, "test-service-1", now.Add(-1*time.Hour), now.Add(time.Minute))
+	if err != nil {
+		t.Fatalf("Failed to get stats by family: %v", err)
+	}
+
+	if len(stats) != 2 {
+		t.Fatalf("Expected stats for 2 families, got %d", len(stats))
+	}
+	if stats[ipv4]["uptime_percentage"].(float64) != 100.0 {
+		t.Errorf("Expected 100%% IPv4 uptime, got %v", stats[ipv4]["uptime_percentage"])
+	}
+	if stats[ipv6]["uptime_percentage"].(float64) != 50.0 {
+		t.Errorf("Expected 50%% IPv6 uptime, got %v", stats[ipv6]["uptime_percentage"])
+	}
+	if stats[ipv6]["total_checks"].(int) != 4 {
+		t.Errorf("Expected 4 IPv6 checks, got %v", stats[ipv6]["total_checks"])
+	}
+}
+
+func TestStatusLogRepository_GetUptimeStatsDualStack(t *testing.T) {
+	db := setupStatusLogTestDB(t)
+	defer db.Close()
+
+	repo := NewStatusLogRepository(db)
+	ctx := context.Background()
+
+	ipv4 := models.AddressFamilyIPv4
+	ipv6 := models.AddressFamilyIPv6
+	now := time.Now()
+	responseTime := 100
+
+	// One dual-stack check with IPv6 down, then one single-stack check that is online
+	logs := []*models.StatusLog{
+		{ServiceID: "test-service-1", Status: models.StatusOnline, ResponseTime: &responseTime, AddressFamily: &ipv4, CheckedAt: now.Add(-2 * time.Minute)},
+		{ServiceID: "test-service-1", Status: models.StatusOffline, AddressFamily: &ipv6, CheckedAt: now.Add(-2 * time.Minute)},
+		{ServiceID: "test-service-1", Status: models.StatusOnline, ResponseTime: &responseTime, CheckedAt: now.Add(-1 * time.Minute)},
+	}
+	for _, log := range logs {
+		if err := repo.Create(ctx, log); err != nil {
+			t.Fatalf("Failed to create test log: %v", err)
+		}
+	}
+
+	stats, err := repo.GetUptimeStats(ctx, "test-service-1", now.Add(-1*time.Hour), now)
+	if err != nil {
+		t.Fatalf("Failed to get uptime stats: %v", err)
+	}
+
+	if stats["total_checks"].(int) != 2 {
+		t.Errorf("Expected 2 checks, got %v", stats["total_checks"])
+	}
+	if stats["online_count"].(int) != 1 {
+		t.Errorf("Expected 1 online check, got %v", stats["online_count"])
+	}
+	if stats["offline_count"].(int) != 1 {
+		t.Errorf("Expected 1 offline check, got %v", stats["offline_count"])
+	}
+	if stats["uptime_percentage"].(float64) != 50.0 {
+		t.Errorf("Expected 50%% uptime, got %v", stats["uptime_percentage"])
+	}
+}
+
+func TestStatusLogRepository_GetResponseTimePercentiles(t *testing.T) {
+	db := setupStatusLogTestDB(t)
+	defer db.Close()
+
+	repo := NewStatusLogRepository(db)
+	ctx := context.Background()
+
+	now := time.Now()
+
+	// Response times 10..100ms, plus a check without a response time
+	for i := 1; i <= 10; i++ {
+		responseTime := i * 10
+		if err := repo.Create(ctx, &models.StatusLog{ServiceID: "test-service-1", Status: models.StatusOnline, ResponseTime: &responseTime, CheckedAt: now.Add(-time.Duration(i) * time.Minute)}); err != nil {
+			t.Fatalf("Failed to create test log: %v", err)
+		}
+	}
+	if err := repo.Create(ctx, &models.StatusLog{ServiceID: "test-service-1", Status: models.StatusOffline, CheckedAt: now}); err != nil {
+		t.Fatalf("Failed to create test log: %v", err)
+	}
+
+	percentiles, err := repo.GetResponseTimePercentiles(ctx, "test-service-1", now.Add(-1*time.Hour), now)
+	if err != nil {
+		t.Fatalf("Failed to get percentiles: %v", err)
+	}
+
+	// Linear interpolation between the closest ranks, like percentile_cont
+	expected := ResponseTimePercentiles{P50: 55, P90: 91, P95: 95.5, P99: 99.1}
+	tolerance := 1e-9
+	if math.Abs(percentiles.P50-expected.P50) > tolerance || math.Abs(percentiles.P90-expected.P90) > tolerance ||
+		math.Abs(percentiles.P95-expected.P95) > tolerance || math.Abs(percentiles.P99-expected.P99) > tolerance {
+		t.Errorf("Expected %+v, got %+v", expected, percentiles)
+	}
+
+	empty, err := repo.GetResponseTimePercentiles(ctx, "test-service-2", now.Add(-1*time.Hour), now)
+	if err != nil {
+		t.Fatalf("Failed to get percentiles: %v", err)
+	}
+	if empty != (ResponseTimePercentiles{}) {
+		t.Errorf("Expected zero percentiles without responses, got %+v", empty)
+	}
+}
+
+func TestStatusLogRepository_GetResponseTimeHistogram(t *testing.T) {
+	db := setupStatusLogTestDB(t)
+	defer db.Close()
+
+	repo := NewStatusLogRepository(db)
+	ctx := context.Background()
+
+	now := time.Now()
+	for i, responseTime := range []int{10, 50, 51, 100, 400, 5000} {
+		responseTime := responseTime
+		if err := repo.Create(ctx, &models.StatusLog{ServiceID: "test-service-1", Status: models.StatusOnline, ResponseTime: &responseTime, CheckedAt: now.Add(-time.Duration(i) * time.Minute)}); err != nil {
+			t.Fatalf("Failed to create test log: %v", err)
+		}
+	}
+
+	counts, err := repo.GetResponseTimeHistogram(ctx, "test-service-1", now.Add(-1*time.Hour), now, []int{50, 100, 1000})
+	if err != nil {
+		t.Fatalf("Failed to get histogram: %v", err)
+	}
+
+	// Buckets are (0, 50], (50, 100], (100, 1000] and the overflow
+	expected := []int64{2, 2, 1, 1}
+	if len(counts) != len(expected) {
+		t.Fatalf("Expected %d buckets, got %v", len(expected), counts)
+	}
+	for i := range expected {
+		if counts[i] != expected[i] {
+			t.Errorf("Expected %v, got %v", expected, counts)
+			break
+		}
+	}
+}
+
 func TestStatusLogRepository_DeleteOlderThan(t *testing.T) {
 	db := setupStatusLogTestDB(t)
 	defer db.Close()
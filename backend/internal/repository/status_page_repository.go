@@ -0,0 +1,359 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/nimbus/backend/internal/models"
+)
+
+type StatusPageRepository struct {
+	db *sql.DB
+}
+
+func NewStatusPageRepository(db *sql.DB) *StatusPageRepository {
+	return &StatusPageRepository{db: db}
+}
+
+const statusPageColumns = `id, user_id, slug, title, description, timezone, password_hash, created_at, updated_at`
+
+const announcementColumns = `id, status_page_id, title, body, severity, starts_at, ends_at, created_at, updated_at`
+
+// Create stores a new status page with its services
+func (r *StatusPageRepository) Create(ctx context.Context, page *models.StatusPage) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `
+		INSERT INTO status_pages (user_id, slug, title, description, timezone, password_hash, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id
+	`
+
+	err = tx.QueryRowContext(
+		ctx,
+		query,
+		page.UserID,
+		page.Slug,
+		page.Title,
+		page.Description,
+		page.Timezone,
+		page.PasswordHash,
+		page.CreatedAt,
+		page.UpdatedAt,
+	).Scan(&page.ID)
+	if err != nil {
+		return fmt.Errorf("failed to create status page: %w", err)
+	}
+
+	if err := insertStatusPageServices(ctx, tx, page); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// GetByID retrieves a status page with its services
+// Returns sql.ErrNoRows if it doesn't exist
+func (r *StatusPageRepository) GetByID(ctx context.Context, id string) (*models.StatusPage, error) {
+	return r.getOne(ctx, `SELECT `+statusPageColumns+` FROM status_pages WHERE id = $1`, id)
+}
+
+// GetBySlug retrieves a status page with its services
+// Returns sql.ErrNoRows if it doesn't exist
+func (r *StatusPageRepository) GetBySlug(ctx context.Context, slug string) (*models.StatusPage, error) {
+	return r.getOne(ctx, `SELECT `+statusPageColumns+` FROM status_pages WHERE slug = $1`, slug)
+}
+
+// GetAllByUserID retrieves a user's status pages
+func (r *StatusPageRepository) GetAllByUserID(ctx context.Context, userID string) ([]*models.StatusPage, error) {
+	return r.query(ctx, `SELECT `+statusPageColumns+` FROM status_pages WHERE user_id = $1 ORDER BY title ASC`, userID)
+}
+
+// Update saves a status page's settings and replaces its services (only if owned by its user)
+// Returns sql.ErrNoRows if it doesn't exist or belongs to another user
+func (r *StatusPageRepository) Update(ctx context.Context, page *models.StatusPage) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `
+		UPDATE status_pages
+		SET slug = $1, title = $2, description = $3, timezone = $4, password_hash = $5, updated_at = $6
+		WHERE id = $7 AND user_id = $8
+	`
+
+	result, err := tx.ExecContext(
+		ctx,
+		query,
+		page.Slug,
+		page.Title,
+		page.Description,
+		page.Timezone,
+		page.PasswordHash,
+		page.UpdatedAt,
+		page.ID,
+		page.UserID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update status page: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM status_page_services WHERE status_page_id = $1`, page.ID); err != nil {
+		return fmt.Errorf("failed to update status page services: %w", err)
+	}
+	if err := insertStatusPageServices(ctx, tx, page); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Delete removes a status page with its announcements (only if owned by the user)
+func (r *StatusPageRepository) Delete(ctx context.Context, id, userID string) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM status_pages WHERE id = $1 AND user_id = $2`, id, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete status page: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// CreateAnnouncement stores a new announcement
+func (r *StatusPageRepository) CreateAnnouncement(ctx context.Context, a *models.StatusPageAnnouncement) error {
+	query := `
+		INSERT INTO status_page_announcements (status_page_id, title, body, severity, starts_at, ends_at, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id
+	`
+
+	err := r.db.QueryRowContext(ctx, query, a.StatusPageID, a.Title, a.Body, a.Severity, a.StartsAt, a.EndsAt, a.CreatedAt, a.UpdatedAt).Scan(&a.ID)
+	if err != nil {
+		return fmt.Errorf("failed to create announcement: %w", err)
+	}
+
+	return nil
+}
+
+// UpdateAnnouncement saves an announcement (only if it belongs to its status page)
+// Returns sql.ErrNoRows if it doesn't exist
+func (r *StatusPageRepository) UpdateAnnouncement(ctx context.Context, a *models.StatusPageAnnouncement) error {
+	query := `
+		UPDATE status_page_announcements
+		SET title = $1, body = $2, severity = $3, starts_at = $4, ends_at = $5, updated_at = $6
+		WHERE id = $7 AND status_page_id = $8
+	`
+
+	result, err := r.db.ExecContext(ctx, query, a.Title, a.Body, a.Severity, a.StartsAt, a.EndsAt, a.UpdatedAt, a.ID, a.StatusPageID)
+	if err != nil {
+		return fmt.Errorf("failed to update announcement: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// DeleteAnnouncement removes an announcement (only if it belongs to the status page)
+// Returns sql.ErrNoRows if it doesn't exist
+func (r *StatusPageRepository) DeleteAnnouncement(ctx context.Context, id, statusPageID string) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM status_page_announcements WHERE id = $1 AND status_page_id = $2`, id, statusPageID)
+	if err != nil {
+		return fmt.Errorf("failed to delete announcement: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// GetAnnouncement retrieves one of a status page's announcements
+// Returns sql.ErrNoRows if it doesn't exist
+func (r *StatusPageRepository) GetAnnouncement(ctx context.Context, id, statusPageID string) (*models.StatusPageAnnouncement, error) {
+	query := `SELECT ` + announcementColumns + ` FROM status_page_announcements WHERE id = $1 AND status_page_id = $2`
+
+	announcements, err := r.queryAnnouncements(ctx, query, id, statusPageID)
+	if err != nil {
+		return nil, err
+	}
+	if len(announcements) == 0 {
+		return nil, sql.ErrNoRows
+	}
+	return announcements[0], nil
+}
+
+// GetAnnouncements retrieves a status page's announcements, newest first
+func (r *StatusPageRepository) GetAnnouncements(ctx context.Context, statusPageID string) ([]*models.StatusPageAnnouncement, error) {
+	query := `SELECT ` + announcementColumns + ` FROM status_page_announcements WHERE status_page_id = $1 ORDER BY starts_at DESC`
+	return r.queryAnnouncements(ctx, query, statusPageID)
+}
+
+// GetActiveAnnouncements retrieves the announcements shown at a time, newest first
+func (r *StatusPageRepository) GetActiveAnnouncements(ctx context.Context, statusPageID string, at time.Time) ([]*models.StatusPageAnnouncement, error) {
+	query := `
+		SELECT ` + announcementColumns + `
+		FROM status_page_announcements
+		WHERE status_page_id = $1 AND starts_at <= $2 AND (ends_at IS NULL OR ends_at > $2)
+		ORDER BY starts_at DESC
+	`
+	return r.queryAnnouncements(ctx, query, statusPageID, at)
+}
+
+func insertStatusPageServices(ctx context.Context, tx *sql.Tx, page *models.StatusPage) error {
+	query := `
+		INSERT INTO status_page_services (status_page_id, service_id, position, display_name, hide_url)
+		VALUES ($1, $2, $3, $4, $5)
+	`
+	for i, service := range page.Services {
+		if _, err := tx.ExecContext(ctx, query, page.ID, service.ServiceID, i, service.DisplayName, service.HideURL); err != nil {
+			return fmt.Errorf("failed to add status page service: %w", err)
+		}
+	}
+	return nil
+}
+
+func (r *StatusPageRepository) getOne(ctx context.Context, query string, args ...interface{}) (*models.StatusPage, error) {
+	pages, err := r.query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	if len(pages) == 0 {
+		return nil, sql.ErrNoRows
+	}
+	return pages[0], nil
+}
+
+func (r *StatusPageRepository) query(ctx context.Context, query string, args ...interface{}) ([]*models.StatusPage, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get status pages: %w", err)
+	}
+	defer rows.Close()
+
+	var pages []*models.StatusPage
+	for rows.Next() {
+		page := &models.StatusPage{Services: []models.StatusPageEntry{}}
+		err := rows.Scan(
+			&page.ID,
+			&page.UserID,
+			&page.Slug,
+			&page.Title,
+			&page.Description,
+			&page.Timezone,
+			&page.PasswordHash,
+			&page.CreatedAt,
+			&page.UpdatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan status page: %w", err)
+		}
+		page.HasPassword = page.PasswordHash != nil
+		pages = append(pages, page)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	for _, page := range pages {
+		if err := r.loadServices(ctx, page); err != nil {
+			return nil, err
+		}
+	}
+
+	return pages, nil
+}
+
+func (r *StatusPageRepository) loadServices(ctx context.Context, page *models.StatusPage) error {
+	query := `
+		SELECT service_id, display_name, hide_url
+		FROM status_page_services
+		WHERE status_page_id = $1
+		ORDER BY position ASC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, page.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get status page services: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var service models.StatusPageEntry
+		if err := rows.Scan(&service.ServiceID, &service.DisplayName, &service.HideURL); err != nil {
+			return fmt.Errorf("failed to scan status page service: %w", err)
+		}
+		page.Services = append(page.Services, service)
+	}
+
+	return rows.Err()
+}
+
+func (r *StatusPageRepository) queryAnnouncements(ctx context.Context, query string, args ...interface{}) ([]*models.StatusPageAnnouncement, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get announcements: %w", err)
+	}
+	defer rows.Close()
+
+	announcements := []*models.StatusPageAnnouncement{}
+	for rows.Next() {
+		a := &models.StatusPageAnnouncement{}
+		err := rows.Scan(
+			&a.ID,
+			&a.StatusPageID,
+			&a.Title,
+			&a.Body,
+			&a.Severity,
+			&a.StartsAt,
+			&a.EndsAt,
+			&a.CreatedAt,
+			&a.UpdatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan announcement: %w", err)
+		}
+		announcements = append(announcements, a)
+	}
+
+	return announcements, rows.Err()
+}
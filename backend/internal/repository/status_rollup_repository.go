@@ -0,0 +1,345 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/nimbus/backend/internal/models"
+)
+
+// Rollup tiers
+const (
+	RollupTierHourly = "hourly"
+	RollupTierDaily  = "daily"
+)
+
+// rollupTables maps each tier to its table, date_trunc unit and bucket width
+var rollupTables = map[string]struct {
+	table, unit, width string
+	duration           time.Duration
+}{
+	RollupTierHourly: {"service_status_rollups_hourly", "hour", "1 hour", time.Hour},
+	RollupTierDaily:  {"service_status_rollups_daily", "day", "24 hours", 24 * time.Hour},
+}
+
+// StatusRollup aggregates the health checks of one service over one bucket
+type StatusRollup struct {
+	ServiceID       string
+	Bucket          time.Time // Start of the bucket (UTC)
+	CheckCount      int
+	OnlineCount     int
+	OfflineCount    int
+	ResponseCount   int   // Checks with a response time
+	ResponseTimeSum int64 // Milliseconds
+	MinResponseTime *int
+	MaxResponseTime *int
+	P95ResponseTime *float64
+	Sketch          models.LatencySketch // Response time distribution (mergeable across buckets)
+	OnlineDuration  time.Duration        // Time the service was known to be online (time-weighted uptime)
+	OfflineDuration time.Duration        // Time the service was known to be offline
+}
+
+// AvgResponseTime returns the average response time in milliseconds (0 without responses)
+func (r *StatusRollup) AvgResponseTime() float64 {
+	if r.ResponseCount == 0 {
+		return 0
+	}
+	return float64(r.ResponseTimeSum) / float64(r.ResponseCount)
+}
+
+// StatusRollupRepository stores hourly and daily aggregates of status logs
+type StatusRollupRepository struct {
+	db *sql.DB
+}
+
+func NewStatusRollupRepository(db *sql.DB) *StatusRollupRepository {
+	return &StatusRollupRepository{db: db}
+}
+
+func rollupTable(tier string) (string, string, error) {
+	t, ok := rollupTables[tier]
+	if !ok {
+		return "", "", fmt.Errorf("unknown rollup tier: %s", tier)
+	}
+	return t.table, t.unit, nil
+}
+
+// Rollup (re)computes the tier's buckets for raw logs checked in [from, to) (PostgreSQL only)
+// Each result counts as the service's state until the next result, for at most maxStaleness.
+// Existing buckets are replaced, so re-running a range is safe
+func (r *StatusRollupRepository) Rollup(ctx context.Context, tier string, from, to time.Time, maxStaleness time.Duration) (int64, error) {
+	table, unit, err := rollupTable(tier)
+	if err != nil {
+		return 0, err
+	}
+	width := rollupTables[tier].width
+
+	bucketExpr := `date_trunc('` + unit + `', checked_at AT TIME ZONE 'UTC') AT TIME ZONE 'UTC'`
+
+	// Sketch bucket index, same formula as models.LatencySketchIndex
+	sketchIndexExpr := `CASE WHEN response_time <= 1 THEN 0 ELSE ceil(ln(response_time) / ` +
+		strconv.FormatFloat(models.LatencySketchLogGamma(), 'g', -1, 64) + `)::int END`
+
+	query := `
+		WITH stats AS (
+			SELECT
+				service_id,
+				` + bucketExpr + ` as bucket,
+				-- Dual-stack checks log a row per family at the same checked_at; count them once
+				COUNT(DISTINCT checked_at) as check_count,
+				COUNT(DISTINCT checked_at) - COUNT(DISTINCT CASE WHEN status <> 'online' THEN checked_at END) as online_count,
+				COUNT(DISTINCT CASE WHEN status = 'offline' THEN checked_at END) as offline_count,
+				COUNT(response_time) as response_count,
+				COALESCE(SUM(response_time), 0) as response_time_sum,
+				MIN(response_time) as min_response_time,
+				MAX(response_time) as max_response_time,
+				percentile_cont(0.95) WITHIN GROUP (ORDER BY response_time) as p95_response_time
+			FROM service_status_logs
+			WHERE checked_at >= $1 AND checked_at < $2
+			GROUP BY service_id, bucket
+		),
+		sketch_bins AS (
+			SELECT service_id, ` + bucketExpr + ` as bucket, ` + sketchIndexExpr + ` as sketch_index, COUNT(*) as sketch_count
+			FROM service_status_logs
+			WHERE checked_at >= $1 AND checked_at < $2 AND response_time IS NOT NULL
+			GROUP BY service_id, bucket, sketch_index
+		),
+		sketches AS (
+			SELECT service_id, bucket, jsonb_object_agg(sketch_index, sketch_count) as latency_sketch
+			FROM sketch_bins
+			GROUP BY service_id, bucket
+		),
+		checks AS (
+			-- One state per check: dual-stack checks log a row per family, any failure wins
+			SELECT service_id, checked_at,
+				CASE WHEN bool_or(status = 'offline') THEN 'offline' WHEN bool_or(status = 'unknown') THEN 'unknown' ELSE 'online' END as status
+			FROM service_status_logs
+			WHERE checked_at >= $1::timestamptz - $3 * interval '1 second' AND checked_at < $2
+			GROUP BY service_id, checked_at
+		),
+		segments AS (
+			SELECT service_id, status, checked_at as seg_start,
+				LEAST(
+					COALESCE(LEAD(checked_at) OVER (PARTITION BY service_id ORDER BY checked_at), 'infinity'),
+					checked_at + $3 * interval '1 second',
+					$2::timestamptz
+				) as seg_end
+			FROM checks
+		),
+		durations AS (
+			-- Segments are split over the buckets they overlap (results from before $1 carry into it)
+			SELECT seg.service_id, b.bucket,
+				SUM(CASE WHEN seg.status = 'online' THEN EXTRACT(EPOCH FROM LEAST(seg.seg_end, b.bucket + interval '` + width + `') - GREATEST(seg.seg_start, b.bucket)) ELSE 0 END) as online_seconds,
+				SUM(CASE WHEN seg.status = 'offline' THEN EXTRACT(EPOCH FROM LEAST(seg.seg_end, b.bucket + interval '` + width + `') - GREATEST(seg.seg_start, b.bucket)) ELSE 0 END) as offline_seconds
+			FROM segments seg
+			CROSS JOIN LATERAL generate_series(
+				date_trunc('` + unit + `', seg.seg_start AT TIME ZONE 'UTC') AT TIME ZONE 'UTC', seg.seg_end, interval '` + width + `'
+			) as b(bucket)
+			WHERE seg.status <> 'unknown' AND b.bucket >= $1 AND b.bucket < $2 AND b.bucket < seg.seg_end
+			GROUP BY seg.service_id, b.bucket
+		)
+		INSERT INTO ` + table + ` (service_id, bucket, check_count, online_count, offline_count, response_count, response_time_sum, min_response_time, max_response_time, p95_response_time, latency_sketch, online_seconds, offline_seconds)
+		SELECT s.service_id, s.bucket, s.check_count, s.online_count, s.offline_count, s.response_count, s.response_time_sum,
+			s.min_response_time, s.max_response_time, s.p95_response_time, k.latency_sketch,
+			COALESCE(d.online_seconds, 0), COALESCE(d.offline_seconds, 0)
+		FROM stats s
+		LEFT JOIN sketches k ON k.service_id = s.service_id AND k.bucket = s.bucket
+		LEFT JOIN durations d ON d.service_id = s.service_id AND d.bucket = s.bucket
+		ON CONFLICT (service_id, bucket) DO UPDATE SET
+			check_count = EXCLUDED.check_count,
+			online_count = EXCLUDED.online_count,
+			offline_count = EXCLUDED.offline_count,
+			response_count = EXCLUDED.response_count,
+			response_time_sum = EXCLUDED.response_time_sum,
+			min_response_time = EXCLUDED.min_response_time,
+			max_response_time = EXCLUDED.max_response_time,
+			p95_response_time = EXCLUDED.p95_response_time,
+			latency_sketch = EXCLUDED.latency_sketch,
+			online_seconds = EXCLUDED.online_seconds,
+			offline_seconds = EXCLUDED.offline_seconds
+	`
+
+	result, err := r.db.ExecContext(ctx, query, from, to, maxStaleness.Seconds())
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+// LatestBucket returns the start of the most recent bucket of the tier (zero time if empty)
+func (r *StatusRollupRepository) LatestBucket(ctx context.Context, tier string) (time.Time, error) {
+	table, _, err := rollupTable(tier)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	var latest time.Time
+	err = r.db.QueryRowContext(ctx, `SELECT bucket FROM `+table+` ORDER BY bucket DESC LIMIT 1`).Scan(&latest)
+	if err == sql.ErrNoRows {
+		return time.Time{}, nil
+	}
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return latest.UTC(), nil
+}
+
+// GetByServiceID returns a service's buckets that overlap [startTime, endTime), oldest first
+func (r *StatusRollupRepository) GetByServiceID(ctx context.Context, tier, serviceID string, startTime, endTime time.Time) ([]*StatusRollup, error) {
+	table, _, err := rollupTable(tier)
+	if err != nil {
+		return nil, err
+	}
+
+	// A bucket starting before startTime still overlaps it if it ends after startTime
+	width := rollupTables[tier].duration
+
+	query := `
+		SELECT service_id, bucket, check_count, online_count, offline_count, response_count, response_time_sum, min_response_time, max_response_time, p95_response_time, latency_sketch, online_seconds, offline_seconds
+		FROM ` + table + `
+		WHERE service_id = $1 AND bucket > $2 AND bucket < $3
+		ORDER BY bucket ASC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, serviceID, startTime.Add(-width), endTime)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var rollups []*StatusRollup
+	for rows.Next() {
+		rollup := &StatusRollup{}
+		var minResponseTime, maxResponseTime sql.NullInt64
+		var p95ResponseTime sql.NullFloat64
+		var onlineSeconds, offlineSeconds sql.NullFloat64
+
+		if err := rows.Scan(
+			&rollup.ServiceID,
+			&rollup.Bucket,
+			&rollup.CheckCount,
+			&rollup.OnlineCount,
+			&rollup.OfflineCount,
+			&rollup.ResponseCount,
+			&rollup.ResponseTimeSum,
+			&minResponseTime,
+			&maxResponseTime,
+			&p95ResponseTime,
+			&rollup.Sketch,
+			&onlineSeconds,
+			&offlineSeconds,
+		); err != nil {
+			return nil, err
+		}
+
+		rollup.Bucket = rollup.Bucket.UTC()
+		if minResponseTime.Valid {
+			v := int(minResponseTime.Int64)
+			rollup.MinResponseTime = &v
+		}
+		if maxResponseTime.Valid {
+			v := int(maxResponseTime.Int64)
+			rollup.MaxResponseTime = &v
+		}
+		if p95ResponseTime.Valid {
+			rollup.P95ResponseTime = &p95ResponseTime.Float64
+		}
+
+		if onlineSeconds.Valid && offlineSeconds.Valid {
+			rollup.OnlineDuration = time.Duration(onlineSeconds.Float64 * float64(time.Second))
+			rollup.OfflineDuration = time.Duration(offlineSeconds.Float64 * float64(time.Second))
+		} else if rollup.CheckCount > 0 {
+			// Buckets rolled up before durations were recorded: assume evenly spaced checks
+			perCheck := width / time.Duration(rollup.CheckCount)
+			rollup.OnlineDuration = perCheck * time.Duration(rollup.OnlineCount)
+			rollup.OfflineDuration = perCheck * time.Duration(rollup.OfflineCount)
+		}
+
+		rollups = append(rollups, rollup)
+	}
+
+	return rollups, rows.Err()
+}
+
+// SummarizeRaw aggregates a service's raw status logs checked in [startTime, endTime) into one rollup
+// Used for the most recent period that hasn't been rolled up yet (sketch but no exact percentile)
+func (r *StatusRollupRepository) SummarizeRaw(ctx context.Context, serviceID string, startTime, endTime time.Time) (*StatusRollup, error) {
+	query := `
+		SELECT
+			-- Counted like Rollup: a dual-stack check is one check, offline if either family failed
+			COUNT(DISTINCT checked_at),
+			COUNT(DISTINCT checked_at) - COUNT(DISTINCT CASE WHEN status <> 'online' THEN checked_at END),
+			COUNT(DISTINCT CASE WHEN status = 'offline' THEN checked_at END),
+			COUNT(response_time),
+			COALESCE(SUM(response_time), 0),
+			MIN(response_time),
+			MAX(response_time)
+		FROM service_status_logs
+		WHERE service_id = $1 AND checked_at >= $2 AND checked_at < $3
+	`
+
+	rollup := &StatusRollup{ServiceID: serviceID, Bucket: startTime.UTC()}
+	var minResponseTime, maxResponseTime sql.NullInt64
+
+	err := r.db.QueryRowContext(ctx, query, serviceID, startTime, endTime).Scan(
+		&rollup.CheckCount,
+		&rollup.OnlineCount,
+		&rollup.OfflineCount,
+		&rollup.ResponseCount,
+		&rollup.ResponseTimeSum,
+		&minResponseTime,
+		&maxResponseTime,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if minResponseTime.Valid {
+		v := int(minResponseTime.Int64)
+		rollup.MinResponseTime = &v
+	}
+	if maxResponseTime.Valid {
+		v := int(maxResponseTime.Int64)
+		rollup.MaxResponseTime = &v
+	}
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT response_time FROM service_status_logs
+		WHERE service_id = $1 AND checked_at >= $2 AND checked_at < $3 AND response_time IS NOT NULL
+	`, serviceID, startTime, endTime)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	rollup.Sketch = models.LatencySketch{}
+	for rows.Next() {
+		var responseTime int
+		if err := rows.Scan(&responseTime); err != nil {
+			return nil, err
+		}
+		rollup.Sketch.Add(responseTime)
+	}
+
+	return rollup, rows.Err()
+}
+
+// DeleteOlderThan deletes the tier's buckets that start before cutoffTime
+func (r *StatusRollupRepository) DeleteOlderThan(ctx context.Context, tier string, cutoffTime time.Time) (int64, error) {
+	table, _, err := rollupTable(tier)
+	if err != nil {
+		return 0, err
+	}
+
+	result, err := r.db.ExecContext(ctx, `DELETE FROM `+table+` WHERE bucket < $1`, cutoffTime)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
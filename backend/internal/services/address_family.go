@@ -0,0 +1,63 @@
+package services
+
+import (
+	"context"
+	"net"
+
+	"github.com/nimbus/backend/internal/models"
+)
+
+// addressFamilyKey is the context key for the address family a health check request must use
+type addressFamilyKey struct{}
+
+// withAddressFamily restricts requests made with ctx to the given address family
+// models.AddressFamilyAuto (or empty) leaves the choice to the dialer (happy eyeballs)
+func withAddressFamily(ctx context.Context, family string) context.Context {
+	return context.WithValue(ctx, addressFamilyKey{}, family)
+}
+
+// addressFamilyFromContext returns the address family requested for ctx, or "" if unrestricted
+func addressFamilyFromContext(ctx context.Context) string {
+	family, _ := ctx.Value(addressFamilyKey{}).(string)
+	return family
+}
+
+// networkForFamily narrows a dial network ("tcp") to a single address family ("tcp4"/"tcp6")
+func networkForFamily(network, family string) string {
+	if network != "tcp" {
+		return network
+	}
+
+	switch family {
+	case models.AddressFamilyIPv4:
+		return "tcp4"
+	case models.AddressFamilyIPv6:
+		return "tcp6"
+	default:
+		return network
+	}
+}
+
+// familyOfAddr returns the address family of a connection's remote address, or "" if unknown
+func familyOfAddr(addr net.Addr) string {
+	tcpAddr, ok := addr.(*net.TCPAddr)
+	if !ok {
+		return ""
+	}
+	if tcpAddr.IP.To4() != nil {
+		return models.AddressFamilyIPv4
+	}
+	return models.AddressFamilyIPv6
+}
+
+// resolvedFamily returns the family to record for a check: the one actually connected over,
+// falling back to the requested family when no connection was made
+func resolvedFamily(connFamily, requested string) *string {
+	if connFamily != "" {
+		return &connFamily
+	}
+	if requested == models.AddressFamilyIPv4 || requested == models.AddressFamilyIPv6 {
+		return &requested
+	}
+	return nil
+}
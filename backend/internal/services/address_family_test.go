@@ -0,0 +1,122 @@
+package services
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nimbus/backend/internal/models"
+	"github.com/nimbus/backend/internal/repository"
+)
+
+func TestNetworkForFamily(t *testing.T) {
+	tests := []struct {
+		network  string
+		family   string
+		expected string
+	}{
+		{"tcp", "", "tcp"},
+		{"tcp", models.AddressFamilyAuto, "tcp"},
+		{"tcp", models.AddressFamilyIPv4, "tcp4"},
+		{"tcp", models.AddressFamilyIPv6, "tcp6"},
+		{"udp", models.AddressFamilyIPv4, "udp"},
+	}
+
+	for _, tt := range tests {
+		if result := networkForFamily(tt.network, tt.family); result != tt.expected {
+			t.Errorf("networkForFamily(%q, %q) = %q, expected %q", tt.network, tt.family, result, tt.expected)
+		}
+	}
+}
+
+func TestFamilyOfAddr(t *testing.T) {
+	if family := familyOfAddr(&net.TCPAddr{IP: net.ParseIP("192.168.1.10")}); family != models.AddressFamilyIPv4 {
+		t.Errorf("Expected ipv4, got %q", family)
+	}
+	if family := familyOfAddr(&net.TCPAddr{IP: net.ParseIP("2001:db8::1")}); family != models.AddressFamilyIPv6 {
+		t.Errorf("Expected ipv6, got %q", family)
+	}
+	if family := familyOfAddr(&net.UnixAddr{Name: "/tmp/sock"}); family != "" {
+		t.Errorf("Expected empty family for unix socket, got %q", family)
+	}
+}
+
+func TestHealthCheckService_CheckService_AddressFamily(t *testing.T) {
+	// httptest listens on 127.0.0.1 only, so IPv6-pinned checks must fail
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer testServer.Close()
+
+	tests := []struct {
+		name             string
+		family           string
+		expectedStatus   string
+		expectedFamilies []string
+	}{
+		{"Auto records the family used", models.AddressFamilyAuto, models.StatusOnline, []string{"ipv4"}},
+		{"Pinned to IPv4", models.AddressFamilyIPv4, models.StatusOnline, []string{"ipv4"}},
+		{"Pinned to IPv6 fails", models.AddressFamilyIPv6, models.StatusOffline, []string{"ipv6"}},
+		{"Both records each family", models.AddressFamilyBoth, models.StatusOffline, []string{"ipv4", "ipv6"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := setupFingerprintTestDB(t)
+			defer db.Close()
+
+			mockRepo := &MockServiceRepository{}
+			statusLogRepo := repository.NewStatusLogRepository(db)
+			healthService := NewHealthCheckService(mockRepo, statusLogRepo, nil, 5*time.Second, nil, nil)
+
+			service := &models.Service{
+				ID:          "test-service-id",
+				URL:         testServer.URL,
+				CheckConfig: models.CheckConfig{AddressFamily: tt.family},
+			}
+
+			if err := healthService.CheckService(context.Background(), service); err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+
+			if mockRepo.lastStatus != tt.expectedStatus {
+				t.Errorf("Expected service status %q, got %q", tt.expectedStatus, mockRepo.lastStatus)
+			}
+
+			logs, err := statusLogRepo.GetLatestByServiceID(context.Background(), service.ID, 10)
+			if err != nil {
+				t.Fatalf("Failed to get status logs: %v", err)
+			}
+			if len(logs) != len(tt.expectedFamilies) {
+				t.Fatalf("Expected %d status logs, got %d", len(tt.expectedFamilies), len(logs))
+			}
+
+			var families []string
+			for _, log := range logs {
+				if log.AddressFamily == nil {
+					t.Fatal("Expected address family to be recorded")
+				}
+				families = append(families, *log.AddressFamily)
+
+				// Each family's result must reflect its own connectivity
+				expected := models.StatusOnline
+				if *log.AddressFamily == models.AddressFamilyIPv6 {
+					expected = models.StatusOffline
+				}
+				if log.Status != expected {
+					t.Errorf("Expected %s result to be %q, got %q", *log.AddressFamily, expected, log.Status)
+				}
+			}
+
+			for _, expected := range tt.expectedFamilies {
+				if !strings.Contains(strings.Join(families, ","), expected) {
+					t.Errorf("Expected a %s status log, got %v", expected, families)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,687 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nimbus/backend/internal/models"
+	"github.com/nimbus/backend/internal/repository"
+)
+
+const (
+	// DefaultAlertEvaluationInterval is how often delays, reminders, escalations and quiet hours are checked
+	DefaultAlertEvaluationInterval = 30 * time.Second
+
+	alertTransitionQueueSize = 1000
+
+	maxAlertDelayMinutes  = 1440
+	minAlertRepeatMinutes = 5
+	maxAlertRepeatMinutes = 1440
+	maxAlertRuleChannels  = 20
+
+	// alertStatusGrace is how long a state may disagree with its service's stored status before the
+	// evaluation trusts the status (covers a dropped recovery without racing the status writer)
+	alertStatusGrace = 2 * time.Minute
+)
+
+// ErrInvalidAlertRule is returned when an alert rule definition is invalid
+var ErrInvalidAlertRule = errors.New("invalid alert rule")
+
+type alertTransition struct {
+	service *models.Service
+	event   *models.StatusEvent
+}
+
+// AlertService applies alert rules to status transitions
+// Each rule keeps a state per service that is down, stored in the database so delays, reminders and
+// escalations carry on after a restart. Transitions are queued and handled by a single loop, which
+// also evaluates the pending states periodically
+type AlertService struct {
+	repo          *repository.AlertRuleRepository
+	serviceRepo   repository.ServiceRepositoryInterface
+	channelRepo   *repository.NotificationChannelRepository
+	notifications *NotificationService
+	incidents     *IncidentService // nil unless acknowledged incidents silence escalation
+	interval      time.Duration
+	now           func() time.Time
+
+	mu          sync.Mutex // Serializes state changes between the loop and rule updates
+	transitions chan alertTransition
+	stopChan    chan struct{}
+	wg          sync.WaitGroup
+	stopOnce    sync.Once
+}
+
+// NewAlertService creates an alert service that sends its alerts through notifications
+func NewAlertService(repo *repository.AlertRuleRepository, serviceRepo repository.ServiceRepositoryInterface, channelRepo *repository.NotificationChannelRepository, notifications *NotificationService, interval time.Duration) *AlertService {
+	if interval <= 0 {
+		interval = DefaultAlertEvaluationInterval
+	}
+
+	return &AlertService{
+		repo:          repo,
+		serviceRepo:   serviceRepo,
+		channelRepo:   channelRepo,
+		notifications: notifications,
+		interval:      interval,
+		now:           time.Now,
+		transitions:   make(chan alertTransition, alertTransitionQueueSize),
+		stopChan:      make(chan struct{}),
+	}
+}
+
+// SetIncidentService silences the escalation of outages whose incident was acknowledged
+func (s *AlertService) SetIncidentService(incidents *IncidentService) {
+	s.incidents = incidents
+}
+
+// Start begins handling transitions and evaluating pending alerts
+func (s *AlertService) Start() {
+	s.wg.Add(1)
+	go s.run()
+	fmt.Printf("Alert engine started (evaluation interval: %v)\n", s.interval)
+}
+
+// Stop handles the queued transitions and stops
+func (s *AlertService) Stop() {
+	s.stopOnce.Do(func() {
+		fmt.Println("Stopping alert engine...")
+		close(s.stopChan)
+		s.wg.Wait()
+		fmt.Println("Alert engine stopped")
+	})
+}
+
+// HandleTransition queues a status transition for the alert rules without blocking
+// Only services going offline and recovering from offline matter. Safe to call on a nil service
+func (s *AlertService) HandleTransition(service *models.Service, event *models.StatusEvent) {
+	if s == nil || event == nil {
+		return
+	}
+	if !isDownTransition(event) && !isRecoveryTransition(event) {
+		return
+	}
+
+	select {
+	case s.transitions <- alertTransition{service: service, event: event}:
+	default:
+		// The evaluation catches up with the stored status
+		fmt.Printf("Alert queue full, dropping %s -> %s transition for service %s\n", event.FromStatus, event.ToStatus, service.ID)
+	}
+}
+
+func isDownTransition(event *models.StatusEvent) bool {
+	return event.ToStatus == models.StatusOffline && event.FromStatus != models.StatusOffline
+}
+
+func isRecoveryTransition(event *models.StatusEvent) bool {
+	return event.FromStatus == models.StatusOffline && event.ToStatus == models.StatusOnline
+}
+
+func (s *AlertService) run() {
+	defer s.wg.Done()
+
+	// Send what came due while the server was down
+	s.evaluate()
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case t := <-s.transitions:
+			s.handleTransition(t)
+		case <-ticker.C:
+			s.evaluate()
+		case <-s.stopChan:
+			for {
+				select {
+				case t := <-s.transitions:
+					s.handleTransition(t)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+func (s *AlertService) handleTransition(t alertTransition) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	if isDownTransition(t.event) {
+		s.serviceDown(ctx, t.service, t.event)
+	} else {
+		s.serviceRecovered(ctx, t.service, t.event)
+	}
+}
+
+// serviceDown starts a state for every enabled rule covering the service
+func (s *AlertService) serviceDown(ctx context.Context, service *models.Service, event *models.StatusEvent) {
+	rules, err := s.repo.GetEnabledForService(ctx, service.UserID, service.ID)
+	if err != nil {
+		fmt.Printf("Failed to get alert rules for service %s: %v\n", service.ID, err)
+		return
+	}
+
+	for _, rule := range rules {
+		state, err := s.repo.GetState(ctx, rule.ID, service.ID)
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			state = &models.AlertState{RuleID: rule.ID, ServiceID: service.ID, DownSince: event.OccurredAt}
+		case err != nil:
+			fmt.Printf("Failed to get alert state of rule %s for service %s: %v\n", rule.ID, service.ID, err)
+			continue
+		case state.RecoveredAt != nil:
+			// Down again before the held recovery was sent: the outage goes on
+			state.RecoveredAt = nil
+		default:
+			continue
+		}
+		state.ErrorMessage = event.ErrorMessage
+
+		s.advance(ctx, rule, service, state, true)
+	}
+}
+
+// serviceRecovered ends the service's states, announcing the recovery where an alert was sent
+func (s *AlertService) serviceRecovered(ctx context.Context, service *models.Service, event *models.StatusEvent) {
+	states, err := s.repo.GetStatesByServiceID(ctx, service.ID)
+	if err != nil {
+		fmt.Printf("Failed to get alert states for service %s: %v\n", service.ID, err)
+		return
+	}
+
+	for _, state := range states {
+		rule, err := s.repo.GetByID(ctx, state.RuleID)
+		if err != nil {
+			fmt.Printf("Failed to get alert rule %s: %v\n", state.RuleID, err)
+			continue
+		}
+		recoveredAt := event.OccurredAt
+		state.RecoveredAt = &recoveredAt
+
+		s.advance(ctx, rule, service, state, true)
+	}
+}
+
+// evaluate advances every pending state: delayed alerts, reminders, escalations and held messages
+func (s *AlertService) evaluate() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.interval)
+	defer cancel()
+
+	states, err := s.repo.GetStates(ctx)
+	if err != nil {
+		fmt.Printf("Failed to get alert states: %v\n", err)
+		return
+	}
+
+	rules := make(map[string]*models.AlertRule)
+	services := make(map[string]*models.Service)
+	for _, state := range states {
+		rule, ok := rules[state.RuleID]
+		if !ok {
+			if rule, err = s.repo.GetByID(ctx, state.RuleID); err != nil && !errors.Is(err, sql.ErrNoRows) {
+				fmt.Printf("Failed to get alert rule %s: %v\n", state.RuleID, err)
+				continue
+			}
+			rules[state.RuleID] = rule
+		}
+
+		service, ok := services[state.ServiceID]
+		if !ok {
+			if service, err = s.serviceRepo.GetByID(ctx, state.ServiceID); err != nil {
+				fmt.Printf("Failed to get service %s: %v\n", state.ServiceID, err)
+				continue
+			}
+			services[state.ServiceID] = service
+		}
+
+		// The rule or service was deleted
+		if rule == nil || service == nil {
+			s.deleteState(ctx, state)
+			continue
+		}
+
+		// A recovery was missed (e.g. the queue was full)
+		missedRecovery := state.RecoveredAt == nil && service.Status == models.StatusOnline && s.now().Sub(state.DownSince) > alertStatusGrace
+		if missedRecovery {
+			recoveredAt := s.now()
+			state.RecoveredAt = &recoveredAt
+		}
+
+		s.advance(ctx, rule, service, state, missedRecovery)
+	}
+}
+
+// advance sends whatever the state has come due for and ends it or, if it changed, saves it
+func (s *AlertService) advance(ctx context.Context, rule *models.AlertRule, service *models.Service, state *models.AlertState, changed bool) {
+	now := s.now()
+	quiet := inQuietHours(rule, now)
+
+	if state.RecoveredAt != nil {
+		// Nothing was alerted, so there is nothing to take back
+		if state.AlertedAt == nil || !rule.NotifyRecovery {
+			s.deleteState(ctx, state)
+			return
+		}
+		if quiet {
+			if changed {
+				s.saveState(ctx, state)
+			}
+			return
+		}
+		s.notify(rule, state, alertRecipients(rule, state), s.recoveryNotification(service, state))
+		s.deleteState(ctx, state)
+		return
+	}
+
+	if quiet {
+		if changed {
+			s.saveState(ctx, state)
+		}
+		return
+	}
+
+	downFor := now.Sub(state.DownSince)
+	switch {
+	case state.AlertedAt == nil:
+		if downFor >= time.Duration(rule.DelayMinutes)*time.Minute {
+			s.notify(rule, state, rule.ChannelIDs, s.outageNotification(models.NotificationEventDown, service, state, downFor))
+			state.AlertedAt = &now
+			state.LastNotifiedAt = &now
+			changed = true
+		}
+	case rule.RepeatMinutes > 0 && state.LastNotifiedAt != nil && now.Sub(*state.LastNotifiedAt) >= time.Duration(rule.RepeatMinutes)*time.Minute:
+		s.notify(rule, state, alertRecipients(rule, state), s.outageNotification(models.NotificationEventReminder, service, state, downFor))
+		state.LastNotifiedAt = &now
+		changed = true
+	}
+
+	// Acknowledged outages have someone on them and are not escalated
+	if state.AlertedAt != nil && state.EscalatedAt == nil && rule.EscalationMinutes > 0 && len(rule.EscalationChannelIDs) > 0 &&
+		downFor >= time.Duration(rule.EscalationMinutes)*time.Minute && !s.incidents.Acknowledged(ctx, service.ID) {
+		s.notify(rule, state, rule.EscalationChannelIDs, s.outageNotification(models.NotificationEventEscalation, service, state, downFor))
+		state.EscalatedAt = &now
+		changed = true
+	}
+
+	if changed {
+		s.saveState(ctx, state)
+	}
+}
+
+// alertRecipients returns the rule's channels, plus its escalation channels once the outage escalated
+func alertRecipients(rule *models.AlertRule, state *models.AlertState) []string {
+	if state.EscalatedAt == nil {
+		return rule.ChannelIDs
+	}
+
+	recipients := append([]string{}, rule.ChannelIDs...)
+	for _, id := range rule.EscalationChannelIDs {
+		if !slices.Contains(recipients, id) {
+			recipients = append(recipients, id)
+		}
+	}
+	return recipients
+}
+
+func (s *AlertService) notify(rule *models.AlertRule, state *models.AlertState, channelIDs []string, n *Notification) {
+	state.NotificationCount++
+	if !s.notifications.NotifyChannels(channelIDs, n) {
+		fmt.Printf("Alert rule %s could not queue its %s notification for service %s\n", rule.ID, n.Event, state.ServiceID)
+	}
+}
+
+func (s *AlertService) outageNotification(event string, service *models.Service, state *models.AlertState, downFor time.Duration) *Notification {
+	seconds := downFor.Round(time.Second).Seconds()
+	return &Notification{
+		Event:           event,
+		ServiceID:       service.ID,
+		ServiceName:     service.Name,
+		ServiceURL:      service.URL,
+		Status:          models.StatusOffline,
+		PreviousStatus:  models.StatusOnline,
+		ErrorMessage:    state.ErrorMessage,
+		DurationSeconds: &seconds,
+		OccurredAt:      state.DownSince,
+	}
+}
+
+func (s *AlertService) recoveryNotification(service *models.Service, state *models.AlertState) *Notification {
+	seconds := state.RecoveredAt.Sub(state.DownSince).Round(time.Second).Seconds()
+	return &Notification{
+		Event:           models.NotificationEventUp,
+		ServiceID:       service.ID,
+		ServiceName:     service.Name,
+		ServiceURL:      service.URL,
+		Status:          models.StatusOnline,
+		PreviousStatus:  models.StatusOffline,
+		DurationSeconds: &seconds,
+		OccurredAt:      *state.RecoveredAt,
+	}
+}
+
+func (s *AlertService) saveState(ctx context.Context, state *models.AlertState) {
+	if err := s.repo.SaveState(ctx, state); err != nil {
+		fmt.Printf("Failed to save alert state of rule %s for service %s: %v\n", state.RuleID, state.ServiceID, err)
+	}
+}
+
+func (s *AlertService) deleteState(ctx context.Context, state *models.AlertState) {
+	if err := s.repo.DeleteState(ctx, state.RuleID, state.ServiceID); err != nil {
+		fmt.Printf("Failed to delete alert state of rule %s for service %s: %v\n", state.RuleID, state.ServiceID, err)
+	}
+}
+
+// inQuietHours reports whether t falls in the rule's daily quiet period (which may span midnight)
+func inQuietHours(rule *models.AlertRule, t time.Time) bool {
+	start, okStart := parseClock(rule.QuietHoursStart)
+	end, okEnd := parseClock(rule.QuietHoursEnd)
+	if !okStart || !okEnd {
+		return false
+	}
+
+	location, err := time.LoadLocation(rule.Timezone)
+	if err != nil {
+		location = time.UTC
+	}
+	local := t.In(location)
+	minute := local.Hour()*60 + local.Minute()
+
+	if start < end {
+		return minute >= start && minute < end
+	}
+	return minute >= start || minute < end
+}
+
+// parseClock parses "HH:MM" into minutes after midnight
+func parseClock(value string) (int, bool) {
+	t, err := time.Parse("15:04", value)
+	if err != nil || len(value) != 5 {
+		return 0, false
+	}
+	return t.Hour()*60 + t.Minute(), true
+}
+
+// List returns the user's alert rules
+func (s *AlertService) List(ctx context.Context, userID string) ([]*models.AlertRule, error) {
+	rules, err := s.repo.GetByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if rules == nil {
+		rules = []*models.AlertRule{}
+	}
+	return rules, nil
+}
+
+// Get returns one of the user's alert rules
+// Returns sql.ErrNoRows if it doesn't exist or belongs to another user
+func (s *AlertService) Get(ctx context.Context, userID, id string) (*models.AlertRule, error) {
+	rule, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if rule.UserID != userID {
+		return nil, sql.ErrNoRows
+	}
+	return rule, nil
+}
+
+// Create validates and stores a new alert rule
+// Services that are already down are picked up right away, their delay counting from now
+func (s *AlertService) Create(ctx context.Context, userID string, req *models.AlertRuleRequest) (*models.AlertRule, error) {
+	rule, err := s.ruleFromRequest(ctx, userID, req)
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.now()
+	rule.CreatedAt = now
+	rule.UpdatedAt = now
+	if err := s.repo.Create(ctx, rule); err != nil {
+		return nil, err
+	}
+
+	s.seedStates(ctx, rule)
+	return rule, nil
+}
+
+// Update replaces an alert rule's settings
+// Pending alerts are dropped when the rule is disabled or applies to other services
+// Returns sql.ErrNoRows if it doesn't exist or belongs to another user
+func (s *AlertService) Update(ctx context.Context, userID, id string, req *models.AlertRuleRequest) (*models.AlertRule, error) {
+	existing, err := s.Get(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	rule, err := s.ruleFromRequest(ctx, userID, req)
+	if err != nil {
+		return nil, err
+	}
+	rule.ID = existing.ID
+	rule.CreatedAt = existing.CreatedAt
+	rule.UpdatedAt = s.now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.repo.Update(ctx, rule); err != nil {
+		return nil, err
+	}
+
+	scopeChanged := (existing.ServiceID == nil) != (rule.ServiceID == nil) ||
+		(existing.ServiceID != nil && *existing.ServiceID != *rule.ServiceID)
+	if !rule.Enabled || scopeChanged {
+		if err := s.repo.DeleteStatesByRuleID(ctx, rule.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	s.seedStatesLocked(ctx, rule)
+	return rule, nil
+}
+
+// Delete removes an alert rule and its pending alerts
+// Returns sql.ErrNoRows if it doesn't exist or belongs to another user
+func (s *AlertService) Delete(ctx context.Context, userID, id string) error {
+	if _, err := s.Get(ctx, userID, id); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.repo.Delete(ctx, id)
+}
+
+// Active returns the pending alerts of the user's rules
+func (s *AlertService) Active(ctx context.Context, userID string) ([]models.ActiveAlert, error) {
+	states, err := s.repo.GetStatesByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	rules, err := s.repo.GetByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	ruleNames := make(map[string]string, len(rules))
+	for _, rule := range rules {
+		ruleNames[rule.ID] = rule.Name
+	}
+
+	services, err := s.serviceRepo.GetAllByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	serviceNames := make(map[string]string, len(services))
+	for _, service := range services {
+		serviceNames[service.ID] = service.Name
+	}
+
+	active := make([]models.ActiveAlert, 0, len(states))
+	for _, state := range states {
+		active = append(active, models.ActiveAlert{
+			AlertState:  *state,
+			RuleName:    ruleNames[state.RuleID],
+			ServiceName: serviceNames[state.ServiceID],
+		})
+	}
+	return active, nil
+}
+
+func (s *AlertService) seedStates(ctx context.Context, rule *models.AlertRule) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.seedStatesLocked(ctx, rule)
+}
+
+// seedStatesLocked starts states for the rule's services that are already down
+func (s *AlertService) seedStatesLocked(ctx context.Context, rule *models.AlertRule) {
+	if !rule.Enabled {
+		return
+	}
+
+	services, err := s.serviceRepo.GetAllByUserID(ctx, rule.UserID)
+	if err != nil {
+		fmt.Printf("Failed to get services for alert rule %s: %v\n", rule.ID, err)
+		return
+	}
+
+	for _, service := range services {
+		if service.Status != models.StatusOffline || !rule.AppliesTo(service) {
+			continue
+		}
+		if _, err := s.repo.GetState(ctx, rule.ID, service.ID); !errors.Is(err, sql.ErrNoRows) {
+			continue
+		}
+		s.saveState(ctx, &models.AlertState{RuleID: rule.ID, ServiceID: service.ID, DownSince: s.now()})
+	}
+}
+
+// ruleFromRequest validates a request and builds the rule it describes
+func (s *AlertService) ruleFromRequest(ctx context.Context, userID string, req *models.AlertRuleRequest) (*models.AlertRule, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" || len(name) > 255 {
+		return nil, fmt.Errorf("%w: name is required (at most 255 characters)", ErrInvalidAlertRule)
+	}
+
+	if req.DelayMinutes < 0 || req.DelayMinutes > maxAlertDelayMinutes {
+		return nil, fmt.Errorf("%w: delay_minutes must be between 0 and %d", ErrInvalidAlertRule, maxAlertDelayMinutes)
+	}
+	if req.RepeatMinutes != 0 && (req.RepeatMinutes < minAlertRepeatMinutes || req.RepeatMinutes > maxAlertRepeatMinutes) {
+		return nil, fmt.Errorf("%w: repeat_minutes must be 0 or between %d and %d", ErrInvalidAlertRule, minAlertRepeatMinutes, maxAlertRepeatMinutes)
+	}
+	if req.EscalationMinutes < 0 || (req.EscalationMinutes != 0 && req.EscalationMinutes <= req.DelayMinutes) {
+		return nil, fmt.Errorf("%w: escalation_minutes must be 0 or more than delay_minutes", ErrInvalidAlertRule)
+	}
+
+	if (req.QuietHoursStart == "") != (req.QuietHoursEnd == "") {
+		return nil, fmt.Errorf("%w: quiet_hours_start and quiet_hours_end must be set together", ErrInvalidAlertRule)
+	}
+	if req.QuietHoursStart != "" {
+		start, okStart := parseClock(req.QuietHoursStart)
+		end, okEnd := parseClock(req.QuietHoursEnd)
+		if !okStart || !okEnd || start == end {
+			return nil, fmt.Errorf("%w: quiet hours must be two different HH:MM times", ErrInvalidAlertRule)
+		}
+	}
+
+	timezone := strings.TrimSpace(req.Timezone)
+	if timezone == "" {
+		timezone = "UTC"
+	}
+	if _, err := time.LoadLocation(timezone); err != nil || len(timezone) > 64 {
+		return nil, fmt.Errorf("%w: unknown timezone %q", ErrInvalidAlertRule, timezone)
+	}
+
+	if req.ServiceID != nil {
+		service, err := s.serviceRepo.GetByID(ctx, *req.ServiceID)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return nil, err
+		}
+		if err != nil || service.UserID != userID {
+			return nil, fmt.Errorf("%w: unknown service %q", ErrInvalidAlertRule, *req.ServiceID)
+		}
+	}
+
+	channelIDs, err := s.checkChannels(ctx, userID, req.ChannelIDs)
+	if err != nil {
+		return nil, err
+	}
+	if len(channelIDs) == 0 {
+		return nil, fmt.Errorf("%w: at least one channel is required", ErrInvalidAlertRule)
+	}
+	escalationChannelIDs, err := s.checkChannels(ctx, userID, req.EscalationChannelIDs)
+	if err != nil {
+		return nil, err
+	}
+	if req.EscalationMinutes > 0 && len(escalationChannelIDs) == 0 {
+		return nil, fmt.Errorf("%w: escalation needs at least one escalation channel", ErrInvalidAlertRule)
+	}
+
+	enabled := true
+	if req.Enabled != nil {
+		enabled = *req.Enabled
+	}
+	notifyRecovery := true
+	if req.NotifyRecovery != nil {
+		notifyRecovery = *req.NotifyRecovery
+	}
+
+	return &models.AlertRule{
+		UserID:               userID,
+		ServiceID:            req.ServiceID,
+		Name:                 name,
+		Enabled:              enabled,
+		DelayMinutes:         req.DelayMinutes,
+		RepeatMinutes:        req.RepeatMinutes,
+		EscalationMinutes:    req.EscalationMinutes,
+		NotifyRecovery:       notifyRecovery,
+		QuietHoursStart:      req.QuietHoursStart,
+		QuietHoursEnd:        req.QuietHoursEnd,
+		Timezone:             timezone,
+		ChannelIDs:           channelIDs,
+		EscalationChannelIDs: escalationChannelIDs,
+	}, nil
+}
+
+// checkChannels deduplicates channel IDs and checks they are the user's own or global channels
+func (s *AlertService) checkChannels(ctx context.Context, userID string, ids []string) ([]string, error) {
+	selected := []string{}
+	for _, id := range ids {
+		if id == "" || slices.Contains(selected, id) {
+			continue
+		}
+		selected = append(selected, id)
+	}
+	if len(selected) > maxAlertRuleChannels {
+		return nil, fmt.Errorf("%w: a rule can notify at most %d channels", ErrInvalidAlertRule, maxAlertRuleChannels)
+	}
+
+	for _, id := range selected {
+		channel, err := s.channelRepo.GetByID(ctx, id)
+		if errors.Is(err, sql.ErrNoRows) || (err == nil && !channel.IsGlobal() && *channel.UserID != userID) {
+			return nil, fmt.Errorf("%w: unknown channel %q", ErrInvalidAlertRule, id)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return selected, nil
+}
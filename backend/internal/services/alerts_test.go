@@ -0,0 +1,344 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/nimbus/backend/internal/models"
+	"github.com/nimbus/backend/internal/repository"
+)
+
+// setupAlertTestDB adds the alert rule tables to the notification test schema
+// user-1 has test-service-1 (online) and test-service-2 (offline)
+func setupAlertTestDB(t *testing.T) *sql.DB {
+	db := setupNotificationTestDB(t)
+
+	_, err := db.Exec(`
+		CREATE TABLE alert_rules (
+			id TEXT PRIMARY KEY DEFAULT (lower(hex(randomblob(16)))),
+			user_id TEXT NOT NULL,
+			service_id TEXT,
+			name TEXT NOT NULL,
+			enabled BOOLEAN NOT NULL DEFAULT 1,
+			delay_minutes INTEGER NOT NULL DEFAULT 0,
+			repeat_minutes INTEGER NOT NULL DEFAULT 0,
+			escalation_minutes INTEGER NOT NULL DEFAULT 0,
+			notify_recovery BOOLEAN NOT NULL DEFAULT 1,
+			quiet_hours_start TEXT,
+			quiet_hours_end TEXT,
+			timezone TEXT NOT NULL DEFAULT 'UTC',
+			created_at TIMESTAMP NOT NULL,
+			updated_at TIMESTAMP NOT NULL
+		);
+
+		CREATE TABLE alert_rule_channels (
+			rule_id TEXT NOT NULL,
+			channel_id TEXT NOT NULL,
+			escalation BOOLEAN NOT NULL DEFAULT 0,
+			PRIMARY KEY (rule_id, channel_id, escalation)
+		);
+
+		CREATE TABLE alert_states (
+			rule_id TEXT NOT NULL,
+			service_id TEXT NOT NULL,
+			down_since TIMESTAMP NOT NULL,
+			error_message TEXT,
+			alerted_at TIMESTAMP,
+			last_notified_at TIMESTAMP,
+			notification_count INTEGER NOT NULL DEFAULT 0,
+			escalated_at TIMESTAMP,
+			recovered_at TIMESTAMP,
+			PRIMARY KEY (rule_id, service_id)
+		);
+
+		INSERT INTO services (id, user_id, name, url, description, status, position)
+		VALUES ('other-service', 'user-2', 'Other', 'http://other.lan', '', 'online', 0);
+	`)
+	if err != nil {
+		t.Fatalf("Failed to create alert tables: %v", err)
+	}
+
+	return db
+}
+
+// alertClock is a settable clock for the alert service
+type alertClock struct{ now time.Time }
+
+func (c *alertClock) Now() time.Time { return c.now }
+
+func (c *alertClock) Advance(d time.Duration) { c.now = c.now.Add(d) }
+
+// newTestAlertService returns an alert service on a fake clock with two webhook channels of user-1 ("1" and "2")
+// Its notifications are queued but not sent
+func newTestAlertService(t *testing.T, db *sql.DB, start time.Time) (*AlertService, *alertClock) {
+	t.Helper()
+	notifications := newTestNotificationService(db)
+	for _, name := range []string{"Primary", "On-call"} {
+		if _, err := notifications.Create(context.Background(), "user-1", false, webhookRequest(name, "http://example.com/"+name)); err != nil {
+			t.Fatalf("Create() error = %v", err)
+		}
+	}
+
+	clock := &alertClock{now: start}
+	s := NewAlertService(repository.NewAlertRuleRepository(db), repository.NewServiceRepository(db), repository.NewNotificationChannelRepository(db), notifications, time.Minute)
+	s.now = clock.Now
+	return s, clock
+}
+
+// queuedAlerts takes the notifications queued since the last call
+func queuedAlerts(s *AlertService) []*Notification {
+	var queued []*Notification
+	for {
+		select {
+		case n := <-s.notifications.queue:
+			queued = append(queued, n)
+		default:
+			return queued
+		}
+	}
+}
+
+// expectAlert checks that exactly one notification of the event was queued for the channels
+func expectAlert(t *testing.T, s *AlertService, event string, channelIDs ...string) *Notification {
+	t.Helper()
+	queued := queuedAlerts(s)
+	if len(queued) != 1 {
+		t.Fatalf("Expected one %s notification, got %d", event, len(queued))
+	}
+	n := queued[0]
+	if n.Event != event || len(n.channelIDs) != len(channelIDs) {
+		t.Fatalf("Expected %s to %v, got %s to %v", event, channelIDs, n.Event, n.channelIDs)
+	}
+	for i, id := range channelIDs {
+		if n.channelIDs[i] != id {
+			t.Fatalf("Expected %s to %v, got %s to %v", event, channelIDs, n.Event, n.channelIDs)
+		}
+	}
+	return n
+}
+
+func expectNoAlerts(t *testing.T, s *AlertService) {
+	t.Helper()
+	if queued := queuedAlerts(s); len(queued) != 0 {
+		t.Fatalf("Expected no notifications, got %s", queued[0].Event)
+	}
+}
+
+// transition hands the engine a status change, storing the new status like the status writer
+func transition(t *testing.T, s *AlertService, serviceID, from, to string, at time.Time) {
+	t.Helper()
+	if err := s.serviceRepo.UpdateStatus(context.Background(), serviceID, to); err != nil {
+		t.Fatalf("UpdateStatus() error = %v", err)
+	}
+	errorMessage := "HTTP 503"
+	event := &models.StatusEvent{ServiceID: serviceID, FromStatus: from, ToStatus: to, OccurredAt: at}
+	if to == models.StatusOffline {
+		event.ErrorMessage = &errorMessage
+	}
+	s.handleTransition(alertTransition{service: &models.Service{ID: serviceID, UserID: "user-1", Name: "Test Service", URL: "http://example.com"}, event: event})
+}
+
+func TestAlertService_Escalation(t *testing.T) {
+	db := setupAlertTestDB(t)
+	start := time.Date(2026, 3, 2, 12, 0, 0, 0, time.UTC)
+	s, clock := newTestAlertService(t, db, start)
+	ctx := context.Background()
+
+	serviceID := "test-service-1"
+	_, err := s.Create(ctx, "user-1", &models.AlertRuleRequest{
+		Name:                 "Plex",
+		ServiceID:            &serviceID,
+		DelayMinutes:         5,
+		RepeatMinutes:        10,
+		EscalationMinutes:    20,
+		ChannelIDs:           []string{"1"},
+		EscalationChannelIDs: []string{"2"},
+	})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	// Nothing is sent before the delay
+	transition(t, s, serviceID, models.StatusOnline, models.StatusOffline, start)
+	expectNoAlerts(t, s)
+	clock.Advance(4 * time.Minute)
+	s.evaluate()
+	expectNoAlerts(t, s)
+
+	clock.Advance(time.Minute)
+	s.evaluate()
+	down := expectAlert(t, s, models.NotificationEventDown, "1")
+	if down.ErrorMessage == nil || *down.ErrorMessage != "HTTP 503" || !down.OccurredAt.Equal(start) {
+		t.Errorf("Unexpected down notification %+v", down)
+	}
+
+	// State survives a restart: a new engine picks up the reminder
+	s2, clock2 := newTestAlertService(t, db, start.Add(15*time.Minute))
+	s2.notifications = s.notifications
+	s2.evaluate()
+	reminder := expectAlert(t, s2, models.NotificationEventReminder, "1")
+	if *reminder.DurationSeconds != 900 {
+		t.Errorf("Expected 15 minutes down, got %v", *reminder.DurationSeconds)
+	}
+	s.evaluate() // Same minute: nothing new
+	expectNoAlerts(t, s)
+
+	clock2.Advance(5 * time.Minute)
+	s2.evaluate()
+	expectAlert(t, s2, models.NotificationEventEscalation, "2")
+
+	// Reminders and the recovery go to the escalation channels too
+	clock2.Advance(5 * time.Minute)
+	s2.evaluate()
+	expectAlert(t, s2, models.NotificationEventReminder, "1", "2")
+
+	transition(t, s2, serviceID, models.StatusOffline, models.StatusOnline, clock2.now)
+	up := expectAlert(t, s2, models.NotificationEventUp, "1", "2")
+	if *up.DurationSeconds != 1500 {
+		t.Errorf("Expected 25 minutes of downtime, got %v", *up.DurationSeconds)
+	}
+
+	if active, _ := s.Active(ctx, "user-1"); len(active) != 0 {
+		t.Errorf("Expected the outage to be closed, got %+v", active)
+	}
+}
+
+func TestAlertService_QuietHours(t *testing.T) {
+	db := setupAlertTestDB(t)
+	evening := time.Date(2026, 3, 2, 21, 0, 0, 0, time.UTC)
+	s, clock := newTestAlertService(t, db, evening)
+	ctx := context.Background()
+
+	_, err := s.Create(ctx, "user-1", &models.AlertRuleRequest{
+		Name:            "All services",
+		QuietHoursStart: "22:00",
+		QuietHoursEnd:   "07:00",
+		ChannelIDs:      []string{"1"},
+	})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	// test-service-2 was already down and is alerted right away
+	s.evaluate()
+	expectAlert(t, s, models.NotificationEventDown, "1")
+
+	// An outage during quiet hours that ends before they do is never mentioned
+	clock.now = evening.Add(2 * time.Hour)
+	transition(t, s, "test-service-1", models.StatusOnline, models.StatusOffline, clock.now)
+	clock.Advance(10 * time.Minute)
+	transition(t, s, "test-service-1", models.StatusOffline, models.StatusOnline, clock.now)
+	expectNoAlerts(t, s)
+
+	// The recovery of an alerted outage is held until the morning
+	transition(t, s, "test-service-2", models.StatusOffline, models.StatusOnline, clock.now)
+	s.evaluate()
+	expectNoAlerts(t, s)
+	active, err := s.Active(ctx, "user-1")
+	if err != nil || len(active) != 1 || active[0].RecoveredAt == nil || active[0].ServiceName != "Test Service 2" || active[0].RuleName != "All services" {
+		t.Fatalf("Expected the held recovery to be active, got %+v, %v", active, err)
+	}
+
+	clock.now = time.Date(2026, 3, 3, 7, 0, 0, 0, time.UTC)
+	s.evaluate()
+	expectAlert(t, s, models.NotificationEventUp, "1")
+	if active, _ := s.Active(ctx, "user-1"); len(active) != 0 {
+		t.Errorf("Expected no active alerts, got %+v", active)
+	}
+}
+
+func TestAlertService_Rules(t *testing.T) {
+	db := setupAlertTestDB(t)
+	s, _ := newTestAlertService(t, db, time.Date(2026, 3, 2, 12, 0, 0, 0, time.UTC))
+	ctx := context.Background()
+	if _, err := s.notifications.Create(ctx, "user-2", false, webhookRequest("Foreign", "http://example.com")); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	other, missing := "other-service", "missing-service"
+
+	invalid := []models.AlertRuleRequest{
+		{Name: "", ChannelIDs: []string{"1"}},
+		{Name: "No channels"},
+		{Name: "Foreign channel", ChannelIDs: []string{"3"}},
+		{Name: "Foreign service", ServiceID: &other, ChannelIDs: []string{"1"}},
+		{Name: "Missing service", ServiceID: &missing, ChannelIDs: []string{"1"}},
+		{Name: "Long delay", DelayMinutes: 2000, ChannelIDs: []string{"1"}},
+		{Name: "Spam", RepeatMinutes: 1, ChannelIDs: []string{"1"}},
+		{Name: "Early escalation", DelayMinutes: 10, EscalationMinutes: 10, ChannelIDs: []string{"1"}, EscalationChannelIDs: []string{"2"}},
+		{Name: "Escalation nowhere", EscalationMinutes: 10, ChannelIDs: []string{"1"}},
+		{Name: "Half quiet", QuietHoursStart: "22:00", ChannelIDs: []string{"1"}},
+		{Name: "Bad quiet", QuietHoursStart: "25:00", QuietHoursEnd: "07:00", ChannelIDs: []string{"1"}},
+		{Name: "Bad timezone", Timezone: "Mars/Olympus", ChannelIDs: []string{"1"}},
+	}
+	for _, req := range invalid {
+		if _, err := s.Create(ctx, "user-1", &req); !errors.Is(err, ErrInvalidAlertRule) {
+			t.Errorf("Create(%q) = %v, expected ErrInvalidAlertRule", req.Name, err)
+		}
+	}
+
+	rule, err := s.Create(ctx, "user-1", &models.AlertRuleRequest{Name: " NAS ", DelayMinutes: 5, ChannelIDs: []string{"1", "1"}})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if rule.Name != "NAS" || !rule.Enabled || !rule.NotifyRecovery || rule.Timezone != "UTC" || len(rule.ChannelIDs) != 1 {
+		t.Errorf("Unexpected defaults %+v", rule)
+	}
+
+	// The already offline service is tracked from the rule's creation
+	active, err := s.Active(ctx, "user-1")
+	if err != nil || len(active) != 1 || active[0].ServiceID != "test-service-2" {
+		t.Fatalf("Expected test-service-2 to be tracked, got %+v, %v", active, err)
+	}
+
+	if _, err := s.Get(ctx, "user-2", rule.ID); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("Expected other users not to see the rule, got %v", err)
+	}
+
+	// Disabling the rule drops its pending alerts
+	disabled := false
+	updated, err := s.Update(ctx, "user-1", rule.ID, &models.AlertRuleRequest{Name: "NAS", Enabled: &disabled, ChannelIDs: []string{"2"}})
+	if err != nil || updated.Enabled || updated.ChannelIDs[0] != "2" {
+		t.Fatalf("Update() = %+v, %v", updated, err)
+	}
+	if active, _ := s.Active(ctx, "user-1"); len(active) != 0 {
+		t.Errorf("Expected no active alerts for a disabled rule, got %+v", active)
+	}
+
+	rules, err := s.List(ctx, "user-1")
+	if err != nil || len(rules) != 1 {
+		t.Fatalf("List() = %d, %v", len(rules), err)
+	}
+	if err := s.Delete(ctx, "user-2", rule.ID); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("Expected sql.ErrNoRows deleting another user's rule, got %v", err)
+	}
+	if err := s.Delete(ctx, "user-1", rule.ID); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+}
+
+func TestInQuietHours(t *testing.T) {
+	overnight := &models.AlertRule{QuietHoursStart: "22:00", QuietHoursEnd: "07:00", Timezone: "Asia/Tokyo"}
+	daytime := &models.AlertRule{QuietHoursStart: "09:00", QuietHoursEnd: "17:30", Timezone: "UTC"}
+
+	tests := []struct {
+		rule  *models.AlertRule
+		at    time.Time
+		quiet bool
+	}{
+		{overnight, time.Date(2026, 3, 2, 13, 0, 0, 0, time.UTC), true},   // 22:00 in Tokyo
+		{overnight, time.Date(2026, 3, 2, 21, 59, 0, 0, time.UTC), true},  // 06:59 in Tokyo
+		{overnight, time.Date(2026, 3, 2, 22, 0, 0, 0, time.UTC), false},  // 07:00 in Tokyo
+		{overnight, time.Date(2026, 3, 2, 12, 59, 0, 0, time.UTC), false}, // 21:59 in Tokyo
+		{daytime, time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC), true},
+		{daytime, time.Date(2026, 3, 2, 17, 30, 0, 0, time.UTC), false},
+		{&models.AlertRule{Timezone: "UTC"}, time.Date(2026, 3, 2, 3, 0, 0, 0, time.UTC), false},
+	}
+
+	for _, tt := range tests {
+		if got := inQuietHours(tt.rule, tt.at); got != tt.quiet {
+			t.Errorf("inQuietHours(%s-%s, %v) = %v, want %v", tt.rule.QuietHoursStart, tt.rule.QuietHoursEnd, tt.at, got, tt.quiet)
+		}
+	}
+}
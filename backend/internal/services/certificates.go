@@ -0,0 +1,84 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/nimbus/backend/internal/models"
+	"github.com/nimbus/backend/internal/repository"
+)
+
+// certificateTracker stores each service's leaf TLS certificate when it changes
+// It remembers the last certificate saved per service so unchanged ones cost no query
+// (after a restart each service's certificate is saved once more)
+type certificateTracker struct {
+	repo *repository.CertificateRepository
+
+	mu   sync.Mutex
+	seen map[string][sha256.Size]byte
+}
+
+// SetCertificateRepository records the TLS certificate of HTTPS services (used for expiry reports)
+func (h *HealthCheckService) SetCertificateRepository(repo *repository.CertificateRepository) {
+	h.certificates = &certificateTracker{repo: repo, seen: make(map[string][sha256.Size]byte)}
+}
+
+// record saves the service's leaf certificate if it differs from the last one saved
+// Errors are logged but never fail the health check
+func (t *certificateTracker) record(serviceID string, state *tls.ConnectionState) {
+	if t == nil || state == nil || len(state.PeerCertificates) == 0 {
+		return
+	}
+	leaf := state.PeerCertificates[0]
+	hash := sha256.Sum256(leaf.Raw)
+
+	t.mu.Lock()
+	if t.seen[serviceID] == hash {
+		t.mu.Unlock()
+		return
+	}
+	t.mu.Unlock()
+
+	// Use an independent context so the certificate is saved even if the check is cancelled
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	err := t.repo.Save(ctx, &models.ServiceCertificate{
+		ServiceID: serviceID,
+		Subject:   certificateSubject(leaf),
+		Issuer:    truncateRunes(leaf.Issuer.CommonName, 255),
+		ExpiresAt: leaf.NotAfter,
+		CheckedAt: time.Now(),
+	})
+	if err != nil {
+		fmt.Printf("Failed to record certificate for service %s: %v\n", serviceID, err)
+		return
+	}
+
+	t.mu.Lock()
+	t.seen[serviceID] = hash
+	t.mu.Unlock()
+}
+
+// certificateSubject names a certificate by its common name, or its first DNS name without one
+func certificateSubject(cert *x509.Certificate) string {
+	subject := cert.Subject.CommonName
+	if subject == "" && len(cert.DNSNames) > 0 {
+		subject = cert.DNSNames[0]
+	}
+	return truncateRunes(subject, 255)
+}
+
+// truncateRunes shortens s to at most n runes
+func truncateRunes(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n])
+}
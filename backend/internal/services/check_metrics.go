@@ -0,0 +1,285 @@
+package services
+
+import (
+	"crypto/tls"
+	"net/http/httptrace"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/nimbus/backend/internal/models"
+)
+
+// CheckDurationBuckets are the upper bounds (seconds) of the nimbus_check_duration_seconds histogram
+var CheckDurationBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// HTTP check phases, measured with httptrace
+const (
+	CheckPhaseResolve   = "resolve"    // Request start until the first connection attempt (DNS and egress checks)
+	CheckPhaseConnect   = "connect"    // TCP connection setup
+	CheckPhaseTLS       = "tls"        // TLS handshake
+	CheckPhaseFirstByte = "first_byte" // Request written until the first response byte
+)
+
+// checkResults are the possible check results, always exported so rates work from the first scrape
+var checkResults = []string{models.StatusOnline, models.StatusOffline, models.StatusUnknown}
+
+// CheckExemplar is the most recent check that fell into a histogram bucket
+type CheckExemplar struct {
+	Result    string
+	Value     float64 // Seconds
+	Timestamp time.Time
+}
+
+// serviceCheckStats accumulates the checks of one service since the process started
+type serviceCheckStats struct {
+	results      map[string]uint64
+	bucketCounts []uint64 // Per bucket (not cumulative), plus +Inf
+	exemplars    []*CheckExemplar
+	count        uint64
+	sum          float64
+	lastCheck    time.Time
+	phases       map[string]float64 // Seconds, from the last HTTP check that measured them
+}
+
+// CheckMetrics records health check results for the Prometheus exporter
+// Counters and histograms start at zero when the process starts, like any Prometheus counter
+type CheckMetrics struct {
+	mu       sync.Mutex
+	services map[string]*serviceCheckStats
+}
+
+// NewCheckMetrics creates an empty check metrics recorder
+func NewCheckMetrics() *CheckMetrics {
+	return &CheckMetrics{services: make(map[string]*serviceCheckStats)}
+}
+
+func (m *CheckMetrics) service(serviceID string) *serviceCheckStats {
+	stats, ok := m.services[serviceID]
+	if !ok {
+		stats = &serviceCheckStats{
+			results:      make(map[string]uint64),
+			bucketCounts: make([]uint64, len(CheckDurationBuckets)+1),
+			exemplars:    make([]*CheckExemplar, len(CheckDurationBuckets)+1),
+		}
+		m.services[serviceID] = stats
+	}
+	return stats
+}
+
+// observeCheck records a check's effective result and (if it got one) its response time
+func (m *CheckMetrics) observeCheck(serviceID, result string, responseTimeMs *int, at time.Time) {
+	if m == nil {
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stats := m.service(serviceID)
+	stats.results[result]++
+	stats.lastCheck = at
+
+	if responseTimeMs == nil {
+		return
+	}
+	seconds := float64(*responseTimeMs) / 1000
+	bucket := sort.SearchFloat64s(CheckDurationBuckets, seconds)
+	stats.bucketCounts[bucket]++
+	stats.exemplars[bucket] = &CheckExemplar{Result: result, Value: seconds, Timestamp: at}
+	stats.count++
+	stats.sum += seconds
+}
+
+// observePhases records the phase timings of a service's latest HTTP check
+func (m *CheckMetrics) observePhases(serviceID string, phases map[string]time.Duration) {
+	if m == nil || len(phases) == 0 {
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stats := m.service(serviceID)
+	stats.phases = make(map[string]float64, len(phases))
+	for phase, duration := range phases {
+		stats.phases[phase] = duration.Seconds()
+	}
+}
+
+// Forget drops the metrics of a deleted service so its series stop being exported
+func (m *CheckMetrics) Forget(serviceID string) {
+	if m == nil {
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.services, serviceID)
+}
+
+// CheckMetricsSnapshot is a copy of the recorded check metrics for a set of services
+type CheckMetricsSnapshot struct {
+	Results  map[string]uint64 // Checks by result, summed over the services
+	Services []ServiceCheckSnapshot
+}
+
+// ServiceCheckSnapshot is one service's recorded check metrics
+type ServiceCheckSnapshot struct {
+	ServiceID        string
+	CumulativeCounts []uint64 // Per CheckDurationBuckets bound, then +Inf
+	Exemplars        []*CheckExemplar
+	Count            uint64
+	Sum              float64
+	LastCheck        time.Time
+	Phases           map[string]float64
+}
+
+// Snapshot copies the metrics of the given services (all services if include is nil)
+func (m *CheckMetrics) Snapshot(include map[string]bool) *CheckMetricsSnapshot {
+	snapshot := &CheckMetricsSnapshot{Results: make(map[string]uint64)}
+	for _, result := range checkResults {
+		snapshot.Results[result] = 0
+	}
+	if m == nil {
+		return snapshot
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for serviceID, stats := range m.services {
+		if include != nil && !include[serviceID] {
+			continue
+		}
+		for result, count := range stats.results {
+			snapshot.Results[result] += count
+		}
+
+		service := ServiceCheckSnapshot{
+			ServiceID:        serviceID,
+			CumulativeCounts: make([]uint64, len(stats.bucketCounts)),
+			Exemplars:        append([]*CheckExemplar(nil), stats.exemplars...),
+			Count:            stats.count,
+			Sum:              stats.sum,
+			LastCheck:        stats.lastCheck,
+			Phases:           make(map[string]float64, len(stats.phases)),
+		}
+		var cumulative uint64
+		for i, count := range stats.bucketCounts {
+			cumulative += count
+			service.CumulativeCounts[i] = cumulative
+		}
+		for phase, seconds := range stats.phases {
+			service.Phases[phase] = seconds
+		}
+		snapshot.Services = append(snapshot.Services, service)
+	}
+
+	sort.Slice(snapshot.Services, func(i, j int) bool { return snapshot.Services[i].ServiceID < snapshot.Services[j].ServiceID })
+	return snapshot
+}
+
+// CheckMetrics returns the recorder of this service's check results
+func (h *HealthCheckService) CheckMetrics() *CheckMetrics {
+	return h.checkMetrics
+}
+
+// phaseTimer measures the phases of one HTTP check with httptrace
+// Hooks can run concurrently when several addresses are dialed, so the times are guarded
+type phaseTimer struct {
+	mu           sync.Mutex
+	start        time.Time
+	connectStart time.Time
+	connectDone  time.Time
+	tlsStart     time.Time
+	tlsDone      time.Time
+	wroteRequest time.Time
+	firstByte    time.Time
+}
+
+func newPhaseTimer(start time.Time) *phaseTimer {
+	return &phaseTimer{start: start}
+}
+
+// mark sets *field to now unless it was already set (the first attempt wins)
+func (p *phaseTimer) mark(field *time.Time) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if field.IsZero() {
+		*field = time.Now()
+	}
+}
+
+// trace returns the client trace hooks that feed the timer
+func (p *phaseTimer) trace() *httptrace.ClientTrace {
+	return &httptrace.ClientTrace{
+		ConnectStart: func(string, string) { p.mark(&p.connectStart) },
+		ConnectDone: func(_, _ string, err error) {
+			if err == nil {
+				p.mark(&p.connectDone)
+			}
+		},
+		TLSHandshakeStart: func() { p.mark(&p.tlsStart) },
+		TLSHandshakeDone: func(_ tls.ConnectionState, err error) {
+			if err == nil {
+				p.mark(&p.tlsDone)
+			}
+		},
+		WroteRequest:         func(httptrace.WroteRequestInfo) { p.mark(&p.wroteRequest) },
+		GotFirstResponseByte: func() { p.mark(&p.firstByte) },
+	}
+}
+
+// durations returns the phases that were completely measured
+// Reused connections only report the time to the first byte
+func (p *phaseTimer) durations() map[string]time.Duration {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	phases := make(map[string]time.Duration)
+	if !p.connectStart.IsZero() {
+		phases[CheckPhaseResolve] = p.connectStart.Sub(p.start)
+		if !p.connectDone.IsZero() {
+			phases[CheckPhaseConnect] = p.connectDone.Sub(p.connectStart)
+		}
+	}
+	if !p.tlsStart.IsZero() && !p.tlsDone.IsZero() {
+		phases[CheckPhaseTLS] = p.tlsDone.Sub(p.tlsStart)
+	}
+	if !p.wroteRequest.IsZero() && !p.firstByte.IsZero() {
+		phases[CheckPhaseFirstByte] = p.firstByte.Sub(p.wroteRequest)
+	}
+	return phases
+}
+
+// annotate adds the phase boundaries as events and the measured phases as attributes to a check span
+func (p *phaseTimer) annotate(span *Span, phases map[string]time.Duration) {
+	if span == nil {
+		return
+	}
+
+	p.mu.Lock()
+	events := []struct {
+		name string
+		at   time.Time
+	}{
+		{"connect.start", p.connectStart},
+		{"connect.done", p.connectDone},
+		{"tls.start", p.tlsStart},
+		{"tls.done", p.tlsDone},
+		{"request.sent", p.wroteRequest},
+		{"response.first_byte", p.firstByte},
+	}
+	p.mu.Unlock()
+
+	for _, event := range events {
+		if !event.at.IsZero() {
+			span.AddEvent(event.name, event.at)
+		}
+	}
+	for _, phase := range sortedKeys(phases) {
+		span.SetAttributes(Attribute{"nimbus.check.phase." + phase, phases[phase].Seconds()})
+	}
+}
@@ -0,0 +1,89 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/nimbus/backend/internal/models"
+)
+
+func TestCheckMetrics_Snapshot(t *testing.T) {
+	checks := NewCheckMetrics()
+	now := time.Now()
+	checks.observeCheck("service-b", models.StatusOnline, intPtr(5), now)
+	checks.observeCheck("service-b", models.StatusOnline, intPtr(7), now)
+	checks.observeCheck("service-a", models.StatusOffline, intPtr(12000), now)
+
+	snapshot := checks.Snapshot(nil)
+	if len(snapshot.Services) != 2 || snapshot.Services[0].ServiceID != "service-a" {
+		t.Fatalf("Expected both services sorted by ID, got %+v", snapshot.Services)
+	}
+
+	serviceB := snapshot.Services[1]
+	// 5ms falls in the 0.005 bucket (bounds are inclusive), 7ms in 0.01
+	if serviceB.CumulativeCounts[0] != 1 || serviceB.CumulativeCounts[1] != 2 || serviceB.Count != 2 {
+		t.Errorf("Unexpected buckets %v (count %d)", serviceB.CumulativeCounts, serviceB.Count)
+	}
+	if serviceA := snapshot.Services[0]; serviceA.CumulativeCounts[len(CheckDurationBuckets)-1] != 0 || serviceA.CumulativeCounts[len(CheckDurationBuckets)] != 1 {
+		t.Errorf("Expected a 12s check only in the +Inf bucket, got %v", serviceA.CumulativeCounts)
+	}
+
+	// A user's snapshot only sums their services
+	owned := checks.Snapshot(map[string]bool{"service-b": true})
+	if len(owned.Services) != 1 || owned.Results[models.StatusOnline] != 2 || owned.Results[models.StatusOffline] != 0 {
+		t.Errorf("Expected only service-b's checks, got %+v", owned)
+	}
+
+	// A deleted service is forgotten
+	checks.Forget("service-a")
+	if remaining := checks.Snapshot(nil); len(remaining.Services) != 1 || remaining.Services[0].ServiceID != "service-b" {
+		t.Errorf("Expected only service-b after forgetting service-a, got %+v", remaining.Services)
+	}
+
+	// A nil recorder records nothing and snapshots as empty
+	var disabled *CheckMetrics
+	disabled.observeCheck("service-a", models.StatusOnline, intPtr(1), now)
+	if empty := disabled.Snapshot(nil); len(empty.Services) != 0 || len(empty.Results) != len(checkResults) {
+		t.Errorf("Expected an empty snapshot, got %+v", empty)
+	}
+}
+
+func TestHealthCheckService_RecordsCheckMetrics(t *testing.T) {
+	testServer := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer testServer.Close()
+
+	healthService := &HealthCheckService{
+		serviceRepo:  &MockServiceRepository{},
+		checkMetrics: NewCheckMetrics(),
+		httpClient:   testServer.Client(),
+	}
+	service := &models.Service{ID: "test-service-id", Name: "Test Service", URL: testServer.URL}
+
+	if err := healthService.CheckService(context.Background(), service); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	snapshot := healthService.CheckMetrics().Snapshot(nil)
+	if len(snapshot.Services) != 1 || snapshot.Services[0].Count != 1 || snapshot.Results[models.StatusOnline] != 1 {
+		t.Fatalf("Expected one online check, got %+v", snapshot)
+	}
+	for _, phase := range []string{CheckPhaseResolve, CheckPhaseConnect, CheckPhaseTLS, CheckPhaseFirstByte} {
+		if _, ok := snapshot.Services[0].Phases[phase]; !ok {
+			t.Errorf("Expected the %s phase of a new connection to be measured", phase)
+		}
+	}
+
+	// The second check reuses the connection - only the time to the first byte is measured
+	if err := healthService.CheckService(context.Background(), service); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	phases := healthService.CheckMetrics().Snapshot(nil).Services[0].Phases
+	if _, ok := phases[CheckPhaseFirstByte]; len(phases) != 1 || !ok {
+		t.Errorf("Expected only the first byte phase for a reused connection, got %v", phases)
+	}
+}
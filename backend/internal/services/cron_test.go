@@ -0,0 +1,75 @@
+package services
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseCronSchedule_Invalid(t *testing.T) {
+	for _, expr := range []string{
+		"",
+		"* * * *",
+		"* * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"*/0 * * * *",
+		"5-1 * * * *",
+		"a * * * *",
+		"0 0 30 2 *",
+		"@often",
+	} {
+		if _, err := parseCronSchedule(expr); err == nil {
+			t.Errorf("parseCronSchedule(%q) succeeded, want an error", expr)
+		}
+	}
+}
+
+func TestCronSchedule_Next(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skipf("Europe/Berlin not available: %v", err)
+	}
+	// Monday, 2 June 2025
+	after := time.Date(2025, 6, 2, 8, 30, 20, 0, time.UTC)
+
+	tests := []struct {
+		expr string
+		loc  *time.Location
+		want time.Time
+	}{
+		{"* * * * *", time.UTC, time.Date(2025, 6, 2, 8, 31, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.UTC, time.Date(2025, 6, 2, 8, 45, 0, 0, time.UTC)},
+		{"5/20 9 * * *", time.UTC, time.Date(2025, 6, 2, 9, 5, 0, 0, time.UTC)},
+		{"0 8 * * 1", time.UTC, time.Date(2025, 6, 9, 8, 0, 0, 0, time.UTC)},
+		{"0 8 * * mon-fri", time.UTC, time.Date(2025, 6, 3, 8, 0, 0, 0, time.UTC)},
+		{"0 9 * * sun", time.UTC, time.Date(2025, 6, 8, 9, 0, 0, 0, time.UTC)},
+		{"0 9 * * 7", time.UTC, time.Date(2025, 6, 8, 9, 0, 0, 0, time.UTC)},
+		{"0 0 1 * *", time.UTC, time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC)},
+		{"@yearly", time.UTC, time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 12 29 feb *", time.UTC, time.Date(2028, 2, 29, 12, 0, 0, 0, time.UTC)},
+		// Day of month or day of week when both are restricted
+		{"0 0 15 * 3", time.UTC, time.Date(2025, 6, 4, 0, 0, 0, 0, time.UTC)},
+		// 10:00 in Berlin (UTC+2 in summer) is 08:00 UTC, already past
+		{"0 10 * * *", berlin, time.Date(2025, 6, 3, 8, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		s, err := parseCronSchedule(tt.expr)
+		if err != nil {
+			t.Fatalf("parseCronSchedule(%q) failed: %v", tt.expr, err)
+		}
+		if got := s.next(after, tt.loc); !got.Equal(tt.want) {
+			t.Errorf("%q: next(%v) = %v, want %v", tt.expr, after, got.UTC(), tt.want)
+		}
+	}
+
+	// Across the spring DST change, 02:30 doesn't exist in Berlin on 30 March 2025
+	s, _ := parseCronSchedule("30 2 * * *")
+	got := s.next(time.Date(2025, 3, 29, 12, 0, 0, 0, time.UTC), berlin)
+	if got.IsZero() || got.Day() != 30 && got.Day() != 31 {
+		t.Errorf("next() across DST = %v, want 30 or 31 March", got)
+	}
+}
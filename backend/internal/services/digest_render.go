@@ -0,0 +1,191 @@
+package services
+
+import (
+	"bytes"
+	"fmt"
+	htmltemplate "html/template"
+	"strings"
+	"time"
+
+	"github.com/nimbus/backend/internal/mailer"
+	"github.com/nimbus/backend/internal/models"
+)
+
+// maxDigestMessageServices limits the uptime list of the Markdown report, which chat channels
+// truncate (the HTML report lists every service)
+const maxDigestMessageServices = 25
+
+// digestMessage is a digest report rendered for delivery
+type digestMessage struct {
+	title    string
+	markdown string
+	html     string // Report body, without the email layout
+}
+
+// email wraps the report in the email layout
+func (m *digestMessage) email(to []string) (*mailer.Message, error) {
+	return mailer.Digest.Render(to, mailer.DigestData{
+		Title: m.title,
+		Text:  m.markdown,
+		HTML:  htmltemplate.HTML(m.html),
+	})
+}
+
+// digestView formats a report's values in the report's timezone
+type digestView struct {
+	*models.DigestReport
+	loc *time.Location
+}
+
+// Date formats a time in the report's timezone
+func (v digestView) Date(t time.Time) string {
+	return t.In(v.loc).Format("Mon 2 Jan 2006 15:04")
+}
+
+// Day formats a date in the report's timezone
+func (v digestView) Day(t time.Time) string {
+	return t.In(v.loc).Format("2 Jan 2006")
+}
+
+// Uptime formats a service's uptime, which is unknown without check results
+func (v digestView) Uptime(s models.DigestServiceSummary) string {
+	if s.CoveragePercentage == 0 {
+		return "no data"
+	}
+	return formatPercent(s.UptimePercentage)
+}
+
+// Percent formats a percentage
+func (v digestView) Percent(value float64) string {
+	return formatPercent(value)
+}
+
+// Millis formats a response time
+func (v digestView) Millis(ms float64) string {
+	return fmt.Sprintf("%.0f ms", ms)
+}
+
+// Duration formats a duration in seconds, rounded to the minute
+func (v digestView) Duration(seconds float64) string {
+	d := (time.Duration(seconds) * time.Second).Round(time.Minute)
+	if d < time.Minute {
+		return "less than a minute"
+	}
+	return strings.TrimSuffix(d.String(), "0s")
+}
+
+// ExpiresIn describes when a certificate expires, relative to the end of the period
+func (v digestView) ExpiresIn(t time.Time) string {
+	days := int(t.Sub(v.PeriodEnd).Hours() / 24)
+	switch {
+	case t.Before(v.PeriodEnd):
+		return "expired"
+	case days == 0:
+		return "expires today"
+	case days == 1:
+		return "expires tomorrow"
+	default:
+		return fmt.Sprintf("expires in %d days", days)
+	}
+}
+
+func formatPercent(value float64) string {
+	return fmt.Sprintf("%.2f%%", value)
+}
+
+// renderDigest renders a report as Markdown (chat channels, plain-text email) and HTML (email, preview)
+func renderDigest(report *models.DigestReport, loc *time.Location) (*digestMessage, error) {
+	view := digestView{DigestReport: report, loc: loc}
+
+	var html bytes.Buffer
+	if err := digestHTMLTemplate.Execute(&html, view); err != nil {
+		return nil, fmt.Errorf("failed to render digest: %w", err)
+	}
+
+	return &digestMessage{
+		title:    report.Title,
+		markdown: renderDigestMarkdown(view),
+		html:     html.String(),
+	}, nil
+}
+
+// renderDigestMarkdown renders the report as Markdown, using lists rather than tables so it reads
+// well in chat apps
+func renderDigestMarkdown(v digestView) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s – %s (%s)\n", v.Date(v.PeriodStart), v.Date(v.PeriodEnd), v.Timezone)
+
+	b.WriteString("\n**Uptime**\n")
+	if len(v.Services) == 0 {
+		b.WriteString("No services are monitored.\n")
+	} else {
+		fmt.Fprintf(&b, "Overall: %s across %d services\n", v.Percent(v.UptimePercentage), len(v.Services))
+		for i, s := range v.Services {
+			if i == maxDigestMessageServices {
+				fmt.Fprintf(&b, "- …and %d more\n", len(v.Services)-i)
+				break
+			}
+			fmt.Fprintf(&b, "- %s: %s", s.Name, v.Uptime(s))
+			if s.Status != models.StatusOnline {
+				fmt.Fprintf(&b, " (currently %s)", s.Status)
+			}
+			b.WriteString("\n")
+		}
+	}
+
+	b.WriteString("\n**Slowest services**\n")
+	if len(v.SlowestServices) == 0 {
+		b.WriteString("No response times were recorded.\n")
+	}
+	for i, s := range v.SlowestServices {
+		fmt.Fprintf(&b, "%d. %s: %s average, %s p95\n", i+1, s.Name, v.Millis(s.AvgResponseTime), v.Millis(s.P95ResponseTime))
+	}
+
+	b.WriteString("\n**Incidents**\n")
+	if len(v.Incidents) == 0 {
+		b.WriteString("No incidents.\n")
+	}
+	for _, incident := range v.Incidents {
+		fmt.Fprintf(&b, "- %s (%s): started %s, ", incident.Title, incident.Status, v.Date(incident.StartedAt))
+		if incident.ResolvedAt != nil {
+			fmt.Fprintf(&b, "lasted %s\n", v.Duration(incident.DurationSeconds))
+		} else {
+			fmt.Fprintf(&b, "ongoing for %s\n", v.Duration(incident.DurationSeconds))
+		}
+	}
+
+	if len(v.ExpiringCertificates) > 0 {
+		b.WriteString("\n**Certificates expiring soon**\n")
+		for _, cert := range v.ExpiringCertificates {
+			fmt.Fprintf(&b, "- %s (%s): %s on %s\n", cert.ServiceName, cert.Subject, v.ExpiresIn(cert.ExpiresAt), v.Day(cert.ExpiresAt))
+		}
+	}
+
+	return b.String()
+}
+
+// digestHTMLTemplate is the HTML report; email wraps it in the mailer layout
+var digestHTMLTemplate = htmltemplate.Must(htmltemplate.New("digest").Parse(`<h2 style="margin:0 0 4px;font-size:20px;">{{.Title}}</h2>
+<p style="margin:0 0 20px;font-size:13px;color:#7b8794;">{{.Date .PeriodStart}} – {{.Date .PeriodEnd}} ({{.Timezone}})</p>
+<h3 style="margin:0 0 8px;font-size:16px;">Uptime</h3>
+{{if .Services}}<p style="margin:0 0 8px;">Overall: <strong>{{.Percent .UptimePercentage}}</strong> across {{len .Services}} services</p>
+<table role="presentation" width="100%" cellpadding="0" cellspacing="0" style="margin:0 0 20px;font-size:14px;border-collapse:collapse;">
+<tr style="color:#7b8794;"><th align="left" style="padding:4px 0;">Service</th><th align="right" style="padding:4px 0;">Uptime</th><th align="right" style="padding:4px 0;">Avg response</th></tr>
+{{range .Services}}<tr style="border-top:1px solid #e4e7eb;"><td style="padding:4px 0;">{{.Name}}{{if ne .Status "online"}} <span style="color:#E53935;">({{.Status}})</span>{{end}}</td><td align="right" style="padding:4px 0;">{{$.Uptime .}}</td><td align="right" style="padding:4px 0;">{{if .TotalChecks}}{{$.Millis .AvgResponseTime}}{{else}}–{{end}}</td></tr>
+{{end}}</table>
+{{else}}<p style="margin:0 0 20px;">No services are monitored.</p>
+{{end}}<h3 style="margin:0 0 8px;font-size:16px;">Slowest services</h3>
+{{if .SlowestServices}}<ol style="margin:0 0 20px;padding-left:20px;">
+{{range .SlowestServices}}<li>{{.Name}}: {{$.Millis .AvgResponseTime}} average, {{$.Millis .P95ResponseTime}} p95</li>
+{{end}}</ol>
+{{else}}<p style="margin:0 0 20px;">No response times were recorded.</p>
+{{end}}<h3 style="margin:0 0 8px;font-size:16px;">Incidents</h3>
+{{if .Incidents}}<ul style="margin:0 0 20px;padding-left:20px;">
+{{range .Incidents}}<li><strong>{{.Title}}</strong> ({{.Status}}): started {{$.Date .StartedAt}}, {{if .ResolvedAt}}lasted{{else}}ongoing for{{end}} {{$.Duration .DurationSeconds}}</li>
+{{end}}</ul>
+{{else}}<p style="margin:0 0 20px;">No incidents.</p>
+{{end}}{{if .ExpiringCertificates}}<h3 style="margin:0 0 8px;font-size:16px;">Certificates expiring soon</h3>
+<ul style="margin:0;padding-left:20px;">
+{{range .ExpiringCertificates}}<li>{{.ServiceName}} ({{.Subject}}): {{$.ExpiresIn .ExpiresAt}} on {{$.Day .ExpiresAt}}</li>
+{{end}}</ul>
+{{end}}`))
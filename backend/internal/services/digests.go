@@ -0,0 +1,383 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/nimbus/backend/internal/mailer"
+	"github.com/nimbus/backend/internal/models"
+	"github.com/nimbus/backend/internal/repository"
+)
+
+// ErrInvalidDigest is returned for invalid digest settings
+var ErrInvalidDigest = errors.New("invalid digest settings")
+
+const (
+	// maxDigestChannels limits the notification channels a digest is sent to
+	maxDigestChannels = 10
+	// digestSlowestServices is the length of the slowest services list
+	digestSlowestServices = 5
+	// digestCertificateWindow lists certificates expiring within this time after the period
+	digestCertificateWindow = 30 * 24 * time.Hour
+	// digestCatchUp is how late a digest may still be sent, e.g. after a restart;
+	// digests missed for longer are skipped until their next scheduled time
+	digestCatchUp = time.Hour
+	// minDigestPeriod and maxDigestPeriod bound the time a report covers
+	minDigestPeriod = time.Hour
+	maxDigestPeriod = 31 * 24 * time.Hour
+)
+
+// DigestService builds the users' periodic digest reports and sends them on their schedule
+// A report covers the time since the previous one (or, for the first, one schedule interval)
+type DigestService struct {
+	preferencesRepo *repository.PreferencesRepository
+	serviceRepo     repository.ServiceRepositoryInterface
+	userRepo        *repository.UserRepository
+	incidentRepo    *repository.IncidentRepository
+	certificateRepo *repository.CertificateRepository
+	channelRepo     *repository.NotificationChannelRepository
+	metrics         *MetricsService
+	notifications   *NotificationService // nil: reports aren't sent to channels
+	mail            *MailService         // nil: reports aren't emailed
+
+	now func() time.Time
+}
+
+// NewDigestService creates a digest service
+func NewDigestService(
+	preferencesRepo *repository.PreferencesRepository,
+	serviceRepo repository.ServiceRepositoryInterface,
+	userRepo *repository.UserRepository,
+	incidentRepo *repository.IncidentRepository,
+	certificateRepo *repository.CertificateRepository,
+	channelRepo *repository.NotificationChannelRepository,
+	metrics *MetricsService,
+) *DigestService {
+	return &DigestService{
+		preferencesRepo: preferencesRepo,
+		serviceRepo:     serviceRepo,
+		userRepo:        userRepo,
+		incidentRepo:    incidentRepo,
+		certificateRepo: certificateRepo,
+		channelRepo:     channelRepo,
+		metrics:         metrics,
+		now:             time.Now,
+	}
+}
+
+// SetNotificationService sends digests to the users' chosen notification channels
+func (s *DigestService) SetNotificationService(n *NotificationService) {
+	s.notifications = n
+}
+
+// SetMailService emails digests to the users' account addresses
+func (s *DigestService) SetMailService(m *MailService) {
+	s.mail = m
+}
+
+// Validate checks a user's digest settings and fills in defaults
+func (s *DigestService) Validate(ctx context.Context, userID string, digest *models.DigestPreferences) error {
+	digest.Schedule = strings.Join(strings.Fields(digest.Schedule), " ")
+	if digest.Schedule == "" {
+		digest.Schedule = models.DefaultDigestSchedule
+	}
+	if len(digest.Schedule) > 100 {
+		return fmt.Errorf("%w: schedule is too long", ErrInvalidDigest)
+	}
+	if _, err := parseCronSchedule(digest.Schedule); err != nil {
+		return fmt.Errorf("%w: schedule: %v", ErrInvalidDigest, err)
+	}
+
+	digest.Timezone = strings.TrimSpace(digest.Timezone)
+	if digest.Timezone == "" {
+		digest.Timezone = models.DefaultDigestTimezone
+	}
+	if _, err := time.LoadLocation(digest.Timezone); err != nil || len(digest.Timezone) > 64 {
+		return fmt.Errorf("%w: unknown timezone %q", ErrInvalidDigest, digest.Timezone)
+	}
+
+	channelIDs := []string{}
+	for _, id := range digest.ChannelIDs {
+		if id == "" || slices.Contains(channelIDs, id) {
+			continue
+		}
+		channelIDs = append(channelIDs, id)
+	}
+	if len(channelIDs) > maxDigestChannels {
+		return fmt.Errorf("%w: a digest can be sent to at most %d channels", ErrInvalidDigest, maxDigestChannels)
+	}
+	for _, id := range channelIDs {
+		channel, err := s.channelRepo.GetByID(ctx, id)
+		if errors.Is(err, sql.ErrNoRows) || (err == nil && !channel.IsGlobal() && *channel.UserID != userID) {
+			return fmt.Errorf("%w: unknown channel %q", ErrInvalidDigest, id)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	digest.ChannelIDs = channelIDs
+
+	if digest.Enabled && !digest.Email && len(digest.ChannelIDs) == 0 {
+		return fmt.Errorf("%w: choose email or at least one channel to receive the digest", ErrInvalidDigest)
+	}
+	digest.LastSentAt = nil
+	return nil
+}
+
+// DigestPreview is a report with its renderings
+type DigestPreview struct {
+	Report   *models.DigestReport
+	Markdown string // As sent to chat channels
+	HTML     string // As emailed, including the email layout
+}
+
+// Preview builds the report the user would receive now
+func (s *DigestService) Preview(ctx context.Context, userID string) (*DigestPreview, error) {
+	digest := models.DefaultDigestPreferences()
+	preferences, err := s.preferencesRepo.GetByUserID(ctx, userID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+	if preferences != nil {
+		digest = preferences.Digest
+	}
+
+	schedule, loc, err := digestSchedule(&digest)
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.now()
+	report, err := s.Build(ctx, userID, digestPeriodStart(schedule, loc, digest.LastSentAt, now), now, loc)
+	if err != nil {
+		return nil, err
+	}
+	message, err := renderDigest(report, loc)
+	if err != nil {
+		return nil, err
+	}
+	email, err := message.email(nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return &DigestPreview{Report: report, Markdown: message.markdown, HTML: email.HTML}, nil
+}
+
+// Build creates a user's report for [start, end)
+func (s *DigestService) Build(ctx context.Context, userID string, start, end time.Time, loc *time.Location) (*models.DigestReport, error) {
+	report := &models.DigestReport{
+		Title:                fmt.Sprintf("Nimbus digest for %s – %s", start.In(loc).Format("2 Jan"), end.In(loc).Format("2 Jan 2006")),
+		PeriodStart:          start,
+		PeriodEnd:            end,
+		Timezone:             loc.String(),
+		Services:             []models.DigestServiceSummary{},
+		SlowestServices:      []models.DigestServiceSummary{},
+		Incidents:            []models.DigestIncident{},
+		ExpiringCertificates: []models.ServiceCertificate{},
+		GeneratedAt:          s.now(),
+	}
+
+	services, err := s.serviceRepo.GetAllByUserID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get services: %w", err)
+	}
+
+	intervalMinutes := int(end.Sub(start).Minutes())
+	var uptimeSum float64
+	var withData int
+	for _, service := range services {
+		metrics, err := s.metrics.GetServiceMetrics(ctx, service.ID, start, end, intervalMinutes, loc)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get metrics for service %s: %w", service.ID, err)
+		}
+
+		report.Services = append(report.Services, models.DigestServiceSummary{
+			ServiceID:          service.ID,
+			Name:               service.Name,
+			URL:                service.URL,
+			Status:             service.Status,
+			UptimePercentage:   metrics.UptimePercentage,
+			CoveragePercentage: metrics.CoveragePercentage,
+			AvgResponseTime:    metrics.AvgResponseTime,
+			P95ResponseTime:    metrics.P95ResponseTime,
+			TotalChecks:        metrics.TotalChecks,
+		})
+		if metrics.CoveragePercentage > 0 {
+			uptimeSum += metrics.UptimePercentage
+			withData++
+		}
+	}
+	if withData > 0 {
+		report.UptimePercentage = uptimeSum / float64(withData)
+	}
+
+	// Lowest uptime first; services without data last
+	sort.SliceStable(report.Services, func(i, j int) bool {
+		a, b := report.Services[i], report.Services[j]
+		if (a.CoveragePercentage > 0) != (b.CoveragePercentage > 0) {
+			return a.CoveragePercentage > 0
+		}
+		if a.UptimePercentage != b.UptimePercentage {
+			return a.UptimePercentage < b.UptimePercentage
+		}
+		return a.Name < b.Name
+	})
+
+	for _, summary := range report.Services {
+		if summary.TotalChecks > 0 && summary.AvgResponseTime > 0 {
+			report.SlowestServices = append(report.SlowestServices, summary)
+		}
+	}
+	sort.SliceStable(report.SlowestServices, func(i, j int) bool {
+		return report.SlowestServices[i].AvgResponseTime > report.SlowestServices[j].AvgResponseTime
+	})
+	if len(report.SlowestServices) > digestSlowestServices {
+		report.SlowestServices = report.SlowestServices[:digestSlowestServices]
+	}
+
+	incidents, err := s.incidentRepo.GetByUserIDBetween(ctx, userID, start, end)
+	if err != nil {
+		return nil, err
+	}
+	for _, incident := range incidents {
+		until := end
+		if incident.ResolvedAt != nil && incident.ResolvedAt.Before(end) {
+			until = *incident.ResolvedAt
+		}
+		report.Incidents = append(report.Incidents, models.DigestIncident{
+			ID:              incident.ID,
+			Title:           incident.Title,
+			Status:          incident.Status,
+			StartedAt:       incident.StartedAt,
+			ResolvedAt:      incident.ResolvedAt,
+			DurationSeconds: until.Sub(incident.StartedAt).Seconds(),
+		})
+	}
+
+	certs, err := s.certificateRepo.GetExpiringByUserID(ctx, userID, end.Add(digestCertificateWindow))
+	if err != nil {
+		return nil, err
+	}
+	report.ExpiringCertificates = certs
+
+	return report, nil
+}
+
+// SendDue sends the digests whose scheduled time has come and returns how many were sent
+// Called every minute by the digest report worker
+func (s *DigestService) SendDue(ctx context.Context) (int, error) {
+	subscribers, err := s.preferencesRepo.GetDigestSubscribers(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	now := s.now()
+	sent := 0
+	for userID, digest := range subscribers {
+		schedule, loc, err := digestSchedule(digest)
+		if err != nil {
+			fmt.Printf("Skipping digest for user %s: %v\n", userID, err)
+			continue
+		}
+		if !digestDue(schedule, loc, digest.LastSentAt, now) {
+			continue
+		}
+
+		if err := s.send(ctx, userID, digest, schedule, loc, now); err != nil {
+			fmt.Printf("Failed to send digest to user %s: %v\n", userID, err)
+			continue
+		}
+		sent++
+	}
+	return sent, nil
+}
+
+// send builds a user's report and hands it to their channels and mailbox
+func (s *DigestService) send(ctx context.Context, userID string, digest *models.DigestPreferences, schedule *cronSchedule, loc *time.Location, now time.Time) error {
+	report, err := s.Build(ctx, userID, digestPeriodStart(schedule, loc, digest.LastSentAt, now), now, loc)
+	if err != nil {
+		return err
+	}
+	message, err := renderDigest(report, loc)
+	if err != nil {
+		return err
+	}
+
+	// Resolve the email first: once the channels are notified, the sent time must be recorded
+	// or channel subscribers would get the digest again every minute
+	var msg *mailer.Message
+	if digest.Email {
+		user, err := s.userRepo.GetByID(userID)
+		if err != nil {
+			return err
+		}
+		msg, err = message.email([]string{user.Email})
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(digest.ChannelIDs) > 0 && s.notifications != nil {
+		s.notifications.NotifyChannels(digest.ChannelIDs, &Notification{
+			Event:      models.NotificationEventDigest,
+			OccurredAt: now,
+			Report:     report,
+			digest:     message,
+		})
+	}
+
+	if msg != nil {
+		if err := s.mail.Enqueue(msg); err != nil {
+			fmt.Printf("Failed to email digest to user %s: %v\n", userID, err)
+		}
+	}
+
+	// Recorded even if email isn't configured, so the digest isn't retried every minute
+	return s.preferencesRepo.SetDigestSentAt(ctx, userID, now)
+}
+
+// digestSchedule parses a digest's schedule and timezone
+func digestSchedule(digest *models.DigestPreferences) (*cronSchedule, *time.Location, error) {
+	schedule, err := parseCronSchedule(digest.Schedule)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: schedule: %v", ErrInvalidDigest, err)
+	}
+	loc, err := time.LoadLocation(digest.Timezone)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: unknown timezone %q", ErrInvalidDigest, digest.Timezone)
+	}
+	return schedule, loc, nil
+}
+
+// digestDue reports whether a scheduled time passed since the last digest (at most digestCatchUp ago)
+func digestDue(schedule *cronSchedule, loc *time.Location, lastSentAt *time.Time, now time.Time) bool {
+	from := now.Add(-digestCatchUp)
+	if lastSentAt != nil && lastSentAt.After(from) {
+		from = *lastSentAt
+	}
+	next := schedule.next(from, loc)
+	return !next.IsZero() && !next.After(now)
+}
+
+// digestPeriodStart returns the start of the period a report sent at now covers: the previous
+// digest, or one schedule interval for the first one
+func digestPeriodStart(schedule *cronSchedule, loc *time.Location, lastSentAt *time.Time, now time.Time) time.Time {
+	var period time.Duration
+	if lastSentAt != nil {
+		period = now.Sub(*lastSentAt)
+	} else {
+		next := schedule.next(now, loc)
+		period = schedule.next(next, loc).Sub(next)
+	}
+
+	period = max(period, minDigestPeriod)
+	period = min(period, maxDigestPeriod)
+	return now.Add(-period)
+}
@@ -0,0 +1,345 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nimbus/backend/internal/models"
+	"github.com/nimbus/backend/internal/repository"
+)
+
+// newTestDigestService returns a digest service on a fake clock with an unstarted notification
+// service, so sent digests stay in its queue. user-1 has test-service-1 (online), test-service-2
+// (offline) and a webhook channel "1"
+func newTestDigestService(t *testing.T, start time.Time) (*DigestService, *alertClock, *sql.DB) {
+	t.Helper()
+	db := setupNotificationTestDB(t)
+	createIncidentTables(t, db)
+
+	_, err := db.Exec(`
+		CREATE TABLE users (
+			id TEXT PRIMARY KEY,
+			email TEXT NOT NULL UNIQUE,
+			name TEXT NOT NULL,
+			password TEXT NOT NULL,
+			role TEXT NOT NULL DEFAULT 'user',
+			last_activity_at TIMESTAMP,
+			created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+		);
+
+		CREATE TABLE user_preferences (
+			id TEXT PRIMARY KEY DEFAULT (lower(hex(randomblob(16)))),
+			user_id TEXT NOT NULL UNIQUE,
+			theme_mode TEXT NOT NULL DEFAULT 'light',
+			theme_background TEXT,
+			theme_accent_color TEXT,
+			open_in_new_tab BOOLEAN NOT NULL DEFAULT 1,
+			digest_enabled BOOLEAN NOT NULL DEFAULT 0,
+			digest_schedule TEXT NOT NULL DEFAULT '0 8 * * 1',
+			digest_timezone TEXT NOT NULL DEFAULT 'UTC',
+			digest_email BOOLEAN NOT NULL DEFAULT 1,
+			digest_channel_ids TEXT NOT NULL DEFAULT '[]',
+			digest_last_sent_at TIMESTAMP,
+			created_at TIMESTAMP NOT NULL,
+			updated_at TIMESTAMP NOT NULL
+		);
+
+		CREATE TABLE service_certificates (
+			service_id TEXT PRIMARY KEY,
+			subject TEXT NOT NULL,
+			issuer TEXT NOT NULL,
+			expires_at TIMESTAMP NOT NULL,
+			checked_at TIMESTAMP NOT NULL
+		);
+
+		INSERT INTO users (id, email, name, password) VALUES ('user-1', 'user1@example.com', 'User 1', 'hashed');
+	`)
+	if err != nil {
+		t.Fatalf("Failed to create digest tables: %v", err)
+	}
+
+	notifications := newTestNotificationService(db)
+	if _, err := notifications.Create(context.Background(), "user-1", false, webhookRequest("Reports", "http://example.com/reports")); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	clock := &alertClock{now: start}
+	s := NewDigestService(
+		repository.NewPreferencesRepository(db),
+		repository.NewServiceRepository(db),
+		repository.NewUserRepository(db),
+		repository.NewIncidentRepository(db),
+		repository.NewCertificateRepository(db),
+		repository.NewNotificationChannelRepository(db),
+		NewMetricsService(repository.NewStatusLogRepository(db), repository.NewServiceRepository(db)),
+	)
+	s.SetNotificationService(notifications)
+	s.now = clock.Now
+	return s, clock, db
+}
+
+// queuedDigests takes the digests queued since the last call
+func queuedDigests(s *DigestService) []*Notification {
+	var queued []*Notification
+	for {
+		select {
+		case n := <-s.notifications.queue:
+			queued = append(queued, n)
+		default:
+			return queued
+		}
+	}
+}
+
+func TestDigestService_Validate(t *testing.T) {
+	s, _, db := newTestDigestService(t, time.Now())
+	ctx := context.Background()
+
+	if _, err := db.Exec(`INSERT INTO notification_channels (user_id, name, type, created_at, updated_at) VALUES ('user-2', 'Foreign', 'webhook', ?, ?)`, time.Now(), time.Now()); err != nil {
+		t.Fatalf("Failed to insert channel: %v", err)
+	}
+
+	digest := &models.DigestPreferences{Enabled: true, Email: true, ChannelIDs: []string{"1", "", "1"}}
+	if err := s.Validate(ctx, "user-1", digest); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+	if digest.Schedule != models.DefaultDigestSchedule || digest.Timezone != models.DefaultDigestTimezone {
+		t.Errorf("Validate() = %+v, want the default schedule and timezone", digest)
+	}
+	if len(digest.ChannelIDs) != 1 || digest.ChannelIDs[0] != "1" {
+		t.Errorf("ChannelIDs = %v, want [1]", digest.ChannelIDs)
+	}
+
+	invalid := []models.DigestPreferences{
+		{Enabled: true, Email: true, Schedule: "0 25 * * *"},
+		{Enabled: true, Email: true, Schedule: "daily"},
+		{Enabled: true, Email: true, Timezone: "Mars/Olympus_Mons"},
+		{Enabled: true, ChannelIDs: []string{"2"}}, // user-2's channel
+		{Enabled: true, ChannelIDs: []string{"99"}},
+		{Enabled: true},
+	}
+	for _, digest := range invalid {
+		if err := s.Validate(ctx, "user-1", &digest); !errors.Is(err, ErrInvalidDigest) {
+			t.Errorf("Validate(%+v) error = %v, want ErrInvalidDigest", digest, err)
+		}
+	}
+
+	// Disabled digests don't need a destination
+	if err := s.Validate(ctx, "user-1", &models.DigestPreferences{}); err != nil {
+		t.Errorf("Validate() of a disabled digest error = %v", err)
+	}
+}
+
+func TestDigestService_Build(t *testing.T) {
+	end := time.Date(2025, 6, 9, 8, 0, 0, 0, time.UTC)
+	start := end.Add(-7 * 24 * time.Hour)
+	s, _, db := newTestDigestService(t, end)
+	ctx := context.Background()
+
+	statusLogRepo := repository.NewStatusLogRepository(db)
+	for i := 0; i < 7*24; i++ {
+		checkedAt := start.Add(time.Duration(i) * time.Hour)
+		fast, slow := 100, 400
+		logs := []*models.StatusLog{
+			{ServiceID: "test-service-1", Status: models.StatusOnline, ResponseTime: &fast, CheckedAt: checkedAt},
+			{ServiceID: "test-service-2", Status: models.StatusOnline, ResponseTime: &slow, CheckedAt: checkedAt},
+		}
+		// test-service-2 is down for the last day
+		if i >= 6*24 {
+			logs[1] = &models.StatusLog{ServiceID: "test-service-2", Status: models.StatusOffline, CheckedAt: checkedAt}
+		}
+		for _, log := range logs {
+			if err := statusLogRepo.Create(ctx, log); err != nil {
+				t.Fatalf("Create() error = %v", err)
+			}
+		}
+	}
+
+	incidentRepo := repository.NewIncidentRepository(db)
+	resolvedAt := start.Add(2 * time.Hour)
+	incidents := []*models.Incident{
+		{UserID: "user-1", Title: "Ongoing outage", Status: models.IncidentStatusOpen, StartedAt: end.Add(-24 * time.Hour)},
+		{UserID: "user-1", Title: "Resolved outage", Status: models.IncidentStatusOpen, StartedAt: start.Add(-time.Hour), ResolvedAt: &resolvedAt},
+		{UserID: "user-1", Title: "Old outage", Status: models.IncidentStatusOpen, StartedAt: start.Add(-48 * time.Hour), ResolvedAt: ptrTime(start.Add(-24 * time.Hour))},
+		{UserID: "user-2", Title: "Someone else's outage", Status: models.IncidentStatusOpen, StartedAt: end.Add(-time.Hour)},
+	}
+	for _, incident := range incidents {
+		incident.CreatedAt, incident.UpdatedAt = incident.StartedAt, incident.StartedAt
+		if err := incidentRepo.Create(ctx, incident); err != nil {
+			t.Fatalf("Create() error = %v", err)
+		}
+		if incident.ResolvedAt != nil {
+			if _, err := incidentRepo.Resolve(ctx, incident.ID, nil, *incident.ResolvedAt); err != nil {
+				t.Fatalf("Resolve() error = %v", err)
+			}
+		}
+	}
+
+	certRepo := repository.NewCertificateRepository(db)
+	certs := []*models.ServiceCertificate{
+		{ServiceID: "test-service-1", Subject: "example.com", Issuer: "Test CA", ExpiresAt: end.Add(10 * 24 * time.Hour), CheckedAt: start},
+		{ServiceID: "test-service-2", Subject: "example2.com", Issuer: "Test CA", ExpiresAt: end.Add(90 * 24 * time.Hour), CheckedAt: start},
+		{ServiceID: "foreign-service", Subject: "foreign.lan", Issuer: "Test CA", ExpiresAt: end.Add(24 * time.Hour), CheckedAt: start},
+	}
+	for _, cert := range certs {
+		if err := certRepo.Save(ctx, cert); err != nil {
+			t.Fatalf("Save() error = %v", err)
+		}
+	}
+
+	report, err := s.Build(ctx, "user-1", start, end, time.UTC)
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+
+	if len(report.Services) != 2 || report.Services[0].ServiceID != "test-service-2" {
+		t.Fatalf("Services = %+v, want test-service-2 (lowest uptime) first", report.Services)
+	}
+	if up := report.Services[0].UptimePercentage; up < 85 || up > 86 {
+		t.Errorf("test-service-2 uptime = %.2f, want 6 of 7 days", up)
+	}
+	if up := report.Services[1].UptimePercentage; up != 100 {
+		t.Errorf("test-service-1 uptime = %.2f, want 100", up)
+	}
+	if len(report.SlowestServices) != 2 || report.SlowestServices[0].ServiceID != "test-service-2" {
+		t.Errorf("SlowestServices = %+v, want test-service-2 first", report.SlowestServices)
+	}
+
+	if len(report.Incidents) != 2 || report.Incidents[0].Title != "Ongoing outage" || report.Incidents[1].Title != "Resolved outage" {
+		t.Fatalf("Incidents = %+v, want the ongoing and the resolved outage", report.Incidents)
+	}
+	if d := report.Incidents[0].DurationSeconds; d != (24 * time.Hour).Seconds() {
+		t.Errorf("Ongoing incident duration = %v, want until the end of the period", d)
+	}
+
+	if len(report.ExpiringCertificates) != 1 || report.ExpiringCertificates[0].ServiceID != "test-service-1" {
+		t.Errorf("ExpiringCertificates = %+v, want test-service-1's", report.ExpiringCertificates)
+	}
+
+	message, err := renderDigest(report, time.UTC)
+	if err != nil {
+		t.Fatalf("renderDigest() error = %v", err)
+	}
+	for _, want := range []string{"Test Service 2: 85.", "(currently offline)", "1. Test Service 2: 400 ms average", "Ongoing outage (open)", "example.com): expires in 10 days"} {
+		if !strings.Contains(message.markdown, want) {
+			t.Errorf("Markdown report is missing %q:\n%s", want, message.markdown)
+		}
+	}
+	if !strings.Contains(message.html, "<strong>Ongoing outage</strong>") {
+		t.Errorf("HTML report is missing the incident:\n%s", message.html)
+	}
+}
+
+func TestDigestService_SendDue(t *testing.T) {
+	// Monday, 2 June 2025
+	s, clock, _ := newTestDigestService(t, time.Date(2025, 6, 2, 7, 59, 0, 0, time.UTC))
+	ctx := context.Background()
+
+	digest := &models.DigestPreferences{Enabled: true, Schedule: "0 8 * * *", ChannelIDs: []string{"1"}}
+	if err := s.Validate(ctx, "user-1", digest); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+	if err := s.preferencesRepo.UpsertDigest(ctx, "user-1", digest); err != nil {
+		t.Fatalf("UpsertDigest() error = %v", err)
+	}
+
+	sendDue := func(want int) []*Notification {
+		t.Helper()
+		sent, err := s.SendDue(ctx)
+		if err != nil {
+			t.Fatalf("SendDue() error = %v", err)
+		}
+		queued := queuedDigests(s)
+		if sent != want || len(queued) != want {
+			t.Fatalf("SendDue() at %v sent %d (%d queued), want %d", clock.Now(), sent, len(queued), want)
+		}
+		return queued
+	}
+
+	sendDue(0)
+
+	clock.now = time.Date(2025, 6, 2, 8, 0, 30, 0, time.UTC)
+	first := sendDue(1)[0]
+	if first.Event != models.NotificationEventDigest || len(first.channelIDs) != 1 || first.channelIDs[0] != "1" {
+		t.Fatalf("Digest = %s to %v, want %s to [1]", first.Event, first.channelIDs, models.NotificationEventDigest)
+	}
+	// The first report covers one schedule interval
+	if got := first.Report.PeriodEnd.Sub(first.Report.PeriodStart); got != 24*time.Hour {
+		t.Errorf("First report covers %v, want 24h", got)
+	}
+	if !strings.HasPrefix(first.Title(), "Nimbus digest for 1 Jun – 2 Jun 2025") || !strings.Contains(first.Message(), "Test Service 1") {
+		t.Errorf("Digest = %q: %q", first.Title(), first.Message())
+	}
+
+	// Sent once per scheduled time
+	clock.now = clock.now.Add(30 * time.Second)
+	sendDue(0)
+
+	// The next one covers the time since the previous one
+	clock.now = time.Date(2025, 6, 3, 8, 0, 5, 0, time.UTC)
+	second := sendDue(1)[0]
+	if !second.Report.PeriodStart.Equal(time.Date(2025, 6, 2, 8, 0, 30, 0, time.UTC)) {
+		t.Errorf("Second report starts at %v, want when the first was sent", second.Report.PeriodStart)
+	}
+
+	// Digests missed by more than the catch-up window are skipped
+	clock.now = time.Date(2025, 6, 4, 9, 30, 0, 0, time.UTC)
+	sendDue(0)
+	clock.now = time.Date(2025, 6, 5, 8, 1, 0, 0, time.UTC)
+	sendDue(1)
+
+	// Disabled digests aren't sent
+	digest.Enabled = false
+	if err := s.preferencesRepo.UpsertDigest(ctx, "user-1", digest); err != nil {
+		t.Fatalf("UpsertDigest() error = %v", err)
+	}
+	clock.now = time.Date(2025, 6, 6, 8, 0, 0, 0, time.UTC)
+	sendDue(0)
+}
+
+func TestDigestService_SendDue_UnknownEmailRecipient(t *testing.T) {
+	s, clock, db := newTestDigestService(t, time.Date(2025, 6, 2, 8, 0, 30, 0, time.UTC))
+	ctx := context.Background()
+
+	digest := &models.DigestPreferences{Enabled: true, Schedule: "0 8 * * *", Timezone: "UTC", Email: true, ChannelIDs: []string{"1"}}
+	if err := s.preferencesRepo.UpsertDigest(ctx, "user-1", digest); err != nil {
+		t.Fatalf("UpsertDigest() error = %v", err)
+	}
+	if _, err := db.Exec(`DELETE FROM users WHERE id = 'user-1'`); err != nil {
+		t.Fatalf("Failed to delete user: %v", err)
+	}
+
+	// Without an email recipient nothing is sent, so the channels don't get a digest every minute
+	for i := 0; i < 2; i++ {
+		if sent, err := s.SendDue(ctx); err != nil || sent != 0 {
+			t.Fatalf("SendDue() = %d, %v, want nothing sent", sent, err)
+		}
+		if queued := queuedDigests(s); len(queued) != 0 {
+			t.Fatalf("Expected no channel digest, got %d", len(queued))
+		}
+		clock.now = clock.now.Add(time.Minute)
+	}
+
+	// Once the recipient resolves, the digest is sent once
+	if _, err := db.Exec(`INSERT INTO users (id, email, name, password) VALUES ('user-1', 'user1@example.com', 'User 1', 'hashed')`); err != nil {
+		t.Fatalf("Failed to restore user: %v", err)
+	}
+	for want := 1; want >= 0; want-- {
+		if sent, err := s.SendDue(ctx); err != nil || sent != want {
+			t.Fatalf("SendDue() = %d, %v, want %d sent", sent, err, want)
+		}
+		if queued := queuedDigests(s); len(queued) != want {
+			t.Fatalf("Expected %d channel digests, got %d", want, len(queued))
+		}
+		clock.now = clock.now.Add(time.Minute)
+	}
+}
+
+func ptrTime(t time.Time) *time.Time {
+	return &t
+}
@@ -0,0 +1,414 @@
+package services
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nimbus/backend/internal/models"
+)
+
+// dnsDialTimeout bounds connecting to a custom DNS server
+const dnsDialTimeout = 5 * time.Second
+
+// DNS lookup cache, keyed per resolver so split-horizon answers never leak between resolvers
+type dnsCacheKey struct {
+	resolver string
+	host     string
+}
+
+// DNS lookup cache entry; err is set for negative (NXDOMAIN) entries
+type dnsCacheEntry struct {
+	ips      []net.IP
+	err      error
+	expireAt time.Time
+}
+
+// Global DNS cache; TTLs can be changed with SetDNSCacheTTL
+var (
+	dnsCacheMu          sync.RWMutex
+	dnsCache            = make(map[dnsCacheKey]dnsCacheEntry)
+	dnsCacheTTL         = 5 * time.Minute
+	dnsNegativeCacheTTL = 30 * time.Second
+)
+
+// SetDNSCacheTTL configures how long successful and not-found lookups are cached
+// A zero TTL disables caching for that kind of answer
+func SetDNSCacheTTL(ttl, negativeTTL time.Duration) {
+	dnsCacheMu.Lock()
+	defer dnsCacheMu.Unlock()
+	dnsCacheTTL = ttl
+	dnsNegativeCacheTTL = negativeTTL
+}
+
+// dnsResolver is a resolver together with the key its answers are cached under
+type dnsResolver struct {
+	key      string
+	resolver *net.Resolver
+}
+
+// systemResolver uses the host's resolver configuration (/etc/resolv.conf, /etc/hosts)
+var systemResolver = &dnsResolver{key: "system", resolver: net.DefaultResolver}
+
+// Custom resolvers are shared between checks using the same server
+var (
+	resolversMu sync.Mutex
+	resolvers   = make(map[string]*dnsResolver)
+)
+
+// ValidateDNSConfig checks that a DNS override names a usable server and protocol
+func ValidateDNSConfig(cfg *models.DNSConfig) error {
+	if cfg == nil {
+		return nil
+	}
+
+	switch cfg.Protocol {
+	case "", models.DNSProtocolUDP, models.DNSProtocolTCP, models.DNSProtocolTLS:
+	default:
+		return fmt.Errorf("dns.protocol must be one of udp, tcp, tls")
+	}
+
+	if strings.TrimSpace(cfg.Server) == "" {
+		return fmt.Errorf("dns.server is required")
+	}
+
+	host, _, err := splitDNSServer(cfg.Server)
+	if err != nil {
+		return fmt.Errorf("dns.server is invalid: %w", err)
+	}
+
+	// Plain DNS servers must be IP addresses - resolving the resolver would go through system DNS
+	if cfg.Protocol != models.DNSProtocolTLS && net.ParseIP(host) == nil {
+		return fmt.Errorf("dns.server must be an IP address for udp and tcp")
+	}
+
+	return nil
+}
+
+// splitDNSServer splits "host", "host:port", "ipv6" or "[ipv6]:port" into host and port ("" if omitted)
+func splitDNSServer(server string) (string, string, error) {
+	server = strings.TrimSpace(server)
+	if ip := net.ParseIP(server); ip != nil {
+		return server, "", nil
+	}
+	if strings.HasPrefix(server, "[") && strings.HasSuffix(server, "]") {
+		return strings.Trim(server, "[]"), "", nil
+	}
+	if !strings.Contains(server, ":") {
+		return server, "", nil
+	}
+
+	host, port, err := net.SplitHostPort(server)
+	if err != nil {
+		return "", "", err
+	}
+	if host == "" || port == "" {
+		return "", "", errors.New("missing host or port")
+	}
+	return host, port, nil
+}
+
+// resolverFor returns the shared resolver for a DNS override, or nil if cfg doesn't set one
+// Invalid configs also return nil (falling back to the default resolver) - they are rejected on save
+func resolverFor(cfg *models.DNSConfig) *dnsResolver {
+	if cfg == nil || cfg.Server == "" || ValidateDNSConfig(cfg) != nil {
+		return nil
+	}
+
+	protocol := cfg.Protocol
+	if protocol == "" {
+		protocol = models.DNSProtocolUDP
+	}
+
+	host, port, _ := splitDNSServer(cfg.Server)
+	if port == "" {
+		port = "53"
+		if protocol == models.DNSProtocolTLS {
+			port = "853"
+		}
+	}
+	address := net.JoinHostPort(host, port)
+
+	serverName := cfg.TLSServerName
+	if serverName == "" {
+		serverName = host
+	}
+
+	key := protocol + "://" + address
+	if protocol == models.DNSProtocolTLS {
+		key += "#" + serverName
+	}
+
+	resolversMu.Lock()
+	defer resolversMu.Unlock()
+
+	if r, ok := resolvers[key]; ok {
+		return r
+	}
+
+	r := &dnsResolver{
+		key:      key,
+		resolver: newNetResolver(protocol, address, serverName),
+	}
+	resolvers[key] = r
+	return r
+}
+
+// newNetResolver creates a pure-Go resolver that sends every query to address
+// The Go resolver handles TCP framing itself for stream connections, including TLS ones
+func newNetResolver(protocol, address, serverName string) *net.Resolver {
+	dialer := &net.Dialer{Timeout: dnsDialTimeout}
+
+	return &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			switch protocol {
+			case models.DNSProtocolTCP:
+				return dialer.DialContext(ctx, "tcp", address)
+			case models.DNSProtocolTLS:
+				tlsDialer := &tls.Dialer{
+					NetDialer: dialer,
+					Config: &tls.Config{
+						ServerName: serverName,
+						MinVersion: tls.VersionTLS12,
+					},
+				}
+				return tlsDialer.DialContext(ctx, "tcp", address)
+			default:
+				// network is "udp", or "tcp" when retrying a truncated response
+				return dialer.DialContext(ctx, network, address)
+			}
+		},
+	}
+}
+
+// dnsResolverKey is the context key for the resolver a health check request must use
+type dnsResolverKey struct{}
+
+// withDNSResolver makes requests made with ctx resolve hostnames through r
+func withDNSResolver(ctx context.Context, r *dnsResolver) context.Context {
+	return context.WithValue(ctx, dnsResolverKey{}, r)
+}
+
+// dnsResolverFromContext returns the resolver requested for ctx, or fallback if none was set
+func dnsResolverFromContext(ctx context.Context, fallback *dnsResolver) *dnsResolver {
+	if r, ok := ctx.Value(dnsResolverKey{}).(*dnsResolver); ok && r != nil {
+		return r
+	}
+	if fallback != nil {
+		return fallback
+	}
+	return systemResolver
+}
+
+// lookupIP resolves host through the resolver, using the per-resolver cache
+// Not-found answers are cached for dnsNegativeCacheTTL; temporary failures are never cached
+func (r *dnsResolver) lookupIP(ctx context.Context, host string) ([]net.IP, error) {
+	key := dnsCacheKey{resolver: r.key, host: strings.ToLower(host)}
+
+	dnsCacheMu.RLock()
+	cached, ok := dnsCache[key]
+	ttl, negativeTTL := dnsCacheTTL, dnsNegativeCacheTTL
+	dnsCacheMu.RUnlock()
+	if ok && time.Now().Before(cached.expireAt) {
+		return cached.ips, cached.err
+	}
+
+	ips, err := r.resolver.LookupIP(ctx, "ip", host)
+	if err == nil && len(ips) == 0 {
+		err = &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+	}
+
+	entry := dnsCacheEntry{ips: ips, err: err}
+	switch {
+	case err == nil && ttl > 0:
+		entry.expireAt = time.Now().Add(ttl)
+	case isNotFoundError(err) && negativeTTL > 0:
+		entry.expireAt = time.Now().Add(negativeTTL)
+	default:
+		return ips, err
+	}
+
+	dnsCacheMu.Lock()
+	dnsCache[key] = entry
+	dnsCacheMu.Unlock()
+
+	return ips, err
+}
+
+// isNotFoundError reports whether err is an authoritative "no such host" answer
+func isNotFoundError(err error) bool {
+	var dnsErr *net.DNSError
+	return errors.As(err, &dnsErr) && dnsErr.IsNotFound
+}
+
+// filterIPsByFamily keeps only the addresses of the requested family ("" or auto keeps all)
+func filterIPsByFamily(ips []net.IP, family string) []net.IP {
+	if family != models.AddressFamilyIPv4 && family != models.AddressFamilyIPv6 {
+		return ips
+	}
+
+	filtered := make([]net.IP, 0, len(ips))
+	for _, ip := range ips {
+		if (ip.To4() != nil) == (family == models.AddressFamilyIPv4) {
+			filtered = append(filtered, ip)
+		}
+	}
+	return filtered
+}
+
+// checkDialContext wraps a dialer so it honours the resolver and address family stored in the
+// request context. Hostnames are resolved through the same cache as isLocalURL, so the address
+// used for the TLS decision is the one actually dialed - and the one checked against the egress
+// policy (a nil egress service allows everything)
+func checkDialContext(dialer *net.Dialer, defaultResolver *dnsResolver, egress *EgressPolicyService) func(ctx context.Context, network, addr string) (net.Conn, error) {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		family := addressFamilyFromContext(ctx)
+		network = networkForFamily(network, family)
+
+		host, port, err := net.SplitHostPort(addr)
+		if err != nil {
+			return nil, err
+		}
+
+		var ips []net.IP
+		if ip := net.ParseIP(host); ip != nil {
+			ips = []net.IP{ip}
+		} else {
+			ips, err = dnsResolverFromContext(ctx, defaultResolver).lookupIP(ctx, host)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		ips = filterIPsByFamily(ips, family)
+		if len(ips) == 0 {
+			return nil, fmt.Errorf("dial %s: no %s address found for %s", network, family, host)
+		}
+
+		portNum, err := strconv.Atoi(port)
+		if err != nil {
+			return nil, fmt.Errorf("dial %s: invalid port %q", network, port)
+		}
+		if ips, err = egress.filterAllowed(ctx, host, portNum, ips); err != nil {
+			return nil, err
+		}
+
+		// Race the address families like the standard dialer does (Happy Eyeballs, RFC 6555)
+		primaries, fallbacks := partitionIPsByFamily(ips)
+		if len(fallbacks) == 0 || dialer.FallbackDelay < 0 {
+			return dialSerial(ctx, dialer, network, port, ips)
+		}
+		return dialParallel(ctx, dialer, network, port, primaries, fallbacks)
+	}
+}
+
+// happyEyeballsDelay is how long the first address family gets before the other one is tried
+// alongside it, when the dialer doesn't set a FallbackDelay (the standard library's default)
+const happyEyeballsDelay = 300 * time.Millisecond
+
+// partitionIPsByFamily splits ips into those of the first address's family and the others
+func partitionIPsByFamily(ips []net.IP) (primaries, fallbacks []net.IP) {
+	primaryIPv4 := ips[0].To4() != nil
+	for _, ip := range ips {
+		if (ip.To4() != nil) == primaryIPv4 {
+			primaries = append(primaries, ip)
+		} else {
+			fallbacks = append(fallbacks, ip)
+		}
+	}
+	return primaries, fallbacks
+}
+
+// dialSerial tries each address in order and returns the first connection
+func dialSerial(ctx context.Context, dialer *net.Dialer, network, port string, ips []net.IP) (net.Conn, error) {
+	var lastErr error
+	for _, ip := range ips {
+		conn, err := dialer.DialContext(ctx, network, net.JoinHostPort(ip.String(), port))
+		if err == nil {
+			return conn, nil
+		}
+		lastErr = err
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	return nil, lastErr
+}
+
+// dialParallel dials the primaries, and starts on the fallbacks once the primaries have failed or
+// the fallback delay has passed, whichever comes first. The first connection wins; the other
+// attempt is cancelled and its connection closed
+func dialParallel(ctx context.Context, dialer *net.Dialer, network, port string, primaries, fallbacks []net.IP) (net.Conn, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	type dialResult struct {
+		conn    net.Conn
+		err     error
+		primary bool
+	}
+	results := make(chan dialResult)
+	returned := make(chan struct{})
+	defer close(returned)
+
+	start := func(ips []net.IP, primary bool) {
+		go func() {
+			conn, err := dialSerial(ctx, dialer, network, port, ips)
+			select {
+			case results <- dialResult{conn: conn, err: err, primary: primary}:
+			case <-returned:
+				if conn != nil {
+					conn.Close()
+				}
+			}
+		}()
+	}
+
+	delay := dialer.FallbackDelay
+	if delay == 0 {
+		delay = happyEyeballsDelay
+	}
+	fallbackTimer := time.NewTimer(delay)
+	defer fallbackTimer.Stop()
+
+	start(primaries, true)
+	pending, fallbackStarted := 1, false
+	var primaryErr, fallbackErr error
+	for {
+		select {
+		case <-fallbackTimer.C:
+			if !fallbackStarted {
+				start(fallbacks, false)
+				pending, fallbackStarted = pending+1, true
+			}
+		case res := <-results:
+			if res.err == nil {
+				return res.conn, nil
+			}
+			pending--
+			if res.primary {
+				primaryErr = res.err
+			} else {
+				fallbackErr = res.err
+			}
+			if !fallbackStarted {
+				fallbackTimer.Stop()
+				start(fallbacks, false)
+				pending, fallbackStarted = pending+1, true
+			}
+			if pending == 0 {
+				if primaryErr != nil {
+					return nil, primaryErr
+				}
+				return nil, fallbackErr
+			}
+		}
+	}
+}
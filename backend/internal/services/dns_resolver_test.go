@@ -0,0 +1,364 @@
+package services
+
+import (
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/nimbus/backend/internal/models"
+	"github.com/nimbus/backend/internal/repository"
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// startTestDNSServer runs an authoritative stub DNS server on 127.0.0.1 (UDP and TCP on the same port)
+// Names missing from records get NXDOMAIN. Returns the server address and a query counter
+func startTestDNSServer(t *testing.T, records map[string][]net.IP) (string, *atomic.Int32) {
+	t.Helper()
+
+	var (
+		pc  net.PacketConn
+		ln  net.Listener
+		err error
+	)
+	for attempt := 0; attempt < 10; attempt++ {
+		pc, err = net.ListenPacket("udp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatalf("Failed to listen on UDP: %v", err)
+		}
+		ln, err = net.Listen("tcp", pc.LocalAddr().String())
+		if err == nil {
+			break
+		}
+		pc.Close()
+	}
+	if err != nil {
+		t.Fatalf("Failed to listen on TCP: %v", err)
+	}
+
+	queries := &atomic.Int32{}
+
+	go func() {
+		buf := make([]byte, 512)
+		for {
+			n, addr, err := pc.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			queries.Add(1)
+			if resp := buildTestDNSResponse(buf[:n], records); resp != nil {
+				pc.WriteTo(resp, addr)
+			}
+		}
+	}()
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func(conn net.Conn) {
+				defer conn.Close()
+				for {
+					var length uint16
+					if err := binary.Read(conn, binary.BigEndian, &length); err != nil {
+						return
+					}
+					msg := make([]byte, length)
+					if _, err := io.ReadFull(conn, msg); err != nil {
+						return
+					}
+					queries.Add(1)
+					resp := buildTestDNSResponse(msg, records)
+					if resp == nil {
+						return
+					}
+					binary.Write(conn, binary.BigEndian, uint16(len(resp)))
+					conn.Write(resp)
+				}
+			}(conn)
+		}
+	}()
+
+	t.Cleanup(func() {
+		pc.Close()
+		ln.Close()
+	})
+
+	return pc.LocalAddr().String(), queries
+}
+
+// buildTestDNSResponse answers A/AAAA questions from records
+func buildTestDNSResponse(query []byte, records map[string][]net.IP) []byte {
+	var parser dnsmessage.Parser
+	header, err := parser.Start(query)
+	if err != nil {
+		return nil
+	}
+	question, err := parser.Question()
+	if err != nil {
+		return nil
+	}
+
+	name := strings.TrimSuffix(strings.ToLower(question.Name.String()), ".")
+	ips, found := records[name]
+
+	rcode := dnsmessage.RCodeSuccess
+	if !found {
+		rcode = dnsmessage.RCodeNameError
+	}
+
+	builder := dnsmessage.NewBuilder(nil, dnsmessage.Header{
+		ID:                 header.ID,
+		Response:           true,
+		Authoritative:      true,
+		RecursionDesired:   header.RecursionDesired,
+		RecursionAvailable: true,
+		RCode:              rcode,
+	})
+	builder.StartQuestions()
+	builder.Question(question)
+	builder.StartAnswers()
+
+	for _, ip := range ips {
+		rh := dnsmessage.ResourceHeader{Name: question.Name, Class: dnsmessage.ClassINET, TTL: 60}
+		if ip4 := ip.To4(); ip4 != nil && question.Type == dnsmessage.TypeA {
+			var a [4]byte
+			copy(a[:], ip4)
+			builder.AResource(rh, dnsmessage.AResource{A: a})
+		} else if ip.To4() == nil && question.Type == dnsmessage.TypeAAAA {
+			var aaaa [16]byte
+			copy(aaaa[:], ip.To16())
+			builder.AAAAResource(rh, dnsmessage.AAAAResource{AAAA: aaaa})
+		}
+	}
+
+	resp, err := builder.Finish()
+	if err != nil {
+		return nil
+	}
+	return resp
+}
+
+func TestValidateDNSConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  *models.DNSConfig
+		wantErr bool
+	}{
+		{"No override", nil, false},
+		{"UDP server", &models.DNSConfig{Server: "192.168.1.1"}, false},
+		{"TCP server with port", &models.DNSConfig{Server: "192.168.1.1:5353", Protocol: models.DNSProtocolTCP}, false},
+		{"IPv6 server", &models.DNSConfig{Server: "fd00::53"}, false},
+		{"IPv6 server with port", &models.DNSConfig{Server: "[fd00::53]:5353"}, false},
+		{"DoT hostname", &models.DNSConfig{Server: "dns.quad9.net", Protocol: models.DNSProtocolTLS}, false},
+		{"Missing server", &models.DNSConfig{Protocol: models.DNSProtocolTCP}, true},
+		{"Hostname over UDP", &models.DNSConfig{Server: "dns.lan"}, true},
+		{"Unknown protocol", &models.DNSConfig{Server: "192.168.1.1", Protocol: "https"}, true},
+		{"Invalid port", &models.DNSConfig{Server: "192.168.1.1:"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidateDNSConfig(tt.config); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateDNSConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestResolverFor(t *testing.T) {
+	if resolverFor(nil) != nil {
+		t.Error("Expected no resolver without an override")
+	}
+	if resolverFor(&models.DNSConfig{Server: "dns.lan"}) != nil {
+		t.Error("Expected no resolver for an invalid override")
+	}
+
+	udp := resolverFor(&models.DNSConfig{Server: "192.168.1.1"})
+	if udp == nil || udp.key != "udp://192.168.1.1:53" {
+		t.Fatalf("Unexpected UDP resolver: %+v", udp)
+	}
+	if again := resolverFor(&models.DNSConfig{Server: "192.168.1.1:53", Protocol: models.DNSProtocolUDP}); again != udp {
+		t.Error("Expected equivalent configs to share a resolver")
+	}
+
+	dot := resolverFor(&models.DNSConfig{Server: "9.9.9.9", Protocol: models.DNSProtocolTLS, TLSServerName: "dns.quad9.net"})
+	if dot == nil || dot.key != "tls://9.9.9.9:853#dns.quad9.net" {
+		t.Fatalf("Unexpected DoT resolver: %+v", dot)
+	}
+}
+
+func TestDNSResolver_PerResolverCache(t *testing.T) {
+	// Two resolvers answering the same name differently (split horizon)
+	lanServer, _ := startTestDNSServer(t, map[string][]net.IP{"app.example.com": {net.ParseIP("192.168.1.20")}})
+	wanServer, _ := startTestDNSServer(t, map[string][]net.IP{"app.example.com": {net.ParseIP("203.0.113.20")}})
+
+	lan := resolverFor(&models.DNSConfig{Server: lanServer})
+	wan := resolverFor(&models.DNSConfig{Server: wanServer, Protocol: models.DNSProtocolTCP})
+
+	if !isLocalURLWithResolver(context.Background(), "https://app.example.com", lan) {
+		t.Error("Expected app.example.com to be local via the LAN resolver")
+	}
+	if isLocalURLWithResolver(context.Background(), "https://app.example.com", wan) {
+		t.Error("Expected app.example.com to be public via the WAN resolver - cache must be per resolver")
+	}
+}
+
+func TestDNSResolver_MixedIPs(t *testing.T) {
+	server, _ := startTestDNSServer(t, map[string][]net.IP{
+		"private.lan": {net.ParseIP("192.168.1.10"), net.ParseIP("fd00::10")},
+		"mixed.lan":   {net.ParseIP("192.168.1.10"), net.ParseIP("203.0.113.50")},
+	})
+	resolver := resolverFor(&models.DNSConfig{Server: server})
+
+	if !isLocalURLWithResolver(context.Background(), "https://private.lan", resolver) {
+		t.Error("Expected a name with only private IPs to be local")
+	}
+	// SECURITY: a single public IP must keep TLS verification on
+	if isLocalURLWithResolver(context.Background(), "https://mixed.lan", resolver) {
+		t.Error("Name resolving to private and public IPs must NOT be local - SECURITY VIOLATION")
+	}
+}
+
+func TestDNSResolver_NegativeCache(t *testing.T) {
+	server, queries := startTestDNSServer(t, map[string][]net.IP{})
+	resolver := resolverFor(&models.DNSConfig{Server: server, Protocol: models.DNSProtocolTCP})
+
+	_, err := resolver.lookupIP(context.Background(), "missing.lan")
+	if !isNotFoundError(err) {
+		t.Fatalf("Expected not-found error, got %v", err)
+	}
+
+	before := queries.Load()
+	if _, err := resolver.lookupIP(context.Background(), "missing.lan"); !isNotFoundError(err) {
+		t.Fatalf("Expected cached not-found error, got %v", err)
+	}
+	if queries.Load() != before {
+		t.Error("Expected not-found answer to be served from the negative cache")
+	}
+
+	// With negative caching disabled every lookup goes to the server
+	SetDNSCacheTTL(5*time.Minute, 0)
+	defer SetDNSCacheTTL(5*time.Minute, 30*time.Second)
+
+	if _, err := resolver.lookupIP(context.Background(), "also-missing.lan"); !isNotFoundError(err) {
+		t.Fatalf("Expected not-found error, got %v", err)
+	}
+	before = queries.Load()
+	resolver.lookupIP(context.Background(), "also-missing.lan")
+	if queries.Load() == before {
+		t.Error("Expected lookup to reach the server when negative caching is disabled")
+	}
+}
+
+func TestHealthCheckService_CheckService_CustomDNS(t *testing.T) {
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer testServer.Close()
+
+	_, port, _ := net.SplitHostPort(testServer.Listener.Addr().String())
+	dnsServer, _ := startTestDNSServer(t, map[string][]net.IP{"nas.lan": {net.ParseIP("127.0.0.1")}})
+
+	tests := []struct {
+		name           string
+		globalDNS      *models.DNSConfig
+		serviceDNS     *models.DNSConfig
+		expectedStatus string
+	}{
+		{"Per-service UDP resolver", nil, &models.DNSConfig{Server: dnsServer}, models.StatusOnline},
+		{"Per-service TCP resolver", nil, &models.DNSConfig{Server: dnsServer, Protocol: models.DNSProtocolTCP}, models.StatusOnline},
+		{"Global resolver", &models.DNSConfig{Server: dnsServer}, nil, models.StatusOnline},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := setupFingerprintTestDB(t)
+			defer db.Close()
+
+			mockRepo := &MockServiceRepository{}
+			statusLogRepo := repository.NewStatusLogRepository(db)
+			healthService := NewHealthCheckService(mockRepo, statusLogRepo, nil, 5*time.Second, tt.globalDNS, nil)
+
+			service := &models.Service{
+				ID:          "test-service-id",
+				URL:         "http://nas.lan:" + port + "/",
+				CheckConfig: models.CheckConfig{DNS: tt.serviceDNS},
+			}
+
+			if err := healthService.CheckService(context.Background(), service); err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+
+			if mockRepo.lastStatus != tt.expectedStatus {
+				logs, _ := statusLogRepo.GetLatestByServiceID(context.Background(), service.ID, 1)
+				if len(logs) == 1 {
+					t.Logf("Status log error: %s", derefString(logs[0].ErrorMessage))
+				}
+				t.Errorf("Expected status %q, got %q", tt.expectedStatus, mockRepo.lastStatus)
+			}
+		})
+	}
+}
+
+func TestCheckDialContext_HappyEyeballs(t *testing.T) {
+	ln, err := net.Listen("tcp", "[::1]:0")
+	if err != nil {
+		t.Skipf("IPv6 loopback unavailable: %v", err)
+	}
+	defer ln.Close()
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			conn.Close()
+		}
+	}()
+	_, port, _ := net.SplitHostPort(ln.Addr().String())
+
+	// The unroutable IPv4 address comes first, as it would after a stale or misconfigured A record
+	resolver := &dnsResolver{key: "happy-eyeballs-test"}
+	dnsCacheMu.Lock()
+	dnsCache[dnsCacheKey{resolver: resolver.key, host: "dual.test"}] = dnsCacheEntry{
+		ips:      []net.IP{net.ParseIP("192.0.2.1"), net.ParseIP("::1")},
+		expireAt: time.Now().Add(time.Minute),
+	}
+	dnsCacheMu.Unlock()
+
+	dialer := &net.Dialer{
+		Timeout: 10 * time.Second,
+		// Blackhole the unroutable address whatever the sandbox's routing does with it
+		ControlContext: func(ctx context.Context, network, address string, _ syscall.RawConn) error {
+			if strings.HasPrefix(address, "192.0.2.1:") {
+				<-ctx.Done()
+				return ctx.Err()
+			}
+			return nil
+		},
+	}
+
+	start := time.Now()
+	conn, err := checkDialContext(dialer, resolver, nil)(context.Background(), "tcp", net.JoinHostPort("dual.test", port))
+	if err != nil {
+		t.Fatalf("Expected the IPv6 fallback to connect, got %v", err)
+	}
+	defer conn.Close()
+
+	if remote := conn.RemoteAddr().(*net.TCPAddr); !remote.IP.Equal(net.ParseIP("::1")) {
+		t.Errorf("Expected a connection to ::1, got %s", remote)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("Expected the fallback after about %v, took %v", happyEyeballsDelay, elapsed)
+	}
+}
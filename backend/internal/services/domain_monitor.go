@@ -0,0 +1,527 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"net"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/nimbus/backend/internal/models"
+	"github.com/nimbus/backend/internal/repository"
+	"golang.org/x/net/idna"
+	"golang.org/x/net/publicsuffix"
+)
+
+const (
+	// DefaultRDAPBaseURL is the IANA bootstrap redirector, which forwards to the authoritative registry
+	DefaultRDAPBaseURL = "https://rdap.org"
+
+	// domainCacheTTL is how long a successful RDAP lookup is reused
+	domainCacheTTL = 24 * time.Hour
+
+	// domainErrorCacheTTL is how long a failed lookup is cached before retrying
+	domainErrorCacheTTL = 1 * time.Hour
+
+	// maxRDAPResponseSize limits how much of an RDAP response is read
+	maxRDAPResponseSize = 1 << 20 // 1 MiB
+)
+
+var (
+	// ErrInvalidDomain is returned when a domain has no registrable (ICANN) suffix
+	ErrInvalidDomain = errors.New("not a registrable domain")
+
+	// ErrDomainAlreadyMonitored is returned when a user adds a domain twice
+	ErrDomainAlreadyMonitored = errors.New("domain is already monitored")
+)
+
+// DomainMonitorService tracks registration expiry for service and manually listed domains
+type DomainMonitorService struct {
+	domainRepo   *repository.DomainRepository
+	serviceRepo  repository.ServiceRepositoryInterface
+	rdapBaseURL  string
+	warningDays  int
+	criticalDays int
+	httpClient   *http.Client
+	refresh      chan struct{} // Signals the worker that domains are missing or stale
+}
+
+// NewDomainMonitorService creates a new domain monitor service
+// warningDays and criticalDays are the thresholds (days before expiry) for the warning and critical states
+func NewDomainMonitorService(domainRepo *repository.DomainRepository, serviceRepo repository.ServiceRepositoryInterface, rdapBaseURL string, warningDays, criticalDays int) *DomainMonitorService {
+	if rdapBaseURL == "" {
+		rdapBaseURL = DefaultRDAPBaseURL
+	}
+	if criticalDays > warningDays {
+		criticalDays = warningDays
+	}
+
+	return &DomainMonitorService{
+		domainRepo:   domainRepo,
+		serviceRepo:  serviceRepo,
+		rdapBaseURL:  strings.TrimRight(rdapBaseURL, "/"),
+		warningDays:  warningDays,
+		criticalDays: criticalDays,
+		httpClient: &http.Client{
+			Timeout: 15 * time.Second,
+		},
+		refresh: make(chan struct{}, 1),
+	}
+}
+
+// RefreshRequested is signalled when a request found a domain without fresh registration data
+// The worker refreshes on it instead of waiting for its next run
+func (d *DomainMonitorService) RefreshRequested() <-chan struct{} {
+	return d.refresh
+}
+
+// requestRefresh signals the worker without blocking; pending requests are coalesced
+func (d *DomainMonitorService) requestRefresh() {
+	select {
+	case d.refresh <- struct{}{}:
+	default:
+	}
+}
+
+// WarningDays returns the warning threshold in days
+func (d *DomainMonitorService) WarningDays() int {
+	return d.warningDays
+}
+
+// CriticalDays returns the critical threshold in days
+func (d *DomainMonitorService) CriticalDays() int {
+	return d.criticalDays
+}
+
+// NormalizeDomain reduces a hostname to its registrable domain (eTLD+1)
+// e.g. "Cloud.Example.co.uk." -> "example.co.uk"
+// Returns ErrInvalidDomain for IP addresses, single-label hosts and non-ICANN TLDs (.lan, .local, .home.arpa)
+func NormalizeDomain(host string) (string, error) {
+	host = strings.TrimSuffix(strings.ToLower(strings.TrimSpace(host)), ".")
+	if host == "" || net.ParseIP(host) != nil || !strings.Contains(host, ".") {
+		return "", ErrInvalidDomain
+	}
+
+	ascii, err := idna.Lookup.ToASCII(host)
+	if err != nil {
+		return "", ErrInvalidDomain
+	}
+
+	// Only ICANN TLDs have registries to query; private and unlisted suffixes are skipped
+	tld := ascii[strings.LastIndex(ascii, ".")+1:]
+	if _, icann := publicsuffix.PublicSuffix(tld); !icann {
+		return "", ErrInvalidDomain
+	}
+
+	domain, err := publicsuffix.EffectiveTLDPlusOne(ascii)
+	if err != nil {
+		return "", ErrInvalidDomain
+	}
+
+	return domain, nil
+}
+
+// domainFromServiceURL returns the registrable domain of a service URL, or "" if it has none
+func domainFromServiceURL(rawURL string) string {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+
+	domain, err := NormalizeDomain(parsed.Hostname())
+	if err != nil {
+		return ""
+	}
+
+	return domain
+}
+
+// GetDomainStatuses returns the expiry status of every domain a user monitors, soonest expiry first
+// Only cached registration data is used: missing entries are pending and old ones are marked stale
+// until the worker refreshes them
+func (d *DomainMonitorService) GetDomainStatuses(ctx context.Context, userID string) ([]*models.DomainStatus, error) {
+	services, err := d.serviceRepo.GetAllByUserID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get services: %w", err)
+	}
+
+	monitored, err := d.domainRepo.GetMonitoredByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make(map[string]*models.DomainStatus)
+	getStatus := func(domain string) *models.DomainStatus {
+		status, ok := statuses[domain]
+		if !ok {
+			status = &models.DomainStatus{Domain: domain, Sources: []string{}}
+			statuses[domain] = status
+		}
+		return status
+	}
+
+	for _, service := range services {
+		domain := domainFromServiceURL(service.URL)
+		if domain == "" {
+			continue
+		}
+		status := getStatus(domain)
+		if len(status.ServiceIDs) == 0 {
+			status.Sources = append(status.Sources, models.DomainSourceService)
+		}
+		status.ServiceIDs = append(status.ServiceIDs, service.ID)
+	}
+
+	for _, m := range monitored {
+		status := getStatus(m.Domain)
+		status.Sources = append(status.Sources, models.DomainSourceManual)
+		id := m.ID
+		status.MonitoredID = &id
+	}
+
+	now := time.Now()
+	result := make([]*models.DomainStatus, 0, len(statuses))
+	for _, status := range statuses {
+		if err := d.applyCachedRegistration(ctx, status, now); err != nil {
+			return nil, err
+		}
+		result = append(result, status)
+	}
+
+	sortDomainStatuses(result)
+	return result, nil
+}
+
+// AddMonitoredDomain adds a domain to a user's monitored list and returns its cached status
+// A domain not looked up yet is pending until the worker has queried RDAP
+func (d *DomainMonitorService) AddMonitoredDomain(ctx context.Context, userID, host string) (*models.DomainStatus, error) {
+	domain, err := NormalizeDomain(host)
+	if err != nil {
+		return nil, err
+	}
+
+	existing, err := d.domainRepo.GetMonitoredByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range existing {
+		if m.Domain == domain {
+			return nil, ErrDomainAlreadyMonitored
+		}
+	}
+
+	monitored := &models.MonitoredDomain{
+		UserID:    userID,
+		Domain:    domain,
+		CreatedAt: time.Now(),
+	}
+	if err := d.domainRepo.CreateMonitored(ctx, monitored); err != nil {
+		return nil, err
+	}
+
+	status := &models.DomainStatus{
+		Domain:      domain,
+		Sources:     []string{models.DomainSourceManual},
+		MonitoredID: &monitored.ID,
+	}
+	if err := d.applyCachedRegistration(ctx, status, time.Now()); err != nil {
+		return nil, err
+	}
+
+	return status, nil
+}
+
+// DeleteMonitoredDomain removes a manually added domain
+// Returns sql.ErrNoRows if it doesn't exist or belongs to another user
+func (d *DomainMonitorService) DeleteMonitoredDomain(ctx context.Context, id, userID string) error {
+	return d.domainRepo.DeleteMonitored(ctx, id, userID)
+}
+
+// RefreshAll refreshes stale registration data for every monitored domain across all users
+// Returns the number of domains looked up
+func (d *DomainMonitorService) RefreshAll(ctx context.Context) (int, error) {
+	services, err := d.serviceRepo.GetAll(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get services: %w", err)
+	}
+
+	monitored, err := d.domainRepo.GetAllMonitored(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	domains := make(map[string]struct{})
+	for _, service := range services {
+		if domain := domainFromServiceURL(service.URL); domain != "" {
+			domains[domain] = struct{}{}
+		}
+	}
+	for _, m := range monitored {
+		domains[m.Domain] = struct{}{}
+	}
+
+	refreshed := 0
+	now := time.Now()
+	for domain := range domains {
+		if ctx.Err() != nil {
+			return refreshed, ctx.Err()
+		}
+
+		cached, err := d.domainRepo.GetRegistration(ctx, domain)
+		if err == nil && isRegistrationFresh(cached, now) {
+			continue
+		}
+
+		if _, err := d.refreshRegistration(ctx, domain, cached, now); err != nil {
+			log.Printf("Domain monitor: failed to refresh %s: %v", domain, err)
+			continue
+		}
+		refreshed++
+	}
+
+	return refreshed, nil
+}
+
+// applyCachedRegistration fills a domain status from the cached registration data, and asks the
+// worker for a refresh if there is none or it is stale
+func (d *DomainMonitorService) applyCachedRegistration(ctx context.Context, status *models.DomainStatus, now time.Time) error {
+	cached, err := d.domainRepo.GetRegistration(ctx, status.Domain)
+	if errors.Is(err, sql.ErrNoRows) {
+		status.State = models.DomainStatePending
+		d.requestRefresh()
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	d.applyRegistration(status, cached, now)
+	if !isRegistrationFresh(cached, now) {
+		status.Stale = true
+		d.requestRefresh()
+	}
+	return nil
+}
+
+// refreshRegistration performs an RDAP lookup and stores the result
+// On lookup failure, previously known expiry data is kept and the error is recorded
+func (d *DomainMonitorService) refreshRegistration(ctx context.Context, domain string, cached *models.DomainRegistration, now time.Time) (*models.DomainRegistration, error) {
+	reg := &models.DomainRegistration{
+		Domain:        domain,
+		LastCheckedAt: now,
+	}
+
+	expiresAt, registrar, lookupErr := d.lookupRDAP(ctx, domain)
+	if lookupErr != nil {
+		if cached != nil {
+			reg.ExpiresAt = cached.ExpiresAt
+			reg.Registrar = cached.Registrar
+		}
+		errMsg := lookupErr.Error()
+		reg.LastError = &errMsg
+	} else {
+		reg.ExpiresAt = expiresAt
+		reg.Registrar = registrar
+	}
+
+	if err := d.domainRepo.UpsertRegistration(ctx, reg); err != nil {
+		return reg, err
+	}
+
+	return reg, nil
+}
+
+// isRegistrationFresh reports whether a cached lookup can be reused
+func isRegistrationFresh(reg *models.DomainRegistration, now time.Time) bool {
+	ttl := domainCacheTTL
+	if reg.LastError != nil {
+		ttl = domainErrorCacheTTL
+	}
+	return now.Sub(reg.LastCheckedAt) < ttl
+}
+
+// applyRegistration fills a domain status from registration data and computes its state
+func (d *DomainMonitorService) applyRegistration(status *models.DomainStatus, reg *models.DomainRegistration, now time.Time) {
+	status.State = models.DomainStateUnknown
+	if reg == nil {
+		return
+	}
+
+	checkedAt := reg.LastCheckedAt
+	status.LastCheckedAt = &checkedAt
+	status.Registrar = reg.Registrar
+	status.Error = reg.LastError
+
+	if reg.ExpiresAt != nil {
+		expiresAt := *reg.ExpiresAt
+		status.ExpiresAt = &expiresAt
+		state, days := computeDomainState(expiresAt, now, d.warningDays, d.criticalDays)
+		status.State = state
+		status.DaysRemaining = &days
+	}
+}
+
+// computeDomainState returns the expiry state and whole days remaining until expiresAt
+func computeDomainState(expiresAt, now time.Time, warningDays, criticalDays int) (string, int) {
+	days := int(math.Floor(expiresAt.Sub(now).Hours() / 24))
+
+	switch {
+	case !expiresAt.After(now):
+		return models.DomainStateExpired, days
+	case days < criticalDays:
+		return models.DomainStateCritical, days
+	case days < warningDays:
+		return models.DomainStateWarning, days
+	default:
+		return models.DomainStateOK, days
+	}
+}
+
+// sortDomainStatuses orders domains by soonest expiry, with unknown expiry last
+func sortDomainStatuses(statuses []*models.DomainStatus) {
+	sort.Slice(statuses, func(i, j int) bool {
+		a, b := statuses[i].ExpiresAt, statuses[j].ExpiresAt
+		if a == nil || b == nil {
+			if a == nil && b == nil {
+				return statuses[i].Domain < statuses[j].Domain
+			}
+			return b == nil
+		}
+		if !a.Equal(*b) {
+			return a.Before(*b)
+		}
+		return statuses[i].Domain < statuses[j].Domain
+	})
+}
+
+// rdapDomain is the subset of an RDAP domain response (RFC 9083) used for expiry monitoring
+type rdapDomain struct {
+	Events   []rdapEvent  `json:"events"`
+	Entities []rdapEntity `json:"entities"`
+}
+
+type rdapEvent struct {
+	EventAction string `json:"eventAction"`
+	EventDate   string `json:"eventDate"`
+}
+
+type rdapEntity struct {
+	Roles      []string          `json:"roles"`
+	VCardArray []json.RawMessage `json:"vcardArray"`
+	Entities   []rdapEntity      `json:"entities"`
+}
+
+// lookupRDAP queries RDAP for a domain's expiration date and registrar name
+func (d *DomainMonitorService) lookupRDAP(ctx context.Context, domain string) (*time.Time, *string, error) {
+	reqCtx, cancel := context.WithTimeout(ctx, 15*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(reqCtx, http.MethodGet, d.rdapBaseURL+"/domain/"+url.PathEscape(domain), nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/rdap+json, application/json")
+	req.Header.Set("User-Agent", "Nimbus-DomainMonitor/1.0")
+
+	resp, err := d.httpClient.Do(req)
+	if err != nil {
+		return nil, nil, fmt.Errorf("RDAP request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil, errors.New("domain not found in RDAP")
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, nil, fmt.Errorf("RDAP server returned HTTP %d", resp.StatusCode)
+	}
+
+	var data rdapDomain
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxRDAPResponseSize)).Decode(&data); err != nil {
+		return nil, nil, fmt.Errorf("invalid RDAP response: %w", err)
+	}
+
+	expiresAt, registrar := parseRDAPDomain(&data)
+	return expiresAt, registrar, nil
+}
+
+// parseRDAPDomain extracts the expiration event and registrar name from an RDAP response
+func parseRDAPDomain(data *rdapDomain) (*time.Time, *string) {
+	var expiresAt *time.Time
+	for _, event := range data.Events {
+		if !strings.EqualFold(event.EventAction, "expiration") {
+			continue
+		}
+		if t, ok := parseRDAPDate(event.EventDate); ok {
+			expiresAt = &t
+			break
+		}
+	}
+
+	var registrar *string
+	if name := findRegistrarName(data.Entities); name != "" {
+		registrar = &name
+	}
+
+	return expiresAt, registrar
+}
+
+// parseRDAPDate parses an RDAP event date; some registries omit the timezone, which is treated as UTC
+func parseRDAPDate(value string) (time.Time, bool) {
+	for _, layout := range []string{time.RFC3339Nano, "2006-01-02T15:04:05", "2006-01-02"} {
+		if t, err := time.Parse(layout, strings.TrimSpace(value)); err == nil {
+			return t.UTC(), true
+		}
+	}
+	return time.Time{}, false
+}
+
+// findRegistrarName returns the "fn" vCard property of the first entity with the registrar role
+func findRegistrarName(entities []rdapEntity) string {
+	for _, entity := range entities {
+		for _, role := range entity.Roles {
+			if strings.EqualFold(role, "registrar") {
+				if name := vcardFullName(entity.VCardArray); name != "" {
+					return name
+				}
+			}
+		}
+		if name := findRegistrarName(entity.Entities); name != "" {
+			return name
+		}
+	}
+	return ""
+}
+
+// vcardFullName extracts the "fn" property from a jCard array: ["vcard", [["fn", {}, "text", "Name"], ...]]
+func vcardFullName(vcard []json.RawMessage) string {
+	if len(vcard) < 2 {
+		return ""
+	}
+
+	var properties [][]interface{}
+	if err := json.Unmarshal(vcard[1], &properties); err != nil {
+		return ""
+	}
+
+	for _, prop := range properties {
+		if len(prop) < 4 {
+			continue
+		}
+		if name, ok := prop[0].(string); ok && name == "fn" {
+			if value, ok := prop[3].(string); ok {
+				return strings.TrimSpace(value)
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,356 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/nimbus/backend/internal/models"
+	"github.com/nimbus/backend/internal/repository"
+)
+
+// setupDomainTestDB creates an in-memory SQLite database with the tables used by domain monitoring
+func setupDomainTestDB(t *testing.T) *sql.DB {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("Failed to create test database: %v", err)
+	}
+
+	_, err = db.Exec(`
+		CREATE TABLE services (
+			id TEXT PRIMARY KEY,
+			user_id TEXT NOT NULL,
+			name TEXT NOT NULL,
+			url TEXT NOT NULL,
+			icon TEXT DEFAULT '',
+			icon_type TEXT DEFAULT 'emoji',
+			icon_image_path TEXT DEFAULT '',
+			description TEXT,
+			status TEXT DEFAULT 'unknown',
+			response_time INTEGER,
+			position INTEGER DEFAULT 0,
+			check_config TEXT NOT NULL DEFAULT '{}',
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);
+
+		CREATE TABLE monitored_domains (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			user_id TEXT NOT NULL,
+			domain TEXT NOT NULL,
+			created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			UNIQUE (user_id, domain)
+		);
+
+		CREATE TABLE domain_registrations (
+			domain TEXT PRIMARY KEY,
+			expires_at TIMESTAMP,
+			registrar TEXT,
+			last_checked_at TIMESTAMP NOT NULL,
+			last_error TEXT
+		);
+	`)
+	if err != nil {
+		t.Fatalf("Failed to create tables: %v", err)
+	}
+
+	return db
+}
+
+// newRDAPStub returns a stub RDAP server that knows the given domain -> expiration dates
+func newRDAPStub(expirations map[string]time.Time, requests *int32) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(requests, 1)
+
+		domain := strings.TrimPrefix(r.URL.Path, "/domain/")
+		expiresAt, ok := expirations[domain]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/rdap+json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"objectClassName": "domain",
+			"ldhName":         domain,
+			"events": []map[string]string{
+				{"eventAction": "registration", "eventDate": "2015-01-01T00:00:00Z"},
+				{"eventAction": "expiration", "eventDate": expiresAt.Format(time.RFC3339)},
+			},
+			"entities": []map[string]interface{}{
+				{
+					"objectClassName": "entity",
+					"roles":           []string{"registrar"},
+					"vcardArray": []interface{}{
+						"vcard",
+						[]interface{}{
+							[]interface{}{"version", map[string]string{}, "text", "4.0"},
+							[]interface{}{"fn", map[string]string{}, "text", "Example Registrar, Inc."},
+						},
+					},
+				},
+			},
+		})
+	}))
+}
+
+func TestNormalizeDomain(t *testing.T) {
+	tests := []struct {
+		host     string
+		expected string
+		wantErr  bool
+	}{
+		{"example.com", "example.com", false},
+		{"Cloud.Example.COM.", "example.com", false},
+		{"nas.home.example.co.uk", "example.co.uk", false},
+		{"bücher.de", "xn--bcher-kva.de", false},
+		{"localhost", "", true},
+		{"192.168.1.10", "", true},
+		{"::1", "", true},
+		{"nas.lan", "", true},
+		{"printer.local", "", true},
+		{"co.uk", "", true},
+		{"", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.host, func(t *testing.T) {
+			result, err := NormalizeDomain(tt.host)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("NormalizeDomain(%q) = %q, expected error", tt.host, result)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("NormalizeDomain(%q) unexpected error: %v", tt.host, err)
+			}
+			if result != tt.expected {
+				t.Errorf("NormalizeDomain(%q) = %q, expected %q", tt.host, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestComputeDomainState(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name         string
+		expiresAt    time.Time
+		expectedDays int
+		expected     string
+	}{
+		{"Far future", now.AddDate(1, 0, 0), 365, models.DomainStateOK},
+		{"Exactly at warning threshold", now.AddDate(0, 0, 30), 30, models.DomainStateOK},
+		{"Inside warning threshold", now.AddDate(0, 0, 29), 29, models.DomainStateWarning},
+		{"Inside critical threshold", now.AddDate(0, 0, 3), 3, models.DomainStateCritical},
+		{"Expires later today", now.Add(2 * time.Hour), 0, models.DomainStateCritical},
+		{"Already expired", now.AddDate(0, 0, -2), -2, models.DomainStateExpired},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			state, days := computeDomainState(tt.expiresAt, now, 30, 7)
+			if state != tt.expected {
+				t.Errorf("Expected state %q, got %q", tt.expected, state)
+			}
+			if days != tt.expectedDays {
+				t.Errorf("Expected %d days remaining, got %d", tt.expectedDays, days)
+			}
+		})
+	}
+}
+
+func TestParseRDAPDate(t *testing.T) {
+	tests := []struct {
+		value string
+		ok    bool
+	}{
+		{"2026-03-15T04:00:00Z", true},
+		{"2026-03-15T04:00:00.123Z", true},
+		{"2026-03-15T04:00:00+02:00", true},
+		{"2026-03-15T04:00:00", true},
+		{"2026-03-15", true},
+		{"not a date", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			_, ok := parseRDAPDate(tt.value)
+			if ok != tt.ok {
+				t.Errorf("parseRDAPDate(%q) ok = %v, expected %v", tt.value, ok, tt.ok)
+			}
+		})
+	}
+}
+
+func TestDomainMonitorService_GetDomainStatuses(t *testing.T) {
+	db := setupDomainTestDB(t)
+	defer db.Close()
+
+	now := time.Now()
+	var requests int32
+	stub := newRDAPStub(map[string]time.Time{
+		"example.com": now.AddDate(0, 0, 200),
+		"example.org": now.AddDate(0, 0, 10),
+	}, &requests)
+	defer stub.Close()
+
+	// Two services on the same registrable domain, one local service, one on another domain
+	_, err := db.Exec(`
+		INSERT INTO services (id, user_id, name, url, description) VALUES
+			('svc-1', 'user-1', 'Cloud', 'https://cloud.example.com', ''),
+			('svc-2', 'user-1', 'Photos', 'https://photos.example.com:8443/app', ''),
+			('svc-3', 'user-1', 'NAS', 'http://192.168.1.10:5000', ''),
+			('svc-4', 'user-2', 'Other', 'https://other.example.net', '')
+	`)
+	if err != nil {
+		t.Fatalf("Failed to insert services: %v", err)
+	}
+
+	domainRepo := repository.NewDomainRepository(db)
+	domainService := NewDomainMonitorService(domainRepo, repository.NewServiceRepository(db), stub.URL+"/", 30, 7)
+
+	ctx := context.Background()
+	if _, err := domainService.AddMonitoredDomain(ctx, "user-1", "www.example.org"); err != nil {
+		t.Fatalf("Failed to add monitored domain: %v", err)
+	}
+	if _, err := domainService.AddMonitoredDomain(ctx, "user-1", "example.org"); err != ErrDomainAlreadyMonitored {
+		t.Errorf("Expected ErrDomainAlreadyMonitored, got %v", err)
+	}
+	if _, err := domainService.AddMonitoredDomain(ctx, "user-1", "nas.lan"); err != ErrInvalidDomain {
+		t.Errorf("Expected ErrInvalidDomain, got %v", err)
+	}
+
+	// Requests only read the cache: nothing is looked up yet, the worker is asked to
+	statuses, err := domainService.GetDomainStatuses(ctx, "user-1")
+	if err != nil {
+		t.Fatalf("GetDomainStatuses failed: %v", err)
+	}
+	if atomic.LoadInt32(&requests) != 0 {
+		t.Errorf("Expected no RDAP lookups during the request, got %d", requests)
+	}
+	for _, status := range statuses {
+		if status.State != models.DomainStatePending {
+			t.Errorf("Expected %s to be pending, got %q", status.Domain, status.State)
+		}
+	}
+	select {
+	case <-domainService.RefreshRequested():
+	default:
+		t.Error("Expected a refresh to be requested")
+	}
+
+	if refreshed, err := domainService.RefreshAll(ctx); err != nil || refreshed != 3 {
+		t.Fatalf("RefreshAll() = %d, %v, want 3 domains looked up (every user's)", refreshed, err)
+	}
+
+	statuses, err = domainService.GetDomainStatuses(ctx, "user-1")
+	if err != nil {
+		t.Fatalf("GetDomainStatuses failed: %v", err)
+	}
+
+	if len(statuses) != 2 {
+		t.Fatalf("Expected 2 domains, got %d", len(statuses))
+	}
+
+	// Soonest expiry first
+	org, com := statuses[0], statuses[1]
+	if org.Domain != "example.org" || com.Domain != "example.com" {
+		t.Fatalf("Unexpected order: %s, %s", org.Domain, com.Domain)
+	}
+
+	if org.State != models.DomainStateWarning {
+		t.Errorf("Expected example.org to be in warning state, got %q", org.State)
+	}
+	if org.MonitoredID == nil {
+		t.Error("Expected example.org to have a monitored ID")
+	}
+	if org.Registrar == nil || *org.Registrar != "Example Registrar, Inc." {
+		t.Errorf("Expected registrar to be parsed, got %v", org.Registrar)
+	}
+
+	if com.State != models.DomainStateOK {
+		t.Errorf("Expected example.com to be ok, got %q", com.State)
+	}
+	if len(com.ServiceIDs) != 2 {
+		t.Errorf("Expected 2 services for example.com, got %v", com.ServiceIDs)
+	}
+	if len(com.Sources) != 1 || com.Sources[0] != models.DomainSourceService {
+		t.Errorf("Expected sources [service], got %v", com.Sources)
+	}
+
+	// Fresh entries are not looked up again
+	before := atomic.LoadInt32(&requests)
+	if _, err := domainService.RefreshAll(ctx); err != nil {
+		t.Fatalf("RefreshAll failed: %v", err)
+	}
+	if after := atomic.LoadInt32(&requests); after != before {
+		t.Errorf("Expected cached lookups, but RDAP was queried %d more times", after-before)
+	}
+}
+
+func TestDomainMonitorService_LookupFailureKeepsPreviousData(t *testing.T) {
+	db := setupDomainTestDB(t)
+	defer db.Close()
+
+	var requests int32
+	stub := newRDAPStub(map[string]time.Time{}, &requests)
+	defer stub.Close()
+
+	domainRepo := repository.NewDomainRepository(db)
+	domainService := NewDomainMonitorService(domainRepo, repository.NewServiceRepository(db), stub.URL, 30, 7)
+
+	// Stale cache entry with known expiry
+	ctx := context.Background()
+	expiresAt := time.Now().AddDate(0, 3, 0).UTC().Truncate(time.Second)
+	err := domainRepo.UpsertRegistration(ctx, &models.DomainRegistration{
+		Domain:        "example.com",
+		ExpiresAt:     &expiresAt,
+		LastCheckedAt: time.Now().Add(-48 * time.Hour),
+	})
+	if err != nil {
+		t.Fatalf("Failed to seed registration: %v", err)
+	}
+
+	status, err := domainService.AddMonitoredDomain(ctx, "user-1", "example.com")
+	if err != nil {
+		t.Fatalf("Failed to add monitored domain: %v", err)
+	}
+	if !status.Stale || atomic.LoadInt32(&requests) != 0 {
+		t.Errorf("Expected the stale entry to be served as stale without a lookup, got stale=%v after %d RDAP requests", status.Stale, requests)
+	}
+
+	if _, err := domainService.RefreshAll(ctx); err != nil {
+		t.Fatalf("RefreshAll failed: %v", err)
+	}
+	if atomic.LoadInt32(&requests) != 1 {
+		t.Errorf("Expected stale entry to be refreshed, got %d RDAP requests", requests)
+	}
+
+	statuses, err := domainService.GetDomainStatuses(ctx, "user-1")
+	if err != nil || len(statuses) != 1 {
+		t.Fatalf("GetDomainStatuses() = %v, %v, want 1 domain", statuses, err)
+	}
+	status = statuses[0]
+	if status.Stale {
+		t.Error("Expected the refreshed entry not to be stale")
+	}
+	if status.Error == nil || !strings.Contains(*status.Error, "not found") {
+		t.Errorf("Expected lookup error to be reported, got %v", status.Error)
+	}
+	if status.ExpiresAt == nil || !status.ExpiresAt.Equal(expiresAt) {
+		t.Errorf("Expected previous expiry %v to be kept, got %v", expiresAt, status.ExpiresAt)
+	}
+	if status.State != models.DomainStateOK {
+		t.Errorf("Expected state ok from previous data, got %q", status.State)
+	}
+}
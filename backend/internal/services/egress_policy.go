@@ -0,0 +1,504 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nimbus/backend/internal/models"
+	"github.com/nimbus/backend/internal/repository"
+)
+
+// egressLogInterval limits how often the same blocked destination is written to the activity log
+// Health checks retry every interval, so logging every attempt would flood the log
+const egressLogInterval = 15 * time.Minute
+
+// Egress policy errors
+var (
+	ErrEgressBlocked       = errors.New("blocked by egress policy")
+	ErrInvalidEgressPolicy = errors.New("invalid egress policy")
+)
+
+// EgressBlockedError describes a connection refused by the egress policy
+type EgressBlockedError struct {
+	Host string
+	IP   net.IP
+	Port int
+	Rule string // The rule that blocked the connection, e.g. "deny_cidrs 10.0.0.0/8"
+}
+
+func (e *EgressBlockedError) Error() string {
+	return fmt.Sprintf("connection to %s (%s) blocked by egress policy: %s",
+		net.JoinHostPort(e.Host, strconv.Itoa(e.Port)), e.IP, e.Rule)
+}
+
+// Unwrap lets callers match any block with errors.Is(err, ErrEgressBlocked)
+func (e *EgressBlockedError) Unwrap() error {
+	return ErrEgressBlocked
+}
+
+// portRange is an inclusive range of ports
+type portRange struct {
+	from, to int
+}
+
+func (r portRange) String() string {
+	if r.from == r.to {
+		return strconv.Itoa(r.from)
+	}
+	return fmt.Sprintf("%d-%d", r.from, r.to)
+}
+
+// compiledEgressPolicy is an EgressPolicy parsed for fast evaluation
+type compiledEgressPolicy struct {
+	defaultDeny bool
+	allowNets   []*net.IPNet
+	denyNets    []*net.IPNet
+	allowPorts  []portRange
+	denyPorts   []portRange
+	allowHosts  []string
+	denyHosts   []string
+}
+
+// ValidateEgressPolicy checks that every rule in the policy can be parsed
+func ValidateEgressPolicy(policy models.EgressPolicy) error {
+	_, err := compileEgressPolicy(policy)
+	return err
+}
+
+// compileEgressPolicy parses and validates a policy
+func compileEgressPolicy(policy models.EgressPolicy) (*compiledEgressPolicy, error) {
+	compiled := &compiledEgressPolicy{}
+
+	switch policy.DefaultAction {
+	case "", models.EgressActionAllow:
+	case models.EgressActionDeny:
+		compiled.defaultDeny = true
+	default:
+		return nil, fmt.Errorf("default_action must be '%s' or '%s'", models.EgressActionAllow, models.EgressActionDeny)
+	}
+
+	var err error
+	if compiled.allowNets, err = parseCIDRs("allow_cidrs", policy.AllowCIDRs); err != nil {
+		return nil, err
+	}
+	if compiled.denyNets, err = parseCIDRs("deny_cidrs", policy.DenyCIDRs); err != nil {
+		return nil, err
+	}
+	if compiled.allowPorts, err = parsePortRanges("allow_ports", policy.AllowPorts); err != nil {
+		return nil, err
+	}
+	if compiled.denyPorts, err = parsePortRanges("deny_ports", policy.DenyPorts); err != nil {
+		return nil, err
+	}
+	if compiled.allowHosts, err = parseHostPatterns("allow_hosts", policy.AllowHosts); err != nil {
+		return nil, err
+	}
+	if compiled.denyHosts, err = parseHostPatterns("deny_hosts", policy.DenyHosts); err != nil {
+		return nil, err
+	}
+
+	return compiled, nil
+}
+
+// parseCIDRs parses CIDR blocks; a bare IP is treated as a single-address block
+func parseCIDRs(field string, values []string) ([]*net.IPNet, error) {
+	nets := make([]*net.IPNet, 0, len(values))
+	for _, value := range values {
+		value = strings.TrimSpace(value)
+		if !strings.Contains(value, "/") {
+			ip := net.ParseIP(value)
+			if ip == nil {
+				return nil, fmt.Errorf("%s contains an invalid CIDR: %q", field, value)
+			}
+			bits := 128
+			if ip.To4() != nil {
+				ip = ip.To4()
+				bits = 32
+			}
+			nets = append(nets, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+
+		_, ipNet, err := net.ParseCIDR(value)
+		if err != nil {
+			return nil, fmt.Errorf("%s contains an invalid CIDR: %q", field, value)
+		}
+		nets = append(nets, ipNet)
+	}
+	return nets, nil
+}
+
+// parsePortRanges parses "443" or "8000-8999" entries
+func parsePortRanges(field string, values []string) ([]portRange, error) {
+	ranges := make([]portRange, 0, len(values))
+	for _, value := range values {
+		from, to, isRange := strings.Cut(strings.TrimSpace(value), "-")
+		if !isRange {
+			to = from
+		}
+
+		start, err1 := strconv.Atoi(strings.TrimSpace(from))
+		end, err2 := strconv.Atoi(strings.TrimSpace(to))
+		if err1 != nil || err2 != nil || start < 1 || end > 65535 || start > end {
+			return nil, fmt.Errorf("%s contains an invalid port or range: %q", field, value)
+		}
+		ranges = append(ranges, portRange{from: start, to: end})
+	}
+	return ranges, nil
+}
+
+// parseHostPatterns normalizes "example.com" and "*.example.com" patterns
+func parseHostPatterns(field string, values []string) ([]string, error) {
+	patterns := make([]string, 0, len(values))
+	for _, value := range values {
+		pattern := normalizeHost(value)
+		name := strings.TrimPrefix(pattern, "*.")
+		if name == "" || strings.ContainsAny(name, "*:/ ") {
+			return nil, fmt.Errorf("%s contains an invalid host pattern: %q", field, value)
+		}
+		patterns = append(patterns, pattern)
+	}
+	return patterns, nil
+}
+
+// normalizeHost lowercases a hostname and strips a trailing dot
+func normalizeHost(host string) string {
+	return strings.TrimSuffix(strings.ToLower(strings.TrimSpace(host)), ".")
+}
+
+// matchHostPattern returns the first pattern matching host, or ""
+// "*.example.com" matches subdomains of example.com but not example.com itself
+func matchHostPattern(patterns []string, host string) string {
+	for _, pattern := range patterns {
+		if suffix, ok := strings.CutPrefix(pattern, "*"); ok {
+			if strings.HasSuffix(host, suffix) {
+				return pattern
+			}
+		} else if host == pattern {
+			return pattern
+		}
+	}
+	return ""
+}
+
+// matchNet returns the first network containing ip, or nil
+func matchNet(nets []*net.IPNet, ip net.IP) *net.IPNet {
+	for _, ipNet := range nets {
+		if ipNet.Contains(ip) {
+			return ipNet
+		}
+	}
+	return nil
+}
+
+// matchPort returns the first range containing port, or nil
+func matchPort(ranges []portRange, port int) *portRange {
+	for i := range ranges {
+		if port >= ranges[i].from && port <= ranges[i].to {
+			return &ranges[i]
+		}
+	}
+	return nil
+}
+
+// evaluate decides whether a connection to host (resolved to ip) on port is allowed
+// Returns the rule that blocked it when it isn't
+func (c *compiledEgressPolicy) evaluate(host string, ip net.IP, port int) (bool, string) {
+	host = normalizeHost(host)
+
+	if pattern := matchHostPattern(c.denyHosts, host); pattern != "" {
+		return false, "deny_hosts " + pattern
+	}
+	if ipNet := matchNet(c.denyNets, ip); ipNet != nil {
+		return false, "deny_cidrs " + ipNet.String()
+	}
+	if r := matchPort(c.denyPorts, port); r != nil {
+		return false, "deny_ports " + r.String()
+	}
+	if len(c.allowPorts) > 0 && matchPort(c.allowPorts, port) == nil {
+		return false, fmt.Sprintf("port %d not in allow_ports", port)
+	}
+
+	if matchHostPattern(c.allowHosts, host) != "" || matchNet(c.allowNets, ip) != nil {
+		return true, ""
+	}
+	if c.defaultDeny {
+		return false, "default_action deny"
+	}
+	return true, ""
+}
+
+// egressOwnerKey is the context key for who an outbound connection is made for
+type egressOwnerKey struct{}
+
+// egressOwner identifies the feature, user and resource behind an outbound connection
+type egressOwner struct {
+	purpose    string
+	userID     string
+	resourceID string
+}
+
+// withEgressOwner records who connections made with ctx belong to, for the activity log
+func withEgressOwner(ctx context.Context, purpose, userID, resourceID string) context.Context {
+	return context.WithValue(ctx, egressOwnerKey{}, egressOwner{purpose: purpose, userID: userID, resourceID: resourceID})
+}
+
+// EgressPolicyService enforces the admin-configured egress policy for outbound connections
+type EgressPolicyService struct {
+	settingsRepo *repository.SettingsRepository
+	activityRepo *repository.ActivityLogRepository
+
+	mu       sync.RWMutex
+	policy   models.EgressPolicy
+	compiled *compiledEgressPolicy
+
+	logMu      sync.Mutex
+	lastLogged map[string]time.Time
+
+	listenersMu sync.Mutex
+	listeners   []func()
+}
+
+// NewEgressPolicyService creates an egress policy service using the default policy until Load is called
+func NewEgressPolicyService(settingsRepo *repository.SettingsRepository, activityRepo *repository.ActivityLogRepository) *EgressPolicyService {
+	policy := models.DefaultEgressPolicy()
+	compiled, _ := compileEgressPolicy(policy)
+
+	return &EgressPolicyService{
+		settingsRepo: settingsRepo,
+		activityRepo: activityRepo,
+		policy:       policy,
+		compiled:     compiled,
+		lastLogged:   make(map[string]time.Time),
+	}
+}
+
+// Load reads the stored policy from system settings, keeping the default if none is stored
+func (e *EgressPolicyService) Load(ctx context.Context) error {
+	setting, err := e.settingsRepo.Get(ctx, models.SettingEgressPolicy)
+	if errors.Is(err, repository.ErrSettingNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var policy models.EgressPolicy
+	if err := json.Unmarshal([]byte(setting.Value), &policy); err != nil {
+		return fmt.Errorf("failed to decode egress policy: %w", err)
+	}
+
+	compiled, err := compileEgressPolicy(policy)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidEgressPolicy, err)
+	}
+
+	e.setPolicy(policy, compiled)
+	return nil
+}
+
+// Policy returns the policy currently being enforced
+func (e *EgressPolicyService) Policy() models.EgressPolicy {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.policy
+}
+
+// Update validates, stores and starts enforcing a new policy
+func (e *EgressPolicyService) Update(ctx context.Context, policy models.EgressPolicy, updatedBy *string) error {
+	if policy.DefaultAction == "" {
+		policy.DefaultAction = models.EgressActionAllow
+	}
+
+	compiled, err := compileEgressPolicy(policy)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidEgressPolicy, err)
+	}
+
+	value, err := json.Marshal(policy)
+	if err != nil {
+		return err
+	}
+	if err := e.settingsRepo.Update(ctx, models.SettingEgressPolicy, string(value), updatedBy); err != nil {
+		return err
+	}
+
+	e.setPolicy(policy, compiled)
+	e.notifyListeners()
+
+	if e.activityRepo != nil {
+		entry := &models.UserActivityLog{
+			ActorID: updatedBy,
+			Action:  models.ActionSettingChanged,
+			Details: map[string]interface{}{"key": models.SettingEgressPolicy, "value": policy},
+		}
+		if err := e.activityRepo.Create(ctx, entry); err != nil {
+			fmt.Printf("Failed to log egress policy change: %v\n", err)
+		}
+	}
+
+	return nil
+}
+
+// OnUpdate registers fn to be called after the policy changes
+// Used to drop pooled connections that were allowed under the previous policy
+func (e *EgressPolicyService) OnUpdate(fn func()) {
+	e.listenersMu.Lock()
+	defer e.listenersMu.Unlock()
+	e.listeners = append(e.listeners, fn)
+}
+
+func (e *EgressPolicyService) notifyListeners() {
+	e.listenersMu.Lock()
+	listeners := append([]func(){}, e.listeners...)
+	e.listenersMu.Unlock()
+
+	for _, fn := range listeners {
+		fn()
+	}
+}
+
+func (e *EgressPolicyService) setPolicy(policy models.EgressPolicy, compiled *compiledEgressPolicy) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.policy = policy
+	e.compiled = compiled
+}
+
+// Check reports whether a connection to host (resolved to ip) on port is allowed
+// Returns an *EgressBlockedError when it isn't
+func (e *EgressPolicyService) Check(host string, ip net.IP, port int) error {
+	e.mu.RLock()
+	compiled := e.compiled
+	e.mu.RUnlock()
+
+	if allowed, rule := compiled.evaluate(host, ip, port); !allowed {
+		return &EgressBlockedError{Host: host, IP: ip, Port: port, Rule: rule}
+	}
+	return nil
+}
+
+// filterAllowed drops addresses the policy blocks
+// If every address is blocked, the attempt is recorded and the first block is returned
+// A nil service allows everything (no policy configured)
+func (e *EgressPolicyService) filterAllowed(ctx context.Context, host string, port int, ips []net.IP) ([]net.IP, error) {
+	if e == nil {
+		return ips, nil
+	}
+
+	allowed := make([]net.IP, 0, len(ips))
+	var firstBlock *EgressBlockedError
+	for _, ip := range ips {
+		err := e.Check(host, ip, port)
+		if err == nil {
+			allowed = append(allowed, ip)
+			continue
+		}
+		if firstBlock == nil {
+			firstBlock = err.(*EgressBlockedError)
+		}
+	}
+
+	if len(allowed) == 0 && firstBlock != nil {
+		e.recordBlocked(ctx, firstBlock)
+		return nil, firstBlock
+	}
+	return allowed, nil
+}
+
+// CheckURL resolves a URL's host and checks it against the policy
+// Used for URLs the server doesn't dial itself (e.g. icon URLs loaded by browsers);
+// hosts that don't resolve are allowed, like utils.ValidateExternalImageURL
+func (e *EgressPolicyService) CheckURL(ctx context.Context, rawURL, purpose, userID string) error {
+	parsedURL, err := url.Parse(rawURL)
+	if err != nil {
+		return err
+	}
+
+	host := parsedURL.Hostname()
+	port, err := strconv.Atoi(parsedURL.Port())
+	if err != nil {
+		port = 80
+		if parsedURL.Scheme == "https" {
+			port = 443
+		}
+	}
+
+	ips := []net.IP{net.ParseIP(host)}
+	if ips[0] == nil {
+		ips, err = systemResolver.lookupIP(ctx, host)
+		if err != nil {
+			return nil
+		}
+	}
+
+	_, err = e.filterAllowed(withEgressOwner(ctx, purpose, userID, ""), host, port, ips)
+	return err
+}
+
+// DialContext wraps a dialer so every connection it makes is checked against the policy
+// Callers should tag requests with withEgressOwner so blocks are attributed in the activity log
+func (e *EgressPolicyService) DialContext(dialer *net.Dialer) func(ctx context.Context, network, addr string) (net.Conn, error) {
+	return checkDialContext(dialer, nil, e)
+}
+
+// recordBlocked writes a blocked attempt to the activity log, at most once per egressLogInterval
+// per owner and destination
+func (e *EgressPolicyService) recordBlocked(ctx context.Context, blocked *EgressBlockedError) {
+	owner, _ := ctx.Value(egressOwnerKey{}).(egressOwner)
+
+	key := strings.Join([]string{owner.purpose, owner.userID, owner.resourceID, blocked.Host, blocked.IP.String(), strconv.Itoa(blocked.Port)}, "|")
+	now := time.Now()
+
+	e.logMu.Lock()
+	if last, ok := e.lastLogged[key]; ok && now.Sub(last) < egressLogInterval {
+		e.logMu.Unlock()
+		return
+	}
+	e.lastLogged[key] = now
+	// Drop expired keys so the map doesn't grow without bound
+	for k, last := range e.lastLogged {
+		if now.Sub(last) >= egressLogInterval {
+			delete(e.lastLogged, k)
+		}
+	}
+	e.logMu.Unlock()
+
+	fmt.Printf("Egress blocked (%s): %v\n", owner.purpose, blocked)
+
+	if e.activityRepo == nil {
+		return
+	}
+
+	entry := &models.UserActivityLog{
+		Action: models.ActionEgressBlocked,
+		Details: map[string]interface{}{
+			"purpose": owner.purpose,
+			"host":    blocked.Host,
+			"ip":      blocked.IP.String(),
+			"port":    blocked.Port,
+			"rule":    blocked.Rule,
+		},
+	}
+	if owner.userID != "" {
+		entry.UserID = &owner.userID
+	}
+	if owner.resourceID != "" {
+		entry.Details["resource_id"] = owner.resourceID
+	}
+
+	// Independent context: the dial context is usually about to be cancelled
+	logCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := e.activityRepo.Create(logCtx, entry); err != nil {
+		fmt.Printf("Failed to log blocked egress attempt: %v\n", err)
+	}
+}
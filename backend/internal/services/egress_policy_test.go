@@ -0,0 +1,258 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nimbus/backend/internal/models"
+	"github.com/nimbus/backend/internal/repository"
+)
+
+// setupEgressTestDB adds the settings and activity log tables to the status log test schema
+func setupEgressTestDB(t *testing.T) *sql.DB {
+	db := setupFingerprintTestDB(t)
+
+	_, err := db.Exec(`
+		CREATE TABLE system_settings (
+			key TEXT PRIMARY KEY,
+			value TEXT NOT NULL,
+			updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			updated_by TEXT
+		);
+
+		CREATE TABLE user_activity_logs (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			user_id TEXT,
+			actor_id TEXT,
+			action TEXT NOT NULL,
+			details TEXT,
+			ip_address TEXT,
+			created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+		);
+	`)
+	if err != nil {
+		t.Fatalf("Failed to create tables: %v", err)
+	}
+
+	return db
+}
+
+func TestValidateEgressPolicy(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  models.EgressPolicy
+		wantErr bool
+	}{
+		{"Default policy", models.DefaultEgressPolicy(), false},
+		{"Deny by default with allow lists", models.EgressPolicy{
+			DefaultAction: models.EgressActionDeny,
+			AllowCIDRs:    []string{"192.168.1.0/24", "10.0.0.5", "fd00::/8"},
+			AllowPorts:    []string{"443", "8000-8999"},
+			AllowHosts:    []string{"example.com", "*.example.org"},
+		}, false},
+		{"Unknown default action", models.EgressPolicy{DefaultAction: "block"}, true},
+		{"Invalid CIDR", models.EgressPolicy{DenyCIDRs: []string{"10.0.0.0/33"}}, true},
+		{"Invalid port", models.EgressPolicy{DenyPorts: []string{"70000"}}, true},
+		{"Reversed port range", models.EgressPolicy{AllowPorts: []string{"9000-8000"}}, true},
+		{"Host with port", models.EgressPolicy{DenyHosts: []string{"example.com:443"}}, true},
+		{"Wildcard in the middle", models.EgressPolicy{AllowHosts: []string{"api.*.example.com"}}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidateEgressPolicy(tt.policy); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateEgressPolicy() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCompiledEgressPolicy_Evaluate(t *testing.T) {
+	policy, err := compileEgressPolicy(models.EgressPolicy{
+		DefaultAction: models.EgressActionDeny,
+		AllowCIDRs:    []string{"192.168.1.0/24"},
+		DenyCIDRs:     []string{"192.168.1.1"},
+		DenyPorts:     []string{"22"},
+		AllowHosts:    []string{"*.example.com"},
+		DenyHosts:     []string{"admin.example.com"},
+	})
+	if err != nil {
+		t.Fatalf("Failed to compile policy: %v", err)
+	}
+
+	tests := []struct {
+		name        string
+		host        string
+		ip          string
+		port        int
+		allowed     bool
+		ruleContain string
+	}{
+		{"Allowed CIDR", "nas.lan", "192.168.1.20", 443, true, ""},
+		{"Deny CIDR wins over allow CIDR", "router.lan", "192.168.1.1", 443, false, "deny_cidrs 192.168.1.1/32"},
+		{"Denied port", "nas.lan", "192.168.1.20", 22, false, "deny_ports 22"},
+		{"Allowed host wildcard", "app.example.com", "203.0.113.10", 443, true, ""},
+		{"Wildcard doesn't match apex", "example.com", "203.0.113.10", 443, false, "default_action deny"},
+		{"Denied host wins over wildcard", "Admin.Example.com.", "203.0.113.10", 443, false, "deny_hosts admin.example.com"},
+		{"IPv4-mapped IPv6 matches IPv4 CIDR", "nas.lan", "::ffff:192.168.1.1", 443, false, "deny_cidrs"},
+		{"Default deny", "other.net", "198.51.100.1", 443, false, "default_action deny"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			allowed, rule := policy.evaluate(tt.host, net.ParseIP(tt.ip), tt.port)
+			if allowed != tt.allowed {
+				t.Errorf("evaluate(%s, %s, %d) = %v (%s), expected %v", tt.host, tt.ip, tt.port, allowed, rule, tt.allowed)
+			}
+			if !strings.Contains(rule, tt.ruleContain) {
+				t.Errorf("Expected rule containing %q, got %q", tt.ruleContain, rule)
+			}
+		})
+	}
+
+	allowPortsPolicy, _ := compileEgressPolicy(models.EgressPolicy{AllowPorts: []string{"80", "443"}})
+	if allowed, _ := allowPortsPolicy.evaluate("example.com", net.ParseIP("203.0.113.10"), 8080); allowed {
+		t.Error("Expected port outside allow_ports to be blocked")
+	}
+}
+
+func TestEgressPolicyService_DefaultBlocksMetadata(t *testing.T) {
+	egress := NewEgressPolicyService(nil, nil)
+
+	err := egress.Check("169.254.169.254", net.ParseIP("169.254.169.254"), 80)
+	if !errors.Is(err, ErrEgressBlocked) {
+		t.Errorf("Expected cloud metadata endpoint to be blocked, got %v", err)
+	}
+	if err := egress.Check("nas.lan", net.ParseIP("192.168.1.20"), 443); err != nil {
+		t.Errorf("Expected private ranges to be allowed by default, got %v", err)
+	}
+}
+
+func TestEgressPolicyService_UpdateAndLoad(t *testing.T) {
+	db := setupEgressTestDB(t)
+	defer db.Close()
+
+	settingsRepo := repository.NewSettingsRepository(db)
+	activityRepo := repository.NewActivityLogRepository(db)
+	egress := NewEgressPolicyService(settingsRepo, activityRepo)
+
+	invalid := models.EgressPolicy{DenyCIDRs: []string{"not-a-cidr"}}
+	if err := egress.Update(context.Background(), invalid, nil); !errors.Is(err, ErrInvalidEgressPolicy) {
+		t.Fatalf("Expected ErrInvalidEgressPolicy, got %v", err)
+	}
+
+	policy := models.EgressPolicy{DenyCIDRs: []string{"10.0.0.0/8"}}
+	if err := egress.Update(context.Background(), policy, nil); err != nil {
+		t.Fatalf("Failed to update policy: %v", err)
+	}
+	if egress.Policy().DefaultAction != models.EgressActionAllow {
+		t.Errorf("Expected empty default action to be stored as allow, got %q", egress.Policy().DefaultAction)
+	}
+
+	// A fresh service (e.g. after restart) picks up the stored policy
+	reloaded := NewEgressPolicyService(settingsRepo, activityRepo)
+	if err := reloaded.Load(context.Background()); err != nil {
+		t.Fatalf("Failed to load policy: %v", err)
+	}
+	if err := reloaded.Check("db.lan", net.ParseIP("10.1.2.3"), 5432); !errors.Is(err, ErrEgressBlocked) {
+		t.Errorf("Expected stored policy to block 10.0.0.0/8, got %v", err)
+	}
+	// The stored policy replaces the default, so metadata is no longer denied
+	if err := reloaded.Check("169.254.169.254", net.ParseIP("169.254.169.254"), 80); err != nil {
+		t.Errorf("Expected stored policy to replace the default, got %v", err)
+	}
+
+	logs, err := activityRepo.GetRecent(context.Background(), 10)
+	if err != nil {
+		t.Fatalf("Failed to get activity logs: %v", err)
+	}
+	if len(logs) != 1 || logs[0].Action != models.ActionSettingChanged {
+		t.Errorf("Expected one setting_changed activity log, got %d", len(logs))
+	}
+}
+
+func TestHealthCheckService_CheckService_EgressBlocked(t *testing.T) {
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("Blocked destination must not receive requests")
+	}))
+	defer testServer.Close()
+
+	_, port, _ := net.SplitHostPort(testServer.Listener.Addr().String())
+	dnsServer, _ := startTestDNSServer(t, map[string][]net.IP{"rebind.example.com": {net.ParseIP("127.0.0.1")}})
+
+	db := setupEgressTestDB(t)
+	defer db.Close()
+
+	settingsRepo := repository.NewSettingsRepository(db)
+	activityRepo := repository.NewActivityLogRepository(db)
+	egress := NewEgressPolicyService(settingsRepo, activityRepo)
+	if err := egress.Update(context.Background(), models.EgressPolicy{DenyCIDRs: []string{"127.0.0.0/8"}}, nil); err != nil {
+		t.Fatalf("Failed to update policy: %v", err)
+	}
+
+	mockRepo := &MockServiceRepository{}
+	statusLogRepo := repository.NewStatusLogRepository(db)
+	healthService := NewHealthCheckService(mockRepo, statusLogRepo, nil, 5*time.Second, nil, egress)
+
+	// The hostname looks public; the block must happen on the resolved address
+	service := &models.Service{
+		ID:          "test-service-id",
+		UserID:      "test-user-id",
+		URL:         "http://rebind.example.com:" + port + "/",
+		CheckConfig: models.CheckConfig{DNS: &models.DNSConfig{Server: dnsServer}},
+	}
+
+	// Check twice: the repeated block must not be logged again
+	for i := 0; i < 2; i++ {
+		if err := healthService.CheckService(context.Background(), service); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	}
+
+	if mockRepo.lastStatus != models.StatusOffline {
+		t.Errorf("Expected blocked service to be offline, got %q", mockRepo.lastStatus)
+	}
+
+	logs, err := statusLogRepo.GetLatestByServiceID(context.Background(), service.ID, 1)
+	if err != nil || len(logs) != 1 {
+		t.Fatalf("Expected a status log, got %d (err: %v)", len(logs), err)
+	}
+	if logs[0].ErrorMessage == nil || !strings.Contains(*logs[0].ErrorMessage, "blocked by egress policy") {
+		t.Errorf("Expected egress error message, got %q", derefString(logs[0].ErrorMessage))
+	}
+
+	activity, err := activityRepo.GetByUserID(context.Background(), service.UserID, 10)
+	if err != nil {
+		t.Fatalf("Failed to get activity logs: %v", err)
+	}
+	if len(activity) != 1 {
+		t.Fatalf("Expected 1 egress_blocked activity log, got %d", len(activity))
+	}
+	entry := activity[0]
+	if entry.Action != models.ActionEgressBlocked {
+		t.Errorf("Expected action %q, got %q", models.ActionEgressBlocked, entry.Action)
+	}
+	if entry.Details["purpose"] != models.EgressPurposeHealthCheck || entry.Details["resource_id"] != service.ID ||
+		entry.Details["ip"] != "127.0.0.1" || entry.Details["host"] != "rebind.example.com" {
+		t.Errorf("Unexpected activity details: %v", entry.Details)
+	}
+}
+
+func TestEgressPolicyService_CheckURL(t *testing.T) {
+	egress := NewEgressPolicyService(nil, nil)
+	egress.setPolicy(models.EgressPolicy{}, &compiledEgressPolicy{denyPorts: []portRange{{from: 8443, to: 8443}}})
+
+	if err := egress.CheckURL(context.Background(), "https://203.0.113.10:8443/icon.png", models.EgressPurposeIcon, "user"); !errors.Is(err, ErrEgressBlocked) {
+		t.Errorf("Expected icon URL on a denied port to be blocked, got %v", err)
+	}
+	if err := egress.CheckURL(context.Background(), "https://203.0.113.10/icon.png", models.EgressPurposeIcon, "user"); err != nil {
+		t.Errorf("Expected icon URL on the default port to be allowed, got %v", err)
+	}
+}
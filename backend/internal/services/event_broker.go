@@ -0,0 +1,186 @@
+package services
+
+import (
+	"sync"
+	"time"
+)
+
+// Event types pushed to live clients
+const (
+	EventServiceStatus  = "service.status"
+	EventServiceCreated = "service.created"
+	EventServiceUpdated = "service.updated"
+	EventServiceDeleted = "service.deleted"
+)
+
+// Default event broker settings
+const (
+	DefaultEventHistorySize    = 1000
+	DefaultEventSubscriberSize = 64
+)
+
+// Event is one change published to a user's live clients
+type Event struct {
+	ID        uint64
+	Type      string
+	UserID    string
+	ServiceID string
+	Data      any // Marshalled as JSON for the client
+	Time      time.Time
+}
+
+// ServiceStatusEventData is the payload of a service.status event
+type ServiceStatusEventData struct {
+	ID           string    `json:"id"`
+	Status       string    `json:"status"`
+	ResponseTime *int      `json:"response_time,omitempty"`
+	ErrorMessage *string   `json:"error_message,omitempty"`
+	CheckedAt    time.Time `json:"checked_at"`
+}
+
+// ServiceDeletedEventData is the payload of a service.deleted event
+type ServiceDeletedEventData struct {
+	ID string `json:"id"`
+}
+
+// EventSubscription receives the events of one user
+// Events is closed when the subscriber falls too far behind or the broker closes;
+// the client then reconnects and resumes from the last event it saw
+type EventSubscription struct {
+	Events <-chan Event
+
+	events chan Event
+	userID string
+	broker *EventBroker
+}
+
+// Unsubscribe stops delivery to the subscription
+func (s *EventSubscription) Unsubscribe() {
+	s.broker.mu.Lock()
+	defer s.broker.mu.Unlock()
+
+	if _, ok := s.broker.subscribers[s]; ok {
+		delete(s.broker.subscribers, s)
+		close(s.events)
+	}
+}
+
+// EventBroker fans out service events to subscribed clients
+// Recent events are kept in a ring buffer so a reconnecting client can replay what it missed
+type EventBroker struct {
+	mu          sync.Mutex
+	nextID      uint64
+	history     []Event // Ring buffer of the last historySize events, historyHead is the next slot
+	historyHead int
+	historySize int
+	subscribers map[*EventSubscription]struct{}
+	bufferSize  int
+	closed      bool
+}
+
+// NewEventBroker creates a broker that keeps historySize events for replay
+func NewEventBroker(historySize int) *EventBroker {
+	if historySize <= 0 {
+		historySize = DefaultEventHistorySize
+	}
+	return &EventBroker{
+		// IDs continue from the clock, so an ID from before a restart is recognised as unknown
+		nextID:      uint64(time.Now().UnixMicro()),
+		history:     make([]Event, historySize),
+		subscribers: make(map[*EventSubscription]struct{}),
+		bufferSize:  DefaultEventSubscriberSize,
+	}
+}
+
+// Publish sends an event to the user's subscribers without blocking
+// Safe to call on a nil broker (live updates disabled)
+func (b *EventBroker) Publish(userID, eventType, serviceID string, data any) {
+	if b == nil || userID == "" {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return
+	}
+
+	b.nextID++
+	event := Event{ID: b.nextID, Type: eventType, UserID: userID, ServiceID: serviceID, Data: data, Time: time.Now()}
+
+	b.history[b.historyHead] = event
+	b.historyHead = (b.historyHead + 1) % len(b.history)
+	if b.historySize < len(b.history) {
+		b.historySize++
+	}
+
+	for sub := range b.subscribers {
+		if sub.userID != userID {
+			continue
+		}
+		select {
+		case sub.events <- event:
+		default:
+			// A stalled client would hold everyone up - drop it, it resumes with Last-Event-ID
+			delete(b.subscribers, sub)
+			close(sub.events)
+		}
+	}
+}
+
+// Subscribe registers a client for the user's events
+// With lastEventID > 0 the events the client missed are returned for replay; complete is false
+// if some of them are no longer in the history, and the client should reload its state instead
+func (b *EventBroker) Subscribe(userID string, lastEventID uint64) (sub *EventSubscription, replay []Event, complete bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	events := make(chan Event, b.bufferSize)
+	sub = &EventSubscription{Events: events, events: events, userID: userID, broker: b}
+	if b.closed {
+		close(events)
+		return sub, nil, true
+	}
+	b.subscribers[sub] = struct{}{}
+
+	if lastEventID == 0 {
+		return sub, nil, true
+	}
+
+	oldest := b.nextID + 1 // Next ID to be published when the history is empty
+	for i := 0; i < b.historySize; i++ {
+		event := b.history[(b.historyHead-b.historySize+i+len(b.history))%len(b.history)]
+		if i == 0 {
+			oldest = event.ID
+		}
+		if event.ID > lastEventID && event.UserID == userID {
+			replay = append(replay, event)
+		}
+	}
+
+	// Complete if nothing after lastEventID was evicted, and the ID was issued by this broker
+	complete = lastEventID+1 >= oldest && lastEventID <= b.nextID
+	return sub, replay, complete
+}
+
+// SubscriberCount returns the number of connected clients
+func (b *EventBroker) SubscriberCount() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.subscribers)
+}
+
+// Close disconnects all subscribers, so open streams end before the server shuts down
+func (b *EventBroker) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return
+	}
+	b.closed = true
+	for sub := range b.subscribers {
+		delete(b.subscribers, sub)
+		close(sub.events)
+	}
+}
@@ -0,0 +1,136 @@
+package services
+
+import (
+	"testing"
+	"time"
+)
+
+// receiveEvent waits briefly for the next event on a subscription
+func receiveEvent(t *testing.T, sub *EventSubscription) (Event, bool) {
+	t.Helper()
+	select {
+	case event, ok := <-sub.Events:
+		return event, ok
+	case <-time.After(time.Second):
+		t.Fatal("Timed out waiting for event")
+		return Event{}, false
+	}
+}
+
+func TestEventBroker_DeliversOnlyToOwner(t *testing.T) {
+	b := NewEventBroker(10)
+	alice, _, _ := b.Subscribe("alice", 0)
+	bob, _, _ := b.Subscribe("bob", 0)
+	defer alice.Unsubscribe()
+	defer bob.Unsubscribe()
+
+	b.Publish("alice", EventServiceStatus, "svc-1", ServiceStatusEventData{ID: "svc-1", Status: "online"})
+
+	event, ok := receiveEvent(t, alice)
+	if !ok || event.Type != EventServiceStatus || event.ServiceID != "svc-1" {
+		t.Fatalf("Unexpected event %+v (ok=%v)", event, ok)
+	}
+	select {
+	case event := <-bob.Events:
+		t.Fatalf("Other user received %+v", event)
+	default:
+	}
+}
+
+func TestEventBroker_ReplaysMissedEvents(t *testing.T) {
+	b := NewEventBroker(10)
+	b.Publish("alice", EventServiceCreated, "svc-1", nil)
+	b.Publish("bob", EventServiceCreated, "svc-2", nil)
+	b.Publish("alice", EventServiceUpdated, "svc-1", nil)
+	b.Publish("alice", EventServiceDeleted, "svc-1", nil)
+
+	// Resume after alice's first event
+	b.mu.Lock()
+	firstID := b.history[0].ID
+	b.mu.Unlock()
+
+	sub, replay, complete := b.Subscribe("alice", firstID)
+	defer sub.Unsubscribe()
+	if !complete {
+		t.Error("Expected complete replay")
+	}
+	if len(replay) != 2 || replay[0].Type != EventServiceUpdated || replay[1].Type != EventServiceDeleted {
+		t.Fatalf("Unexpected replay %+v", replay)
+	}
+}
+
+func TestEventBroker_ReplayIncompleteAfterEviction(t *testing.T) {
+	b := NewEventBroker(2)
+	for i := 0; i < 5; i++ {
+		b.Publish("alice", EventServiceStatus, "svc-1", nil)
+	}
+
+	b.mu.Lock()
+	latest := b.nextID
+	b.mu.Unlock()
+
+	// The event after latest-4 was evicted
+	_, replay, complete := b.Subscribe("alice", latest-4)
+	if complete {
+		t.Error("Expected incomplete replay after eviction")
+	}
+	if len(replay) != 2 {
+		t.Errorf("Expected the 2 retained events, got %d", len(replay))
+	}
+
+	// Only the retained events were missed
+	_, replay, complete = b.Subscribe("alice", latest-2)
+	if !complete || len(replay) != 2 {
+		t.Errorf("Expected complete replay of 2 events, got %d (complete=%v)", len(replay), complete)
+	}
+
+	// An ID this broker never issued (e.g. from before a restart) can't be resumed
+	if _, _, complete = b.Subscribe("alice", latest+100); complete {
+		t.Error("Expected unknown event ID to be incomplete")
+	}
+}
+
+func TestEventBroker_DropsSlowSubscriber(t *testing.T) {
+	b := NewEventBroker(10)
+	sub, _, _ := b.Subscribe("alice", 0)
+
+	for i := 0; i < DefaultEventSubscriberSize+1; i++ {
+		b.Publish("alice", EventServiceStatus, "svc-1", nil)
+	}
+
+	if b.SubscriberCount() != 0 {
+		t.Fatalf("Expected stalled subscriber to be dropped, %d left", b.SubscriberCount())
+	}
+	received := 0
+	for range sub.Events {
+		received++
+	}
+	if received != DefaultEventSubscriberSize {
+		t.Errorf("Expected %d buffered events, got %d", DefaultEventSubscriberSize, received)
+	}
+
+	// Unsubscribing an already dropped subscription is a no-op
+	sub.Unsubscribe()
+}
+
+func TestEventBroker_CloseEndsSubscriptions(t *testing.T) {
+	b := NewEventBroker(10)
+	sub, _, _ := b.Subscribe("alice", 0)
+
+	b.Close()
+	if _, ok := receiveEvent(t, sub); ok {
+		t.Error("Expected subscription to be closed")
+	}
+
+	// Publishing and subscribing after Close don't block or panic
+	b.Publish("alice", EventServiceStatus, "svc-1", nil)
+	late, _, _ := b.Subscribe("alice", 0)
+	if _, ok := receiveEvent(t, late); ok {
+		t.Error("Expected late subscription to be closed")
+	}
+}
+
+func TestEventBroker_NilIsNoop(t *testing.T) {
+	var b *EventBroker
+	b.Publish("alice", EventServiceStatus, "svc-1", nil)
+}
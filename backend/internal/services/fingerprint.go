@@ -0,0 +1,169 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"html"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/nimbus/backend/internal/models"
+)
+
+// maxFingerprintBodySize limits how much of a response body is read for fingerprinting
+const maxFingerprintBodySize = 1 << 20 // 1 MiB
+
+var (
+	htmlTitleRegex  = regexp.MustCompile(`(?is)<title[^>]*>(.*?)</title>`)
+	whitespaceRegex = regexp.MustCompile(`\s+`)
+)
+
+// computeFingerprint builds a fingerprint from the response headers, body and TLS state
+func computeFingerprint(serviceID string, resp *http.Response, body []byte, headerNames []string) *models.ServiceFingerprint {
+	normalized := whitespaceRegex.ReplaceAll(body, []byte(" "))
+	normalized = []byte(strings.TrimSpace(string(normalized)))
+	bodyHash := sha256.Sum256(normalized)
+
+	fp := &models.ServiceFingerprint{
+		ServiceID: serviceID,
+		BodyHash:  hex.EncodeToString(bodyHash[:]),
+		Headers:   make(map[string]string, len(headerNames)),
+		CreatedAt: time.Now(),
+	}
+
+	// Only look for a <title> in HTML responses
+	if strings.Contains(strings.ToLower(resp.Header.Get("Content-Type")), "html") {
+		fp.Title = extractHTMLTitle(body)
+	}
+
+	for _, name := range headerNames {
+		key := strings.ToLower(strings.TrimSpace(name))
+		if key == "" {
+			continue
+		}
+		fp.Headers[key] = resp.Header.Get(key)
+	}
+
+	if resp.TLS != nil && len(resp.TLS.PeerCertificates) > 0 {
+		certHash := sha256.Sum256(resp.TLS.PeerCertificates[0].Raw)
+		certFingerprint := hex.EncodeToString(certHash[:])
+		fp.CertFingerprint = &certFingerprint
+	}
+
+	return fp
+}
+
+// extractHTMLTitle returns the normalized contents of the first <title> element, or nil if absent
+func extractHTMLTitle(body []byte) *string {
+	match := htmlTitleRegex.FindSubmatch(body)
+	if match == nil {
+		return nil
+	}
+
+	title := html.UnescapeString(string(match[1]))
+	title = strings.TrimSpace(whitespaceRegex.ReplaceAllString(title, " "))
+	return &title
+}
+
+// diffFingerprints returns the fields that changed between two fingerprints
+// HTML pages are compared by title rather than body hash, since dynamic markup
+// (CSRF tokens, timestamps) would otherwise report a change on every check
+func diffFingerprints(prev, cur *models.ServiceFingerprint) []string {
+	var changed []string
+
+	if prev.Title != nil || cur.Title != nil {
+		if !equalStringPtr(prev.Title, cur.Title) {
+			changed = append(changed, models.FingerprintFieldTitle)
+		}
+	} else if prev.BodyHash != cur.BodyHash {
+		changed = append(changed, models.FingerprintFieldBody)
+	}
+
+	// Compare headers present in either snapshot (header selection may have changed)
+	keys := make(map[string]struct{}, len(cur.Headers))
+	for k := range prev.Headers {
+		keys[k] = struct{}{}
+	}
+	for k := range cur.Headers {
+		keys[k] = struct{}{}
+	}
+	var headerChanges []string
+	for k := range keys {
+		prevVal, prevOK := prev.Headers[k]
+		curVal, curOK := cur.Headers[k]
+		// Ignore headers that were only added to or removed from the selection
+		if prevOK && curOK && prevVal != curVal {
+			headerChanges = append(headerChanges, models.FingerprintFieldHeaderPfx+k)
+		}
+	}
+	sort.Strings(headerChanges)
+	changed = append(changed, headerChanges...)
+
+	if !equalStringPtr(prev.CertFingerprint, cur.CertFingerprint) {
+		changed = append(changed, models.FingerprintFieldCertificate)
+	}
+
+	return changed
+}
+
+// sameHeaderKeys reports whether two fingerprints track the same headers
+func sameHeaderKeys(a, b map[string]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k := range a {
+		if _, ok := b[k]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// equalStringPtr compares two optional strings
+func equalStringPtr(a, b *string) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+// recordFingerprint compares the fingerprint against the last stored one and persists it if it changed
+// Returns the changed fields, or nil if this is the first fingerprint or nothing changed
+func (h *HealthCheckService) recordFingerprint(fp *models.ServiceFingerprint) ([]string, error) {
+	// Use an independent context so the snapshot is saved even if the check is cancelled
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	prev, err := h.fingerprintRepo.GetLatestByServiceID(ctx, fp.ServiceID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+
+	// First fingerprint becomes the baseline
+	if prev == nil {
+		return nil, h.fingerprintRepo.Create(ctx, fp)
+	}
+
+	changed := diffFingerprints(prev, fp)
+	if len(changed) == 0 {
+		// A header added to the selection only starts being compared once it is in the stored
+		// fingerprint, so a new selection becomes the baseline without reporting a change
+		if !sameHeaderKeys(prev.Headers, fp.Headers) {
+			return nil, h.fingerprintRepo.Create(ctx, fp)
+		}
+		return nil, nil
+	}
+
+	fp.ChangedFields = changed
+	if err := h.fingerprintRepo.Create(ctx, fp); err != nil {
+		return nil, err
+	}
+
+	return changed, nil
+}
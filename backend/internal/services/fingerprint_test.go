@@ -0,0 +1,335 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/nimbus/backend/internal/models"
+	"github.com/nimbus/backend/internal/repository"
+)
+
+// setupFingerprintTestDB creates an in-memory SQLite database with the tables used by fingerprinting
+func setupFingerprintTestDB(t *testing.T) *sql.DB {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("Failed to create test database: %v", err)
+	}
+
+	_, err = db.Exec(`
+		CREATE TABLE service_status_logs (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			service_id TEXT NOT NULL,
+			status TEXT NOT NULL CHECK(status IN ('online', 'offline', 'unknown')),
+			response_time INTEGER,
+			error_message TEXT,
+			event TEXT,
+			event_detail TEXT,
+			failed_step INTEGER,
+			step_timings TEXT,
+			address_family TEXT,
+			checked_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+		);
+
+		CREATE TABLE service_fingerprints (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			service_id TEXT NOT NULL,
+			title TEXT,
+			body_hash TEXT NOT NULL,
+			headers TEXT NOT NULL DEFAULT '{}',
+			cert_fingerprint TEXT,
+			changed_fields TEXT NOT NULL DEFAULT '[]',
+			created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+		);
+	`)
+	if err != nil {
+		t.Fatalf("Failed to create tables: %v", err)
+	}
+
+	return db
+}
+
+func TestExtractHTMLTitle(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		expected *string
+	}{
+		{"Simple title", "<html><head><title>Nextcloud</title></head></html>", stringPtr("Nextcloud")},
+		{"Title with attributes", `<title data-x="1">Plex</title>`, stringPtr("Plex")},
+		{"Whitespace normalized", "<title>\n  Home   Assistant\n</title>", stringPtr("Home Assistant")},
+		{"Entities unescaped", "<TITLE>Tom &amp; Jerry</TITLE>", stringPtr("Tom & Jerry")},
+		{"No title", "<html><body>hello</body></html>", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := extractHTMLTitle([]byte(tt.body))
+			if !equalStringPtr(result, tt.expected) {
+				t.Errorf("extractHTMLTitle() = %v, expected %v", derefString(result), derefString(tt.expected))
+			}
+		})
+	}
+}
+
+func TestComputeFingerprint(t *testing.T) {
+	resp := &http.Response{Header: http.Header{}}
+	resp.Header.Set("Content-Type", "text/html; charset=utf-8")
+	resp.Header.Set("Server", "nginx/1.25")
+
+	fp := computeFingerprint("svc-1", resp, []byte("<title>App</title>"), []string{"Server", "X-Missing"})
+
+	if fp.Title == nil || *fp.Title != "App" {
+		t.Errorf("Expected title 'App', got %v", derefString(fp.Title))
+	}
+	if fp.Headers["server"] != "nginx/1.25" {
+		t.Errorf("Expected server header 'nginx/1.25', got %q", fp.Headers["server"])
+	}
+	if v, ok := fp.Headers["x-missing"]; !ok || v != "" {
+		t.Errorf("Expected missing header to be recorded as empty, got %q (present=%v)", v, ok)
+	}
+	if fp.CertFingerprint != nil {
+		t.Error("Expected no certificate fingerprint for plain HTTP")
+	}
+
+	// Whitespace-only differences must not change the body hash
+	other := computeFingerprint("svc-1", resp, []byte("  <title>App</title>\n"), []string{"Server"})
+	if fp.BodyHash != other.BodyHash {
+		t.Error("Expected whitespace-normalized bodies to have the same hash")
+	}
+}
+
+func TestDiffFingerprints(t *testing.T) {
+	base := func() *models.ServiceFingerprint {
+		return &models.ServiceFingerprint{
+			BodyHash: "hash-a",
+			Headers:  map[string]string{"server": "nginx"},
+		}
+	}
+
+	tests := []struct {
+		name     string
+		mutate   func(prev, cur *models.ServiceFingerprint)
+		expected []string
+	}{
+		{"No change", func(prev, cur *models.ServiceFingerprint) {}, nil},
+		{"Body changed", func(prev, cur *models.ServiceFingerprint) { cur.BodyHash = "hash-b" }, []string{"body"}},
+		{"Header changed", func(prev, cur *models.ServiceFingerprint) { cur.Headers["server"] = "caddy" }, []string{"header:server"}},
+		{"Header added to selection", func(prev, cur *models.ServiceFingerprint) { cur.Headers["x-powered-by"] = "php" }, nil},
+		{"Title changed", func(prev, cur *models.ServiceFingerprint) {
+			prev.Title = stringPtr("Old")
+			cur.Title = stringPtr("New")
+		}, []string{"title"}},
+		{"HTML body changed but title same", func(prev, cur *models.ServiceFingerprint) {
+			prev.Title = stringPtr("Same")
+			cur.Title = stringPtr("Same")
+			cur.BodyHash = "hash-b"
+		}, nil},
+		{"Certificate re-issued", func(prev, cur *models.ServiceFingerprint) {
+			prev.CertFingerprint = stringPtr("cert-a")
+			cur.CertFingerprint = stringPtr("cert-b")
+		}, []string{"certificate"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prev, cur := base(), base()
+			tt.mutate(prev, cur)
+			result := diffFingerprints(prev, cur)
+			if strings.Join(result, ",") != strings.Join(tt.expected, ",") {
+				t.Errorf("diffFingerprints() = %v, expected %v", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestHealthCheckService_CheckService_FingerprintChange(t *testing.T) {
+	db := setupFingerprintTestDB(t)
+	defer db.Close()
+
+	// Serve a different Server header after the second request
+	var requests int32
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&requests, 1)
+		w.Header().Set("Content-Type", "text/html")
+		if n <= 2 {
+			w.Header().Set("Server", "nginx")
+		} else {
+			w.Header().Set("Server", "evil-proxy")
+		}
+		fmt.Fprint(w, "<title>Nextcloud</title>")
+	}))
+	defer testServer.Close()
+
+	fingerprintRepo := repository.NewFingerprintRepository(db)
+	statusLogRepo := repository.NewStatusLogRepository(db)
+	healthService := &HealthCheckService{
+		serviceRepo:     &MockServiceRepository{},
+		statusLogRepo:   statusLogRepo,
+		fingerprintRepo: fingerprintRepo,
+		httpClient: &http.Client{
+			Timeout: 5 * time.Second,
+		},
+	}
+
+	service := &models.Service{
+		ID:  "test-service-id",
+		URL: testServer.URL,
+		CheckConfig: models.CheckConfig{
+			Fingerprint: &models.FingerprintConfig{Enabled: true},
+		},
+	}
+
+	ctx := context.Background()
+	for i := 0; i < 3; i++ {
+		if err := healthService.CheckService(ctx, service); err != nil {
+			t.Fatalf("Check %d failed: %v", i+1, err)
+		}
+	}
+
+	// Baseline + one change
+	fingerprints, err := fingerprintRepo.GetByServiceID(ctx, service.ID, 10)
+	if err != nil {
+		t.Fatalf("Failed to get fingerprints: %v", err)
+	}
+	if len(fingerprints) != 2 {
+		t.Fatalf("Expected 2 fingerprints (baseline + change), got %d", len(fingerprints))
+	}
+
+	latest := fingerprints[0]
+	if len(latest.ChangedFields) != 1 || latest.ChangedFields[0] != "header:server" {
+		t.Errorf("Expected changed fields [header:server], got %v", latest.ChangedFields)
+	}
+
+	// Only the last check should carry the change event
+	logs, err := statusLogRepo.GetLatestByServiceID(ctx, service.ID, 10)
+	if err != nil {
+		t.Fatalf("Failed to get status logs: %v", err)
+	}
+	events := 0
+	for _, log := range logs {
+		if log.Event != nil {
+			events++
+			if *log.Event != models.EventFingerprintChanged {
+				t.Errorf("Expected event %q, got %q", models.EventFingerprintChanged, *log.Event)
+			}
+		}
+	}
+	if events != 1 {
+		t.Errorf("Expected exactly 1 fingerprint_changed event, got %d", events)
+	}
+}
+
+func TestHealthCheckService_CheckService_FingerprintHeaderAdded(t *testing.T) {
+	db := setupFingerprintTestDB(t)
+	defer db.Close()
+
+	// X-Version changes on the fourth request, after the header was added to the selection
+	var requests int32
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&requests, 1)
+		w.Header().Set("Server", "nginx")
+		if n <= 3 {
+			w.Header().Set("X-Version", "1.0")
+		} else {
+			w.Header().Set("X-Version", "2.0")
+		}
+		fmt.Fprint(w, "ok")
+	}))
+	defer testServer.Close()
+
+	fingerprintRepo := repository.NewFingerprintRepository(db)
+	healthService := &HealthCheckService{
+		serviceRepo:     &MockServiceRepository{},
+		statusLogRepo:   repository.NewStatusLogRepository(db),
+		fingerprintRepo: fingerprintRepo,
+		httpClient: &http.Client{
+			Timeout: 5 * time.Second,
+		},
+	}
+
+	service := &models.Service{
+		ID:  "test-service-id",
+		URL: testServer.URL,
+		CheckConfig: models.CheckConfig{
+			Fingerprint: &models.FingerprintConfig{Enabled: true},
+		},
+	}
+
+	ctx := context.Background()
+	if err := healthService.CheckService(ctx, service); err != nil {
+		t.Fatalf("Check 1 failed: %v", err)
+	}
+
+	service.CheckConfig.Fingerprint.Headers = []string{"Server", "X-Version"}
+	for i := 2; i <= 4; i++ {
+		if err := healthService.CheckService(ctx, service); err != nil {
+			t.Fatalf("Check %d failed: %v", i, err)
+		}
+	}
+
+	// Baseline, new baseline with X-Version, then the X-Version change
+	fingerprints, err := fingerprintRepo.GetByServiceID(ctx, service.ID, 10)
+	if err != nil {
+		t.Fatalf("Failed to get fingerprints: %v", err)
+	}
+	if len(fingerprints) != 3 {
+		t.Fatalf("Expected 3 fingerprints, got %d", len(fingerprints))
+	}
+	if len(fingerprints[1].ChangedFields) != 0 || fingerprints[1].Headers["x-version"] != "1.0" {
+		t.Errorf("Expected a new baseline tracking x-version, got %+v", fingerprints[1])
+	}
+	if latest := fingerprints[0]; len(latest.ChangedFields) != 1 || latest.ChangedFields[0] != "header:x-version" {
+		t.Errorf("Expected changed fields [header:x-version], got %v", latest.ChangedFields)
+	}
+}
+
+func TestHealthCheckService_CheckService_FingerprintDisabled(t *testing.T) {
+	db := setupFingerprintTestDB(t)
+	defer db.Close()
+
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer testServer.Close()
+
+	fingerprintRepo := repository.NewFingerprintRepository(db)
+	healthService := &HealthCheckService{
+		serviceRepo:     &MockServiceRepository{},
+		fingerprintRepo: fingerprintRepo,
+		httpClient: &http.Client{
+			Timeout: 5 * time.Second,
+		},
+	}
+
+	service := &models.Service{ID: "test-service-id", URL: testServer.URL}
+	if err := healthService.CheckService(context.Background(), service); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	fingerprints, err := fingerprintRepo.GetByServiceID(context.Background(), service.ID, 10)
+	if err != nil {
+		t.Fatalf("Failed to get fingerprints: %v", err)
+	}
+	if len(fingerprints) != 0 {
+		t.Errorf("Expected no fingerprints when disabled, got %d", len(fingerprints))
+	}
+}
+
+func stringPtr(s string) *string {
+	return &s
+}
+
+func derefString(s *string) string {
+	if s == nil {
+		return "<nil>"
+	}
+	return *s
+}
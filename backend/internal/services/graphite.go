@@ -0,0 +1,130 @@
+package services
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultGraphitePrefix is the first path component of exported Graphite metrics
+const DefaultGraphitePrefix = "nimbus"
+
+// GraphiteSink writes check results with the Graphite plaintext protocol over TCP
+// Metrics are named <prefix>.services.<service id>[.<address family>].{up,response_time_ms}
+type GraphiteSink struct {
+	address string
+	prefix  string
+
+	mu   sync.Mutex // Guards conn, which is kept open between batches
+	conn net.Conn
+}
+
+// NewGraphiteSink creates a sink for a Carbon plaintext listener (host:port, usually port 2003)
+func NewGraphiteSink(address, prefix string) (*GraphiteSink, error) {
+	if _, _, err := net.SplitHostPort(address); err != nil {
+		return nil, fmt.Errorf("invalid Graphite address %q: %w", address, err)
+	}
+	if prefix == "" {
+		prefix = DefaultGraphitePrefix
+	}
+	return &GraphiteSink{address: address, prefix: strings.Trim(prefix, ".")}, nil
+}
+
+// Name identifies the sink in logs
+func (s *GraphiteSink) Name() string {
+	return "Graphite"
+}
+
+// Write sends a batch of results, reconnecting if the connection was lost
+// Carbon doesn't acknowledge writes, so a failed write resends the whole batch on retry
+func (s *GraphiteSink) Write(ctx context.Context, records []CheckResultRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Carbon never writes to us: a readable connection was closed by the other side,
+	// and writing to it would silently lose the first batch
+	// (the deadline must lie ahead: an expired one fails the read without looking at the socket)
+	if s.conn != nil {
+		s.conn.SetReadDeadline(time.Now().Add(time.Millisecond))
+		if _, err := s.conn.Read(make([]byte, 1)); !errors.Is(err, os.ErrDeadlineExceeded) {
+			s.conn.Close()
+			s.conn = nil
+		}
+	}
+
+	if s.conn == nil {
+		var dialer net.Dialer
+		conn, err := dialer.DialContext(ctx, "tcp", s.address)
+		if err != nil {
+			return err
+		}
+		s.conn = conn
+	}
+
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(resultExportWriteTimeout)
+	}
+	s.conn.SetWriteDeadline(deadline)
+
+	w := bufio.NewWriter(s.conn)
+	for _, record := range records {
+		for _, line := range s.lines(record) {
+			w.WriteString(line)
+			w.WriteByte('\n')
+		}
+	}
+	if err := w.Flush(); err != nil {
+		s.conn.Close()
+		s.conn = nil
+		return err
+	}
+	return nil
+}
+
+// Close closes the connection to Carbon
+func (s *GraphiteSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.conn == nil {
+		return nil
+	}
+	err := s.conn.Close()
+	s.conn = nil
+	return err
+}
+
+// lines formats a check result as plaintext protocol lines ("path value timestamp")
+func (s *GraphiteSink) lines(record CheckResultRecord) []string {
+	path := s.prefix + ".services." + graphitePathComponent(record.ServiceID)
+	if record.AddressFamily != nil && *record.AddressFamily != "" {
+		path += "." + graphitePathComponent(*record.AddressFamily)
+	}
+	timestamp := strconv.FormatInt(record.CheckedAt.Unix(), 10)
+
+	lines := []string{path + ".up " + strconv.Itoa(record.Up()) + " " + timestamp}
+	if record.ResponseTime != nil {
+		lines = append(lines, path+".response_time_ms "+strconv.Itoa(*record.ResponseTime)+" "+timestamp)
+	}
+	return lines
+}
+
+// graphitePathComponent replaces characters that would split or break a metric path
+func graphitePathComponent(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_':
+			return r
+		default:
+			return '_'
+		}
+	}, s)
+}
@@ -2,11 +2,13 @@ package services
 
 import (
 	"context"
-	"crypto/tls"
 	"fmt"
+	"io"
 	"net"
 	"net/http"
+	"net/http/httptrace"
 	"net/url"
+	"strings"
 	"sync"
 	"time"
 
@@ -14,24 +16,31 @@ import (
 	"github.com/nimbus/backend/internal/repository"
 )
 
-// DNS lookup cache entry
-type dnsCacheEntry struct {
-	isLocal  bool
-	expireAt time.Time
+// HealthCheckService handles health checking of services
+type HealthCheckService struct {
+	serviceRepo     repository.ServiceRepositoryInterface
+	statusLogRepo   *repository.StatusLogRepository
+	fingerprintRepo *repository.FingerprintRepository
+	statusWriter    *StatusWriter
+	statusEvents    *statusTracker // nil unless status transitions are recorded
+	checkMetrics    *CheckMetrics
+	tracer          *Tracer // nil unless traces are exported
+	resultExporters []*ResultExporter
+	eventBroker     *EventBroker         // nil unless live updates are streamed
+	notifications   *NotificationService // nil unless transitions are notified
+	alerts          *AlertService        // nil unless alert rules are applied
+	incidents       *IncidentService     // nil unless incidents are recorded
+	certificates    *certificateTracker  // nil unless TLS certificates are recorded
+	httpClient      *http.Client
 }
 
-// Global DNS cache with 5-minute TTL
-var (
-	dnsCacheMu  sync.RWMutex
-	dnsCache    = make(map[string]dnsCacheEntry)
-	dnsCacheTTL = 5 * time.Minute
-)
+// bufferedStatusWritesKey is the context key that lets a check's result go through the status writer
+type bufferedStatusWritesKey struct{}
 
-// HealthCheckService handles health checking of services
-type HealthCheckService struct {
-	serviceRepo   repository.ServiceRepositoryInterface
-	statusLogRepo *repository.StatusLogRepository
-	httpClient    *http.Client
+// WithBufferedStatusWrites lets checks made with ctx queue their results on the status writer
+// instead of writing them before CheckService returns (used by the background health monitor)
+func WithBufferedStatusWrites(ctx context.Context) context.Context {
+	return context.WithValue(ctx, bufferedStatusWritesKey{}, true)
 }
 
 // isPrivateIP checks if an IP address is in a private/local range
@@ -42,9 +51,14 @@ func isPrivateIP(ip net.IP) bool {
 	return false
 }
 
-// isLocalURL checks if a URL points to a local/private network address
-// Optimized with DNS caching and fast-path IP checking
+// isLocalURL checks if a URL points to a local/private network address using the system resolver
 func isLocalURL(urlStr string) bool {
+	return isLocalURLWithResolver(context.Background(), urlStr, systemResolver)
+}
+
+// isLocalURLWithResolver checks if a URL points to a local/private network address
+// Optimized with DNS caching and fast-path IP checking
+func isLocalURLWithResolver(ctx context.Context, urlStr string, r *dnsResolver) bool {
 	parsedURL, err := url.Parse(urlStr)
 	if err != nil {
 		return false
@@ -68,16 +82,8 @@ func isLocalURL(urlStr string) bool {
 		return true
 	}
 
-	// Check cache before DNS lookup
-	dnsCacheMu.RLock()
-	if cached, ok := dnsCache[host]; ok && time.Now().Before(cached.expireAt) {
-		dnsCacheMu.RUnlock()
-		return cached.isLocal
-	}
-	dnsCacheMu.RUnlock()
-
-	// Slow path: DNS lookup (only for hostnames that aren't IPs)
-	ips, err := net.LookupIP(host)
+	// Slow path: DNS lookup through the check's resolver (cached per resolver)
+	ips, err := r.lookupIP(ctx, host)
 	if err != nil {
 		// If we can't resolve, assume it might be external (safer default)
 		return false
@@ -93,56 +99,65 @@ func isLocalURL(urlStr string) bool {
 		}
 	}
 
-	// Cache the result
-	dnsCacheMu.Lock()
-	dnsCache[host] = dnsCacheEntry{
-		isLocal:  isLocal,
-		expireAt: time.Now().Add(dnsCacheTTL),
-	}
-	dnsCacheMu.Unlock()
-
 	return isLocal
 }
 
-// customTransport creates a transport that skips TLS verification only for local IPs
+// customTransport skips TLS verification only for local IPs, reusing pooled transports
 type customTransport struct {
-	baseTransport *http.Transport
+	pool     *transportPool
+	resolver *dnsResolver // Used when the request context doesn't select a resolver
 }
 
 func (t *customTransport) RoundTrip(req *http.Request) (*http.Response, error) {
-	// Check if this is a local URL
-	isLocal := isLocalURL(req.URL.String())
-
-	// Clone the base transport for this request
-	transport := t.baseTransport.Clone()
-
-	// Only skip TLS verification for local/private IPs
-	if transport.TLSClientConfig == nil {
-		transport.TLSClientConfig = &tls.Config{
-			MinVersion: tls.VersionTLS12, // Require TLS 1.2 or higher
-		}
+	ctx := req.Context()
+
+	// Check if this is a local URL, resolving it the same way the dialer will
+	resolver := dnsResolverFromContext(ctx, t.resolver)
+	isLocal := isLocalURLWithResolver(ctx, req.URL.String(), resolver)
+
+	// Pick the long-lived transport for this combination of TLS and dial settings
+	opts := transportOptionsFromContext(ctx)
+	key := transportKey{
+		skipVerify: isLocal,
+		tls:        tlsVariantKey(opts.tls),
+		resolver:   resolver.key,
+		family:     addressFamilyFromContext(ctx),
+		fresh:      opts.fresh,
 	}
-	transport.TLSClientConfig.InsecureSkipVerify = isLocal
 
-	return transport.RoundTrip(req)
+	return t.pool.get(key, opts.tls).RoundTrip(req)
 }
 
 // NewHealthCheckService creates a new health check service
-func NewHealthCheckService(serviceRepo repository.ServiceRepositoryInterface, statusLogRepo *repository.StatusLogRepository, timeout time.Duration) *HealthCheckService {
-	baseTransport := &http.Transport{
-		TLSClientConfig: &tls.Config{
-			MinVersion:         tls.VersionTLS12, // Require TLS 1.2 or higher
-			InsecureSkipVerify: false,            // Default: verify certificates
-		},
+// dnsConfig optionally overrides the system resolver for all checks (services can override it again)
+// egress enforces the admin egress policy on every connection (nil allows everything)
+func NewHealthCheckService(serviceRepo repository.ServiceRepositoryInterface, statusLogRepo *repository.StatusLogRepository, fingerprintRepo *repository.FingerprintRepository, timeout time.Duration, dnsConfig *models.DNSConfig, egress *EgressPolicyService) *HealthCheckService {
+	resolver := resolverFor(dnsConfig)
+
+	pool := newTransportPool(&http.Transport{
+		// Honour the per-check resolver, address family and egress policy
+		DialContext: checkDialContext(&net.Dialer{
+			Timeout:   30 * time.Second,
+			KeepAlive: 30 * time.Second,
+		}, resolver, egress),
+		TLSHandshakeTimeout: 10 * time.Second,
+	})
+
+	// Connections were allowed under the old policy - make them dial (and be checked) again
+	if egress != nil {
+		egress.OnUpdate(pool.CloseIdleConnections)
 	}
 
 	return &HealthCheckService{
-		serviceRepo:   serviceRepo,
-		statusLogRepo: statusLogRepo,
+		serviceRepo:     serviceRepo,
+		statusLogRepo:   statusLogRepo,
+		fingerprintRepo: fingerprintRepo,
+		checkMetrics:    NewCheckMetrics(),
 		httpClient: &http.Client{
 			Timeout: timeout,
 			Transport: &customTransport{
-				baseTransport: baseTransport,
+				pool:     pool,
+				resolver: resolver,
 			},
 			CheckRedirect: func(req *http.Request, via []*http.Request) error {
 				// Don't follow redirects - consider them successful
@@ -152,8 +167,104 @@ func NewHealthCheckService(serviceRepo repository.ServiceRepositoryInterface, st
 	}
 }
 
+// SetStatusWriter enables batched status writes for checks made with WithBufferedStatusWrites
+func (h *HealthCheckService) SetStatusWriter(w *StatusWriter) {
+	h.statusWriter = w
+}
+
+// AddResultExporter forwards every check result to an external time-series database
+func (h *HealthCheckService) AddResultExporter(e *ResultExporter) {
+	h.resultExporters = append(h.resultExporters, e)
+}
+
+// SetEventBroker pushes every check result to the owner's live clients
+func (h *HealthCheckService) SetEventBroker(b *EventBroker) {
+	h.eventBroker = b
+}
+
+// SetNotificationService sends notifications for status transitions
+// Transitions are only detected when a status event repository is set
+func (h *HealthCheckService) SetNotificationService(n *NotificationService) {
+	h.notifications = n
+}
+
+// SetAlertService applies the users' alert rules to status transitions
+// Transitions are only detected when a status event repository is set
+func (h *HealthCheckService) SetAlertService(a *AlertService) {
+	h.alerts = a
+}
+
+// SetIncidentService opens and resolves incidents on status transitions
+// Transitions are only detected when a status event repository is set
+func (h *HealthCheckService) SetIncidentService(i *IncidentService) {
+	h.incidents = i
+}
+
+// SetTracer makes every check a span, with a client span per HTTP request
+func (h *HealthCheckService) SetTracer(t *Tracer) {
+	h.tracer = t
+}
+
 // CheckService performs a health check on a single service
 func (h *HealthCheckService) CheckService(ctx context.Context, service *models.Service) error {
+	ctx, span := h.tracer.StartSpan(ctx, "health_check", SpanKindInternal,
+		Attribute{"nimbus.service.id", service.ID},
+		Attribute{"nimbus.service.name", service.Name},
+	)
+	defer span.Finish()
+
+	// Attribute blocked connections to the service owner in the activity log
+	ctx = withEgressOwner(ctx, models.EgressPurposeHealthCheck, service.UserID, service.ID)
+
+	// Per-service TLS options and connection reuse
+	ctx = withTransportOptions(ctx, service.CheckConfig)
+
+	// Per-service DNS server override (split-horizon names)
+	if resolver := resolverFor(service.CheckConfig.DNS); resolver != nil {
+		ctx = withDNSResolver(ctx, resolver)
+	}
+
+	// Know the service's previous status so a change can be recorded as an event
+	if h.statusEvents != nil {
+		h.statusEvents.seed(ctx, service)
+	}
+
+	family := service.CheckConfig.AddressFamily
+	if family == models.AddressFamilyBoth {
+		return h.checkDualStack(ctx, service)
+	}
+
+	return h.updateStatus(ctx, service, h.performCheck(withAddressFamily(ctx, family), service, true))
+}
+
+// checkDualStack checks a service over IPv4 and IPv6 separately and records a result for each
+// The service is only reported online if both families are healthy
+func (h *HealthCheckService) checkDualStack(ctx context.Context, service *models.Service) error {
+	families := []string{models.AddressFamilyIPv4, models.AddressFamilyIPv6}
+	statusLogs := make([]*models.StatusLog, len(families))
+
+	var wg sync.WaitGroup
+	for i, family := range families {
+		wg.Add(1)
+		go func(i int, family string) {
+			defer wg.Done()
+			// Only fingerprint one family - different backends per family would report spurious changes
+			statusLogs[i] = h.performCheck(withAddressFamily(ctx, family), service, i == 0)
+		}(i, family)
+	}
+	wg.Wait()
+
+	return h.updateStatus(ctx, service, statusLogs...)
+}
+
+// performCheck runs the configured check for a service and returns its result without saving it
+func (h *HealthCheckService) performCheck(ctx context.Context, service *models.Service, fingerprint bool) *models.StatusLog {
+	// Multi-step transaction checks replace the single GET request
+	if service.CheckConfig.IsTransaction() {
+		return h.runTransaction(ctx, service)
+	}
+
+	requestedFamily := addressFamilyFromContext(ctx)
 	start := time.Now()
 
 	// Create request with context for cancellation
@@ -161,36 +272,106 @@ func (h *HealthCheckService) CheckService(ctx context.Context, service *models.S
 	if err != nil {
 		// Invalid URL - mark as offline
 		errorMsg := err.Error()
-		return h.updateStatus(ctx, service.ID, models.StatusOffline, nil, &errorMsg)
+		return &models.StatusLog{
+			ServiceID:    service.ID,
+			Status:       models.StatusOffline,
+			ErrorMessage: &errorMsg,
+		}
 	}
 
 	// Set user agent
 	req.Header.Set("User-Agent", "Nimbus-HealthCheck/1.0")
 
+	ctx, span := h.tracer.StartSpan(ctx, http.MethodGet, SpanKindClient,
+		Attribute{"http.request.method", http.MethodGet},
+		Attribute{"url.full", service.URL},
+	)
+	defer span.Finish()
+	if span != nil {
+		// Let the checked service continue the trace
+		req.Header.Set("traceparent", span.Context().Traceparent())
+		req = req.WithContext(ctx)
+	}
+
+	// Record which address family the connection actually used, and the timing of each phase
+	var connFamily string
+	phases := newPhaseTimer(start)
+	trace := phases.trace()
+	trace.GotConn = func(info httptrace.GotConnInfo) {
+		connFamily = familyOfAddr(info.Conn.RemoteAddr())
+	}
+	req = req.WithContext(httptrace.WithClientTrace(req.Context(), trace))
+
 	// Perform the request
 	resp, err := h.httpClient.Do(req)
 	responseTime := int(time.Since(start).Milliseconds())
+	measured := phases.durations()
+	h.checkMetrics.observePhases(service.ID, measured)
+	phases.annotate(span, measured)
 
 	if err != nil {
 		// Request failed - service is offline
 		errorMsg := err.Error()
-		return h.updateStatus(ctx, service.ID, models.StatusOffline, &responseTime, &errorMsg)
+		span.SetStatus(SpanStatusError, errorMsg)
+		return &models.StatusLog{
+			ServiceID:     service.ID,
+			Status:        models.StatusOffline,
+			ResponseTime:  &responseTime,
+			ErrorMessage:  &errorMsg,
+			AddressFamily: resolvedFamily(connFamily, requestedFamily),
+		}
 	}
 	defer resp.Body.Close()
+	span.SetAttributes(Attribute{"http.response.status_code", resp.StatusCode})
+	h.certificates.record(service.ID, resp.TLS)
+
+	statusLog := &models.StatusLog{
+		ServiceID:     service.ID,
+		ResponseTime:  &responseTime,
+		AddressFamily: resolvedFamily(connFamily, requestedFamily),
+	}
 
 	// Consider 2xx and 3xx status codes as "online"
 	// 4xx and 5xx are considered "offline" (service is responding but not healthy)
-	var status string
-	var errorMsg *string
 	if resp.StatusCode >= 200 && resp.StatusCode < 400 {
-		status = models.StatusOnline
+		statusLog.Status = models.StatusOnline
 	} else {
-		status = models.StatusOffline
+		statusLog.Status = models.StatusOffline
 		msg := fmt.Sprintf("HTTP %d", resp.StatusCode)
-		errorMsg = &msg
+		statusLog.ErrorMessage = &msg
+		span.SetStatus(SpanStatusError, msg)
+	}
+
+	// Only fingerprint healthy responses - error pages would report spurious changes
+	if fingerprint && statusLog.Status == models.StatusOnline && service.CheckConfig.FingerprintEnabled() && h.fingerprintRepo != nil {
+		h.fingerprintResponse(service, resp, statusLog)
+	}
+
+	return statusLog
+}
+
+// fingerprintResponse fingerprints the response and flags the status log if it changed
+// Errors are logged but never fail the health check
+func (h *HealthCheckService) fingerprintResponse(service *models.Service, resp *http.Response, statusLog *models.StatusLog) {
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxFingerprintBodySize))
+	if err != nil {
+		fmt.Printf("Failed to read response body for fingerprint of service %s: %v\n", service.ID, err)
+		return
+	}
+
+	fp := computeFingerprint(service.ID, resp, body, service.CheckConfig.FingerprintHeaders())
+	changed, err := h.recordFingerprint(fp)
+	if err != nil {
+		fmt.Printf("Failed to record fingerprint for service %s: %v\n", service.ID, err)
+		return
 	}
 
-	return h.updateStatus(ctx, service.ID, status, &responseTime, errorMsg)
+	if len(changed) > 0 {
+		event := models.EventFingerprintChanged
+		detail := "Response changed: " + strings.Join(changed, ", ")
+		statusLog.Event = &event
+		statusLog.EventDetail = &detail
+	}
 }
 
 // CheckAllServices checks all services for a specific user
@@ -226,32 +407,102 @@ func (h *HealthCheckService) CheckAllServicesForAllUsers(ctx context.Context) er
 	return fmt.Errorf("not implemented yet - check services per user")
 }
 
-// updateStatus is a helper to update service status and response time, and create a status log entry
+// updateStatus is a helper to update service status and response time, and create status log entries
+// With several results (dual-stack checks) the service is online only if all of them are,
+// and the slowest response time is reported
 // Uses a background context to ensure status updates persist even if the check request is cancelled
-func (h *HealthCheckService) updateStatus(ctx context.Context, serviceID, status string, responseTime *int, errorMessage *string) error {
+// Checks made with WithBufferedStatusWrites are queued on the status writer when one is set
+func (h *HealthCheckService) updateStatus(ctx context.Context, service *models.Service, statusLogs ...*models.StatusLog) error {
+	if len(statusLogs) == 0 {
+		return nil
+	}
+
+	status := models.StatusOnline
+	var responseTime *int
+	var errorMessage *string
+	for _, statusLog := range statusLogs {
+		if statusLog.Status != models.StatusOnline {
+			status = statusLog.Status
+			if errorMessage == nil {
+				errorMessage = statusLog.ErrorMessage
+			}
+		}
+		if statusLog.ResponseTime != nil && (responseTime == nil || *statusLog.ResponseTime > *responseTime) {
+			responseTime = statusLog.ResponseTime
+		}
+	}
+
+	checkedAt := time.Now()
+	for _, statusLog := range statusLogs {
+		statusLog.CheckedAt = checkedAt
+	}
+
+	h.checkMetrics.observeCheck(statusLogs[0].ServiceID, status, responseTime, checkedAt)
+	for _, exporter := range h.resultExporters {
+		for _, statusLog := range statusLogs {
+			exporter.Export(CheckResultRecord{
+				ServiceID:     statusLog.ServiceID,
+				ServiceName:   service.Name,
+				Status:        statusLog.Status,
+				ResponseTime:  statusLog.ResponseTime,
+				AddressFamily: statusLog.AddressFamily,
+				CheckedAt:     checkedAt,
+			})
+		}
+	}
+	h.eventBroker.Publish(service.UserID, EventServiceStatus, service.ID, ServiceStatusEventData{
+		ID:           service.ID,
+		Status:       status,
+		ResponseTime: responseTime,
+		ErrorMessage: errorMessage,
+		CheckedAt:    checkedAt,
+	})
+	if span := SpanFromContext(ctx); span != nil {
+		span.SetAttributes(Attribute{"nimbus.check.result", status})
+		if responseTime != nil {
+			span.SetAttributes(Attribute{"nimbus.check.response_time_ms", *responseTime})
+		}
+		if status != models.StatusOnline && errorMessage != nil {
+			span.SetStatus(SpanStatusError, *errorMessage)
+		}
+	}
+
+	// Record the transition right away - events are rare and shouldn't wait for the status writer
+	if h.statusEvents != nil {
+		eventCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		event := h.statusEvents.record(eventCtx, statusLogs[0].ServiceID, status, checkedAt, errorMessage)
+		cancel()
+		h.incidents.HandleTransition(service, event)
+		h.notifications.NotifyTransition(service, event)
+		h.alerts.HandleTransition(service, event)
+	}
+
+	// Background checks hand the result to the status writer; if its queue is full
+	// (counted as an overflow) the result is written synchronously below
+	if buffered, _ := ctx.Value(bufferedStatusWritesKey{}).(bool); buffered && h.statusWriter != nil {
+		update := repository.ServiceStatusUpdate{ID: statusLogs[0].ServiceID, Status: status, ResponseTime: responseTime}
+		if h.statusWriter.Enqueue(update, statusLogs) {
+			return nil
+		}
+	}
+
 	// Create independent context with timeout for DB update
 	// This ensures status is saved even if the HTTP check context is cancelled
 	updateCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
 	// Update the service's current status
-	if err := h.serviceRepo.UpdateStatusWithResponseTime(updateCtx, serviceID, status, responseTime); err != nil {
+	if err := h.serviceRepo.UpdateStatusWithResponseTime(updateCtx, statusLogs[0].ServiceID, status, responseTime); err != nil {
 		return err
 	}
 
-	// Create status log entry if statusLogRepo is available
+	// Create status log entries if statusLogRepo is available
 	if h.statusLogRepo != nil {
-		statusLog := &models.StatusLog{
-			ServiceID:    serviceID,
-			Status:       status,
-			ResponseTime: responseTime,
-			ErrorMessage: errorMessage,
-			CheckedAt:    time.Now(),
-		}
-
-		// Log creation errors but don't fail the health check
-		if err := h.statusLogRepo.Create(updateCtx, statusLog); err != nil {
-			fmt.Printf("Failed to create status log for service %s: %v\n", serviceID, err)
+		for _, statusLog := range statusLogs {
+			// Log creation errors but don't fail the health check
+			if err := h.statusLogRepo.Create(updateCtx, statusLog); err != nil {
+				fmt.Printf("Failed to create status log for service %s: %v\n", statusLog.ServiceID, err)
+			}
 		}
 	}
 
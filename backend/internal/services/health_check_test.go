@@ -2,6 +2,7 @@ package services
 
 import (
 	"context"
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -400,39 +401,47 @@ func TestIsLocalURL_DNSResolution(t *testing.T) {
 func TestIsLocalURL_DNSCache(t *testing.T) {
 	// Clear the cache before testing
 	dnsCacheMu.Lock()
-	dnsCache = make(map[string]dnsCacheEntry)
+	dnsCache = make(map[dnsCacheKey]dnsCacheEntry)
 	dnsCacheMu.Unlock()
 
-	// Use a real domain that will trigger DNS lookup (not localhost which has a fast-path)
-	// We use example.com which is guaranteed to exist per RFC 2606
+	// Use a stub DNS server so the lookup doesn't depend on the network
+	server, queries := startTestDNSServer(t, map[string][]net.IP{
+		"example.com": {net.ParseIP("93.184.215.14")},
+	})
+	resolver := resolverFor(&models.DNSConfig{Server: server})
+
 	url := "https://example.com"
 	hostname := "example.com"
 
 	// First call should populate cache
-	result1 := isLocalURL(url)
-	// example.com should resolve to public IPs, so should return false
+	result1 := isLocalURLWithResolver(context.Background(), url, resolver)
+	// example.com resolves to a public IP, so should return false
 	if result1 {
 		t.Error("Expected example.com to NOT be local (has public IPs)")
 	}
 
-	// Check cache was populated (DNS lookup happens for non-localhost hostnames)
+	// Check cache was populated under this resolver's key
 	dnsCacheMu.RLock()
-	cached, exists := dnsCache[hostname]
+	cached, exists := dnsCache[dnsCacheKey{resolver: resolver.key, host: hostname}]
 	dnsCacheMu.RUnlock()
 
 	if !exists {
-		t.Error("Expected 'example.com' to be in cache after DNS lookup")
+		t.Fatal("Expected 'example.com' to be in cache after DNS lookup")
 	}
 
-	if cached.isLocal {
-		t.Error("Expected cached value for 'example.com' to be false (public domain)")
+	if cached.err != nil || len(cached.ips) != 1 || cached.ips[0].IsPrivate() {
+		t.Errorf("Expected cached public IP for 'example.com', got %v (err: %v)", cached.ips, cached.err)
 	}
 
-	// Second call should use cache (result should be consistent)
-	result2 := isLocalURL(url)
+	// Second call should use cache (result should be consistent, no new queries)
+	before := queries.Load()
+	result2 := isLocalURLWithResolver(context.Background(), url, resolver)
 	if result1 != result2 {
 		t.Error("Cache should return consistent results")
 	}
+	if queries.Load() != before {
+		t.Error("Expected second lookup to be served from cache")
+	}
 
 	// Verify cache expiration is set correctly
 	if cached.expireAt.Before(time.Now()) {
@@ -0,0 +1,475 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nimbus/backend/internal/models"
+	"github.com/nimbus/backend/internal/repository"
+)
+
+const (
+	// DefaultIncidentListLimit is how many incidents are listed when no limit is given
+	DefaultIncidentListLimit = 50
+
+	// MaxIncidentListLimit caps the limit of a listing
+	MaxIncidentListLimit = 500
+
+	maxIncidentNoteLength = 10000
+	maxIncidentServices   = 50
+)
+
+var (
+	// ErrInvalidIncident is returned when an incident, note or service link is invalid
+	ErrInvalidIncident = errors.New("invalid incident")
+
+	// ErrIncidentResolved is returned when changing an incident that is already resolved
+	ErrIncidentResolved = errors.New("incident is resolved")
+)
+
+// IncidentService keeps the incident record of outages
+// An incident opens when a service goes offline (unless an unresolved incident already covers it)
+// and resolves once all of its services are back online. Users acknowledge incidents, which
+// silences alert escalation, add notes and link further affected services
+type IncidentService struct {
+	repo            *repository.IncidentRepository
+	serviceRepo     repository.ServiceRepositoryInterface
+	statusEventRepo *repository.StatusEventRepository
+	activityRepo    *repository.ActivityLogRepository
+	now             func() time.Time
+
+	mu sync.Mutex // Serializes automatic opening and resolution
+}
+
+// NewIncidentService creates an incident service
+func NewIncidentService(repo *repository.IncidentRepository, serviceRepo repository.ServiceRepositoryInterface, statusEventRepo *repository.StatusEventRepository, activityRepo *repository.ActivityLogRepository) *IncidentService {
+	return &IncidentService{
+		repo:            repo,
+		serviceRepo:     serviceRepo,
+		statusEventRepo: statusEventRepo,
+		activityRepo:    activityRepo,
+		now:             time.Now,
+	}
+}
+
+// HandleTransition opens an incident when a service goes offline and resolves its incidents when
+// it recovers. Safe to call on a nil service
+func (s *IncidentService) HandleTransition(service *models.Service, event *models.StatusEvent) {
+	if s == nil || event == nil {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	switch {
+	case isDownTransition(event):
+		s.open(ctx, service, event)
+	case isRecoveryTransition(event):
+		s.resolveRecovered(ctx, service, event)
+	}
+}
+
+func (s *IncidentService) open(ctx context.Context, service *models.Service, event *models.StatusEvent) {
+	unresolved, err := s.repo.GetUnresolvedByServiceID(ctx, service.ID)
+	if err != nil {
+		fmt.Printf("Failed to get incidents of service %s: %v\n", service.ID, err)
+		return
+	}
+	if len(unresolved) > 0 {
+		return
+	}
+
+	now := s.now()
+	incident := &models.Incident{
+		UserID:    service.UserID,
+		Title:     fmt.Sprintf("%s is down", service.Name),
+		Status:    models.IncidentStatusOpen,
+		StartedAt: event.OccurredAt,
+		Services:  []models.IncidentService{{ServiceID: service.ID, AddedAt: now}},
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := s.repo.Create(ctx, incident); err != nil {
+		fmt.Printf("Failed to open incident for service %s: %v\n", service.ID, err)
+	}
+}
+
+// resolveRecovered resolves the service's incidents whose other services are online too
+func (s *IncidentService) resolveRecovered(ctx context.Context, service *models.Service, event *models.StatusEvent) {
+	unresolved, err := s.repo.GetUnresolvedByServiceID(ctx, service.ID)
+	if err != nil {
+		fmt.Printf("Failed to get incidents of service %s: %v\n", service.ID, err)
+		return
+	}
+
+	for _, incident := range unresolved {
+		if s.othersOffline(ctx, incident, service.ID) {
+			continue
+		}
+		if _, err := s.repo.Resolve(ctx, incident.ID, nil, event.OccurredAt); err != nil {
+			fmt.Printf("Failed to resolve incident %s: %v\n", incident.ID, err)
+		}
+	}
+}
+
+// othersOffline reports whether an incident service other than the recovered one is still offline
+func (s *IncidentService) othersOffline(ctx context.Context, incident *models.Incident, recoveredID string) bool {
+	for _, linked := range incident.Services {
+		if linked.ServiceID == recoveredID {
+			continue
+		}
+		service, err := s.serviceRepo.GetByID(ctx, linked.ServiceID)
+		if err != nil {
+			fmt.Printf("Failed to get service %s: %v\n", linked.ServiceID, err)
+			return true
+		}
+		if service != nil && service.Status == models.StatusOffline {
+			return true
+		}
+	}
+	return false
+}
+
+// Acknowledged reports whether a service is affected by an acknowledged incident
+// Used to silence alert escalation. Safe to call on a nil service
+func (s *IncidentService) Acknowledged(ctx context.Context, serviceID string) bool {
+	if s == nil {
+		return false
+	}
+
+	acknowledged, err := s.repo.IsAcknowledged(ctx, serviceID)
+	if err != nil {
+		fmt.Printf("Failed to check incidents of service %s: %v\n", serviceID, err)
+		return false
+	}
+	return acknowledged
+}
+
+// List returns the user's incidents, newest first
+// status is open, acknowledged, resolved, active (not resolved) or empty for all
+func (s *IncidentService) List(ctx context.Context, userID, status string, limit int) ([]*models.Incident, error) {
+	switch status {
+	case "", "active", models.IncidentStatusOpen, models.IncidentStatusAcknowledged, models.IncidentStatusResolved:
+	default:
+		return nil, fmt.Errorf("%w: status must be open, acknowledged, resolved or active", ErrInvalidIncident)
+	}
+	if limit <= 0 {
+		limit = DefaultIncidentListLimit
+	}
+	limit = min(limit, MaxIncidentListLimit)
+
+	incidents, err := s.repo.GetByUserID(ctx, userID, status, limit)
+	if err != nil {
+		return nil, err
+	}
+	if incidents == nil {
+		incidents = []*models.Incident{}
+	}
+	return incidents, nil
+}
+
+// Get returns one of the user's incidents with its notes
+// Returns sql.ErrNoRows if it doesn't exist or belongs to another user
+func (s *IncidentService) Get(ctx context.Context, userID, id string) (*models.IncidentDetail, error) {
+	incident, err := s.get(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	notes, err := s.repo.GetNotes(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return &models.IncidentDetail{Incident: incident, Notes: notes}, nil
+}
+
+// Create opens an incident manually
+func (s *IncidentService) Create(ctx context.Context, userID string, req *models.CreateIncidentRequest) (*models.Incident, error) {
+	title := strings.TrimSpace(req.Title)
+	if title == "" || len(title) > 255 {
+		return nil, fmt.Errorf("%w: title is required (at most 255 characters)", ErrInvalidIncident)
+	}
+
+	serviceIDs, err := s.checkServices(ctx, userID, req.ServiceIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.now()
+	incident := &models.Incident{
+		UserID:    userID,
+		Title:     title,
+		Status:    models.IncidentStatusOpen,
+		StartedAt: now,
+		Services:  []models.IncidentService{},
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	for _, serviceID := range serviceIDs {
+		incident.Services = append(incident.Services, models.IncidentService{ServiceID: serviceID, AddedAt: now})
+	}
+
+	if err := s.repo.Create(ctx, incident); err != nil {
+		return nil, err
+	}
+	return s.repo.GetByID(ctx, incident.ID)
+}
+
+// Acknowledge marks an open incident as acknowledged by the user (acknowledging twice does nothing)
+// Returns sql.ErrNoRows if it doesn't exist or belongs to another user
+func (s *IncidentService) Acknowledge(ctx context.Context, userID, id, ipAddress string) (*models.Incident, error) {
+	incident, err := s.get(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	if incident.IsResolved() {
+		return nil, ErrIncidentResolved
+	}
+
+	acknowledged, err := s.repo.Acknowledge(ctx, id, userID, s.now())
+	if err != nil {
+		return nil, err
+	}
+	if acknowledged {
+		s.logActivity(ctx, userID, models.ActionIncidentAcknowledged, ipAddress, map[string]interface{}{
+			"incident_id": id,
+			"title":       incident.Title,
+		})
+	}
+
+	return s.repo.GetByID(ctx, id)
+}
+
+// Resolve marks an incident as resolved by the user
+// Returns sql.ErrNoRows if it doesn't exist or belongs to another user
+func (s *IncidentService) Resolve(ctx context.Context, userID, id string) (*models.Incident, error) {
+	if _, err := s.get(ctx, userID, id); err != nil {
+		return nil, err
+	}
+
+	resolved, err := s.repo.Resolve(ctx, id, &userID, s.now())
+	if err != nil {
+		return nil, err
+	}
+	if !resolved {
+		return nil, ErrIncidentResolved
+	}
+
+	return s.repo.GetByID(ctx, id)
+}
+
+// AddNote adds a note to an incident (resolved incidents too, e.g. for a post-mortem)
+// Returns sql.ErrNoRows if it doesn't exist or belongs to another user
+func (s *IncidentService) AddNote(ctx context.Context, userID, id, body, ipAddress string) (*models.IncidentNote, error) {
+	body = strings.TrimSpace(body)
+	if body == "" || len(body) > maxIncidentNoteLength {
+		return nil, fmt.Errorf("%w: note is required (at most %d characters)", ErrInvalidIncident, maxIncidentNoteLength)
+	}
+
+	incident, err := s.get(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	note := &models.IncidentNote{IncidentID: id, UserID: &userID, Body: body, CreatedAt: s.now()}
+	if err := s.repo.AddNote(ctx, note); err != nil {
+		return nil, err
+	}
+
+	s.logActivity(ctx, userID, models.ActionIncidentNoteAdded, ipAddress, map[string]interface{}{
+		"incident_id": id,
+		"title":       incident.Title,
+		"note_id":     note.ID,
+	})
+
+	return note, nil
+}
+
+// LinkServices adds affected services to an unresolved incident
+// Returns sql.ErrNoRows if it doesn't exist or belongs to another user
+func (s *IncidentService) LinkServices(ctx context.Context, userID, id string, serviceIDs []string) (*models.Incident, error) {
+	incident, err := s.get(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	if incident.IsResolved() {
+		return nil, ErrIncidentResolved
+	}
+
+	serviceIDs, err = s.checkServices(ctx, userID, serviceIDs)
+	if err != nil {
+		return nil, err
+	}
+	if len(serviceIDs) == 0 {
+		return nil, fmt.Errorf("%w: service_ids is required", ErrInvalidIncident)
+	}
+	if len(incident.Services)+len(serviceIDs) > maxIncidentServices {
+		return nil, fmt.Errorf("%w: an incident can affect at most %d services", ErrInvalidIncident, maxIncidentServices)
+	}
+
+	now := s.now()
+	for _, serviceID := range serviceIDs {
+		if err := s.repo.LinkService(ctx, id, serviceID, now); err != nil {
+			return nil, err
+		}
+	}
+
+	return s.repo.GetByID(ctx, id)
+}
+
+// UnlinkService removes a service from an incident
+// Returns sql.ErrNoRows if the incident doesn't exist, belongs to another user or doesn't affect the service
+func (s *IncidentService) UnlinkService(ctx context.Context, userID, id, serviceID string) (*models.Incident, error) {
+	if _, err := s.get(ctx, userID, id); err != nil {
+		return nil, err
+	}
+	if err := s.repo.UnlinkService(ctx, id, serviceID); err != nil {
+		return nil, err
+	}
+	return s.repo.GetByID(ctx, id)
+}
+
+// Timeline returns what happened during an incident, oldest first: its opening, the status
+// events of its services, service links, the acknowledgement, notes and the resolution
+// Returns sql.ErrNoRows if it doesn't exist or belongs to another user
+func (s *IncidentService) Timeline(ctx context.Context, userID, id string) ([]models.IncidentTimelineEntry, error) {
+	incident, err := s.get(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	timeline := []models.IncidentTimelineEntry{{Type: models.IncidentTimelineOpened, OccurredAt: incident.StartedAt}}
+
+	end := s.now()
+	if incident.ResolvedAt != nil {
+		end = *incident.ResolvedAt
+	}
+	for _, linked := range incident.Services {
+		events, err := s.statusEventRepo.GetByServiceID(ctx, linked.ServiceID, incident.StartedAt, end)
+		if err != nil {
+			return nil, err
+		}
+		for _, event := range events {
+			timeline = append(timeline, models.IncidentTimelineEntry{
+				Type:         models.IncidentTimelineStatusChange,
+				OccurredAt:   event.OccurredAt,
+				ServiceID:    linked.ServiceID,
+				ServiceName:  linked.ServiceName,
+				FromStatus:   event.FromStatus,
+				ToStatus:     event.ToStatus,
+				ErrorMessage: event.ErrorMessage,
+			})
+		}
+
+		// Services linked after the incident opened
+		if linked.AddedAt.After(incident.CreatedAt) {
+			timeline = append(timeline, models.IncidentTimelineEntry{
+				Type:        models.IncidentTimelineServiceLinked,
+				OccurredAt:  linked.AddedAt,
+				ServiceID:   linked.ServiceID,
+				ServiceName: linked.ServiceName,
+			})
+		}
+	}
+
+	if incident.AcknowledgedAt != nil {
+		timeline = append(timeline, models.IncidentTimelineEntry{
+			Type:       models.IncidentTimelineAcknowledged,
+			OccurredAt: *incident.AcknowledgedAt,
+			UserID:     incident.AcknowledgedBy,
+		})
+	}
+
+	notes, err := s.repo.GetNotes(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	for _, note := range notes {
+		timeline = append(timeline, models.IncidentTimelineEntry{
+			Type:       models.IncidentTimelineNote,
+			OccurredAt: note.CreatedAt,
+			UserID:     note.UserID,
+			Body:       note.Body,
+		})
+	}
+
+	if incident.ResolvedAt != nil {
+		timeline = append(timeline, models.IncidentTimelineEntry{
+			Type:       models.IncidentTimelineResolved,
+			OccurredAt: *incident.ResolvedAt,
+			UserID:     incident.ResolvedBy,
+		})
+	}
+
+	sort.SliceStable(timeline, func(i, j int) bool {
+		return timeline[i].OccurredAt.Before(timeline[j].OccurredAt)
+	})
+	return timeline, nil
+}
+
+// get returns one of the user's incidents; other users' incidents are reported as missing
+func (s *IncidentService) get(ctx context.Context, userID, id string) (*models.Incident, error) {
+	incident, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if incident.UserID != userID {
+		return nil, sql.ErrNoRows
+	}
+	return incident, nil
+}
+
+// checkServices deduplicates service IDs and checks they belong to the user
+func (s *IncidentService) checkServices(ctx context.Context, userID string, ids []string) ([]string, error) {
+	selected := []string{}
+	for _, id := range ids {
+		if id == "" || slices.Contains(selected, id) {
+			continue
+		}
+		selected = append(selected, id)
+	}
+	if len(selected) > maxIncidentServices {
+		return nil, fmt.Errorf("%w: an incident can affect at most %d services", ErrInvalidIncident, maxIncidentServices)
+	}
+
+	for _, id := range selected {
+		service, err := s.serviceRepo.GetByID(ctx, id)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return nil, err
+		}
+		if err != nil || service.UserID != userID {
+			return nil, fmt.Errorf("%w: unknown service %q", ErrInvalidIncident, id)
+		}
+	}
+	return selected, nil
+}
+
+func (s *IncidentService) logActivity(ctx context.Context, userID, action, ipAddress string, details map[string]interface{}) {
+	if s.activityRepo == nil {
+		return
+	}
+
+	entry := &models.UserActivityLog{
+		UserID:  &userID,
+		ActorID: &userID,
+		Action:  action,
+		Details: details,
+	}
+	if ipAddress != "" {
+		entry.IPAddress = &ipAddress
+	}
+	if err := s.activityRepo.Create(ctx, entry); err != nil {
+		fmt.Printf("Failed to log %s: %v\n", action, err)
+	}
+}
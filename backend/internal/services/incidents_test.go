@@ -0,0 +1,310 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/nimbus/backend/internal/models"
+	"github.com/nimbus/backend/internal/repository"
+)
+
+// createIncidentTables adds the incident, status event and activity log tables to a test database
+func createIncidentTables(t *testing.T, db *sql.DB) {
+	t.Helper()
+	_, err := db.Exec(`
+		CREATE TABLE incidents (
+			id TEXT PRIMARY KEY DEFAULT (lower(hex(randomblob(16)))),
+			user_id TEXT NOT NULL,
+			title TEXT NOT NULL,
+			status TEXT NOT NULL DEFAULT 'open',
+			started_at TIMESTAMP NOT NULL,
+			acknowledged_at TIMESTAMP,
+			acknowledged_by TEXT,
+			resolved_at TIMESTAMP,
+			resolved_by TEXT,
+			created_at TIMESTAMP NOT NULL,
+			updated_at TIMESTAMP NOT NULL
+		);
+
+		CREATE TABLE incident_services (
+			incident_id TEXT NOT NULL,
+			service_id TEXT NOT NULL,
+			added_at TIMESTAMP NOT NULL,
+			PRIMARY KEY (incident_id, service_id)
+		);
+
+		CREATE TABLE incident_notes (
+			id TEXT PRIMARY KEY DEFAULT (lower(hex(randomblob(16)))),
+			incident_id TEXT NOT NULL,
+			user_id TEXT,
+			body TEXT NOT NULL,
+			created_at TIMESTAMP NOT NULL
+		);
+
+		CREATE TABLE IF NOT EXISTS service_status_events (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			service_id TEXT NOT NULL,
+			from_status TEXT NOT NULL,
+			to_status TEXT NOT NULL,
+			occurred_at TIMESTAMP NOT NULL,
+			previous_duration_ms INTEGER,
+			error_message TEXT
+		);
+
+		CREATE TABLE IF NOT EXISTS user_activity_logs (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			user_id TEXT,
+			actor_id TEXT,
+			action TEXT NOT NULL,
+			details TEXT,
+			ip_address TEXT,
+			created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+		);
+
+		INSERT INTO services (id, user_id, name, url, description, status, position)
+		VALUES ('foreign-service', 'user-2', 'Foreign', 'http://foreign.lan', '', 'online', 0);
+	`)
+	if err != nil {
+		t.Fatalf("Failed to create incident tables: %v", err)
+	}
+}
+
+// newTestIncidentService returns an incident service on a fake clock
+// user-1 has test-service-1 (online) and test-service-2 (offline)
+func newTestIncidentService(t *testing.T, start time.Time) (*IncidentService, *alertClock, *sql.DB) {
+	db := setupMetricsTestDB(t)
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+	createIncidentTables(t, db)
+
+	clock := &alertClock{now: start}
+	s := NewIncidentService(
+		repository.NewIncidentRepository(db),
+		repository.NewServiceRepository(db),
+		repository.NewStatusEventRepository(db),
+		repository.NewActivityLogRepository(db),
+	)
+	s.now = clock.Now
+	return s, clock, db
+}
+
+// recordTransition stores a status event and the new status, then hands it to the incident service
+func recordTransition(t *testing.T, s *IncidentService, db *sql.DB, serviceID, from, to string, at time.Time) {
+	t.Helper()
+	event := &models.StatusEvent{ServiceID: serviceID, FromStatus: from, ToStatus: to, OccurredAt: at}
+	if err := repository.NewStatusEventRepository(db).Create(context.Background(), event); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	service, err := s.serviceRepo.GetByID(context.Background(), serviceID)
+	if err != nil || service == nil {
+		t.Fatalf("GetByID() = %v, %v", service, err)
+	}
+	if err := s.serviceRepo.UpdateStatus(context.Background(), serviceID, to); err != nil {
+		t.Fatalf("UpdateStatus() error = %v", err)
+	}
+	s.HandleTransition(service, event)
+}
+
+func TestIncidentService_Lifecycle(t *testing.T) {
+	start := time.Date(2026, 3, 2, 12, 0, 0, 0, time.UTC)
+	s, clock, db := newTestIncidentService(t, start)
+	ctx := context.Background()
+
+	// Going offline opens an incident; staying offline (or a second outage) doesn't open another
+	recordTransition(t, s, db, "test-service-1", models.StatusOnline, models.StatusOffline, start)
+	recordTransition(t, s, db, "test-service-1", models.StatusOffline, models.StatusOffline, start.Add(time.Minute))
+	incidents, err := s.List(ctx, "user-1", "active", 0)
+	if err != nil || len(incidents) != 1 {
+		t.Fatalf("List() = %d, %v", len(incidents), err)
+	}
+	incident := incidents[0]
+	if incident.Title != "Test Service 1 is down" || incident.Status != models.IncidentStatusOpen || !incident.StartedAt.Equal(start) {
+		t.Errorf("Unexpected incident %+v", incident)
+	}
+
+	// Linking the offline service keeps the incident open until both recover
+	clock.Advance(2 * time.Minute)
+	incident, err = s.LinkServices(ctx, "user-1", incident.ID, []string{"test-service-2", "test-service-2"})
+	if err != nil || len(incident.Services) != 2 {
+		t.Fatalf("LinkServices() = %+v, %v", incident, err)
+	}
+	if _, err := s.LinkServices(ctx, "user-1", incident.ID, []string{"foreign-service"}); !errors.Is(err, ErrInvalidIncident) {
+		t.Errorf("Expected ErrInvalidIncident linking another user's service, got %v", err)
+	}
+
+	clock.Advance(time.Minute)
+	acknowledged, err := s.Acknowledge(ctx, "user-1", incident.ID, "10.0.0.1")
+	if err != nil || acknowledged.Status != models.IncidentStatusAcknowledged || *acknowledged.AcknowledgedBy != "user-1" {
+		t.Fatalf("Acknowledge() = %+v, %v", acknowledged, err)
+	}
+	if !s.Acknowledged(ctx, "test-service-2") {
+		t.Error("Expected the linked service to be acknowledged")
+	}
+	if _, err := s.Acknowledge(ctx, "user-1", incident.ID, ""); err != nil {
+		t.Errorf("Expected acknowledging twice to succeed, got %v", err)
+	}
+
+	clock.Advance(time.Minute)
+	if _, err := s.AddNote(ctx, "user-1", incident.ID, "  Restarting the NAS  ", "10.0.0.1"); err != nil {
+		t.Fatalf("AddNote() error = %v", err)
+	}
+	if _, err := s.AddNote(ctx, "user-1", incident.ID, " ", ""); !errors.Is(err, ErrInvalidIncident) {
+		t.Errorf("Expected ErrInvalidIncident for an empty note, got %v", err)
+	}
+
+	recordTransition(t, s, db, "test-service-1", models.StatusOffline, models.StatusOnline, start.Add(5*time.Minute))
+	if detail, _ := s.Get(ctx, "user-1", incident.ID); detail.IsResolved() {
+		t.Fatal("Expected the incident to stay open while a service is offline")
+	}
+	recordTransition(t, s, db, "test-service-2", models.StatusOffline, models.StatusOnline, start.Add(6*time.Minute))
+
+	detail, err := s.Get(ctx, "user-1", incident.ID)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if !detail.IsResolved() || detail.ResolvedBy != nil || !detail.ResolvedAt.Equal(start.Add(6*time.Minute)) {
+		t.Errorf("Expected the incident to resolve on recovery, got %+v", detail.Incident)
+	}
+	if len(detail.Notes) != 1 || detail.Notes[0].Body != "Restarting the NAS" {
+		t.Errorf("Unexpected notes %+v", detail.Notes)
+	}
+	if s.Acknowledged(ctx, "test-service-2") {
+		t.Error("Expected resolved incidents not to count as acknowledged")
+	}
+	if _, err := s.Acknowledge(ctx, "user-1", incident.ID, ""); !errors.Is(err, ErrIncidentResolved) {
+		t.Errorf("Expected ErrIncidentResolved, got %v", err)
+	}
+
+	timeline, err := s.Timeline(ctx, "user-1", incident.ID)
+	if err != nil {
+		t.Fatalf("Timeline() error = %v", err)
+	}
+	expected := []string{
+		models.IncidentTimelineOpened,
+		models.IncidentTimelineStatusChange, // test-service-1 down
+		models.IncidentTimelineStatusChange, // test-service-1 still offline
+		models.IncidentTimelineServiceLinked,
+		models.IncidentTimelineAcknowledged,
+		models.IncidentTimelineNote,
+		models.IncidentTimelineStatusChange, // test-service-1 up
+		models.IncidentTimelineStatusChange, // test-service-2 up
+		models.IncidentTimelineResolved,
+	}
+	if len(timeline) != len(expected) {
+		t.Fatalf("Expected %d timeline entries, got %+v", len(expected), timeline)
+	}
+	for i, entryType := range expected {
+		if timeline[i].Type != entryType {
+			t.Errorf("Timeline entry %d: expected %s, got %s", i, entryType, timeline[i].Type)
+		}
+	}
+	if timeline[7].ServiceName != "Test Service 2" || timeline[7].ToStatus != models.StatusOnline {
+		t.Errorf("Unexpected status entry %+v", timeline[7])
+	}
+
+	// Acknowledgements and notes are logged
+	var logged int
+	db.QueryRow(`SELECT COUNT(*) FROM user_activity_logs WHERE action IN (?, ?)`, models.ActionIncidentAcknowledged, models.ActionIncidentNoteAdded).Scan(&logged)
+	if logged != 2 {
+		t.Errorf("Expected 2 activity log entries, got %d", logged)
+	}
+}
+
+func TestIncidentService_Permissions(t *testing.T) {
+	s, _, _ := newTestIncidentService(t, time.Date(2026, 3, 2, 12, 0, 0, 0, time.UTC))
+	ctx := context.Background()
+
+	if _, err := s.Create(ctx, "user-1", &models.CreateIncidentRequest{Title: " "}); !errors.Is(err, ErrInvalidIncident) {
+		t.Errorf("Expected ErrInvalidIncident without a title, got %v", err)
+	}
+	if _, err := s.Create(ctx, "user-1", &models.CreateIncidentRequest{Title: "Power cut", ServiceIDs: []string{"foreign-service"}}); !errors.Is(err, ErrInvalidIncident) {
+		t.Errorf("Expected ErrInvalidIncident for another user's service, got %v", err)
+	}
+	if _, err := s.Create(ctx, "user-1", &models.CreateIncidentRequest{Title: "Power cut", ServiceIDs: []string{"missing-service"}}); !errors.Is(err, ErrInvalidIncident) {
+		t.Errorf("Expected ErrInvalidIncident for a service that doesn't exist, got %v", err)
+	}
+
+	incident, err := s.Create(ctx, "user-1", &models.CreateIncidentRequest{Title: "Power cut", ServiceIDs: []string{"test-service-1"}})
+	if err != nil || len(incident.Services) != 1 || incident.Services[0].ServiceName != "Test Service 1" {
+		t.Fatalf("Create() = %+v, %v", incident, err)
+	}
+
+	if _, err := s.Get(ctx, "user-2", incident.ID); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("Expected other users not to see the incident, got %v", err)
+	}
+	if _, err := s.AddNote(ctx, "user-2", incident.ID, "Hi", ""); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("Expected sql.ErrNoRows adding a note to another user's incident, got %v", err)
+	}
+	if _, err := s.List(ctx, "user-1", "closed", 0); !errors.Is(err, ErrInvalidIncident) {
+		t.Errorf("Expected ErrInvalidIncident for an unknown status, got %v", err)
+	}
+
+	incident, err = s.UnlinkService(ctx, "user-1", incident.ID, "test-service-1")
+	if err != nil || len(incident.Services) != 0 {
+		t.Fatalf("UnlinkService() = %+v, %v", incident, err)
+	}
+	if _, err := s.UnlinkService(ctx, "user-1", incident.ID, "test-service-1"); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("Expected sql.ErrNoRows unlinking twice, got %v", err)
+	}
+
+	resolved, err := s.Resolve(ctx, "user-1", incident.ID)
+	if err != nil || !resolved.IsResolved() || *resolved.ResolvedBy != "user-1" {
+		t.Fatalf("Resolve() = %+v, %v", resolved, err)
+	}
+	if _, err := s.Resolve(ctx, "user-1", incident.ID); !errors.Is(err, ErrIncidentResolved) {
+		t.Errorf("Expected ErrIncidentResolved, got %v", err)
+	}
+
+	resolvedList, err := s.List(ctx, "user-1", models.IncidentStatusResolved, 0)
+	if err != nil || len(resolvedList) != 1 {
+		t.Errorf("List(resolved) = %d, %v", len(resolvedList), err)
+	}
+}
+
+func TestAlertService_AcknowledgedIncidentSilencesEscalation(t *testing.T) {
+	db := setupAlertTestDB(t)
+	createIncidentTables(t, db)
+	start := time.Date(2026, 3, 2, 12, 0, 0, 0, time.UTC)
+	s, clock := newTestAlertService(t, db, start)
+	incidents := NewIncidentService(repository.NewIncidentRepository(db), s.serviceRepo, repository.NewStatusEventRepository(db), nil)
+	s.SetIncidentService(incidents)
+	ctx := context.Background()
+
+	_, err := s.Create(ctx, "user-1", &models.AlertRuleRequest{
+		Name:                 "Escalating",
+		EscalationMinutes:    10,
+		ChannelIDs:           []string{"1"},
+		EscalationChannelIDs: []string{"2"},
+	})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	service, _ := s.serviceRepo.GetByID(ctx, "test-service-1")
+	event := &models.StatusEvent{ServiceID: service.ID, FromStatus: models.StatusOnline, ToStatus: models.StatusOffline, OccurredAt: start}
+	transition(t, s, service.ID, models.StatusOnline, models.StatusOffline, start)
+	incidents.HandleTransition(service, event)
+	s.evaluate()
+	if queued := queuedAlerts(s); len(queued) != 2 {
+		t.Fatalf("Expected down alerts for both services, got %d", len(queued))
+	}
+
+	active, err := incidents.List(ctx, "user-1", "active", 0)
+	if err != nil || len(active) != 1 {
+		t.Fatalf("Expected an open incident, got %d, %v", len(active), err)
+	}
+	if _, err := incidents.Acknowledge(ctx, "user-1", active[0].ID, ""); err != nil {
+		t.Fatalf("Acknowledge() error = %v", err)
+	}
+
+	// Only test-service-2, which has no acknowledged incident, is escalated
+	clock.Advance(10 * time.Minute)
+	s.evaluate()
+	escalation := expectAlert(t, s, models.NotificationEventEscalation, "2")
+	if escalation.ServiceID != "test-service-2" {
+		t.Errorf("Expected test-service-2 to be escalated, got %s", escalation.ServiceID)
+	}
+}
@@ -0,0 +1,151 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// DefaultInfluxDBMeasurement is the measurement check results are written to
+const DefaultInfluxDBMeasurement = "nimbus_check"
+
+// InfluxDBConfig configures the InfluxDB sink
+// InfluxDB 2.x (and 3.x) is used when Bucket is set, the 1.x write API when Database is set
+type InfluxDBConfig struct {
+	URL         string
+	Measurement string
+
+	// InfluxDB 2.x: token authentication
+	Token  string
+	Org    string
+	Bucket string
+
+	// InfluxDB 1.x: optional basic authentication
+	Database        string
+	RetentionPolicy string
+	Username        string
+	Password        string
+}
+
+// InfluxDBSink writes check results with the InfluxDB line protocol over the HTTP write API
+type InfluxDBSink struct {
+	cfg      InfluxDBConfig
+	writeURL string
+	client   *http.Client
+}
+
+// NewInfluxDBSink validates the configuration and creates the sink
+func NewInfluxDBSink(cfg InfluxDBConfig) (*InfluxDBSink, error) {
+	base, err := url.Parse(strings.TrimSuffix(cfg.URL, "/"))
+	if err != nil || (base.Scheme != "http" && base.Scheme != "https") || base.Host == "" {
+		return nil, fmt.Errorf("invalid InfluxDB URL %q", cfg.URL)
+	}
+	if cfg.Measurement == "" {
+		cfg.Measurement = DefaultInfluxDBMeasurement
+	}
+
+	query := url.Values{"precision": {"ms"}}
+	switch {
+	case cfg.Bucket != "":
+		base.Path += "/api/v2/write"
+		query.Set("bucket", cfg.Bucket)
+		if cfg.Org != "" {
+			query.Set("org", cfg.Org)
+		}
+	case cfg.Database != "":
+		base.Path += "/write"
+		query.Set("db", cfg.Database)
+		if cfg.RetentionPolicy != "" {
+			query.Set("rp", cfg.RetentionPolicy)
+		}
+	default:
+		return nil, fmt.Errorf("InfluxDB needs a bucket (2.x) or a database (1.x)")
+	}
+	base.RawQuery = query.Encode()
+
+	return &InfluxDBSink{
+		cfg:      cfg,
+		writeURL: base.String(),
+		client:   &http.Client{Timeout: resultExportWriteTimeout},
+	}, nil
+}
+
+// Name identifies the sink in logs
+func (s *InfluxDBSink) Name() string {
+	return "InfluxDB"
+}
+
+// Write posts a batch of results
+// Rejected writes (4xx other than 429) are permanent; server errors and throttling are retried
+func (s *InfluxDBSink) Write(ctx context.Context, records []CheckResultRecord) error {
+	var body bytes.Buffer
+	for _, record := range records {
+		body.WriteString(influxLine(s.cfg.Measurement, record))
+		body.WriteByte('\n')
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.writeURL, &body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+	if s.cfg.Token != "" {
+		req.Header.Set("Authorization", "Token "+s.cfg.Token)
+	} else if s.cfg.Username != "" {
+		req.SetBasicAuth(s.cfg.Username, s.cfg.Password)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+
+	message, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	err = fmt.Errorf("InfluxDB returned HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(message)))
+	if resp.StatusCode >= 400 && resp.StatusCode < 500 && resp.StatusCode != http.StatusTooManyRequests {
+		return fmt.Errorf("%w: %v", errPermanentExport, err)
+	}
+	return err
+}
+
+var (
+	// Line protocol can't represent newlines in names and tags - they become (escaped) spaces
+	influxMeasurementEscaper = strings.NewReplacer(",", `\,`, " ", `\ `, "\n", `\ `)
+	influxTagEscaper         = strings.NewReplacer(",", `\,`, "=", `\=`, " ", `\ `, "\n", `\ `)
+	influxStringEscaper      = strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+)
+
+// influxLine formats a check result as one line of the line protocol (millisecond precision)
+// e.g. nimbus_check,service_id=1,service_name=Plex up=1i,status="online",response_time_ms=42i 1700000000000
+func influxLine(measurement string, record CheckResultRecord) string {
+	var b strings.Builder
+	b.WriteString(influxMeasurementEscaper.Replace(measurement))
+
+	// Tags are sorted by key, as InfluxDB recommends; empty tag values aren't allowed
+	if record.AddressFamily != nil && *record.AddressFamily != "" {
+		b.WriteString(",address_family=" + influxTagEscaper.Replace(*record.AddressFamily))
+	}
+	b.WriteString(",service_id=" + influxTagEscaper.Replace(record.ServiceID))
+	if record.ServiceName != "" {
+		b.WriteString(",service_name=" + influxTagEscaper.Replace(record.ServiceName))
+	}
+
+	b.WriteString(" up=" + strconv.Itoa(record.Up()) + "i")
+	b.WriteString(`,status="` + influxStringEscaper.Replace(record.Status) + `"`)
+	if record.ResponseTime != nil {
+		b.WriteString(",response_time_ms=" + strconv.Itoa(*record.ResponseTime) + "i")
+	}
+
+	b.WriteString(" " + strconv.FormatInt(record.CheckedAt.UnixMilli(), 10))
+	return b.String()
+}
@@ -0,0 +1,203 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/mail"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/nimbus/backend/internal/mailer"
+	"github.com/nimbus/backend/internal/models"
+	"github.com/nimbus/backend/internal/repository"
+)
+
+// DefaultInvitationExpiry is how long invitation links stay valid
+const DefaultInvitationExpiry = 72 * time.Hour
+
+var (
+	// ErrInvalidInvitation is returned for invitations to an invalid address
+	ErrInvalidInvitation = errors.New("invalid invitation")
+
+	// ErrInviteeExists is returned when the invited address already has an account
+	ErrInviteeExists = errors.New("a user with this email already exists")
+
+	// ErrInvitationUnusable is returned when registering with an unknown, used, expired or
+	// revoked invitation, or one sent to another address
+	ErrInvitationUnusable = errors.New("invitation is invalid or has expired")
+)
+
+// InvitationService creates invitations and emails their registration links
+type InvitationService struct {
+	repo         *repository.InvitationRepository
+	userRepo     *repository.UserRepository
+	activityRepo *repository.ActivityLogRepository
+	mail         *MailService
+	appURL       string // Public URL of the web app, prefix of the registration link
+	expiry       time.Duration
+}
+
+// NewInvitationService creates an invitation service
+// mail may be nil, in which case admins share the returned links themselves
+func NewInvitationService(repo *repository.InvitationRepository, userRepo *repository.UserRepository, activityRepo *repository.ActivityLogRepository, mail *MailService, appURL string, expiry time.Duration) *InvitationService {
+	if expiry <= 0 {
+		expiry = DefaultInvitationExpiry
+	}
+
+	return &InvitationService{
+		repo:         repo,
+		userRepo:     userRepo,
+		activityRepo: activityRepo,
+		mail:         mail,
+		appURL:       strings.TrimRight(appURL, "/"),
+		expiry:       expiry,
+	}
+}
+
+// Invite creates an invitation for an address and queues the invitation email
+// Failing to queue the email doesn't fail the invitation: the response carries the link and the reason
+func (s *InvitationService) Invite(ctx context.Context, email, invitedBy, ipAddress string) (*models.InvitationCreatedResponse, error) {
+	addr, err := mail.ParseAddress(strings.TrimSpace(email))
+	if err != nil || addr.Name != "" {
+		return nil, fmt.Errorf("%w: email must be a valid address", ErrInvalidInvitation)
+	}
+	email = addr.Address
+
+	exists, err := s.userRepo.EmailExists(email)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, ErrInviteeExists
+	}
+
+	hours := int(s.expiry.Round(time.Hour) / time.Hour)
+	invitation, err := s.repo.Create(ctx, email, invitedBy, max(hours, 1))
+	if err != nil {
+		return nil, err
+	}
+
+	response := &models.InvitationCreatedResponse{
+		Invitation: invitation.ToResponse(),
+		URL:        s.invitationURL(invitation),
+	}
+
+	inviterName := "An administrator"
+	if inviter, err := s.userRepo.GetByID(invitedBy); err == nil {
+		inviterName = inviter.Name
+	}
+
+	msg, err := mailer.Invitation.Render([]string{email}, mailer.InvitationData{
+		InvitedBy: inviterName,
+		Email:     email,
+		URL:       response.URL,
+		ExpiresAt: invitation.ExpiresAt,
+	})
+	if err == nil {
+		err = s.mail.Enqueue(msg)
+	}
+	if err != nil {
+		response.EmailError = err.Error()
+	} else {
+		response.EmailQueued = true
+	}
+
+	if s.activityRepo != nil {
+		entry := &models.UserActivityLog{
+			ActorID: &invitedBy,
+			Action:  models.ActionInvitationSent,
+			Details: map[string]interface{}{
+				"invitation_id": invitation.ID,
+				"email":         email,
+				"email_queued":  response.EmailQueued,
+			},
+		}
+		if ipAddress != "" {
+			entry.IPAddress = &ipAddress
+		}
+		if err := s.activityRepo.Create(ctx, entry); err != nil {
+			fmt.Printf("Failed to log invitation: %v\n", err)
+		}
+	}
+
+	return response, nil
+}
+
+// List returns all invitations, newest first
+func (s *InvitationService) List(ctx context.Context) ([]models.InvitationResponse, error) {
+	invitations, err := s.repo.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	response := make([]models.InvitationResponse, 0, len(invitations))
+	for _, invitation := range invitations {
+		response = append(response, invitation.ToResponse())
+	}
+	return response, nil
+}
+
+// Revoke deletes an invitation, invalidating its link
+// Returns repository.ErrInvitationNotFound for unknown IDs
+func (s *InvitationService) Revoke(ctx context.Context, id string) error {
+	return s.repo.Delete(ctx, id)
+}
+
+// Validate returns the pending invitation for token if it was sent to email
+// Returns ErrInvitationUnusable otherwise
+func (s *InvitationService) Validate(ctx context.Context, token, email string) (*models.UserInvitation, error) {
+	invitation, err := s.repo.GetByToken(ctx, token)
+	if errors.Is(err, repository.ErrInvitationNotFound) {
+		return nil, ErrInvitationUnusable
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if invitation.AcceptedAt != nil || !time.Now().Before(invitation.ExpiresAt) || !strings.EqualFold(invitation.Email, strings.TrimSpace(email)) {
+		return nil, ErrInvitationUnusable
+	}
+	return invitation, nil
+}
+
+// Accept marks an invitation as used by the account registered with it, so its link stops working
+// Returns ErrInvitationUnusable if it was used in the meantime
+func (s *InvitationService) Accept(ctx context.Context, invitation *models.UserInvitation, userID, ipAddress string) error {
+	if err := s.repo.MarkAsAccepted(ctx, invitation.Token); err != nil {
+		if errors.Is(err, repository.ErrInvitationNotFound) {
+			return ErrInvitationUnusable
+		}
+		return err
+	}
+
+	if s.activityRepo != nil {
+		entry := &models.UserActivityLog{
+			UserID:  &userID,
+			ActorID: &userID,
+			Action:  models.ActionInvitationUsed,
+			Details: map[string]interface{}{
+				"invitation_id": invitation.ID,
+				"email":         invitation.Email,
+				"invited_by":    invitation.InvitedBy,
+			},
+		}
+		if ipAddress != "" {
+			entry.IPAddress = &ipAddress
+		}
+		if err := s.activityRepo.Create(ctx, entry); err != nil {
+			fmt.Printf("Failed to log invitation use: %v\n", err)
+		}
+	}
+
+	return nil
+}
+
+// invitationURL is the registration link sent to the invitee
+func (s *InvitationService) invitationURL(invitation *models.UserInvitation) string {
+	query := url.Values{}
+	query.Set("invite", invitation.Token)
+	query.Set("email", invitation.Email)
+	return s.appURL + "/register?" + query.Encode()
+}
@@ -0,0 +1,124 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// DefaultLatencyBuckets are the histogram bucket upper bounds (milliseconds) used when none are requested
+var DefaultLatencyBuckets = []int{50, 100, 250, 500, 1000, 2500, 5000, 10000}
+
+// MaxLatencyBuckets limits the number of bucket bounds per histogram request
+const MaxLatencyBuckets = 30
+
+// ErrInvalidLatencyBuckets is returned for bounds that are not positive and strictly ascending
+var ErrInvalidLatencyBuckets = errors.New("latency buckets must be positive and strictly ascending")
+
+// LatencyHistogram is the response time distribution of a service over a time range
+type LatencyHistogram struct {
+	ServiceID   string            `json:"service_id"`
+	TimeRange   TimeRange         `json:"time_range"`
+	Tier        string            `json:"tier"`        // Data source: raw, hourly or daily
+	Approximate bool              `json:"approximate"` // Counts were estimated from rollup sketches
+	Total       int64             `json:"total"`
+	Buckets     []HistogramBucket `json:"buckets"`
+}
+
+// HistogramBucket counts response times in (Min, Max] milliseconds
+type HistogramBucket struct {
+	Min   int   `json:"min"`
+	Max   *int  `json:"max"` // nil for the overflow bucket
+	Count int64 `json:"count"`
+}
+
+// ValidateLatencyBuckets checks histogram bucket bounds
+func ValidateLatencyBuckets(bounds []int) error {
+	if len(bounds) == 0 || len(bounds) > MaxLatencyBuckets {
+		return fmt.Errorf("between 1 and %d latency buckets are required", MaxLatencyBuckets)
+	}
+	for i, bound := range bounds {
+		if bound <= 0 || (i > 0 && bound <= bounds[i-1]) {
+			return ErrInvalidLatencyBuckets
+		}
+	}
+	return nil
+}
+
+// GetLatencyHistogram counts a service's response times per bucket over a time range
+// bounds are ascending upper bounds in milliseconds; an overflow bucket is always appended.
+// Ranges beyond raw retention are estimated from rollup sketches
+func (m *MetricsService) GetLatencyHistogram(ctx context.Context, serviceID string, startTime, endTime time.Time, bounds []int) (*LatencyHistogram, error) {
+	if err := ValidateLatencyBuckets(bounds); err != nil {
+		return nil, err
+	}
+
+	tier := MetricsTierRaw
+	if m.rollupRepo != nil && startTime.Before(time.Now().AddDate(0, 0, -m.rawRetentionDays)) {
+		tier = MetricsTierDaily
+		if !startTime.Before(time.Now().AddDate(0, -m.hourlyRetentionMonths, 0)) {
+			tier = MetricsTierHourly
+		}
+	}
+
+	counts := make([]int64, len(bounds)+1)
+	rawStart := startTime
+
+	if tier != MetricsTierRaw {
+		rollups, err := m.rollupRepo.GetByServiceID(ctx, tier, serviceID, startTime, endTime)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get %s rollups: %w", tier, err)
+		}
+
+		// Each sketch bucket is counted at its representative value
+		for _, rollup := range rollups {
+			rollup.Sketch.Buckets(func(ms float64, count int64) {
+				counts[histogramBucketIndex(bounds, ms)] += count
+			})
+		}
+
+		rawStart, err = m.rollupTailStart(ctx, tier, startTime)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if rawStart.Before(endTime) {
+		rawCounts, err := m.statusLogRepo.GetResponseTimeHistogram(ctx, serviceID, rawStart, endTime, bounds)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get response time histogram: %w", err)
+		}
+		for i, count := range rawCounts {
+			counts[i] += count
+		}
+	}
+
+	histogram := &LatencyHistogram{
+		ServiceID:   serviceID,
+		TimeRange:   TimeRange{Start: startTime, End: endTime},
+		Tier:        tier,
+		Approximate: tier != MetricsTierRaw,
+		Buckets:     make([]HistogramBucket, len(counts)),
+	}
+	for i, count := range counts {
+		bucket := HistogramBucket{Count: count}
+		if i > 0 {
+			bucket.Min = bounds[i-1]
+		}
+		if i < len(bounds) {
+			bound := bounds[i]
+			bucket.Max = &bound
+		}
+		histogram.Buckets[i] = bucket
+		histogram.Total += count
+	}
+
+	return histogram, nil
+}
+
+// histogramBucketIndex returns the index of the first bound >= ms (len(bounds) for overflow)
+func histogramBucketIndex(bounds []int, ms float64) int {
+	return sort.Search(len(bounds), func(i int) bool { return float64(bounds[i]) >= ms })
+}
@@ -0,0 +1,212 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nimbus/backend/internal/mailer"
+	"github.com/nimbus/backend/internal/models"
+	"github.com/nimbus/backend/internal/repository"
+)
+
+// mailSendTimeout bounds synchronous sends (test emails)
+const mailSendTimeout = 30 * time.Second
+
+var (
+	// ErrInvalidSMTPSettings is returned when admins submit invalid SMTP settings
+	ErrInvalidSMTPSettings = errors.New("invalid SMTP settings")
+
+	// ErrMailNotConfigured is returned when email is disabled or not set up
+	ErrMailNotConfigured = mailer.ErrNotConfigured
+)
+
+// MailService sends email with the SMTP settings admins store in system settings
+// Messages are queued and retried by a mailer.Queue; test emails are sent directly so
+// admins see the server's reply
+type MailService struct {
+	settingsRepo *repository.SettingsRepository
+	activityRepo *repository.ActivityLogRepository
+	queue        *mailer.Queue
+
+	mu       sync.RWMutex
+	settings models.SMTPSettings
+}
+
+// NewMailService creates a mail service with email disabled until Load is called
+func NewMailService(settingsRepo *repository.SettingsRepository, activityRepo *repository.ActivityLogRepository, queueSize int) *MailService {
+	m := &MailService{
+		settingsRepo: settingsRepo,
+		activityRepo: activityRepo,
+	}
+	m.queue = mailer.NewQueue(m.config, queueSize)
+	return m
+}
+
+// Load reads the stored SMTP settings, keeping email disabled if none are stored
+func (m *MailService) Load(ctx context.Context) error {
+	setting, err := m.settingsRepo.Get(ctx, models.SettingSMTP)
+	if errors.Is(err, repository.ErrSettingNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var settings models.SMTPSettings
+	if err := json.Unmarshal([]byte(setting.Value), &settings); err != nil {
+		return fmt.Errorf("failed to decode SMTP settings: %w", err)
+	}
+
+	m.mu.Lock()
+	m.settings = settings
+	m.mu.Unlock()
+	return nil
+}
+
+// Settings returns the current SMTP settings without the password
+func (m *MailService) Settings() models.SMTPSettingsResponse {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.settings.ToResponse()
+}
+
+// Enabled reports whether email can be sent. Safe to call on a nil service
+func (m *MailService) Enabled() bool {
+	if m == nil {
+		return false
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.settings.Enabled
+}
+
+// Update validates and stores new SMTP settings
+// An empty password keeps the stored one unless the username is cleared
+func (m *MailService) Update(ctx context.Context, settings models.SMTPSettings, updatedBy *string) error {
+	settings.Host = strings.TrimSpace(settings.Host)
+	settings.Username = strings.TrimSpace(settings.Username)
+	settings.FromAddress = strings.TrimSpace(settings.FromAddress)
+	settings.FromName = strings.TrimSpace(settings.FromName)
+
+	m.mu.RLock()
+	if settings.Password == "" && settings.Username != "" {
+		settings.Password = m.settings.Password
+	}
+	m.mu.RUnlock()
+	if settings.Username == "" {
+		settings.Password = ""
+	}
+
+	// Disabled settings may be incomplete, so admins can save a draft
+	if settings.Enabled {
+		cfg := configFromSettings(settings)
+		if err := cfg.Validate(); err != nil {
+			return fmt.Errorf("%w: %s", ErrInvalidSMTPSettings, strings.TrimPrefix(err.Error(), mailer.ErrInvalidConfig.Error()+": "))
+		}
+		settings.Port = cfg.Port
+		settings.Security = cfg.Security
+		settings.FromAddress = cfg.From
+	}
+
+	value, err := json.Marshal(settings)
+	if err != nil {
+		return err
+	}
+	if err := m.settingsRepo.Update(ctx, models.SettingSMTP, string(value), updatedBy); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	m.settings = settings
+	m.mu.Unlock()
+
+	if m.activityRepo != nil {
+		entry := &models.UserActivityLog{
+			ActorID: updatedBy,
+			Action:  models.ActionSettingChanged,
+			Details: map[string]interface{}{"key": models.SettingSMTP, "value": settings.ToResponse()},
+		}
+		if err := m.activityRepo.Create(ctx, entry); err != nil {
+			fmt.Printf("Failed to log SMTP settings change: %v\n", err)
+		}
+	}
+
+	return nil
+}
+
+// Start begins sending queued email
+func (m *MailService) Start() {
+	m.queue.Start()
+}
+
+// Stop sends the queued email and stops
+func (m *MailService) Stop() {
+	m.queue.Stop()
+}
+
+// Stats returns the email queue's counters
+func (m *MailService) Stats() mailer.QueueStats {
+	return m.queue.Stats()
+}
+
+// Enqueue queues a message for delivery with retries. Safe to call on a nil service
+func (m *MailService) Enqueue(msg *mailer.Message) error {
+	if !m.Enabled() {
+		return ErrMailNotConfigured
+	}
+	return m.queue.Enqueue(msg)
+}
+
+// Send delivers a message immediately, in one attempt. Safe to call on a nil service
+func (m *MailService) Send(ctx context.Context, msg *mailer.Message) error {
+	if m == nil {
+		return ErrMailNotConfigured
+	}
+	cfg, err := m.config()
+	if err != nil {
+		return err
+	}
+	return mailer.Send(ctx, cfg, msg)
+}
+
+// SendTest sends the test email to an address, without retries
+func (m *MailService) SendTest(ctx context.Context, to string) error {
+	msg, err := mailer.Test.Render([]string{to}, mailer.TestData{SentAt: time.Now()})
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, mailSendTimeout)
+	defer cancel()
+	return m.Send(ctx, msg)
+}
+
+// config returns the settings for the next send, or ErrMailNotConfigured
+func (m *MailService) config() (mailer.Config, error) {
+	m.mu.RLock()
+	settings := m.settings
+	m.mu.RUnlock()
+
+	if !settings.Enabled {
+		return mailer.Config{}, ErrMailNotConfigured
+	}
+	return configFromSettings(settings), nil
+}
+
+func configFromSettings(settings models.SMTPSettings) mailer.Config {
+	return mailer.Config{
+		Host:          settings.Host,
+		Port:          settings.Port,
+		Security:      settings.Security,
+		Username:      settings.Username,
+		Password:      settings.Password,
+		From:          settings.FromAddress,
+		FromName:      settings.FromName,
+		SkipTLSVerify: settings.SkipTLSVerify,
+	}
+}
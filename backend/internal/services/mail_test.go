@@ -0,0 +1,261 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nimbus/backend/internal/mailer"
+	"github.com/nimbus/backend/internal/mailer/mailertest"
+	"github.com/nimbus/backend/internal/models"
+	"github.com/nimbus/backend/internal/repository"
+)
+
+// setupMailTestDB adds the user and invitation tables to the settings test schema
+func setupMailTestDB(t *testing.T) *sql.DB {
+	db := setupEgressTestDB(t)
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+
+	_, err := db.Exec(`
+		CREATE TABLE users (
+			id TEXT PRIMARY KEY,
+			email TEXT NOT NULL UNIQUE,
+			name TEXT NOT NULL,
+			password TEXT NOT NULL,
+			role TEXT NOT NULL DEFAULT 'user',
+			last_activity_at TIMESTAMP,
+			created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+		);
+
+		CREATE TABLE user_invitations (
+			id TEXT PRIMARY KEY DEFAULT (lower(hex(randomblob(16)))),
+			email TEXT NOT NULL,
+			token TEXT NOT NULL UNIQUE,
+			invited_by TEXT NOT NULL,
+			expires_at TIMESTAMP NOT NULL,
+			accepted_at TIMESTAMP,
+			created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+		);
+
+		INSERT INTO users (id, email, name, password, role) VALUES ('admin-1', 'admin@example.com', 'Alice', 'x', 'admin');
+	`)
+	if err != nil {
+		t.Fatalf("Failed to create tables: %v", err)
+	}
+
+	return db
+}
+
+// enableTestSMTP points the mail service at a local SMTP server
+func enableTestSMTP(t *testing.T, m *MailService, server *mailertest.Server) {
+	t.Helper()
+	err := m.Update(context.Background(), models.SMTPSettings{
+		Enabled:       true,
+		Host:          server.Host,
+		Port:          server.Port,
+		Security:      mailer.SecurityNone,
+		FromAddress:   "nimbus@example.com",
+		SkipTLSVerify: true,
+	}, nil)
+	if err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+}
+
+func TestMailService_Settings(t *testing.T) {
+	db := setupMailTestDB(t)
+	settingsRepo := repository.NewSettingsRepository(db)
+	m := NewMailService(settingsRepo, repository.NewActivityLogRepository(db), 10)
+	ctx := context.Background()
+	admin := "admin-1"
+
+	if m.Enabled() {
+		t.Fatal("Expected email to be disabled without settings")
+	}
+	if err := m.SendTest(ctx, "alice@example.com"); !errors.Is(err, ErrMailNotConfigured) {
+		t.Errorf("Expected ErrMailNotConfigured, got %v", err)
+	}
+
+	// Enabled settings must be complete
+	err := m.Update(ctx, models.SMTPSettings{Enabled: true, Host: "smtp.example.com"}, &admin)
+	if !errors.Is(err, ErrInvalidSMTPSettings) || !strings.Contains(err.Error(), "from must be an email address") {
+		t.Fatalf("Expected ErrInvalidSMTPSettings, got %v", err)
+	}
+
+	settings := models.SMTPSettings{Enabled: true, Host: "smtp.example.com", Username: "nimbus", Password: "s3cret", FromAddress: "nimbus@example.com"}
+	if err := m.Update(ctx, settings, &admin); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	response := m.Settings()
+	if response.Port != 587 || response.Security != mailer.SecurityStartTLS || !response.HasPassword {
+		t.Errorf("Expected defaults and a stored password, got %+v", response)
+	}
+
+	// An empty password keeps the stored one
+	settings.Password = ""
+	settings.Port = 2525
+	if err := m.Update(ctx, settings, &admin); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+
+	// Settings survive a restart
+	reloaded := NewMailService(settingsRepo, nil, 10)
+	if err := reloaded.Load(ctx); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	cfg, err := reloaded.config()
+	if err != nil || cfg.Password != "s3cret" || cfg.Port != 2525 {
+		t.Errorf("Expected the stored password and new port, got %+v, %v", cfg, err)
+	}
+
+	// The activity log records changes without the password
+	var details string
+	if err := db.QueryRow(`SELECT details FROM user_activity_logs WHERE action = ? ORDER BY id DESC LIMIT 1`, models.ActionSettingChanged).Scan(&details); err != nil {
+		t.Fatalf("Expected an activity log entry: %v", err)
+	}
+	if strings.Contains(details, "s3cret") || !strings.Contains(details, `"has_password":true`) {
+		t.Errorf("Unexpected activity details %s", details)
+	}
+}
+
+func TestMailService_SendTest(t *testing.T) {
+	db := setupMailTestDB(t)
+	server := mailertest.NewServer(t, mailertest.Options{})
+	m := NewMailService(repository.NewSettingsRepository(db), nil, 10)
+	enableTestSMTP(t, m, server)
+
+	if err := m.SendTest(context.Background(), "alice@example.com"); err != nil {
+		t.Fatalf("SendTest() error = %v", err)
+	}
+	messages := server.Messages()
+	if len(messages) != 1 || messages[0].To[0] != "alice@example.com" || !strings.Contains(messages[0].Data, "Subject: Nimbus test email") {
+		t.Errorf("Unexpected messages %+v", messages)
+	}
+}
+
+func TestEmailNotificationChannel(t *testing.T) {
+	server := mailertest.NewServer(t, mailertest.Options{RejectRecipient: "nobody@example.com"})
+	m := NewMailService(repository.NewSettingsRepository(setupMailTestDB(t)), nil, 10)
+	s := newTestNotificationService(setupNotificationTestDB(t))
+	ctx := context.Background()
+
+	req := &models.NotificationChannelRequest{
+		Name:   "Inbox",
+		Type:   models.NotificationTypeEmail,
+		Config: models.NotificationChannelConfig{To: []string{" Alice <alice@example.com>"}},
+	}
+
+	// Without a mail service (or with email disabled) sends fail permanently
+	err := s.SendTestConfig(ctx, "user-1", req)
+	if !errors.Is(err, errPermanentNotification) {
+		t.Errorf("Expected a permanent error without email, got %v", err)
+	}
+	s.SetMailService(m)
+	if err := s.SendTestConfig(ctx, "user-1", req); !errors.Is(err, errPermanentNotification) {
+		t.Errorf("Expected a permanent error with email disabled, got %v", err)
+	}
+
+	_, err = s.Create(ctx, "user-1", false, &models.NotificationChannelRequest{Name: "Empty", Type: models.NotificationTypeEmail})
+	if !errors.Is(err, ErrInvalidNotificationChannel) {
+		t.Errorf("Expected ErrInvalidNotificationChannel without recipients, got %v", err)
+	}
+
+	enableTestSMTP(t, m, server)
+	channel, err := s.Create(ctx, "user-1", false, req)
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if channel.Config.To[0] != "alice@example.com" {
+		t.Errorf("Expected normalized recipients, got %v", channel.Config.To)
+	}
+
+	if err := s.send(ctx, channel, downNotification()); err != nil {
+		t.Fatalf("send() error = %v", err)
+	}
+	messages := server.Messages()
+	if len(messages) != 1 || !strings.Contains(messages[0].Data, "Error: HTTP 503") || !strings.Contains(messages[0].Data, "text/html") {
+		t.Fatalf("Unexpected messages %+v", messages)
+	}
+
+	// Rejected recipients are not retried
+	channel.Config.To = []string{"nobody@example.com"}
+	if err := s.send(ctx, channel, downNotification()); !errors.Is(err, errPermanentNotification) {
+		t.Errorf("Expected a permanent error for a rejected recipient, got %v", err)
+	}
+}
+
+func TestInvitationService(t *testing.T) {
+	db := setupMailTestDB(t)
+	server := mailertest.NewServer(t, mailertest.Options{})
+	m := NewMailService(repository.NewSettingsRepository(db), nil, 10)
+	m.Start()
+	defer m.Stop()
+
+	s := NewInvitationService(
+		repository.NewInvitationRepository(db),
+		repository.NewUserRepository(db),
+		repository.NewActivityLogRepository(db),
+		m,
+		"https://nimbus.example.com/",
+		24*time.Hour,
+	)
+	ctx := context.Background()
+
+	// Without email the invitation is created and the link returned for sharing
+	response, err := s.Invite(ctx, "bob@example.com", "admin-1", "10.0.0.1")
+	if err != nil {
+		t.Fatalf("Invite() error = %v", err)
+	}
+	if response.EmailQueued || response.EmailError != ErrMailNotConfigured.Error() {
+		t.Errorf("Expected the email not to be queued, got %+v", response)
+	}
+	if !strings.HasPrefix(response.URL, "https://nimbus.example.com/register?") || !strings.Contains(response.URL, "email=bob%40example.com") {
+		t.Errorf("Unexpected URL %q", response.URL)
+	}
+	if expiry := time.Until(response.Invitation.ExpiresAt); expiry < 23*time.Hour || expiry > 25*time.Hour {
+		t.Errorf("Expected the invitation to expire in a day, got %v", expiry)
+	}
+
+	enableTestSMTP(t, m, server)
+	response, err = s.Invite(ctx, "carol@example.com", "admin-1", "10.0.0.1")
+	if err != nil || !response.EmailQueued {
+		t.Fatalf("Invite() = %+v, %v", response, err)
+	}
+	if !server.WaitForMessages(1, 2*time.Second) {
+		t.Fatal("Timed out waiting for the invitation email")
+	}
+	received := server.Messages()[0]
+	if received.To[0] != "carol@example.com" || !strings.Contains(received.Data, "Alice invited you to Nimbus") {
+		t.Errorf("Unexpected invitation email %+v", received)
+	}
+
+	// Existing users and invalid addresses are rejected
+	if _, err := s.Invite(ctx, "admin@example.com", "admin-1", ""); !errors.Is(err, ErrInviteeExists) {
+		t.Errorf("Expected ErrInviteeExists, got %v", err)
+	}
+	if _, err := s.Invite(ctx, "not an address", "admin-1", ""); !errors.Is(err, ErrInvalidInvitation) {
+		t.Errorf("Expected ErrInvalidInvitation, got %v", err)
+	}
+
+	var logged int
+	db.QueryRow(`SELECT COUNT(*) FROM user_activity_logs WHERE action = ?`, models.ActionInvitationSent).Scan(&logged)
+	if logged != 2 {
+		t.Errorf("Expected 2 logged invitations, got %d", logged)
+	}
+
+	invitations, err := s.List(ctx)
+	if err != nil || len(invitations) != 2 {
+		t.Fatalf("List() = %d, %v", len(invitations), err)
+	}
+	if err := s.Revoke(ctx, invitations[0].ID); err != nil {
+		t.Fatalf("Revoke() error = %v", err)
+	}
+	if err := s.Revoke(ctx, invitations[0].ID); !errors.Is(err, repository.ErrInvitationNotFound) {
+		t.Errorf("Expected ErrInvitationNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,166 @@
+package services
+
+import (
+	"sort"
+	"time"
+)
+
+// MaxMetricDataPoints caps the number of data points in a metrics response
+// Requests whose interval would exceed it get a wider interval
+const MaxMetricDataPoints = 500
+
+// metricIntervalSteps are the intervals (minutes) tried when a requested interval is too fine
+var metricIntervalSteps = []int{
+	1, 5, 10, 15, 30, // minutes
+	60, 120, 180, 360, 720, // hours
+	minutesPerDay, 2 * minutesPerDay, 7 * minutesPerDay, 14 * minutesPerDay, 28 * minutesPerDay,
+}
+
+// metricBuckets picks the data point interval for a range and returns it with the bucket boundaries
+// The requested interval is kept unless the range would need more than MaxMetricDataPoints buckets;
+// intervals over a day are rounded up to whole days
+func metricBuckets(startTime, endTime time.Time, intervalMinutes int, loc *time.Location) (int, []time.Time) {
+	if intervalMinutes > minutesPerDay {
+		intervalMinutes = roundUpMinutes(intervalMinutes, minutesPerDay)
+	}
+
+	// Only build boundaries for intervals that can fit: DST makes local days at most an hour
+	// shorter, so a span needing a tenth more buckets than allowed can't
+	span := endTime.Sub(startTime)
+	mightFit := func(minutes int) bool {
+		return span <= time.Duration(minutes)*time.Minute*MaxMetricDataPoints*11/10
+	}
+
+	if mightFit(intervalMinutes) {
+		if boundaries := metricBucketBoundaries(startTime, endTime, intervalMinutes, loc); len(boundaries) <= MaxMetricDataPoints {
+			return intervalMinutes, boundaries
+		}
+	}
+
+	for _, step := range metricIntervalSteps {
+		if step <= intervalMinutes || !mightFit(step) {
+			continue
+		}
+		intervalMinutes = step
+		if boundaries := metricBucketBoundaries(startTime, endTime, intervalMinutes, loc); len(boundaries) <= MaxMetricDataPoints {
+			return intervalMinutes, boundaries
+		}
+	}
+
+	// Beyond the largest step: whole days, sized from the span
+	days := int(span.Hours()/24)/(MaxMetricDataPoints-1) + 1
+	intervalMinutes = days * minutesPerDay
+	return intervalMinutes, metricBucketBoundaries(startTime, endTime, intervalMinutes, loc)
+}
+
+// metricBucketBoundaries returns the start of every bucket overlapping [startTime, endTime], oldest first
+// Buckets are aligned to local midnight in loc:
+//   - intervals under a day restart at every local midnight and follow the wall clock, so 6h
+//     buckets always start at 00:00, 06:00, 12:00 and 18:00 (and last 5 or 7 hours on DST days)
+//   - whole-day intervals start at local midnight, counting days from 1970-01-01
+//
+// A wall-clock boundary that occurs twice (DST end) starts two buckets; one skipped by DST start
+// moves to the end of the gap if its bucket has any time left
+func metricBucketBoundaries(startTime, endTime time.Time, intervalMinutes int, loc *time.Location) []time.Time {
+	if loc == nil {
+		loc = time.UTC
+	}
+
+	start := startTime.In(loc)
+	end := endTime.In(loc)
+
+	// Walk local dates, starting early enough to include the bucket containing startTime
+	stepDays := 1
+	date := time.Date(start.Year(), start.Month(), start.Day()-1, 0, 0, 0, 0, time.UTC)
+	if intervalMinutes%minutesPerDay == 0 {
+		stepDays = intervalMinutes / minutesPerDay
+		epochDays := int(date.Unix() / 86400)
+		date = date.AddDate(0, 0, -(epochDays % stepDays))
+	}
+	lastDate := time.Date(end.Year(), end.Month(), end.Day(), 0, 0, 0, 0, time.UTC)
+
+	var boundaries []time.Time
+	for ; !date.After(lastDate); date = date.AddDate(0, 0, stepDays) {
+		if intervalMinutes%minutesPerDay == 0 {
+			instants, gapEnd := wallClockInstants(date, 0, loc)
+			if len(instants) > 0 {
+				// A repeated midnight starts the day once
+				boundaries = append(boundaries, instants[0])
+			} else {
+				boundaries = append(boundaries, gapEnd)
+			}
+			continue
+		}
+
+		for minute := 0; minute < minutesPerDay; minute += intervalMinutes {
+			instants, gapEnd := wallClockInstants(date, minute, loc)
+			if len(instants) > 0 {
+				boundaries = append(boundaries, instants...)
+				continue
+			}
+
+			// Skipped boundary: its bucket starts when the gap ends, unless the next bucket does
+			local := gapEnd.In(loc)
+			gapEndMinute := local.Hour()*60 + local.Minute()
+			sameDay := local.Year() == date.Year() && local.YearDay() == date.YearDay()
+			if sameDay && gapEndMinute < minute+intervalMinutes {
+				boundaries = append(boundaries, gapEnd)
+			}
+		}
+	}
+
+	// Keep the bucket containing startTime and every bucket starting within the range
+	first := sort.Search(len(boundaries), func(i int) bool { return boundaries[i].After(startTime) }) - 1
+	if first < 0 {
+		first = 0
+	}
+
+	var result []time.Time
+	for _, boundary := range boundaries[first:] {
+		if boundary.After(endTime) {
+			break
+		}
+		if len(result) > 0 && !boundary.After(result[len(result)-1]) {
+			continue
+		}
+		result = append(result, boundary)
+	}
+
+	return result
+}
+
+// wallClockInstants returns the instants at which the wall clock in loc shows date plus minute
+// There are two during a DST fall-back and none inside a DST gap; then gapEnd is when the gap ends
+func wallClockInstants(date time.Time, minute int, loc *time.Location) ([]time.Time, time.Time) {
+	wall := time.Date(date.Year(), date.Month(), date.Day(), 0, minute, 0, 0, time.UTC)
+	guess := time.Date(date.Year(), date.Month(), date.Day(), 0, minute, 0, 0, loc)
+
+	// Offsets in effect around the wall time
+	var candidates []time.Time
+	seen := map[int]bool{}
+	for _, probe := range []time.Time{guess.Add(-24 * time.Hour), guess, guess.Add(24 * time.Hour)} {
+		_, offset := probe.Zone()
+		if seen[offset] {
+			continue
+		}
+		seen[offset] = true
+		candidates = append(candidates, wall.Add(-time.Duration(offset)*time.Second))
+	}
+	sort.Slice(candidates, func(i, j int) bool { return candidates[i].Before(candidates[j]) })
+
+	var instants []time.Time
+	for _, candidate := range candidates {
+		local := candidate.In(loc)
+		if local.Year() == wall.Year() && local.YearDay() == wall.YearDay() &&
+			local.Hour() == wall.Hour() && local.Minute() == wall.Minute() {
+			instants = append(instants, candidate)
+		}
+	}
+	if len(instants) > 0 {
+		return instants, time.Time{}
+	}
+
+	// Inside a gap: the latest candidate lies after the transition
+	gapEnd, _ := candidates[len(candidates)-1].In(loc).ZoneBounds()
+	return nil, gapEnd
+}
@@ -0,0 +1,158 @@
+package services
+
+import (
+	"testing"
+	"time"
+)
+
+func loadTestLocation(t *testing.T, name string) *time.Location {
+	t.Helper()
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		t.Skipf("Time zone data unavailable: %v", err)
+	}
+	return loc
+}
+
+// localDayBoundaries returns the buckets of one local day
+func localDayBoundaries(loc *time.Location, year int, month time.Month, day, intervalMinutes int) []time.Time {
+	start := time.Date(year, month, day, 0, 0, 0, 0, loc)
+	end := time.Date(year, month, day+1, 0, 0, 0, 0, loc).Add(-time.Nanosecond)
+	return metricBucketBoundaries(start, end, intervalMinutes, loc)
+}
+
+func TestMetricBucketBoundaries_HourlyAcrossDST(t *testing.T) {
+	newYork := loadTestLocation(t, "America/New_York")
+
+	// Spring forward: 02:00 doesn't exist, the day has 23 hourly buckets
+	spring := localDayBoundaries(newYork, 2026, 3, 8, 60)
+	if len(spring) != 23 {
+		t.Fatalf("Expected 23 hourly buckets on DST start, got %d", len(spring))
+	}
+	if got := spring[2].In(newYork); got.Hour() != 3 || spring[2].Sub(spring[1]) != time.Hour {
+		t.Errorf("Expected 03:00 to follow 01:00 after one hour, got %v", got)
+	}
+
+	// Fall back: 01:00 happens twice, the day has 25 hourly buckets
+	fall := localDayBoundaries(newYork, 2026, 11, 1, 60)
+	if len(fall) != 25 {
+		t.Fatalf("Expected 25 hourly buckets on DST end, got %d", len(fall))
+	}
+	for i := 1; i < len(fall); i++ {
+		if fall[i].Sub(fall[i-1]) != time.Hour {
+			t.Errorf("Expected every bucket to last one hour, bucket %d starts %v after the previous", i, fall[i].Sub(fall[i-1]))
+		}
+	}
+	if fall[1].In(newYork).Hour() != 1 || fall[2].In(newYork).Hour() != 1 {
+		t.Errorf("Expected two buckets starting at 01:00, got %v and %v", fall[1].In(newYork), fall[2].In(newYork))
+	}
+}
+
+func TestMetricBucketBoundaries_SixHoursAcrossDST(t *testing.T) {
+	newYork := loadTestLocation(t, "America/New_York")
+
+	tests := []struct {
+		name          string
+		day           time.Time
+		firstDuration time.Duration
+	}{
+		{"DST start", time.Date(2026, 3, 8, 0, 0, 0, 0, newYork), 5 * time.Hour},
+		{"DST end", time.Date(2026, 11, 1, 0, 0, 0, 0, newYork), 7 * time.Hour},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			boundaries := localDayBoundaries(newYork, tt.day.Year(), tt.day.Month(), tt.day.Day(), 360)
+			if len(boundaries) != 4 {
+				t.Fatalf("Expected 4 six-hour buckets, got %d", len(boundaries))
+			}
+			for i, boundary := range boundaries {
+				if local := boundary.In(newYork); local.Hour() != i*6 || local.Minute() != 0 {
+					t.Errorf("Expected bucket %d to start at %02d:00 local time, got %v", i, i*6, local)
+				}
+			}
+			if got := boundaries[1].Sub(boundaries[0]); got != tt.firstDuration {
+				t.Errorf("Expected the first bucket to last %v, got %v", tt.firstDuration, got)
+			}
+		})
+	}
+}
+
+func TestMetricBucketBoundaries_DailyAcrossDST(t *testing.T) {
+	amsterdam := loadTestLocation(t, "Europe/Amsterdam")
+
+	start := time.Date(2026, 3, 27, 15, 0, 0, 0, amsterdam)
+	end := time.Date(2026, 3, 31, 9, 0, 0, 0, amsterdam)
+	boundaries := metricBucketBoundaries(start, end, minutesPerDay, amsterdam)
+
+	// The bucket containing start begins at its local midnight
+	if len(boundaries) != 5 || !boundaries[0].Equal(time.Date(2026, 3, 27, 0, 0, 0, 0, amsterdam)) {
+		t.Fatalf("Expected 5 daily buckets from local midnight on the 27th, got %v", boundaries)
+	}
+	for _, boundary := range boundaries {
+		if local := boundary.In(amsterdam); local.Hour() != 0 || local.Minute() != 0 {
+			t.Errorf("Expected buckets to start at local midnight, got %v", local)
+		}
+	}
+	if got := boundaries[3].Sub(boundaries[2]); got != 23*time.Hour {
+		t.Errorf("Expected the DST start day to last 23 hours, got %v", got)
+	}
+}
+
+func TestMetricBucketBoundaries_SkippedBoundary(t *testing.T) {
+	newYork := loadTestLocation(t, "America/New_York")
+
+	// 50 minute buckets start at 01:40 and 02:30 local time; 02:30 is skipped by DST start
+	boundaries := localDayBoundaries(newYork, 2026, 3, 8, 50)
+
+	var afterGap []time.Time
+	for _, boundary := range boundaries {
+		if local := boundary.In(newYork); local.Hour() >= 1 && local.Hour() <= 3 {
+			afterGap = append(afterGap, boundary)
+		}
+	}
+
+	// 01:40, then 03:00 (end of the gap, starting the 02:30 bucket), then 03:20
+	expected := []time.Time{
+		time.Date(2026, 3, 8, 1, 40, 0, 0, newYork),
+		time.Date(2026, 3, 8, 7, 0, 0, 0, time.UTC),
+		time.Date(2026, 3, 8, 3, 20, 0, 0, newYork),
+	}
+	if len(afterGap) != len(expected) {
+		t.Fatalf("Expected boundaries %v, got %v", expected, afterGap)
+	}
+	for i := range expected {
+		if !afterGap[i].Equal(expected[i]) {
+			t.Errorf("Boundary %d: expected %v, got %v", i, expected[i], afterGap[i])
+		}
+	}
+}
+
+func TestMetricBuckets_PointCap(t *testing.T) {
+	end := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name             string
+		start            time.Time
+		interval         int
+		expectedInterval int
+	}{
+		{"Within the cap", end.Add(-24 * time.Hour), 5, 5},
+		{"30 days of 5 minute points", end.AddDate(0, 0, -30), 5, 120},
+		{"A year of hourly points", end.AddDate(-1, 0, 0), 60, minutesPerDay},
+		{"Intervals over a day round up to days", end.AddDate(0, 0, -30), 36 * 60, 2 * minutesPerDay},
+		{"Beyond the largest step", end.AddDate(-40, 0, 0), 5, 30 * minutesPerDay},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			interval, boundaries := metricBuckets(tt.start, end, tt.interval, time.UTC)
+			if interval != tt.expectedInterval {
+				t.Errorf("Expected interval %d, got %d", tt.expectedInterval, interval)
+			}
+			if len(boundaries) > MaxMetricDataPoints {
+				t.Errorf("Expected at most %d buckets, got %d", MaxMetricDataPoints, len(boundaries))
+			}
+		})
+	}
+}
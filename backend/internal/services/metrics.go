@@ -3,7 +3,6 @@ package services
 import (
 	"context"
 	"fmt"
-	"strings"
 	"time"
 
 	"github.com/nimbus/backend/internal/models"
@@ -14,6 +13,22 @@ import (
 type MetricsService struct {
 	statusLogRepo *repository.StatusLogRepository
 	serviceRepo   repository.ServiceRepositoryInterface
+	statusWriter  *StatusWriter
+	partitions    *StatusLogPartitionService
+
+	// Rollup tiers (optional): raw logs are kept rawRetentionDays, hourly rollups hourlyRetentionMonths
+	rollupRepo            *repository.StatusRollupRepository
+	rawRetentionDays      int
+	hourlyRetentionMonths int
+
+	// How long a check result counts toward time-weighted uptime without a newer result
+	maxStaleness time.Duration
+
+	// SLO error budgets in the Prometheus output (optional)
+	slos *SLOService
+
+	// Check counters and histograms recorded by the health check service (optional)
+	checkMetrics *CheckMetrics
 }
 
 // NewMetricsService creates a new metrics service
@@ -21,21 +36,88 @@ func NewMetricsService(statusLogRepo *repository.StatusLogRepository, serviceRep
 	return &MetricsService{
 		statusLogRepo: statusLogRepo,
 		serviceRepo:   serviceRepo,
+		maxStaleness:  DefaultUptimeMaxStaleness,
+	}
+}
+
+// SetUptimeMaxStaleness sets how long a check result counts toward time-weighted uptime
+// when no newer result follows (e.g. a missed check during a restart); the rest is a gap
+func (m *MetricsService) SetUptimeMaxStaleness(d time.Duration) {
+	if d > 0 {
+		m.maxStaleness = d
+	}
+}
+
+// SetStatusWriter includes the status writer's queue metrics in the admin Prometheus output
+func (m *MetricsService) SetStatusWriter(w *StatusWriter) {
+	m.statusWriter = w
+}
+
+// SetSLOService includes SLO error budgets in the Prometheus output
+func (m *MetricsService) SetSLOService(s *SLOService) {
+	m.slos = s
+}
+
+// SetCheckMetrics includes check counters, duration histograms and phase timings in the Prometheus output
+func (m *MetricsService) SetCheckMetrics(c *CheckMetrics) {
+	m.checkMetrics = c
+}
+
+// SetPartitionService makes cleanup drop expired partitions once the status log table is partitioned
+func (m *MetricsService) SetPartitionService(p *StatusLogPartitionService) {
+	m.partitions = p
+}
+
+// SetRollups lets GetServiceMetrics serve long ranges and coarse intervals from rollup tiers
+func (m *MetricsService) SetRollups(repo *repository.StatusRollupRepository, rawRetentionDays, hourlyRetentionMonths int) {
+	if rawRetentionDays <= 0 {
+		rawRetentionDays = 30 // Same default as MetricsCleanupWorker
+	}
+	if hourlyRetentionMonths <= 0 {
+		hourlyRetentionMonths = DefaultHourlyRetentionMonths
 	}
+	m.rollupRepo = repo
+	m.rawRetentionDays = rawRetentionDays
+	m.hourlyRetentionMonths = hourlyRetentionMonths
 }
 
 // MetricsResponse represents aggregated metrics for a service
 type MetricsResponse struct {
-	ServiceID        string            `json:"service_id"`
-	TimeRange        TimeRange         `json:"time_range"`
-	UptimePercentage float64           `json:"uptime_percentage"`
-	TotalChecks      int               `json:"total_checks"`
-	OnlineCount      int               `json:"online_count"`
-	OfflineCount     int               `json:"offline_count"`
-	AvgResponseTime  float64           `json:"avg_response_time"`
-	MinResponseTime  float64           `json:"min_response_time"`
-	MaxResponseTime  float64           `json:"max_response_time"`
-	DataPoints       []MetricDataPoint `json:"data_points"`
+	ServiceID        string    `json:"service_id"`
+	TimeRange        TimeRange `json:"time_range"`
+	Tier             string    `json:"tier"`              // Data source: raw, hourly or daily
+	IntervalMinutes  int       `json:"interval_minutes"`  // Effective data point interval
+	Timezone         string    `json:"timezone"`          // IANA zone the data points are aligned to
+	UptimePercentage float64   `json:"uptime_percentage"` // Time-weighted: time online / time with results
+	// Share of the range with fresh results; gaps count as neither up nor down
+	CoveragePercentage float64 `json:"coverage_percentage"`
+	// online_count / total_checks, for comparison with the time-weighted value
+	CountUptimePercentage float64           `json:"count_uptime_percentage"`
+	TotalChecks           int               `json:"total_checks"`
+	OnlineCount           int               `json:"online_count"`
+	OfflineCount          int               `json:"offline_count"`
+	AvgResponseTime       float64           `json:"avg_response_time"`
+	MinResponseTime       float64           `json:"min_response_time"`
+	MaxResponseTime       float64           `json:"max_response_time"`
+	P50ResponseTime       float64           `json:"p50_response_time"`
+	P90ResponseTime       float64           `json:"p90_response_time"`
+	P95ResponseTime       float64           `json:"p95_response_time"`
+	P99ResponseTime       float64           `json:"p99_response_time"`
+	DataPoints            []MetricDataPoint `json:"data_points"`
+
+	// Per address family breakdown (keyed by "ipv4"/"ipv6"), omitted if no family was recorded
+	ByAddressFamily map[string]FamilyMetrics `json:"by_address_family,omitempty"`
+}
+
+// FamilyMetrics represents uptime statistics for a single IP address family
+type FamilyMetrics struct {
+	UptimePercentage      float64 `json:"uptime_percentage"`
+	CoveragePercentage    float64 `json:"coverage_percentage"`
+	CountUptimePercentage float64 `json:"count_uptime_percentage"`
+	TotalChecks           int     `json:"total_checks"`
+	OnlineCount           int     `json:"online_count"`
+	OfflineCount          int     `json:"offline_count"`
+	AvgResponseTime       float64 `json:"avg_response_time"`
 }
 
 // TimeRange represents a time range
@@ -50,15 +132,43 @@ type MetricDataPoint struct {
 	CheckCount       int       `json:"check_count"`
 	OnlineCount      int       `json:"online_count"`
 	UptimePercentage float64   `json:"uptime_percentage"`
-	AvgResponseTime  float64   `json:"avg_response_time"`
+	// Share of the data point's bucket with fresh results
+	CoveragePercentage    float64 `json:"coverage_percentage"`
+	CountUptimePercentage float64 `json:"count_uptime_percentage"`
+	AvgResponseTime       float64 `json:"avg_response_time"`
+	P50ResponseTime       float64 `json:"p50_response_time"`
+	P90ResponseTime       float64 `json:"p90_response_time"`
+	P95ResponseTime       float64 `json:"p95_response_time"`
+	P99ResponseTime       float64 `json:"p99_response_time"`
 }
 
 // GetServiceMetrics retrieves aggregated metrics for a service over a time range
-func (m *MetricsService) GetServiceMetrics(ctx context.Context, serviceID string, startTime, endTime time.Time, intervalMinutes int) (*MetricsResponse, error) {
-	// Validate intervalMinutes to prevent division by zero in SQL
+// Data points are aligned to local midnight in loc (UTC if nil) and the interval is widened
+// when the range would exceed MaxMetricDataPoints. When rollups are configured, the coarsest
+// tier that satisfies the range and interval is used
+func (m *MetricsService) GetServiceMetrics(ctx context.Context, serviceID string, startTime, endTime time.Time, intervalMinutes int, loc *time.Location) (*MetricsResponse, error) {
 	if intervalMinutes <= 0 {
 		return nil, fmt.Errorf("invalid intervalMinutes: must be > 0, got %d", intervalMinutes)
 	}
+	if !startTime.Before(endTime) {
+		return nil, fmt.Errorf("invalid time range: start %v is not before end %v", startTime, endTime)
+	}
+	if loc == nil {
+		loc = time.UTC
+	}
+
+	intervalMinutes, boundaries := metricBuckets(startTime, endTime, intervalMinutes, loc)
+
+	if m.rollupRepo != nil {
+		now := time.Now()
+		tier, interval := selectMetricsTier(now, startTime, intervalMinutes, m.rawRetentionDays, m.hourlyRetentionMonths, rollupAlignment(loc, startTime, now))
+		if tier != MetricsTierRaw {
+			if interval != intervalMinutes {
+				interval, boundaries = metricBuckets(startTime, endTime, interval, loc)
+			}
+			return m.getRollupMetrics(ctx, serviceID, tier, startTime, endTime, interval, boundaries, loc)
+		}
+	}
 
 	// Get overall stats
 	stats, err := m.statusLogRepo.GetUptimeStats(ctx, serviceID, startTime, endTime)
@@ -66,21 +176,78 @@ func (m *MetricsService) GetServiceMetrics(ctx context.Context, serviceID string
 		return nil, fmt.Errorf("failed to get uptime stats: %w", err)
 	}
 
+	// Get response time percentiles (exact on raw data)
+	percentiles, err := m.statusLogRepo.GetResponseTimePercentiles(ctx, serviceID, startTime, endTime)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get response time percentiles: %w", err)
+	}
+
 	// Get aggregated data points for graphing
-	aggregatedData, err := m.statusLogRepo.GetAggregatedByInterval(ctx, serviceID, startTime, endTime, intervalMinutes)
+	aggregatedData, err := m.statusLogRepo.GetAggregatedByBuckets(ctx, serviceID, startTime, endTime, boundaries)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get aggregated data: %w", err)
 	}
 
+	// Time-weighted uptime: results from before the range still count for up to maxStaleness
+	samples, err := m.statusLogRepo.GetStatusSamples(ctx, serviceID, startTime.Add(-m.maxStaleness), endTime)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get status samples: %w", err)
+	}
+	overall := timeWeightedDurations(samples, startTime, endTime, []time.Time{startTime}, m.maxStaleness)[0]
+	bucketDurations := timeWeightedDurations(samples, startTime, endTime, boundaries, m.maxStaleness)
+	bucketIndex := make(map[int64]int, len(boundaries))
+	for i, boundary := range boundaries {
+		bucketIndex[boundary.UnixNano()] = i
+	}
+
+	// Get per-family stats (dual-stack and family-pinned checks)
+	familyStats, err := m.statusLogRepo.GetUptimeStatsByFamily(ctx, serviceID, startTime, endTime)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get address family stats: %w", err)
+	}
+
+	var byFamily map[string]FamilyMetrics
+	if len(familyStats) > 0 {
+		byFamily = make(map[string]FamilyMetrics, len(familyStats))
+		for family, fs := range familyStats {
+			var familySamples []repository.StatusSample
+			for _, sample := range samples {
+				if sample.AddressFamily != nil && *sample.AddressFamily == family {
+					familySamples = append(familySamples, sample)
+				}
+			}
+			familyDurations := timeWeightedDurations(familySamples, startTime, endTime, []time.Time{startTime}, m.maxStaleness)[0]
+
+			byFamily[family] = FamilyMetrics{
+				UptimePercentage:      familyDurations.uptime(),
+				CoveragePercentage:    familyDurations.coverage(endTime.Sub(startTime)),
+				CountUptimePercentage: fs["uptime_percentage"].(float64),
+				TotalChecks:           fs["total_checks"].(int),
+				OnlineCount:           fs["online_count"].(int),
+				OfflineCount:          fs["offline_count"].(int),
+				AvgResponseTime:       fs["avg_response_time"].(float64),
+			}
+		}
+	}
+
 	// Convert aggregated data to MetricDataPoints
 	dataPoints := make([]MetricDataPoint, len(aggregatedData))
 	for i, data := range aggregatedData {
+		pointPercentiles, _ := data["percentiles"].(repository.ResponseTimePercentiles)
+		timestamp := data["timestamp"].(time.Time)
+		index := bucketIndex[timestamp.UnixNano()]
 		dataPoints[i] = MetricDataPoint{
-			Timestamp:        data["timestamp"].(time.Time),
-			CheckCount:       data["check_count"].(int),
-			OnlineCount:      data["online_count"].(int),
-			UptimePercentage: data["uptime_percentage"].(float64),
-			AvgResponseTime:  data["avg_response_time"].(float64),
+			Timestamp:             timestamp.In(loc),
+			CheckCount:            data["check_count"].(int),
+			OnlineCount:           data["online_count"].(int),
+			UptimePercentage:      bucketDurations[index].uptime(),
+			CoveragePercentage:    bucketDurations[index].coverage(bucketPeriod(boundaries, index, startTime, endTime)),
+			CountUptimePercentage: data["uptime_percentage"].(float64),
+			AvgResponseTime:       data["avg_response_time"].(float64),
+			P50ResponseTime:       pointPercentiles.P50,
+			P90ResponseTime:       pointPercentiles.P90,
+			P95ResponseTime:       pointPercentiles.P95,
+			P99ResponseTime:       pointPercentiles.P99,
 		}
 	}
 
@@ -90,14 +257,24 @@ func (m *MetricsService) GetServiceMetrics(ctx context.Context, serviceID string
 			Start: startTime,
 			End:   endTime,
 		},
-		UptimePercentage: stats["uptime_percentage"].(float64),
-		TotalChecks:      stats["total_checks"].(int),
-		OnlineCount:      stats["online_count"].(int),
-		OfflineCount:     stats["offline_count"].(int),
-		AvgResponseTime:  stats["avg_response_time"].(float64),
-		MinResponseTime:  stats["min_response_time"].(float64),
-		MaxResponseTime:  stats["max_response_time"].(float64),
-		DataPoints:       dataPoints,
+		Tier:                  MetricsTierRaw,
+		IntervalMinutes:       intervalMinutes,
+		Timezone:              loc.String(),
+		UptimePercentage:      overall.uptime(),
+		CoveragePercentage:    overall.coverage(endTime.Sub(startTime)),
+		CountUptimePercentage: stats["uptime_percentage"].(float64),
+		TotalChecks:           stats["total_checks"].(int),
+		OnlineCount:           stats["online_count"].(int),
+		OfflineCount:          stats["offline_count"].(int),
+		AvgResponseTime:       stats["avg_response_time"].(float64),
+		MinResponseTime:       stats["min_response_time"].(float64),
+		MaxResponseTime:       stats["max_response_time"].(float64),
+		P50ResponseTime:       percentiles.P50,
+		P90ResponseTime:       percentiles.P90,
+		P95ResponseTime:       percentiles.P95,
+		P99ResponseTime:       percentiles.P99,
+		DataPoints:            dataPoints,
+		ByAddressFamily:       byFamily,
 	}, nil
 }
 
@@ -106,148 +283,35 @@ func (m *MetricsService) GetRecentStatusLogs(ctx context.Context, serviceID stri
 	return m.statusLogRepo.GetLatestByServiceID(ctx, serviceID, limit)
 }
 
-// GetLast24HoursUptime calculates uptime percentage for the last 24 hours
+// GetLast24HoursUptime calculates the time-weighted uptime percentage for the last 24 hours
 func (m *MetricsService) GetLast24HoursUptime(ctx context.Context, serviceID string) (float64, error) {
 	endTime := time.Now()
 	startTime := endTime.Add(-24 * time.Hour)
 
-	stats, err := m.statusLogRepo.GetUptimeStats(ctx, serviceID, startTime, endTime)
+	samples, err := m.statusLogRepo.GetStatusSamples(ctx, serviceID, startTime.Add(-m.maxStaleness), endTime)
 	if err != nil {
 		return 0, err
 	}
 
-	return stats["uptime_percentage"].(float64), nil
+	return timeWeightedDurations(samples, startTime, endTime, []time.Time{startTime}, m.maxStaleness)[0].uptime(), nil
 }
 
 // CleanupOldLogs removes status logs older than the retention period
+// On a partitioned table whole partitions are dropped instead of deleting rows;
+// the returned count then only covers rows deleted from the default partition
 func (m *MetricsService) CleanupOldLogs(ctx context.Context, retentionDays int) (int64, error) {
 	cutoffTime := time.Now().AddDate(0, 0, -retentionDays)
-	return m.statusLogRepo.DeleteOlderThan(ctx, cutoffTime)
-}
-
-// PrometheusMetrics represents metrics in Prometheus format
-type PrometheusMetrics struct {
-	ServiceMetrics []ServiceMetric
-	TotalServices  int
-	OnlineServices int
-}
-
-// ServiceMetric represents a single service's metrics for Prometheus
-type ServiceMetric struct {
-	ServiceID    string
-	ServiceName  string
-	ServiceURL   string
-	Status       string
-	IsOnline     int
-	ResponseTime int
-}
-
-// GetPrometheusMetrics retrieves all service metrics in a Prometheus-compatible format (admin only)
-func (m *MetricsService) GetPrometheusMetrics(ctx context.Context) (*PrometheusMetrics, error) {
-	// Get all services
-	services, err := m.serviceRepo.GetAll(ctx)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get services: %w", err)
-	}
-
-	return m.buildPrometheusMetrics(services), nil
-}
-
-// GetPrometheusMetricsByUser retrieves service metrics for a specific user
-func (m *MetricsService) GetPrometheusMetricsByUser(ctx context.Context, userID string) (*PrometheusMetrics, error) {
-	// Get services for specific user
-	services, err := m.serviceRepo.GetAllByUserID(ctx, userID)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get user services: %w", err)
-	}
-
-	return m.buildPrometheusMetrics(services), nil
-}
 
-// buildPrometheusMetrics converts service models to Prometheus metrics format
-func (m *MetricsService) buildPrometheusMetrics(services []*models.Service) *PrometheusMetrics {
-	totalServices := len(services)
-	onlineServices := 0
-	serviceMetrics := make([]ServiceMetric, 0, totalServices)
-
-	for _, service := range services {
-		isOnline := 0
-		if service.Status == models.StatusOnline {
-			isOnline = 1
-			onlineServices++
+	if m.partitions != nil {
+		partitioned, err := m.partitions.Enabled(ctx)
+		if err != nil {
+			return 0, fmt.Errorf("failed to check status log partitioning: %w", err)
 		}
-
-		responseTime := 0
-		if service.ResponseTime != nil {
-			responseTime = *service.ResponseTime
+		if partitioned {
+			_, deleted, err := m.partitions.DropBefore(ctx, cutoffTime)
+			return deleted, err
 		}
-
-		serviceMetrics = append(serviceMetrics, ServiceMetric{
-			ServiceID:    service.ID,
-			ServiceName:  service.Name,
-			ServiceURL:   service.URL,
-			Status:       service.Status,
-			IsOnline:     isOnline,
-			ResponseTime: responseTime,
-		})
-	}
-
-	return &PrometheusMetrics{
-		ServiceMetrics: serviceMetrics,
-		TotalServices:  totalServices,
-		OnlineServices: onlineServices,
-	}
-}
-
-// escapePromLabel escapes special characters in Prometheus label values
-// to prevent invalid metric exposition format
-func escapePromLabel(s string) string {
-	replacer := strings.NewReplacer(
-		"\\", "\\\\", // backslash -> double backslash
-		"\n", "\\n", // newline -> \n
-		"\"", "\\\"", // quote -> \"
-	)
-	return replacer.Replace(s)
-}
-
-// FormatPrometheusMetrics converts metrics to Prometheus text format
-func FormatPrometheusMetrics(metrics *PrometheusMetrics) string {
-	output := ""
-
-	// Add HELP and TYPE comments
-	output += "# HELP nimbus_service_up Whether the service is up (1) or down (0)\n"
-	output += "# TYPE nimbus_service_up gauge\n"
-
-	for _, metric := range metrics.ServiceMetrics {
-		output += fmt.Sprintf(
-			"nimbus_service_up{service_id=\"%s\",service_name=\"%s\",service_url=\"%s\",status=\"%s\"} %d\n",
-			escapePromLabel(metric.ServiceID),
-			escapePromLabel(metric.ServiceName),
-			escapePromLabel(metric.ServiceURL),
-			escapePromLabel(metric.Status),
-			metric.IsOnline,
-		)
 	}
 
-	output += "\n# HELP nimbus_service_response_time_milliseconds Response time of the service in milliseconds\n"
-	output += "# TYPE nimbus_service_response_time_milliseconds gauge\n"
-
-	for _, metric := range metrics.ServiceMetrics {
-		output += fmt.Sprintf(
-			"nimbus_service_response_time_milliseconds{service_id=\"%s\",service_name=\"%s\"} %d\n",
-			escapePromLabel(metric.ServiceID),
-			escapePromLabel(metric.ServiceName),
-			metric.ResponseTime,
-		)
-	}
-
-	output += "\n# HELP nimbus_total_services Total number of services being monitored\n"
-	output += "# TYPE nimbus_total_services gauge\n"
-	output += fmt.Sprintf("nimbus_total_services %d\n", metrics.TotalServices)
-
-	output += "\n# HELP nimbus_online_services Number of services currently online\n"
-	output += "# TYPE nimbus_online_services gauge\n"
-	output += fmt.Sprintf("nimbus_online_services %d\n", metrics.OnlineServices)
-
-	return output
+	return m.statusLogRepo.DeleteOlderThan(ctx, cutoffTime)
 }
@@ -32,6 +32,7 @@ func setupMetricsTestDB(t *testing.T) *sql.DB {
 			status TEXT DEFAULT 'unknown',
 			response_time INTEGER,
 			position INTEGER,
+			check_config TEXT NOT NULL DEFAULT '{}',
 			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
 			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
 		)
@@ -48,6 +49,11 @@ func setupMetricsTestDB(t *testing.T) *sql.DB {
 			status TEXT NOT NULL CHECK(status IN ('online', 'offline', 'unknown')),
 			response_time INTEGER,
 			error_message TEXT,
+			event TEXT,
+			event_detail TEXT,
+			failed_step INTEGER,
+			step_timings TEXT,
+			address_family TEXT,
 			checked_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
 			FOREIGN KEY(service_id) REFERENCES services(id) ON DELETE CASCADE
 		)
@@ -71,11 +77,69 @@ func setupMetricsTestDB(t *testing.T) *sql.DB {
 }
 
 func TestMetricsService_GetServiceMetrics(t *testing.T) {
-	// NOTE: This test is skipped because GetServiceMetrics relies on GetAggregatedByInterval
-	// which uses PostgreSQL-specific SQL functions (date_trunc, EXTRACT, interval).
-	// In production, this works fine with PostgreSQL. For SQLite testing, we test
-	// the individual components separately (GetUptimeStats, GetRecentStatusLogs, etc.)
-	t.Skip("Skipping due to PostgreSQL-specific SQL in GetAggregatedByInterval - tested in integration tests with real PostgreSQL")
+	db := setupMetricsTestDB(t)
+	defer db.Close()
+
+	statusLogRepo := repository.NewStatusLogRepository(db)
+	serviceRepo := repository.NewServiceRepository(db)
+	metricsService := NewMetricsService(statusLogRepo, serviceRepo)
+
+	ctx := context.Background()
+	amsterdam, err := time.LoadLocation("Europe/Amsterdam")
+	if err != nil {
+		t.Skipf("Time zone data unavailable: %v", err)
+	}
+
+	// Checks every hour around local midnight: 22:00-23:00 on the 17th, 00:00-02:00 on the 18th
+	// (the 00:00 and 01:00 checks are still the 17th in UTC)
+	for i, hour := range []int{22, 23, 24, 25, 26} {
+		status := models.StatusOnline
+		if i == 4 {
+			status = models.StatusOffline
+		}
+		responseTime := 100 * (i + 1)
+		log := &models.StatusLog{
+			ServiceID:    "test-service-1",
+			Status:       status,
+			ResponseTime: &responseTime,
+			CheckedAt:    time.Date(2026, 10, 17, hour, 0, 0, 0, amsterdam),
+		}
+		if err := statusLogRepo.Create(ctx, log); err != nil {
+			t.Fatalf("Failed to create test log: %v", err)
+		}
+	}
+
+	start := time.Date(2026, 10, 17, 12, 0, 0, 0, amsterdam)
+	end := time.Date(2026, 10, 18, 12, 0, 0, 0, amsterdam)
+	metrics, err := metricsService.GetServiceMetrics(ctx, "test-service-1", start, end, 24*60, amsterdam)
+	if err != nil {
+		t.Fatalf("GetServiceMetrics() error = %v", err)
+	}
+
+	if metrics.TotalChecks != 5 || metrics.OnlineCount != 4 {
+		t.Errorf("Expected 5 checks (4 online), got %d (%d)", metrics.TotalChecks, metrics.OnlineCount)
+	}
+	if metrics.Timezone != "Europe/Amsterdam" || metrics.IntervalMinutes != 24*60 {
+		t.Errorf("Expected daily points in Europe/Amsterdam, got %d minutes in %s", metrics.IntervalMinutes, metrics.Timezone)
+	}
+	if metrics.P50ResponseTime != 300 {
+		t.Errorf("Expected p50 of 300ms, got %v", metrics.P50ResponseTime)
+	}
+
+	// Daily points split at local midnight, not UTC midnight
+	if len(metrics.DataPoints) != 2 {
+		t.Fatalf("Expected 2 daily data points, got %d", len(metrics.DataPoints))
+	}
+	first, second := metrics.DataPoints[0], metrics.DataPoints[1]
+	if !first.Timestamp.Equal(time.Date(2026, 10, 17, 0, 0, 0, 0, amsterdam)) || first.CheckCount != 2 {
+		t.Errorf("Expected 2 checks on the 17th (local), got %d at %v", first.CheckCount, first.Timestamp)
+	}
+	if !second.Timestamp.Equal(time.Date(2026, 10, 18, 0, 0, 0, 0, amsterdam)) || second.CheckCount != 3 {
+		t.Errorf("Expected 3 checks on the 18th (local), got %d at %v", second.CheckCount, second.Timestamp)
+	}
+	if second.UptimePercentage < 66.6 || second.UptimePercentage > 66.7 || second.AvgResponseTime != 400 {
+		t.Errorf("Expected 66.67%% uptime and 400ms average on the 18th, got %+v", second)
+	}
 }
 
 func TestMetricsService_GetRecentStatusLogs(t *testing.T) {
@@ -156,9 +220,20 @@ func TestMetricsService_GetLast24HoursUptime(t *testing.T) {
 		t.Fatalf("Failed to get last 24 hours uptime: %v", err)
 	}
 
-	expectedUptime := 80.0
-	if uptime != expectedUptime {
-		t.Errorf("Expected uptime %.2f%%, got %.2f%%", expectedUptime, uptime)
+	// Hourly checks with the default staleness: each result counts for 3 minutes. The latest
+	// (offline) result is cut off by the end of the range, so 8 online results weigh against ~1 offline
+	if uptime < 88.8 || uptime > 89.0 {
+		t.Errorf("Expected time-weighted uptime of ~88.9%%, got %.2f%%", uptime)
+	}
+
+	// With a staleness covering the gaps, each result holds until the next one: 8 of 9 hours online
+	metricsService.SetUptimeMaxStaleness(2 * time.Hour)
+	uptime, err = metricsService.GetLast24HoursUptime(ctx, "test-service-1")
+	if err != nil {
+		t.Fatalf("Failed to get last 24 hours uptime: %v", err)
+	}
+	if uptime < 88.8 || uptime > 89.0 {
+		t.Errorf("Expected time-weighted uptime of ~88.9%%, got %.2f%%", uptime)
 	}
 }
 
@@ -218,6 +293,10 @@ func TestMetricsService_GetPrometheusMetrics(t *testing.T) {
 	statusLogRepo := repository.NewStatusLogRepository(db)
 	serviceRepo := repository.NewServiceRepository(db)
 	metricsService := NewMetricsService(statusLogRepo, serviceRepo)
+	checks := NewCheckMetrics()
+	metricsService.SetCheckMetrics(checks)
+	checks.observeCheck("test-service-1", models.StatusOnline, intPtr(150), time.Now())
+	checks.observeCheck("deleted-service", models.StatusOnline, intPtr(150), time.Now())
 
 	ctx := context.Background()
 
@@ -234,6 +313,14 @@ func TestMetricsService_GetPrometheusMetrics(t *testing.T) {
 		t.Fatalf("Failed to get Prometheus metrics: %v", err)
 	}
 
+	// Check metrics of services that no longer exist are left out
+	if len(metrics.Checks.Services) != 1 || metrics.Checks.Services[0].ServiceID != "test-service-1" {
+		t.Errorf("Expected check metrics for test-service-1 only, got %+v", metrics.Checks.Services)
+	}
+	if metrics.Checks.Results[models.StatusOnline] != 1 {
+		t.Errorf("Expected 1 online check, got %d", metrics.Checks.Results[models.StatusOnline])
+	}
+
 	// Verify metrics
 	if metrics.TotalServices != 2 {
 		t.Errorf("Expected 2 total services, got %d", metrics.TotalServices)
@@ -258,8 +345,8 @@ func TestMetricsService_GetPrometheusMetrics(t *testing.T) {
 			if metric.IsOnline != 1 {
 				t.Errorf("Expected is_online 1, got %d", metric.IsOnline)
 			}
-			if metric.ResponseTime != responseTime {
-				t.Errorf("Expected response time %d, got %d", responseTime, metric.ResponseTime)
+			if metric.ResponseTime == nil || *metric.ResponseTime != responseTime {
+				t.Errorf("Expected response time %d, got %v", responseTime, metric.ResponseTime)
 			}
 		}
 	}
@@ -280,15 +367,14 @@ func TestFormatPrometheusMetrics(t *testing.T) {
 				ServiceURL:   "http://example.com",
 				Status:       "online",
 				IsOnline:     1,
-				ResponseTime: 150,
+				ResponseTime: intPtr(150),
 			},
 			{
-				ServiceID:    "service-2",
-				ServiceName:  "Test Service 2",
-				ServiceURL:   "http://example2.com",
-				Status:       "offline",
-				IsOnline:     0,
-				ResponseTime: 0,
+				ServiceID:   "service-2",
+				ServiceName: "Test Service 2",
+				ServiceURL:  "http://example2.com",
+				Status:      "offline",
+				IsOnline:    0,
 			},
 		},
 	}
@@ -320,8 +406,29 @@ func TestFormatPrometheusMetrics(t *testing.T) {
 }
 
 func TestMetricsService_GetServiceMetrics_NoData(t *testing.T) {
-	// NOTE: Skipped for same reason as TestMetricsService_GetServiceMetrics
-	t.Skip("Skipping due to PostgreSQL-specific SQL in GetAggregatedByInterval - tested in integration tests with real PostgreSQL")
+	db := setupMetricsTestDB(t)
+	defer db.Close()
+
+	statusLogRepo := repository.NewStatusLogRepository(db)
+	serviceRepo := repository.NewServiceRepository(db)
+	metricsService := NewMetricsService(statusLogRepo, serviceRepo)
+
+	now := time.Now()
+	metrics, err := metricsService.GetServiceMetrics(context.Background(), "test-service-2", now.Add(-24*time.Hour), now, 5, nil)
+	if err != nil {
+		t.Fatalf("GetServiceMetrics() error = %v", err)
+	}
+
+	if metrics.TotalChecks != 0 || metrics.UptimePercentage != 0 || len(metrics.DataPoints) != 0 {
+		t.Errorf("Expected empty metrics, got %+v", metrics)
+	}
+	if metrics.Timezone != "UTC" {
+		t.Errorf("Expected UTC by default, got %s", metrics.Timezone)
+	}
+
+	if _, err := metricsService.GetServiceMetrics(context.Background(), "test-service-2", now, now.Add(-time.Hour), 5, nil); err == nil {
+		t.Error("Expected an error for a start time after the end time")
+	}
 }
 
 // Helper function to check if string contains substring
@@ -0,0 +1,482 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/mail"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/nimbus/backend/internal/mailer"
+	"github.com/nimbus/backend/internal/models"
+)
+
+// DefaultNtfyServer is used for ntfy channels without a server URL
+const DefaultNtfyServer = "https://ntfy.sh"
+
+// errPermanentNotification marks delivery errors that won't succeed on retry (bad URL, rejected payload)
+var errPermanentNotification = errors.New("permanent notification error")
+
+var ntfyTopicPattern = regexp.MustCompile(`^[-_A-Za-z0-9]{1,64}$`)
+
+// Notification is one message sent to notification channels
+type Notification struct {
+	Event           string    `json:"event"` // models.NotificationEventDown, Up, Reminder, Escalation, Digest or Test
+	ServiceID       string    `json:"service_id"`
+	ServiceName     string    `json:"service_name"`
+	ServiceURL      string    `json:"service_url"`
+	Status          string    `json:"status"`
+	PreviousStatus  string    `json:"previous_status"`
+	ErrorMessage    *string   `json:"error_message"`
+	DurationSeconds *float64  `json:"duration_seconds"` // Time spent in the previous status (nil if unknown); reminders and escalations: time down so far
+	OccurredAt      time.Time `json:"occurred_at"`
+	ChannelName     string    `json:"channel_name"`
+
+	Report *models.DigestReport `json:"report,omitempty"` // Digest reports only
+
+	channelIDs []string       // Channels chosen by an alert rule or digest (nil: the service's channels)
+	digest     *digestMessage // Rendered digest report
+}
+
+// Title is a one-line summary of the notification
+func (n *Notification) Title() string {
+	switch n.Event {
+	case models.NotificationEventDown:
+		return fmt.Sprintf("🔴 %s is down", n.ServiceName)
+	case models.NotificationEventUp:
+		return fmt.Sprintf("🟢 %s is back up", n.ServiceName)
+	case models.NotificationEventReminder:
+		return fmt.Sprintf("🔴 %s is still down", n.ServiceName)
+	case models.NotificationEventEscalation:
+		return fmt.Sprintf("🚨 %s is still down (escalated)", n.ServiceName)
+	case models.NotificationEventDigest:
+		return n.digest.title
+	default:
+		return "Nimbus test notification"
+	}
+}
+
+// Message is the body of the notification
+func (n *Notification) Message() string {
+	switch n.Event {
+	case models.NotificationEventDigest:
+		return n.digest.markdown
+	case models.NotificationEventDown:
+		msg := fmt.Sprintf("%s (%s) went offline at %s.", n.ServiceName, n.ServiceURL, n.OccurredAt.UTC().Format(time.RFC1123))
+		if n.ErrorMessage != nil && *n.ErrorMessage != "" {
+			msg += "\nError: " + *n.ErrorMessage
+		}
+		return msg
+	case models.NotificationEventUp:
+		msg := fmt.Sprintf("%s (%s) is online again.", n.ServiceName, n.ServiceURL)
+		if n.DurationSeconds != nil {
+			msg += "\nDowntime: " + (time.Duration(*n.DurationSeconds) * time.Second).String()
+		}
+		return msg
+	case models.NotificationEventReminder, models.NotificationEventEscalation:
+		msg := fmt.Sprintf("%s (%s) has been offline since %s.", n.ServiceName, n.ServiceURL, n.OccurredAt.UTC().Format(time.RFC1123))
+		if n.DurationSeconds != nil {
+			msg += "\nDowntime so far: " + (time.Duration(*n.DurationSeconds) * time.Second).String()
+		}
+		if n.ErrorMessage != nil && *n.ErrorMessage != "" {
+			msg += "\nError: " + *n.ErrorMessage
+		}
+		return msg
+	default:
+		return fmt.Sprintf("Notification channel %q is set up correctly.", n.ChannelName)
+	}
+}
+
+// isRecovery reports whether the notification announces good news (rendered green)
+func (n *Notification) isRecovery() bool {
+	return n.Event == models.NotificationEventUp || n.Event == models.NotificationEventTest
+}
+
+// testNotification is sent by the test endpoints and used to validate webhook templates
+func testNotification(channelName string) *Notification {
+	errorMessage := "Connection refused"
+	return &Notification{
+		Event:          models.NotificationEventTest,
+		ServiceID:      "00000000-0000-0000-0000-000000000000",
+		ServiceName:    "Example service",
+		ServiceURL:     "https://example.com",
+		Status:         models.StatusOnline,
+		PreviousStatus: models.StatusOffline,
+		ErrorMessage:   &errorMessage,
+		OccurredAt:     time.Now(),
+		ChannelName:    channelName,
+	}
+}
+
+// notificationProvider delivers notifications for one channel type
+// validate checks a channel's config and fills in defaults
+type notificationProvider interface {
+	validate(cfg *models.NotificationChannelConfig) error
+	send(ctx context.Context, client *http.Client, cfg *models.NotificationChannelConfig, n *Notification) error
+}
+
+var notificationProviders = map[string]notificationProvider{
+	models.NotificationTypeWebhook: webhookProvider{},
+	models.NotificationTypeNtfy:    ntfyProvider{},
+	models.NotificationTypeGotify:  gotifyProvider{},
+	models.NotificationTypeDiscord: discordProvider{},
+	models.NotificationTypeSlack:   slackProvider{},
+	models.NotificationTypeEmail:   emailProvider{},
+}
+
+// validateHTTPURL checks that a channel URL is an absolute http(s) URL
+func validateHTTPURL(field, rawURL string) error {
+	parsedURL, err := url.Parse(rawURL)
+	if err != nil || (parsedURL.Scheme != "http" && parsedURL.Scheme != "https") || parsedURL.Host == "" {
+		return fmt.Errorf("%s must be an http or https URL", field)
+	}
+	return nil
+}
+
+// sendHTTP makes a notification request
+// 4xx responses (other than 408 and 429) are permanent failures, everything else is retried
+func sendHTTP(ctx context.Context, client *http.Client, method, rawURL string, headers map[string]string, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, method, rawURL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("%w: %v", errPermanentNotification, err)
+	}
+	req.Header.Set("User-Agent", "Nimbus")
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		if errors.Is(err, ErrEgressBlocked) {
+			return fmt.Errorf("%w: %v", errPermanentNotification, err)
+		}
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+		return nil
+	}
+
+	detail, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	err = fmt.Errorf("HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(detail)))
+	if resp.StatusCode >= 400 && resp.StatusCode < 500 && resp.StatusCode != http.StatusRequestTimeout && resp.StatusCode != http.StatusTooManyRequests {
+		return fmt.Errorf("%w: %v", errPermanentNotification, err)
+	}
+	return err
+}
+
+func sendJSON(ctx context.Context, client *http.Client, rawURL string, headers map[string]string, payload any) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("%w: %v", errPermanentNotification, err)
+	}
+	if headers == nil {
+		headers = map[string]string{}
+	}
+	headers["Content-Type"] = "application/json"
+	return sendHTTP(ctx, client, http.MethodPost, rawURL, headers, body)
+}
+
+// webhookProvider sends the notification as JSON, optionally shaped by a Go template
+// Templates see the Notification fields plus .Title and .Message; {{json .X}} writes a JSON-encoded value
+type webhookProvider struct{}
+
+var webhookTemplateFuncs = template.FuncMap{
+	"json": func(v any) (string, error) {
+		data, err := json.Marshal(v)
+		return string(data), err
+	},
+}
+
+// webhookTemplateData exposes the notification and its rendered title and message to templates
+type webhookTemplateData struct {
+	*Notification
+	Title   string
+	Message string
+}
+
+func (webhookProvider) validate(cfg *models.NotificationChannelConfig) error {
+	if err := validateHTTPURL("url", cfg.URL); err != nil {
+		return err
+	}
+	cfg.Method = strings.ToUpper(cfg.Method)
+	switch cfg.Method {
+	case "":
+		cfg.Method = http.MethodPost
+	case http.MethodPost, http.MethodPut, http.MethodPatch:
+	default:
+		return fmt.Errorf("method must be POST, PUT or PATCH")
+	}
+	for name, value := range cfg.Headers {
+		if name == "" || strings.ContainsAny(name, " :\r\n") {
+			return fmt.Errorf("invalid header name %q", name)
+		}
+		if strings.ContainsAny(value, "\r\n") {
+			return fmt.Errorf("invalid value for header %q", name)
+		}
+	}
+	if cfg.BodyTemplate != "" {
+		// Render a sample so broken templates are rejected when the channel is saved
+		if _, err := renderWebhookBody(cfg.BodyTemplate, testNotification("example")); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// renderWebhookBody renders the body template, which must produce valid JSON
+func renderWebhookBody(bodyTemplate string, n *Notification) ([]byte, error) {
+	if bodyTemplate == "" {
+		return json.Marshal(webhookPayload(n))
+	}
+
+	tmpl, err := template.New("body").Funcs(webhookTemplateFuncs).Option("missingkey=error").Parse(bodyTemplate)
+	if err != nil {
+		return nil, fmt.Errorf("invalid body_template: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, webhookTemplateData{Notification: n, Title: n.Title(), Message: n.Message()}); err != nil {
+		return nil, fmt.Errorf("invalid body_template: %v", err)
+	}
+	if !json.Valid(buf.Bytes()) {
+		return nil, fmt.Errorf("body_template must render valid JSON")
+	}
+	return buf.Bytes(), nil
+}
+
+// webhookPayload is the default webhook body: the notification with its title and message
+func webhookPayload(n *Notification) any {
+	return struct {
+		*Notification
+		Title   string `json:"title"`
+		Message string `json:"message"`
+	}{n, n.Title(), n.Message()}
+}
+
+func (webhookProvider) send(ctx context.Context, client *http.Client, cfg *models.NotificationChannelConfig, n *Notification) error {
+	body, err := renderWebhookBody(cfg.BodyTemplate, n)
+	if err != nil {
+		return fmt.Errorf("%w: %v", errPermanentNotification, err)
+	}
+
+	headers := map[string]string{"Content-Type": "application/json"}
+	for name, value := range cfg.Headers {
+		headers[name] = value
+	}
+
+	method := cfg.Method
+	if method == "" {
+		method = http.MethodPost
+	}
+	return sendHTTP(ctx, client, method, cfg.URL, headers, body)
+}
+
+// ntfyProvider publishes to an ntfy topic (https://docs.ntfy.sh/publish/)
+type ntfyProvider struct{}
+
+func (ntfyProvider) validate(cfg *models.NotificationChannelConfig) error {
+	if cfg.URL == "" {
+		cfg.URL = DefaultNtfyServer
+	}
+	if err := validateHTTPURL("url", cfg.URL); err != nil {
+		return err
+	}
+	if !ntfyTopicPattern.MatchString(cfg.Topic) {
+		return fmt.Errorf("topic is required (letters, digits, - and _, at most 64 characters)")
+	}
+	if cfg.Priority != nil && (*cfg.Priority < 1 || *cfg.Priority > 5) {
+		return fmt.Errorf("priority must be between 1 and 5")
+	}
+	return nil
+}
+
+func (ntfyProvider) send(ctx context.Context, client *http.Client, cfg *models.NotificationChannelConfig, n *Notification) error {
+	server := cfg.URL
+	if server == "" {
+		server = DefaultNtfyServer
+	}
+
+	headers := map[string]string{
+		"Content-Type": "text/plain; charset=utf-8",
+		"Title":        n.Title(),
+		"Tags":         "white_check_mark",
+	}
+	priority := 3
+	if n.Event == models.NotificationEventDigest {
+		headers["Tags"] = "bar_chart"
+		headers["Markdown"] = "yes"
+	} else if !n.isRecovery() {
+		headers["Tags"] = "rotating_light"
+		priority = 4
+	}
+	if cfg.Priority != nil {
+		priority = *cfg.Priority
+	}
+	headers["Priority"] = strconv.Itoa(priority)
+	if n.ServiceURL != "" {
+		headers["Click"] = n.ServiceURL
+	}
+	if cfg.Token != "" {
+		headers["Authorization"] = "Bearer " + cfg.Token
+	}
+
+	return sendHTTP(ctx, client, http.MethodPost, strings.TrimSuffix(server, "/")+"/"+cfg.Topic, headers, []byte(n.Message()))
+}
+
+// gotifyProvider creates a Gotify message with an application token
+type gotifyProvider struct{}
+
+func (gotifyProvider) validate(cfg *models.NotificationChannelConfig) error {
+	if err := validateHTTPURL("url", cfg.URL); err != nil {
+		return err
+	}
+	if cfg.Token == "" {
+		return fmt.Errorf("token (application token) is required")
+	}
+	if cfg.Priority != nil && (*cfg.Priority < 0 || *cfg.Priority > 10) {
+		return fmt.Errorf("priority must be between 0 and 10")
+	}
+	return nil
+}
+
+func (gotifyProvider) send(ctx context.Context, client *http.Client, cfg *models.NotificationChannelConfig, n *Notification) error {
+	priority := 5
+	if !n.isRecovery() && n.Event != models.NotificationEventDigest {
+		priority = 8
+	}
+	if cfg.Priority != nil {
+		priority = *cfg.Priority
+	}
+
+	payload := map[string]any{
+		"title":    n.Title(),
+		"message":  n.Message(),
+		"priority": priority,
+	}
+	return sendJSON(ctx, client, strings.TrimSuffix(cfg.URL, "/")+"/message", map[string]string{"X-Gotify-Key": cfg.Token}, payload)
+}
+
+// Embed and attachment colours
+const (
+	notificationColorDown   = 0xE53935
+	notificationColorUp     = 0x43A047
+	notificationColorDigest = 0x3949AB
+)
+
+// maxDiscordDescription is the length limit of a Discord embed description
+const maxDiscordDescription = 4096
+
+func notificationColor(n *Notification) int {
+	if n.Event == models.NotificationEventDigest {
+		return notificationColorDigest
+	}
+	if n.isRecovery() {
+		return notificationColorUp
+	}
+	return notificationColorDown
+}
+
+// discordProvider posts an embed to a Discord incoming webhook
+type discordProvider struct{}
+
+func (discordProvider) validate(cfg *models.NotificationChannelConfig) error {
+	return validateHTTPURL("url", cfg.URL)
+}
+
+func (discordProvider) send(ctx context.Context, client *http.Client, cfg *models.NotificationChannelConfig, n *Notification) error {
+	embed := map[string]any{
+		"title":       n.Title(),
+		"description": truncateRunes(n.Message(), maxDiscordDescription),
+		"color":       notificationColor(n),
+		"timestamp":   n.OccurredAt.UTC().Format(time.RFC3339),
+	}
+	if n.ServiceURL != "" {
+		embed["url"] = n.ServiceURL
+	}
+
+	payload := map[string]any{
+		"username": "Nimbus",
+		"embeds":   []any{embed},
+	}
+	return sendJSON(ctx, client, cfg.URL, nil, payload)
+}
+
+// slackProvider posts a coloured attachment to a Slack incoming webhook
+type slackProvider struct{}
+
+func (slackProvider) validate(cfg *models.NotificationChannelConfig) error {
+	return validateHTTPURL("url", cfg.URL)
+}
+
+func (slackProvider) send(ctx context.Context, client *http.Client, cfg *models.NotificationChannelConfig, n *Notification) error {
+	payload := map[string]any{
+		"text": n.Title(),
+		"attachments": []any{map[string]any{
+			"color": fmt.Sprintf("#%06X", notificationColor(n)),
+			"text":  n.Message(),
+			"ts":    n.OccurredAt.Unix(),
+		}},
+	}
+	return sendJSON(ctx, client, cfg.URL, nil, payload)
+}
+
+// maxEmailRecipients limits the recipients of an email channel
+const maxEmailRecipients = 10
+
+// emailProvider sends an email through the mail service
+// It is registered without a mail service for validation; NotificationService.provider supplies one
+type emailProvider struct {
+	mail *MailService
+}
+
+func (emailProvider) validate(cfg *models.NotificationChannelConfig) error {
+	if len(cfg.To) == 0 || len(cfg.To) > maxEmailRecipients {
+		return fmt.Errorf("to must list 1 to %d email addresses", maxEmailRecipients)
+	}
+	for i, rcpt := range cfg.To {
+		addr, err := mail.ParseAddress(strings.TrimSpace(rcpt))
+		if err != nil {
+			return fmt.Errorf("to: %q is not a valid email address", rcpt)
+		}
+		cfg.To[i] = addr.Address
+	}
+	return nil
+}
+
+func (p emailProvider) send(ctx context.Context, _ *http.Client, cfg *models.NotificationChannelConfig, n *Notification) error {
+	var msg *mailer.Message
+	var err error
+	if n.digest != nil {
+		msg, err = n.digest.email(cfg.To)
+	} else {
+		msg, err = mailer.Alert.Render(cfg.To, mailer.AlertData{
+			Title:      n.Title(),
+			Lines:      strings.Split(n.Message(), "\n"),
+			ServiceURL: n.ServiceURL,
+			Down:       !n.isRecovery(),
+		})
+	}
+	if err != nil {
+		return fmt.Errorf("%w: %v", errPermanentNotification, err)
+	}
+
+	if err := p.mail.Send(ctx, msg); err != nil {
+		if mailer.IsPermanent(err) {
+			return fmt.Errorf("%w: %v", errPermanentNotification, err)
+		}
+		return err
+	}
+	return nil
+}
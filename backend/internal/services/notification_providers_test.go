@@ -0,0 +1,264 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nimbus/backend/internal/models"
+)
+
+// capturedRequest is a request received by a provider stub
+type capturedRequest struct {
+	method  string
+	path    string
+	headers http.Header
+	body    []byte
+}
+
+// newProviderStub records requests and answers with status
+func newProviderStub(t *testing.T, status int) (*httptest.Server, <-chan capturedRequest) {
+	t.Helper()
+	requests := make(chan capturedRequest, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		requests <- capturedRequest{method: r.Method, path: r.URL.Path, headers: r.Header.Clone(), body: body}
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(server.Close)
+	return server, requests
+}
+
+func downNotification() *Notification {
+	errorMessage := "HTTP 503"
+	return &Notification{
+		Event:          models.NotificationEventDown,
+		ServiceID:      "svc-1",
+		ServiceName:    "Plex",
+		ServiceURL:     "http://plex.lan:32400",
+		Status:         models.StatusOffline,
+		PreviousStatus: models.StatusOnline,
+		ErrorMessage:   &errorMessage,
+		OccurredAt:     time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC),
+	}
+}
+
+func sendWithProvider(t *testing.T, channelType string, cfg models.NotificationChannelConfig, n *Notification) error {
+	t.Helper()
+	provider := notificationProviders[channelType]
+	if err := provider.validate(&cfg); err != nil {
+		t.Fatalf("validate() error = %v", err)
+	}
+	return provider.send(context.Background(), http.DefaultClient, &cfg, n)
+}
+
+func TestWebhookProvider_DefaultBody(t *testing.T) {
+	server, requests := newProviderStub(t, http.StatusOK)
+
+	cfg := models.NotificationChannelConfig{URL: server.URL + "/hook", Headers: map[string]string{"X-Secret": "s3cret"}}
+	if err := sendWithProvider(t, models.NotificationTypeWebhook, cfg, downNotification()); err != nil {
+		t.Fatalf("send() error = %v", err)
+	}
+
+	req := <-requests
+	if req.method != http.MethodPost || req.path != "/hook" {
+		t.Errorf("Expected POST /hook, got %s %s", req.method, req.path)
+	}
+	if req.headers.Get("X-Secret") != "s3cret" || req.headers.Get("Content-Type") != "application/json" {
+		t.Errorf("Missing headers: %v", req.headers)
+	}
+
+	var payload map[string]any
+	if err := json.Unmarshal(req.body, &payload); err != nil {
+		t.Fatalf("Body is not JSON: %s", req.body)
+	}
+	if payload["event"] != models.NotificationEventDown || payload["service_name"] != "Plex" || payload["title"] != "🔴 Plex is down" {
+		t.Errorf("Unexpected payload %v", payload)
+	}
+}
+
+func TestWebhookProvider_BodyTemplate(t *testing.T) {
+	server, requests := newProviderStub(t, http.StatusOK)
+
+	cfg := models.NotificationChannelConfig{
+		URL:          server.URL,
+		Method:       "put",
+		BodyTemplate: `{"text": {{json .Title}}, "error": {{json .ErrorMessage}}, "at": "{{.OccurredAt.Format "2006-01-02"}}"}`,
+	}
+	if err := sendWithProvider(t, models.NotificationTypeWebhook, cfg, downNotification()); err != nil {
+		t.Fatalf("send() error = %v", err)
+	}
+
+	req := <-requests
+	if req.method != http.MethodPut {
+		t.Errorf("Expected PUT, got %s", req.method)
+	}
+	expected := `{"text": "🔴 Plex is down", "error": "HTTP 503", "at": "2026-10-18"}`
+	if string(req.body) != expected {
+		t.Errorf("Expected body %s, got %s", expected, req.body)
+	}
+}
+
+func TestWebhookProvider_Validate(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  models.NotificationChannelConfig
+	}{
+		{"Missing URL", models.NotificationChannelConfig{}},
+		{"Unsupported scheme", models.NotificationChannelConfig{URL: "ftp://example.com"}},
+		{"Unsupported method", models.NotificationChannelConfig{URL: "https://example.com", Method: "DELETE"}},
+		{"Invalid header", models.NotificationChannelConfig{URL: "https://example.com", Headers: map[string]string{"Bad Header": "x"}}},
+		{"Template syntax", models.NotificationChannelConfig{URL: "https://example.com", BodyTemplate: `{"a": {{.Title}`}},
+		{"Unknown field", models.NotificationChannelConfig{URL: "https://example.com", BodyTemplate: `{"a": {{json .Nope}}}`}},
+		{"Not JSON", models.NotificationChannelConfig{URL: "https://example.com", BodyTemplate: `{{.Title}}`}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := (webhookProvider{}).validate(&tt.cfg); err == nil {
+				t.Error("Expected validation error")
+			}
+		})
+	}
+}
+
+func TestNtfyProvider(t *testing.T) {
+	server, requests := newProviderStub(t, http.StatusOK)
+
+	cfg := models.NotificationChannelConfig{URL: server.URL + "/", Topic: "homelab", Token: "tk_abc"}
+	if err := sendWithProvider(t, models.NotificationTypeNtfy, cfg, downNotification()); err != nil {
+		t.Fatalf("send() error = %v", err)
+	}
+
+	req := <-requests
+	if req.method != http.MethodPost || req.path != "/homelab" {
+		t.Errorf("Expected POST /homelab, got %s %s", req.method, req.path)
+	}
+	if req.headers.Get("Authorization") != "Bearer tk_abc" || req.headers.Get("Priority") != "4" || req.headers.Get("Tags") != "rotating_light" {
+		t.Errorf("Unexpected headers: %v", req.headers)
+	}
+	if !strings.Contains(string(req.body), "Plex (http://plex.lan:32400) went offline") || !strings.Contains(string(req.body), "Error: HTTP 503") {
+		t.Errorf("Unexpected message %q", req.body)
+	}
+
+	// The server defaults to ntfy.sh; the topic is required
+	empty := models.NotificationChannelConfig{}
+	if err := (ntfyProvider{}).validate(&empty); err == nil {
+		t.Error("Expected error without a topic")
+	}
+	if empty.URL != DefaultNtfyServer {
+		t.Errorf("Expected default server, got %q", empty.URL)
+	}
+}
+
+func TestGotifyProvider(t *testing.T) {
+	server, requests := newProviderStub(t, http.StatusOK)
+
+	priority := 9
+	cfg := models.NotificationChannelConfig{URL: server.URL, Token: "app-token", Priority: &priority}
+	if err := sendWithProvider(t, models.NotificationTypeGotify, cfg, downNotification()); err != nil {
+		t.Fatalf("send() error = %v", err)
+	}
+
+	req := <-requests
+	if req.path != "/message" || req.headers.Get("X-Gotify-Key") != "app-token" {
+		t.Errorf("Unexpected request %s %v", req.path, req.headers)
+	}
+	var payload struct {
+		Title    string `json:"title"`
+		Message  string `json:"message"`
+		Priority int    `json:"priority"`
+	}
+	if err := json.Unmarshal(req.body, &payload); err != nil {
+		t.Fatalf("Body is not JSON: %s", req.body)
+	}
+	if payload.Title != "🔴 Plex is down" || payload.Priority != 9 {
+		t.Errorf("Unexpected payload %+v", payload)
+	}
+
+	if err := (gotifyProvider{}).validate(&models.NotificationChannelConfig{URL: server.URL}); err == nil {
+		t.Error("Expected error without a token")
+	}
+}
+
+func TestDiscordProvider(t *testing.T) {
+	server, requests := newProviderStub(t, http.StatusNoContent)
+
+	n := downNotification()
+	n.Event = models.NotificationEventUp
+	duration := 125.0
+	n.DurationSeconds = &duration
+	if err := sendWithProvider(t, models.NotificationTypeDiscord, models.NotificationChannelConfig{URL: server.URL}, n); err != nil {
+		t.Fatalf("send() error = %v", err)
+	}
+
+	req := <-requests
+	var payload struct {
+		Embeds []struct {
+			Title       string `json:"title"`
+			Description string `json:"description"`
+			Color       int    `json:"color"`
+		} `json:"embeds"`
+	}
+	if err := json.Unmarshal(req.body, &payload); err != nil || len(payload.Embeds) != 1 {
+		t.Fatalf("Unexpected body %s", req.body)
+	}
+	embed := payload.Embeds[0]
+	if embed.Title != "🟢 Plex is back up" || embed.Color != notificationColorUp || !strings.Contains(embed.Description, "Downtime: 2m5s") {
+		t.Errorf("Unexpected embed %+v", embed)
+	}
+}
+
+func TestSlackProvider(t *testing.T) {
+	server, requests := newProviderStub(t, http.StatusOK)
+
+	if err := sendWithProvider(t, models.NotificationTypeSlack, models.NotificationChannelConfig{URL: server.URL}, downNotification()); err != nil {
+		t.Fatalf("send() error = %v", err)
+	}
+
+	req := <-requests
+	var payload struct {
+		Text        string `json:"text"`
+		Attachments []struct {
+			Color string `json:"color"`
+			Text  string `json:"text"`
+		} `json:"attachments"`
+	}
+	if err := json.Unmarshal(req.body, &payload); err != nil || len(payload.Attachments) != 1 {
+		t.Fatalf("Unexpected body %s", req.body)
+	}
+	if payload.Text != "🔴 Plex is down" || payload.Attachments[0].Color != "#E53935" {
+		t.Errorf("Unexpected payload %+v", payload)
+	}
+}
+
+func TestSendHTTP_ClassifiesErrors(t *testing.T) {
+	tests := []struct {
+		status    int
+		permanent bool
+	}{
+		{http.StatusBadRequest, true},
+		{http.StatusNotFound, true},
+		{http.StatusTooManyRequests, false},
+		{http.StatusInternalServerError, false},
+		{http.StatusBadGateway, false},
+	}
+
+	for _, tt := range tests {
+		server, _ := newProviderStub(t, tt.status)
+		err := sendHTTP(context.Background(), http.DefaultClient, http.MethodPost, server.URL, nil, nil)
+		if err == nil {
+			t.Errorf("HTTP %d: expected error", tt.status)
+			continue
+		}
+		if errors.Is(err, errPermanentNotification) != tt.permanent {
+			t.Errorf("HTTP %d: permanent = %v, want %v", tt.status, !tt.permanent, tt.permanent)
+		}
+	}
+}
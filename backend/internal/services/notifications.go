@@ -0,0 +1,550 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/nimbus/backend/internal/models"
+	"github.com/nimbus/backend/internal/repository"
+)
+
+// Default notification settings
+const (
+	DefaultNotificationQueueSize  = 1000
+	DefaultNotificationMaxRetries = 5
+
+	notificationWorkers     = 4
+	notificationSendTimeout = 10 * time.Second
+	notificationMaxBackoff  = 5 * time.Minute
+
+	// maxServiceNotificationChannels limits how many channels a single service notifies
+	maxServiceNotificationChannels = 20
+)
+
+var (
+	// ErrInvalidNotificationChannel is returned when a channel definition is invalid
+	ErrInvalidNotificationChannel = errors.New("invalid notification channel")
+
+	// ErrGlobalNotificationChannel is returned when a non-admin tries to change a global channel
+	ErrGlobalNotificationChannel = errors.New("only admins can manage global notification channels")
+)
+
+// NotificationStats is a snapshot of the notification queue's counters
+type NotificationStats struct {
+	QueueLength int
+	Retrying    int64 // Deliveries waiting for their next attempt
+	Dropped     int64 // Notifications discarded because the queue was full
+	Sent        int64 // Deliveries to a channel
+	Failed      int64 // Deliveries given up after a permanent error or the last retry
+}
+
+// NotificationService manages notification channels and delivers status notifications
+// Transitions are queued and sent by a few workers, so slow or unreachable channels never hold up
+// health checks; failed deliveries are retried with exponential backoff, each channel on its own
+// schedule so the workers never wait out a backoff
+type NotificationService struct {
+	repo        *repository.NotificationChannelRepository
+	serviceRepo repository.ServiceRepositoryInterface
+	mail        *MailService // Sends email channels (nil: email channels fail)
+	client      *http.Client
+	maxRetries  int
+	backoff     time.Duration // Delay before the first retry, doubled for each further retry
+
+	queue    chan *Notification
+	retries  chan *channelDelivery // Deliveries whose backoff has elapsed
+	stopChan chan struct{}
+	wg       sync.WaitGroup
+	stopOnce sync.Once
+
+	retrying atomic.Int64
+	dropped  atomic.Int64
+	sent     atomic.Int64
+	failed   atomic.Int64
+}
+
+// channelDelivery is a notification on its way to one channel
+type channelDelivery struct {
+	channel *models.NotificationChannel
+	n       *Notification
+	attempt int
+	backoff time.Duration // Delay before the next retry
+}
+
+// NewNotificationService creates a notification service
+// egress enforces the admin egress policy on every delivery (nil allows everything)
+func NewNotificationService(repo *repository.NotificationChannelRepository, serviceRepo repository.ServiceRepositoryInterface, egress *EgressPolicyService, queueSize int) *NotificationService {
+	if queueSize <= 0 {
+		queueSize = DefaultNotificationQueueSize
+	}
+
+	transport := &http.Transport{
+		DialContext: egress.DialContext(&net.Dialer{
+			Timeout:   10 * time.Second,
+			KeepAlive: 30 * time.Second,
+		}),
+		TLSHandshakeTimeout: 10 * time.Second,
+		MaxIdleConnsPerHost: 2,
+		IdleConnTimeout:     90 * time.Second,
+	}
+
+	// Connections were allowed under the old policy - make them dial (and be checked) again
+	if egress != nil {
+		egress.OnUpdate(transport.CloseIdleConnections)
+	}
+
+	return &NotificationService{
+		repo:        repo,
+		serviceRepo: serviceRepo,
+		client: &http.Client{
+			Timeout:   notificationSendTimeout,
+			Transport: transport,
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				// Webhook endpoints don't redirect; following one could reach an unintended host
+				return http.ErrUseLastResponse
+			},
+		},
+		maxRetries: DefaultNotificationMaxRetries,
+		backoff:    5 * time.Second,
+		queue:      make(chan *Notification, queueSize),
+		retries:    make(chan *channelDelivery, queueSize),
+		stopChan:   make(chan struct{}),
+	}
+}
+
+// SetMailService sets the mail service used by email channels
+func (s *NotificationService) SetMailService(mail *MailService) {
+	s.mail = mail
+}
+
+// Start begins delivering queued notifications
+func (s *NotificationService) Start() {
+	for i := 0; i < notificationWorkers; i++ {
+		s.wg.Add(1)
+		go s.run()
+	}
+	fmt.Printf("Notification dispatcher started (queue: %d, workers: %d)\n", cap(s.queue), notificationWorkers)
+}
+
+// Stop sends the queued notifications and makes one last attempt for those waiting for a retry, then stops
+func (s *NotificationService) Stop() {
+	s.stopOnce.Do(func() {
+		fmt.Println("Stopping notification dispatcher...")
+		close(s.stopChan)
+		s.wg.Wait()
+		fmt.Println("Notification dispatcher stopped")
+	})
+}
+
+// Stats returns the dispatcher's counters
+func (s *NotificationService) Stats() NotificationStats {
+	return NotificationStats{
+		QueueLength: len(s.queue),
+		Retrying:    s.retrying.Load(),
+		Dropped:     s.dropped.Load(),
+		Sent:        s.sent.Load(),
+		Failed:      s.failed.Load(),
+	}
+}
+
+// NotifyTransition queues notifications for a status transition without blocking
+// Services going offline and recovering from offline are notified; other transitions
+// (e.g. the first check of a new service) are not. Safe to call on a nil service
+func (s *NotificationService) NotifyTransition(service *models.Service, event *models.StatusEvent) {
+	if s == nil || event == nil {
+		return
+	}
+
+	var notificationEvent string
+	switch {
+	case event.ToStatus == models.StatusOffline && event.FromStatus != models.StatusOffline:
+		notificationEvent = models.NotificationEventDown
+	case event.FromStatus == models.StatusOffline && event.ToStatus == models.StatusOnline:
+		notificationEvent = models.NotificationEventUp
+	default:
+		return
+	}
+
+	n := &Notification{
+		Event:          notificationEvent,
+		ServiceID:      service.ID,
+		ServiceName:    service.Name,
+		ServiceURL:     service.URL,
+		Status:         event.ToStatus,
+		PreviousStatus: event.FromStatus,
+		ErrorMessage:   event.ErrorMessage,
+		OccurredAt:     event.OccurredAt,
+	}
+	if event.PreviousDurationMs != nil {
+		seconds := float64(*event.PreviousDurationMs) / 1000
+		n.DurationSeconds = &seconds
+	}
+
+	s.enqueue(n)
+}
+
+// NotifyChannels queues a notification for specific channels without blocking
+// Used by alert rules, which pick their own channels instead of the service's. Returns false if
+// the notification was dropped. Safe to call on a nil service
+func (s *NotificationService) NotifyChannels(channelIDs []string, n *Notification) bool {
+	if s == nil || len(channelIDs) == 0 {
+		return false
+	}
+	n.channelIDs = channelIDs
+	return s.enqueue(n)
+}
+
+func (s *NotificationService) enqueue(n *Notification) bool {
+	select {
+	case s.queue <- n:
+		return true
+	default:
+		s.dropped.Add(1)
+		fmt.Printf("Notification queue full, dropping %s notification for service %s\n", n.Event, n.ServiceID)
+		return false
+	}
+}
+
+func (s *NotificationService) run() {
+	defer s.wg.Done()
+
+	for {
+		select {
+		case n := <-s.queue:
+			s.deliver(n)
+		case d := <-s.retries:
+			s.attempt(d)
+		case <-s.stopChan:
+			for {
+				select {
+				case n := <-s.queue:
+					s.deliver(n)
+				case d := <-s.retries:
+					s.attempt(d)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+// deliver makes the first attempt for every enabled channel of its service (or its alert rule's
+// channels), in parallel; failed attempts are retried later without holding up the worker
+func (s *NotificationService) deliver(n *Notification) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	var channels []*models.NotificationChannel
+	var err error
+	if n.channelIDs != nil {
+		channels, err = s.repo.GetEnabledByIDs(ctx, n.channelIDs)
+	} else {
+		channels, err = s.repo.GetEnabledByServiceID(ctx, n.ServiceID)
+	}
+	cancel()
+	if err != nil {
+		fmt.Printf("Failed to get notification channels for service %s: %v\n", n.ServiceID, err)
+		return
+	}
+
+	var wg sync.WaitGroup
+	for _, channel := range channels {
+		wg.Add(1)
+		go func(channel *models.NotificationChannel) {
+			defer wg.Done()
+			channelNotification := *n
+			channelNotification.ChannelName = channel.Name
+			s.attempt(&channelDelivery{channel: channel, n: &channelNotification, backoff: s.backoff})
+		}(channel)
+	}
+	wg.Wait()
+}
+
+// attempt sends to one channel, scheduling a retry with exponential backoff for temporary failures
+func (s *NotificationService) attempt(d *channelDelivery) {
+	err := s.send(context.Background(), d.channel, d.n)
+	if err == nil {
+		s.sent.Add(1)
+		return
+	}
+
+	stopping := false
+	select {
+	case <-s.stopChan:
+		stopping = true
+	default:
+	}
+
+	if errors.Is(err, errPermanentNotification) || d.attempt >= s.maxRetries || stopping {
+		s.failed.Add(1)
+		fmt.Printf("Failed to send %s notification to channel %s (%s): %v\n", d.n.Event, d.channel.ID, d.channel.Type, err)
+		return
+	}
+
+	fmt.Printf("Failed to send %s notification to channel %s (retrying in %v): %v\n", d.n.Event, d.channel.ID, d.backoff, err)
+	s.scheduleRetry(d)
+}
+
+// scheduleRetry hands a delivery back to the workers once its backoff has elapsed
+// When shutting down, the retry is attempted right away instead
+func (s *NotificationService) scheduleRetry(d *channelDelivery) {
+	delay := d.backoff
+	d.attempt++
+	d.backoff = min(2*d.backoff, notificationMaxBackoff)
+
+	s.retrying.Add(1)
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+
+		timer := time.NewTimer(delay)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+			select {
+			case s.retries <- d:
+				s.retrying.Add(-1)
+				return
+			case <-s.stopChan:
+			}
+		case <-s.stopChan:
+		}
+
+		// Shutting down: one last attempt without waiting
+		s.retrying.Add(-1)
+		s.attempt(d)
+	}()
+}
+
+// send makes one delivery attempt through the channel's provider
+func (s *NotificationService) send(ctx context.Context, channel *models.NotificationChannel, n *Notification) error {
+	provider, ok := s.provider(channel.Type)
+	if !ok {
+		return fmt.Errorf("%w: unknown channel type %q", errPermanentNotification, channel.Type)
+	}
+
+	ownerID := ""
+	if channel.UserID != nil {
+		ownerID = *channel.UserID
+	}
+	ctx, cancel := context.WithTimeout(withEgressOwner(ctx, models.EgressPurposeWebhook, ownerID, channel.ID), notificationSendTimeout)
+	defer cancel()
+
+	return provider.send(ctx, s.client, &channel.Config, n)
+}
+
+// provider returns the provider for a channel type, wired to the service's dependencies
+func (s *NotificationService) provider(channelType string) (notificationProvider, bool) {
+	if channelType == models.NotificationTypeEmail {
+		return emailProvider{mail: s.mail}, true
+	}
+	provider, ok := notificationProviders[channelType]
+	return provider, ok
+}
+
+// List returns the user's channels and the global channels
+// Settings of global channels are only included for admins
+func (s *NotificationService) List(ctx context.Context, userID string, isAdmin bool) ([]models.NotificationChannelResponse, error) {
+	channels, err := s.repo.GetAvailableToUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	response := make([]models.NotificationChannelResponse, 0, len(channels))
+	for _, channel := range channels {
+		response = append(response, channel.ToResponse(!channel.IsGlobal() || isAdmin))
+	}
+	return response, nil
+}
+
+// Create validates and stores a new channel; global channels require an admin
+func (s *NotificationService) Create(ctx context.Context, userID string, isAdmin bool, req *models.NotificationChannelRequest) (*models.NotificationChannel, error) {
+	if req.Global && !isAdmin {
+		return nil, ErrGlobalNotificationChannel
+	}
+
+	channel, err := channelFromRequest(req, nil)
+	if err != nil {
+		return nil, err
+	}
+	if !req.Global {
+		channel.UserID = &userID
+	}
+
+	now := time.Now()
+	channel.CreatedAt = now
+	channel.UpdatedAt = now
+	if err := s.repo.Create(ctx, channel); err != nil {
+		return nil, err
+	}
+
+	return channel, nil
+}
+
+// Update replaces a channel's settings; an empty token keeps the stored one
+// Returns sql.ErrNoRows if the user can't see the channel
+func (s *NotificationService) Update(ctx context.Context, userID string, isAdmin bool, id string, req *models.NotificationChannelRequest) (*models.NotificationChannel, error) {
+	existing, err := s.getManageable(ctx, userID, isAdmin, id)
+	if err != nil {
+		return nil, err
+	}
+
+	channel, err := channelFromRequest(req, existing)
+	if err != nil {
+		return nil, err
+	}
+
+	channel.ID = existing.ID
+	channel.UserID = existing.UserID
+	channel.CreatedAt = existing.CreatedAt
+	channel.UpdatedAt = time.Now()
+	if err := s.repo.Update(ctx, channel); err != nil {
+		return nil, err
+	}
+
+	return channel, nil
+}
+
+// Delete removes a channel
+// Returns sql.ErrNoRows if the user can't see the channel
+func (s *NotificationService) Delete(ctx context.Context, userID string, isAdmin bool, id string) error {
+	if _, err := s.getManageable(ctx, userID, isAdmin, id); err != nil {
+		return err
+	}
+	return s.repo.Delete(ctx, id)
+}
+
+// SendTest sends a test notification to a stored channel, without retries
+func (s *NotificationService) SendTest(ctx context.Context, userID string, isAdmin bool, id string) error {
+	channel, err := s.getManageable(ctx, userID, isAdmin, id)
+	if err != nil {
+		return err
+	}
+	return s.send(ctx, channel, testNotification(channel.Name))
+}
+
+// SendTestConfig sends a test notification to an unsaved channel definition, without retries
+func (s *NotificationService) SendTestConfig(ctx context.Context, userID string, req *models.NotificationChannelRequest) error {
+	channel, err := channelFromRequest(req, nil)
+	if err != nil {
+		return err
+	}
+	channel.UserID = &userID
+	return s.send(ctx, channel, testNotification(channel.Name))
+}
+
+// GetServiceChannels returns the IDs of the channels a user's service notifies
+// Returns sql.ErrNoRows if the service doesn't exist or belongs to another user
+func (s *NotificationService) GetServiceChannels(ctx context.Context, userID, serviceID string) ([]string, error) {
+	if err := s.checkServiceOwner(ctx, userID, serviceID); err != nil {
+		return nil, err
+	}
+	return s.repo.GetChannelIDsByServiceID(ctx, serviceID)
+}
+
+// SetServiceChannels selects the channels a user's service notifies (its own or global channels)
+// Returns sql.ErrNoRows if the service doesn't exist or belongs to another user
+func (s *NotificationService) SetServiceChannels(ctx context.Context, userID, serviceID string, channelIDs []string) ([]string, error) {
+	if err := s.checkServiceOwner(ctx, userID, serviceID); err != nil {
+		return nil, err
+	}
+
+	selected := []string{}
+	seen := make(map[string]bool)
+	for _, id := range channelIDs {
+		if id == "" || seen[id] {
+			continue
+		}
+		seen[id] = true
+		selected = append(selected, id)
+	}
+	if len(selected) > maxServiceNotificationChannels {
+		return nil, fmt.Errorf("%w: a service can notify at most %d channels", ErrInvalidNotificationChannel, maxServiceNotificationChannels)
+	}
+
+	for _, id := range selected {
+		channel, err := s.repo.GetByID(ctx, id)
+		if errors.Is(err, sql.ErrNoRows) || (err == nil && !channel.IsGlobal() && *channel.UserID != userID) {
+			return nil, fmt.Errorf("%w: unknown channel %q", ErrInvalidNotificationChannel, id)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.repo.SetServiceChannels(ctx, serviceID, selected); err != nil {
+		return nil, err
+	}
+	return selected, nil
+}
+
+func (s *NotificationService) checkServiceOwner(ctx context.Context, userID, serviceID string) error {
+	service, err := s.serviceRepo.GetByID(ctx, serviceID)
+	if err != nil {
+		return err
+	}
+	if service == nil || service.UserID != userID {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// getManageable returns a channel the user may change: their own, or a global one for admins
+// Other users' channels are reported as missing
+func (s *NotificationService) getManageable(ctx context.Context, userID string, isAdmin bool, id string) (*models.NotificationChannel, error) {
+	channel, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if channel.IsGlobal() {
+		if !isAdmin {
+			return nil, ErrGlobalNotificationChannel
+		}
+		return channel, nil
+	}
+	if *channel.UserID != userID {
+		return nil, sql.ErrNoRows
+	}
+	return channel, nil
+}
+
+// channelFromRequest validates a request and builds the channel it describes
+// On update the existing channel's token is kept when the request leaves it empty
+func channelFromRequest(req *models.NotificationChannelRequest, existing *models.NotificationChannel) (*models.NotificationChannel, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" || len(name) > 255 {
+		return nil, fmt.Errorf("%w: name is required (at most 255 characters)", ErrInvalidNotificationChannel)
+	}
+
+	provider, ok := notificationProviders[req.Type]
+	if !ok {
+		return nil, fmt.Errorf("%w: type must be webhook, ntfy, gotify, discord, slack or email", ErrInvalidNotificationChannel)
+	}
+
+	config := req.Config
+	config.URL = strings.TrimSpace(config.URL)
+	if config.Token == "" && existing != nil && existing.Type == req.Type {
+		config.Token = existing.Config.Token
+	}
+	if err := provider.validate(&config); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidNotificationChannel, err)
+	}
+
+	enabled := true
+	if req.Enabled != nil {
+		enabled = *req.Enabled
+	}
+
+	return &models.NotificationChannel{
+		Name:    name,
+		Type:    req.Type,
+		Config:  config,
+		Enabled: enabled,
+	}, nil
+}
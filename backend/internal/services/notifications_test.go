@@ -0,0 +1,375 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/nimbus/backend/internal/models"
+	"github.com/nimbus/backend/internal/repository"
+)
+
+// setupNotificationTestDB extends the metrics test database with the notification tables
+func setupNotificationTestDB(t *testing.T) *sql.DB {
+	db := setupMetricsTestDB(t)
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+
+	_, err := db.Exec(`
+		CREATE TABLE notification_channels (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			user_id TEXT,
+			name TEXT NOT NULL,
+			type TEXT NOT NULL,
+			config TEXT NOT NULL DEFAULT '{}',
+			enabled BOOLEAN NOT NULL DEFAULT 1,
+			created_at TIMESTAMP NOT NULL,
+			updated_at TIMESTAMP NOT NULL
+		);
+
+		CREATE TABLE service_notification_channels (
+			service_id TEXT NOT NULL,
+			channel_id TEXT NOT NULL,
+			PRIMARY KEY (service_id, channel_id)
+		);
+	`)
+	if err != nil {
+		t.Fatalf("Failed to create notification tables: %v", err)
+	}
+
+	return db
+}
+
+func newTestNotificationService(db *sql.DB) *NotificationService {
+	s := NewNotificationService(repository.NewNotificationChannelRepository(db), repository.NewServiceRepository(db), nil, 10)
+	s.backoff = 10 * time.Millisecond
+	return s
+}
+
+// waitForNotifications polls until n deliveries succeeded or failed
+func waitForNotifications(t *testing.T, s *NotificationService, n int64) NotificationStats {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		stats := s.Stats()
+		if stats.Sent+stats.Failed >= n {
+			return stats
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Timed out waiting for %d notifications, got %+v", n, stats)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func webhookRequest(name, url string) *models.NotificationChannelRequest {
+	return &models.NotificationChannelRequest{
+		Name:   name,
+		Type:   models.NotificationTypeWebhook,
+		Config: models.NotificationChannelConfig{URL: url},
+	}
+}
+
+func TestNotificationService_ChannelPermissions(t *testing.T) {
+	db := setupNotificationTestDB(t)
+	s := newTestNotificationService(db)
+	ctx := context.Background()
+
+	// Only admins create global channels
+	if _, err := s.Create(ctx, "user-1", false, &models.NotificationChannelRequest{Name: "Global", Type: models.NotificationTypeSlack, Config: models.NotificationChannelConfig{URL: "https://hooks.slack.com/x"}, Global: true}); !errors.Is(err, ErrGlobalNotificationChannel) {
+		t.Fatalf("Expected ErrGlobalNotificationChannel, got %v", err)
+	}
+	global, err := s.Create(ctx, "admin", true, &models.NotificationChannelRequest{Name: "Global", Type: models.NotificationTypeSlack, Config: models.NotificationChannelConfig{URL: "https://hooks.slack.com/x"}, Global: true})
+	if err != nil || !global.IsGlobal() {
+		t.Fatalf("Create(global) = %+v, %v", global, err)
+	}
+
+	gotify := &models.NotificationChannelRequest{Name: "Phone", Type: models.NotificationTypeGotify, Config: models.NotificationChannelConfig{URL: "http://gotify.lan", Token: "secret"}}
+	own, err := s.Create(ctx, "user-1", false, gotify)
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if _, err := s.Create(ctx, "user-2", false, webhookRequest("Other", "http://example.com")); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	// Users see their own channels and global ones, without tokens or global settings
+	channels, err := s.List(ctx, "user-1", false)
+	if err != nil || len(channels) != 2 {
+		t.Fatalf("List() = %d channels, %v", len(channels), err)
+	}
+	for _, channel := range channels {
+		switch {
+		case channel.Global && channel.Config != nil:
+			t.Error("Expected global channel settings to be hidden from users")
+		case !channel.Global && (channel.Config == nil || channel.Config.Token != "" || !channel.HasToken):
+			t.Errorf("Expected own settings without the token, got %+v", channel.Config)
+		}
+	}
+
+	// Users can't change global or other users' channels
+	if _, err := s.Update(ctx, "user-1", false, global.ID, webhookRequest("Mine now", "http://example.com")); !errors.Is(err, ErrGlobalNotificationChannel) {
+		t.Errorf("Expected ErrGlobalNotificationChannel, got %v", err)
+	}
+	if err := s.Delete(ctx, "user-2", false, own.ID); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("Expected sql.ErrNoRows for another user's channel, got %v", err)
+	}
+
+	// An update without a token keeps the stored one
+	gotify.Name = "Phone (renamed)"
+	gotify.Config.Token = ""
+	updated, err := s.Update(ctx, "user-1", false, own.ID, gotify)
+	if err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	if updated.Name != "Phone (renamed)" || updated.Config.Token != "secret" {
+		t.Errorf("Unexpected update %+v", updated)
+	}
+
+	// Invalid definitions are rejected
+	if _, err := s.Create(ctx, "user-1", false, &models.NotificationChannelRequest{Name: "Pager", Type: "pager"}); !errors.Is(err, ErrInvalidNotificationChannel) {
+		t.Errorf("Expected ErrInvalidNotificationChannel for unknown type, got %v", err)
+	}
+}
+
+func TestNotificationService_SetServiceChannels(t *testing.T) {
+	db := setupNotificationTestDB(t)
+	s := newTestNotificationService(db)
+	ctx := context.Background()
+
+	own, _ := s.Create(ctx, "user-1", false, webhookRequest("Own", "http://example.com"))
+	global, _ := s.Create(ctx, "admin", true, &models.NotificationChannelRequest{Name: "Global", Type: models.NotificationTypeDiscord, Config: models.NotificationChannelConfig{URL: "https://discord.com/api/webhooks/1"}, Global: true})
+	other, _ := s.Create(ctx, "user-2", false, webhookRequest("Other", "http://example.com"))
+
+	selected, err := s.SetServiceChannels(ctx, "user-1", "test-service-1", []string{own.ID, global.ID, own.ID})
+	if err != nil || len(selected) != 2 {
+		t.Fatalf("SetServiceChannels() = %v, %v", selected, err)
+	}
+	channelIDs, err := s.GetServiceChannels(ctx, "user-1", "test-service-1")
+	if err != nil || len(channelIDs) != 2 {
+		t.Errorf("GetServiceChannels() = %v, %v", channelIDs, err)
+	}
+
+	if _, err := s.SetServiceChannels(ctx, "user-1", "test-service-1", []string{other.ID}); !errors.Is(err, ErrInvalidNotificationChannel) {
+		t.Errorf("Expected another user's channel to be rejected, got %v", err)
+	}
+	if _, err := s.SetServiceChannels(ctx, "user-2", "test-service-1", nil); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("Expected another user's service to be rejected, got %v", err)
+	}
+
+	// Deleting a channel removes it from services
+	if err := s.Delete(ctx, "user-1", false, own.ID); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	channelIDs, _ = s.GetServiceChannels(ctx, "user-1", "test-service-1")
+	if len(channelIDs) != 1 || channelIDs[0] != global.ID {
+		t.Errorf("Expected only the global channel left, got %v", channelIDs)
+	}
+}
+
+func TestNotificationService_DeliversWithRetry(t *testing.T) {
+	db := setupNotificationTestDB(t)
+	s := newTestNotificationService(db)
+	ctx := context.Background()
+
+	// The first attempt fails, the retry succeeds
+	var attempts atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if attempts.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	// A channel that rejects the payload is not retried
+	var rejected atomic.Int32
+	rejecting := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rejected.Add(1)
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer rejecting.Close()
+
+	working, _ := s.Create(ctx, "user-1", false, webhookRequest("Working", server.URL))
+	broken, _ := s.Create(ctx, "user-1", false, webhookRequest("Broken", rejecting.URL))
+	disabled := webhookRequest("Disabled", server.URL)
+	disabled.Enabled = new(bool)
+	off, _ := s.Create(ctx, "user-1", false, disabled)
+	if _, err := s.SetServiceChannels(ctx, "user-1", "test-service-1", []string{working.ID, broken.ID, off.ID}); err != nil {
+		t.Fatalf("SetServiceChannels() error = %v", err)
+	}
+
+	s.Start()
+	defer s.Stop()
+
+	service := &models.Service{ID: "test-service-1", UserID: "user-1", Name: "Test Service 1", URL: "http://example.com"}
+	s.NotifyTransition(service, &models.StatusEvent{ServiceID: service.ID, FromStatus: models.StatusOnline, ToStatus: models.StatusOffline, OccurredAt: time.Now()})
+
+	stats := waitForNotifications(t, s, 2)
+	if stats.Sent != 1 || stats.Failed != 1 {
+		t.Errorf("Expected 1 sent and 1 failed, got %+v", stats)
+	}
+	if attempts.Load() != 2 {
+		t.Errorf("Expected 2 attempts on the working channel, got %d", attempts.Load())
+	}
+	if rejected.Load() != 1 {
+		t.Errorf("Expected 1 attempt on the rejecting channel, got %d", rejected.Load())
+	}
+}
+
+func TestNotificationService_RetriesDontBlockWorkers(t *testing.T) {
+	db := setupNotificationTestDB(t)
+	s := newTestNotificationService(db)
+	s.backoff = time.Hour
+	ctx := context.Background()
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer failing.Close()
+	working := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer working.Close()
+
+	down, _ := s.Create(ctx, "user-1", false, webhookRequest("Down", failing.URL))
+	up, _ := s.Create(ctx, "user-1", false, webhookRequest("Up", working.URL))
+
+	s.Start()
+
+	// More failing deliveries than workers, all waiting an hour for their retry
+	for i := 0; i < notificationWorkers+1; i++ {
+		s.NotifyChannels([]string{down.ID}, &Notification{Event: models.NotificationEventDown, ServiceID: "test-service-1"})
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for s.Stats().Retrying < notificationWorkers+1 {
+		if time.Now().After(deadline) {
+			t.Fatalf("Timed out waiting for retries to be scheduled, got %+v", s.Stats())
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	s.NotifyChannels([]string{up.ID}, &Notification{Event: models.NotificationEventDown, ServiceID: "test-service-1"})
+	if stats := waitForNotifications(t, s, 1); stats.Sent != 1 {
+		t.Errorf("Expected the working channel to be notified while retries wait, got %+v", stats)
+	}
+
+	// Stopping makes the last attempt instead of waiting out the backoff
+	stopped := make(chan struct{})
+	go func() {
+		s.Stop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Stop() waited for the retry backoff")
+	}
+	if stats := s.Stats(); stats.Failed != notificationWorkers+1 || stats.Retrying != 0 {
+		t.Errorf("Expected %d failed deliveries and none retrying, got %+v", notificationWorkers+1, stats)
+	}
+}
+
+func TestNotificationService_NotifyTransition(t *testing.T) {
+	tests := []struct {
+		from, to string
+		notify   bool
+	}{
+		{models.StatusOnline, models.StatusOffline, true},
+		{models.StatusUnknown, models.StatusOffline, true},
+		{models.StatusOffline, models.StatusOnline, true},
+		{models.StatusUnknown, models.StatusOnline, false},
+		{models.StatusOffline, models.StatusUnknown, false},
+	}
+
+	for _, tt := range tests {
+		s := &NotificationService{queue: make(chan *Notification, 1)}
+		s.NotifyTransition(&models.Service{ID: "svc-1"}, &models.StatusEvent{FromStatus: tt.from, ToStatus: tt.to})
+		if queued := len(s.queue) == 1; queued != tt.notify {
+			t.Errorf("%s -> %s: queued = %v, want %v", tt.from, tt.to, queued, tt.notify)
+		}
+	}
+
+	// No transition and a nil service are no-ops
+	var nilService *NotificationService
+	nilService.NotifyTransition(&models.Service{}, &models.StatusEvent{})
+	(&NotificationService{}).NotifyTransition(&models.Service{}, nil)
+}
+
+func TestHealthCheckService_NotifiesTransitions(t *testing.T) {
+	db := setupNotificationTestDB(t)
+	if _, err := db.Exec(`
+		CREATE TABLE service_status_events (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			service_id TEXT NOT NULL,
+			from_status TEXT NOT NULL,
+			to_status TEXT NOT NULL,
+			occurred_at TIMESTAMP NOT NULL,
+			previous_duration_ms INTEGER,
+			error_message TEXT
+		)
+	`); err != nil {
+		t.Fatalf("Failed to create service_status_events table: %v", err)
+	}
+
+	var healthy atomic.Bool
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !healthy.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer target.Close()
+
+	bodies := make(chan string, 10)
+	hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		bodies <- string(body)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer hook.Close()
+
+	s := newTestNotificationService(db)
+	ctx := context.Background()
+	req := webhookRequest("Hook", hook.URL)
+	req.Config.BodyTemplate = `{"event": {{json .Event}}}`
+	channel, _ := s.Create(ctx, "user-1", false, req)
+	if _, err := s.SetServiceChannels(ctx, "user-1", "test-service-1", []string{channel.ID}); err != nil {
+		t.Fatalf("SetServiceChannels() error = %v", err)
+	}
+	s.Start()
+	defer s.Stop()
+
+	healthService := &HealthCheckService{
+		serviceRepo: &MockServiceRepository{},
+		httpClient:  &http.Client{Timeout: 5 * time.Second},
+	}
+	healthService.SetStatusEventRepository(repository.NewStatusEventRepository(db))
+	healthService.SetNotificationService(s)
+
+	// online (stored) -> offline -> offline -> online
+	service := &models.Service{ID: "test-service-1", UserID: "user-1", Name: "Test Service 1", URL: target.URL, Status: models.StatusOnline}
+	for _, up := range []bool{false, false, true} {
+		healthy.Store(up)
+		if err := healthService.CheckService(ctx, service); err != nil {
+			t.Fatalf("CheckService() error = %v", err)
+		}
+	}
+
+	stats := waitForNotifications(t, s, 2)
+	if stats.Sent != 2 {
+		t.Fatalf("Expected a down and an up notification, got %+v", stats)
+	}
+	received := map[string]bool{<-bodies: true, <-bodies: true}
+	if !received[`{"event": "service.down"}`] || !received[`{"event": "service.up"}`] {
+		t.Errorf("Unexpected notifications %v", received)
+	}
+}
@@ -0,0 +1,393 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// OTLP export protocols
+const (
+	OTLPProtocolGRPC = "grpc"
+	OTLPProtocolHTTP = "http/protobuf"
+)
+
+// Default OTLP export settings
+const (
+	DefaultOTLPMetricsInterval = time.Minute
+
+	otlpScopeName         = "github.com/nimbus/backend"
+	otlpSpanQueueSize     = 2048
+	otlpSpanBatchSize     = 512
+	otlpSpanFlushInterval = 5 * time.Second
+	otlpExportTimeout     = 10 * time.Second
+)
+
+// ErrInvalidOTLPConfig is returned for an unusable OTLP configuration
+var ErrInvalidOTLPConfig = errors.New("invalid OTLP configuration")
+
+// OTLPConfig configures the OTLP exporter
+type OTLPConfig struct {
+	Endpoint        string // Base URL, e.g. http://collector:4318 (HTTP) or http://collector:4317 (gRPC)
+	Protocol        string // OTLPProtocolGRPC or OTLPProtocolHTTP (default)
+	Headers         map[string]string
+	ServiceName     string
+	Traces          bool
+	Metrics         bool
+	MetricsInterval time.Duration
+}
+
+// ParseOTLPHeaders parses OTEL_EXPORTER_OTLP_HEADERS ("key1=value1,key2=value2", values URL-encoded)
+func ParseOTLPHeaders(s string) (map[string]string, error) {
+	headers := make(map[string]string)
+	for _, pair := range strings.Split(s, ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(pair, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			return nil, fmt.Errorf("%w: header %q must be key=value", ErrInvalidOTLPConfig, pair)
+		}
+		decoded, err := url.QueryUnescape(strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("%w: header %q: %v", ErrInvalidOTLPConfig, key, err)
+		}
+		headers[key] = decoded
+	}
+	return headers, nil
+}
+
+// OTLPExporter pushes check metrics and traces to an OpenTelemetry collector
+// Spans are batched in memory and dropped (counted) when the queue is full, so tracing
+// never slows down checks or requests
+type OTLPExporter struct {
+	cfg      OTLPConfig
+	client   *http.Client
+	resource []Attribute
+	tracer   *Tracer
+	metrics  *MetricsService
+
+	spans        chan *Span
+	droppedSpans atomic.Int64
+	stopChan     chan struct{}
+	done         chan struct{}
+	stopOnce     sync.Once
+}
+
+// NewOTLPExporter validates the configuration and creates an exporter
+func NewOTLPExporter(cfg OTLPConfig) (*OTLPExporter, error) {
+	endpoint, err := url.Parse(cfg.Endpoint)
+	if err != nil || (endpoint.Scheme != "http" && endpoint.Scheme != "https") || endpoint.Host == "" {
+		return nil, fmt.Errorf("%w: endpoint must be an http:// or https:// URL", ErrInvalidOTLPConfig)
+	}
+	cfg.Endpoint = strings.TrimSuffix(cfg.Endpoint, "/")
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	switch cfg.Protocol {
+	case "", OTLPProtocolHTTP:
+		cfg.Protocol = OTLPProtocolHTTP
+	case OTLPProtocolGRPC:
+		// gRPC needs HTTP/2, without TLS (h2c with prior knowledge) for http:// endpoints
+		protocols := new(http.Protocols)
+		protocols.SetHTTP2(true)
+		protocols.SetUnencryptedHTTP2(true)
+		transport.Protocols = protocols
+	default:
+		return nil, fmt.Errorf("%w: protocol must be %s or %s", ErrInvalidOTLPConfig, OTLPProtocolGRPC, OTLPProtocolHTTP)
+	}
+
+	if cfg.ServiceName == "" {
+		cfg.ServiceName = "nimbus"
+	}
+	if cfg.MetricsInterval <= 0 {
+		cfg.MetricsInterval = DefaultOTLPMetricsInterval
+	}
+
+	resource := []Attribute{
+		{"service.name", cfg.ServiceName},
+		{"service.version", Version},
+		{"telemetry.sdk.language", "go"},
+	}
+	if hostname, err := os.Hostname(); err == nil {
+		resource = append(resource, Attribute{"host.name", hostname})
+	}
+
+	e := &OTLPExporter{
+		cfg:      cfg,
+		client:   &http.Client{Timeout: otlpExportTimeout, Transport: transport},
+		resource: resource,
+		spans:    make(chan *Span, otlpSpanQueueSize),
+		stopChan: make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	if cfg.Traces {
+		e.tracer = NewTracer(e.enqueue)
+	}
+	return e, nil
+}
+
+// Tracer returns the tracer feeding this exporter (nil when traces are disabled)
+func (e *OTLPExporter) Tracer() *Tracer {
+	return e.tracer
+}
+
+// SetMetricsService is the source of the pushed service and check metrics
+func (e *OTLPExporter) SetMetricsService(m *MetricsService) {
+	e.metrics = m
+}
+
+// Start begins exporting in the background
+func (e *OTLPExporter) Start() {
+	go e.run()
+	fmt.Printf("OTLP exporter started (%s, %s, traces: %v, metrics: %v every %v)\n",
+		e.cfg.Endpoint, e.cfg.Protocol, e.cfg.Traces, e.cfg.Metrics, e.cfg.MetricsInterval)
+}
+
+// Stop exports the queued spans and a last set of metrics, then stops
+func (e *OTLPExporter) Stop() {
+	e.stopOnce.Do(func() {
+		fmt.Println("Stopping OTLP exporter...")
+		close(e.stopChan)
+		<-e.done
+		fmt.Println("OTLP exporter stopped")
+	})
+}
+
+// enqueue queues a finished span without blocking
+func (e *OTLPExporter) enqueue(span *Span) {
+	select {
+	case e.spans <- span:
+	default:
+		e.droppedSpans.Add(1)
+	}
+}
+
+func (e *OTLPExporter) run() {
+	defer close(e.done)
+
+	spanTicker := time.NewTicker(otlpSpanFlushInterval)
+	defer spanTicker.Stop()
+	metricsTicker := time.NewTicker(e.cfg.MetricsInterval)
+	defer metricsTicker.Stop()
+
+	batch := make([]*Span, 0, otlpSpanBatchSize)
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		if err := e.exportSpans(batch); err != nil {
+			fmt.Printf("Failed to export %d spans: %v\n", len(batch), err)
+		}
+		batch = batch[:0]
+	}
+
+	for {
+		select {
+		case span := <-e.spans:
+			batch = append(batch, span)
+			if len(batch) >= otlpSpanBatchSize {
+				flush()
+			}
+		case <-spanTicker.C:
+			if dropped := e.droppedSpans.Swap(0); dropped > 0 {
+				fmt.Printf("OTLP span queue full, dropped %d spans\n", dropped)
+			}
+			flush()
+		case <-metricsTicker.C:
+			if err := e.exportMetrics(); err != nil {
+				fmt.Printf("Failed to export metrics: %v\n", err)
+			}
+		case <-e.stopChan:
+			for len(e.spans) > 0 {
+				batch = append(batch, <-e.spans)
+				if len(batch) >= otlpSpanBatchSize {
+					flush()
+				}
+			}
+			flush()
+			if err := e.exportMetrics(); err != nil {
+				fmt.Printf("Failed to export metrics: %v\n", err)
+			}
+			return
+		}
+	}
+}
+
+// exportSpans sends a batch of finished spans
+func (e *OTLPExporter) exportSpans(spans []*Span) error {
+	ctx, cancel := context.WithTimeout(context.Background(), otlpExportTimeout)
+	defer cancel()
+	return e.send(ctx, "traces", encodeTraceRequest(e.resource, spans))
+}
+
+// exportMetrics sends the current service and check metrics
+func (e *OTLPExporter) exportMetrics() error {
+	if !e.cfg.Metrics || e.metrics == nil {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), otlpExportTimeout)
+	defer cancel()
+
+	services, err := e.metrics.serviceRepo.GetAll(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get services: %w", err)
+	}
+	metrics := e.metrics.buildPrometheusMetrics(services)
+	metrics.Checks = e.metrics.checkMetrics.Snapshot(serviceIDSet(services))
+
+	return e.send(ctx, "metrics", encodeMetricsRequest(e.resource, buildOTLPMetrics(metrics, time.Now())))
+}
+
+// send posts an export request with the configured protocol
+// signal is "traces" or "metrics"
+func (e *OTLPExporter) send(ctx context.Context, signal string, payload []byte) error {
+	var target, contentType string
+	body := payload
+	if e.cfg.Protocol == OTLPProtocolGRPC {
+		service := "trace.v1.TraceService"
+		if signal == "metrics" {
+			service = "metrics.v1.MetricsService"
+		}
+		target = e.cfg.Endpoint + "/opentelemetry.proto.collector." + service + "/Export"
+		contentType = "application/grpc"
+
+		// Length-prefixed message: uncompressed flag, then the big-endian length
+		body = make([]byte, 5, 5+len(payload))
+		binary.BigEndian.PutUint32(body[1:], uint32(len(payload)))
+		body = append(body, payload...)
+	} else {
+		target = e.cfg.Endpoint + "/v1/" + signal
+		contentType = "application/x-protobuf"
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("User-Agent", "Nimbus-OTLP/"+Version)
+	if e.cfg.Protocol == OTLPProtocolGRPC {
+		req.Header.Set("TE", "trailers")
+	}
+	for key, value := range e.cfg.Headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	// The body must be read completely before gRPC trailers are available
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("collector returned HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(respBody)))
+	}
+
+	if e.cfg.Protocol == OTLPProtocolGRPC {
+		// Trailers-only responses carry the status in the headers
+		status, message := resp.Trailer.Get("Grpc-Status"), resp.Trailer.Get("Grpc-Message")
+		if status == "" {
+			status, message = resp.Header.Get("Grpc-Status"), resp.Header.Get("Grpc-Message")
+		}
+		if status != "0" {
+			return fmt.Errorf("collector returned gRPC status %s: %s", status, message)
+		}
+	}
+
+	return nil
+}
+
+// OTLP metric types
+const (
+	otlpGauge = iota
+	otlpCounter
+	otlpHistogram
+)
+
+// otlpMetric is one metric with its data points
+type otlpMetric struct {
+	Name        string
+	Description string
+	Unit        string
+	Type        int
+	Points      []otlpPoint
+}
+
+// otlpPoint is a gauge or counter value (Value), or a histogram (Count, Value as sum, BucketCounts)
+type otlpPoint struct {
+	Attributes   []Attribute
+	Start        time.Time // Counters and histograms only
+	Time         time.Time
+	Value        float64
+	Count        uint64
+	BucketCounts []uint64 // Per bucket, not cumulative
+	Bounds       []float64
+}
+
+// buildOTLPMetrics converts the exporter metrics to OTLP metrics
+// Counters and histograms are cumulative since the process started, like their Prometheus counterparts
+func buildOTLPMetrics(metrics *PrometheusMetrics, now time.Time) []otlpMetric {
+	up := otlpMetric{Name: "nimbus.service.up", Description: "Whether the service is up (1) or down (0)", Unit: "1", Type: otlpGauge}
+	responseTime := otlpMetric{Name: "nimbus.service.response_time", Description: "Response time of the service's last check", Unit: "ms", Type: otlpGauge}
+	for _, service := range metrics.ServiceMetrics {
+		attrs := []Attribute{{"nimbus.service.id", service.ServiceID}, {"nimbus.service.name", service.ServiceName}}
+		up.Points = append(up.Points, otlpPoint{
+			Attributes: append(attrs, Attribute{"url.full", service.ServiceURL}),
+			Time:       now,
+			Value:      float64(service.IsOnline),
+		})
+		if service.ResponseTime != nil {
+			responseTime.Points = append(responseTime.Points, otlpPoint{Attributes: attrs, Time: now, Value: float64(*service.ResponseTime)})
+		}
+	}
+	result := []otlpMetric{up, responseTime}
+
+	if checks := metrics.Checks; checks != nil {
+		counter := otlpMetric{Name: "nimbus.checks", Description: "Health checks performed, by effective result", Unit: "{check}", Type: otlpCounter}
+		for _, checkResult := range sortedKeys(checks.Results) {
+			counter.Points = append(counter.Points, otlpPoint{
+				Attributes: []Attribute{{"nimbus.check.result", checkResult}},
+				Start:      processStartTime,
+				Time:       now,
+				Value:      float64(checks.Results[checkResult]),
+			})
+		}
+
+		duration := otlpMetric{Name: "nimbus.check.duration", Description: "Response time of health checks", Unit: "s", Type: otlpHistogram}
+		for _, service := range checks.Services {
+			bucketCounts := make([]uint64, len(service.CumulativeCounts))
+			var previous uint64
+			for i, cumulative := range service.CumulativeCounts {
+				bucketCounts[i] = cumulative - previous
+				previous = cumulative
+			}
+			duration.Points = append(duration.Points, otlpPoint{
+				Attributes:   []Attribute{{"nimbus.service.id", service.ServiceID}},
+				Start:        processStartTime,
+				Time:         now,
+				Value:        service.Sum,
+				Count:        service.Count,
+				BucketCounts: bucketCounts,
+				Bounds:       CheckDurationBuckets,
+			})
+		}
+		result = append(result, counter, duration)
+	}
+
+	return result
+}
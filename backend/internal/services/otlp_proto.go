@@ -0,0 +1,257 @@
+package services
+
+import (
+	"encoding/binary"
+	"math"
+	"time"
+)
+
+// Minimal protobuf encoding of the OTLP trace and metric export requests
+// Field numbers follow opentelemetry-proto v1 (collector/trace/v1, collector/metrics/v1)
+
+// protoBuffer appends protobuf fields to a byte slice
+type protoBuffer struct {
+	b []byte
+}
+
+func (p *protoBuffer) tag(field int, wireType int) {
+	p.b = binary.AppendUvarint(p.b, uint64(field)<<3|uint64(wireType))
+}
+
+func (p *protoBuffer) varint(field int, v uint64) {
+	if v == 0 {
+		return
+	}
+	p.tag(field, 0)
+	p.b = binary.AppendUvarint(p.b, v)
+}
+
+func (p *protoBuffer) boolean(field int, v bool) {
+	if v {
+		p.varint(field, 1)
+	}
+}
+
+func (p *protoBuffer) fixed64(field int, v uint64) {
+	if v == 0 {
+		return
+	}
+	p.tag(field, 1)
+	p.b = binary.LittleEndian.AppendUint64(p.b, v)
+}
+
+// double always writes the field - optional doubles (histogram sums) must be present even when 0
+func (p *protoBuffer) double(field int, v float64) {
+	p.tag(field, 1)
+	p.b = binary.LittleEndian.AppendUint64(p.b, math.Float64bits(v))
+}
+
+func (p *protoBuffer) bytes(field int, v []byte) {
+	if len(v) == 0 {
+		return
+	}
+	p.tag(field, 2)
+	p.b = binary.AppendUvarint(p.b, uint64(len(v)))
+	p.b = append(p.b, v...)
+}
+
+func (p *protoBuffer) str(field int, v string) {
+	p.bytes(field, []byte(v))
+}
+
+// message writes a nested message (always, even when empty)
+func (p *protoBuffer) message(field int, encode func(m *protoBuffer)) {
+	var m protoBuffer
+	encode(&m)
+	p.tag(field, 2)
+	p.b = binary.AppendUvarint(p.b, uint64(len(m.b)))
+	p.b = append(p.b, m.b...)
+}
+
+// packedFixed64 writes a packed repeated fixed64 field
+func (p *protoBuffer) packedFixed64(field int, values []uint64) {
+	if len(values) == 0 {
+		return
+	}
+	p.tag(field, 2)
+	p.b = binary.AppendUvarint(p.b, uint64(8*len(values)))
+	for _, v := range values {
+		p.b = binary.LittleEndian.AppendUint64(p.b, v)
+	}
+}
+
+// packedDouble writes a packed repeated double field
+func (p *protoBuffer) packedDouble(field int, values []float64) {
+	if len(values) == 0 {
+		return
+	}
+	p.tag(field, 2)
+	p.b = binary.AppendUvarint(p.b, uint64(8*len(values)))
+	for _, v := range values {
+		p.b = binary.LittleEndian.AppendUint64(p.b, math.Float64bits(v))
+	}
+}
+
+func unixNano(t time.Time) uint64 {
+	if t.IsZero() {
+		return 0
+	}
+	return uint64(t.UnixNano())
+}
+
+// encodeKeyValue encodes a KeyValue with its AnyValue
+// AnyValue is a oneof, so its field is written even for zero values
+func encodeKeyValue(p *protoBuffer, field int, attr Attribute) {
+	p.message(field, func(kv *protoBuffer) {
+		kv.str(1, attr.Key)
+		kv.message(2, func(v *protoBuffer) {
+			switch value := attr.Value.(type) {
+			case string:
+				v.tag(1, 2)
+				v.b = binary.AppendUvarint(v.b, uint64(len(value)))
+				v.b = append(v.b, value...)
+			case bool:
+				v.tag(2, 0)
+				if value {
+					v.b = append(v.b, 1)
+				} else {
+					v.b = append(v.b, 0)
+				}
+			case int:
+				v.tag(3, 0)
+				v.b = binary.AppendUvarint(v.b, uint64(int64(value)))
+			case int64:
+				v.tag(3, 0)
+				v.b = binary.AppendUvarint(v.b, uint64(value))
+			case float64:
+				v.double(4, value)
+			}
+		})
+	})
+}
+
+// encodeResource encodes the Resource and InstrumentationScope shared by traces and metrics
+func encodeResource(p *protoBuffer, resource []Attribute) {
+	p.message(1, func(r *protoBuffer) {
+		for _, attr := range resource {
+			encodeKeyValue(r, 1, attr)
+		}
+	})
+}
+
+func encodeScope(p *protoBuffer) {
+	p.message(1, func(s *protoBuffer) {
+		s.str(1, otlpScopeName)
+		s.str(2, Version)
+	})
+}
+
+// encodeTraceRequest encodes an ExportTraceServiceRequest
+func encodeTraceRequest(resource []Attribute, spans []*Span) []byte {
+	var p protoBuffer
+	p.message(1, func(rs *protoBuffer) { // ResourceSpans
+		encodeResource(rs, resource)
+		rs.message(2, func(ss *protoBuffer) { // ScopeSpans
+			encodeScope(ss)
+			for _, span := range spans {
+				ss.message(2, func(s *protoBuffer) { encodeSpan(s, span) })
+			}
+		})
+	})
+	return p.b
+}
+
+func encodeSpan(s *protoBuffer, span *Span) {
+	s.bytes(1, span.TraceID[:])
+	s.bytes(2, span.SpanID[:])
+	if span.ParentSpanID != ([8]byte{}) {
+		s.bytes(4, span.ParentSpanID[:])
+	}
+	s.str(5, span.Name)
+	s.varint(6, uint64(span.Kind))
+	s.fixed64(7, unixNano(span.Start))
+	s.fixed64(8, unixNano(span.End))
+	for _, attr := range span.Attributes {
+		encodeKeyValue(s, 9, attr)
+	}
+	for _, event := range span.Events {
+		s.message(11, func(e *protoBuffer) {
+			e.fixed64(1, unixNano(event.Time))
+			e.str(2, event.Name)
+			for _, attr := range event.Attributes {
+				encodeKeyValue(e, 3, attr)
+			}
+		})
+	}
+	if span.StatusCode != SpanStatusUnset {
+		s.message(15, func(st *protoBuffer) {
+			st.str(2, span.StatusMessage)
+			st.varint(3, uint64(span.StatusCode))
+		})
+	}
+}
+
+// OTLP aggregation temporality
+const otlpCumulative = 2
+
+// encodeMetricsRequest encodes an ExportMetricsServiceRequest
+func encodeMetricsRequest(resource []Attribute, metrics []otlpMetric) []byte {
+	var p protoBuffer
+	p.message(1, func(rm *protoBuffer) { // ResourceMetrics
+		encodeResource(rm, resource)
+		rm.message(2, func(sm *protoBuffer) { // ScopeMetrics
+			encodeScope(sm)
+			for _, metric := range metrics {
+				sm.message(2, func(m *protoBuffer) { encodeMetric(m, metric) })
+			}
+		})
+	})
+	return p.b
+}
+
+func encodeMetric(m *protoBuffer, metric otlpMetric) {
+	m.str(1, metric.Name)
+	m.str(2, metric.Description)
+	m.str(3, metric.Unit)
+
+	switch metric.Type {
+	case otlpGauge, otlpCounter:
+		field := 5 // Gauge
+		if metric.Type == otlpCounter {
+			field = 7 // Sum
+		}
+		m.message(field, func(data *protoBuffer) {
+			for _, point := range metric.Points {
+				data.message(1, func(dp *protoBuffer) {
+					dp.fixed64(2, unixNano(point.Start))
+					dp.fixed64(3, unixNano(point.Time))
+					dp.double(4, point.Value)
+					for _, attr := range point.Attributes {
+						encodeKeyValue(dp, 7, attr)
+					}
+				})
+			}
+			if metric.Type == otlpCounter {
+				data.varint(2, otlpCumulative)
+				data.boolean(3, true)
+			}
+		})
+	case otlpHistogram:
+		m.message(9, func(data *protoBuffer) {
+			for _, point := range metric.Points {
+				data.message(1, func(dp *protoBuffer) {
+					dp.fixed64(2, unixNano(point.Start))
+					dp.fixed64(3, unixNano(point.Time))
+					dp.fixed64(4, point.Count)
+					dp.double(5, point.Value)
+					dp.packedFixed64(6, point.BucketCounts)
+					dp.packedDouble(7, point.Bounds)
+					for _, attr := range point.Attributes {
+						encodeKeyValue(dp, 9, attr)
+					}
+				})
+			}
+			data.varint(2, otlpCumulative)
+		})
+	}
+}
@@ -0,0 +1,440 @@
+package services
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nimbus/backend/internal/models"
+	"github.com/nimbus/backend/internal/repository"
+)
+
+// protoMessage is a decoded protobuf message: field number -> raw values
+// Varints and fixed64 values are stored as numbers, length-delimited fields as bytes
+type protoMessage map[int][]protoValue
+
+type protoValue struct {
+	number uint64
+	bytes  []byte
+}
+
+// decodeProto decodes one level of a protobuf message
+func decodeProto(t *testing.T, b []byte) protoMessage {
+	t.Helper()
+
+	msg := make(protoMessage)
+	for len(b) > 0 {
+		key, n := binary.Uvarint(b)
+		if n <= 0 {
+			t.Fatal("Invalid protobuf tag")
+		}
+		b = b[n:]
+		field, wireType := int(key>>3), key&7
+
+		var value protoValue
+		switch wireType {
+		case 0:
+			value.number, n = binary.Uvarint(b)
+			if n <= 0 {
+				t.Fatal("Invalid protobuf varint")
+			}
+			b = b[n:]
+		case 1:
+			if len(b) < 8 {
+				t.Fatal("Truncated fixed64")
+			}
+			value.number = binary.LittleEndian.Uint64(b)
+			b = b[8:]
+		case 2:
+			length, n := binary.Uvarint(b)
+			if n <= 0 || uint64(len(b)-n) < length {
+				t.Fatal("Truncated length-delimited field")
+			}
+			value.bytes = b[n : n+int(length)]
+			b = b[n+int(length):]
+		default:
+			t.Fatalf("Unexpected wire type %d", wireType)
+		}
+		msg[field] = append(msg[field], value)
+	}
+	return msg
+}
+
+func (m protoMessage) str(field int) string {
+	if len(m[field]) == 0 {
+		return ""
+	}
+	return string(m[field][0].bytes)
+}
+
+func (m protoMessage) number(field int) uint64 {
+	if len(m[field]) == 0 {
+		return 0
+	}
+	return m[field][0].number
+}
+
+func (m protoMessage) messages(t *testing.T, field int) []protoMessage {
+	var messages []protoMessage
+	for _, value := range m[field] {
+		messages = append(messages, decodeProto(t, value.bytes))
+	}
+	return messages
+}
+
+func (m protoMessage) message(t *testing.T, field int) protoMessage {
+	messages := m.messages(t, field)
+	if len(messages) == 0 {
+		return protoMessage{}
+	}
+	return messages[0]
+}
+
+// attributes decodes repeated KeyValue fields with string, int and double values
+func (m protoMessage) attributes(t *testing.T, field int) map[string]any {
+	attrs := make(map[string]any)
+	for _, kv := range m.messages(t, field) {
+		value := kv.message(t, 2)
+		switch {
+		case len(value[1]) > 0:
+			attrs[kv.str(1)] = value.str(1)
+		case len(value[3]) > 0:
+			attrs[kv.str(1)] = int64(value.number(3))
+		case len(value[4]) > 0:
+			attrs[kv.str(1)] = math.Float64frombits(value.number(4))
+		}
+	}
+	return attrs
+}
+
+// otlpRequest is an export request received by the test collector
+type otlpRequest struct {
+	path    string
+	headers http.Header
+	body    []byte // Protobuf message (unframed for gRPC)
+}
+
+// otlpReceiver is an in-process collector accepting OTLP/HTTP and OTLP/gRPC (h2c)
+type otlpReceiver struct {
+	mu         sync.Mutex
+	requests   []otlpRequest
+	grpcStatus string
+}
+
+func newOTLPReceiver(t *testing.T) (*httptest.Server, *otlpReceiver) {
+	receiver := &otlpReceiver{grpcStatus: "0"}
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		request := otlpRequest{path: r.URL.Path, headers: r.Header, body: body}
+
+		if r.Header.Get("Content-Type") == "application/grpc" {
+			if r.ProtoMajor != 2 {
+				t.Errorf("Expected gRPC over HTTP/2, got %s", r.Proto)
+			}
+			if len(body) < 5 || body[0] != 0 || int(binary.BigEndian.Uint32(body[1:5])) != len(body)-5 {
+				t.Errorf("Invalid gRPC message framing")
+			} else {
+				request.body = body[5:]
+			}
+
+			receiver.mu.Lock()
+			status := receiver.grpcStatus
+			receiver.requests = append(receiver.requests, request)
+			receiver.mu.Unlock()
+
+			w.Header().Set("Content-Type", "application/grpc")
+			w.Header().Set("Trailer", "Grpc-Status, Grpc-Message")
+			w.Write([]byte{0, 0, 0, 0, 0}) // Empty Export*ServiceResponse
+			w.Header().Set("Grpc-Status", status)
+			if status != "0" {
+				w.Header().Set("Grpc-Message", "collector unavailable")
+			}
+			return
+		}
+
+		receiver.mu.Lock()
+		receiver.requests = append(receiver.requests, request)
+		receiver.mu.Unlock()
+		w.Header().Set("Content-Type", "application/x-protobuf")
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	// Accept HTTP/2 without TLS (prior knowledge), like a collector's gRPC port
+	server.Config.Protocols = new(http.Protocols)
+	server.Config.Protocols.SetHTTP1(true)
+	server.Config.Protocols.SetUnencryptedHTTP2(true)
+	server.Start()
+	t.Cleanup(server.Close)
+
+	return server, receiver
+}
+
+// received returns the requests sent to a path
+func (r *otlpReceiver) received(path string) []otlpRequest {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var requests []otlpRequest
+	for _, request := range r.requests {
+		if request.path == path {
+			requests = append(requests, request)
+		}
+	}
+	return requests
+}
+
+// exportedSpans decodes the spans of ExportTraceServiceRequests
+func exportedSpans(t *testing.T, requests []otlpRequest) []protoMessage {
+	var spans []protoMessage
+	for _, request := range requests {
+		for _, resourceSpans := range decodeProto(t, request.body).messages(t, 1) {
+			for _, scopeSpans := range resourceSpans.messages(t, 2) {
+				spans = append(spans, scopeSpans.messages(t, 2)...)
+			}
+		}
+	}
+	return spans
+}
+
+// exportedMetrics decodes the metrics of ExportMetricsServiceRequests by name
+func exportedMetrics(t *testing.T, requests []otlpRequest) map[string]protoMessage {
+	metrics := make(map[string]protoMessage)
+	for _, request := range requests {
+		for _, resourceMetrics := range decodeProto(t, request.body).messages(t, 1) {
+			for _, scopeMetrics := range resourceMetrics.messages(t, 2) {
+				for _, metric := range scopeMetrics.messages(t, 2) {
+					metrics[metric.str(1)] = metric
+				}
+			}
+		}
+	}
+	return metrics
+}
+
+func TestOTLPExporter_HTTP(t *testing.T) {
+	server, receiver := newOTLPReceiver(t)
+
+	db := setupMetricsTestDB(t)
+	defer db.Close()
+	metricsService := NewMetricsService(repository.NewStatusLogRepository(db), repository.NewServiceRepository(db))
+	checks := NewCheckMetrics()
+	metricsService.SetCheckMetrics(checks)
+	checks.observeCheck("test-service-1", models.StatusOnline, intPtr(20), time.Now())
+	checks.observeCheck("test-service-1", models.StatusOnline, intPtr(300), time.Now())
+
+	exporter, err := NewOTLPExporter(OTLPConfig{
+		Endpoint:    server.URL + "/",
+		Headers:     map[string]string{"api-key": "secret"},
+		ServiceName: "nimbus-test",
+		Traces:      true,
+		Metrics:     true,
+	})
+	if err != nil {
+		t.Fatalf("NewOTLPExporter() error = %v", err)
+	}
+	exporter.SetMetricsService(metricsService)
+	exporter.Start()
+
+	tracer := exporter.Tracer()
+	ctx, root := tracer.StartSpan(context.Background(), "health_check", SpanKindInternal, Attribute{"nimbus.service.id", "test-service-1"})
+	_, child := tracer.StartSpan(ctx, "GET", SpanKindClient)
+	child.SetStatus(SpanStatusError, "HTTP 503")
+	child.Finish()
+	root.Finish()
+
+	// Stopping flushes the queued spans and pushes the metrics once
+	exporter.Stop()
+
+	traceRequests := receiver.received("/v1/traces")
+	if len(traceRequests) != 1 {
+		t.Fatalf("Expected one trace export, got %d", len(traceRequests))
+	}
+	request := traceRequests[0]
+	if request.headers.Get("Content-Type") != "application/x-protobuf" || request.headers.Get("Api-Key") != "secret" {
+		t.Errorf("Unexpected headers %v", request.headers)
+	}
+
+	resource := decodeProto(t, request.body).message(t, 1).message(t, 1).attributes(t, 1)
+	if resource["service.name"] != "nimbus-test" {
+		t.Errorf("Expected the service name in the resource, got %v", resource)
+	}
+
+	spans := exportedSpans(t, traceRequests)
+	if len(spans) != 2 {
+		t.Fatalf("Expected 2 spans, got %d", len(spans))
+	}
+	exportedChild, exportedRoot := spans[0], spans[1]
+	if exportedRoot.str(5) != "health_check" || exportedRoot.number(6) != uint64(SpanKindInternal) || len(exportedRoot[4]) != 0 {
+		t.Errorf("Unexpected root span name %q, kind %d", exportedRoot.str(5), exportedRoot.number(6))
+	}
+	if exportedChild.str(1) != exportedRoot.str(1) || exportedChild.str(4) != exportedRoot.str(2) {
+		t.Error("Expected the client span to be a child in the same trace")
+	}
+	if status := exportedChild.message(t, 15); status.number(3) != uint64(SpanStatusError) || status.str(2) != "HTTP 503" {
+		t.Errorf("Expected an error status, got %v", status)
+	}
+	if exportedRoot.number(8) < exportedRoot.number(7) || exportedRoot.number(7) == 0 {
+		t.Error("Expected start and end times")
+	}
+	if attrs := exportedRoot.attributes(t, 9); attrs["nimbus.service.id"] != "test-service-1" {
+		t.Errorf("Unexpected root span attributes %v", attrs)
+	}
+
+	metrics := exportedMetrics(t, receiver.received("/v1/metrics"))
+	for _, name := range []string{"nimbus.service.up", "nimbus.service.response_time", "nimbus.checks", "nimbus.check.duration"} {
+		if _, ok := metrics[name]; !ok {
+			t.Errorf("Expected metric %s", name)
+		}
+	}
+
+	if sum := metrics["nimbus.checks"].message(t, 7); sum.number(2) != otlpCumulative || sum.number(3) != 1 {
+		t.Errorf("Expected a cumulative monotonic sum, got %v", sum)
+	}
+
+	histogram := metrics["nimbus.check.duration"].message(t, 9).message(t, 1)
+	if histogram.number(4) != 2 {
+		t.Errorf("Expected 2 checks in the histogram, got %d", histogram.number(4))
+	}
+	bucketCounts := histogram[6][0].bytes
+	var total uint64
+	for i := 0; i < len(bucketCounts); i += 8 {
+		total += binary.LittleEndian.Uint64(bucketCounts[i:])
+	}
+	if len(bucketCounts)/8 != len(CheckDurationBuckets)+1 || total != 2 {
+		t.Errorf("Expected per-bucket (non-cumulative) counts adding up to 2, got %d buckets totalling %d", len(bucketCounts)/8, total)
+	}
+}
+
+func TestOTLPExporter_GRPC(t *testing.T) {
+	server, receiver := newOTLPReceiver(t)
+
+	exporter, err := NewOTLPExporter(OTLPConfig{Endpoint: server.URL, Protocol: OTLPProtocolGRPC, Traces: true})
+	if err != nil {
+		t.Fatalf("NewOTLPExporter() error = %v", err)
+	}
+
+	_, span := exporter.Tracer().StartSpan(context.Background(), "GET /api/v1/services", SpanKindServer)
+	span.Finish()
+
+	if err := exporter.exportSpans([]*Span{<-exporter.spans}); err != nil {
+		t.Fatalf("exportSpans() error = %v", err)
+	}
+
+	requests := receiver.received("/opentelemetry.proto.collector.trace.v1.TraceService/Export")
+	if len(requests) != 1 || requests[0].headers.Get("Te") != "trailers" {
+		t.Fatalf("Expected one gRPC export with TE: trailers, got %+v", requests)
+	}
+	if spans := exportedSpans(t, requests); len(spans) != 1 || spans[0].str(5) != "GET /api/v1/services" {
+		t.Errorf("Expected the server span, got %v", spans)
+	}
+
+	// A non-zero grpc-status in the trailers fails the export
+	receiver.mu.Lock()
+	receiver.grpcStatus = "14"
+	receiver.mu.Unlock()
+	err = exporter.exportSpans([]*Span{span})
+	if err == nil || !strings.Contains(err.Error(), "gRPC status 14") {
+		t.Errorf("Expected a gRPC status error, got %v", err)
+	}
+}
+
+func TestNewOTLPExporter_Validation(t *testing.T) {
+	invalid := []OTLPConfig{
+		{Endpoint: "collector:4317"},
+		{Endpoint: "ftp://collector:4317"},
+		{Endpoint: "http://collector:4318", Protocol: "http/json"},
+	}
+	for _, cfg := range invalid {
+		if _, err := NewOTLPExporter(cfg); !errors.Is(err, ErrInvalidOTLPConfig) {
+			t.Errorf("Expected ErrInvalidOTLPConfig for %+v, got %v", cfg, err)
+		}
+	}
+
+	exporter, err := NewOTLPExporter(OTLPConfig{Endpoint: "http://collector:4318", Metrics: true})
+	if err != nil {
+		t.Fatalf("NewOTLPExporter() error = %v", err)
+	}
+	if exporter.Tracer() != nil {
+		t.Error("Expected no tracer with traces disabled")
+	}
+
+	headers, err := ParseOTLPHeaders("api-key=abc%3D%3D, x-tenant = home ,")
+	if err != nil || headers["api-key"] != "abc==" || headers["x-tenant"] != "home" {
+		t.Errorf("Unexpected headers %v (error %v)", headers, err)
+	}
+	if _, err := ParseOTLPHeaders("no-value"); !errors.Is(err, ErrInvalidOTLPConfig) {
+		t.Errorf("Expected ErrInvalidOTLPConfig, got %v", err)
+	}
+}
+
+func TestHealthCheckService_Spans(t *testing.T) {
+	var received string
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r.Header.Get("traceparent")
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer testServer.Close()
+
+	var mu sync.Mutex
+	var spans []*Span
+	tracer := NewTracer(func(span *Span) {
+		mu.Lock()
+		defer mu.Unlock()
+		spans = append(spans, span)
+	})
+
+	healthService := &HealthCheckService{
+		serviceRepo: &MockServiceRepository{},
+		tracer:      tracer,
+		httpClient:  &http.Client{Timeout: 5 * time.Second},
+	}
+	service := &models.Service{ID: "test-service-id", Name: "Test Service", URL: testServer.URL}
+	if err := healthService.CheckService(context.Background(), service); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if len(spans) != 2 {
+		t.Fatalf("Expected a check span and a client span, got %d", len(spans))
+	}
+	client, check := spans[0], spans[1]
+
+	if check.Name != "health_check" || check.StatusCode != SpanStatusError || check.StatusMessage != "HTTP 503" {
+		t.Errorf("Unexpected check span %s (%d %q)", check.Name, check.StatusCode, check.StatusMessage)
+	}
+	if client.Kind != SpanKindClient || client.ParentSpanID != check.SpanID || client.TraceID != check.TraceID {
+		t.Error("Expected the request to be a client span of the check")
+	}
+	if received != client.Context().Traceparent() {
+		t.Errorf("Expected the checked service to receive traceparent %s, got %q", client.Context().Traceparent(), received)
+	}
+
+	events := make(map[string]bool)
+	for _, event := range client.Events {
+		events[event.Name] = true
+	}
+	for _, name := range []string{"connect.start", "connect.done", "request.sent", "response.first_byte"} {
+		if !events[name] {
+			t.Errorf("Expected a %s event, got %v", name, client.Events)
+		}
+	}
+
+	attrs := make(map[string]any)
+	for _, attr := range append(client.Attributes, check.Attributes...) {
+		attrs[attr.Key] = attr.Value
+	}
+	if attrs["http.response.status_code"] != http.StatusServiceUnavailable || attrs["nimbus.check.result"] != models.StatusOffline {
+		t.Errorf("Unexpected span attributes %v", attrs)
+	}
+	if _, ok := attrs["nimbus.check.phase."+CheckPhaseConnect]; !ok {
+		t.Errorf("Expected the connect phase as an attribute, got %v", attrs)
+	}
+}
@@ -0,0 +1,450 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nimbus/backend/internal/models"
+)
+
+// Content types of the Prometheus exposition formats
+const (
+	PrometheusTextContentType  = "text/plain; version=0.0.4; charset=utf-8"
+	OpenMetricsTextContentType = "application/openmetrics-text; version=1.0.0; charset=utf-8"
+)
+
+// PrometheusMetrics represents metrics in Prometheus format
+type PrometheusMetrics struct {
+	Build          BuildInfo
+	ServiceMetrics []ServiceMetric
+	TotalServices  int
+	OnlineServices int
+	Checks         *CheckMetricsSnapshot // Checks recorded since the process started
+	SLOs           []SLOMetric
+	StatusWriter   *StatusWriterStats // Only set for the admin export
+	Runtime        *RuntimeStats      // Only set for the admin export
+}
+
+// SLOMetric is the remaining error budget of one SLO objective for Prometheus
+type SLOMetric struct {
+	SLOID                string
+	SLOName              string
+	SLI                  string // "availability" or "latency"
+	ErrorBudgetRemaining float64
+}
+
+// ServiceMetric represents a single service's metrics for Prometheus
+type ServiceMetric struct {
+	ServiceID    string
+	ServiceName  string
+	ServiceURL   string
+	Status       string
+	IsOnline     int
+	ResponseTime *int // Milliseconds (nil if never checked)
+}
+
+// GetPrometheusMetrics retrieves all service metrics in a Prometheus-compatible format (admin only)
+func (m *MetricsService) GetPrometheusMetrics(ctx context.Context) (*PrometheusMetrics, error) {
+	// Get all services
+	services, err := m.serviceRepo.GetAll(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get services: %w", err)
+	}
+
+	metrics := m.buildPrometheusMetrics(services)
+	metrics.Checks = m.checkMetrics.Snapshot(serviceIDSet(services))
+	if m.slos != nil {
+		slos, err := m.slos.ListAll(ctx)
+		if err != nil {
+			// Keep the service metrics scrapeable
+			fmt.Printf("Failed to compute SLO metrics: %v\n", err)
+		}
+		metrics.SLOs = buildSLOMetrics(slos)
+	}
+	if m.statusWriter != nil {
+		stats := m.statusWriter.Stats()
+		metrics.StatusWriter = &stats
+	}
+	runtimeStats := collectRuntimeStats()
+	metrics.Runtime = &runtimeStats
+
+	return metrics, nil
+}
+
+// GetPrometheusMetricsByUser retrieves service metrics for a specific user
+func (m *MetricsService) GetPrometheusMetricsByUser(ctx context.Context, userID string) (*PrometheusMetrics, error) {
+	// Get services for specific user
+	services, err := m.serviceRepo.GetAllByUserID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user services: %w", err)
+	}
+
+	metrics := m.buildPrometheusMetrics(services)
+	metrics.Checks = m.checkMetrics.Snapshot(serviceIDSet(services))
+
+	if m.slos != nil {
+		slos, err := m.slos.List(ctx, userID)
+		if err != nil {
+			fmt.Printf("Failed to compute SLO metrics for user %s: %v\n", userID, err)
+		}
+		metrics.SLOs = buildSLOMetrics(slos)
+	}
+
+	return metrics, nil
+}
+
+// buildSLOMetrics lists the remaining error budget of every SLO objective
+func buildSLOMetrics(slos []SLOWithStatus) []SLOMetric {
+	var metrics []SLOMetric
+	for _, slo := range slos {
+		metrics = append(metrics, SLOMetric{
+			SLOID:                slo.ID,
+			SLOName:              slo.Name,
+			SLI:                  "availability",
+			ErrorBudgetRemaining: slo.Status.Availability.ErrorBudgetRemaining,
+		})
+		if slo.Status.Latency != nil {
+			metrics = append(metrics, SLOMetric{
+				SLOID:                slo.ID,
+				SLOName:              slo.Name,
+				SLI:                  "latency",
+				ErrorBudgetRemaining: slo.Status.Latency.ErrorBudgetRemaining,
+			})
+		}
+	}
+	return metrics
+}
+
+// serviceIDSet is the set of the services' IDs, so check metrics of deleted services are left out
+func serviceIDSet(services []*models.Service) map[string]bool {
+	ids := make(map[string]bool, len(services))
+	for _, service := range services {
+		ids[service.ID] = true
+	}
+	return ids
+}
+
+// buildPrometheusMetrics converts service models to Prometheus metrics format
+func (m *MetricsService) buildPrometheusMetrics(services []*models.Service) *PrometheusMetrics {
+	totalServices := len(services)
+	onlineServices := 0
+	serviceMetrics := make([]ServiceMetric, 0, totalServices)
+
+	for _, service := range services {
+		isOnline := 0
+		if service.Status == models.StatusOnline {
+			isOnline = 1
+			onlineServices++
+		}
+
+		serviceMetrics = append(serviceMetrics, ServiceMetric{
+			ServiceID:    service.ID,
+			ServiceName:  service.Name,
+			ServiceURL:   service.URL,
+			Status:       service.Status,
+			IsOnline:     isOnline,
+			ResponseTime: service.ResponseTime,
+		})
+	}
+
+	return &PrometheusMetrics{
+		Build:          currentBuildInfo(),
+		ServiceMetrics: serviceMetrics,
+		TotalServices:  totalServices,
+		OnlineServices: onlineServices,
+	}
+}
+
+// escapePromLabel escapes special characters in Prometheus label values
+// to prevent invalid metric exposition format
+func escapePromLabel(s string) string {
+	replacer := strings.NewReplacer(
+		"\\", "\\\\", // backslash -> double backslash
+		"\n", "\\n", // newline -> \n
+		"\"", "\\\"", // quote -> \"
+	)
+	return replacer.Replace(s)
+}
+
+// escapePromHelp escapes HELP text (backslashes and newlines only)
+func escapePromHelp(s string) string {
+	return strings.NewReplacer("\\", "\\\\", "\n", "\\n").Replace(s)
+}
+
+// formatPromValue formats a sample value, including the special values
+func formatPromValue(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	default:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	}
+}
+
+// promLabel is a label name and (unescaped) value
+type promLabel struct {
+	name  string
+	value string
+}
+
+// promWriter writes metric families in the Prometheus text format or OpenMetrics
+type promWriter struct {
+	b           strings.Builder
+	openMetrics bool
+}
+
+// family starts a metric family
+// Counters are named with their _total suffix; OpenMetrics declares them without it
+func (w *promWriter) family(name, metricType, help string) {
+	if w.openMetrics {
+		name = strings.TrimSuffix(name, "_total")
+	} else if w.b.Len() > 0 {
+		w.b.WriteString("\n")
+	}
+	fmt.Fprintf(&w.b, "# HELP %s %s\n", name, escapePromHelp(help))
+	fmt.Fprintf(&w.b, "# TYPE %s %s\n", name, metricType)
+}
+
+// sample writes a sample; exemplars are only written in OpenMetrics
+func (w *promWriter) sample(name string, labels []promLabel, value float64, exemplar *CheckExemplar, exemplarLabels ...promLabel) {
+	w.b.WriteString(name)
+	writePromLabels(&w.b, labels)
+	w.b.WriteString(" ")
+	w.b.WriteString(formatPromValue(value))
+
+	if exemplar != nil && w.openMetrics {
+		w.b.WriteString(" # ")
+		writePromLabels(&w.b, exemplarLabels)
+		if len(exemplarLabels) == 0 {
+			w.b.WriteString("{}")
+		}
+		fmt.Fprintf(&w.b, " %s %s", formatPromValue(exemplar.Value), formatPromTimestamp(exemplar.Timestamp))
+	}
+	w.b.WriteString("\n")
+}
+
+func writePromLabels(b *strings.Builder, labels []promLabel) {
+	if len(labels) == 0 {
+		return
+	}
+	b.WriteString("{")
+	for i, label := range labels {
+		if i > 0 {
+			b.WriteString(",")
+		}
+		fmt.Fprintf(b, "%s=\"%s\"", label.name, escapePromLabel(label.value))
+	}
+	b.WriteString("}")
+}
+
+// formatPromTimestamp formats a time as Unix seconds with millisecond precision
+func formatPromTimestamp(t time.Time) string {
+	return strconv.FormatFloat(float64(t.UnixMilli())/1000, 'f', -1, 64)
+}
+
+// FormatPrometheusMetrics converts metrics to the Prometheus text format (version 0.0.4)
+func FormatPrometheusMetrics(metrics *PrometheusMetrics) string {
+	return formatMetrics(metrics, false)
+}
+
+// FormatOpenMetrics converts metrics to OpenMetrics text (1.0.0), including histogram exemplars
+func FormatOpenMetrics(metrics *PrometheusMetrics) string {
+	return formatMetrics(metrics, true)
+}
+
+func formatMetrics(metrics *PrometheusMetrics, openMetrics bool) string {
+	w := &promWriter{openMetrics: openMetrics}
+
+	build := metrics.Build
+	if build.Version == "" {
+		build = currentBuildInfo()
+	}
+	w.family("nimbus_build_info", "gauge", "Nimbus build information (always 1)")
+	w.sample("nimbus_build_info", []promLabel{
+		{"version", build.Version},
+		{"revision", build.Revision},
+		{"goversion", build.GoVersion},
+	}, 1, nil)
+
+	w.family("nimbus_service_up", "gauge", "Whether the service is up (1) or down (0)")
+	for _, metric := range metrics.ServiceMetrics {
+		w.sample("nimbus_service_up", []promLabel{
+			{"service_id", metric.ServiceID},
+			{"service_name", metric.ServiceName},
+			{"service_url", metric.ServiceURL},
+			{"status", metric.Status},
+		}, float64(metric.IsOnline), nil)
+	}
+
+	// Never-checked services have no response time and are left out
+	w.family("nimbus_service_response_time_milliseconds", "gauge", "Response time of the service in milliseconds")
+	for _, metric := range metrics.ServiceMetrics {
+		if metric.ResponseTime == nil {
+			continue
+		}
+		w.sample("nimbus_service_response_time_milliseconds", []promLabel{
+			{"service_id", metric.ServiceID},
+			{"service_name", metric.ServiceName},
+		}, float64(*metric.ResponseTime), nil)
+	}
+
+	w.family("nimbus_total_services", "gauge", "Total number of services being monitored")
+	w.sample("nimbus_total_services", nil, float64(metrics.TotalServices), nil)
+
+	w.family("nimbus_online_services", "gauge", "Number of services currently online")
+	w.sample("nimbus_online_services", nil, float64(metrics.OnlineServices), nil)
+
+	if checks := metrics.Checks; checks != nil {
+		formatCheckMetrics(w, checks)
+	}
+
+	if len(metrics.SLOs) > 0 {
+		w.family("nimbus_slo_error_budget_remaining", "gauge", "Fraction of the SLO error budget left in the current window (negative when exceeded)")
+		for _, slo := range metrics.SLOs {
+			w.sample("nimbus_slo_error_budget_remaining", []promLabel{
+				{"slo_id", slo.SLOID},
+				{"slo_name", slo.SLOName},
+				{"sli", slo.SLI},
+			}, slo.ErrorBudgetRemaining, nil)
+		}
+	}
+
+	if writer := metrics.StatusWriter; writer != nil {
+		w.family("nimbus_status_writer_queue_length", "gauge", "Check results waiting to be written")
+		w.sample("nimbus_status_writer_queue_length", nil, float64(writer.QueueLength), nil)
+
+		w.family("nimbus_status_writer_queue_capacity", "gauge", "Maximum number of queued check results")
+		w.sample("nimbus_status_writer_queue_capacity", nil, float64(writer.QueueCapacity), nil)
+
+		w.family("nimbus_status_writer_queue_overflow_total", "counter", "Check results written synchronously because the queue was full")
+		w.sample("nimbus_status_writer_queue_overflow_total", nil, float64(writer.Overflows), nil)
+
+		w.family("nimbus_status_writer_flushes_total", "counter", "Batches flushed to the database")
+		w.sample("nimbus_status_writer_flushes_total", nil, float64(writer.Flushes), nil)
+
+		w.family("nimbus_status_writer_failed_flushes_total", "counter", "Batches with at least one failed write")
+		w.sample("nimbus_status_writer_failed_flushes_total", nil, float64(writer.FailedFlushes), nil)
+
+		w.family("nimbus_status_writer_logs_written_total", "counter", "Status logs written by the status writer")
+		w.sample("nimbus_status_writer_logs_written_total", nil, float64(writer.WrittenLogs), nil)
+	}
+
+	if stats := metrics.Runtime; stats != nil {
+		formatRuntimeMetrics(w, stats)
+	}
+
+	if openMetrics {
+		w.b.WriteString("# EOF\n")
+	}
+	return w.b.String()
+}
+
+// formatCheckMetrics writes the check counters, duration histograms, last check times and phase timings
+func formatCheckMetrics(w *promWriter, checks *CheckMetricsSnapshot) {
+	w.family("nimbus_checks_total", "counter", "Health checks performed since the server started, by effective result")
+	for _, result := range sortedKeys(checks.Results) {
+		w.sample("nimbus_checks_total", []promLabel{{"result", result}}, float64(checks.Results[result]), nil)
+	}
+
+	w.family("nimbus_check_duration_seconds", "histogram", "Response time of health checks in seconds")
+	for _, service := range checks.Services {
+		for i, count := range service.CumulativeCounts {
+			le := math.Inf(1)
+			if i < len(CheckDurationBuckets) {
+				le = CheckDurationBuckets[i]
+			}
+
+			var exemplarLabels []promLabel
+			exemplar := service.Exemplars[i]
+			if exemplar != nil {
+				exemplarLabels = []promLabel{{"result", exemplar.Result}}
+			}
+			w.sample("nimbus_check_duration_seconds_bucket", []promLabel{
+				{"service_id", service.ServiceID},
+				{"le", formatPromValue(le)},
+			}, float64(count), exemplar, exemplarLabels...)
+		}
+		labels := []promLabel{{"service_id", service.ServiceID}}
+		w.sample("nimbus_check_duration_seconds_sum", labels, service.Sum, nil)
+		w.sample("nimbus_check_duration_seconds_count", labels, float64(service.Count), nil)
+	}
+
+	w.family("nimbus_service_last_check_timestamp_seconds", "gauge", "Unix time of the service's last health check")
+	for _, service := range checks.Services {
+		if service.LastCheck.IsZero() {
+			continue
+		}
+		w.sample("nimbus_service_last_check_timestamp_seconds", []promLabel{{"service_id", service.ServiceID}},
+			float64(service.LastCheck.UnixMilli())/1000, nil)
+	}
+
+	w.family("nimbus_check_phase_duration_seconds", "gauge", "Phase timings of the service's last HTTP check (phases of reused connections are omitted)")
+	for _, service := range checks.Services {
+		for _, phase := range sortedKeys(service.Phases) {
+			w.sample("nimbus_check_phase_duration_seconds", []promLabel{
+				{"service_id", service.ServiceID},
+				{"phase", phase},
+			}, service.Phases[phase], nil)
+		}
+	}
+}
+
+// formatRuntimeMetrics writes Go runtime and process metrics (named like the official Go client's)
+func formatRuntimeMetrics(w *promWriter, stats *RuntimeStats) {
+	w.family("go_goroutines", "gauge", "Number of goroutines that currently exist")
+	w.sample("go_goroutines", nil, float64(stats.Goroutines), nil)
+
+	w.family("go_threads", "gauge", "Number of OS threads created")
+	w.sample("go_threads", nil, float64(stats.Threads), nil)
+
+	w.family("go_memstats_alloc_bytes", "gauge", "Number of bytes allocated and still in use")
+	w.sample("go_memstats_alloc_bytes", nil, float64(stats.AllocBytes), nil)
+
+	w.family("go_memstats_sys_bytes", "gauge", "Number of bytes obtained from the system")
+	w.sample("go_memstats_sys_bytes", nil, float64(stats.SysBytes), nil)
+
+	w.family("go_memstats_heap_objects", "gauge", "Number of allocated objects")
+	w.sample("go_memstats_heap_objects", nil, float64(stats.HeapObjects), nil)
+
+	w.family("go_gc_cycles_total", "counter", "Number of completed garbage collection cycles")
+	w.sample("go_gc_cycles_total", nil, float64(stats.GCCycles), nil)
+
+	if !stats.LastGC.IsZero() {
+		w.family("go_memstats_last_gc_time_seconds", "gauge", "Unix time of the last garbage collection")
+		w.sample("go_memstats_last_gc_time_seconds", nil, float64(stats.LastGC.UnixMilli())/1000, nil)
+	}
+
+	w.family("process_start_time_seconds", "gauge", "Start time of the process since unix epoch in seconds")
+	w.sample("process_start_time_seconds", nil, float64(stats.StartTime.UnixMilli())/1000, nil)
+
+	if stats.CPUSeconds != nil {
+		w.family("process_cpu_seconds_total", "counter", "Total user and system CPU time spent in seconds")
+		w.sample("process_cpu_seconds_total", nil, *stats.CPUSeconds, nil)
+	}
+	if stats.ResidentBytes != nil {
+		w.family("process_resident_memory_bytes", "gauge", "Resident memory size in bytes")
+		w.sample("process_resident_memory_bytes", nil, float64(*stats.ResidentBytes), nil)
+	}
+	if stats.OpenFDs != nil {
+		w.family("process_open_fds", "gauge", "Number of open file descriptors")
+		w.sample("process_open_fds", nil, float64(*stats.OpenFDs), nil)
+	}
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
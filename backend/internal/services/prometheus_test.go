@@ -0,0 +1,476 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nimbus/backend/internal/models"
+)
+
+var (
+	promMetricName = regexp.MustCompile(`^[a-zA-Z_:][a-zA-Z0-9_:]*`)
+	promLabelName  = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*`)
+)
+
+// parsedSample is one sample line of an exposition
+type parsedSample struct {
+	name     string
+	labels   map[string]string
+	value    float64
+	exemplar bool
+}
+
+// parsedExposition is an exposition that passed the strict format checks of parseExposition
+type parsedExposition struct {
+	types   map[string]string // Family name -> type
+	samples []parsedSample
+}
+
+// find returns the value of the sample with exactly these labels
+func (p *parsedExposition) find(name string, labels map[string]string) (float64, bool) {
+	for _, sample := range p.samples {
+		if sample.name == name && fmt.Sprint(sample.labels) == fmt.Sprint(labels) {
+			return sample.value, true
+		}
+	}
+	return 0, false
+}
+
+// parseExposition parses the Prometheus text format (or OpenMetrics) strictly enough to catch
+// what scrapers reject: samples before their TYPE, interleaved families, duplicate series,
+// invalid names or values, and (in the text format) exemplars
+func parseExposition(t *testing.T, output string, openMetrics bool) *parsedExposition {
+	t.Helper()
+
+	if !strings.HasSuffix(output, "\n") {
+		t.Fatal("Exposition must end with a newline")
+	}
+	lines := strings.Split(strings.TrimSuffix(output, "\n"), "\n")
+	if openMetrics {
+		if lines[len(lines)-1] != "# EOF" {
+			t.Fatal("OpenMetrics exposition must end with # EOF")
+		}
+		lines = lines[:len(lines)-1]
+	}
+
+	parsed := &parsedExposition{types: make(map[string]string)}
+	seriesSeen := make(map[string]bool)
+	finished := make(map[string]bool) // Families followed by another family
+	current := ""
+
+	for i, line := range lines {
+		fail := func(format string, args ...any) {
+			t.Fatalf("Line %d %q: %s", i+1, line, fmt.Sprintf(format, args...))
+		}
+
+		if line == "" {
+			if openMetrics {
+				fail("blank lines aren't allowed in OpenMetrics")
+			}
+			continue
+		}
+
+		if strings.HasPrefix(line, "# ") {
+			parts := strings.SplitN(line, " ", 4)
+			if len(parts) < 4 || (parts[1] != "HELP" && parts[1] != "TYPE") {
+				fail("unexpected comment")
+			}
+			name := parts[2]
+			if promMetricName.FindString(name) != name {
+				fail("invalid metric name")
+			}
+			if name != current {
+				if finished[name] || parsed.types[name] != "" {
+					fail("family %s is split up", name)
+				}
+				if current != "" {
+					finished[current] = true
+				}
+				current = name
+			}
+			if parts[1] == "TYPE" {
+				if parsed.types[name] != "" {
+					fail("duplicate TYPE")
+				}
+				switch parts[3] {
+				case "gauge", "counter", "histogram":
+				default:
+					fail("unexpected type %s", parts[3])
+				}
+				if openMetrics && parts[3] == "counter" && strings.HasSuffix(name, "_total") {
+					fail("OpenMetrics counter families are named without _total")
+				}
+				parsed.types[name] = parts[3]
+			}
+			continue
+		}
+
+		sample, rest := parseSampleLine(line, fail)
+		family := sampleFamily(parsed.types, sample.name, openMetrics)
+		if family == "" {
+			fail("sample without a TYPE")
+		}
+		if family != current {
+			fail("sample of family %s outside its family", family)
+		}
+
+		if rest != "" {
+			if !openMetrics {
+				fail("exemplars are only valid in OpenMetrics")
+			}
+			if !strings.HasSuffix(sample.name, "_bucket") {
+				fail("exemplars are only expected on histogram buckets")
+			}
+			parseExemplar(rest, fail)
+			sample.exemplar = true
+		}
+
+		key := sample.name + fmt.Sprint(sample.labels)
+		if seriesSeen[key] {
+			fail("duplicate series")
+		}
+		seriesSeen[key] = true
+		parsed.samples = append(parsed.samples, sample)
+	}
+
+	checkHistograms(t, parsed)
+	return parsed
+}
+
+// parseSampleLine parses `name{labels} value` and returns anything after the value
+func parseSampleLine(line string, fail func(string, ...any)) (parsedSample, string) {
+	sample := parsedSample{name: promMetricName.FindString(line)}
+	if sample.name == "" {
+		fail("invalid metric name")
+	}
+	rest := line[len(sample.name):]
+
+	sample.labels, rest = parseLabels(rest, fail)
+	if !strings.HasPrefix(rest, " ") {
+		fail("expected a space before the value")
+	}
+
+	fields := strings.SplitN(rest[1:], " ", 2)
+	value, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		fail("invalid value: %v", err)
+	}
+	sample.value = value
+
+	if len(fields) == 1 {
+		return sample, ""
+	}
+	if !strings.HasPrefix(fields[1], "# ") {
+		fail("unexpected timestamp or trailing data")
+	}
+	return sample, fields[1][2:]
+}
+
+// parseExemplar checks `{labels} value timestamp`
+func parseExemplar(exemplar string, fail func(string, ...any)) {
+	if !strings.HasPrefix(exemplar, "{") {
+		fail("exemplar without a label set")
+	}
+	_, rest := parseLabels(exemplar, fail)
+	fields := strings.Fields(rest)
+	if len(fields) != 2 {
+		fail("exemplar needs a value and a timestamp")
+	}
+	for _, field := range fields {
+		if _, err := strconv.ParseFloat(field, 64); err != nil {
+			fail("invalid exemplar number: %v", err)
+		}
+	}
+}
+
+// parseLabels parses an optional {name="value",...} label set, unescaping the values
+func parseLabels(s string, fail func(string, ...any)) (map[string]string, string) {
+	labels := map[string]string{}
+	if !strings.HasPrefix(s, "{") {
+		return labels, s
+	}
+	s = s[1:]
+	for !strings.HasPrefix(s, "}") {
+		name := promLabelName.FindString(s)
+		if name == "" || !strings.HasPrefix(s[len(name):], `="`) {
+			fail("invalid label name")
+		}
+		if _, ok := labels[name]; ok {
+			fail("duplicate label %s", name)
+		}
+		s = s[len(name)+2:]
+
+		var value strings.Builder
+		for {
+			if s == "" {
+				fail("unterminated label value")
+			}
+			c := s[0]
+			s = s[1:]
+			if c == '"' {
+				break
+			}
+			if c == '\n' {
+				fail("raw newline in label value")
+			}
+			if c == '\\' {
+				if s == "" {
+					fail("dangling escape")
+				}
+				switch s[0] {
+				case '\\':
+					value.WriteByte('\\')
+				case '"':
+					value.WriteByte('"')
+				case 'n':
+					value.WriteByte('\n')
+				default:
+					fail("invalid escape \\%c", s[0])
+				}
+				s = s[1:]
+				continue
+			}
+			value.WriteByte(c)
+		}
+		labels[name] = value.String()
+
+		if strings.HasPrefix(s, ",") {
+			s = s[1:]
+		} else if !strings.HasPrefix(s, "}") {
+			fail("expected , or } after a label")
+		}
+	}
+	return labels, s[1:]
+}
+
+// sampleFamily returns the declared family a sample belongs to
+func sampleFamily(types map[string]string, name string, openMetrics bool) string {
+	if typ := types[name]; typ != "" && (typ != "counter" || !openMetrics) {
+		return name
+	}
+	for _, suffix := range []string{"_bucket", "_sum", "_count"} {
+		if family := strings.TrimSuffix(name, suffix); family != name && types[family] == "histogram" {
+			return family
+		}
+	}
+	if family := strings.TrimSuffix(name, "_total"); openMetrics && family != name && types[family] == "counter" {
+		return family
+	}
+	return ""
+}
+
+// checkHistograms verifies every histogram's buckets are cumulative and end in +Inf == _count
+func checkHistograms(t *testing.T, parsed *parsedExposition) {
+	t.Helper()
+
+	type bucket struct {
+		le    float64
+		count float64
+	}
+	buckets := make(map[string][]bucket)
+	counts := make(map[string]float64)
+
+	for _, sample := range parsed.samples {
+		series := make(map[string]string)
+		for name, value := range sample.labels {
+			if name != "le" {
+				series[name] = value
+			}
+		}
+
+		switch {
+		case strings.HasSuffix(sample.name, "_bucket"):
+			le, err := strconv.ParseFloat(sample.labels["le"], 64)
+			if err != nil {
+				t.Fatalf("Bucket %s%v has an invalid le: %v", sample.name, sample.labels, err)
+			}
+			key := strings.TrimSuffix(sample.name, "_bucket") + fmt.Sprint(series)
+			buckets[key] = append(buckets[key], bucket{le, sample.value})
+		case strings.HasSuffix(sample.name, "_count"):
+			counts[strings.TrimSuffix(sample.name, "_count")+fmt.Sprint(series)] = sample.value
+		}
+	}
+
+	for key, series := range buckets {
+		if !sort.SliceIsSorted(series, func(i, j int) bool { return series[i].le < series[j].le }) {
+			t.Errorf("Histogram %s buckets aren't in ascending order", key)
+		}
+		for i := 1; i < len(series); i++ {
+			if series[i].count < series[i-1].count {
+				t.Errorf("Histogram %s buckets aren't cumulative", key)
+			}
+		}
+		last := series[len(series)-1]
+		if !math.IsInf(last.le, 1) {
+			t.Errorf("Histogram %s has no +Inf bucket", key)
+		}
+		count, ok := counts[key]
+		if !ok || count != last.count {
+			t.Errorf("Histogram %s: +Inf bucket %v doesn't match _count %v", key, last.count, count)
+		}
+	}
+}
+
+// testPrometheusMetrics returns metrics with every optional family filled in
+func testPrometheusMetrics() *PrometheusMetrics {
+	checks := NewCheckMetrics()
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	checks.observeCheck("service-1", models.StatusOnline, intPtr(42), now.Add(-time.Minute))
+	checks.observeCheck("service-1", models.StatusOnline, intPtr(180), now.Add(-30*time.Second))
+	checks.observeCheck("service-1", models.StatusOffline, intPtr(30000), now)
+	checks.observeCheck("service-2", models.StatusOffline, nil, now)
+	checks.observePhases("service-1", map[string]time.Duration{
+		CheckPhaseResolve:   2 * time.Millisecond,
+		CheckPhaseConnect:   10 * time.Millisecond,
+		CheckPhaseTLS:       25 * time.Millisecond,
+		CheckPhaseFirstByte: 100 * time.Millisecond,
+	})
+
+	runtimeStats := collectRuntimeStats()
+	return &PrometheusMetrics{
+		Build:          BuildInfo{Version: "v1.2.3", Revision: "abc123", GoVersion: "go1.25.0"},
+		TotalServices:  2,
+		OnlineServices: 1,
+		ServiceMetrics: []ServiceMetric{
+			{ServiceID: "service-1", ServiceName: "Plex \"media\"\nserver", ServiceURL: `http://plex\local`, Status: models.StatusOnline, IsOnline: 1, ResponseTime: intPtr(42)},
+			{ServiceID: "service-2", ServiceName: "Never checked", ServiceURL: "http://new.example.com", Status: models.StatusUnknown},
+		},
+		Checks:       checks.Snapshot(nil),
+		SLOs:         []SLOMetric{{SLOID: "slo-1", SLOName: "Plex", SLI: "availability", ErrorBudgetRemaining: -0.5}},
+		StatusWriter: &StatusWriterStats{QueueLength: 3, QueueCapacity: 100, Flushes: 7},
+		Runtime:      &runtimeStats,
+	}
+}
+
+func TestFormatPrometheusMetrics_Parses(t *testing.T) {
+	metrics := testPrometheusMetrics()
+
+	for _, openMetrics := range []bool{false, true} {
+		t.Run(fmt.Sprintf("openmetrics=%v", openMetrics), func(t *testing.T) {
+			output := FormatPrometheusMetrics(metrics)
+			if openMetrics {
+				output = FormatOpenMetrics(metrics)
+			}
+			parsed := parseExposition(t, output, openMetrics)
+
+			expectedTypes := map[string]string{
+				"nimbus_build_info":                           "gauge",
+				"nimbus_check_duration_seconds":               "histogram",
+				"nimbus_service_last_check_timestamp_seconds": "gauge",
+				"nimbus_check_phase_duration_seconds":         "gauge",
+				"go_goroutines":                               "gauge",
+				"process_start_time_seconds":                  "gauge",
+			}
+			counters := []string{"nimbus_checks_total", "nimbus_status_writer_flushes_total", "go_gc_cycles_total"}
+			for _, counter := range counters {
+				if openMetrics {
+					counter = strings.TrimSuffix(counter, "_total")
+				}
+				expectedTypes[counter] = "counter"
+			}
+			for family, typ := range expectedTypes {
+				if parsed.types[family] != typ {
+					t.Errorf("Expected %s to be a %s, got %q", family, typ, parsed.types[family])
+				}
+			}
+
+			expectedValues := []struct {
+				name   string
+				labels map[string]string
+				value  float64
+			}{
+				{"nimbus_build_info", map[string]string{"version": "v1.2.3", "revision": "abc123", "goversion": "go1.25.0"}, 1},
+				{"nimbus_service_up", map[string]string{"service_id": "service-1", "service_name": "Plex \"media\"\nserver", "service_url": `http://plex\local`, "status": "online"}, 1},
+				{"nimbus_checks_total", map[string]string{"result": "online"}, 2},
+				{"nimbus_checks_total", map[string]string{"result": "offline"}, 2},
+				{"nimbus_checks_total", map[string]string{"result": "unknown"}, 0},
+				{"nimbus_check_duration_seconds_bucket", map[string]string{"service_id": "service-1", "le": "0.05"}, 1},
+				{"nimbus_check_duration_seconds_bucket", map[string]string{"service_id": "service-1", "le": "0.25"}, 2},
+				{"nimbus_check_duration_seconds_bucket", map[string]string{"service_id": "service-1", "le": "+Inf"}, 3},
+				{"nimbus_check_duration_seconds_sum", map[string]string{"service_id": "service-1"}, 30.222},
+				{"nimbus_check_duration_seconds_count", map[string]string{"service_id": "service-2"}, 0},
+				{"nimbus_service_last_check_timestamp_seconds", map[string]string{"service_id": "service-2"}, 1792324800},
+				{"nimbus_check_phase_duration_seconds", map[string]string{"service_id": "service-1", "phase": "tls"}, 0.025},
+				{"nimbus_status_writer_flushes_total", map[string]string{}, 7},
+			}
+			for _, expected := range expectedValues {
+				value, ok := parsed.find(expected.name, expected.labels)
+				if !ok {
+					t.Errorf("Missing sample %s%v", expected.name, expected.labels)
+				} else if math.Abs(value-expected.value) > 1e-9 {
+					t.Errorf("Expected %s%v = %v, got %v", expected.name, expected.labels, expected.value, value)
+				}
+			}
+
+			// The never-checked service has no response time rather than 0
+			if _, ok := parsed.find("nimbus_service_response_time_milliseconds", map[string]string{"service_id": "service-2", "service_name": "Never checked"}); ok {
+				t.Error("Expected no response time sample for a never-checked service")
+			}
+			if _, ok := parsed.find("nimbus_check_phase_duration_seconds", map[string]string{"service_id": "service-2", "phase": "tls"}); ok {
+				t.Error("Expected no phase timings for a service without an HTTP check")
+			}
+
+			exemplars := 0
+			for _, sample := range parsed.samples {
+				if sample.exemplar {
+					exemplars++
+				}
+			}
+			if openMetrics && exemplars != 3 {
+				t.Errorf("Expected an exemplar for each of the 3 filled buckets, got %d", exemplars)
+			}
+		})
+	}
+}
+
+func TestFormatOpenMetrics_Exemplar(t *testing.T) {
+	output := FormatOpenMetrics(testPrometheusMetrics())
+
+	expected := `nimbus_check_duration_seconds_bucket{service_id="service-1",le="+Inf"} 3 # {result="offline"} 30 1792324800`
+	if !strings.Contains(output, expected+"\n") {
+		t.Errorf("Expected output to contain %q", expected)
+	}
+}
+
+func TestFormatPrometheusMetrics_Minimal(t *testing.T) {
+	// A user export without checks, SLOs or runtime stats is still valid
+	for _, openMetrics := range []bool{false, true} {
+		metrics := &PrometheusMetrics{}
+		output := FormatPrometheusMetrics(metrics)
+		if openMetrics {
+			output = FormatOpenMetrics(metrics)
+		}
+		parsed := parseExposition(t, output, openMetrics)
+
+		if _, ok := parsed.find("nimbus_total_services", map[string]string{}); !ok {
+			t.Error("Expected nimbus_total_services")
+		}
+		if parsed.types["go_goroutines"] != "" {
+			t.Error("Expected no runtime metrics outside the admin export")
+		}
+	}
+}
+
+func TestParseProcStat(t *testing.T) {
+	// The command name contains spaces and a parenthesis
+	stat := "1234 (nimbus (srv) x) S 1 1234 1234 0 -1 4194560 2000 0 0 0 250 50 0 0 20 0 12 0 100 1000000 512 18446744073709551615"
+
+	cpu, resident := parseProcStat(stat)
+	if cpu == nil || *cpu != 3 {
+		t.Errorf("Expected 3 CPU seconds, got %v", cpu)
+	}
+	if resident == nil || *resident != 512*uint64(os.Getpagesize()) {
+		t.Errorf("Expected 512 resident pages, got %v", resident)
+	}
+
+	if cpu, resident := parseProcStat("garbage"); cpu != nil || resident != nil {
+		t.Error("Expected nothing from an unparseable stat line")
+	}
+}
@@ -0,0 +1,214 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/nimbus/backend/internal/models"
+)
+
+// Default result export settings
+const (
+	DefaultResultExportQueueSize     = 10000
+	DefaultResultExportBatchSize     = 500
+	DefaultResultExportFlushInterval = 10 * time.Second
+	DefaultResultExportMaxRetries    = 5
+
+	resultExportWriteTimeout = 10 * time.Second
+	resultExportMaxBackoff   = 30 * time.Second
+)
+
+// errPermanentExport marks write errors that won't succeed on retry (bad credentials, rejected data)
+var errPermanentExport = errors.New("permanent export error")
+
+// CheckResultRecord is one check result forwarded to an external time-series database
+type CheckResultRecord struct {
+	ServiceID     string
+	ServiceName   string
+	Status        string
+	ResponseTime  *int    // Milliseconds (nil if the check got no response)
+	AddressFamily *string // Set for checks pinned to IPv4 or IPv6
+	CheckedAt     time.Time
+}
+
+// Up returns 1 for online results and 0 otherwise
+func (r CheckResultRecord) Up() int {
+	if r.Status == models.StatusOnline {
+		return 1
+	}
+	return 0
+}
+
+// ResultSink writes batches of check results to one time-series database
+type ResultSink interface {
+	Name() string
+	Write(ctx context.Context, records []CheckResultRecord) error
+}
+
+// ResultExporterStats is a snapshot of a result exporter's counters
+type ResultExporterStats struct {
+	QueueLength int
+	Dropped     int64 // Results discarded because the queue was full
+	Written     int64
+	Failed      int64 // Results discarded after a permanent error or the last retry
+}
+
+// ResultExporter forwards check results to a sink in batches
+// The queue is bounded: while the sink is down (and being retried with exponential backoff)
+// new results are dropped and counted rather than held in memory indefinitely
+type ResultExporter struct {
+	sink          ResultSink
+	batchSize     int
+	flushInterval time.Duration
+	maxRetries    int
+	backoff       time.Duration // Delay before the first retry, doubled for each further retry
+
+	queue    chan CheckResultRecord
+	stopChan chan struct{}
+	done     chan struct{}
+	stopOnce sync.Once
+
+	dropped atomic.Int64
+	written atomic.Int64
+	failed  atomic.Int64
+}
+
+// NewResultExporter creates an exporter for a sink
+// A batch is written when it reaches batchSize results or every flushInterval, whichever comes first
+func NewResultExporter(sink ResultSink, queueSize, batchSize int, flushInterval time.Duration) *ResultExporter {
+	if queueSize <= 0 {
+		queueSize = DefaultResultExportQueueSize
+	}
+	if batchSize <= 0 {
+		batchSize = DefaultResultExportBatchSize
+	}
+	if flushInterval <= 0 {
+		flushInterval = DefaultResultExportFlushInterval
+	}
+
+	return &ResultExporter{
+		sink:          sink,
+		batchSize:     batchSize,
+		flushInterval: flushInterval,
+		maxRetries:    DefaultResultExportMaxRetries,
+		backoff:       time.Second,
+		queue:         make(chan CheckResultRecord, queueSize),
+		stopChan:      make(chan struct{}),
+		done:          make(chan struct{}),
+	}
+}
+
+// Start begins the export loop
+func (e *ResultExporter) Start() {
+	go e.run()
+	fmt.Printf("%s exporter started (queue: %d, batch: %d, interval: %v)\n", e.sink.Name(), cap(e.queue), e.batchSize, e.flushInterval)
+}
+
+// Stop writes the queued results (without further retries) and stops
+func (e *ResultExporter) Stop() {
+	e.stopOnce.Do(func() {
+		fmt.Printf("Stopping %s exporter...\n", e.sink.Name())
+		close(e.stopChan)
+		<-e.done
+		fmt.Printf("%s exporter stopped\n", e.sink.Name())
+	})
+}
+
+// Export queues a check result without blocking
+func (e *ResultExporter) Export(record CheckResultRecord) {
+	select {
+	case e.queue <- record:
+	default:
+		e.dropped.Add(1)
+	}
+}
+
+// Stats returns the exporter's counters
+func (e *ResultExporter) Stats() ResultExporterStats {
+	return ResultExporterStats{
+		QueueLength: len(e.queue),
+		Dropped:     e.dropped.Load(),
+		Written:     e.written.Load(),
+		Failed:      e.failed.Load(),
+	}
+}
+
+func (e *ResultExporter) run() {
+	defer close(e.done)
+
+	ticker := time.NewTicker(e.flushInterval)
+	defer ticker.Stop()
+
+	batch := make([]CheckResultRecord, 0, e.batchSize)
+	flush := func() {
+		if len(batch) > 0 {
+			e.write(batch)
+			batch = batch[:0]
+		}
+	}
+
+	for {
+		select {
+		case record := <-e.queue:
+			batch = append(batch, record)
+			if len(batch) >= e.batchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		case <-e.stopChan:
+			for len(e.queue) > 0 {
+				batch = append(batch, <-e.queue)
+				if len(batch) >= e.batchSize {
+					flush()
+				}
+			}
+			flush()
+			if closer, ok := e.sink.(io.Closer); ok {
+				closer.Close()
+			}
+			return
+		}
+	}
+}
+
+// write writes a batch, retrying temporary failures with exponential backoff
+func (e *ResultExporter) write(batch []CheckResultRecord) {
+	backoff := e.backoff
+	for attempt := 0; ; attempt++ {
+		ctx, cancel := context.WithTimeout(context.Background(), resultExportWriteTimeout)
+		err := e.sink.Write(ctx, batch)
+		cancel()
+
+		if err == nil {
+			e.written.Add(int64(len(batch)))
+			return
+		}
+
+		stopping := false
+		select {
+		case <-e.stopChan:
+			stopping = true
+		default:
+		}
+
+		if errors.Is(err, errPermanentExport) || attempt >= e.maxRetries || stopping {
+			e.failed.Add(int64(len(batch)))
+			fmt.Printf("Failed to export %d check results to %s: %v\n", len(batch), e.sink.Name(), err)
+			return
+		}
+
+		fmt.Printf("Failed to export check results to %s (retrying in %v): %v\n", e.sink.Name(), backoff, err)
+		select {
+		case <-time.After(backoff):
+		case <-e.stopChan:
+			// Shutting down: one last attempt without waiting
+		}
+		backoff = min(2*backoff, resultExportMaxBackoff)
+	}
+}
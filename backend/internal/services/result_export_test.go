@@ -0,0 +1,356 @@
+package services
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nimbus/backend/internal/models"
+)
+
+// recordingSink keeps every batch written to it
+type recordingSink struct {
+	mu      sync.Mutex
+	records []CheckResultRecord
+}
+
+func (s *recordingSink) Name() string { return "recording" }
+
+func (s *recordingSink) Write(ctx context.Context, records []CheckResultRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.records = append(s.records, records...)
+	return nil
+}
+
+func waitFor(t *testing.T, condition func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !condition() {
+		if time.Now().After(deadline) {
+			t.Fatal("Timed out waiting for the exporter")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestInfluxLine(t *testing.T) {
+	checkedAt := time.UnixMilli(1700000000123)
+
+	tests := []struct {
+		name     string
+		record   CheckResultRecord
+		expected string
+	}{
+		{
+			name:     "Online",
+			record:   CheckResultRecord{ServiceID: "42", ServiceName: "Plex", Status: models.StatusOnline, ResponseTime: intPtr(87), CheckedAt: checkedAt},
+			expected: `nimbus_check,service_id=42,service_name=Plex up=1i,status="online",response_time_ms=87i 1700000000123`,
+		},
+		{
+			name:     "Offline without response time",
+			record:   CheckResultRecord{ServiceID: "42", ServiceName: "Plex", Status: models.StatusOffline, CheckedAt: checkedAt},
+			expected: `nimbus_check,service_id=42,service_name=Plex up=0i,status="offline" 1700000000123`,
+		},
+		{
+			name:     "Address family tag",
+			record:   CheckResultRecord{ServiceID: "42", Status: models.StatusOnline, AddressFamily: stringPtr("ipv6"), CheckedAt: checkedAt},
+			expected: `nimbus_check,address_family=ipv6,service_id=42 up=1i,status="online" 1700000000123`,
+		},
+		{
+			name:     "Escaped tag values",
+			record:   CheckResultRecord{ServiceID: "42", ServiceName: "Home Assistant, a=b\nx", Status: models.StatusOnline, CheckedAt: checkedAt},
+			expected: `nimbus_check,service_id=42,service_name=Home\ Assistant\,\ a\=b\ x up=1i,status="online" 1700000000123`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if line := influxLine(DefaultInfluxDBMeasurement, tt.record); line != tt.expected {
+				t.Errorf("Expected\n%s\ngot\n%s", tt.expected, line)
+			}
+		})
+	}
+
+	record := CheckResultRecord{ServiceID: "1", Status: `say "hi" \o/`, CheckedAt: checkedAt}
+	if line := influxLine("checks,v 2", record); line != `checks\,v\ 2,service_id=1 up=0i,status="say \"hi\" \\o/" 1700000000123` {
+		t.Errorf("Unexpected escaping: %s", line)
+	}
+}
+
+func TestInfluxDBSink_Write(t *testing.T) {
+	tests := []struct {
+		name          string
+		cfg           InfluxDBConfig
+		expectedPath  string
+		expectedQuery string
+		expectedAuth  string
+	}{
+		{
+			name:          "InfluxDB 2.x",
+			cfg:           InfluxDBConfig{Token: "secret", Org: "home", Bucket: "nimbus"},
+			expectedPath:  "/api/v2/write",
+			expectedQuery: "bucket=nimbus&org=home&precision=ms",
+			expectedAuth:  "Token secret",
+		},
+		{
+			name:          "InfluxDB 1.x",
+			cfg:           InfluxDBConfig{Database: "nimbus", RetentionPolicy: "autogen", Username: "user", Password: "pass"},
+			expectedPath:  "/write",
+			expectedQuery: "db=nimbus&precision=ms&rp=autogen",
+			expectedAuth:  "Basic dXNlcjpwYXNz",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var path, query, auth, body string
+			testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				data, _ := io.ReadAll(r.Body)
+				path, query, auth, body = r.URL.Path, r.URL.RawQuery, r.Header.Get("Authorization"), string(data)
+				w.WriteHeader(http.StatusNoContent)
+			}))
+			defer testServer.Close()
+
+			tt.cfg.URL = testServer.URL + "/"
+			sink, err := NewInfluxDBSink(tt.cfg)
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+
+			records := []CheckResultRecord{
+				{ServiceID: "1", Status: models.StatusOnline, ResponseTime: intPtr(10), CheckedAt: time.UnixMilli(1000)},
+				{ServiceID: "2", Status: models.StatusOffline, CheckedAt: time.UnixMilli(2000)},
+			}
+			if err := sink.Write(context.Background(), records); err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+
+			if path != tt.expectedPath || query != tt.expectedQuery {
+				t.Errorf("Expected %s?%s, got %s?%s", tt.expectedPath, tt.expectedQuery, path, query)
+			}
+			if auth != tt.expectedAuth {
+				t.Errorf("Expected Authorization %q, got %q", tt.expectedAuth, auth)
+			}
+			if lines := strings.Split(strings.TrimSpace(body), "\n"); len(lines) != 2 {
+				t.Errorf("Expected 2 lines, got %q", body)
+			}
+		})
+	}
+}
+
+func TestNewInfluxDBSink_Validation(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  InfluxDBConfig
+	}{
+		{"Missing URL", InfluxDBConfig{Bucket: "nimbus"}},
+		{"Unsupported scheme", InfluxDBConfig{URL: "udp://influxdb:8089", Bucket: "nimbus"}},
+		{"No bucket or database", InfluxDBConfig{URL: "http://influxdb:8086"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewInfluxDBSink(tt.cfg); err == nil {
+				t.Error("Expected an error")
+			}
+		})
+	}
+}
+
+func TestResultExporter_Retry(t *testing.T) {
+	var mu sync.Mutex
+	attempts := 0
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		attempts++
+		if attempts <= 2 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer testServer.Close()
+
+	sink, _ := NewInfluxDBSink(InfluxDBConfig{URL: testServer.URL, Database: "nimbus"})
+	exporter := NewResultExporter(sink, 10, 2, time.Hour)
+	exporter.backoff = 10 * time.Millisecond
+	exporter.Start()
+	defer exporter.Stop()
+
+	exporter.Export(CheckResultRecord{ServiceID: "1", Status: models.StatusOnline, CheckedAt: time.Now()})
+	exporter.Export(CheckResultRecord{ServiceID: "2", Status: models.StatusOnline, CheckedAt: time.Now()})
+
+	waitFor(t, func() bool { return exporter.Stats().Written == 2 })
+
+	mu.Lock()
+	defer mu.Unlock()
+	if attempts != 3 {
+		t.Errorf("Expected 3 attempts, got %d", attempts)
+	}
+}
+
+func TestResultExporter_PermanentError(t *testing.T) {
+	var mu sync.Mutex
+	attempts := 0
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		attempts++
+		http.Error(w, `{"error":"unable to parse"}`, http.StatusBadRequest)
+	}))
+	defer testServer.Close()
+
+	sink, _ := NewInfluxDBSink(InfluxDBConfig{URL: testServer.URL, Database: "nimbus"})
+	exporter := NewResultExporter(sink, 10, 1, time.Hour)
+	exporter.backoff = 10 * time.Millisecond
+	exporter.Start()
+	defer exporter.Stop()
+
+	exporter.Export(CheckResultRecord{ServiceID: "1", Status: models.StatusOnline, CheckedAt: time.Now()})
+
+	waitFor(t, func() bool { return exporter.Stats().Failed == 1 })
+
+	mu.Lock()
+	defer mu.Unlock()
+	if attempts != 1 {
+		t.Errorf("Expected a rejected write not to be retried, got %d attempts", attempts)
+	}
+}
+
+func TestResultExporter_QueueOverflow(t *testing.T) {
+	sink := &recordingSink{}
+	exporter := NewResultExporter(sink, 2, 10, time.Hour)
+
+	for i := 0; i < 3; i++ {
+		exporter.Export(CheckResultRecord{ServiceID: "1", Status: models.StatusOnline, CheckedAt: time.Now()})
+	}
+	if stats := exporter.Stats(); stats.QueueLength != 2 || stats.Dropped != 1 {
+		t.Errorf("Expected 2 queued and 1 dropped, got %+v", stats)
+	}
+
+	// Stopping writes what's still queued
+	exporter.Start()
+	exporter.Stop()
+	if stats := exporter.Stats(); stats.Written != 2 || len(sink.records) != 2 {
+		t.Errorf("Expected the queue to be drained on stop, got %+v", stats)
+	}
+}
+
+func TestGraphiteSink_Write(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	defer listener.Close()
+
+	lines := make(chan string, 10)
+	conns := make(chan net.Conn, 2)
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			conns <- conn
+			go func() {
+				scanner := bufio.NewScanner(conn)
+				for scanner.Scan() {
+					lines <- scanner.Text()
+				}
+			}()
+		}
+	}()
+
+	sink, err := NewGraphiteSink(listener.Addr().String(), "homelab.nimbus.")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	defer sink.Close()
+
+	records := []CheckResultRecord{
+		{ServiceID: "svc.1", Status: models.StatusOnline, ResponseTime: intPtr(42), CheckedAt: time.Unix(1700000000, 0)},
+		{ServiceID: "svc.1", Status: models.StatusOffline, AddressFamily: stringPtr("ipv6"), CheckedAt: time.Unix(1700000000, 0)},
+	}
+	if err := sink.Write(context.Background(), records); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	expected := []string{
+		"homelab.nimbus.services.svc_1.up 1 1700000000",
+		"homelab.nimbus.services.svc_1.response_time_ms 42 1700000000",
+		"homelab.nimbus.services.svc_1.ipv6.up 0 1700000000",
+	}
+	for _, want := range expected {
+		select {
+		case line := <-lines:
+			if line != want {
+				t.Errorf("Expected %q, got %q", want, line)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("Timed out waiting for %q", want)
+		}
+	}
+
+	// Carbon restarted: the next batch goes over a new connection instead of being lost
+	(<-conns).Close()
+	time.Sleep(50 * time.Millisecond)
+	if err := sink.Write(context.Background(), records[:1]); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	select {
+	case <-conns:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected the sink to reconnect")
+	}
+	select {
+	case line := <-lines:
+		if line != expected[0] {
+			t.Errorf("Expected %q after reconnecting, got %q", expected[0], line)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for the batch after reconnecting")
+	}
+}
+
+func TestHealthCheckService_ExportsResults(t *testing.T) {
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer testServer.Close()
+
+	sink := &recordingSink{}
+	exporter := NewResultExporter(sink, 10, 10, time.Hour)
+	exporter.Start()
+
+	healthService := &HealthCheckService{
+		serviceRepo: &MockServiceRepository{},
+		httpClient:  &http.Client{Timeout: 5 * time.Second},
+	}
+	healthService.AddResultExporter(exporter)
+
+	service := &models.Service{ID: "test-service-id", Name: "Test Service", URL: testServer.URL}
+	if err := healthService.CheckService(context.Background(), service); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	exporter.Stop()
+
+	if len(sink.records) != 1 {
+		t.Fatalf("Expected 1 exported result, got %d", len(sink.records))
+	}
+	record := sink.records[0]
+	if record.ServiceID != service.ID || record.ServiceName != service.Name || record.Status != models.StatusOnline {
+		t.Errorf("Unexpected record %+v", record)
+	}
+	if record.ResponseTime == nil || record.CheckedAt.IsZero() {
+		t.Errorf("Expected a response time and check time, got %+v", record)
+	}
+}
@@ -0,0 +1,292 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/nimbus/backend/internal/models"
+	"github.com/nimbus/backend/internal/repository"
+)
+
+// Metrics tiers, from finest to coarsest
+const (
+	MetricsTierRaw    = "raw"
+	MetricsTierHourly = repository.RollupTierHourly
+	MetricsTierDaily  = repository.RollupTierDaily
+
+	DefaultHourlyRetentionMonths = 12
+
+	// Time after a bucket ends before it is rolled up (covers the status writer's flush interval)
+	rollupGracePeriod = time.Minute
+
+	minutesPerHour = 60
+	minutesPerDay  = 24 * 60
+)
+
+// rollupWidth returns the bucket width of a rollup tier
+func rollupWidth(tier string) time.Duration {
+	if tier == MetricsTierDaily {
+		return 24 * time.Hour
+	}
+	return time.Hour
+}
+
+// RollupService maintains the hourly and daily rollups of status logs
+type RollupService struct {
+	repo                  *repository.StatusRollupRepository
+	hourlyRetentionMonths int
+	maxStaleness          time.Duration
+}
+
+// NewRollupService creates a rollup service
+// Hourly rollups older than hourlyRetentionMonths are deleted; daily rollups are kept forever.
+// maxStaleness caps how long a result counts toward a bucket's time-weighted uptime
+func NewRollupService(repo *repository.StatusRollupRepository, hourlyRetentionMonths int, maxStaleness time.Duration) *RollupService {
+	if hourlyRetentionMonths <= 0 {
+		hourlyRetentionMonths = DefaultHourlyRetentionMonths
+	}
+	if maxStaleness <= 0 {
+		maxStaleness = DefaultUptimeMaxStaleness
+	}
+	return &RollupService{repo: repo, hourlyRetentionMonths: hourlyRetentionMonths, maxStaleness: maxStaleness}
+}
+
+// HourlyRetentionMonths returns how long hourly rollups are kept
+func (s *RollupService) HourlyRetentionMonths() int {
+	return s.hourlyRetentionMonths
+}
+
+// Run rolls up every completed hour and day since the last run and applies hourly retention
+// A bucket is rolled up once, rollupGracePeriod after it ends, so buffered results have been written
+func (s *RollupService) Run(ctx context.Context, now time.Time) error {
+	for _, tier := range []string{MetricsTierHourly, MetricsTierDaily} {
+		width := rollupWidth(tier)
+
+		latest, err := s.repo.LatestBucket(ctx, tier)
+		if err != nil {
+			return fmt.Errorf("failed to get latest %s rollup: %w", tier, err)
+		}
+
+		// Without rollups yet, backfill from all retained raw logs
+		from := time.Time{}
+		if !latest.IsZero() {
+			from = latest.Add(width)
+		}
+
+		// Only completed buckets - the current one is served from raw logs
+		to := now.UTC().Add(-rollupGracePeriod).Truncate(width)
+		if !from.Before(to) {
+			continue
+		}
+
+		if _, err := s.repo.Rollup(ctx, tier, from, to, s.maxStaleness); err != nil {
+			return fmt.Errorf("failed to roll up %s buckets: %w", tier, err)
+		}
+	}
+
+	cutoff := now.AddDate(0, -s.hourlyRetentionMonths, 0)
+	if _, err := s.repo.DeleteOlderThan(ctx, MetricsTierHourly, cutoff); err != nil {
+		return fmt.Errorf("failed to delete old hourly rollups: %w", err)
+	}
+
+	return nil
+}
+
+// tierAlignment tells which rollup tiers line up with a time zone's local buckets
+type tierAlignment struct {
+	hourly bool // Whole-hour UTC offset
+	daily  bool // UTC itself
+}
+
+// rollupAlignment checks the zone's offset at both ends of a range (DST may change it)
+func rollupAlignment(loc *time.Location, times ...time.Time) tierAlignment {
+	alignment := tierAlignment{hourly: true, daily: true}
+	for _, t := range times {
+		_, offset := t.In(loc).Zone()
+		if offset%3600 != 0 {
+			alignment.hourly = false
+		}
+		if offset != 0 {
+			alignment.daily = false
+		}
+	}
+	return alignment
+}
+
+// selectMetricsTier picks the coarsest tier whose buckets divide the requested interval, line up
+// with the time zone and whose retention still covers startTime. If no tier satisfies all three,
+// the finest tier covering the range is used and the interval is rounded up to its bucket width
+// (UTC buckets then only approximate local ones)
+func selectMetricsTier(now, startTime time.Time, intervalMinutes, rawRetentionDays, hourlyRetentionMonths int, alignment tierAlignment) (string, int) {
+	rawCovers := !startTime.Before(now.AddDate(0, 0, -rawRetentionDays))
+	hourlyCovers := !startTime.Before(now.AddDate(0, -hourlyRetentionMonths, 0))
+
+	switch {
+	case intervalMinutes%minutesPerDay == 0 && (alignment.daily || (!hourlyCovers && !rawCovers)):
+		return MetricsTierDaily, intervalMinutes
+	case intervalMinutes%minutesPerHour == 0 && alignment.hourly && hourlyCovers:
+		return MetricsTierHourly, intervalMinutes
+	case rawCovers:
+		return MetricsTierRaw, intervalMinutes
+	case hourlyCovers:
+		return MetricsTierHourly, roundUpMinutes(intervalMinutes, minutesPerHour)
+	default:
+		return MetricsTierDaily, roundUpMinutes(intervalMinutes, minutesPerDay)
+	}
+}
+
+func roundUpMinutes(minutes, multiple int) int {
+	return (minutes + multiple - 1) / multiple * multiple
+}
+
+// mergeRollup adds src's counts into dst
+func mergeRollup(dst, src *repository.StatusRollup) {
+	dst.CheckCount += src.CheckCount
+	dst.OnlineCount += src.OnlineCount
+	dst.OfflineCount += src.OfflineCount
+	dst.ResponseCount += src.ResponseCount
+	dst.ResponseTimeSum += src.ResponseTimeSum
+	dst.OnlineDuration += src.OnlineDuration
+	dst.OfflineDuration += src.OfflineDuration
+	if src.MinResponseTime != nil && (dst.MinResponseTime == nil || *src.MinResponseTime < *dst.MinResponseTime) {
+		v := *src.MinResponseTime
+		dst.MinResponseTime = &v
+	}
+	if src.MaxResponseTime != nil && (dst.MaxResponseTime == nil || *src.MaxResponseTime > *dst.MaxResponseTime) {
+		v := *src.MaxResponseTime
+		dst.MaxResponseTime = &v
+	}
+	if len(src.Sketch) > 0 {
+		if dst.Sketch == nil {
+			dst.Sketch = models.LatencySketch{}
+		}
+		dst.Sketch.Merge(src.Sketch)
+	}
+}
+
+// rollupTailStart returns where the tier's rolled-up buckets end, but not before startTime
+// Data from there on has to be read from raw logs
+func (m *MetricsService) rollupTailStart(ctx context.Context, tier string, startTime time.Time) (time.Time, error) {
+	latest, err := m.rollupRepo.LatestBucket(ctx, tier)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to get latest %s rollup: %w", tier, err)
+	}
+
+	if !latest.IsZero() && latest.Add(rollupWidth(tier)).After(startTime) {
+		return latest.Add(rollupWidth(tier)), nil
+	}
+	return startTime, nil
+}
+
+// getRollupMetrics builds service metrics from a rollup tier
+// The period after the last rolled-up bucket is summarized from raw logs
+func (m *MetricsService) getRollupMetrics(ctx context.Context, serviceID, tier string, startTime, endTime time.Time, intervalMinutes int, boundaries []time.Time, loc *time.Location) (*MetricsResponse, error) {
+	rollups, err := m.rollupRepo.GetByServiceID(ctx, tier, serviceID, startTime, endTime)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get %s rollups: %w", tier, err)
+	}
+
+	tailStart, err := m.rollupTailStart(ctx, tier, startTime)
+	if err != nil {
+		return nil, err
+	}
+	if tailStart.Before(endTime) {
+		tail, err := m.rollupRepo.SummarizeRaw(ctx, serviceID, tailStart, endTime)
+		if err != nil {
+			return nil, fmt.Errorf("failed to summarize recent status logs: %w", err)
+		}
+
+		samples, err := m.statusLogRepo.GetStatusSamples(ctx, serviceID, tailStart.Add(-m.maxStaleness), endTime)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get recent status samples: %w", err)
+		}
+		tailDurations := timeWeightedDurations(samples, tailStart, endTime, []time.Time{tailStart}, m.maxStaleness)[0]
+		tail.OnlineDuration = tailDurations.online
+		tail.OfflineDuration = tailDurations.offline
+
+		if tail.CheckCount > 0 {
+			rollups = append(rollups, tail)
+		}
+	}
+
+	// Group buckets into data points by the local bucket boundaries
+	total := &repository.StatusRollup{}
+	var dataPoints []MetricDataPoint
+	var current *repository.StatusRollup
+	currentIndex := 0
+
+	flush := func() {
+		if current == nil {
+			return
+		}
+		durations := stateDurations{online: current.OnlineDuration, offline: current.OfflineDuration}
+		dataPoints = append(dataPoints, MetricDataPoint{
+			Timestamp:             current.Bucket.In(loc),
+			CheckCount:            current.CheckCount,
+			OnlineCount:           current.OnlineCount,
+			UptimePercentage:      durations.uptime(),
+			CoveragePercentage:    durations.coverage(bucketPeriod(boundaries, currentIndex, startTime, endTime)),
+			CountUptimePercentage: countUptime(current.OnlineCount, current.CheckCount),
+			AvgResponseTime:       current.AvgResponseTime(),
+			P50ResponseTime:       current.Sketch.Quantile(0.5),
+			P90ResponseTime:       current.Sketch.Quantile(0.9),
+			P95ResponseTime:       current.Sketch.Quantile(0.95),
+			P99ResponseTime:       current.Sketch.Quantile(0.99),
+		})
+	}
+
+	for _, rollup := range rollups {
+		mergeRollup(total, rollup)
+
+		// A rollup starting before the first boundary still overlaps the range
+		index := sort.Search(len(boundaries), func(i int) bool { return boundaries[i].After(rollup.Bucket) }) - 1
+		if index < 0 {
+			index = 0
+		}
+		bucket := boundaries[index]
+		if current == nil || !current.Bucket.Equal(bucket) {
+			flush()
+			current = &repository.StatusRollup{Bucket: bucket}
+			currentIndex = index
+		}
+		mergeRollup(current, rollup)
+	}
+	flush()
+
+	if dataPoints == nil {
+		dataPoints = []MetricDataPoint{}
+	}
+
+	totalDurations := stateDurations{online: total.OnlineDuration, offline: total.OfflineDuration}
+	var minResponseTime, maxResponseTime float64
+	if total.MinResponseTime != nil {
+		minResponseTime = float64(*total.MinResponseTime)
+	}
+	if total.MaxResponseTime != nil {
+		maxResponseTime = float64(*total.MaxResponseTime)
+	}
+
+	return &MetricsResponse{
+		ServiceID:             serviceID,
+		TimeRange:             TimeRange{Start: startTime, End: endTime},
+		Tier:                  tier,
+		IntervalMinutes:       intervalMinutes,
+		Timezone:              loc.String(),
+		UptimePercentage:      totalDurations.uptime(),
+		CoveragePercentage:    totalDurations.coverage(endTime.Sub(startTime)),
+		CountUptimePercentage: countUptime(total.OnlineCount, total.CheckCount),
+		TotalChecks:           total.CheckCount,
+		OnlineCount:           total.OnlineCount,
+		OfflineCount:          total.OfflineCount,
+		AvgResponseTime:       total.AvgResponseTime(),
+		MinResponseTime:       minResponseTime,
+		MaxResponseTime:       maxResponseTime,
+		P50ResponseTime:       total.Sketch.Quantile(0.5),
+		P90ResponseTime:       total.Sketch.Quantile(0.9),
+		P95ResponseTime:       total.Sketch.Quantile(0.95),
+		P99ResponseTime:       total.Sketch.Quantile(0.99),
+		DataPoints:            dataPoints,
+	}, nil
+}
@@ -0,0 +1,366 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/nimbus/backend/internal/models"
+	"github.com/nimbus/backend/internal/repository"
+)
+
+// setupRollupTestDB adds the rollup tables to the metrics test schema
+func setupRollupTestDB(t *testing.T) *sql.DB {
+	db := setupMetricsTestDB(t)
+
+	for _, table := range []string{"service_status_rollups_hourly", "service_status_rollups_daily"} {
+		_, err := db.Exec(`
+			CREATE TABLE ` + table + ` (
+				service_id TEXT NOT NULL,
+				bucket TIMESTAMP NOT NULL,
+				check_count INTEGER NOT NULL,
+				online_count INTEGER NOT NULL,
+				offline_count INTEGER NOT NULL,
+				response_count INTEGER NOT NULL,
+				response_time_sum INTEGER NOT NULL,
+				min_response_time INTEGER,
+				max_response_time INTEGER,
+				p95_response_time REAL,
+				latency_sketch TEXT,
+				online_seconds REAL,
+				offline_seconds REAL,
+				PRIMARY KEY (service_id, bucket)
+			)
+		`)
+		if err != nil {
+			t.Fatalf("Failed to create %s table: %v", table, err)
+		}
+	}
+
+	return db
+}
+
+// insertTestRollup inserts a rollup; without durations they are stored as NULL (rolled up before durations existed)
+func insertTestRollup(t *testing.T, db *sql.DB, table string, r repository.StatusRollup) {
+	t.Helper()
+	var onlineSeconds, offlineSeconds interface{}
+	if r.OnlineDuration > 0 || r.OfflineDuration > 0 {
+		onlineSeconds, offlineSeconds = r.OnlineDuration.Seconds(), r.OfflineDuration.Seconds()
+	}
+	_, err := db.Exec(`
+		INSERT INTO `+table+` (service_id, bucket, check_count, online_count, offline_count, response_count, response_time_sum, min_response_time, max_response_time, latency_sketch, online_seconds, offline_seconds)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+	`, r.ServiceID, r.Bucket, r.CheckCount, r.OnlineCount, r.OfflineCount, r.ResponseCount, r.ResponseTimeSum, r.MinResponseTime, r.MaxResponseTime, r.Sketch, onlineSeconds, offlineSeconds)
+	if err != nil {
+		t.Fatalf("Failed to insert rollup: %v", err)
+	}
+}
+
+func TestSelectMetricsTier(t *testing.T) {
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+
+	utc := tierAlignment{hourly: true, daily: true}
+	amsterdam := tierAlignment{hourly: true}
+	kolkata := tierAlignment{}
+
+	tests := []struct {
+		name             string
+		start            time.Time
+		interval         int
+		alignment        tierAlignment
+		expectedTier     string
+		expectedInterval int
+	}{
+		{"Short range, fine interval", now.Add(-24 * time.Hour), 5, utc, MetricsTierRaw, 5},
+		{"Hourly interval", now.Add(-24 * time.Hour), 60, utc, MetricsTierHourly, 60},
+		{"Six hour interval", now.AddDate(0, 0, -7), 360, utc, MetricsTierHourly, 360},
+		{"Daily interval", now.AddDate(0, 0, -30), 1440, utc, MetricsTierDaily, 1440},
+		{"Fine interval beyond raw retention", now.AddDate(0, -3, 0), 5, utc, MetricsTierHourly, 60},
+		{"Hourly interval beyond hourly retention", now.AddDate(-2, 0, 0), 60, utc, MetricsTierDaily, 1440},
+		{"Odd interval beyond hourly retention", now.AddDate(-2, 0, 0), 90, utc, MetricsTierDaily, 1440},
+		{"Local days from hourly rollups", now.AddDate(0, 0, -30), 1440, amsterdam, MetricsTierHourly, 1440},
+		{"Local days beyond hourly retention", now.AddDate(-2, 0, 0), 1440, amsterdam, MetricsTierDaily, 1440},
+		{"Half-hour offset within raw retention", now.AddDate(0, 0, -7), 60, kolkata, MetricsTierRaw, 60},
+		{"Half-hour offset beyond raw retention", now.AddDate(0, -3, 0), 1440, kolkata, MetricsTierHourly, 1440},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tier, interval := selectMetricsTier(now, tt.start, tt.interval, 30, 12, tt.alignment)
+			if tier != tt.expectedTier || interval != tt.expectedInterval {
+				t.Errorf("selectMetricsTier() = %s/%d, expected %s/%d", tier, interval, tt.expectedTier, tt.expectedInterval)
+			}
+		})
+	}
+}
+
+func TestMetricsService_GetServiceMetrics_HourlyTier(t *testing.T) {
+	db := setupRollupTestDB(t)
+	defer db.Close()
+
+	statusLogRepo := repository.NewStatusLogRepository(db)
+	serviceRepo := repository.NewServiceRepository(db)
+	metricsService := NewMetricsService(statusLogRepo, serviceRepo)
+	metricsService.SetRollups(repository.NewStatusRollupRepository(db), 30, 12)
+
+	now := time.Now().UTC()
+	lastRolledUp := now.Truncate(time.Hour).Add(-2 * time.Hour)
+	minRT, maxRT := 50, 300
+
+	// Three rolled-up hours; the last one ended at least an hour ago
+	sketch := models.LatencySketch{}
+	for i := 0; i < 60; i++ {
+		sketch.Add(100)
+	}
+	for i := 2; i >= 0; i-- {
+		insertTestRollup(t, db, "service_status_rollups_hourly", repository.StatusRollup{
+			ServiceID:       "test-service-1",
+			Bucket:          lastRolledUp.Add(-time.Duration(i) * time.Hour),
+			CheckCount:      60,
+			OnlineCount:     57,
+			OfflineCount:    3,
+			ResponseCount:   60,
+			ResponseTimeSum: 60 * 100,
+			MinResponseTime: &minRT,
+			MaxResponseTime: &maxRT,
+			Sketch:          sketch,
+		})
+	}
+
+	// Raw logs after the last rollup are summarized on the fly
+	responseTime := 400
+	for i := 0; i < 2; i++ {
+		if err := statusLogRepo.Create(context.Background(), &models.StatusLog{
+			ServiceID:    "test-service-1",
+			Status:       models.StatusOffline,
+			ResponseTime: &responseTime,
+			CheckedAt:    lastRolledUp.Add(time.Hour + time.Duration(i+1)*time.Minute),
+		}); err != nil {
+			t.Fatalf("Failed to create status log: %v", err)
+		}
+	}
+
+	metrics, err := metricsService.GetServiceMetrics(context.Background(), "test-service-1", now.Add(-6*time.Hour), now, 60, time.UTC)
+	if err != nil {
+		t.Fatalf("GetServiceMetrics() error = %v", err)
+	}
+
+	if metrics.Tier != MetricsTierHourly || metrics.IntervalMinutes != 60 {
+		t.Errorf("Expected hourly tier with 60 minute interval, got %s/%d", metrics.Tier, metrics.IntervalMinutes)
+	}
+	if metrics.TotalChecks != 182 || metrics.OnlineCount != 171 || metrics.OfflineCount != 11 {
+		t.Errorf("Expected 182 checks (171 online, 11 offline), got %d (%d/%d)", metrics.TotalChecks, metrics.OnlineCount, metrics.OfflineCount)
+	}
+	if metrics.MinResponseTime != 50 || metrics.MaxResponseTime != 400 {
+		t.Errorf("Expected min 50 and max 400, got %v and %v", metrics.MinResponseTime, metrics.MaxResponseTime)
+	}
+	expectedAvg := float64(180*100+2*400) / 182
+	if metrics.AvgResponseTime != expectedAvg {
+		t.Errorf("Expected weighted average %v, got %v", expectedAvg, metrics.AvgResponseTime)
+	}
+	// Percentiles come from the merged sketches (within the sketch's relative accuracy)
+	if !withinRelativeAccuracy(metrics.P50ResponseTime, 100) || !withinRelativeAccuracy(metrics.P99ResponseTime, 100) {
+		t.Errorf("Expected p50 and p99 around 100ms, got %v and %v", metrics.P50ResponseTime, metrics.P99ResponseTime)
+	}
+	if len(metrics.DataPoints) != 4 {
+		t.Fatalf("Expected 3 rolled-up points and 1 recent point, got %d", len(metrics.DataPoints))
+	}
+	if last := metrics.DataPoints[3]; last.CheckCount != 2 || last.UptimePercentage != 0 {
+		t.Errorf("Expected the recent point to hold the 2 raw offline checks, got %+v", last)
+	}
+	if last := metrics.DataPoints[3]; !withinRelativeAccuracy(last.P95ResponseTime, 400) {
+		t.Errorf("Expected the recent point's p95 around 400ms, got %v", last.P95ResponseTime)
+	}
+
+	// Six-hour points merge the hourly buckets
+	metrics, err = metricsService.GetServiceMetrics(context.Background(), "test-service-1", now.Add(-6*time.Hour), now, 360, time.UTC)
+	if err != nil {
+		t.Fatalf("GetServiceMetrics() error = %v", err)
+	}
+	total := 0
+	for _, point := range metrics.DataPoints {
+		if !point.Timestamp.Equal(point.Timestamp.Truncate(6 * time.Hour)) {
+			t.Errorf("Expected points aligned to 6 hours, got %v", point.Timestamp)
+		}
+		total += point.CheckCount
+	}
+	if len(metrics.DataPoints) > 2 || total != 182 {
+		t.Errorf("Expected at most 2 six-hour points with 182 checks, got %d points with %d checks", len(metrics.DataPoints), total)
+	}
+}
+
+func TestMetricsService_GetServiceMetrics_DualStackTail(t *testing.T) {
+	db := setupRollupTestDB(t)
+	defer db.Close()
+
+	statusLogRepo := repository.NewStatusLogRepository(db)
+	serviceRepo := repository.NewServiceRepository(db)
+	metricsService := NewMetricsService(statusLogRepo, serviceRepo)
+	metricsService.SetRollups(repository.NewStatusRollupRepository(db), 30, 12)
+
+	now := time.Now().UTC()
+	lastRolledUp := now.Truncate(time.Hour).Add(-2 * time.Hour)
+	insertTestRollup(t, db, "service_status_rollups_hourly", repository.StatusRollup{
+		ServiceID: "test-service-1", Bucket: lastRolledUp, CheckCount: 60, OnlineCount: 60,
+	})
+
+	// Three dual-stack checks after the last rollup, one row per family; IPv6 fails the last one
+	ipv4 := models.AddressFamilyIPv4
+	ipv6 := models.AddressFamilyIPv6
+	responseTime := 100
+	for i := 0; i < 3; i++ {
+		checkedAt := lastRolledUp.Add(time.Hour + time.Duration(i+1)*time.Minute)
+		ipv6Status := models.StatusOnline
+		if i == 2 {
+			ipv6Status = models.StatusOffline
+		}
+		for _, log := range []*models.StatusLog{
+			{ServiceID: "test-service-1", Status: models.StatusOnline, ResponseTime: &responseTime, AddressFamily: &ipv4, CheckedAt: checkedAt},
+			{ServiceID: "test-service-1", Status: ipv6Status, ResponseTime: &responseTime, AddressFamily: &ipv6, CheckedAt: checkedAt},
+		} {
+			if err := statusLogRepo.Create(context.Background(), log); err != nil {
+				t.Fatalf("Failed to create status log: %v", err)
+			}
+		}
+	}
+
+	metrics, err := metricsService.GetServiceMetrics(context.Background(), "test-service-1", now.Add(-6*time.Hour), now, 60, time.UTC)
+	if err != nil {
+		t.Fatalf("GetServiceMetrics() error = %v", err)
+	}
+
+	if len(metrics.DataPoints) != 2 {
+		t.Fatalf("Expected 1 rolled-up point and 1 recent point, got %d", len(metrics.DataPoints))
+	}
+	if tail := metrics.DataPoints[1]; tail.CheckCount != 3 {
+		t.Errorf("Expected the recent point to count 3 dual-stack checks once each, got %d", tail.CheckCount)
+	}
+	if metrics.TotalChecks != 63 || metrics.OnlineCount != 62 || metrics.OfflineCount != 1 {
+		t.Errorf("Expected 63 checks (62 online, 1 offline), got %d (%d/%d)", metrics.TotalChecks, metrics.OnlineCount, metrics.OfflineCount)
+	}
+}
+
+func withinRelativeAccuracy(got, expected float64) bool {
+	return math.Abs(got-expected) <= expected*models.LatencySketchRelativeAccuracy
+}
+
+func TestMetricsService_GetLatencyHistogram_FromSketches(t *testing.T) {
+	db := setupRollupTestDB(t)
+	defer db.Close()
+
+	statusLogRepo := repository.NewStatusLogRepository(db)
+	metricsService := NewMetricsService(statusLogRepo, repository.NewServiceRepository(db))
+	// One day of raw retention, so a two day range is served from hourly sketches
+	metricsService.SetRollups(repository.NewStatusRollupRepository(db), 1, 12)
+
+	now := time.Now().UTC()
+	lastRolledUp := now.Truncate(time.Hour).Add(-2 * time.Hour)
+
+	sketch := models.LatencySketch{}
+	for _, ms := range []int{20, 80, 80, 300, 20000} {
+		sketch.Add(ms)
+	}
+	insertTestRollup(t, db, "service_status_rollups_hourly", repository.StatusRollup{
+		ServiceID:     "test-service-1",
+		Bucket:        lastRolledUp,
+		CheckCount:    5,
+		OnlineCount:   5,
+		ResponseCount: 5,
+		Sketch:        sketch,
+	})
+
+	// Raw logs after the last rollup are counted exactly
+	responseTime := 100
+	if err := statusLogRepo.Create(context.Background(), &models.StatusLog{
+		ServiceID:    "test-service-1",
+		Status:       models.StatusOnline,
+		ResponseTime: &responseTime,
+		CheckedAt:    lastRolledUp.Add(time.Hour + time.Minute),
+	}); err != nil {
+		t.Fatalf("Failed to create status log: %v", err)
+	}
+
+	histogram, err := metricsService.GetLatencyHistogram(context.Background(), "test-service-1", now.Add(-48*time.Hour), now, []int{50, 100, 1000})
+	if err != nil {
+		t.Fatalf("GetLatencyHistogram() error = %v", err)
+	}
+
+	if histogram.Tier != MetricsTierHourly || !histogram.Approximate {
+		t.Errorf("Expected an approximate hourly histogram, got %s (approximate: %v)", histogram.Tier, histogram.Approximate)
+	}
+	expected := []int64{1, 3, 1, 1}
+	if len(histogram.Buckets) != len(expected) || histogram.Total != 6 {
+		t.Fatalf("Expected %d buckets with 6 responses, got %+v", len(expected), histogram)
+	}
+	for i, bucket := range histogram.Buckets {
+		if bucket.Count != expected[i] {
+			t.Errorf("Bucket %d: expected %d, got %d", i, expected[i], bucket.Count)
+		}
+	}
+	if last := histogram.Buckets[3]; last.Min != 1000 || last.Max != nil {
+		t.Errorf("Expected an open overflow bucket above 1000ms, got %+v", last)
+	}
+
+	if _, err := metricsService.GetLatencyHistogram(context.Background(), "test-service-1", now.Add(-time.Hour), now, []int{100, 50}); err != ErrInvalidLatencyBuckets {
+		t.Errorf("Expected ErrInvalidLatencyBuckets, got %v", err)
+	}
+}
+
+func TestMetricsService_GetServiceMetrics_RollupUptime(t *testing.T) {
+	db := setupRollupTestDB(t)
+	defer db.Close()
+
+	statusLogRepo := repository.NewStatusLogRepository(db)
+	metricsService := NewMetricsService(statusLogRepo, repository.NewServiceRepository(db))
+	metricsService.SetRollups(repository.NewStatusRollupRepository(db), 30, 12)
+
+	now := time.Now().UTC()
+	bucket := now.Truncate(time.Hour).Add(-3 * time.Hour)
+
+	// A restart left the first hour half covered: 30 minutes online, no data after
+	insertTestRollup(t, db, "service_status_rollups_hourly", repository.StatusRollup{
+		ServiceID: "test-service-1", Bucket: bucket, CheckCount: 30, OnlineCount: 30,
+		OnlineDuration: 30 * time.Minute,
+	})
+	// Checks every 5 minutes instead of every minute: 12 checks, 2 offline for 10 minutes
+	insertTestRollup(t, db, "service_status_rollups_hourly", repository.StatusRollup{
+		ServiceID: "test-service-1", Bucket: bucket.Add(time.Hour), CheckCount: 12, OnlineCount: 10, OfflineCount: 2,
+		OnlineDuration: 50 * time.Minute, OfflineDuration: 10 * time.Minute,
+	})
+	// Rolled up before durations were recorded: estimated from the counts
+	insertTestRollup(t, db, "service_status_rollups_hourly", repository.StatusRollup{
+		ServiceID: "test-service-1", Bucket: bucket.Add(2 * time.Hour), CheckCount: 60, OnlineCount: 45, OfflineCount: 15,
+	})
+
+	metrics, err := metricsService.GetServiceMetrics(context.Background(), "test-service-1", bucket, bucket.Add(3*time.Hour), 60, time.UTC)
+	if err != nil {
+		t.Fatalf("GetServiceMetrics() error = %v", err)
+	}
+	if len(metrics.DataPoints) != 3 {
+		t.Fatalf("Expected 3 hourly data points, got %d", len(metrics.DataPoints))
+	}
+
+	first, second, third := metrics.DataPoints[0], metrics.DataPoints[1], metrics.DataPoints[2]
+	if first.UptimePercentage != 100 || first.CoveragePercentage != 50 {
+		t.Errorf("Expected 100%% uptime over 50%% coverage for the restart hour, got %v/%v", first.UptimePercentage, first.CoveragePercentage)
+	}
+	if math.Abs(second.UptimePercentage-100*50.0/60) > 1e-9 || second.CoveragePercentage != 100 {
+		t.Errorf("Expected 83.33%% uptime over full coverage, got %v/%v", second.UptimePercentage, second.CoveragePercentage)
+	}
+	if third.UptimePercentage != 75 || third.CountUptimePercentage != 75 || third.CoveragePercentage != 100 {
+		t.Errorf("Expected estimated durations to match the counts, got %+v", third)
+	}
+
+	// 125 of 150 covered minutes online; the count-based value weighs the 5 minute checks less
+	if math.Abs(metrics.UptimePercentage-100*125.0/150) > 1e-9 {
+		t.Errorf("Expected time-weighted uptime of 83.33%%, got %v", metrics.UptimePercentage)
+	}
+	if math.Abs(metrics.CoveragePercentage-100*150.0/180) > 1e-9 {
+		t.Errorf("Expected 83.33%% coverage, got %v", metrics.CoveragePercentage)
+	}
+	if math.Abs(metrics.CountUptimePercentage-100*85.0/102) > 1e-9 {
+		t.Errorf("Expected count-based uptime of 83.33%%, got %v", metrics.CountUptimePercentage)
+	}
+}
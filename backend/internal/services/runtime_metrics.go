@@ -0,0 +1,111 @@
+package services
+
+import (
+	"os"
+	"runtime"
+	"runtime/debug"
+	"runtime/pprof"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Version is the Nimbus version reported by nimbus_build_info
+// Set at build time: -ldflags "-X github.com/nimbus/backend/internal/services.Version=v1.2.3"
+var Version = "dev"
+
+// processStartTime approximates when the process started (package initialization)
+var processStartTime = time.Now()
+
+// clockTicksPerSecond is USER_HZ, the unit of CPU times in /proc (100 on all common Linux platforms)
+const clockTicksPerSecond = 100
+
+// BuildInfo identifies the running binary
+type BuildInfo struct {
+	Version   string
+	Revision  string // VCS revision embedded by the Go toolchain ("unknown" without one)
+	GoVersion string
+}
+
+// currentBuildInfo reads the build information of the running binary
+func currentBuildInfo() BuildInfo {
+	info := BuildInfo{Version: Version, Revision: "unknown", GoVersion: runtime.Version()}
+	if build, ok := debug.ReadBuildInfo(); ok {
+		for _, setting := range build.Settings {
+			if setting.Key == "vcs.revision" && setting.Value != "" {
+				info.Revision = setting.Value
+			}
+		}
+	}
+	return info
+}
+
+// RuntimeStats are Go runtime and process statistics for the admin Prometheus export
+type RuntimeStats struct {
+	Goroutines    int
+	Threads       int
+	AllocBytes    uint64
+	SysBytes      uint64
+	HeapObjects   uint64
+	GCCycles      uint32
+	LastGC        time.Time // Zero before the first collection
+	StartTime     time.Time
+	CPUSeconds    *float64 // nil where /proc is unavailable
+	ResidentBytes *uint64
+	OpenFDs       *int
+}
+
+// collectRuntimeStats reads the current runtime statistics
+// Process statistics come from /proc and are omitted on other platforms
+func collectRuntimeStats() RuntimeStats {
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+
+	stats := RuntimeStats{
+		Goroutines:  runtime.NumGoroutine(),
+		Threads:     pprof.Lookup("threadcreate").Count(),
+		AllocBytes:  mem.Alloc,
+		SysBytes:    mem.Sys,
+		HeapObjects: mem.HeapObjects,
+		GCCycles:    mem.NumGC,
+		StartTime:   processStartTime,
+	}
+	if mem.LastGC > 0 {
+		stats.LastGC = time.Unix(0, int64(mem.LastGC))
+	}
+
+	if stat, err := os.ReadFile("/proc/self/stat"); err == nil {
+		stats.CPUSeconds, stats.ResidentBytes = parseProcStat(string(stat))
+	}
+	if entries, err := os.ReadDir("/proc/self/fd"); err == nil {
+		openFDs := len(entries)
+		stats.OpenFDs = &openFDs
+	}
+
+	return stats
+}
+
+// parseProcStat extracts CPU time and resident memory from /proc/self/stat
+// Fields are counted after the command name, which is parenthesized and may contain spaces
+func parseProcStat(stat string) (*float64, *uint64) {
+	end := strings.LastIndexByte(stat, ')')
+	if end < 0 {
+		return nil, nil
+	}
+	// fields[0] is field 3 (state): utime is field 14, stime 15 and rss 24
+	fields := strings.Fields(stat[end+1:])
+	if len(fields) < 22 {
+		return nil, nil
+	}
+
+	utime, err1 := strconv.ParseUint(fields[11], 10, 64)
+	stime, err2 := strconv.ParseUint(fields[12], 10, 64)
+	rssPages, err3 := strconv.ParseUint(fields[21], 10, 64)
+	if err1 != nil || err2 != nil || err3 != nil {
+		return nil, nil
+	}
+
+	cpuSeconds := float64(utime+stime) / clockTicksPerSecond
+	residentBytes := rssPages * uint64(os.Getpagesize())
+	return &cpuSeconds, &residentBytes
+}
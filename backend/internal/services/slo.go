@@ -0,0 +1,458 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nimbus/backend/internal/models"
+	"github.com/nimbus/backend/internal/repository"
+)
+
+const (
+	// MaxSLOWindowDays limits rolling windows; budgets are computed from raw status logs,
+	// so windows longer than METRICS_RETENTION_DAYS only see the retained part
+	MaxSLOWindowDays = 90
+
+	// maxSLOServices limits how many services a single SLO can group
+	maxSLOServices = 50
+
+	// sloStatusCacheTTL is how long a computed SLO status is reused (Prometheus scrapes often)
+	sloStatusCacheTTL = time.Minute
+)
+
+// ErrInvalidSLO is returned when an SLO definition is invalid
+var ErrInvalidSLO = errors.New("invalid SLO")
+
+// SLIStatus is the state of one objective (availability or latency) within the current window
+type SLIStatus struct {
+	Target               float64  `json:"target"`                 // Percentage the objective promises
+	Actual               *float64 `json:"actual"`                 // Percentage achieved so far in the window (nil without data)
+	ErrorBudgetRemaining float64  `json:"error_budget_remaining"` // Fraction of the error budget left: 1 untouched, 0 exhausted, negative when exceeded
+	BurnRate1h           float64  `json:"burn_rate_1h"`           // Error rate over the last hour relative to the allowed rate (1 spends the budget exactly over the window)
+	BurnRate6h           float64  `json:"burn_rate_6h"`           // Same over the last 6 hours
+}
+
+// SLOStatus is the error budget state of an SLO
+type SLOStatus struct {
+	WindowStart          time.Time  `json:"window_start"`
+	WindowEnd            time.Time  `json:"window_end"`
+	Availability         SLIStatus  `json:"availability"`
+	Latency              *SLIStatus `json:"latency,omitempty"`      // Only for SLOs with a latency objective
+	ErrorBudgetRemaining float64    `json:"error_budget_remaining"` // Lowest remaining budget of the objectives
+	ComputedAt           time.Time  `json:"computed_at"`
+}
+
+// SLOWithStatus is an SLO definition with its current status
+type SLOWithStatus struct {
+	*models.SLO
+	Status *SLOStatus `json:"status"`
+}
+
+type cachedSLOStatus struct {
+	status    *SLOStatus
+	updatedAt time.Time // SLO version the status was computed for
+}
+
+// SLOService manages service level objectives and computes their error budgets from status logs
+type SLOService struct {
+	repo          *repository.SLORepository
+	serviceRepo   repository.ServiceRepositoryInterface
+	statusLogRepo *repository.StatusLogRepository
+	maxStaleness  time.Duration
+
+	mu    sync.Mutex
+	cache map[string]cachedSLOStatus
+}
+
+// NewSLOService creates an SLO service
+// maxStaleness caps how long a check result counts toward availability (see SetUptimeMaxStaleness)
+func NewSLOService(repo *repository.SLORepository, serviceRepo repository.ServiceRepositoryInterface, statusLogRepo *repository.StatusLogRepository, maxStaleness time.Duration) *SLOService {
+	if maxStaleness <= 0 {
+		maxStaleness = DefaultUptimeMaxStaleness
+	}
+	return &SLOService{
+		repo:          repo,
+		serviceRepo:   serviceRepo,
+		statusLogRepo: statusLogRepo,
+		maxStaleness:  maxStaleness,
+		cache:         make(map[string]cachedSLOStatus),
+	}
+}
+
+// Create validates and stores a new SLO for a user
+// Returns ErrInvalidSLO for invalid definitions or services the user doesn't own
+func (s *SLOService) Create(ctx context.Context, userID string, req *models.SLORequest) (*models.SLO, error) {
+	slo, err := s.sloFromRequest(ctx, userID, req)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	slo.CreatedAt = now
+	slo.UpdatedAt = now
+	if err := s.repo.Create(ctx, slo); err != nil {
+		return nil, err
+	}
+
+	return slo, nil
+}
+
+// Update replaces an SLO's definition
+// Returns sql.ErrNoRows if it doesn't exist or belongs to another user
+func (s *SLOService) Update(ctx context.Context, userID, id string, req *models.SLORequest) (*models.SLO, error) {
+	existing, err := s.getOwned(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	slo, err := s.sloFromRequest(ctx, userID, req)
+	if err != nil {
+		return nil, err
+	}
+
+	slo.ID = existing.ID
+	slo.CreatedAt = existing.CreatedAt
+	slo.UpdatedAt = time.Now()
+	if err := s.repo.Update(ctx, slo); err != nil {
+		return nil, err
+	}
+
+	s.forget(id)
+	return slo, nil
+}
+
+// Delete removes an SLO
+// Returns sql.ErrNoRows if it doesn't exist or belongs to another user
+func (s *SLOService) Delete(ctx context.Context, userID, id string) error {
+	if err := s.repo.Delete(ctx, id, userID); err != nil {
+		return err
+	}
+	s.forget(id)
+	return nil
+}
+
+// Get returns one of a user's SLOs with its status
+// Returns sql.ErrNoRows if it doesn't exist or belongs to another user
+func (s *SLOService) Get(ctx context.Context, userID, id string) (*SLOWithStatus, error) {
+	slo, err := s.getOwned(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	status, err := s.Status(ctx, slo, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	return &SLOWithStatus{SLO: slo, Status: status}, nil
+}
+
+// List returns a user's SLOs with their status
+func (s *SLOService) List(ctx context.Context, userID string) ([]SLOWithStatus, error) {
+	slos, err := s.repo.GetAllByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return s.withStatuses(ctx, slos)
+}
+
+// ListAll returns every user's SLOs with their status (for the admin Prometheus export)
+func (s *SLOService) ListAll(ctx context.Context) ([]SLOWithStatus, error) {
+	slos, err := s.repo.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return s.withStatuses(ctx, slos)
+}
+
+func (s *SLOService) withStatuses(ctx context.Context, slos []*models.SLO) ([]SLOWithStatus, error) {
+	now := time.Now()
+	result := make([]SLOWithStatus, 0, len(slos))
+	for _, slo := range slos {
+		status, err := s.Status(ctx, slo, now)
+		if err != nil {
+			return nil, fmt.Errorf("failed to compute status of SLO %s: %w", slo.ID, err)
+		}
+		result = append(result, SLOWithStatus{SLO: slo, Status: status})
+	}
+	return result, nil
+}
+
+func (s *SLOService) getOwned(ctx context.Context, userID, id string) (*models.SLO, error) {
+	slo, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if slo.UserID != userID {
+		return nil, sql.ErrNoRows
+	}
+	return slo, nil
+}
+
+// sloFromRequest validates a request and builds the SLO it describes
+func (s *SLOService) sloFromRequest(ctx context.Context, userID string, req *models.SLORequest) (*models.SLO, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" || len(name) > 255 {
+		return nil, fmt.Errorf("%w: name is required (at most 255 characters)", ErrInvalidSLO)
+	}
+
+	// One service or a group; every service must belong to the user
+	var serviceIDs []string
+	seen := make(map[string]bool)
+	for _, id := range req.ServiceIDs {
+		if id == "" || seen[id] {
+			continue
+		}
+		seen[id] = true
+		serviceIDs = append(serviceIDs, id)
+	}
+	if len(serviceIDs) == 0 || len(serviceIDs) > maxSLOServices {
+		return nil, fmt.Errorf("%w: service_ids must list 1 to %d services", ErrInvalidSLO, maxSLOServices)
+	}
+	for _, id := range serviceIDs {
+		service, err := s.serviceRepo.GetByID(ctx, id)
+		if err != nil || service == nil || service.UserID != userID {
+			return nil, fmt.Errorf("%w: unknown service %q", ErrInvalidSLO, id)
+		}
+	}
+
+	if req.AvailabilityTarget <= 0 || req.AvailabilityTarget >= 100 {
+		return nil, fmt.Errorf("%w: availability_target must be a percentage between 0 and 100 (exclusive)", ErrInvalidSLO)
+	}
+
+	slo := &models.SLO{
+		UserID:             userID,
+		Name:               name,
+		ServiceIDs:         serviceIDs,
+		AvailabilityTarget: req.AvailabilityTarget,
+		WindowType:         req.WindowType,
+		Timezone:           req.Timezone,
+	}
+
+	// Latency objective: both fields or neither
+	switch {
+	case req.LatencyThresholdMs == nil && req.LatencyTarget == nil:
+	case req.LatencyThresholdMs == nil || req.LatencyTarget == nil:
+		return nil, fmt.Errorf("%w: latency_threshold_ms and latency_target must be set together", ErrInvalidSLO)
+	case *req.LatencyThresholdMs < 1:
+		return nil, fmt.Errorf("%w: latency_threshold_ms must be at least 1", ErrInvalidSLO)
+	case *req.LatencyTarget <= 0 || *req.LatencyTarget >= 100:
+		return nil, fmt.Errorf("%w: latency_target must be a percentage between 0 and 100 (exclusive)", ErrInvalidSLO)
+	default:
+		slo.LatencyThresholdMs = req.LatencyThresholdMs
+		slo.LatencyTarget = req.LatencyTarget
+	}
+
+	switch slo.WindowType {
+	case models.SLOWindowRolling:
+		if req.WindowDays == nil || *req.WindowDays < 1 || *req.WindowDays > MaxSLOWindowDays {
+			return nil, fmt.Errorf("%w: window_days must be between 1 and %d", ErrInvalidSLO, MaxSLOWindowDays)
+		}
+		slo.WindowDays = req.WindowDays
+	case models.SLOWindowCalendar:
+		if req.CalendarPeriod == nil {
+			return nil, fmt.Errorf("%w: calendar_period is required for calendar windows", ErrInvalidSLO)
+		}
+		switch *req.CalendarPeriod {
+		case models.SLOPeriodWeek, models.SLOPeriodMonth, models.SLOPeriodQuarter:
+		default:
+			return nil, fmt.Errorf("%w: calendar_period must be one of week, month, quarter", ErrInvalidSLO)
+		}
+		slo.CalendarPeriod = req.CalendarPeriod
+	default:
+		return nil, fmt.Errorf("%w: window_type must be rolling or calendar", ErrInvalidSLO)
+	}
+
+	if slo.Timezone == "" {
+		slo.Timezone = "UTC"
+	}
+	if _, err := time.LoadLocation(slo.Timezone); err != nil {
+		return nil, fmt.Errorf("%w: invalid timezone %q", ErrInvalidSLO, slo.Timezone)
+	}
+
+	return slo, nil
+}
+
+// sloWindow returns the window an SLO is measured over at now
+// Rolling windows end now; calendar windows are the current week (from Monday), month or quarter
+// in the SLO's time zone and end in the future
+func sloWindow(slo *models.SLO, now time.Time) (time.Time, time.Time) {
+	if slo.WindowType != models.SLOWindowCalendar || slo.CalendarPeriod == nil {
+		days := 30
+		if slo.WindowDays != nil {
+			days = *slo.WindowDays
+		}
+		return now.AddDate(0, 0, -days), now
+	}
+
+	loc, err := time.LoadLocation(slo.Timezone)
+	if err != nil {
+		loc = time.UTC
+	}
+	local := now.In(loc)
+
+	switch *slo.CalendarPeriod {
+	case models.SLOPeriodWeek:
+		daysSinceMonday := (int(local.Weekday()) + 6) % 7
+		start := time.Date(local.Year(), local.Month(), local.Day()-daysSinceMonday, 0, 0, 0, 0, loc)
+		return start, start.AddDate(0, 0, 7)
+	case models.SLOPeriodQuarter:
+		firstMonth := time.Month((int(local.Month())-1)/3*3 + 1)
+		start := time.Date(local.Year(), firstMonth, 1, 0, 0, 0, 0, loc)
+		return start, start.AddDate(0, 3, 0)
+	default:
+		start := time.Date(local.Year(), local.Month(), 1, 0, 0, 0, 0, loc)
+		return start, start.AddDate(0, 1, 0)
+	}
+}
+
+// Status computes an SLO's error budgets at now (cached for sloStatusCacheTTL)
+func (s *SLOService) Status(ctx context.Context, slo *models.SLO, now time.Time) (*SLOStatus, error) {
+	s.mu.Lock()
+	cached, ok := s.cache[slo.ID]
+	s.mu.Unlock()
+	if ok && cached.updatedAt.Equal(slo.UpdatedAt) && now.Sub(cached.status.ComputedAt) < sloStatusCacheTTL && !now.Before(cached.status.ComputedAt) {
+		return cached.status, nil
+	}
+
+	status, err := s.computeStatus(ctx, slo, now)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	s.cache[slo.ID] = cachedSLOStatus{status: status, updatedAt: slo.UpdatedAt}
+	s.mu.Unlock()
+
+	return status, nil
+}
+
+func (s *SLOService) forget(id string) {
+	s.mu.Lock()
+	delete(s.cache, id)
+	s.mu.Unlock()
+}
+
+func (s *SLOService) computeStatus(ctx context.Context, slo *models.SLO, now time.Time) (*SLOStatus, error) {
+	windowStart, windowEnd := sloWindow(slo, now)
+	elapsedEnd := now
+	if windowEnd.Before(elapsedEnd) {
+		elapsedEnd = windowEnd
+	}
+
+	status := &SLOStatus{WindowStart: windowStart, WindowEnd: windowEnd, ComputedAt: now}
+
+	// Availability: offline time against the downtime the target allows over the whole window
+	window, err := s.availability(ctx, slo.ServiceIDs, windowStart, elapsedEnd)
+	if err != nil {
+		return nil, err
+	}
+	lastHour, err := s.availability(ctx, slo.ServiceIDs, now.Add(-time.Hour), now)
+	if err != nil {
+		return nil, err
+	}
+	last6Hours, err := s.availability(ctx, slo.ServiceIDs, now.Add(-6*time.Hour), now)
+	if err != nil {
+		return nil, err
+	}
+
+	allowedDowntimeFraction := 1 - slo.AvailabilityTarget/100
+	status.Availability = SLIStatus{
+		Target:               slo.AvailabilityTarget,
+		ErrorBudgetRemaining: remainingBudget(float64(window.offline), allowedDowntimeFraction*float64(windowEnd.Sub(windowStart))),
+		BurnRate1h:           burnRate(float64(lastHour.offline), float64(lastHour.online+lastHour.offline), allowedDowntimeFraction),
+		BurnRate6h:           burnRate(float64(last6Hours.offline), float64(last6Hours.online+last6Hours.offline), allowedDowntimeFraction),
+	}
+	if window.online+window.offline > 0 {
+		actual := window.uptime()
+		status.Availability.Actual = &actual
+	}
+	status.ErrorBudgetRemaining = status.Availability.ErrorBudgetRemaining
+
+	// Latency: slow checks against the share of checks the target allows
+	if slo.HasLatencyObjective() {
+		threshold := *slo.LatencyThresholdMs
+		windowWithin, windowTotal, err := s.responseTimesWithin(ctx, slo.ServiceIDs, windowStart, elapsedEnd, threshold)
+		if err != nil {
+			return nil, err
+		}
+		hourWithin, hourTotal, err := s.responseTimesWithin(ctx, slo.ServiceIDs, now.Add(-time.Hour), now, threshold)
+		if err != nil {
+			return nil, err
+		}
+		sixHourWithin, sixHourTotal, err := s.responseTimesWithin(ctx, slo.ServiceIDs, now.Add(-6*time.Hour), now, threshold)
+		if err != nil {
+			return nil, err
+		}
+
+		allowedSlowFraction := 1 - *slo.LatencyTarget/100
+		latency := &SLIStatus{
+			Target:               *slo.LatencyTarget,
+			ErrorBudgetRemaining: remainingBudget(float64(windowTotal-windowWithin), allowedSlowFraction*float64(windowTotal)),
+			BurnRate1h:           burnRate(float64(hourTotal-hourWithin), float64(hourTotal), allowedSlowFraction),
+			BurnRate6h:           burnRate(float64(sixHourTotal-sixHourWithin), float64(sixHourTotal), allowedSlowFraction),
+		}
+		if windowTotal > 0 {
+			actual := float64(windowWithin) / float64(windowTotal) * 100
+			latency.Actual = &actual
+		}
+		status.Latency = latency
+
+		if latency.ErrorBudgetRemaining < status.ErrorBudgetRemaining {
+			status.ErrorBudgetRemaining = latency.ErrorBudgetRemaining
+		}
+	}
+
+	return status, nil
+}
+
+// availability sums the time-weighted online and offline durations of services within [startTime, endTime]
+func (s *SLOService) availability(ctx context.Context, serviceIDs []string, startTime, endTime time.Time) (stateDurations, error) {
+	var total stateDurations
+	for _, serviceID := range serviceIDs {
+		samples, err := s.statusLogRepo.GetStatusSamples(ctx, serviceID, startTime.Add(-s.maxStaleness), endTime)
+		if err != nil {
+			return stateDurations{}, fmt.Errorf("failed to get status samples: %w", err)
+		}
+		total.add(timeWeightedDurations(samples, startTime, endTime, []time.Time{startTime}, s.maxStaleness)[0])
+	}
+	return total, nil
+}
+
+// responseTimesWithin counts the checks of services within [startTime, endTime] and those under thresholdMs
+func (s *SLOService) responseTimesWithin(ctx context.Context, serviceIDs []string, startTime, endTime time.Time, thresholdMs int) (int, int, error) {
+	var within, total int
+	for _, serviceID := range serviceIDs {
+		w, t, err := s.statusLogRepo.CountResponseTimesWithin(ctx, serviceID, startTime, endTime, thresholdMs)
+		if err != nil {
+			return 0, 0, fmt.Errorf("failed to count response times: %w", err)
+		}
+		within += w
+		total += t
+	}
+	return within, total, nil
+}
+
+// remainingBudget returns the fraction of an error budget left after spending spent of allowed
+func remainingBudget(spent, allowed float64) float64 {
+	if allowed <= 0 {
+		if spent > 0 {
+			return -1 // Nothing to spend yet, but already failing
+		}
+		return 1
+	}
+	return 1 - spent/allowed
+}
+
+// burnRate returns how fast errors spend the budget: the error rate relative to the allowed rate
+func burnRate(bad, total, allowedFraction float64) float64 {
+	if total <= 0 || allowedFraction <= 0 {
+		return 0
+	}
+	return bad / total / allowedFraction
+}
@@ -0,0 +1,284 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nimbus/backend/internal/models"
+	"github.com/nimbus/backend/internal/repository"
+)
+
+// setupSLOTestDB extends the metrics test database with the SLO table
+func setupSLOTestDB(t *testing.T) *sql.DB {
+	db := setupMetricsTestDB(t)
+
+	_, err := db.Exec(`
+		CREATE TABLE slos (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			user_id TEXT NOT NULL,
+			name TEXT NOT NULL,
+			service_ids TEXT NOT NULL DEFAULT '[]',
+			availability_target REAL NOT NULL,
+			latency_threshold_ms INTEGER,
+			latency_target REAL,
+			window_type TEXT NOT NULL,
+			window_days INTEGER,
+			calendar_period TEXT,
+			timezone TEXT NOT NULL DEFAULT 'UTC',
+			created_at TIMESTAMP NOT NULL,
+			updated_at TIMESTAMP NOT NULL
+		)
+	`)
+	if err != nil {
+		t.Fatalf("Failed to create slos table: %v", err)
+	}
+
+	return db
+}
+
+func newTestSLOService(db *sql.DB) *SLOService {
+	return NewSLOService(
+		repository.NewSLORepository(db),
+		repository.NewServiceRepository(db),
+		repository.NewStatusLogRepository(db),
+		3*time.Minute,
+	)
+}
+
+func float64Ptr(v float64) *float64 { return &v }
+
+func TestSLOWindow(t *testing.T) {
+	amsterdam := loadTestLocation(t, "Europe/Amsterdam")
+	// Thursday 2026-10-15 01:30 in Amsterdam (still the 14th in UTC)
+	now := time.Date(2026, 10, 14, 23, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name          string
+		slo           *models.SLO
+		expectedStart time.Time
+		expectedEnd   time.Time
+	}{
+		{
+			name:          "Rolling 30 days",
+			slo:           &models.SLO{WindowType: models.SLOWindowRolling, WindowDays: intPtr(30)},
+			expectedStart: now.AddDate(0, 0, -30),
+			expectedEnd:   now,
+		},
+		{
+			name:          "Calendar week",
+			slo:           &models.SLO{WindowType: models.SLOWindowCalendar, CalendarPeriod: stringPtr(models.SLOPeriodWeek), Timezone: "Europe/Amsterdam"},
+			expectedStart: time.Date(2026, 10, 12, 0, 0, 0, 0, amsterdam),
+			expectedEnd:   time.Date(2026, 10, 19, 0, 0, 0, 0, amsterdam),
+		},
+		{
+			name:          "Calendar month",
+			slo:           &models.SLO{WindowType: models.SLOWindowCalendar, CalendarPeriod: stringPtr(models.SLOPeriodMonth), Timezone: "Europe/Amsterdam"},
+			expectedStart: time.Date(2026, 10, 1, 0, 0, 0, 0, amsterdam),
+			expectedEnd:   time.Date(2026, 11, 1, 0, 0, 0, 0, amsterdam),
+		},
+		{
+			name:          "Calendar quarter",
+			slo:           &models.SLO{WindowType: models.SLOWindowCalendar, CalendarPeriod: stringPtr(models.SLOPeriodQuarter), Timezone: "UTC"},
+			expectedStart: time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC),
+			expectedEnd:   time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start, end := sloWindow(tt.slo, now)
+			if !start.Equal(tt.expectedStart) || !end.Equal(tt.expectedEnd) {
+				t.Errorf("Expected window %v - %v, got %v - %v", tt.expectedStart, tt.expectedEnd, start, end)
+			}
+		})
+	}
+}
+
+func TestSLOService_CreateValidation(t *testing.T) {
+	db := setupSLOTestDB(t)
+	defer db.Close()
+
+	_, err := db.Exec(`INSERT INTO services (id, user_id, name, url, status, position) VALUES ('other-service', 'user-2', 'Other', 'http://other.example.com', 'online', 0)`)
+	if err != nil {
+		t.Fatalf("Failed to insert service: %v", err)
+	}
+
+	sloService := newTestSLOService(db)
+	ctx := context.Background()
+
+	valid := func() *models.SLORequest {
+		return &models.SLORequest{
+			Name:               "Plex",
+			ServiceIDs:         []string{"test-service-1"},
+			AvailabilityTarget: 99.5,
+			WindowType:         models.SLOWindowCalendar,
+			CalendarPeriod:     stringPtr(models.SLOPeriodMonth),
+		}
+	}
+
+	tests := []struct {
+		name   string
+		modify func(req *models.SLORequest)
+	}{
+		{"Missing name", func(req *models.SLORequest) { req.Name = " " }},
+		{"No services", func(req *models.SLORequest) { req.ServiceIDs = nil }},
+		{"Another user's service", func(req *models.SLORequest) { req.ServiceIDs = []string{"other-service"} }},
+		{"Target of 100%", func(req *models.SLORequest) { req.AvailabilityTarget = 100 }},
+		{"Latency threshold without target", func(req *models.SLORequest) { req.LatencyThresholdMs = intPtr(500) }},
+		{"Unknown window type", func(req *models.SLORequest) { req.WindowType = "sliding" }},
+		{"Rolling window too long", func(req *models.SLORequest) {
+			req.WindowType = models.SLOWindowRolling
+			req.WindowDays = intPtr(MaxSLOWindowDays + 1)
+		}},
+		{"Unknown calendar period", func(req *models.SLORequest) { req.CalendarPeriod = stringPtr("year") }},
+		{"Unknown timezone", func(req *models.SLORequest) { req.Timezone = "Mars/Olympus_Mons" }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := valid()
+			tt.modify(req)
+			if _, err := sloService.Create(ctx, "user-1", req); !errors.Is(err, ErrInvalidSLO) {
+				t.Errorf("Expected ErrInvalidSLO, got %v", err)
+			}
+		})
+	}
+
+	// A group of services, listed twice
+	req := valid()
+	req.ServiceIDs = []string{"test-service-1", "test-service-2", "test-service-1"}
+	slo, err := sloService.Create(ctx, "user-1", req)
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if len(slo.ServiceIDs) != 2 || slo.Timezone != "UTC" || slo.WindowDays != nil {
+		t.Errorf("Expected a deduplicated group in UTC, got %+v", slo)
+	}
+
+	// Other users can't see, change or delete it
+	if _, err := sloService.Get(ctx, "user-2", slo.ID); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("Expected sql.ErrNoRows for another user, got %v", err)
+	}
+	if _, err := sloService.Update(ctx, "user-2", slo.ID, valid()); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("Expected sql.ErrNoRows updating another user's SLO, got %v", err)
+	}
+	if err := sloService.Delete(ctx, "user-2", slo.ID); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("Expected sql.ErrNoRows deleting another user's SLO, got %v", err)
+	}
+}
+
+func TestSLOService_Status(t *testing.T) {
+	db := setupSLOTestDB(t)
+	defer db.Close()
+
+	statusLogRepo := repository.NewStatusLogRepository(db)
+	sloService := newTestSLOService(db)
+	ctx := context.Background()
+
+	// One check per minute over the last 6 hours: offline for 30 minutes 2 hours ago,
+	// and the last 20 checks slow
+	now := time.Now().UTC().Truncate(time.Minute)
+	for i := 0; i < 360; i++ {
+		status := models.StatusOnline
+		if i >= 240 && i < 270 {
+			status = models.StatusOffline
+		}
+		responseTime := 100
+		if i >= 340 {
+			responseTime = 900
+		}
+		err := statusLogRepo.Create(ctx, &models.StatusLog{
+			ServiceID:    "test-service-1",
+			Status:       status,
+			ResponseTime: &responseTime,
+			CheckedAt:    now.Add(time.Duration(i-360) * time.Minute),
+		})
+		if err != nil {
+			t.Fatalf("Failed to create status log: %v", err)
+		}
+	}
+
+	slo, err := sloService.Create(ctx, "user-1", &models.SLORequest{
+		Name:               "Plex",
+		ServiceIDs:         []string{"test-service-1"},
+		AvailabilityTarget: 99,
+		LatencyThresholdMs: intPtr(500),
+		LatencyTarget:      float64Ptr(95),
+		WindowType:         models.SLOWindowRolling,
+		WindowDays:         intPtr(1),
+	})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	status, err := sloService.Status(ctx, slo, now)
+	if err != nil {
+		t.Fatalf("Status() error = %v", err)
+	}
+
+	const epsilon = 1e-9
+	availability := status.Availability
+	if availability.Actual == nil || math.Abs(*availability.Actual-100*330.0/360) > epsilon {
+		t.Errorf("Expected 91.67%% availability, got %v", availability.Actual)
+	}
+	// 30 minutes offline against 1% of a day (14.4 minutes)
+	if math.Abs(availability.ErrorBudgetRemaining-(1-30/14.4)) > epsilon {
+		t.Errorf("Expected an exceeded availability budget of %v, got %v", 1-30/14.4, availability.ErrorBudgetRemaining)
+	}
+	if availability.BurnRate1h != 0 || math.Abs(availability.BurnRate6h-(30.0/360)/0.01) > epsilon {
+		t.Errorf("Expected burn rates 0 (1h) and 8.33 (6h), got %v and %v", availability.BurnRate1h, availability.BurnRate6h)
+	}
+
+	latency := status.Latency
+	if latency == nil {
+		t.Fatal("Expected a latency status")
+	}
+	if latency.Actual == nil || math.Abs(*latency.Actual-100*340.0/360) > epsilon {
+		t.Errorf("Expected 94.44%% of checks within the threshold, got %v", latency.Actual)
+	}
+	// 20 slow checks against 5% of 360 (18)
+	if math.Abs(latency.ErrorBudgetRemaining-(1-20.0/18)) > epsilon {
+		t.Errorf("Expected a latency budget of %v, got %v", 1-20.0/18, latency.ErrorBudgetRemaining)
+	}
+	if math.Abs(latency.BurnRate1h-(20.0/60)/0.05) > epsilon {
+		t.Errorf("Expected a 1h latency burn rate of 6.67, got %v", latency.BurnRate1h)
+	}
+
+	if status.ErrorBudgetRemaining != availability.ErrorBudgetRemaining {
+		t.Errorf("Expected the overall budget to be the lowest objective's, got %v", status.ErrorBudgetRemaining)
+	}
+
+	// Repeated requests within the cache TTL reuse the status
+	cached, err := sloService.Status(ctx, slo, now.Add(time.Second))
+	if err != nil {
+		t.Fatalf("Status() error = %v", err)
+	}
+	if cached != status {
+		t.Error("Expected the cached status to be reused")
+	}
+}
+
+func TestFormatPrometheusMetrics_SLOs(t *testing.T) {
+	output := FormatPrometheusMetrics(&PrometheusMetrics{
+		SLOs: []SLOMetric{
+			{SLOID: "slo-1", SLOName: "Plex \"monthly\"", SLI: "availability", ErrorBudgetRemaining: 0.25},
+			{SLOID: "slo-1", SLOName: "Plex \"monthly\"", SLI: "latency", ErrorBudgetRemaining: -0.5},
+		},
+	})
+
+	expectedStrings := []string{
+		"# TYPE nimbus_slo_error_budget_remaining gauge",
+		`nimbus_slo_error_budget_remaining{slo_id="slo-1",slo_name="Plex \"monthly\"",sli="availability"} 0.25`,
+		`nimbus_slo_error_budget_remaining{slo_id="slo-1",slo_name="Plex \"monthly\"",sli="latency"} -0.5`,
+	}
+	for _, expected := range expectedStrings {
+		if !strings.Contains(output, expected) {
+			t.Errorf("Expected output to contain '%s'", expected)
+		}
+	}
+}
@@ -0,0 +1,229 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/nimbus/backend/internal/models"
+	"github.com/nimbus/backend/internal/repository"
+)
+
+// ErrInvalidTimeRange is returned for a range that doesn't start before its end, or starts in the future
+var ErrInvalidTimeRange = errors.New("invalid time range")
+
+// trackedStatus is the last effective status of a service and when it began (nil if unknown)
+type trackedStatus struct {
+	status string
+	since  *time.Time
+}
+
+// statusTracker remembers each service's last status so transitions can be detected without a
+// query per check. Entries are loaded from the latest event the first time a service is checked
+type statusTracker struct {
+	repo *repository.StatusEventRepository
+
+	mu       sync.Mutex
+	statuses map[string]trackedStatus
+}
+
+// SetStatusEventRepository enables recording status transitions as events
+func (h *HealthCheckService) SetStatusEventRepository(repo *repository.StatusEventRepository) {
+	h.statusEvents = &statusTracker{repo: repo, statuses: make(map[string]trackedStatus)}
+}
+
+// seed makes sure the tracker knows the service's status before its result is recorded
+// The latest event wins; services without events start from their stored status
+func (t *statusTracker) seed(ctx context.Context, service *models.Service) {
+	t.mu.Lock()
+	_, ok := t.statuses[service.ID]
+	t.mu.Unlock()
+	if ok {
+		return
+	}
+
+	tracked := trackedStatus{status: service.Status}
+	if tracked.status == "" {
+		tracked.status = models.StatusUnknown
+	}
+
+	latest, err := t.repo.GetLatestByServiceID(ctx, service.ID)
+	switch {
+	case err == nil:
+		occurredAt := latest.OccurredAt
+		tracked = trackedStatus{status: latest.ToStatus, since: &occurredAt}
+	case !errors.Is(err, sql.ErrNoRows):
+		fmt.Printf("Failed to get latest status event for service %s: %v\n", service.ID, err)
+	}
+
+	t.mu.Lock()
+	if _, ok := t.statuses[service.ID]; !ok {
+		t.statuses[service.ID] = tracked
+	}
+	t.mu.Unlock()
+}
+
+// record stores a transition if status differs from the service's last status
+// Returns the transition (nil if there was none); services the tracker hasn't seen are only remembered
+func (t *statusTracker) record(ctx context.Context, serviceID, status string, at time.Time, errorMessage *string) *models.StatusEvent {
+	t.mu.Lock()
+	previous, ok := t.statuses[serviceID]
+	if ok && previous.status == status {
+		t.mu.Unlock()
+		return nil
+	}
+	t.statuses[serviceID] = trackedStatus{status: status, since: &at}
+	t.mu.Unlock()
+
+	if !ok {
+		return nil
+	}
+
+	event := &models.StatusEvent{
+		ServiceID:    serviceID,
+		FromStatus:   previous.status,
+		ToStatus:     status,
+		OccurredAt:   at,
+		ErrorMessage: errorMessage,
+	}
+	if previous.since != nil {
+		durationMs := at.Sub(*previous.since).Milliseconds()
+		event.PreviousDurationMs = &durationMs
+	}
+
+	// Log creation errors but don't fail the health check
+	if err := t.repo.Create(ctx, event); err != nil {
+		fmt.Printf("Failed to create status event for service %s: %v\n", serviceID, err)
+	}
+	return event
+}
+
+// OutageStats summarizes a service's outages (periods offline) within a time range
+type OutageStats struct {
+	OutageCount          int      `json:"outage_count"`           // Outages overlapping the range
+	OngoingOutage        bool     `json:"ongoing_outage"`         // The service is still offline at the end of the range
+	DowntimeSeconds      float64  `json:"downtime_seconds"`       // Time offline within the range
+	LongestOutageSeconds float64  `json:"longest_outage_seconds"` // Full length of the longest outage, including time outside the range
+	MTTRSeconds          *float64 `json:"mttr_seconds"`           // Mean time to recovery of outages resolved in the range (nil without any)
+	MTBFSeconds          *float64 `json:"mtbf_seconds"`           // Mean time between failures: time not offline per outage (nil without outages)
+}
+
+// StatusEventsResponse is the transition history of a service with its outage statistics
+type StatusEventsResponse struct {
+	ServiceID string                `json:"service_id"`
+	TimeRange TimeRange             `json:"time_range"`
+	Events    []*models.StatusEvent `json:"events"` // Newest first
+	Stats     OutageStats           `json:"stats"`
+}
+
+// StatusEventService serves status transition history
+type StatusEventService struct {
+	repo *repository.StatusEventRepository
+}
+
+// NewStatusEventService creates a status event service
+func NewStatusEventService(repo *repository.StatusEventRepository) *StatusEventService {
+	return &StatusEventService{repo: repo}
+}
+
+// GetServiceEvents returns a service's transitions within [startTime, endTime] and its outage statistics
+// Ranges reaching into the future end now; at most limit events are returned, the statistics cover all
+func (s *StatusEventService) GetServiceEvents(ctx context.Context, serviceID string, startTime, endTime time.Time, limit int) (*StatusEventsResponse, error) {
+	if now := time.Now(); endTime.After(now) {
+		endTime = now
+	}
+	if !startTime.Before(endTime) {
+		return nil, fmt.Errorf("%w: start time must be before end time and not in the future", ErrInvalidTimeRange)
+	}
+
+	// The last transition before the range tells the status at its start
+	var initial *models.StatusEvent
+	previous, err := s.repo.GetLatestBefore(ctx, serviceID, startTime)
+	if err == nil {
+		initial = previous
+	} else if !errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("failed to get status before range: %w", err)
+	}
+
+	events, err := s.repo.GetByServiceID(ctx, serviceID, startTime, endTime)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get status events: %w", err)
+	}
+
+	// Newest first, limited
+	newest := make([]*models.StatusEvent, 0, len(events))
+	for i := len(events) - 1; i >= 0 && (limit <= 0 || len(newest) < limit); i-- {
+		newest = append(newest, events[i])
+	}
+
+	return &StatusEventsResponse{
+		ServiceID: serviceID,
+		TimeRange: TimeRange{Start: startTime, End: endTime},
+		Events:    newest,
+		Stats:     outageStats(initial, events, startTime, endTime),
+	}, nil
+}
+
+// outageStats computes outage statistics from the transitions within [startTime, endTime] (oldest
+// first) and the last transition before the range (nil if none)
+func outageStats(initial *models.StatusEvent, events []*models.StatusEvent, startTime, endTime time.Time) OutageStats {
+	var stats OutageStats
+	var downtime, longest, repairTotal time.Duration
+	var resolved int
+
+	// outageStart is when the current outage began; it may lie before the range
+	var outageStart *time.Time
+	if initial != nil && initial.ToStatus == models.StatusOffline {
+		began := initial.OccurredAt
+		outageStart = &began
+		stats.OutageCount++
+	}
+
+	endOutage := func(at time.Time) time.Duration {
+		inRange := outageStart
+		if inRange.Before(startTime) {
+			inRange = &startTime
+		}
+		downtime += at.Sub(*inRange)
+
+		length := at.Sub(*outageStart)
+		if length > longest {
+			longest = length
+		}
+		return length
+	}
+
+	for _, event := range events {
+		switch {
+		case event.ToStatus == models.StatusOffline && outageStart == nil:
+			began := event.OccurredAt
+			outageStart = &began
+			stats.OutageCount++
+		case event.ToStatus != models.StatusOffline && outageStart != nil:
+			repairTotal += endOutage(event.OccurredAt)
+			resolved++
+			outageStart = nil
+		}
+	}
+
+	if outageStart != nil {
+		endOutage(endTime)
+		stats.OngoingOutage = true
+	}
+
+	stats.DowntimeSeconds = downtime.Seconds()
+	stats.LongestOutageSeconds = longest.Seconds()
+	if resolved > 0 {
+		mttr := repairTotal.Seconds() / float64(resolved)
+		stats.MTTRSeconds = &mttr
+	}
+	if stats.OutageCount > 0 {
+		mtbf := (endTime.Sub(startTime) - downtime).Seconds() / float64(stats.OutageCount)
+		stats.MTBFSeconds = &mtbf
+	}
+
+	return stats
+}
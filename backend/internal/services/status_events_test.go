@@ -0,0 +1,227 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/nimbus/backend/internal/models"
+	"github.com/nimbus/backend/internal/repository"
+)
+
+// setupStatusEventTestDB creates an in-memory SQLite database with the status event table
+func setupStatusEventTestDB(t *testing.T) *sql.DB {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("Failed to create test database: %v", err)
+	}
+
+	_, err = db.Exec(`
+		CREATE TABLE service_status_events (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			service_id TEXT NOT NULL,
+			from_status TEXT NOT NULL,
+			to_status TEXT NOT NULL,
+			occurred_at TIMESTAMP NOT NULL,
+			previous_duration_ms INTEGER,
+			error_message TEXT
+		)
+	`)
+	if err != nil {
+		t.Fatalf("Failed to create service_status_events table: %v", err)
+	}
+
+	return db
+}
+
+func statusEvent(to string, at time.Time) *models.StatusEvent {
+	return &models.StatusEvent{ServiceID: "test-service-1", ToStatus: to, OccurredAt: at}
+}
+
+func TestOutageStats(t *testing.T) {
+	start := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	end := start.Add(24 * time.Hour)
+	hour := func(h float64) time.Time { return start.Add(time.Duration(h * float64(time.Hour))) }
+
+	t.Run("No outages", func(t *testing.T) {
+		stats := outageStats(statusEvent(models.StatusOnline, hour(-5)), nil, start, end)
+		if stats.OutageCount != 0 || stats.MTTRSeconds != nil || stats.MTBFSeconds != nil || stats.DowntimeSeconds != 0 {
+			t.Errorf("Expected empty stats, got %+v", stats)
+		}
+	})
+
+	t.Run("Resolved outages", func(t *testing.T) {
+		events := []*models.StatusEvent{
+			statusEvent(models.StatusOffline, hour(2)),
+			statusEvent(models.StatusOnline, hour(3)),
+			statusEvent(models.StatusOffline, hour(10)),
+			statusEvent(models.StatusUnknown, hour(13)), // Unknown ends an outage
+			statusEvent(models.StatusOnline, hour(14)),
+		}
+		stats := outageStats(nil, events, start, end)
+
+		if stats.OutageCount != 2 || stats.OngoingOutage {
+			t.Errorf("Expected 2 resolved outages, got %+v", stats)
+		}
+		if stats.DowntimeSeconds != 4*3600 || stats.LongestOutageSeconds != 3*3600 {
+			t.Errorf("Expected 4h downtime and a 3h longest outage, got %v/%v", stats.DowntimeSeconds, stats.LongestOutageSeconds)
+		}
+		if stats.MTTRSeconds == nil || *stats.MTTRSeconds != 2*3600 {
+			t.Errorf("Expected a 2h MTTR, got %v", stats.MTTRSeconds)
+		}
+		if stats.MTBFSeconds == nil || *stats.MTBFSeconds != 10*3600 {
+			t.Errorf("Expected a 10h MTBF, got %v", stats.MTBFSeconds)
+		}
+	})
+
+	t.Run("Outages crossing the range", func(t *testing.T) {
+		// Offline since 2h before the range, recovered 1h in; offline again for the last 2h
+		events := []*models.StatusEvent{
+			statusEvent(models.StatusOnline, hour(1)),
+			statusEvent(models.StatusOffline, hour(22)),
+		}
+		stats := outageStats(statusEvent(models.StatusOffline, hour(-2)), events, start, end)
+
+		if stats.OutageCount != 2 || !stats.OngoingOutage {
+			t.Errorf("Expected 2 outages with one ongoing, got %+v", stats)
+		}
+		if stats.DowntimeSeconds != 3*3600 {
+			t.Errorf("Expected 3h downtime within the range, got %v", stats.DowntimeSeconds)
+		}
+		if stats.LongestOutageSeconds != 3*3600 {
+			t.Errorf("Expected the full 3h of the first outage as longest, got %v", stats.LongestOutageSeconds)
+		}
+		if stats.MTTRSeconds == nil || *stats.MTTRSeconds != 3*3600 {
+			t.Errorf("Expected MTTR from the resolved outage only, got %v", stats.MTTRSeconds)
+		}
+	})
+}
+
+func TestHealthCheckService_RecordsStatusTransitions(t *testing.T) {
+	db := setupStatusEventTestDB(t)
+	defer db.Close()
+
+	var healthy atomic.Bool
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !healthy.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer testServer.Close()
+
+	eventRepo := repository.NewStatusEventRepository(db)
+	healthService := &HealthCheckService{
+		serviceRepo: &MockServiceRepository{},
+		httpClient:  &http.Client{Timeout: 5 * time.Second},
+	}
+	healthService.SetStatusEventRepository(eventRepo)
+
+	ctx := context.Background()
+	service := &models.Service{ID: "test-service-1", Name: "Test Service", URL: testServer.URL, Status: models.StatusOnline}
+
+	// online (stored) -> offline -> offline -> online
+	for _, up := range []bool{false, false, true} {
+		healthy.Store(up)
+		if err := healthService.CheckService(ctx, service); err != nil {
+			t.Fatalf("CheckService() error = %v", err)
+		}
+	}
+
+	events, err := eventRepo.GetByServiceID(ctx, service.ID, time.Now().Add(-time.Hour), time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatalf("GetByServiceID() error = %v", err)
+	}
+	if len(events) != 2 {
+		t.Fatalf("Expected 2 transitions, got %d", len(events))
+	}
+
+	down, up := events[0], events[1]
+	if down.FromStatus != models.StatusOnline || down.ToStatus != models.StatusOffline {
+		t.Errorf("Expected online -> offline, got %s -> %s", down.FromStatus, down.ToStatus)
+	}
+	if down.PreviousDurationMs != nil {
+		t.Errorf("Expected no previous duration without an earlier event, got %d", *down.PreviousDurationMs)
+	}
+	if down.ErrorMessage == nil || *down.ErrorMessage != "HTTP 503" {
+		t.Errorf("Expected the triggering error, got %v", down.ErrorMessage)
+	}
+	if up.FromStatus != models.StatusOffline || up.ToStatus != models.StatusOnline || up.ErrorMessage != nil {
+		t.Errorf("Expected offline -> online without error, got %+v", up)
+	}
+	if up.PreviousDurationMs == nil || *up.PreviousDurationMs != up.OccurredAt.Sub(down.OccurredAt).Milliseconds() {
+		t.Errorf("Expected the outage length as previous duration, got %v", up.PreviousDurationMs)
+	}
+
+	// A restarted checker continues from the latest event instead of the stored status
+	restarted := &HealthCheckService{
+		serviceRepo: &MockServiceRepository{},
+		httpClient:  &http.Client{Timeout: 5 * time.Second},
+	}
+	restarted.SetStatusEventRepository(eventRepo)
+	stale := &models.Service{ID: service.ID, Name: service.Name, URL: service.URL, Status: models.StatusOffline}
+	if err := restarted.CheckService(ctx, stale); err != nil {
+		t.Fatalf("CheckService() error = %v", err)
+	}
+
+	events, err = eventRepo.GetByServiceID(ctx, service.ID, time.Now().Add(-time.Hour), time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatalf("GetByServiceID() error = %v", err)
+	}
+	if len(events) != 2 {
+		t.Errorf("Expected no new transition after a restart, got %d events", len(events))
+	}
+}
+
+func TestStatusEventService_GetServiceEvents(t *testing.T) {
+	db := setupStatusEventTestDB(t)
+	defer db.Close()
+
+	eventRepo := repository.NewStatusEventRepository(db)
+	ctx := context.Background()
+
+	end := time.Now().UTC().Truncate(time.Hour)
+	start := end.Add(-24 * time.Hour)
+	for _, event := range []*models.StatusEvent{
+		statusEvent(models.StatusOffline, start.Add(-time.Hour)),
+		statusEvent(models.StatusOnline, start.Add(time.Hour)),
+		statusEvent(models.StatusOffline, start.Add(5*time.Hour)),
+		statusEvent(models.StatusOnline, start.Add(6*time.Hour)),
+	} {
+		event.FromStatus = models.StatusUnknown
+		if err := eventRepo.Create(ctx, event); err != nil {
+			t.Fatalf("Create() error = %v", err)
+		}
+	}
+
+	service := NewStatusEventService(eventRepo)
+	response, err := service.GetServiceEvents(ctx, "test-service-1", start, end, 2)
+	if err != nil {
+		t.Fatalf("GetServiceEvents() error = %v", err)
+	}
+
+	if len(response.Events) != 2 || !response.Events[0].OccurredAt.Equal(start.Add(6*time.Hour)) {
+		t.Errorf("Expected the 2 newest events, got %+v", response.Events)
+	}
+	if response.Stats.OutageCount != 2 || response.Stats.DowntimeSeconds != 2*3600 {
+		t.Errorf("Expected 2 outages and 2h downtime over all events, got %+v", response.Stats)
+	}
+	if response.Stats.LongestOutageSeconds != 2*3600 {
+		t.Errorf("Expected the outage carried into the range to last 2h, got %v", response.Stats.LongestOutageSeconds)
+	}
+
+	if _, err := service.GetServiceEvents(ctx, "test-service-1", end, start, 10); !errors.Is(err, ErrInvalidTimeRange) {
+		t.Errorf("Expected ErrInvalidTimeRange for an inverted range, got %v", err)
+	}
+	future := time.Now().Add(time.Hour)
+	if _, err := service.GetServiceEvents(ctx, "test-service-1", future, future.Add(time.Hour), 10); !errors.Is(err, ErrInvalidTimeRange) {
+		t.Errorf("Expected ErrInvalidTimeRange for a range in the future, got %v", err)
+	}
+}
@@ -0,0 +1,172 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/nimbus/backend/internal/repository"
+)
+
+// Status log partition intervals
+const (
+	PartitionIntervalDay  = "day"
+	PartitionIntervalWeek = "week"
+
+	DefaultPartitionsAhead = 7
+)
+
+var ErrInvalidPartitionInterval = errors.New("partition interval must be day or week")
+
+// StatusLogPartitionService keeps service_status_logs partitioned by checked_at
+// Future partitions are created ahead of time and retention drops whole partitions,
+// so old data is removed without a long-running DELETE
+type StatusLogPartitionService struct {
+	repo     repository.StatusLogPartitioner
+	interval string
+	ahead    int // Partitions to keep ready after the current one
+}
+
+// NewStatusLogPartitionService creates a partition service
+// interval is the width of new partitions (day or week); partitions created earlier with
+// another interval are kept and new ones are fitted around them
+func NewStatusLogPartitionService(repo repository.StatusLogPartitioner, interval string, ahead int) (*StatusLogPartitionService, error) {
+	if interval == "" {
+		interval = PartitionIntervalDay
+	}
+	if interval != PartitionIntervalDay && interval != PartitionIntervalWeek {
+		return nil, ErrInvalidPartitionInterval
+	}
+	if ahead <= 0 {
+		ahead = DefaultPartitionsAhead
+	}
+
+	return &StatusLogPartitionService{repo: repo, interval: interval, ahead: ahead}, nil
+}
+
+// Interval returns the width of new partitions
+func (s *StatusLogPartitionService) Interval() string {
+	return s.interval
+}
+
+// Ahead returns the number of future partitions kept ready
+func (s *StatusLogPartitionService) Ahead() int {
+	return s.ahead
+}
+
+// Enabled reports whether the status log table is partitioned (the migration has run)
+func (s *StatusLogPartitionService) Enabled(ctx context.Context) (bool, error) {
+	return s.repo.IsPartitioned(ctx)
+}
+
+// EnsurePartitions creates any missing partitions from the one containing now
+// up to ahead partitions into the future, and returns the names it created
+func (s *StatusLogPartitionService) EnsurePartitions(ctx context.Context, now time.Time) ([]string, error) {
+	partitions, err := s.repo.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list partitions: %w", err)
+	}
+
+	cursor := partitionStart(now, s.interval)
+	horizon := cursor
+	for i := 0; i <= s.ahead; i++ {
+		horizon = nextPartitionBoundary(horizon, s.interval)
+	}
+
+	var created []string
+	for cursor.Before(horizon) {
+		if covering := findCoveringPartition(partitions, cursor); covering != nil {
+			cursor = covering.To
+			continue
+		}
+
+		// Stop at the next boundary, or earlier where an existing partition begins
+		// (e.g. daily partitions from the migration when the interval is a week)
+		end := nextPartitionBoundary(cursor, s.interval)
+		for _, p := range partitions {
+			if !p.Default && p.From.After(cursor) && p.From.Before(end) {
+				end = p.From
+			}
+		}
+
+		name := statusLogPartitionName(cursor)
+		if err := s.repo.Create(ctx, name, cursor, end); err != nil {
+			return created, fmt.Errorf("failed to create partition %s: %w", name, err)
+		}
+		created = append(created, name)
+		partitions = append(partitions, repository.StatusLogPartition{Name: name, From: cursor, To: end})
+		cursor = end
+	}
+
+	return created, nil
+}
+
+// DropBefore drops every partition that only holds rows older than cutoffTime
+// Retention is rounded down to whole partitions: the partition containing the cutoff is kept
+// until all of its rows have expired. Stray rows in the default partition are deleted
+func (s *StatusLogPartitionService) DropBefore(ctx context.Context, cutoffTime time.Time) (int, int64, error) {
+	partitions, err := s.repo.List(ctx)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to list partitions: %w", err)
+	}
+
+	dropped := 0
+	for _, p := range partitions {
+		if p.Default || p.To.After(cutoffTime) {
+			continue
+		}
+
+		if err := s.repo.Drop(ctx, p.Name); err != nil {
+			return dropped, 0, fmt.Errorf("failed to drop partition %s: %w", p.Name, err)
+		}
+		fmt.Printf("Dropped status log partition %s (%s to %s)\n", p.Name, p.From.Format(time.DateOnly), p.To.Format(time.DateOnly))
+		dropped++
+	}
+
+	deleted, err := s.repo.DeleteFromDefaultOlderThan(ctx, cutoffTime)
+	if err != nil {
+		return dropped, 0, fmt.Errorf("failed to clean default partition: %w", err)
+	}
+
+	return dropped, deleted, nil
+}
+
+// findCoveringPartition returns the range partition containing t
+func findCoveringPartition(partitions []repository.StatusLogPartition, t time.Time) *repository.StatusLogPartition {
+	for i := range partitions {
+		p := &partitions[i]
+		if !p.Default && !t.Before(p.From) && t.Before(p.To) {
+			return p
+		}
+	}
+	return nil
+}
+
+// partitionStart returns the start of the partition period containing t
+// Periods start at UTC midnight; weekly periods start on Monday
+func partitionStart(t time.Time, interval string) time.Time {
+	t = t.UTC()
+	start := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+
+	if interval == PartitionIntervalWeek {
+		daysSinceMonday := (int(start.Weekday()) + 6) % 7
+		start = start.AddDate(0, 0, -daysSinceMonday)
+	}
+
+	return start
+}
+
+// nextPartitionBoundary returns the start of the period after the one containing t
+func nextPartitionBoundary(t time.Time, interval string) time.Time {
+	start := partitionStart(t, interval)
+	if interval == PartitionIntervalWeek {
+		return start.AddDate(0, 0, 7)
+	}
+	return start.AddDate(0, 0, 1)
+}
+
+// statusLogPartitionName names a partition after its first day (service_status_logs_pYYYYMMDD)
+func statusLogPartitionName(from time.Time) string {
+	return "service_status_logs_p" + from.UTC().Format("20060102")
+}
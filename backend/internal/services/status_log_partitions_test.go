@@ -0,0 +1,164 @@
+package services
+
+import (
+	"context"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/nimbus/backend/internal/repository"
+)
+
+// mockPartitioner is an in-memory StatusLogPartitioner
+type mockPartitioner struct {
+	partitions    []repository.StatusLogPartition
+	dropped       []string
+	defaultCutoff time.Time
+}
+
+func (m *mockPartitioner) IsPartitioned(ctx context.Context) (bool, error) {
+	return true, nil
+}
+
+func (m *mockPartitioner) List(ctx context.Context) ([]repository.StatusLogPartition, error) {
+	return append([]repository.StatusLogPartition(nil), m.partitions...), nil
+}
+
+func (m *mockPartitioner) Create(ctx context.Context, name string, from, to time.Time) error {
+	m.partitions = append(m.partitions, repository.StatusLogPartition{Name: name, From: from, To: to})
+	return nil
+}
+
+func (m *mockPartitioner) Drop(ctx context.Context, name string) error {
+	for i, p := range m.partitions {
+		if p.Name == name {
+			m.partitions = append(m.partitions[:i], m.partitions[i+1:]...)
+			break
+		}
+	}
+	m.dropped = append(m.dropped, name)
+	return nil
+}
+
+func (m *mockPartitioner) DeleteFromDefaultOlderThan(ctx context.Context, cutoffTime time.Time) (int64, error) {
+	m.defaultCutoff = cutoffTime
+	return 2, nil
+}
+
+func utcDate(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+func TestPartitionStart(t *testing.T) {
+	// Sunday evening in New York is already Monday in UTC
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("Time zone data unavailable: %v", err)
+	}
+	sundayEvening := time.Date(2026, 10, 18, 22, 30, 0, 0, newYork)
+
+	if got := partitionStart(sundayEvening, PartitionIntervalDay); !got.Equal(utcDate(2026, 10, 19)) {
+		t.Errorf("Expected daily partition to start at UTC midnight, got %v", got)
+	}
+	if got := partitionStart(sundayEvening, PartitionIntervalWeek); !got.Equal(utcDate(2026, 10, 19)) {
+		t.Errorf("Expected weekly partition to start on Monday, got %v", got)
+	}
+	if got := partitionStart(utcDate(2026, 10, 18), PartitionIntervalWeek); !got.Equal(utcDate(2026, 10, 12)) {
+		t.Errorf("Expected Sunday to belong to the week starting Monday 12th, got %v", got)
+	}
+	if got := nextPartitionBoundary(utcDate(2026, 10, 14), PartitionIntervalWeek); !got.Equal(utcDate(2026, 10, 19)) {
+		t.Errorf("Expected next weekly boundary on Monday 19th, got %v", got)
+	}
+}
+
+func TestNewStatusLogPartitionService_InvalidInterval(t *testing.T) {
+	if _, err := NewStatusLogPartitionService(&mockPartitioner{}, "month", 0); err != ErrInvalidPartitionInterval {
+		t.Errorf("Expected ErrInvalidPartitionInterval, got %v", err)
+	}
+}
+
+func TestStatusLogPartitionService_EnsurePartitions_Daily(t *testing.T) {
+	repo := &mockPartitioner{partitions: []repository.StatusLogPartition{
+		{Name: repository.StatusLogDefaultPartition, Default: true},
+		{Name: "service_status_logs_p20261018", From: utcDate(2026, 10, 18), To: utcDate(2026, 10, 19)},
+	}}
+	service, _ := NewStatusLogPartitionService(repo, PartitionIntervalDay, 3)
+
+	created, err := service.EnsurePartitions(context.Background(), time.Date(2026, 10, 18, 15, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("EnsurePartitions() error = %v", err)
+	}
+
+	expected := []string{"service_status_logs_p20261019", "service_status_logs_p20261020", "service_status_logs_p20261021"}
+	if len(created) != len(expected) {
+		t.Fatalf("Expected %v, got %v", expected, created)
+	}
+	for i := range expected {
+		if created[i] != expected[i] {
+			t.Errorf("Expected %v, got %v", expected, created)
+		}
+	}
+
+	// Running again is a no-op
+	if again, _ := service.EnsurePartitions(context.Background(), time.Date(2026, 10, 18, 16, 0, 0, 0, time.UTC)); len(again) != 0 {
+		t.Errorf("Expected no new partitions, got %v", again)
+	}
+}
+
+func TestStatusLogPartitionService_EnsurePartitions_WeeklyAfterDaily(t *testing.T) {
+	// Daily partitions from the migration cover Wed 14th - Fri 16th
+	repo := &mockPartitioner{partitions: []repository.StatusLogPartition{
+		{Name: "service_status_logs_p20261014", From: utcDate(2026, 10, 14), To: utcDate(2026, 10, 15)},
+		{Name: "service_status_logs_p20261015", From: utcDate(2026, 10, 15), To: utcDate(2026, 10, 16)},
+		{Name: "service_status_logs_p20261016", From: utcDate(2026, 10, 16), To: utcDate(2026, 10, 17)},
+	}}
+	service, _ := NewStatusLogPartitionService(repo, PartitionIntervalWeek, 1)
+
+	if _, err := service.EnsurePartitions(context.Background(), utcDate(2026, 10, 14)); err != nil {
+		t.Fatalf("EnsurePartitions() error = %v", err)
+	}
+
+	sort.Slice(repo.partitions, func(i, j int) bool { return repo.partitions[i].From.Before(repo.partitions[j].From) })
+
+	// Mon-Tue before the dailies, Sat-Sun after them, then one full week ahead
+	expected := []struct{ from, to time.Time }{
+		{utcDate(2026, 10, 12), utcDate(2026, 10, 14)},
+		{utcDate(2026, 10, 14), utcDate(2026, 10, 15)},
+		{utcDate(2026, 10, 15), utcDate(2026, 10, 16)},
+		{utcDate(2026, 10, 16), utcDate(2026, 10, 17)},
+		{utcDate(2026, 10, 17), utcDate(2026, 10, 19)},
+		{utcDate(2026, 10, 19), utcDate(2026, 10, 26)},
+	}
+	if len(repo.partitions) != len(expected) {
+		t.Fatalf("Expected %d partitions, got %+v", len(expected), repo.partitions)
+	}
+	for i, p := range repo.partitions {
+		if !p.From.Equal(expected[i].from) || !p.To.Equal(expected[i].to) {
+			t.Errorf("Partition %d: expected %v - %v, got %v - %v", i, expected[i].from, expected[i].to, p.From, p.To)
+		}
+	}
+}
+
+func TestStatusLogPartitionService_DropBefore(t *testing.T) {
+	repo := &mockPartitioner{partitions: []repository.StatusLogPartition{
+		{Name: repository.StatusLogDefaultPartition, Default: true},
+		{Name: "service_status_logs_p20260916", From: utcDate(2026, 9, 16), To: utcDate(2026, 9, 17)},
+		{Name: "service_status_logs_p20260917", From: utcDate(2026, 9, 17), To: utcDate(2026, 9, 18)},
+		{Name: "service_status_logs_p20260918", From: utcDate(2026, 9, 18), To: utcDate(2026, 9, 19)},
+	}}
+	service, _ := NewStatusLogPartitionService(repo, PartitionIntervalDay, 0)
+
+	// The cutoff falls inside the 17th: that partition still holds rows within retention
+	cutoff := time.Date(2026, 9, 17, 12, 0, 0, 0, time.UTC)
+	dropped, deleted, err := service.DropBefore(context.Background(), cutoff)
+	if err != nil {
+		t.Fatalf("DropBefore() error = %v", err)
+	}
+
+	if dropped != 1 || len(repo.dropped) != 1 || repo.dropped[0] != "service_status_logs_p20260916" {
+		t.Errorf("Expected only the 16th to be dropped, got %v", repo.dropped)
+	}
+	if deleted != 2 || !repo.defaultCutoff.Equal(cutoff) {
+		t.Errorf("Expected old rows to be deleted from the default partition, got %d (cutoff %v)", deleted, repo.defaultCutoff)
+	}
+}
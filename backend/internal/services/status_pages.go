@@ -0,0 +1,556 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/nimbus/backend/internal/models"
+	"github.com/nimbus/backend/internal/repository"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	// StatusPageDays is how many daily uptime bars a status page shows
+	StatusPageDays = 90
+
+	// maxStatusPageServices limits how many services a single status page shows
+	maxStatusPageServices = 50
+
+	// maxStatusPageIncidents limits how many unresolved incidents are looked at per page
+	maxStatusPageIncidents = 50
+
+	// Status page passwords are bcrypt hashed, which only uses the first 72 bytes
+	minStatusPagePassword = 8
+	maxStatusPagePassword = 72
+
+	// maxStatusPageText limits descriptions and announcement bodies
+	maxStatusPageText = 5000
+
+	// statusPageCacheTTL is how long a rendered public page is reused (it reads 90 days of metrics)
+	statusPageCacheTTL = time.Minute
+)
+
+var (
+	// ErrInvalidStatusPage is returned when a status page definition is invalid
+	ErrInvalidStatusPage = errors.New("invalid status page")
+
+	// ErrInvalidAnnouncement is returned when an announcement is invalid
+	ErrInvalidAnnouncement = errors.New("invalid announcement")
+
+	// ErrStatusPagePassword is returned when a protected status page is requested without the right password
+	ErrStatusPagePassword = errors.New("status page password required")
+)
+
+// statusPageSlugPattern matches 3 to 64 lowercase letters, digits and inner dashes
+var statusPageSlugPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{1,62}[a-z0-9]$`)
+
+type cachedStatusPage struct {
+	page      *models.PublicStatusPage
+	updatedAt time.Time // Page version the view was built for
+}
+
+// StatusPageService manages public status pages and builds the view served to visitors
+type StatusPageService struct {
+	repo         *repository.StatusPageRepository
+	serviceRepo  repository.ServiceRepositoryInterface
+	incidentRepo *repository.IncidentRepository
+	metrics      *MetricsService
+	now          func() time.Time
+
+	mu    sync.Mutex
+	cache map[string]cachedStatusPage
+}
+
+// NewStatusPageService creates a status page service
+func NewStatusPageService(repo *repository.StatusPageRepository, serviceRepo repository.ServiceRepositoryInterface, incidentRepo *repository.IncidentRepository, metrics *MetricsService) *StatusPageService {
+	return &StatusPageService{
+		repo:         repo,
+		serviceRepo:  serviceRepo,
+		incidentRepo: incidentRepo,
+		metrics:      metrics,
+		now:          time.Now,
+		cache:        make(map[string]cachedStatusPage),
+	}
+}
+
+// List returns a user's status pages
+func (s *StatusPageService) List(ctx context.Context, userID string) ([]*models.StatusPage, error) {
+	pages, err := s.repo.GetAllByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if pages == nil {
+		pages = []*models.StatusPage{}
+	}
+	return pages, nil
+}
+
+// Create validates and stores a new status page for a user
+// Returns ErrInvalidStatusPage for invalid definitions, taken slugs or services the user doesn't own
+func (s *StatusPageService) Create(ctx context.Context, userID string, req *models.StatusPageRequest) (*models.StatusPage, error) {
+	page, err := s.pageFromRequest(ctx, userID, nil, req)
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.now()
+	page.CreatedAt = now
+	page.UpdatedAt = now
+	if err := s.repo.Create(ctx, page); err != nil {
+		return nil, err
+	}
+
+	return page, nil
+}
+
+// Update replaces a status page's definition; the password is kept unless the request sets one
+// Returns sql.ErrNoRows if it doesn't exist or belongs to another user
+func (s *StatusPageService) Update(ctx context.Context, userID, id string, req *models.StatusPageRequest) (*models.StatusPage, error) {
+	existing, err := s.getOwned(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	page, err := s.pageFromRequest(ctx, userID, existing, req)
+	if err != nil {
+		return nil, err
+	}
+
+	page.ID = existing.ID
+	page.CreatedAt = existing.CreatedAt
+	page.UpdatedAt = s.now()
+	if err := s.repo.Update(ctx, page); err != nil {
+		return nil, err
+	}
+
+	s.forget(id)
+	return page, nil
+}
+
+// Delete removes a status page with its announcements
+// Returns sql.ErrNoRows if it doesn't exist or belongs to another user
+func (s *StatusPageService) Delete(ctx context.Context, userID, id string) error {
+	if err := s.repo.Delete(ctx, id, userID); err != nil {
+		return err
+	}
+	s.forget(id)
+	return nil
+}
+
+// ListAnnouncements returns all announcements of one of a user's status pages, including past
+// and scheduled ones, newest first
+// Returns sql.ErrNoRows if the page doesn't exist or belongs to another user
+func (s *StatusPageService) ListAnnouncements(ctx context.Context, userID, pageID string) ([]*models.StatusPageAnnouncement, error) {
+	if _, err := s.getOwned(ctx, userID, pageID); err != nil {
+		return nil, err
+	}
+	return s.repo.GetAnnouncements(ctx, pageID)
+}
+
+// CreateAnnouncement adds an announcement to one of a user's status pages
+// Returns ErrInvalidAnnouncement for invalid announcements and sql.ErrNoRows if the page doesn't
+// exist or belongs to another user
+func (s *StatusPageService) CreateAnnouncement(ctx context.Context, userID, pageID string, req *models.AnnouncementRequest) (*models.StatusPageAnnouncement, error) {
+	if _, err := s.getOwned(ctx, userID, pageID); err != nil {
+		return nil, err
+	}
+
+	now := s.now()
+	announcement, err := announcementFromRequest(req, now)
+	if err != nil {
+		return nil, err
+	}
+
+	announcement.StatusPageID = pageID
+	announcement.CreatedAt = now
+	announcement.UpdatedAt = now
+	if err := s.repo.CreateAnnouncement(ctx, announcement); err != nil {
+		return nil, err
+	}
+
+	s.forget(pageID)
+	return announcement, nil
+}
+
+// UpdateAnnouncement replaces an announcement; setting ends_at to now takes it off the page
+// Returns sql.ErrNoRows if the page or announcement doesn't exist or belongs to another user
+func (s *StatusPageService) UpdateAnnouncement(ctx context.Context, userID, pageID, id string, req *models.AnnouncementRequest) (*models.StatusPageAnnouncement, error) {
+	if _, err := s.getOwned(ctx, userID, pageID); err != nil {
+		return nil, err
+	}
+	existing, err := s.repo.GetAnnouncement(ctx, id, pageID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Without starts_at the announcement keeps its start
+	if req.StartsAt == nil {
+		req.StartsAt = &existing.StartsAt
+	}
+	announcement, err := announcementFromRequest(req, s.now())
+	if err != nil {
+		return nil, err
+	}
+
+	announcement.ID = existing.ID
+	announcement.StatusPageID = pageID
+	announcement.CreatedAt = existing.CreatedAt
+	announcement.UpdatedAt = s.now()
+	if err := s.repo.UpdateAnnouncement(ctx, announcement); err != nil {
+		return nil, err
+	}
+
+	s.forget(pageID)
+	return announcement, nil
+}
+
+// DeleteAnnouncement removes an announcement
+// Returns sql.ErrNoRows if the page or announcement doesn't exist or belongs to another user
+func (s *StatusPageService) DeleteAnnouncement(ctx context.Context, userID, pageID, id string) error {
+	if _, err := s.getOwned(ctx, userID, pageID); err != nil {
+		return err
+	}
+	if err := s.repo.DeleteAnnouncement(ctx, id, pageID); err != nil {
+		return err
+	}
+	s.forget(pageID)
+	return nil
+}
+
+// Public returns the view of a status page for visitors (cached for statusPageCacheTTL)
+// Returns sql.ErrNoRows if no page has the slug and ErrStatusPagePassword if the page is
+// protected and password doesn't match
+func (s *StatusPageService) Public(ctx context.Context, slug, password string) (*models.PublicStatusPage, error) {
+	page, err := s.repo.GetBySlug(ctx, strings.ToLower(slug))
+	if err != nil {
+		return nil, err
+	}
+
+	if page.PasswordHash != nil {
+		if password == "" || bcrypt.CompareHashAndPassword([]byte(*page.PasswordHash), []byte(password)) != nil {
+			return nil, ErrStatusPagePassword
+		}
+	}
+
+	now := s.now()
+	s.mu.Lock()
+	cached, ok := s.cache[page.ID]
+	s.mu.Unlock()
+	if ok && cached.updatedAt.Equal(page.UpdatedAt) && now.Sub(cached.page.GeneratedAt) < statusPageCacheTTL && !now.Before(cached.page.GeneratedAt) {
+		return cached.page, nil
+	}
+
+	view, err := s.build(ctx, page, now)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	s.cache[page.ID] = cachedStatusPage{page: view, updatedAt: page.UpdatedAt}
+	s.mu.Unlock()
+
+	return view, nil
+}
+
+// build assembles the public view of a page at now
+func (s *StatusPageService) build(ctx context.Context, page *models.StatusPage, now time.Time) (*models.PublicStatusPage, error) {
+	loc, err := time.LoadLocation(page.Timezone)
+	if err != nil {
+		loc = time.UTC
+	}
+
+	// Daily bars from local midnight StatusPageDays-1 days ago up to now
+	local := now.In(loc)
+	start := time.Date(local.Year(), local.Month(), local.Day()-(StatusPageDays-1), 0, 0, 0, 0, loc)
+
+	view := &models.PublicStatusPage{
+		Slug:              page.Slug,
+		Title:             page.Title,
+		Description:       page.Description,
+		Timezone:          loc.String(),
+		PasswordProtected: page.PasswordHash != nil,
+		Services:          []models.PublicStatusService{},
+		Incidents:         []models.PublicStatusIncident{},
+		Announcements:     []models.PublicStatusAnnouncement{},
+		GeneratedAt:       now,
+	}
+
+	names := make(map[string]string, len(page.Services)) // Service ID -> name shown on the page
+	checked, offline := 0, 0
+	for _, entry := range page.Services {
+		service, err := s.serviceRepo.GetByID(ctx, entry.ServiceID)
+		if err != nil || service == nil || service.UserID != page.UserID {
+			continue
+		}
+
+		public := models.PublicStatusService{
+			Name:   service.Name,
+			Status: service.Status,
+		}
+		if entry.DisplayName != nil {
+			public.Name = *entry.DisplayName
+		}
+		if !entry.HideURL {
+			public.URL = service.URL
+		}
+
+		metrics, err := s.metrics.GetServiceMetrics(ctx, service.ID, start, now, 24*60, loc)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get uptime of service %s: %w", service.ID, err)
+		}
+		public.UptimePercentage, public.Days = statusPageDays(metrics, start)
+
+		switch service.Status {
+		case models.StatusOnline:
+			checked++
+		case models.StatusOffline:
+			checked++
+			offline++
+		}
+
+		names[service.ID] = public.Name
+		view.Services = append(view.Services, public)
+	}
+
+	switch {
+	case offline == 0:
+		view.Status = models.StatusPageOperational
+	case offline == checked:
+		view.Status = models.StatusPageMajorOutage
+	default:
+		view.Status = models.StatusPagePartialOutage
+	}
+
+	// Only incidents affecting a service on the page, listed by the names the page uses
+	// Stored titles aren't shown: they can name services (e.g. automatic "<service> is down")
+	incidents, err := s.incidentRepo.GetByUserID(ctx, page.UserID, "active", maxStatusPageIncidents)
+	if err != nil {
+		return nil, err
+	}
+	for _, incident := range incidents {
+		var affected []string
+		for _, service := range incident.Services {
+			if name, ok := names[service.ServiceID]; ok {
+				affected = append(affected, name)
+			}
+		}
+		if len(affected) == 0 {
+			continue
+		}
+
+		view.Incidents = append(view.Incidents, models.PublicStatusIncident{
+			Title:          publicIncidentTitle(affected),
+			Status:         incident.Status,
+			StartedAt:      incident.StartedAt,
+			AcknowledgedAt: incident.AcknowledgedAt,
+			Services:       affected,
+		})
+	}
+
+	announcements, err := s.repo.GetActiveAnnouncements(ctx, page.ID, now)
+	if err != nil {
+		return nil, err
+	}
+	for _, a := range announcements {
+		view.Announcements = append(view.Announcements, models.PublicStatusAnnouncement{
+			Title:    a.Title,
+			Body:     a.Body,
+			Severity: a.Severity,
+			StartsAt: a.StartsAt,
+			EndsAt:   a.EndsAt,
+		})
+	}
+
+	return view, nil
+}
+
+// publicIncidentTitle describes an incident by the page's names for the services it affects
+func publicIncidentTitle(affected []string) string {
+	if len(affected) == 1 {
+		return affected[0] + " is down"
+	}
+	return strings.Join(affected[:len(affected)-1], ", ") + " and " + affected[len(affected)-1] + " are down"
+}
+
+// statusPageDays turns daily metrics into StatusPageDays bars starting at start, leaving days
+// without check results empty, along with the uptime over all of them
+func statusPageDays(metrics *MetricsResponse, start time.Time) (*float64, []models.StatusPageDay) {
+	byDate := make(map[string]MetricDataPoint, len(metrics.DataPoints))
+	for _, point := range metrics.DataPoints {
+		byDate[point.Timestamp.Format(time.DateOnly)] = point
+	}
+
+	days := make([]models.StatusPageDay, StatusPageDays)
+	for i := range days {
+		date := start.AddDate(0, 0, i).Format(time.DateOnly)
+		days[i].Date = date
+		if point, ok := byDate[date]; ok && point.CoveragePercentage > 0 {
+			uptime := point.UptimePercentage
+			days[i].UptimePercentage = &uptime
+		}
+	}
+
+	if metrics.CoveragePercentage == 0 {
+		return nil, days
+	}
+	uptime := metrics.UptimePercentage
+	return &uptime, days
+}
+
+func (s *StatusPageService) getOwned(ctx context.Context, userID, id string) (*models.StatusPage, error) {
+	page, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if page.UserID != userID {
+		return nil, sql.ErrNoRows
+	}
+	return page, nil
+}
+
+// forget drops the cached view of a page after it changed
+func (s *StatusPageService) forget(id string) {
+	s.mu.Lock()
+	delete(s.cache, id)
+	s.mu.Unlock()
+}
+
+// pageFromRequest validates a request and builds the page it describes; existing is the page
+// being updated (nil on create)
+func (s *StatusPageService) pageFromRequest(ctx context.Context, userID string, existing *models.StatusPage, req *models.StatusPageRequest) (*models.StatusPage, error) {
+	slug := strings.ToLower(strings.TrimSpace(req.Slug))
+	if !statusPageSlugPattern.MatchString(slug) {
+		return nil, fmt.Errorf("%w: slug must be 3 to 64 lowercase letters, digits or dashes, not starting or ending with a dash", ErrInvalidStatusPage)
+	}
+	taken, err := s.repo.GetBySlug(ctx, slug)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+	if taken != nil && (existing == nil || taken.ID != existing.ID) {
+		return nil, fmt.Errorf("%w: slug %q is already in use", ErrInvalidStatusPage, slug)
+	}
+
+	title := strings.TrimSpace(req.Title)
+	if title == "" || utf8.RuneCountInString(title) > 255 {
+		return nil, fmt.Errorf("%w: title is required (at most 255 characters)", ErrInvalidStatusPage)
+	}
+
+	description := strings.TrimSpace(req.Description)
+	if utf8.RuneCountInString(description) > maxStatusPageText {
+		return nil, fmt.Errorf("%w: description must be at most %d characters", ErrInvalidStatusPage, maxStatusPageText)
+	}
+
+	timezone := req.Timezone
+	if timezone == "" {
+		timezone = "UTC"
+	}
+	if _, err := time.LoadLocation(timezone); err != nil {
+		return nil, fmt.Errorf("%w: invalid timezone %q", ErrInvalidStatusPage, timezone)
+	}
+
+	// Services in the requested order; every service must belong to the user
+	entries := []models.StatusPageEntry{}
+	seen := make(map[string]bool)
+	for _, entry := range req.Services {
+		if entry.ServiceID == "" || seen[entry.ServiceID] {
+			continue
+		}
+		seen[entry.ServiceID] = true
+
+		if entry.DisplayName != nil {
+			name := strings.TrimSpace(*entry.DisplayName)
+			if utf8.RuneCountInString(name) > 255 {
+				return nil, fmt.Errorf("%w: display_name must be at most 255 characters", ErrInvalidStatusPage)
+			}
+			entry.DisplayName = &name
+			if name == "" {
+				entry.DisplayName = nil
+			}
+		}
+		entries = append(entries, entry)
+	}
+	if len(entries) == 0 || len(entries) > maxStatusPageServices {
+		return nil, fmt.Errorf("%w: services must list 1 to %d services", ErrInvalidStatusPage, maxStatusPageServices)
+	}
+	for _, entry := range entries {
+		service, err := s.serviceRepo.GetByID(ctx, entry.ServiceID)
+		if err != nil || service == nil || service.UserID != userID {
+			return nil, fmt.Errorf("%w: unknown service %q", ErrInvalidStatusPage, entry.ServiceID)
+		}
+	}
+
+	page := &models.StatusPage{
+		UserID:      userID,
+		Slug:        slug,
+		Title:       title,
+		Description: description,
+		Timezone:    timezone,
+		Services:    entries,
+	}
+
+	switch {
+	case req.Password == nil:
+		if existing != nil {
+			page.PasswordHash = existing.PasswordHash
+		}
+	case *req.Password == "":
+	default:
+		if len(*req.Password) < minStatusPagePassword || len(*req.Password) > maxStatusPagePassword {
+			return nil, fmt.Errorf("%w: password must be %d to %d bytes", ErrInvalidStatusPage, minStatusPagePassword, maxStatusPagePassword)
+		}
+		hash, err := bcrypt.GenerateFromPassword([]byte(*req.Password), bcrypt.DefaultCost)
+		if err != nil {
+			return nil, fmt.Errorf("failed to hash password: %w", err)
+		}
+		encoded := string(hash)
+		page.PasswordHash = &encoded
+	}
+	page.HasPassword = page.PasswordHash != nil
+
+	return page, nil
+}
+
+// announcementFromRequest validates a request and builds the announcement it describes
+func announcementFromRequest(req *models.AnnouncementRequest, now time.Time) (*models.StatusPageAnnouncement, error) {
+	title := strings.TrimSpace(req.Title)
+	if title == "" || utf8.RuneCountInString(title) > 255 {
+		return nil, fmt.Errorf("%w: title is required (at most 255 characters)", ErrInvalidAnnouncement)
+	}
+
+	body := strings.TrimSpace(req.Body)
+	if utf8.RuneCountInString(body) > maxStatusPageText {
+		return nil, fmt.Errorf("%w: body must be at most %d characters", ErrInvalidAnnouncement, maxStatusPageText)
+	}
+
+	severity := req.Severity
+	switch severity {
+	case "":
+		severity = models.AnnouncementSeverityInfo
+	case models.AnnouncementSeverityInfo, models.AnnouncementSeverityMaintenance, models.AnnouncementSeverityWarning:
+	default:
+		return nil, fmt.Errorf("%w: severity must be one of info, maintenance, warning", ErrInvalidAnnouncement)
+	}
+
+	startsAt := now
+	if req.StartsAt != nil {
+		startsAt = *req.StartsAt
+	}
+	if req.EndsAt != nil && !req.EndsAt.After(startsAt) {
+		return nil, fmt.Errorf("%w: ends_at must be after starts_at", ErrInvalidAnnouncement)
+	}
+
+	return &models.StatusPageAnnouncement{
+		Title:    title,
+		Body:     body,
+		Severity: severity,
+		StartsAt: startsAt,
+		EndsAt:   req.EndsAt,
+	}, nil
+}
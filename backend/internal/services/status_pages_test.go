@@ -0,0 +1,288 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nimbus/backend/internal/models"
+	"github.com/nimbus/backend/internal/repository"
+)
+
+// newTestStatusPageService returns a status page service on a fake clock
+// user-1 has test-service-1 (online) and test-service-2 (offline), user-2 has foreign-service
+func newTestStatusPageService(t *testing.T, start time.Time) (*StatusPageService, *alertClock, *sql.DB) {
+	t.Helper()
+	db := setupMetricsTestDB(t)
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+	createIncidentTables(t, db)
+
+	_, err := db.Exec(`
+		CREATE TABLE status_pages (
+			id TEXT PRIMARY KEY DEFAULT (lower(hex(randomblob(16)))),
+			user_id TEXT NOT NULL,
+			slug TEXT NOT NULL UNIQUE,
+			title TEXT NOT NULL,
+			description TEXT NOT NULL DEFAULT '',
+			timezone TEXT NOT NULL DEFAULT 'UTC',
+			password_hash TEXT,
+			created_at TIMESTAMP NOT NULL,
+			updated_at TIMESTAMP NOT NULL
+		);
+
+		CREATE TABLE status_page_services (
+			status_page_id TEXT NOT NULL,
+			service_id TEXT NOT NULL,
+			position INTEGER NOT NULL DEFAULT 0,
+			display_name TEXT,
+			hide_url BOOLEAN NOT NULL DEFAULT 0,
+			PRIMARY KEY (status_page_id, service_id)
+		);
+
+		CREATE TABLE status_page_announcements (
+			id TEXT PRIMARY KEY DEFAULT (lower(hex(randomblob(16)))),
+			status_page_id TEXT NOT NULL,
+			title TEXT NOT NULL,
+			body TEXT NOT NULL DEFAULT '',
+			severity TEXT NOT NULL DEFAULT 'info',
+			starts_at TIMESTAMP NOT NULL,
+			ends_at TIMESTAMP,
+			created_at TIMESTAMP NOT NULL,
+			updated_at TIMESTAMP NOT NULL
+		);
+	`)
+	if err != nil {
+		t.Fatalf("Failed to create status page tables: %v", err)
+	}
+
+	clock := &alertClock{now: start}
+	s := NewStatusPageService(
+		repository.NewStatusPageRepository(db),
+		repository.NewServiceRepository(db),
+		repository.NewIncidentRepository(db),
+		NewMetricsService(repository.NewStatusLogRepository(db), repository.NewServiceRepository(db)),
+	)
+	s.now = clock.Now
+	return s, clock, db
+}
+
+func TestStatusPageService_CreateValidation(t *testing.T) {
+	s, _, _ := newTestStatusPageService(t, time.Now())
+	ctx := context.Background()
+
+	valid := func() *models.StatusPageRequest {
+		return &models.StatusPageRequest{
+			Slug:     "Family",
+			Title:    "Family services",
+			Services: []models.StatusPageEntry{{ServiceID: "test-service-1"}, {ServiceID: "test-service-1"}, {ServiceID: "test-service-2", DisplayName: stringPtr("  ")}},
+		}
+	}
+
+	page, err := s.Create(ctx, "user-1", valid())
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if page.Slug != "family" || page.Timezone != "UTC" || page.HasPassword {
+		t.Errorf("Create() = %+v, want slug family, UTC and no password", page)
+	}
+	if len(page.Services) != 2 || page.Services[1].DisplayName != nil {
+		t.Errorf("Services = %+v, want 2 services without a blank display name", page.Services)
+	}
+
+	invalid := map[string]func(*models.StatusPageRequest){
+		"slug in use":       func(r *models.StatusPageRequest) {},
+		"short slug":        func(r *models.StatusPageRequest) { r.Slug = "ab" },
+		"slug with a slash": func(r *models.StatusPageRequest) { r.Slug = "family/plex" },
+		"trailing dash":     func(r *models.StatusPageRequest) { r.Slug = "family-" },
+		"no title":          func(r *models.StatusPageRequest) { r.Slug, r.Title = "other", " " },
+		"no services":       func(r *models.StatusPageRequest) { r.Slug, r.Services = "other", nil },
+		"foreign service": func(r *models.StatusPageRequest) {
+			r.Slug, r.Services = "other", []models.StatusPageEntry{{ServiceID: "foreign-service"}}
+		},
+		"invalid timezone": func(r *models.StatusPageRequest) { r.Slug, r.Timezone = "other", "Mars/Olympus_Mons" },
+		"short password":   func(r *models.StatusPageRequest) { r.Slug, r.Password = "other", stringPtr("plex") },
+	}
+	for name, modify := range invalid {
+		t.Run(name, func(t *testing.T) {
+			req := valid()
+			modify(req)
+			if _, err := s.Create(ctx, "user-1", req); !errors.Is(err, ErrInvalidStatusPage) {
+				t.Errorf("Create() error = %v, want ErrInvalidStatusPage", err)
+			}
+		})
+	}
+
+	// Another user can't update or delete the page
+	if _, err := s.Update(ctx, "user-2", page.ID, valid()); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("Update() by another user error = %v, want sql.ErrNoRows", err)
+	}
+	if err := s.Delete(ctx, "user-2", page.ID); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("Delete() by another user error = %v, want sql.ErrNoRows", err)
+	}
+	if _, err := s.CreateAnnouncement(ctx, "user-2", page.ID, &models.AnnouncementRequest{Title: "Hi"}); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("CreateAnnouncement() by another user error = %v, want sql.ErrNoRows", err)
+	}
+
+	// Updating keeps the slug available to its own page
+	if _, err := s.Update(ctx, "user-1", page.ID, valid()); err != nil {
+		t.Errorf("Update() error = %v", err)
+	}
+}
+
+func TestStatusPageService_Public(t *testing.T) {
+	now := time.Date(2025, 6, 9, 12, 0, 0, 0, time.UTC)
+	s, clock, db := newTestStatusPageService(t, now)
+	ctx := context.Background()
+
+	// test-service-1 has been checked hourly for the last three days; it was down for the whole
+	// day before yesterday
+	statusLogRepo := repository.NewStatusLogRepository(db)
+	start := time.Date(2025, 6, 7, 0, 0, 0, 0, time.UTC)
+	for checkedAt := start; checkedAt.Before(now); checkedAt = checkedAt.Add(time.Hour) {
+		status := models.StatusOnline
+		if checkedAt.Day() == 7 {
+			status = models.StatusOffline
+		}
+		if err := statusLogRepo.Create(ctx, &models.StatusLog{ServiceID: "test-service-1", Status: status, CheckedAt: checkedAt}); err != nil {
+			t.Fatalf("Create() error = %v", err)
+		}
+	}
+
+	page, err := s.Create(ctx, "user-1", &models.StatusPageRequest{
+		Slug:  "family",
+		Title: "Family services",
+		Services: []models.StatusPageEntry{
+			{ServiceID: "test-service-2", DisplayName: stringPtr("Plex"), HideURL: true},
+			{ServiceID: "test-service-1"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	incidentRepo := repository.NewIncidentRepository(db)
+	incidents := []*models.Incident{
+		{UserID: "user-1", Title: "Test Service 2 is down", Status: models.IncidentStatusOpen, StartedAt: now.Add(-time.Hour),
+			Services: []models.IncidentService{{ServiceID: "test-service-2", AddedAt: now.Add(-time.Hour)}}},
+		{UserID: "user-1", Title: "Private outage", Status: models.IncidentStatusOpen, StartedAt: now.Add(-time.Hour)},
+	}
+	for _, incident := range incidents {
+		incident.CreatedAt, incident.UpdatedAt = incident.StartedAt, incident.StartedAt
+		if err := incidentRepo.Create(ctx, incident); err != nil {
+			t.Fatalf("Create() error = %v", err)
+		}
+	}
+
+	announcements := []*models.AnnouncementRequest{
+		{Title: "Maintenance tonight", Severity: models.AnnouncementSeverityMaintenance},
+		{Title: "Upcoming", StartsAt: ptrTime(now.Add(24 * time.Hour))},
+		{Title: "Over", StartsAt: ptrTime(now.Add(-48 * time.Hour)), EndsAt: ptrTime(now.Add(-24 * time.Hour))},
+	}
+	for _, req := range announcements {
+		if _, err := s.CreateAnnouncement(ctx, "user-1", page.ID, req); err != nil {
+			t.Fatalf("CreateAnnouncement() error = %v", err)
+		}
+	}
+	if _, err := s.CreateAnnouncement(ctx, "user-1", page.ID, &models.AnnouncementRequest{Title: "Backwards", EndsAt: ptrTime(now.Add(-time.Hour))}); !errors.Is(err, ErrInvalidAnnouncement) {
+		t.Errorf("CreateAnnouncement() ending before it starts error = %v, want ErrInvalidAnnouncement", err)
+	}
+
+	view, err := s.Public(ctx, "Family", "")
+	if err != nil {
+		t.Fatalf("Public() error = %v", err)
+	}
+
+	if view.Status != models.StatusPagePartialOutage || view.PasswordProtected {
+		t.Errorf("Public() = %+v, want a partial outage without password", view)
+	}
+	if len(view.Services) != 2 {
+		t.Fatalf("Services = %+v, want 2", view.Services)
+	}
+	plex, service1 := view.Services[0], view.Services[1]
+	if plex.Name != "Plex" || plex.URL != "" || plex.Status != models.StatusOffline {
+		t.Errorf("Services[0] = %+v, want Plex without URL", plex)
+	}
+	if service1.Name != "Test Service 1" || service1.URL != "http://example.com" {
+		t.Errorf("Services[1] = %+v, want the service's own name and URL", service1)
+	}
+
+	// 90 bars ending today; only the last three days have data
+	days := service1.Days
+	if len(days) != StatusPageDays || days[0].Date != "2025-03-12" || days[StatusPageDays-1].Date != "2025-06-09" {
+		t.Fatalf("Days = %d from %s, want %d ending 2025-06-09", len(days), days[0].Date, StatusPageDays)
+	}
+	if days[StatusPageDays-4].UptimePercentage != nil || plex.Days[StatusPageDays-1].UptimePercentage != nil {
+		t.Errorf("Days without results have uptime, want nil")
+	}
+	if up := days[StatusPageDays-3].UptimePercentage; up == nil || *up != 0 {
+		t.Errorf("Uptime on 2025-06-07 = %v, want 0", up)
+	}
+	if up := days[StatusPageDays-1].UptimePercentage; up == nil || *up != 100 {
+		t.Errorf("Uptime on 2025-06-09 = %v, want 100", up)
+	}
+	if service1.UptimePercentage == nil || *service1.UptimePercentage < 50 || *service1.UptimePercentage > 70 {
+		t.Errorf("UptimePercentage = %v, want about 60", service1.UptimePercentage)
+	}
+
+	// Incidents without a service on the page stay private
+	if len(view.Incidents) != 1 || view.Incidents[0].Title != "Plex is down" || len(view.Incidents[0].Services) != 1 || view.Incidents[0].Services[0] != "Plex" {
+		t.Errorf("Incidents = %+v, want only Plex is down, naming Plex", view.Incidents)
+	}
+	// Renamed services are never shown by their real name
+	body, err := json.Marshal(view)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	if strings.Contains(string(body), "Test Service 2") {
+		t.Errorf("Public() = %s, want no mention of the renamed service's real name", body)
+	}
+	if len(view.Announcements) != 1 || view.Announcements[0].Title != "Maintenance tonight" {
+		t.Errorf("Announcements = %+v, want only the active one", view.Announcements)
+	}
+
+	// Cached until the page changes
+	if again, err := s.Public(ctx, "family", ""); err != nil || again != view {
+		t.Errorf("Public() again = %p, %v, want the cached view", again, err)
+	}
+	clock.Advance(time.Second)
+	if _, err := s.Update(ctx, "user-1", page.ID, &models.StatusPageRequest{
+		Slug:     "family",
+		Title:    "Family services",
+		Password: stringPtr("correct horse"),
+		Services: []models.StatusPageEntry{{ServiceID: "test-service-1"}},
+	}); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+
+	for _, password := range []string{"", "wrong password"} {
+		if _, err := s.Public(ctx, "family", password); !errors.Is(err, ErrStatusPagePassword) {
+			t.Errorf("Public(%q) error = %v, want ErrStatusPagePassword", password, err)
+		}
+	}
+	view, err = s.Public(ctx, "family", "correct horse")
+	if err != nil {
+		t.Fatalf("Public() with password error = %v", err)
+	}
+	if !view.PasswordProtected || view.Status != models.StatusPageOperational || len(view.Services) != 1 || len(view.Incidents) != 0 {
+		t.Errorf("Public() = %+v, want the updated, protected page", view)
+	}
+
+	// Updates without a password keep it; an empty one removes it
+	updated, err := s.Update(ctx, "user-1", page.ID, &models.StatusPageRequest{Slug: "family", Title: "Family", Services: []models.StatusPageEntry{{ServiceID: "test-service-1"}}})
+	if err != nil || !updated.HasPassword {
+		t.Errorf("Update() without password = %+v, %v, want the password kept", updated, err)
+	}
+	updated, err = s.Update(ctx, "user-1", page.ID, &models.StatusPageRequest{Slug: "family", Title: "Family", Password: stringPtr(""), Services: []models.StatusPageEntry{{ServiceID: "test-service-1"}}})
+	if err != nil || updated.HasPassword {
+		t.Errorf("Update() with an empty password = %+v, %v, want the password removed", updated, err)
+	}
+
+	if _, err := s.Public(ctx, "unknown", ""); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("Public() of an unknown slug error = %v, want sql.ErrNoRows", err)
+	}
+}
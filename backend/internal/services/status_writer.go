@@ -0,0 +1,228 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/nimbus/backend/internal/models"
+	"github.com/nimbus/backend/internal/repository"
+)
+
+// Default status writer settings
+const (
+	DefaultStatusWriterQueueSize     = 1000
+	DefaultStatusWriterBatchSize     = 100
+	DefaultStatusWriterFlushInterval = time.Second
+
+	statusWriterFlushTimeout = 10 * time.Second
+)
+
+// statusResult is one check result waiting to be written
+type statusResult struct {
+	update repository.ServiceStatusUpdate
+	logs   []*models.StatusLog
+}
+
+// StatusWriterStats is a snapshot of the status writer's counters
+type StatusWriterStats struct {
+	QueueLength   int
+	QueueCapacity int
+	Overflows     int64 // Results that didn't fit in the queue and were written synchronously
+	Flushes       int64
+	FailedFlushes int64
+	WrittenLogs   int64
+}
+
+// StatusWriter buffers health check results in memory and writes them in batches
+// Service statuses are updated in one statement and status logs with multi-row inserts,
+// so a check cycle no longer needs two database round trips per service
+type StatusWriter struct {
+	serviceRepo   repository.StatusBatchUpdater
+	statusLogRepo *repository.StatusLogRepository
+	batchSize     int
+	flushInterval time.Duration
+
+	queue    chan statusResult
+	mu       sync.RWMutex // Guards stopped so nothing is enqueued after the final drain
+	stopped  bool
+	stopChan chan struct{}
+	done     chan struct{}
+	stopOnce sync.Once
+
+	overflows     atomic.Int64
+	flushes       atomic.Int64
+	failedFlushes atomic.Int64
+	writtenLogs   atomic.Int64
+}
+
+// NewStatusWriter creates a status writer
+// A batch is flushed when it reaches batchSize results or every flushInterval, whichever comes first
+func NewStatusWriter(serviceRepo repository.StatusBatchUpdater, statusLogRepo *repository.StatusLogRepository, queueSize, batchSize int, flushInterval time.Duration) *StatusWriter {
+	if queueSize <= 0 {
+		queueSize = DefaultStatusWriterQueueSize
+	}
+	if batchSize <= 0 {
+		batchSize = DefaultStatusWriterBatchSize
+	}
+	if flushInterval <= 0 {
+		flushInterval = DefaultStatusWriterFlushInterval
+	}
+
+	return &StatusWriter{
+		serviceRepo:   serviceRepo,
+		statusLogRepo: statusLogRepo,
+		batchSize:     batchSize,
+		flushInterval: flushInterval,
+		queue:         make(chan statusResult, queueSize),
+		stopChan:      make(chan struct{}),
+		done:          make(chan struct{}),
+	}
+}
+
+// Start begins the flush loop
+func (w *StatusWriter) Start() {
+	go w.run()
+	fmt.Printf("Status writer started (queue: %d, batch: %d, interval: %v)\n", cap(w.queue), w.batchSize, w.flushInterval)
+}
+
+// Stop stops accepting results, writes everything still queued and waits for the final flush
+func (w *StatusWriter) Stop() {
+	w.stopOnce.Do(func() {
+		fmt.Println("Stopping status writer...")
+
+		w.mu.Lock()
+		w.stopped = true
+		w.mu.Unlock()
+
+		close(w.stopChan)
+		<-w.done
+		fmt.Println("Status writer stopped")
+	})
+}
+
+// Enqueue queues a check result without blocking
+// Returns false if the writer is stopped or the queue is full; the caller must then write the result itself
+func (w *StatusWriter) Enqueue(update repository.ServiceStatusUpdate, logs []*models.StatusLog) bool {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	if w.stopped {
+		return false
+	}
+
+	select {
+	case w.queue <- statusResult{update: update, logs: logs}:
+		return true
+	default:
+		w.overflows.Add(1)
+		return false
+	}
+}
+
+// Stats returns the writer's current queue length and counters
+func (w *StatusWriter) Stats() StatusWriterStats {
+	return StatusWriterStats{
+		QueueLength:   len(w.queue),
+		QueueCapacity: cap(w.queue),
+		Overflows:     w.overflows.Load(),
+		Flushes:       w.flushes.Load(),
+		FailedFlushes: w.failedFlushes.Load(),
+		WrittenLogs:   w.writtenLogs.Load(),
+	}
+}
+
+// run collects results into batches and flushes them by size or on the interval
+func (w *StatusWriter) run() {
+	defer close(w.done)
+
+	ticker := time.NewTicker(w.flushInterval)
+	defer ticker.Stop()
+
+	batch := make([]statusResult, 0, w.batchSize)
+	add := func(result statusResult) {
+		batch = append(batch, result)
+		if len(batch) >= w.batchSize {
+			w.flush(batch)
+			batch = batch[:0]
+		}
+	}
+
+	for {
+		select {
+		case result := <-w.queue:
+			add(result)
+		case <-ticker.C:
+			if len(batch) > 0 {
+				w.flush(batch)
+				batch = batch[:0]
+			}
+		case <-w.stopChan:
+			// Enqueue refuses new results once stopped, so the queue only shrinks from here
+			for {
+				select {
+				case result := <-w.queue:
+					add(result)
+				default:
+					if len(batch) > 0 {
+						w.flush(batch)
+					}
+					return
+				}
+			}
+		}
+	}
+}
+
+// flush writes a batch of results
+// Only the latest status per service is applied; every status log is kept
+func (w *StatusWriter) flush(batch []statusResult) {
+	ctx, cancel := context.WithTimeout(context.Background(), statusWriterFlushTimeout)
+	defer cancel()
+
+	latest := make(map[string]int, len(batch))
+	updates := make([]repository.ServiceStatusUpdate, 0, len(batch))
+	var logs []*models.StatusLog
+
+	for _, result := range batch {
+		if i, ok := latest[result.update.ID]; ok {
+			updates[i] = result.update
+		} else {
+			latest[result.update.ID] = len(updates)
+			updates = append(updates, result.update)
+		}
+		logs = append(logs, result.logs...)
+	}
+
+	w.flushes.Add(1)
+	failed := false
+
+	if err := w.serviceRepo.UpdateStatusesBatch(ctx, updates); err != nil {
+		fmt.Printf("Failed to update statuses for %d services: %v\n", len(updates), err)
+		failed = true
+	}
+
+	if w.statusLogRepo != nil && len(logs) > 0 {
+		if err := w.statusLogRepo.CreateBatch(ctx, logs); err != nil {
+			fmt.Printf("Failed to write %d status logs in batch, retrying individually: %v\n", len(logs), err)
+			failed = true
+
+			// One bad row shouldn't lose the whole batch
+			for _, statusLog := range logs {
+				if err := w.statusLogRepo.Create(ctx, statusLog); err != nil {
+					fmt.Printf("Failed to create status log for service %s: %v\n", statusLog.ServiceID, err)
+					continue
+				}
+				w.writtenLogs.Add(1)
+			}
+		} else {
+			w.writtenLogs.Add(int64(len(logs)))
+		}
+	}
+
+	if failed {
+		w.failedFlushes.Add(1)
+	}
+}
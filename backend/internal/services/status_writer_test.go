@@ -0,0 +1,202 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nimbus/backend/internal/models"
+	"github.com/nimbus/backend/internal/repository"
+)
+
+// setupStatusWriterTest returns repositories on a single-connection database
+// (each connection to :memory: would otherwise be a separate, empty database)
+func setupStatusWriterTest(t *testing.T) (*sql.DB, *repository.ServiceRepository, *repository.StatusLogRepository) {
+	db := setupMetricsTestDB(t)
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+	return db, repository.NewServiceRepository(db), repository.NewStatusLogRepository(db)
+}
+
+// waitForFlushes polls until the writer has flushed at least n batches
+func waitForFlushes(t *testing.T, w *StatusWriter, n int64) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for w.Stats().Flushes < n {
+		if time.Now().After(deadline) {
+			t.Fatalf("Timed out waiting for %d flushes, got %d", n, w.Stats().Flushes)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func testStatusResult(serviceID, status string, responseTime int) (repository.ServiceStatusUpdate, []*models.StatusLog) {
+	return repository.ServiceStatusUpdate{ID: serviceID, Status: status, ResponseTime: &responseTime},
+		[]*models.StatusLog{{ServiceID: serviceID, Status: status, ResponseTime: &responseTime, CheckedAt: time.Now()}}
+}
+
+func TestStatusWriter_FlushBySize(t *testing.T) {
+	_, serviceRepo, statusLogRepo := setupStatusWriterTest(t)
+
+	// The interval never fires during the test, so only the batch size can trigger the flush
+	writer := NewStatusWriter(serviceRepo, statusLogRepo, 10, 2, time.Hour)
+	writer.Start()
+	defer writer.Stop()
+
+	writer.Enqueue(testStatusResult("test-service-1", models.StatusOffline, 100))
+	writer.Enqueue(testStatusResult("test-service-2", models.StatusOnline, 200))
+	waitForFlushes(t, writer, 1)
+
+	service, err := serviceRepo.GetByID(context.Background(), "test-service-2")
+	if err != nil {
+		t.Fatalf("Failed to get service: %v", err)
+	}
+	if service.Status != models.StatusOnline || service.ResponseTime == nil || *service.ResponseTime != 200 {
+		t.Errorf("Expected service to be online with 200ms, got %s %v", service.Status, service.ResponseTime)
+	}
+
+	if stats := writer.Stats(); stats.WrittenLogs != 2 || stats.FailedFlushes != 0 {
+		t.Errorf("Expected 2 logs written without failures, got %+v", stats)
+	}
+}
+
+func TestStatusWriter_FlushByInterval(t *testing.T) {
+	_, serviceRepo, statusLogRepo := setupStatusWriterTest(t)
+
+	writer := NewStatusWriter(serviceRepo, statusLogRepo, 10, 100, 20*time.Millisecond)
+	writer.Start()
+	defer writer.Stop()
+
+	writer.Enqueue(testStatusResult("test-service-1", models.StatusOffline, 100))
+	waitForFlushes(t, writer, 1)
+
+	logs, err := statusLogRepo.GetLatestByServiceID(context.Background(), "test-service-1", 10)
+	if err != nil {
+		t.Fatalf("Failed to get status logs: %v", err)
+	}
+	if len(logs) != 1 || logs[0].Status != models.StatusOffline {
+		t.Errorf("Expected one offline status log, got %d", len(logs))
+	}
+}
+
+func TestStatusWriter_DrainOnStop(t *testing.T) {
+	_, serviceRepo, statusLogRepo := setupStatusWriterTest(t)
+
+	writer := NewStatusWriter(serviceRepo, statusLogRepo, 10, 100, time.Hour)
+	writer.Start()
+
+	// Several results for the same service: every log is kept, the last status wins
+	writer.Enqueue(testStatusResult("test-service-1", models.StatusOffline, 100))
+	writer.Enqueue(testStatusResult("test-service-1", models.StatusOffline, 150))
+	writer.Enqueue(testStatusResult("test-service-1", models.StatusOnline, 50))
+	writer.Stop()
+
+	service, err := serviceRepo.GetByID(context.Background(), "test-service-1")
+	if err != nil {
+		t.Fatalf("Failed to get service: %v", err)
+	}
+	if service.Status != models.StatusOnline || service.ResponseTime == nil || *service.ResponseTime != 50 {
+		t.Errorf("Expected the latest result to be applied, got %s %v", service.Status, service.ResponseTime)
+	}
+
+	logs, err := statusLogRepo.GetLatestByServiceID(context.Background(), "test-service-1", 10)
+	if err != nil {
+		t.Fatalf("Failed to get status logs: %v", err)
+	}
+	if len(logs) != 3 {
+		t.Errorf("Expected all 3 queued logs to be written on stop, got %d", len(logs))
+	}
+
+	if writer.Enqueue(testStatusResult("test-service-1", models.StatusOnline, 50)) {
+		t.Error("Expected a stopped writer to refuse results")
+	}
+}
+
+func TestStatusWriter_Overflow(t *testing.T) {
+	_, serviceRepo, statusLogRepo := setupStatusWriterTest(t)
+
+	// Not started yet, so nothing drains the queue
+	writer := NewStatusWriter(serviceRepo, statusLogRepo, 1, 100, time.Hour)
+
+	if !writer.Enqueue(testStatusResult("test-service-1", models.StatusOnline, 50)) {
+		t.Fatal("Expected the first result to be queued")
+	}
+	if writer.Enqueue(testStatusResult("test-service-2", models.StatusOnline, 50)) {
+		t.Fatal("Expected a full queue to refuse the result")
+	}
+
+	stats := writer.Stats()
+	if stats.Overflows != 1 || stats.QueueLength != 1 || stats.QueueCapacity != 1 {
+		t.Errorf("Unexpected stats: %+v", stats)
+	}
+
+	output := FormatPrometheusMetrics(&PrometheusMetrics{StatusWriter: &stats})
+	if !strings.Contains(output, "nimbus_status_writer_queue_overflow_total 1\n") {
+		t.Errorf("Expected overflow counter in Prometheus output, got:\n%s", output)
+	}
+	if strings.Contains(FormatPrometheusMetrics(&PrometheusMetrics{}), "nimbus_status_writer") {
+		t.Error("Expected no status writer metrics without a writer")
+	}
+
+	writer.Start()
+	writer.Stop()
+}
+
+func TestHealthCheckService_BufferedStatusWrites(t *testing.T) {
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer testServer.Close()
+
+	_, serviceRepo, statusLogRepo := setupStatusWriterTest(t)
+
+	writer := NewStatusWriter(serviceRepo, statusLogRepo, 10, 100, time.Hour)
+	writer.Start()
+
+	healthService := NewHealthCheckService(serviceRepo, statusLogRepo, nil, 5*time.Second, nil, nil)
+	healthService.SetStatusWriter(writer)
+
+	service, err := serviceRepo.GetByID(context.Background(), "test-service-1")
+	if err != nil {
+		t.Fatalf("Failed to get service: %v", err)
+	}
+	service.URL = testServer.URL
+
+	if err := healthService.CheckService(WithBufferedStatusWrites(context.Background()), service); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// Queued, not written yet
+	stored, _ := serviceRepo.GetByID(context.Background(), service.ID)
+	if stored.Status != models.StatusOnline {
+		t.Errorf("Expected buffered result to be pending, got status %q", stored.Status)
+	}
+
+	writer.Stop()
+
+	stored, _ = serviceRepo.GetByID(context.Background(), service.ID)
+	if stored.Status != models.StatusOffline {
+		t.Errorf("Expected buffered result to be written on stop, got status %q", stored.Status)
+	}
+
+	logs, err := statusLogRepo.GetLatestByServiceID(context.Background(), service.ID, 10)
+	if err != nil || len(logs) != 1 {
+		t.Fatalf("Expected 1 status log, got %d (err: %v)", len(logs), err)
+	}
+	if logs[0].ErrorMessage == nil || *logs[0].ErrorMessage != "HTTP 503" {
+		t.Errorf("Expected error message to be kept, got %q", derefString(logs[0].ErrorMessage))
+	}
+
+	// Without the context flag (manual checks) the result is written before CheckService returns
+	service.URL = "http://127.0.0.1:1/"
+	if err := healthService.CheckService(context.Background(), service); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if logs, _ := statusLogRepo.GetLatestByServiceID(context.Background(), service.ID, 10); len(logs) != 2 {
+		t.Errorf("Expected synchronous check to write its log immediately, got %d logs", len(logs))
+	}
+}
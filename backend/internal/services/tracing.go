@@ -0,0 +1,232 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Attribute is a span, event or metric attribute (string, bool, int, int64 or float64 value)
+type Attribute struct {
+	Key   string
+	Value any
+}
+
+// SpanKind is the OTLP span kind
+type SpanKind int
+
+// Span kinds
+const (
+	SpanKindInternal SpanKind = 1
+	SpanKindServer   SpanKind = 2
+	SpanKindClient   SpanKind = 3
+)
+
+// SpanStatusCode is the OTLP span status code
+type SpanStatusCode int
+
+// Span status codes
+const (
+	SpanStatusUnset SpanStatusCode = 0
+	SpanStatusOK    SpanStatusCode = 1
+	SpanStatusError SpanStatusCode = 2
+)
+
+// SpanEvent is a timestamped annotation on a span
+type SpanEvent struct {
+	Name       string
+	Time       time.Time
+	Attributes []Attribute
+}
+
+// SpanContext identifies a span across process boundaries (W3C trace context)
+type SpanContext struct {
+	TraceID [16]byte
+	SpanID  [8]byte
+	Sampled bool
+}
+
+// IsValid reports whether the trace and span IDs are set
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceID != [16]byte{} && sc.SpanID != [8]byte{}
+}
+
+// Traceparent formats the span context as a W3C traceparent header
+func (sc SpanContext) Traceparent() string {
+	flags := "00"
+	if sc.Sampled {
+		flags = "01"
+	}
+	return "00-" + hex.EncodeToString(sc.TraceID[:]) + "-" + hex.EncodeToString(sc.SpanID[:]) + "-" + flags
+}
+
+// ParseTraceparent parses a W3C traceparent header
+// Unknown future versions are accepted as long as the version 00 fields parse
+func ParseTraceparent(header string) (SpanContext, bool) {
+	var sc SpanContext
+	parts := strings.Split(strings.TrimSpace(header), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" || (parts[0] == "00" && len(parts) != 4) {
+		return sc, false
+	}
+	if len(parts[1]) != 32 || len(parts[2]) != 16 || len(parts[3]) != 2 {
+		return sc, false
+	}
+	if _, err := hex.Decode(sc.TraceID[:], []byte(parts[1])); err != nil {
+		return sc, false
+	}
+	if _, err := hex.Decode(sc.SpanID[:], []byte(parts[2])); err != nil {
+		return sc, false
+	}
+	flags, err := hex.DecodeString(parts[3])
+	if err != nil {
+		return sc, false
+	}
+	sc.Sampled = flags[0]&1 == 1
+	return sc, sc.IsValid()
+}
+
+// Span is one traced operation
+// A nil *Span (tracing disabled) accepts every call and records nothing
+type Span struct {
+	TraceID       [16]byte
+	SpanID        [8]byte
+	ParentSpanID  [8]byte // Zero for root spans
+	Name          string
+	Kind          SpanKind
+	Start         time.Time
+	End           time.Time
+	Attributes    []Attribute
+	Events        []SpanEvent
+	StatusCode    SpanStatusCode
+	StatusMessage string
+
+	mu     sync.Mutex
+	tracer *Tracer
+	ended  bool
+}
+
+// Context returns the span's propagation context
+func (s *Span) Context() SpanContext {
+	if s == nil {
+		return SpanContext{}
+	}
+	return SpanContext{TraceID: s.TraceID, SpanID: s.SpanID, Sampled: true}
+}
+
+// SetName renames the span (server spans are named after the matched route)
+func (s *Span) SetName(name string) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Name = name
+}
+
+// SetAttributes adds attributes to the span
+func (s *Span) SetAttributes(attrs ...Attribute) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Attributes = append(s.Attributes, attrs...)
+}
+
+// AddEvent records an event that happened at the given time
+func (s *Span) AddEvent(name string, at time.Time, attrs ...Attribute) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Events = append(s.Events, SpanEvent{Name: name, Time: at, Attributes: attrs})
+}
+
+// SetStatus sets the span's status
+func (s *Span) SetStatus(code SpanStatusCode, message string) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.StatusCode = code
+	s.StatusMessage = message
+}
+
+// Finish ends the span and hands it to the exporter (only the first call counts)
+func (s *Span) Finish() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+	s.End = time.Now()
+	s.mu.Unlock()
+
+	s.tracer.export(s)
+}
+
+// Tracer creates spans and passes finished ones to an exporter
+// A nil *Tracer (tracing disabled) creates nil spans
+type Tracer struct {
+	export func(*Span)
+}
+
+// NewTracer creates a tracer that passes finished spans to export
+func NewTracer(export func(*Span)) *Tracer {
+	return &Tracer{export: export}
+}
+
+// spanContextKey is the context key of the active span
+type spanContextKey struct{}
+
+// remoteSpanContextKey is the context key of a span context extracted from an incoming request
+type remoteSpanContextKey struct{}
+
+// ContextWithRemoteSpanContext makes sc the parent of spans started from ctx
+func ContextWithRemoteSpanContext(ctx context.Context, sc SpanContext) context.Context {
+	return context.WithValue(ctx, remoteSpanContextKey{}, sc)
+}
+
+// SpanFromContext returns the active span of ctx (nil if none)
+func SpanFromContext(ctx context.Context) *Span {
+	span, _ := ctx.Value(spanContextKey{}).(*Span)
+	return span
+}
+
+// StartSpan starts a span as a child of the active (or remote) span in ctx
+// The returned context carries the new span
+func (t *Tracer) StartSpan(ctx context.Context, name string, kind SpanKind, attrs ...Attribute) (context.Context, *Span) {
+	if t == nil {
+		return ctx, nil
+	}
+
+	span := &Span{
+		Name:       name,
+		Kind:       kind,
+		Start:      time.Now(),
+		Attributes: attrs,
+		tracer:     t,
+	}
+
+	if parent := SpanFromContext(ctx); parent != nil {
+		span.TraceID = parent.TraceID
+		span.ParentSpanID = parent.SpanID
+	} else if remote, ok := ctx.Value(remoteSpanContextKey{}).(SpanContext); ok && remote.IsValid() {
+		span.TraceID = remote.TraceID
+		span.ParentSpanID = remote.SpanID
+	} else {
+		rand.Read(span.TraceID[:])
+	}
+	rand.Read(span.SpanID[:])
+
+	return context.WithValue(ctx, spanContextKey{}, span), span
+}
@@ -0,0 +1,83 @@
+package services
+
+import (
+	"context"
+	"testing"
+)
+
+func TestParseTraceparent(t *testing.T) {
+	tests := []struct {
+		name    string
+		header  string
+		valid   bool
+		sampled bool
+	}{
+		{"Sampled", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", true, true},
+		{"Not sampled", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00", true, false},
+		{"Future version with extra fields", "cc-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra", true, true},
+		{"Version 00 with extra fields", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra", false, false},
+		{"Invalid version ff", "ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", false, false},
+		{"Zero trace ID", "00-00000000000000000000000000000000-00f067aa0ba902b7-01", false, false},
+		{"Zero span ID", "00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01", false, false},
+		{"Short trace ID", "00-4bf92f3577b34da6a3ce929d0e0e47-00f067aa0ba902b7-01", false, false},
+		{"Not hex", "00-4bf92f3577b34da6a3ce929d0e0e47zz-00f067aa0ba902b7-01", false, false},
+		{"Empty", "", false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sc, ok := ParseTraceparent(tt.header)
+			if ok != tt.valid {
+				t.Fatalf("Expected valid = %v, got %v", tt.valid, ok)
+			}
+			if ok && sc.Sampled != tt.sampled {
+				t.Errorf("Expected sampled = %v, got %v", tt.sampled, sc.Sampled)
+			}
+		})
+	}
+
+	sc, _ := ParseTraceparent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	if sc.Traceparent() != "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01" {
+		t.Errorf("Expected the header to round-trip, got %s", sc.Traceparent())
+	}
+}
+
+func TestTracer_StartSpan(t *testing.T) {
+	var finished []*Span
+	tracer := NewTracer(func(span *Span) { finished = append(finished, span) })
+
+	// A request from a traced caller continues its trace
+	remote, _ := ParseTraceparent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	ctx := ContextWithRemoteSpanContext(context.Background(), remote)
+
+	ctx, server := tracer.StartSpan(ctx, "GET /api/v1/services", SpanKindServer)
+	if server.TraceID != remote.TraceID || server.ParentSpanID != remote.SpanID {
+		t.Error("Expected the server span to continue the remote trace")
+	}
+
+	_, child := tracer.StartSpan(ctx, "health_check", SpanKindInternal)
+	if child.TraceID != remote.TraceID || child.ParentSpanID != server.SpanID {
+		t.Error("Expected the child span to be parented to the server span")
+	}
+
+	// A new root span gets its own trace
+	_, root := tracer.StartSpan(context.Background(), "health_check", SpanKindInternal)
+	if root.TraceID == remote.TraceID || root.ParentSpanID != [8]byte{} || !root.Context().IsValid() {
+		t.Error("Expected a new trace for a root span")
+	}
+
+	child.Finish()
+	child.Finish()
+	if len(finished) != 1 || child.End.IsZero() {
+		t.Errorf("Expected a span to be exported once, got %d", len(finished))
+	}
+
+	// Tracing disabled: nil tracer and nil spans are safe to use
+	var disabled *Tracer
+	ctx, span := disabled.StartSpan(context.Background(), "noop", SpanKindInternal)
+	span.SetAttributes(Attribute{"key", "value"})
+	span.Finish()
+	if span != nil || SpanFromContext(ctx) != nil {
+		t.Error("Expected no span without a tracer")
+	}
+}
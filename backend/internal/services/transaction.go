@@ -0,0 +1,325 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nimbus/backend/internal/models"
+	"golang.org/x/net/publicsuffix"
+)
+
+// maxTransactionBodySize limits how much of each step's response body is read
+const maxTransactionBodySize = 1 << 20 // 1 MiB
+
+// templateVarRegex matches {{name}} variable references in step URLs, headers and bodies
+var templateVarRegex = regexp.MustCompile(`\{\{\s*([A-Za-z_][A-Za-z0-9_]*)\s*\}\}`)
+
+// runTransaction executes a multi-step transaction check and returns its status log
+// Steps share a cookie jar and run in order; the check stops at the first failing step
+func (h *HealthCheckService) runTransaction(ctx context.Context, service *models.Service) *models.StatusLog {
+	statusLog := &models.StatusLog{
+		ServiceID:     service.ID,
+		AddressFamily: resolvedFamily("", addressFamilyFromContext(ctx)),
+	}
+
+	jar, err := cookiejar.New(&cookiejar.Options{PublicSuffixList: publicsuffix.List})
+	if err != nil {
+		errorMsg := err.Error()
+		statusLog.Status = models.StatusOffline
+		statusLog.ErrorMessage = &errorMsg
+		return statusLog
+	}
+
+	// Reuse the health check client (timeout, TLS and redirect policy) with a per-check jar
+	client := *h.httpClient
+	client.Jar = jar
+
+	vars := make(map[string]string)
+	start := time.Now()
+
+	for i, step := range service.CheckConfig.Transaction.Steps {
+		stepNum := i + 1
+		timing, err := runTransactionStep(ctx, &client, service.URL, step, vars)
+		timing.Step = stepNum
+		timing.Name = step.Name
+		if err != nil {
+			timing.Error = err.Error()
+		}
+		statusLog.StepTimings = append(statusLog.StepTimings, timing)
+
+		if err != nil {
+			label := fmt.Sprintf("Step %d", stepNum)
+			if step.Name != "" {
+				label = fmt.Sprintf("Step %d (%s)", stepNum, step.Name)
+			}
+			errorMsg := fmt.Sprintf("%s: %v", label, err)
+			responseTime := int(time.Since(start).Milliseconds())
+
+			statusLog.Status = models.StatusOffline
+			statusLog.ErrorMessage = &errorMsg
+			statusLog.ResponseTime = &responseTime
+			statusLog.FailedStep = &stepNum
+			return statusLog
+		}
+	}
+
+	responseTime := int(time.Since(start).Milliseconds())
+	statusLog.Status = models.StatusOnline
+	statusLog.ResponseTime = &responseTime
+	return statusLog
+}
+
+// runTransactionStep performs a single step, checks its assertions and stores extracted variables
+func runTransactionStep(ctx context.Context, client *http.Client, serviceURL string, step models.TransactionStep, vars map[string]string) (models.StepTiming, error) {
+	timing := models.StepTiming{}
+
+	req, err := buildStepRequest(ctx, serviceURL, step, vars)
+	if err != nil {
+		return timing, err
+	}
+
+	start := time.Now()
+	resp, err := client.Do(req)
+	if err != nil {
+		timing.DurationMs = int(time.Since(start).Milliseconds())
+		return timing, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxTransactionBodySize))
+	timing.DurationMs = int(time.Since(start).Milliseconds())
+	timing.StatusCode = resp.StatusCode
+	if err != nil {
+		return timing, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	if err := checkStepAssertions(step.Assert, resp.StatusCode, body); err != nil {
+		return timing, err
+	}
+
+	for _, extract := range step.Extract {
+		value, err := extractStepValue(extract, client.Jar, req.URL, resp, body)
+		if err != nil {
+			return timing, fmt.Errorf("extract %q: %w", extract.Name, err)
+		}
+		vars[extract.Name] = value
+	}
+
+	return timing, nil
+}
+
+// buildStepRequest creates the HTTP request for a step, substituting variables
+// Relative step URLs are resolved against the service URL
+func buildStepRequest(ctx context.Context, serviceURL string, step models.TransactionStep, vars map[string]string) (*http.Request, error) {
+	rawURL, err := substituteVars(step.URL, vars)
+	if err != nil {
+		return nil, err
+	}
+
+	base, err := url.Parse(serviceURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid service URL: %w", err)
+	}
+	ref, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid step URL: %w", err)
+	}
+	target := base.ResolveReference(ref)
+
+	method := strings.ToUpper(step.Method)
+	if method == "" {
+		method = http.MethodGet
+	}
+
+	var body io.Reader
+	if step.Body != "" {
+		rendered, err := substituteVars(step.Body, vars)
+		if err != nil {
+			return nil, err
+		}
+		body = strings.NewReader(rendered)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, target.String(), body)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("User-Agent", "Nimbus-HealthCheck/1.0")
+	for name, value := range step.Headers {
+		rendered, err := substituteVars(value, vars)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set(name, rendered)
+	}
+
+	return req, nil
+}
+
+// substituteVars replaces {{name}} references with extracted values
+// Referencing a variable that hasn't been extracted is an error
+func substituteVars(s string, vars map[string]string) (string, error) {
+	var missing string
+	result := templateVarRegex.ReplaceAllStringFunc(s, func(match string) string {
+		name := templateVarRegex.FindStringSubmatch(match)[1]
+		value, ok := vars[name]
+		if !ok && missing == "" {
+			missing = name
+		}
+		return value
+	})
+
+	if missing != "" {
+		return "", fmt.Errorf("undefined variable %q", missing)
+	}
+	return result, nil
+}
+
+// checkStepAssertions verifies the status code and body of a step response
+// Without an explicit status list, any 2xx or 3xx response is accepted (same as a regular check)
+func checkStepAssertions(assert *models.TransactionAssert, statusCode int, body []byte) error {
+	if assert == nil || len(assert.Status) == 0 {
+		if statusCode < 200 || statusCode >= 400 {
+			return fmt.Errorf("HTTP %d", statusCode)
+		}
+	} else {
+		accepted := false
+		for _, code := range assert.Status {
+			if code == statusCode {
+				accepted = true
+				break
+			}
+		}
+		if !accepted {
+			return fmt.Errorf("expected status %v, got HTTP %d", assert.Status, statusCode)
+		}
+	}
+
+	if assert == nil {
+		return nil
+	}
+
+	if assert.BodyContains != "" && !strings.Contains(string(body), assert.BodyContains) {
+		return fmt.Errorf("response body does not contain %q", assert.BodyContains)
+	}
+
+	if assert.BodyRegex != "" {
+		re, err := regexp.Compile(assert.BodyRegex)
+		if err != nil {
+			return fmt.Errorf("invalid body_regex: %w", err)
+		}
+		if !re.Match(body) {
+			return fmt.Errorf("response body does not match %q", assert.BodyRegex)
+		}
+	}
+
+	return nil
+}
+
+// extractStepValue reads a variable from a step response
+func extractStepValue(extract models.TransactionExtract, jar http.CookieJar, reqURL *url.URL, resp *http.Response, body []byte) (string, error) {
+	switch extract.Source {
+	case models.ExtractSourceCookie:
+		// Prefer cookies set by this response, then fall back to the shared jar
+		for _, cookie := range resp.Cookies() {
+			if cookie.Name == extract.Key {
+				return cookie.Value, nil
+			}
+		}
+		if jar != nil {
+			for _, cookie := range jar.Cookies(reqURL) {
+				if cookie.Name == extract.Key {
+					return cookie.Value, nil
+				}
+			}
+		}
+		return "", fmt.Errorf("cookie %q not found", extract.Key)
+
+	case models.ExtractSourceHeader:
+		value := resp.Header.Get(extract.Key)
+		if value == "" {
+			return "", fmt.Errorf("header %q not found", extract.Key)
+		}
+		return value, nil
+
+	case models.ExtractSourceJSON:
+		var data interface{}
+		if err := json.Unmarshal(body, &data); err != nil {
+			return "", fmt.Errorf("response is not valid JSON: %w", err)
+		}
+		return lookupJSONPath(data, extract.Key)
+
+	case models.ExtractSourceRegex:
+		re, err := regexp.Compile(extract.Key)
+		if err != nil {
+			return "", fmt.Errorf("invalid regex: %w", err)
+		}
+		group := extract.Group
+		if group == 0 {
+			group = 1
+		}
+		match := re.FindSubmatch(body)
+		if match == nil {
+			return "", errors.New("regex did not match")
+		}
+		if group >= len(match) {
+			return "", fmt.Errorf("regex has no group %d", group)
+		}
+		return string(match[group]), nil
+
+	default:
+		return "", fmt.Errorf("unknown source %q", extract.Source)
+	}
+}
+
+// lookupJSONPath resolves a dot-separated path (e.g. "data.items.0.id") in decoded JSON
+func lookupJSONPath(data interface{}, path string) (string, error) {
+	current := data
+	for _, part := range strings.Split(path, ".") {
+		switch node := current.(type) {
+		case map[string]interface{}:
+			value, ok := node[part]
+			if !ok {
+				return "", fmt.Errorf("JSON field %q not found", path)
+			}
+			current = value
+		case []interface{}:
+			index, err := strconv.Atoi(part)
+			if err != nil || index < 0 || index >= len(node) {
+				return "", fmt.Errorf("JSON field %q not found", path)
+			}
+			current = node[index]
+		default:
+			return "", fmt.Errorf("JSON field %q not found", path)
+		}
+	}
+
+	switch value := current.(type) {
+	case string:
+		return value, nil
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64), nil
+	case bool:
+		return strconv.FormatBool(value), nil
+	case nil:
+		return "", fmt.Errorf("JSON field %q is null", path)
+	default:
+		// Objects and arrays are returned as compact JSON
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			return "", err
+		}
+		return string(encoded), nil
+	}
+}
@@ -0,0 +1,224 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nimbus/backend/internal/models"
+	"github.com/nimbus/backend/internal/repository"
+)
+
+// newLoginTestServer simulates an app with a CSRF-protected login form and a JSON API
+func newLoginTestServer() *httptest.Server {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/login", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			http.SetCookie(w, &http.Cookie{Name: "session", Value: "sess-123", Path: "/"})
+			fmt.Fprint(w, `<form><input type="hidden" name="csrf" value="tok-456"></form>`)
+			return
+		}
+
+		session, err := r.Cookie("session")
+		if err != nil || session.Value != "sess-123" {
+			http.Error(w, "no session", http.StatusForbidden)
+			return
+		}
+		r.ParseForm()
+		if r.FormValue("csrf") != "tok-456" || r.FormValue("password") != "secret" {
+			http.Error(w, "bad credentials", http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"data": {"token": "api-789", "roles": ["admin"]}}`)
+	})
+
+	mux.HandleFunc("/api/me", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer api-789" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		fmt.Fprint(w, `{"user": "admin"}`)
+	})
+
+	return httptest.NewServer(mux)
+}
+
+// loginTransaction returns a 3-step login transaction using the given password
+func loginTransaction(password string) *models.TransactionConfig {
+	return &models.TransactionConfig{
+		Steps: []models.TransactionStep{
+			{
+				Name: "login page",
+				URL:  "/login",
+				Extract: []models.TransactionExtract{
+					{Name: "csrf", Source: models.ExtractSourceRegex, Key: `name="csrf" value="([^"]+)"`},
+					{Name: "session", Source: models.ExtractSourceCookie, Key: "session"},
+				},
+			},
+			{
+				Name:    "submit login",
+				Method:  "post",
+				URL:     "/login",
+				Headers: map[string]string{"Content-Type": "application/x-www-form-urlencoded"},
+				Body:    "csrf={{csrf}}&password=" + password,
+				Extract: []models.TransactionExtract{
+					{Name: "token", Source: models.ExtractSourceJSON, Key: "data.token"},
+				},
+				Assert: &models.TransactionAssert{Status: []int{200}},
+			},
+			{
+				Name:    "api",
+				URL:     "/api/me",
+				Headers: map[string]string{"Authorization": "Bearer {{ token }}"},
+				Assert:  &models.TransactionAssert{BodyContains: `"admin"`},
+			},
+		},
+	}
+}
+
+func TestHealthCheckService_CheckService_Transaction(t *testing.T) {
+	testServer := newLoginTestServer()
+	defer testServer.Close()
+
+	tests := []struct {
+		name           string
+		password       string
+		expectedStatus string
+		expectedFailed *int
+		expectedSteps  int
+		errorContains  string
+	}{
+		{"Successful login flow", "secret", models.StatusOnline, nil, 3, ""},
+		{"Broken login", "wrong", models.StatusOffline, intPtr(2), 2, "Step 2 (submit login): expected status [200], got HTTP 401"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := setupFingerprintTestDB(t)
+			defer db.Close()
+
+			mockRepo := &MockServiceRepository{}
+			statusLogRepo := repository.NewStatusLogRepository(db)
+			healthService := &HealthCheckService{
+				serviceRepo:   mockRepo,
+				statusLogRepo: statusLogRepo,
+				httpClient: &http.Client{
+					Timeout: 5 * time.Second,
+					CheckRedirect: func(req *http.Request, via []*http.Request) error {
+						return http.ErrUseLastResponse
+					},
+				},
+			}
+
+			service := &models.Service{
+				ID:  "test-service-id",
+				URL: testServer.URL + "/",
+				CheckConfig: models.CheckConfig{
+					Type:        models.CheckTypeTransaction,
+					Transaction: loginTransaction(tt.password),
+				},
+			}
+
+			if err := healthService.CheckService(context.Background(), service); err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+
+			if mockRepo.lastStatus != tt.expectedStatus {
+				t.Errorf("Expected status %q, got %q", tt.expectedStatus, mockRepo.lastStatus)
+			}
+
+			logs, err := statusLogRepo.GetLatestByServiceID(context.Background(), service.ID, 1)
+			if err != nil || len(logs) != 1 {
+				t.Fatalf("Expected 1 status log, got %d (err: %v)", len(logs), err)
+			}
+			log := logs[0]
+
+			if (log.FailedStep == nil) != (tt.expectedFailed == nil) ||
+				(log.FailedStep != nil && *log.FailedStep != *tt.expectedFailed) {
+				t.Errorf("Expected failed step %v, got %v", tt.expectedFailed, log.FailedStep)
+			}
+
+			if len(log.StepTimings) != tt.expectedSteps {
+				t.Fatalf("Expected %d step timings, got %d", tt.expectedSteps, len(log.StepTimings))
+			}
+			for i, timing := range log.StepTimings {
+				if timing.Step != i+1 {
+					t.Errorf("Expected step number %d, got %d", i+1, timing.Step)
+				}
+			}
+
+			if tt.errorContains != "" {
+				if log.ErrorMessage == nil || !strings.Contains(*log.ErrorMessage, tt.errorContains) {
+					t.Errorf("Expected error containing %q, got %v", tt.errorContains, derefString(log.ErrorMessage))
+				}
+				last := log.StepTimings[len(log.StepTimings)-1]
+				if last.StatusCode != http.StatusUnauthorized || last.Error == "" {
+					t.Errorf("Expected failed step to record HTTP 401 and an error, got %+v", last)
+				}
+			}
+		})
+	}
+}
+
+func TestSubstituteVars(t *testing.T) {
+	vars := map[string]string{"token": "abc", "id": "42"}
+
+	result, err := substituteVars("/items/{{id}}?t={{ token }}", vars)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if result != "/items/42?t=abc" {
+		t.Errorf("Expected '/items/42?t=abc', got %q", result)
+	}
+
+	if _, err := substituteVars("Bearer {{missing}}", vars); err == nil {
+		t.Error("Expected error for undefined variable")
+	}
+}
+
+func TestLookupJSONPath(t *testing.T) {
+	var data interface{} = map[string]interface{}{
+		"data": map[string]interface{}{
+			"token": "abc",
+			"count": float64(3),
+			"ok":    true,
+			"items": []interface{}{map[string]interface{}{"id": "first"}},
+		},
+	}
+
+	tests := []struct {
+		path     string
+		expected string
+		wantErr  bool
+	}{
+		{"data.token", "abc", false},
+		{"data.count", "3", false},
+		{"data.ok", "true", false},
+		{"data.items.0.id", "first", false},
+		{"data.items.1.id", "", true},
+		{"data.missing", "", true},
+		{"data.token.nested", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			result, err := lookupJSONPath(data, tt.path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("lookupJSONPath(%q) error = %v, wantErr %v", tt.path, err, tt.wantErr)
+			}
+			if result != tt.expected {
+				t.Errorf("lookupJSONPath(%q) = %q, expected %q", tt.path, result, tt.expected)
+			}
+		})
+	}
+}
+
+func intPtr(i int) *int {
+	return &i
+}
@@ -0,0 +1,142 @@
+package services
+
+import (
+	"context"
+	"crypto/tls"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/nimbus/backend/internal/models"
+)
+
+// Idle connection limits for pooled health check transports
+const (
+	transportMaxIdleConns        = 100
+	transportMaxIdleConnsPerHost = 2
+	transportIdleConnTimeout     = 90 * time.Second
+)
+
+// transportKey identifies a pooled transport
+// Connections are only shared between requests that would have dialed them the same way:
+// same TLS verification and options, same resolver and same address family
+type transportKey struct {
+	skipVerify bool
+	tls        string // Canonical per-service TLS options ("" for defaults)
+	resolver   string
+	family     string
+	fresh      bool // Keep-alives disabled: every request opens a new connection
+}
+
+// transportPool keeps long-lived transports so health checks reuse connections and TLS sessions
+type transportPool struct {
+	base *http.Transport // Template for new transports (dialer, timeouts)
+
+	mu         sync.Mutex
+	transports map[transportKey]*http.Transport
+	lastFlush  time.Time
+}
+
+// newTransportPool creates a pool of transports cloned from base
+func newTransportPool(base *http.Transport) *transportPool {
+	base.MaxIdleConns = transportMaxIdleConns
+	base.MaxIdleConnsPerHost = transportMaxIdleConnsPerHost
+	base.IdleConnTimeout = transportIdleConnTimeout
+
+	return &transportPool{
+		base:       base,
+		transports: make(map[transportKey]*http.Transport),
+		lastFlush:  time.Now(),
+	}
+}
+
+// get returns the transport for key, creating it on first use
+// Idle connections are dropped once per DNS cache TTL so a changed DNS record is picked up
+// even when frequent checks keep a connection alive indefinitely
+func (p *transportPool) get(key transportKey, tlsConfig *models.TLSConfig) *http.Transport {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	dnsCacheMu.RLock()
+	maxAge := dnsCacheTTL
+	dnsCacheMu.RUnlock()
+	if maxAge > 0 && time.Since(p.lastFlush) > maxAge {
+		p.closeIdleLocked()
+	}
+
+	if transport, ok := p.transports[key]; ok {
+		return transport
+	}
+
+	transport := p.base.Clone()
+	transport.TLSClientConfig = buildTLSConfig(key.skipVerify, tlsConfig)
+	if key.fresh {
+		transport.DisableKeepAlives = true
+	}
+
+	p.transports[key] = transport
+	return transport
+}
+
+// CloseIdleConnections closes idle connections on every pooled transport
+// Called when the egress policy changes so existing connections are re-checked on their next dial
+func (p *transportPool) CloseIdleConnections() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.closeIdleLocked()
+}
+
+func (p *transportPool) closeIdleLocked() {
+	for _, transport := range p.transports {
+		transport.CloseIdleConnections()
+	}
+	p.lastFlush = time.Now()
+}
+
+// buildTLSConfig creates the client TLS config for a transport variant
+func buildTLSConfig(skipVerify bool, cfg *models.TLSConfig) *tls.Config {
+	tlsConfig := &tls.Config{
+		MinVersion:         tls.VersionTLS12, // Require TLS 1.2 or higher
+		InsecureSkipVerify: skipVerify,       // Only for local/private addresses (see isLocalURL)
+	}
+
+	if cfg != nil {
+		tlsConfig.ServerName = cfg.ServerName
+		if cfg.MinVersion == models.TLSVersion13 {
+			tlsConfig.MinVersion = tls.VersionTLS13
+		}
+	}
+
+	return tlsConfig
+}
+
+// tlsVariantKey returns the canonical pool key for per-service TLS options
+func tlsVariantKey(cfg *models.TLSConfig) string {
+	if cfg == nil || (cfg.ServerName == "" && cfg.MinVersion == "") {
+		return ""
+	}
+	return "sni=" + cfg.ServerName + ";min=" + cfg.MinVersion
+}
+
+// transportOptionsKey is the context key for a check's transport options
+type transportOptionsKey struct{}
+
+// transportOptions are the per-service settings that select a transport variant
+type transportOptions struct {
+	tls   *models.TLSConfig
+	fresh bool
+}
+
+// withTransportOptions selects the TLS variant and connection reuse for requests made with ctx
+func withTransportOptions(ctx context.Context, cfg models.CheckConfig) context.Context {
+	if cfg.TLS == nil && !cfg.FreshConnection {
+		return ctx
+	}
+	return context.WithValue(ctx, transportOptionsKey{}, transportOptions{tls: cfg.TLS, fresh: cfg.FreshConnection})
+}
+
+// transportOptionsFromContext returns the transport options for ctx (zero value if unset)
+func transportOptionsFromContext(ctx context.Context) transportOptions {
+	opts, _ := ctx.Value(transportOptionsKey{}).(transportOptions)
+	return opts
+}
@@ -0,0 +1,169 @@
+package services
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/nimbus/backend/internal/models"
+	"github.com/nimbus/backend/internal/repository"
+)
+
+// newConnCountingTLSServer starts a TLS test server that counts new connections
+func newConnCountingTLSServer(tb testing.TB) (*httptest.Server, *atomic.Int32) {
+	conns := &atomic.Int32{}
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	server.Config.ConnState = func(conn net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			conns.Add(1)
+		}
+	}
+	server.StartTLS()
+	tb.Cleanup(server.Close)
+	return server, conns
+}
+
+func TestHealthCheckService_ConnectionReuse(t *testing.T) {
+	tests := []struct {
+		name          string
+		fresh         bool
+		expectedConns int32
+	}{
+		{"Pooled connections are reused", false, 1},
+		{"Fresh connections per check", true, 5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, conns := newConnCountingTLSServer(t)
+			mockRepo := &MockServiceRepository{}
+			healthService := NewHealthCheckService(mockRepo, nil, nil, 5*time.Second, nil, nil)
+
+			service := &models.Service{
+				ID:          "test-service-id",
+				URL:         server.URL,
+				CheckConfig: models.CheckConfig{FreshConnection: tt.fresh},
+			}
+
+			for i := 0; i < 5; i++ {
+				if err := healthService.CheckService(context.Background(), service); err != nil {
+					t.Fatalf("Expected no error, got %v", err)
+				}
+				if mockRepo.lastStatus != models.StatusOnline {
+					t.Fatalf("Expected service to be online, got %q", mockRepo.lastStatus)
+				}
+			}
+
+			if got := conns.Load(); got != tt.expectedConns {
+				t.Errorf("Expected %d connections, got %d", tt.expectedConns, got)
+			}
+		})
+	}
+}
+
+func TestTransportPool_Variants(t *testing.T) {
+	pool := newTransportPool(&http.Transport{})
+
+	verify := pool.get(transportKey{resolver: "system"}, nil)
+	if verify != pool.get(transportKey{resolver: "system"}, nil) {
+		t.Error("Expected the same key to return the pooled transport")
+	}
+	if verify.TLSClientConfig.InsecureSkipVerify {
+		t.Error("Expected default transport to verify certificates")
+	}
+	if verify.MaxIdleConnsPerHost != transportMaxIdleConnsPerHost {
+		t.Errorf("Expected bounded idle pool, got MaxIdleConnsPerHost=%d", verify.MaxIdleConnsPerHost)
+	}
+
+	skip := pool.get(transportKey{skipVerify: true, resolver: "system"}, nil)
+	if skip == verify || !skip.TLSClientConfig.InsecureSkipVerify {
+		t.Error("Expected a separate skip-verify transport")
+	}
+
+	// Connections must never be shared across resolvers or address families
+	if pool.get(transportKey{resolver: "udp://192.168.1.1:53"}, nil) == verify {
+		t.Error("Expected a separate transport per resolver")
+	}
+	if pool.get(transportKey{resolver: "system", family: models.AddressFamilyIPv6}, nil) == verify {
+		t.Error("Expected a separate transport per address family")
+	}
+
+	tlsConfig := &models.TLSConfig{ServerName: "nas.internal", MinVersion: models.TLSVersion13}
+	variant := pool.get(transportKey{resolver: "system", tls: tlsVariantKey(tlsConfig)}, tlsConfig)
+	if variant.TLSClientConfig.ServerName != "nas.internal" || variant.TLSClientConfig.MinVersion != tls.VersionTLS13 {
+		t.Errorf("Expected per-service TLS options, got %+v", variant.TLSClientConfig)
+	}
+
+	fresh := pool.get(transportKey{resolver: "system", fresh: true}, nil)
+	if !fresh.DisableKeepAlives {
+		t.Error("Expected fresh transport to disable keep-alives")
+	}
+}
+
+func TestHealthCheckService_EgressUpdateDropsPooledConnections(t *testing.T) {
+	server, _ := newConnCountingTLSServer(t)
+
+	db := setupEgressTestDB(t)
+	defer db.Close()
+
+	egress := NewEgressPolicyService(repository.NewSettingsRepository(db), repository.NewActivityLogRepository(db))
+	mockRepo := &MockServiceRepository{}
+	healthService := NewHealthCheckService(mockRepo, nil, nil, 5*time.Second, nil, egress)
+	service := &models.Service{ID: "test-service-id", URL: server.URL}
+
+	if err := healthService.CheckService(context.Background(), service); err != nil || mockRepo.lastStatus != models.StatusOnline {
+		t.Fatalf("Expected first check to succeed, got %q (err: %v)", mockRepo.lastStatus, err)
+	}
+
+	// The idle connection was allowed under the old policy; it must not be reused under the new one
+	if err := egress.Update(context.Background(), models.EgressPolicy{DenyCIDRs: []string{"127.0.0.0/8"}}, nil); err != nil {
+		t.Fatalf("Failed to update policy: %v", err)
+	}
+
+	if err := healthService.CheckService(context.Background(), service); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if mockRepo.lastStatus != models.StatusOffline {
+		t.Errorf("Expected check to be blocked after the policy change, got %q", mockRepo.lastStatus)
+	}
+}
+
+// BenchmarkHealthCheck_ConnectionReuse compares pooled connections with a fresh TLS connection per check
+// Run with: go test -bench ConnectionReuse -benchmem ./internal/services/
+func BenchmarkHealthCheck_ConnectionReuse(b *testing.B) {
+	for _, bm := range []struct {
+		name  string
+		fresh bool
+	}{
+		{"pooled", false},
+		{"fresh", true},
+	} {
+		b.Run(bm.name, func(b *testing.B) {
+			server, conns := newConnCountingTLSServer(b)
+			healthService := NewHealthCheckService(&MockServiceRepository{}, nil, nil, 5*time.Second, nil, nil)
+			service := &models.Service{
+				ID:          "bench-service-id",
+				URL:         server.URL,
+				CheckConfig: models.CheckConfig{FreshConnection: bm.fresh},
+			}
+			ctx := withTransportOptions(context.Background(), service.CheckConfig)
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if log := healthService.performCheck(ctx, service, false); log.Status != models.StatusOnline {
+					b.Fatalf("Expected online, got %q (%s)", log.Status, derefString(log.ErrorMessage))
+				}
+			}
+			b.StopTimer()
+
+			b.ReportMetric(float64(conns.Load())/float64(b.N), "conns/op")
+		})
+	}
+}
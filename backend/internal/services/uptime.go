@@ -0,0 +1,135 @@
+package services
+
+import (
+	"sort"
+	"time"
+
+	"github.com/nimbus/backend/internal/models"
+	"github.com/nimbus/backend/internal/repository"
+)
+
+// DefaultUptimeMaxStaleness is how long a check result counts when no newer result follows
+const DefaultUptimeMaxStaleness = 3 * time.Minute
+
+// stateDurations is the time a service was known to be online and offline during a period
+// The rest of the period had no (fresh) results and counts toward neither
+type stateDurations struct {
+	online  time.Duration
+	offline time.Duration
+}
+
+func (d *stateDurations) add(other stateDurations) {
+	d.online += other.online
+	d.offline += other.offline
+}
+
+// uptime returns the time-weighted uptime percentage (0 without data)
+func (d stateDurations) uptime() float64 {
+	covered := d.online + d.offline
+	if covered <= 0 {
+		return 0
+	}
+	return float64(d.online) / float64(covered) * 100
+}
+
+// coverage returns the percentage of period that had results
+func (d stateDurations) coverage(period time.Duration) float64 {
+	if period <= 0 {
+		return 0
+	}
+	coverage := float64(d.online+d.offline) / float64(period) * 100
+	if coverage > 100 {
+		coverage = 100 // Rollup buckets may extend past the requested range
+	}
+	return coverage
+}
+
+// countUptime returns the count-based uptime percentage (0 without checks)
+func countUptime(onlineCount, checkCount int) float64 {
+	if checkCount == 0 {
+		return 0
+	}
+	return float64(onlineCount) / float64(checkCount) * 100
+}
+
+// mergeSimultaneousSamples combines results recorded at the same time (a dual-stack check logs one
+// per address family) into one, the same way updateStatus does: any failure wins
+func mergeSimultaneousSamples(samples []repository.StatusSample) []repository.StatusSample {
+	merged := make([]repository.StatusSample, 0, len(samples))
+	for _, sample := range samples {
+		last := len(merged) - 1
+		if last >= 0 && merged[last].CheckedAt.Equal(sample.CheckedAt) {
+			if sample.Status != models.StatusOnline && merged[last].Status != models.StatusOffline {
+				merged[last].Status = sample.Status
+			}
+			continue
+		}
+		merged = append(merged, sample)
+	}
+	return merged
+}
+
+// timeWeightedDurations measures how long a service was online and offline in each bucket
+// Each result holds until the next one, for at most maxStaleness; results before startTime carry
+// into the range. boundaries are the bucket starts (see metricBucketBoundaries) and samples must
+// be ordered by time. Buckets are clipped to [startTime, endTime]
+func timeWeightedDurations(samples []repository.StatusSample, startTime, endTime time.Time, boundaries []time.Time, maxStaleness time.Duration) []stateDurations {
+	durations := make([]stateDurations, len(boundaries))
+	if len(boundaries) == 0 {
+		return durations
+	}
+
+	samples = mergeSimultaneousSamples(samples)
+	for i, sample := range samples {
+		if sample.Status != models.StatusOnline && sample.Status != models.StatusOffline {
+			continue // Unknown results are gaps
+		}
+
+		from := sample.CheckedAt
+		to := from.Add(maxStaleness)
+		if i+1 < len(samples) && samples[i+1].CheckedAt.Before(to) {
+			to = samples[i+1].CheckedAt
+		}
+		if from.Before(startTime) {
+			from = startTime
+		}
+		if to.After(endTime) {
+			to = endTime
+		}
+
+		// Split the segment over the buckets it overlaps
+		index := sort.Search(len(boundaries), func(i int) bool { return boundaries[i].After(from) }) - 1
+		if index < 0 {
+			index = 0
+		}
+		for from.Before(to) && index < len(boundaries) {
+			segmentEnd := to
+			if index+1 < len(boundaries) && boundaries[index+1].Before(segmentEnd) {
+				segmentEnd = boundaries[index+1]
+			}
+
+			if sample.Status == models.StatusOnline {
+				durations[index].online += segmentEnd.Sub(from)
+			} else {
+				durations[index].offline += segmentEnd.Sub(from)
+			}
+
+			from = segmentEnd
+			index++
+		}
+	}
+
+	return durations
+}
+
+// bucketPeriod returns the length of bucket i clipped to [startTime, endTime]
+func bucketPeriod(boundaries []time.Time, i int, startTime, endTime time.Time) time.Duration {
+	from, to := boundaries[i], endTime
+	if i+1 < len(boundaries) && boundaries[i+1].Before(to) {
+		to = boundaries[i+1]
+	}
+	if from.Before(startTime) {
+		from = startTime
+	}
+	return to.Sub(from)
+}
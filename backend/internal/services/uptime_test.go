@@ -0,0 +1,103 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/nimbus/backend/internal/models"
+	"github.com/nimbus/backend/internal/repository"
+)
+
+func TestTimeWeightedDurations(t *testing.T) {
+	start := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	end := start.Add(2 * time.Hour)
+	boundaries := []time.Time{start, start.Add(time.Hour)}
+	ipv4, ipv6 := "ipv4", "ipv6"
+
+	sample := func(offset time.Duration, status string) repository.StatusSample {
+		return repository.StatusSample{CheckedAt: start.Add(offset), Status: status}
+	}
+
+	tests := []struct {
+		name            string
+		samples         []repository.StatusSample
+		expectedOnline  []time.Duration
+		expectedOffline []time.Duration
+	}{
+		{
+			name:            "Results hold until the next one",
+			samples:         []repository.StatusSample{sample(0, models.StatusOnline), sample(time.Minute, models.StatusOffline), sample(2*time.Minute, models.StatusOnline)},
+			expectedOnline:  []time.Duration{4 * time.Minute, 0},
+			expectedOffline: []time.Duration{time.Minute, 0},
+		},
+		{
+			name:            "A restart gap counts as no data",
+			samples:         []repository.StatusSample{sample(0, models.StatusOnline), sample(30*time.Minute, models.StatusOnline)},
+			expectedOnline:  []time.Duration{6 * time.Minute, 0},
+			expectedOffline: []time.Duration{0, 0},
+		},
+		{
+			name:            "Unknown results are gaps",
+			samples:         []repository.StatusSample{sample(0, models.StatusUnknown), sample(time.Minute, models.StatusOffline)},
+			expectedOnline:  []time.Duration{0, 0},
+			expectedOffline: []time.Duration{3 * time.Minute, 0},
+		},
+		{
+			name:            "A result before the range carries into it",
+			samples:         []repository.StatusSample{sample(-time.Minute, models.StatusOffline)},
+			expectedOnline:  []time.Duration{0, 0},
+			expectedOffline: []time.Duration{2 * time.Minute, 0},
+		},
+		{
+			name:            "Segments are split across buckets",
+			samples:         []repository.StatusSample{sample(59*time.Minute, models.StatusOnline)},
+			expectedOnline:  []time.Duration{time.Minute, 2 * time.Minute},
+			expectedOffline: []time.Duration{0, 0},
+		},
+		{
+			name:            "The range end cuts off the last result",
+			samples:         []repository.StatusSample{sample(2*time.Hour-time.Minute, models.StatusOnline)},
+			expectedOnline:  []time.Duration{0, time.Minute},
+			expectedOffline: []time.Duration{0, 0},
+		},
+		{
+			name: "A failing address family marks a dual-stack check offline",
+			samples: []repository.StatusSample{
+				{CheckedAt: start, Status: models.StatusOnline, AddressFamily: &ipv4},
+				{CheckedAt: start, Status: models.StatusOffline, AddressFamily: &ipv6},
+			},
+			expectedOnline:  []time.Duration{0, 0},
+			expectedOffline: []time.Duration{3 * time.Minute, 0},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			durations := timeWeightedDurations(tt.samples, start, end, boundaries, 3*time.Minute)
+			if len(durations) != len(boundaries) {
+				t.Fatalf("Expected %d buckets, got %d", len(boundaries), len(durations))
+			}
+			for i, d := range durations {
+				if d.online != tt.expectedOnline[i] || d.offline != tt.expectedOffline[i] {
+					t.Errorf("Bucket %d: expected %v online/%v offline, got %v/%v", i, tt.expectedOnline[i], tt.expectedOffline[i], d.online, d.offline)
+				}
+			}
+		})
+	}
+}
+
+func TestStateDurations(t *testing.T) {
+	d := stateDurations{online: 45 * time.Minute, offline: 15 * time.Minute}
+	if got := d.uptime(); got != 75 {
+		t.Errorf("Expected 75%% uptime, got %v", got)
+	}
+	if got := d.coverage(2 * time.Hour); got != 50 {
+		t.Errorf("Expected 50%% coverage, got %v", got)
+	}
+	if got := d.coverage(30 * time.Minute); got != 100 {
+		t.Errorf("Expected coverage to be capped at 100%%, got %v", got)
+	}
+	if got := (stateDurations{}).uptime(); got != 0 {
+		t.Errorf("Expected 0%% uptime without data, got %v", got)
+	}
+}
@@ -0,0 +1,80 @@
+package workers
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/nimbus/backend/internal/services"
+)
+
+// DigestReportWorker sends the users' digest reports when their schedules come up
+type DigestReportWorker struct {
+	digestService *services.DigestService
+	interval      time.Duration
+	stopChan      chan struct{}
+	stopOnce      sync.Once
+	wg            sync.WaitGroup
+}
+
+// NewDigestReportWorker creates a new digest report worker
+func NewDigestReportWorker(digestService *services.DigestService) *DigestReportWorker {
+	return &DigestReportWorker{
+		digestService: digestService,
+		// Schedules have minute precision
+		interval: time.Minute,
+		stopChan: make(chan struct{}),
+	}
+}
+
+// Start begins checking for due digests
+func (w *DigestReportWorker) Start() {
+	log.Printf("Starting digest report worker (interval: %s)", w.interval)
+
+	w.wg.Add(1)
+	go w.run()
+}
+
+// Stop gracefully stops the worker, waiting for a run in progress (safe to call multiple times)
+func (w *DigestReportWorker) Stop() {
+	w.stopOnce.Do(func() {
+		log.Println("Stopping digest report worker...")
+		close(w.stopChan)
+		w.wg.Wait()
+	})
+}
+
+// run is the main worker loop
+func (w *DigestReportWorker) run() {
+	defer w.wg.Done()
+
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			w.runSend()
+		case <-w.stopChan:
+			log.Println("Digest report worker stopped")
+			return
+		}
+	}
+}
+
+// runSend sends the digests that are due
+func (w *DigestReportWorker) runSend() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	sent, err := w.digestService.SendDue(ctx)
+	if err != nil {
+		log.Printf("Error sending digest reports: %v", err)
+		return
+	}
+
+	if sent > 0 {
+		log.Printf("Digest reports sent: %d", sent)
+	}
+}
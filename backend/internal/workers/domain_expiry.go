@@ -0,0 +1,95 @@
+package workers
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/nimbus/backend/internal/services"
+)
+
+// DomainExpiryWorker periodically refreshes cached domain registration data, and as soon as a
+// request finds a domain without fresh data (requests only read the cache)
+type DomainExpiryWorker struct {
+	domainService   *services.DomainMonitorService
+	refreshInterval time.Duration
+	stopChan        chan struct{}
+	refreshTimer    *time.Timer
+	refreshMu       sync.Mutex // Held while refreshing, so refreshes never overlap
+	stopOnce        sync.Once
+}
+
+// NewDomainExpiryWorker creates a new domain expiry worker
+func NewDomainExpiryWorker(domainService *services.DomainMonitorService) *DomainExpiryWorker {
+	return &DomainExpiryWorker{
+		domainService: domainService,
+		// RDAP data is cached for a day, so refresh once per day
+		refreshInterval: 24 * time.Hour,
+		stopChan:        make(chan struct{}),
+	}
+}
+
+// Start begins the periodic refresh process
+func (w *DomainExpiryWorker) Start() {
+	log.Printf("Starting domain expiry worker (interval: %s, warning: %d days, critical: %d days)",
+		w.refreshInterval, w.domainService.WarningDays(), w.domainService.CriticalDays())
+
+	// Run first refresh shortly after startup
+	w.refreshTimer = time.AfterFunc(1*time.Minute, func() {
+		w.runRefresh()
+	})
+
+	go w.run()
+}
+
+// Stop gracefully stops the worker (safe to call multiple times)
+func (w *DomainExpiryWorker) Stop() {
+	w.stopOnce.Do(func() {
+		log.Println("Stopping domain expiry worker...")
+
+		if w.refreshTimer != nil {
+			w.refreshTimer.Stop()
+		}
+
+		close(w.stopChan)
+	})
+}
+
+// run is the main worker loop
+func (w *DomainExpiryWorker) run() {
+	ticker := time.NewTicker(w.refreshInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			w.runRefresh()
+		case <-w.domainService.RefreshRequested():
+			w.runRefresh()
+		case <-w.stopChan:
+			log.Println("Domain expiry worker stopped")
+			return
+		}
+	}
+}
+
+// runRefresh refreshes stale registration data for all domains
+func (w *DomainExpiryWorker) runRefresh() {
+	// The startup refresh may still be running; the domains it misses are requested again
+	if !w.refreshMu.TryLock() {
+		return
+	}
+	defer w.refreshMu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	defer cancel()
+
+	refreshed, err := w.domainService.RefreshAll(ctx)
+	if err != nil {
+		log.Printf("Error during domain expiry refresh: %v", err)
+		return
+	}
+
+	log.Printf("Domain expiry refresh completed: %d domains looked up", refreshed)
+}
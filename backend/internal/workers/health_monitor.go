@@ -84,6 +84,9 @@ func (h *HealthMonitor) checkAllServices() {
 	ctx, cancel := context.WithTimeout(context.Background(), h.interval)
 	defer cancel()
 
+	// Results are written in batches by the status writer (if one is configured)
+	ctx = services.WithBufferedStatusWrites(ctx)
+
 	services, err := h.getAllServices(ctx)
 	if err != nil {
 		fmt.Printf("Failed to fetch services for health check: %v\n", err)
@@ -32,6 +32,7 @@ func setupCleanupTestDB(t *testing.T) *sql.DB {
 			status TEXT DEFAULT 'unknown',
 			response_time INTEGER,
 			position INTEGER,
+			check_config TEXT NOT NULL DEFAULT '{}',
 			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
 			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
 		)
@@ -48,6 +49,11 @@ func setupCleanupTestDB(t *testing.T) *sql.DB {
 			status TEXT NOT NULL CHECK(status IN ('online', 'offline', 'unknown')),
 			response_time INTEGER,
 			error_message TEXT,
+			event TEXT,
+			event_detail TEXT,
+			failed_step INTEGER,
+			step_timings TEXT,
+			address_family TEXT,
 			checked_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
 			FOREIGN KEY(service_id) REFERENCES services(id) ON DELETE CASCADE
 		)
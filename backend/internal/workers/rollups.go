@@ -0,0 +1,78 @@
+package workers
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/nimbus/backend/internal/services"
+)
+
+// RollupWorker keeps the hourly and daily status rollups up to date
+type RollupWorker struct {
+	rollupService *services.RollupService
+	interval      time.Duration
+	stopChan      chan struct{}
+	stopOnce      sync.Once
+	wg            sync.WaitGroup
+}
+
+// NewRollupWorker creates a new rollup worker
+func NewRollupWorker(rollupService *services.RollupService) *RollupWorker {
+	return &RollupWorker{
+		rollupService: rollupService,
+		// Hourly buckets become available once the hour is over
+		interval: 15 * time.Minute,
+		stopChan: make(chan struct{}),
+	}
+}
+
+// Start begins the periodic rollup process
+func (w *RollupWorker) Start() {
+	log.Printf("Starting rollup worker (interval: %s, hourly retention: %d months)",
+		w.interval, w.rollupService.HourlyRetentionMonths())
+
+	w.wg.Add(1)
+	go w.run()
+}
+
+// Stop gracefully stops the worker (safe to call multiple times)
+func (w *RollupWorker) Stop() {
+	w.stopOnce.Do(func() {
+		log.Println("Stopping rollup worker...")
+		close(w.stopChan)
+		w.wg.Wait()
+	})
+}
+
+// run is the main worker loop
+func (w *RollupWorker) run() {
+	defer w.wg.Done()
+
+	// Catch up immediately (also backfills rollups from existing raw logs on the first start)
+	w.RunNow()
+
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			w.RunNow()
+		case <-w.stopChan:
+			log.Println("Rollup worker stopped")
+			return
+		}
+	}
+}
+
+// RunNow rolls up all completed buckets and applies hourly retention
+func (w *RollupWorker) RunNow() {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	defer cancel()
+
+	if err := w.rollupService.Run(ctx, time.Now()); err != nil {
+		log.Printf("Error during status rollup: %v", err)
+	}
+}
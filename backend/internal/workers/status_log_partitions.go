@@ -0,0 +1,93 @@
+package workers
+
+import (
+	"context"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nimbus/backend/internal/services"
+)
+
+// StatusLogPartitionWorker creates status log partitions ahead of time
+// so inserts never land in the default partition
+type StatusLogPartitionWorker struct {
+	partitionService *services.StatusLogPartitionService
+	interval         time.Duration
+	stopChan         chan struct{}
+	stopOnce         sync.Once
+	wg               sync.WaitGroup
+}
+
+// NewStatusLogPartitionWorker creates a new partition maintenance worker
+func NewStatusLogPartitionWorker(partitionService *services.StatusLogPartitionService) *StatusLogPartitionWorker {
+	return &StatusLogPartitionWorker{
+		partitionService: partitionService,
+		// Several runs per day, so a failed run is retried long before the partitions run out
+		interval: 6 * time.Hour,
+		stopChan: make(chan struct{}),
+	}
+}
+
+// Start begins the periodic maintenance process
+func (w *StatusLogPartitionWorker) Start() {
+	log.Printf("Starting status log partition worker (partition: %s, ahead: %d, interval: %s)",
+		w.partitionService.Interval(), w.partitionService.Ahead(), w.interval)
+
+	w.wg.Add(1)
+	go w.run()
+}
+
+// Stop gracefully stops the worker (safe to call multiple times)
+func (w *StatusLogPartitionWorker) Stop() {
+	w.stopOnce.Do(func() {
+		log.Println("Stopping status log partition worker...")
+		close(w.stopChan)
+		w.wg.Wait()
+	})
+}
+
+// run is the main worker loop
+func (w *StatusLogPartitionWorker) run() {
+	defer w.wg.Done()
+
+	// Run immediately: the server may have been down past the last pre-created partition
+	w.RunNow()
+
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			w.RunNow()
+		case <-w.stopChan:
+			log.Println("Status log partition worker stopped")
+			return
+		}
+	}
+}
+
+// RunNow creates any missing future partitions
+func (w *StatusLogPartitionWorker) RunNow() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	partitioned, err := w.partitionService.Enabled(ctx)
+	if err != nil {
+		log.Printf("Error checking status log partitioning: %v", err)
+		return
+	}
+	if !partitioned {
+		return
+	}
+
+	created, err := w.partitionService.EnsurePartitions(ctx, time.Now())
+	if len(created) > 0 {
+		log.Printf("Created status log partitions: %s", strings.Join(created, ", "))
+	}
+	if err != nil {
+		log.Printf("Error creating status log partitions: %v", err)
+	}
+}
@@ -1,6 +1,6 @@
 'use client'
 
-import { useState } from 'react'
+import { useEffect, useState } from 'react'
 import Link from 'next/link'
 import { getApiUrl } from '@/lib/utils/api-url'
 
@@ -11,6 +11,17 @@ export default function RegisterPage() {
   const [confirmPassword, setConfirmPassword] = useState('')
   const [isLoading, setIsLoading] = useState(false)
   const [error, setError] = useState('')
+  const [inviteToken, setInviteToken] = useState('')
+
+  // Invitation links carry the token and the invited address
+  useEffect(() => {
+    const params = new URLSearchParams(window.location.search)
+    const invite = params.get('invite')
+    if (invite) {
+      setInviteToken(invite)
+      setEmail(params.get('email') || '')
+    }
+  }, [])
 
   const handleSubmit = async (e: React.FormEvent) => {
     e.preventDefault()
@@ -38,7 +49,7 @@ export default function RegisterPage() {
           'Content-Type': 'application/json',
         },
         credentials: 'include', // Required to receive and send httpOnly cookies
-        body: JSON.stringify({ name, email, password }),
+        body: JSON.stringify({ name, email, password, invite_token: inviteToken || undefined }),
       })
 
       const data = await response.json()